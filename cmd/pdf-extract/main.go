@@ -0,0 +1,21 @@
+// Command pdf-extract extracts structured data from PDF documents from the command line.
+//
+// Usage:
+//
+//	pdf-extract batch [flags] pattern...
+//	pdf-extract watch [flags] directory
+//	pdf-extract repl [flags] document.pdf
+//	pdf-extract compat [flags] old.json new.json
+//
+// The OpenAI API key is read from the OPENAI_API_KEY environment variable.
+package main
+
+import (
+	"os"
+
+	"github.com/ilopezluna/go-pdf-extractor/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/journal"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// progressWidth is the number of characters of the progress bar
+const progressWidth = 30
+
+// runBatch extracts the documents matching glob patterns with a schema and writes their records as
+// JSON Lines. It returns exitPartial when some documents failed, and exitError when all did.
+func runBatch(args []string, stdout, stderr io.Writer) (int, error) {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "Usage: pdf-extract batch [flags] pattern...\n\nExtract the PDF files matching glob patterns, or in directories, to JSON Lines.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	var config extractorFlags
+	config.register(fs)
+	schemaPath := fs.String("schema", "", "JSON schema file of the data to extract (required)")
+	concurrency := fs.Int("concurrency", 4, "number of documents extracted in parallel")
+	out := fs.String("out", "-", `JSON Lines output file, or "-" for the standard output`)
+	resume := fs.Bool("resume", false, "skip the documents already extracted to the output file, appending the others")
+	quiet := fs.Bool("quiet", false, "show no progress bar")
+	plan := fs.Bool("plan", false, "show the pages, routing, tokens and cost of each document, and exit without extracting")
+	patterns, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case len(patterns) == 0:
+		return 0, fmt.Errorf("%w: no pattern of PDF files", errUsage)
+	case *schemaPath == "":
+		return 0, fmt.Errorf("%w: -schema is required", errUsage)
+	case *concurrency <= 0:
+		return 0, fmt.Errorf("%w: -concurrency must be positive", errUsage)
+	case *resume && *out == "-":
+		return 0, fmt.Errorf("%w: -resume requires an -out file", errUsage)
+	}
+	paths, err := matchPDFs(patterns)
+	if err != nil {
+		return 0, err
+	}
+	schema, err := readSchema(*schemaPath)
+	if err != nil {
+		return 0, err
+	}
+	ext, err := config.newExtractor()
+	if err != nil {
+		return 0, err
+	}
+	defer ext.Shutdown(context.Background())
+
+	options := types.BatchOptions{Concurrency: *concurrency}
+	if *resume {
+		// The output file is the journal of the batch, which writes the records itself
+		file, err := journal.NewFile(*out)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		options.Journal = file
+	}
+
+	documents := make([]types.BatchDocument, len(paths))
+	for i, path := range paths {
+		documents[i] = types.BatchDocument{ID: path, Options: types.ExtractionOptions{PDFPath: path, Schema: schema}}
+	}
+
+	// An interrupted batch stops extracting; with -resume, the next run picks it up
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *plan {
+		batchPlan, err := ext.PlanBatch(ctx, documents, options)
+		if err != nil {
+			return 0, err
+		}
+		printPlan(stdout, batchPlan)
+		return exitOK, nil
+	}
+
+	var writer *types.JSONLWriter
+	switch {
+	case *resume:
+	case *out == "-":
+		writer = types.NewJSONLWriter(stdout)
+	default:
+		file, err := os.Create(*out)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		writer = types.NewJSONLWriter(file)
+	}
+
+	progress := &batchProgress{total: len(documents), start: time.Now()}
+	if !*quiet && isTerminal(stderr) {
+		progress.bar = stderr
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := ext.ExtractBatch(ctx, documents, options)
+	for result := range results {
+		if writer != nil {
+			if err := writer.Write(result); err != nil {
+				// The workers block until their results are received: stop the batch and drain them
+				cancel()
+				for range results {
+				}
+				return 0, err
+			}
+		}
+		progress.add(result, stderr)
+	}
+	progress.summary(stderr)
+
+	switch {
+	case progress.failed == 0:
+		return exitOK, nil
+	case progress.failed == progress.total:
+		return exitError, nil
+	default:
+		return exitPartial, nil
+	}
+}
+
+// matchPDFs returns the files matching glob patterns, sorted and without duplicates. Directories
+// stand for the PDF files they hold. A pattern matching no file is an error.
+func matchPDFs(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	paths := make([]string, 0)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid pattern %q: %v", errUsage, pattern, err)
+		}
+		found := false
+		for _, match := range matches {
+			files := []string{match}
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				files, _ = filepath.Glob(filepath.Join(match, "*.[pP][dD][fF]"))
+			}
+			for _, file := range files {
+				found = true
+				if !seen[file] {
+					seen[file] = true
+					paths = append(paths, file)
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: no PDF file matches %q", errUsage, pattern)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// batchProgress counts the documents of a batch as their results come, drawing a progress bar on
+// a terminal
+type batchProgress struct {
+	total, done, failed, resumed int
+	tokens                       int
+	start                        time.Time
+	// bar is the terminal the progress bar is drawn on, or nil
+	bar   io.Writer
+	drawn bool
+}
+
+// add counts a result, reports it when it failed and redraws the progress bar
+func (p *batchProgress) add(result types.BatchResult, stderr io.Writer) {
+	p.done++
+	switch {
+	case result.Status == types.BatchStatusError:
+		p.failed++
+		p.clear()
+		fmt.Fprintf(stderr, "failed: %s: %v\n", result.ID, result.Err)
+	case result.Resumed:
+		p.resumed++
+	case result.Result != nil:
+		p.tokens += result.Result.TokensUsed
+	}
+	if p.bar == nil {
+		return
+	}
+	p.drawn = true
+	filled := progressWidth * p.done / p.total
+	fmt.Fprintf(p.bar, "\r[%s%s] %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled), p.done, p.total)
+	if p.failed > 0 {
+		fmt.Fprintf(p.bar, ", %d failed", p.failed)
+	}
+}
+
+// clear erases the progress bar, if any
+func (p *batchProgress) clear() {
+	if p.drawn {
+		fmt.Fprintf(p.bar, "\r%s\r", strings.Repeat(" ", progressWidth+40))
+		p.drawn = false
+	}
+}
+
+// summary writes the counts of the batch. The tokens of resumed documents were used by an earlier
+// run and are not counted.
+func (p *batchProgress) summary(stderr io.Writer) {
+	p.clear()
+	succeeded := p.done - p.failed
+	fmt.Fprintf(stderr, "%d documents: %d succeeded", p.total, succeeded)
+	if p.resumed > 0 {
+		fmt.Fprintf(stderr, " (%d resumed)", p.resumed)
+	}
+	fmt.Fprintf(stderr, ", %d failed, %d tokens used in %s\n", p.failed, p.tokens, time.Since(p.start).Round(time.Millisecond))
+}
+
+// printPlan writes a batch plan as a table of the documents to extract, followed by the documents
+// skipped or failing and the totals
+func printPlan(w io.Writer, plan *types.BatchPlan) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "DOCUMENT\tPAGES\tROUTE\tMODEL\tPROMPT\tCOMPLETION\tCOST")
+	toExtract, skipped := 0, 0
+	for _, document := range plan.Documents {
+		if document.Skipped || document.Err != nil {
+			continue
+		}
+		toExtract++
+		route := document.Route
+		if document.Route == "mixed" {
+			route = fmt.Sprintf("mixed (%d text, %d images)", document.TextPages, document.ImagePages)
+		}
+		cost := "unknown"
+		if document.Priced {
+			cost = fmt.Sprintf("~$%.6f", document.Cost)
+		}
+		fmt.Fprintf(table, "%s\t%d\t%s\t%s\t~%d\t~%d\t%s\n", document.ID, document.Pages, route, document.Model, document.PromptTokens, document.CompletionTokens, cost)
+	}
+	table.Flush()
+
+	for _, document := range plan.Documents {
+		switch {
+		case document.Skipped:
+			skipped++
+		case document.Err != nil:
+			fmt.Fprintf(w, "would fail: %s: %v\n", document.ID, document.Err)
+		}
+	}
+	fmt.Fprintf(w, "\nPlan: %d documents to extract, %d skipped, %d failing; %d pages, ~%d prompt tokens and ~%d completion tokens (at most %d)\n",
+		toExtract, skipped, len(plan.Documents)-toExtract-skipped, plan.Pages, plan.PromptTokens, plan.CompletionTokens, plan.MaxCompletionTokens)
+	fmt.Fprintf(w, "Estimated cost: ~$%.4f (at most $%.4f)", plan.Cost, plan.MaxCost)
+	if len(plan.UnpricedModels) > 0 {
+		fmt.Fprintf(w, ", without the documents sent to %s, whose price is unknown", strings.Join(plan.UnpricedModels, ", "))
+	}
+	fmt.Fprintln(w)
+}
+
+// isTerminal reports whether a writer is a terminal
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,179 @@
+// Package cli implements the commands of pdf-extract, run by Run with the arguments and standard
+// streams of the process, so that they can be tested
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// Exit codes of the commands
+const (
+	exitOK = 0
+	// exitError is returned when the command could not run, or every document failed
+	exitError = 1
+	// exitUsage is returned for invalid arguments
+	exitUsage = 2
+	// exitPartial is returned when some documents failed and others succeeded
+	exitPartial = 3
+	// exitBreaking is returned by compat when the new schema has breaking changes
+	exitBreaking = 4
+)
+
+const usage = `Usage: pdf-extract <command> [flags] [arguments]
+
+Commands:
+  batch    Extract the documents matching glob patterns to JSON Lines
+  watch    Extract the PDF files arriving in a directory
+  repl     Parse a document once and iterate on the schema, prompt and model of its extraction
+  compat   Report the changes between two versions of a schema, failing on breaking ones
+
+Run "pdf-extract <command> -h" for the flags of a command.
+`
+
+var (
+	// errUsage marks the errors of invalid arguments
+	errUsage = errors.New("invalid arguments")
+	// errFlags is returned for invalid flags, already reported by the flag set
+	errFlags = errors.New("invalid flags")
+)
+
+// Run runs the command of the arguments and returns the exit code
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var code int
+	var err error
+	switch args[0] {
+	case "batch":
+		code, err = runBatch(args[1:], stdout, stderr)
+	case "watch":
+		code, err = runWatch(args[1:], stderr)
+	case "repl":
+		code, err = runRepl(args[1:], stdin, stdout, stderr)
+	case "compat":
+		code, err = runCompat(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "pdf-extract: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errFlags):
+		return exitUsage
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "pdf-extract %s: %v\n", args[0], err)
+		return exitUsage
+	case err != nil:
+		fmt.Fprintf(stderr, "pdf-extract %s: %v\n", args[0], err)
+		return exitError
+	}
+	return code
+}
+
+// extractorFlags are the flags configuring the extractor, shared by the commands
+type extractorFlags struct {
+	model   string
+	baseURL string
+	vision  bool
+}
+
+// register adds the flags to a flag set
+func (f *extractorFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.model, "model", "", "model used for extraction (default: gpt-4o-mini)")
+	fs.StringVar(&f.baseURL, "base-url", "", "base URL of an OpenAI-compatible endpoint")
+	fs.BoolVar(&f.vision, "vision", false, "send the page images of scanned documents to the model")
+}
+
+// config returns the extractor config of the flags and the environment
+func (f *extractorFlags) config() (types.ExtractorConfig, error) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return types.ExtractorConfig{}, errors.New("the OPENAI_API_KEY environment variable is required")
+	}
+	return types.ExtractorConfig{
+		OpenAIAPIKey:  apiKey,
+		BaseURL:       f.baseURL,
+		Model:         f.model,
+		VisionEnabled: f.vision,
+	}, nil
+}
+
+// newExtractor creates an extractor configured by the flags and the environment
+func (f *extractorFlags) newExtractor() (*extractor.Extractor, error) {
+	config, err := f.config()
+	if err != nil {
+		return nil, err
+	}
+	return extractor.New(config)
+}
+
+// parseArgs parses flags placed before, between or after the positional arguments, which it
+// returns
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		} else if err != nil {
+			return nil, errFlags
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// readSchema reads a JSON schema file, inlining the schema files its $ref references point to,
+// relative to its directory
+func readSchema(path string) (map[string]interface{}, error) {
+	schemaData, err := readJSONFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	inlined, err := schema.InlineRefs(schemaData, func(uri string) (map[string]interface{}, error) {
+		ref, err := url.Parse(uri)
+		if err != nil || ref.Scheme != "" || ref.Host != "" {
+			return nil, errors.New("only references to local files are supported")
+		}
+		return readJSONFile(filepath.Join(dir, filepath.FromSlash(ref.Path)))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return inlined, nil
+}
+
+// readJSONFile reads a file holding a JSON object
+func readJSONFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return object, nil
+}
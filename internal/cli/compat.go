@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+)
+
+// runCompat reports the changes from an old version of a schema to a new one, failing with
+// exitBreaking when one of them is breaking
+func runCompat(args []string, stdout, stderr io.Writer) (int, error) {
+	fs := flag.NewFlagSet("compat", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "Usage: pdf-extract compat [flags] old.json new.json\n\nReport the changes from an old version of a schema to a new one, failing when one of them is breaking.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	breakingOnly := fs.Bool("breaking", false, "report the breaking changes only")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 2 {
+		return 0, fmt.Errorf("%w: expected the old and the new schema", errUsage)
+	}
+
+	oldSchema, err := readSchema(positional[0])
+	if err != nil {
+		return 0, err
+	}
+	newSchema, err := readSchema(positional[1])
+	if err != nil {
+		return 0, err
+	}
+	changes, err := schema.CheckCompatibility(oldSchema, newSchema)
+	if err != nil {
+		return 0, err
+	}
+	breaking := schema.BreakingChanges(changes)
+	if *breakingOnly {
+		changes = breaking
+	}
+	for _, change := range changes {
+		fmt.Fprintln(stdout, change)
+	}
+	if len(breaking) > 0 {
+		fmt.Fprintf(stderr, "%d breaking changes\n", len(breaking))
+		return exitBreaking, nil
+	}
+	return exitOK, nil
+}
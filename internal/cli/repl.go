@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const replHelp = `Commands:
+  schema <file>       Set the JSON schema file, read again on every run
+  prompt [text]       Set the instructions added to the prompt, or clear them
+  system [text]       Set the system prompt, or restore the default
+  model [name]        Set the model, or restore the default
+  temperature [t]     Set the temperature, or restore the default
+  run                 Extract the document with the current settings
+  history             List the attempts with their token usage
+  show                Show the document and the current settings
+  help                Show this help
+  quit                Leave
+`
+
+// replSession is the state of an interactive session: the document parsed once and the settings
+// of the next extraction
+type replSession struct {
+	config      types.ExtractorConfig
+	ext         *extractor.Extractor
+	path        string
+	parsed      *types.ParsedPdf
+	schemaPath  string
+	options     types.ExtractionOptions
+	attempts    []replAttempt
+	totalTokens int
+}
+
+// replAttempt is an extraction run in a session
+type replAttempt struct {
+	model        string
+	instructions string
+	tokens       int
+	err          error
+}
+
+// runRepl parses a document once, then reads commands changing the schema, prompt and model of its
+// extraction and running it against the parsed document
+func runRepl(args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "Usage: pdf-extract repl [flags] document.pdf\n\nParse a document once, then iterate on the schema, prompt and model of its extraction.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	var flags extractorFlags
+	flags.register(fs)
+	schemaPath := fs.String("schema", "", "JSON schema file of the data to extract")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 1 {
+		return 0, fmt.Errorf("%w: expected a single document", errUsage)
+	}
+
+	config, err := flags.config()
+	if err != nil {
+		return 0, err
+	}
+	session := &replSession{config: config, path: positional[0], schemaPath: *schemaPath}
+	if session.ext, err = extractor.New(config); err != nil {
+		return 0, err
+	}
+	defer func() { session.ext.Shutdown(context.Background()) }()
+	if session.parsed, err = session.ext.Parse(types.ExtractionOptions{PDFPath: session.path}); err != nil {
+		return 0, err
+	}
+	session.show(stdout)
+	fmt.Fprintln(stdout, `Type "help" for the commands.`)
+	return exitOK, session.serve(stdin, stdout)
+}
+
+// serve reads commands from stdin until quit or the end of the input, writing their output and
+// errors to stdout
+func (s *replSession) serve(stdin io.Reader, stdout io.Writer) error {
+	scanner := bufio.NewScanner(stdin)
+	for {
+		fmt.Fprint(stdout, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(stdout)
+			return scanner.Err()
+		}
+		command, argument := parseCommand(scanner.Text())
+		if command == "quit" || command == "exit" {
+			return nil
+		}
+		if err := s.execute(command, argument, stdout); err != nil {
+			fmt.Fprintf(stdout, "error: %v\n", err)
+		}
+	}
+}
+
+// parseCommand splits a line into its command, the first word, and the argument, the rest of the
+// line without surrounding spaces
+func parseCommand(line string) (command, argument string) {
+	command, argument, _ = strings.Cut(strings.TrimSpace(line), " ")
+	return command, strings.TrimSpace(argument)
+}
+
+// execute runs a command of the session
+func (s *replSession) execute(command, argument string, stdout io.Writer) error {
+	switch command {
+	case "":
+	case "schema":
+		if argument == "" {
+			return errors.New("schema takes a file")
+		}
+		if _, err := readSchema(argument); err != nil {
+			return err
+		}
+		s.schemaPath = argument
+	case "prompt":
+		s.options.Instructions = argument
+	case "system":
+		return s.reconfigure(func(config *types.ExtractorConfig) { config.SystemPrompt = argument })
+	case "model":
+		return s.reconfigure(func(config *types.ExtractorConfig) { config.Model = argument })
+	case "temperature":
+		if argument == "" {
+			s.options.Temperature = nil
+			return nil
+		}
+		temperature, err := strconv.ParseFloat(argument, 64)
+		if err != nil || temperature < 0 || temperature > 2 {
+			return fmt.Errorf("invalid temperature %q, expected a number from 0 to 2", argument)
+		}
+		s.options.Temperature = &temperature
+	case "run":
+		return s.run(stdout)
+	case "history":
+		s.history(stdout)
+	case "show":
+		s.show(stdout)
+	case "help":
+		fmt.Fprint(stdout, replHelp)
+	default:
+		return fmt.Errorf("unknown command %q, type \"help\" for the commands", command)
+	}
+	return nil
+}
+
+// reconfigure replaces the extractor of the session by one with a changed config. The parsed
+// document is kept.
+func (s *replSession) reconfigure(change func(config *types.ExtractorConfig)) error {
+	config := s.config
+	change(&config)
+	ext, err := extractor.New(config)
+	if err != nil {
+		return err
+	}
+	s.ext.Shutdown(context.Background())
+	s.config, s.ext = config, ext
+	return nil
+}
+
+// run extracts the parsed document with the current settings, reading the schema file again so
+// that it can be edited between runs
+func (s *replSession) run(stdout io.Writer) error {
+	if s.schemaPath == "" {
+		return errors.New(`no schema, set one with "schema <file>"`)
+	}
+	schema, err := readSchema(s.schemaPath)
+	if err != nil {
+		return err
+	}
+	options := s.options
+	options.ParsedPdf = s.parsed
+	options.Schema = schema
+
+	attempt := replAttempt{model: s.config.Model, instructions: options.Instructions}
+	result, err := s.ext.Extract(options)
+	if err != nil {
+		attempt.err = err
+		s.attempts = append(s.attempts, attempt)
+		return err
+	}
+	attempt.model, attempt.tokens = result.Model, result.TokensUsed
+	s.attempts = append(s.attempts, attempt)
+	s.totalTokens += result.TokensUsed
+
+	data, err := json.MarshalIndent(result.Data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format the data: %w", err)
+	}
+	fmt.Fprintln(stdout, string(data))
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(stdout, "warning: %s\n", warning)
+	}
+	fmt.Fprintf(stdout, "Attempt %d: %s, %d tokens (%d in the session)\n", len(s.attempts), result.Model, result.TokensUsed, s.totalTokens)
+	return nil
+}
+
+// history lists the attempts of the session
+func (s *replSession) history(stdout io.Writer) {
+	if len(s.attempts) == 0 {
+		fmt.Fprintln(stdout, "No attempt yet")
+		return
+	}
+	for i, attempt := range s.attempts {
+		model := attempt.model
+		if model == "" {
+			model = "default model"
+		}
+		fmt.Fprintf(stdout, "%d. %s, %d tokens", i+1, model, attempt.tokens)
+		if attempt.instructions != "" {
+			fmt.Fprintf(stdout, ", prompt %q", attempt.instructions)
+		}
+		if attempt.err != nil {
+			fmt.Fprintf(stdout, ", failed: %v", attempt.err)
+		}
+		fmt.Fprintln(stdout)
+	}
+	fmt.Fprintf(stdout, "%d tokens in the session\n", s.totalTokens)
+}
+
+// show describes the parsed document and the current settings
+func (s *replSession) show(stdout io.Writer) {
+	fmt.Fprintf(stdout, "%s: %d pages, read as %s\n", s.path, s.parsed.NumPages, s.parsed.Content.Type)
+	settings := []struct{ name, value, unset string }{
+		{"schema", s.schemaPath, "(none)"},
+		{"model", s.config.Model, "(default)"},
+		{"prompt", s.options.Instructions, "(none)"},
+		{"system", s.config.SystemPrompt, "(default)"},
+	}
+	for _, setting := range settings {
+		if setting.value == "" {
+			setting.value = setting.unset
+		}
+		fmt.Fprintf(stdout, "  %-11s %s\n", setting.name, setting.value)
+	}
+	if s.options.Temperature != nil {
+		fmt.Fprintf(stdout, "  %-11s %g\n", "temperature", *s.options.Temperature)
+	}
+}
@@ -0,0 +1,322 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// Folders of the output directory of the watch command
+const (
+	doneFolder   = "done"
+	failedFolder = "failed"
+)
+
+// fileState is the size and modification time of a file seen by a poll of the watched directory
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// runWatch monitors a directory and extracts the PDF files arriving in it, moving them with their
+// records to the done or failed folder of the output directory
+func runWatch(args []string, stderr io.Writer) (int, error) {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "Usage: pdf-extract watch [flags] directory\n\nExtract the PDF files arriving in a directory, moving them to the done or failed folder of the output directory.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	var flags extractorFlags
+	flags.register(fs)
+	schemaPath := fs.String("schema", "", "JSON schema file of the data to extract (required)")
+	out := fs.String("out", "", "output directory of the processed files (required)")
+	interval := fs.Duration("interval", 2*time.Second, "time between two scans of the directory while its files change, or always without file notifications")
+	concurrency := fs.Int("concurrency", 4, "number of documents extracted in parallel")
+	once := fs.Bool("once", false, "process the files in the directory and exit")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case len(positional) != 1:
+		return 0, fmt.Errorf("%w: expected a single directory", errUsage)
+	case *schemaPath == "":
+		return 0, fmt.Errorf("%w: -schema is required", errUsage)
+	case *out == "":
+		return 0, fmt.Errorf("%w: -out is required", errUsage)
+	case *interval <= 0:
+		return 0, fmt.Errorf("%w: -interval must be positive", errUsage)
+	case *concurrency <= 0:
+		return 0, fmt.Errorf("%w: -concurrency must be positive", errUsage)
+	}
+	inbox := positional[0]
+	if info, err := os.Stat(inbox); err != nil || !info.IsDir() {
+		return 0, fmt.Errorf("%w: %s is no directory", errUsage, inbox)
+	}
+	schema, err := readSchema(*schemaPath)
+	if err != nil {
+		return 0, err
+	}
+	for _, folder := range []string{doneFolder, failedFolder} {
+		if err := os.MkdirAll(filepath.Join(*out, folder), 0o755); err != nil {
+			return 0, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	ext, err := flags.newExtractor()
+	if err != nil {
+		return 0, err
+	}
+	defer ext.Shutdown(context.Background())
+
+	// Files in progress when the command is interrupted are left in the directory for the next run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := &watcher{ext: ext, schema: schema, inbox: inbox, out: *out, concurrency: *concurrency, stderr: stderr,
+		seen: make(map[string]fileState), stuck: make(map[string]fileState)}
+	if *once {
+		paths, err := pdfFiles(inbox)
+		if err != nil {
+			return 0, err
+		}
+		processed, failed := w.process(ctx, paths)
+		switch {
+		case failed == 0:
+			return exitOK, nil
+		case failed == processed:
+			return exitError, nil
+		default:
+			return exitPartial, nil
+		}
+	}
+
+	fmt.Fprintf(stderr, "Watching %s, press Ctrl-C to stop\n", inbox)
+	w.watch(ctx, *interval)
+	return exitOK, nil
+}
+
+// watcher extracts the PDF files arriving in a directory
+type watcher struct {
+	ext         *extractor.Extractor
+	schema      map[string]interface{}
+	inbox, out  string
+	concurrency int
+	stderr      io.Writer
+	// seen are the files of the last poll that were still changing
+	seen map[string]fileState
+	// stuck are the files processed but left in the directory as they could not be moved, which are
+	// not extracted again until they change
+	stuck map[string]fileState
+}
+
+// watch extracts the files arriving in the directory until ctx is canceled. The directory is scanned
+// when it notifies a change, then every interval until its files stop changing. Without
+// notifications, it is scanned every interval.
+func (w *watcher) watch(ctx context.Context, interval time.Duration) {
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	notifier, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = notifier.Add(w.inbox); err != nil {
+			notifier.Close()
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(w.stderr, "warning: no notifications of %s, scanning it every %s: %v\n", w.inbox, interval, err)
+	} else {
+		defer notifier.Close()
+		events, errs = notifier.Events, notifier.Errors
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) || event.Has(fsnotify.Rename) || event.Has(fsnotify.Chmod) {
+				timer.Reset(interval)
+			}
+			continue
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			fmt.Fprintf(w.stderr, "error: %v\n", err)
+			continue
+		case <-timer.C:
+		}
+
+		paths, err := w.poll()
+		if err != nil {
+			fmt.Fprintf(w.stderr, "error: %v\n", err)
+		}
+		w.process(ctx, paths)
+		// Files still changing are scanned again, and all of them without notifications
+		if len(w.seen) > 0 || events == nil {
+			timer.Reset(interval)
+		}
+	}
+}
+
+// poll returns the PDF files of the directory that have not changed since the last poll, so that
+// files still being copied are left for later
+func (w *watcher) poll() ([]string, error) {
+	paths, err := pdfFiles(w.inbox)
+	if err != nil {
+		return nil, err
+	}
+	stable := make([]string, 0)
+	seen := make(map[string]fileState)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		state := fileState{size: info.Size(), modTime: info.ModTime()}
+		if w.stuck[path] == state {
+			continue
+		}
+		if previous, ok := w.seen[path]; ok && previous == state {
+			stable = append(stable, path)
+			continue
+		}
+		seen[path] = state
+	}
+	w.seen = seen
+	return stable, nil
+}
+
+// process extracts files and moves them to the done or failed folder, with a JSON file holding
+// their record. It returns the numbers of files processed and failed.
+func (w *watcher) process(ctx context.Context, paths []string) (processed, failed int) {
+	if len(paths) == 0 {
+		return 0, 0
+	}
+	documents := make([]types.BatchDocument, len(paths))
+	for i, path := range paths {
+		documents[i] = types.BatchDocument{ID: path, Options: types.ExtractionOptions{PDFPath: path, Schema: w.schema}}
+	}
+
+	for result := range w.ext.ExtractBatch(ctx, documents, types.BatchOptions{Concurrency: w.concurrency}) {
+		if result.Err != nil && ctx.Err() != nil {
+			continue
+		}
+		processed++
+		folder := doneFolder
+		if result.Status == types.BatchStatusError {
+			folder = failedFolder
+			failed++
+		}
+		if err := w.move(result, folder); err != nil {
+			if folder == doneFolder {
+				failed++
+			}
+			fmt.Fprintf(w.stderr, "error: %v\n", err)
+			if info, err := os.Stat(result.ID); err == nil {
+				w.stuck[result.ID] = fileState{size: info.Size(), modTime: info.ModTime()}
+			}
+			continue
+		}
+		if result.Err != nil {
+			fmt.Fprintf(w.stderr, "failed: %s: %v\n", filepath.Base(result.ID), result.Err)
+		} else {
+			fmt.Fprintf(w.stderr, "done: %s (%d tokens)\n", filepath.Base(result.ID), result.Result.TokensUsed)
+		}
+	}
+	return processed, failed
+}
+
+// move moves the file of a result to a folder of the output directory, next to a JSON file holding
+// its record. A number is added to the name of a file already in the folder.
+func (w *watcher) move(result types.BatchResult, folder string) error {
+	record, err := json.MarshalIndent(types.NewJSONLRecord(result), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the record of %s: %w", result.ID, err)
+	}
+	name := strings.TrimSuffix(filepath.Base(result.ID), filepath.Ext(result.ID))
+	base := filepath.Join(w.out, folder, name)
+	for i := 1; fileExists(base+".pdf") || fileExists(base+".json"); i++ {
+		base = filepath.Join(w.out, folder, fmt.Sprintf("%s-%d", name, i))
+	}
+	if err := os.WriteFile(base+".json", append(record, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write the record of %s: %w", result.ID, err)
+	}
+	if err := moveFile(result.ID, base+".pdf"); err != nil {
+		return fmt.Errorf("failed to move %s: %w", result.ID, err)
+	}
+	return nil
+}
+
+// pdfFiles returns the PDF files of a directory, sorted, leaving out hidden files
+func pdfFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	paths := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && !strings.HasPrefix(name, ".") && strings.EqualFold(filepath.Ext(name), ".pdf") {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// moveFile renames a file, or copies it and removes the original when the target is on another
+// file system
+func moveFile(source, target string) error {
+	if err := os.Rename(source, target); !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(target)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(target)
+		return err
+	}
+	return os.Remove(source)
+}
+
+// fileExists reports whether a file exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,83 @@
+// Package artifacts provides stores for the artifacts of parsing, such as rendered page images:
+// a directory, e.g. a temporary directory shared by workers, and an S3 bucket. Set one as
+// ExtractorConfig.ArtifactStore.
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dir is an artifact store keeping every artifact in a file of a directory, under the path of its key
+type Dir struct {
+	path string
+}
+
+// NewDir creates an artifact store in a directory, creating the directory if needed. An empty path
+// stores the artifacts in "pdf-artifacts" under the temporary directory of the system.
+func NewDir(path string) (*Dir, error) {
+	if path == "" {
+		path = filepath.Join(os.TempDir(), "pdf-artifacts")
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	return &Dir{path: path}, nil
+}
+
+// Get reads the artifact of a key
+func (d *Dir) Get(_ context.Context, key string) ([]byte, bool, error) {
+	path, err := d.file(key)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read artifact %s: %w", key, err)
+	}
+	return data, true, nil
+}
+
+// Put writes the artifact of a key to a temporary file renamed into place, so that readers never
+// see a partial artifact
+func (d *Dir) Put(_ context.Context, key string, data []byte) error {
+	path, err := d.file(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".artifact-*")
+	if err != nil {
+		return fmt.Errorf("failed to write artifact %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write artifact %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write artifact %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write artifact %s: %w", key, err)
+	}
+	return nil
+}
+
+// file returns the path of the file of a key, which must stay in the directory
+func (d *Dir) file(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid artifact key %q", key)
+	}
+	return filepath.Join(d.path, clean), nil
+}
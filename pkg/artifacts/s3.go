@@ -0,0 +1,128 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/auth"
+)
+
+// S3Config configures an S3 artifact store
+type S3Config struct {
+	// Bucket is the name of the bucket (required)
+	Bucket string
+	// Region is the AWS region of the bucket, e.g. "eu-west-1" (required)
+	Region string
+	// Endpoint is the base URL of the service, for S3-compatible stores such as MinIO; the bucket is
+	// then addressed by path (default: "https://<Bucket>.s3.<Region>.amazonaws.com")
+	Endpoint string
+	// Prefix is prepended to the keys of the artifacts, e.g. "extractor/" (optional)
+	Prefix string
+	// AccessKeyID and SecretAccessKey are the AWS credentials of the requests (required)
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials (optional)
+	SessionToken string
+	// Client is the HTTP client used for requests (default: http.DefaultClient)
+	Client *http.Client
+}
+
+// S3 is an artifact store keeping every artifact in an object of an S3 bucket. Expire the
+// artifacts with a lifecycle rule of the bucket.
+type S3 struct {
+	config  S3Config
+	client  *http.Client
+	signer  *auth.SigV4
+	baseURL string
+}
+
+// NewS3 creates an artifact store in an S3 bucket
+func NewS3(config S3Config) (*S3, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("S3 bucket is required")
+	}
+	signer, err := auth.NewSigV4(auth.SigV4Config{
+		Region:          config.Region,
+		Service:         "s3",
+		AccessKeyID:     config.AccessKeyID,
+		SecretAccessKey: config.SecretAccessKey,
+		SessionToken:    config.SessionToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", config.Bucket, config.Region)
+	if config.Endpoint != "" {
+		baseURL = strings.TrimRight(config.Endpoint, "/") + "/" + url.PathEscape(config.Bucket)
+	}
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3{config: config, client: client, signer: signer, baseURL: baseURL}, nil
+}
+
+// Endpoints returns the URL of the bucket
+func (s *S3) Endpoints() []string {
+	return []string{s.baseURL}
+}
+
+// Get downloads the object of a key
+func (s *S3) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	status, body, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	switch {
+	case status == http.StatusNotFound:
+		return nil, false, nil
+	case status != http.StatusOK:
+		return nil, false, fmt.Errorf("failed to get artifact %s (status %d): %s", key, status, body)
+	}
+	return body, true, nil
+}
+
+// Put uploads the object of a key
+func (s *S3) Put(ctx context.Context, key string, data []byte) error {
+	status, body, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("failed to put artifact %s (status %d): %s", key, status, body)
+	}
+	return nil
+}
+
+// do sends a signed request for the object of a key and returns the status and body of the response
+func (s *S3) do(ctx context.Context, method, key string, data []byte) (int, []byte, error) {
+	segments := strings.Split(s.config.Prefix+key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+"/"+strings.Join(segments, "/"), bytes.NewReader(data))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	if err := s.signer.SignRequest(req, data); err != nil {
+		return 0, nil, fmt.Errorf("failed to sign S3 request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to call S3: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read S3 response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
@@ -0,0 +1,35 @@
+// Package auth provides request signers for OpenAI-compatible gateways that do not take a Bearer
+// API key: static custom headers, HMAC signatures, OAuth2 client credentials and AWS Signature
+// Version 4. Set one as ExtractorConfig.Signer.
+package auth
+
+import (
+	"net/http"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// Headers is a signer setting static headers on every request, e.g. the "api-key" header of Azure
+// OpenAI or the subscription key of an API gateway
+type Headers map[string]string
+
+// SignRequest sets the headers
+func (h Headers) SignRequest(req *http.Request, _ []byte) error {
+	for name, value := range h {
+		req.Header.Set(name, value)
+	}
+	return nil
+}
+
+// Chain is a signer applying several signers in order, e.g. Headers along with an OAuth2 token
+type Chain []types.RequestSigner
+
+// SignRequest applies every signer, stopping at the first error
+func (c Chain) SignRequest(req *http.Request, body []byte) error {
+	for _, signer := range c {
+		if err := signer.SignRequest(req, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin is how long before its expiry a token is refreshed, so that it does not
+// expire while a request is in flight
+const tokenExpiryMargin = 30 * time.Second
+
+// OAuth2Config configures an OAuth2 client credentials signer
+type OAuth2Config struct {
+	// TokenURL is the token endpoint of the authorization server
+	TokenURL string
+	// ClientID and ClientSecret are the credentials of the client
+	ClientID     string
+	ClientSecret string
+	// Scopes are the scopes requested (optional)
+	Scopes []string
+	// Params are more form parameters of the token request, e.g. the "audience" of Auth0 (optional)
+	Params map[string]string
+	// CredentialsInBody sends the client credentials as form parameters instead of HTTP Basic
+	// authentication, for servers that do not support the latter
+	CredentialsInBody bool
+	// HTTPClient requests the tokens (default: http.DefaultClient)
+	HTTPClient *http.Client
+}
+
+// OAuth2 is a signer setting a Bearer token obtained with the OAuth2 client credentials grant.
+// The token is requested on first use and again shortly before it expires, or after the gateway
+// rejected it.
+type OAuth2 struct {
+	config OAuth2Config
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewOAuth2 creates an OAuth2 client credentials signer
+func NewOAuth2(config OAuth2Config) (*OAuth2, error) {
+	if config.TokenURL == "" {
+		return nil, errors.New("token URL is required")
+	}
+	if config.ClientID == "" {
+		return nil, errors.New("client ID is required")
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &OAuth2{config: config}, nil
+}
+
+// Endpoints returns the URL of the token endpoint
+func (o *OAuth2) Endpoints() []string {
+	return []string{o.config.TokenURL}
+}
+
+// SignRequest sets the Bearer token of a request, requesting a new one if needed
+func (o *OAuth2) SignRequest(req *http.Request, _ []byte) error {
+	token, err := o.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token returns the current access token, requesting a new one if there is none or it is about
+// to expire
+func (o *OAuth2) Token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.token != "" && (o.expires.IsZero() || time.Now().Before(o.expires)) {
+		return o.token, nil
+	}
+
+	token, expiresIn, err := o.requestToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	o.token, o.expires = token, time.Time{}
+	if expiresIn > 0 {
+		o.expires = time.Now().Add(max(expiresIn-tokenExpiryMargin, expiresIn/2))
+	}
+	return o.token, nil
+}
+
+// Expire drops the current token, so that the next request gets a new one. The extractor calls it
+// when the gateway rejects a token as unauthorized.
+func (o *OAuth2) Expire() {
+	o.mu.Lock()
+	o.token = ""
+	o.mu.Unlock()
+}
+
+// requestToken requests an access token from the token endpoint and returns it along with its
+// lifetime, zero when the server does not tell it
+func (o *OAuth2) requestToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.config.Scopes, " "))
+	}
+	for name, value := range o.config.Params {
+		form.Set(name, value)
+	}
+	if o.config.CredentialsInBody {
+		form.Set("client_id", o.config.ClientID)
+		form.Set("client_secret", o.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !o.config.CredentialsInBody {
+		req.SetBasicAuth(url.QueryEscape(o.config.ClientID), url.QueryEscape(o.config.ClientSecret))
+	}
+
+	resp, err := o.config.HTTPClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token endpoint error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", 0, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, errors.New("token response has no access token")
+	}
+	return token.AccessToken, time.Duration(token.ExpiresIn) * time.Second, nil
+}
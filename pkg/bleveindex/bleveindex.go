@@ -0,0 +1,86 @@
+//go:build bleve
+
+// Package bleveindex is a reference types.Indexer backed by a Bleve full-text index. It is only
+// built with the bleve build tag, so that the Bleve dependency stays optional.
+package bleveindex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// Indexer indexes extracted documents in a Bleve index. Every document is indexed with its text
+// in the "text" field, its extracted data under "data" (nested fields are searchable by their
+// dotted path, e.g. "data.vendor.name"), and its "numPages" and "model".
+type Indexer struct {
+	index bleve.Index
+}
+
+// Hit is a document matching a search, by decreasing score
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// Open opens the Bleve index at path, creating it with the default mapping when it does not exist
+func Open(path string) (*Indexer, error) {
+	index, err := bleve.Open(path)
+	if err == bleve.ErrorIndexPathDoesNotExist {
+		index, err = bleve.New(path, bleve.NewIndexMapping())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Bleve index: %w", err)
+	}
+	return &Indexer{index: index}, nil
+}
+
+// New creates an indexer writing to an existing Bleve index, for custom mappings or in-memory
+// indexes
+func New(index bleve.Index) *Indexer {
+	return &Indexer{index: index}
+}
+
+// Index adds a document to the index, replacing any document with the same ID
+func (i *Indexer) Index(ctx context.Context, document types.IndexDocument) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fields := map[string]interface{}{
+		"text":     document.Text,
+		"data":     document.Data,
+		"numPages": document.NumPages,
+		"model":    document.Model,
+	}
+	if err := i.index.Index(document.ID, fields); err != nil {
+		return fmt.Errorf("failed to index document in Bleve: %w", err)
+	}
+	return nil
+}
+
+// Search runs a query string query (e.g. `text:overdue data.total:>1000`) and returns up to size
+// matching documents
+func (i *Indexer) Search(ctx context.Context, query string, size int) ([]Hit, error) {
+	request := bleve.NewSearchRequestOptions(bleve.NewQueryStringQuery(query), size, 0, false)
+	result, err := i.index.SearchInContext(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search Bleve index: %w", err)
+	}
+	hits := make([]Hit, len(result.Hits))
+	for j, match := range result.Hits {
+		hits[j] = Hit{ID: match.ID, Score: match.Score}
+	}
+	return hits, nil
+}
+
+// Bleve returns the underlying Bleve index
+func (i *Indexer) Bleve() bleve.Index {
+	return i.index
+}
+
+// Close closes the index
+func (i *Indexer) Close() error {
+	return i.index.Close()
+}
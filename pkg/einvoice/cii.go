@@ -0,0 +1,256 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	ciiRsmNamespace = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	ciiRamNamespace = "urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100"
+	ciiUdtNamespace = "urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100"
+	// ciiDateFormat is the UNTDID 2379 code for CCYYMMDD dates
+	ciiDateFormat = "102"
+)
+
+type ciiID struct {
+	Value string `xml:"ram:ID"`
+}
+
+type ciiDateTime struct {
+	DateTimeString struct {
+		Format string `xml:"format,attr"`
+		Value  string `xml:",chardata"`
+	} `xml:"udt:DateTimeString"`
+}
+
+type ciiAmount struct {
+	CurrencyID string `xml:"currencyID,attr,omitempty"`
+	Value      string `xml:",chardata"`
+}
+
+type ciiQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ciiTax struct {
+	CalculatedAmount string `xml:"ram:CalculatedAmount,omitempty"`
+	TypeCode         string `xml:"ram:TypeCode"`
+	BasisAmount      string `xml:"ram:BasisAmount,omitempty"`
+	CategoryCode     string `xml:"ram:CategoryCode"`
+	Rate             string `xml:"ram:RateApplicablePercent,omitempty"`
+}
+
+type ciiAddress struct {
+	PostcodeCode string `xml:"ram:PostcodeCode,omitempty"`
+	LineOne      string `xml:"ram:LineOne,omitempty"`
+	CityName     string `xml:"ram:CityName,omitempty"`
+	CountryID    string `xml:"ram:CountryID"`
+}
+
+type ciiTaxRegistration struct {
+	ID struct {
+		SchemeID string `xml:"schemeID,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"ram:ID"`
+}
+
+type ciiLegalOrganization struct {
+	ID string `xml:"ram:ID"`
+}
+
+type ciiParty struct {
+	Name              string                `xml:"ram:Name"`
+	LegalOrganization *ciiLegalOrganization `xml:"ram:SpecifiedLegalOrganization,omitempty"`
+	Address           ciiAddress            `xml:"ram:PostalTradeAddress"`
+	TaxRegistration   *ciiTaxRegistration   `xml:"ram:SpecifiedTaxRegistration,omitempty"`
+}
+
+type ciiLineItem struct {
+	LineDocument struct {
+		LineID string `xml:"ram:LineID"`
+	} `xml:"ram:AssociatedDocumentLineDocument"`
+	Product struct {
+		Name string `xml:"ram:Name"`
+	} `xml:"ram:SpecifiedTradeProduct"`
+	Agreement struct {
+		NetPrice struct {
+			ChargeAmount string `xml:"ram:ChargeAmount"`
+		} `xml:"ram:NetPriceProductTradePrice"`
+	} `xml:"ram:SpecifiedLineTradeAgreement"`
+	Delivery struct {
+		BilledQuantity ciiQuantity `xml:"ram:BilledQuantity"`
+	} `xml:"ram:SpecifiedLineTradeDelivery"`
+	Settlement struct {
+		Tax       ciiTax `xml:"ram:ApplicableTradeTax"`
+		Summation struct {
+			LineTotalAmount string `xml:"ram:LineTotalAmount"`
+		} `xml:"ram:SpecifiedTradeSettlementLineMonetarySummation"`
+	} `xml:"ram:SpecifiedLineTradeSettlement"`
+}
+
+type ciiPaymentMeans struct {
+	TypeCode string `xml:"ram:TypeCode"`
+	Account  *struct {
+		IBANID string `xml:"ram:IBANID"`
+	} `xml:"ram:PayeePartyCreditorFinancialAccount,omitempty"`
+}
+
+type ciiPaymentTerms struct {
+	Description string       `xml:"ram:Description,omitempty"`
+	DueDate     *ciiDateTime `xml:"ram:DueDateDateTime,omitempty"`
+}
+
+type ciiSummation struct {
+	LineTotalAmount     string    `xml:"ram:LineTotalAmount"`
+	TaxBasisTotalAmount string    `xml:"ram:TaxBasisTotalAmount"`
+	TaxTotalAmount      ciiAmount `xml:"ram:TaxTotalAmount"`
+	GrandTotalAmount    string    `xml:"ram:GrandTotalAmount"`
+	TotalPrepaidAmount  string    `xml:"ram:TotalPrepaidAmount,omitempty"`
+	DuePayableAmount    string    `xml:"ram:DuePayableAmount"`
+}
+
+type ciiInvoice struct {
+	XMLName  xml.Name `xml:"rsm:CrossIndustryInvoice"`
+	XmlnsRsm string   `xml:"xmlns:rsm,attr"`
+	XmlnsRam string   `xml:"xmlns:ram,attr"`
+	XmlnsUdt string   `xml:"xmlns:udt,attr"`
+	Context  struct {
+		Guideline ciiID `xml:"ram:GuidelineSpecifiedDocumentContextParameter"`
+	} `xml:"rsm:ExchangedDocumentContext"`
+	Document struct {
+		ID        string      `xml:"ram:ID"`
+		TypeCode  string      `xml:"ram:TypeCode"`
+		IssueDate ciiDateTime `xml:"ram:IssueDateTime"`
+	} `xml:"rsm:ExchangedDocument"`
+	Transaction struct {
+		LineItems []ciiLineItem `xml:"ram:IncludedSupplyChainTradeLineItem"`
+		Agreement struct {
+			BuyerReference string   `xml:"ram:BuyerReference,omitempty"`
+			Seller         ciiParty `xml:"ram:SellerTradeParty"`
+			Buyer          ciiParty `xml:"ram:BuyerTradeParty"`
+			OrderReference *struct {
+				IssuerAssignedID string `xml:"ram:IssuerAssignedID"`
+			} `xml:"ram:BuyerOrderReferencedDocument,omitempty"`
+		} `xml:"ram:ApplicableHeaderTradeAgreement"`
+		Delivery   struct{} `xml:"ram:ApplicableHeaderTradeDelivery"`
+		Settlement struct {
+			PaymentReference string           `xml:"ram:PaymentReference,omitempty"`
+			CurrencyCode     string           `xml:"ram:InvoiceCurrencyCode"`
+			PaymentMeans     *ciiPaymentMeans `xml:"ram:SpecifiedTradeSettlementPaymentMeans,omitempty"`
+			Taxes            []ciiTax         `xml:"ram:ApplicableTradeTax"`
+			PaymentTerms     *ciiPaymentTerms `xml:"ram:SpecifiedTradePaymentTerms,omitempty"`
+			Summation        ciiSummation     `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+		} `xml:"ram:ApplicableHeaderTradeSettlement"`
+	} `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+// ToCII serializes an invoice to UN/CEFACT Cross Industry Invoice (D16B) XML following the
+// EN 16931 core specification, the syntax used by ZUGFeRD, Factur-X and XRechnung
+func ToCII(invoice *types.Invoice) ([]byte, error) {
+	if err := validate(invoice); err != nil {
+		return nil, err
+	}
+
+	doc := ciiInvoice{
+		XmlnsRsm: ciiRsmNamespace,
+		XmlnsRam: ciiRamNamespace,
+		XmlnsUdt: ciiUdtNamespace,
+	}
+	doc.Context.Guideline.Value = EN16931CustomizationID
+	doc.Document.ID = invoice.Number
+	doc.Document.TypeCode = typeCode(invoice)
+	doc.Document.IssueDate = ciiDate(invoice.IssueDate)
+
+	for i, line := range invoice.Lines {
+		var item ciiLineItem
+		item.LineDocument.LineID = lineID(line, i)
+		item.Product.Name = line.Name
+		item.Agreement.NetPrice.ChargeAmount = formatAmount(line.NetPrice)
+		item.Delivery.BilledQuantity = ciiQuantity{UnitCode: unitCode(line), Value: formatDecimal(line.Quantity)}
+		item.Settlement.Tax = ciiTax{TypeCode: "VAT", CategoryCode: vatCategory(line.VATCategory), Rate: formatDecimal(line.VATRate)}
+		item.Settlement.Summation.LineTotalAmount = formatAmount(line.NetAmount)
+		doc.Transaction.LineItems = append(doc.Transaction.LineItems, item)
+	}
+
+	agreement := &doc.Transaction.Agreement
+	agreement.BuyerReference = invoice.BuyerReference
+	agreement.Seller = toCIIParty(invoice.Seller)
+	agreement.Buyer = toCIIParty(invoice.Buyer)
+	if invoice.PurchaseOrderReference != "" {
+		agreement.OrderReference = &struct {
+			IssuerAssignedID string `xml:"ram:IssuerAssignedID"`
+		}{IssuerAssignedID: invoice.PurchaseOrderReference}
+	}
+
+	settlement := &doc.Transaction.Settlement
+	settlement.PaymentReference = invoice.PaymentReference
+	settlement.CurrencyCode = invoice.Currency
+	if invoice.PaymentIBAN != "" {
+		settlement.PaymentMeans = &ciiPaymentMeans{TypeCode: creditTransferMeansCode}
+		settlement.PaymentMeans.Account = &struct {
+			IBANID string `xml:"ram:IBANID"`
+		}{IBANID: invoice.PaymentIBAN}
+	}
+	for _, vat := range invoice.VATBreakdown {
+		settlement.Taxes = append(settlement.Taxes, ciiTax{
+			CalculatedAmount: formatAmount(vat.TaxAmount),
+			TypeCode:         "VAT",
+			BasisAmount:      formatAmount(vat.TaxableAmount),
+			CategoryCode:     vatCategory(vat.Category),
+			Rate:             formatDecimal(vat.Rate),
+		})
+	}
+	if invoice.PaymentTerms != "" || invoice.DueDate != "" {
+		settlement.PaymentTerms = &ciiPaymentTerms{Description: invoice.PaymentTerms}
+		if invoice.DueDate != "" {
+			dueDate := ciiDate(invoice.DueDate)
+			settlement.PaymentTerms.DueDate = &dueDate
+		}
+	}
+	settlement.Summation = ciiSummation{
+		LineTotalAmount:     formatAmount(invoice.LineTotal),
+		TaxBasisTotalAmount: formatAmount(invoice.TaxExclusiveTotal),
+		TaxTotalAmount:      ciiAmount{CurrencyID: invoice.Currency, Value: formatAmount(invoice.TaxTotal)},
+		GrandTotalAmount:    formatAmount(invoice.TaxInclusiveTotal),
+		DuePayableAmount:    formatAmount(invoice.PayableAmount),
+	}
+	if invoice.PaidAmount != 0 {
+		settlement.Summation.TotalPrepaidAmount = formatAmount(invoice.PaidAmount)
+	}
+
+	return marshal(doc)
+}
+
+// toCIIParty converts an invoice party to its CII representation
+func toCIIParty(party types.InvoiceParty) ciiParty {
+	p := ciiParty{
+		Name: party.Name,
+		Address: ciiAddress{
+			PostcodeCode: party.PostalCode,
+			LineOne:      party.Street,
+			CityName:     party.City,
+			CountryID:    party.CountryCode,
+		},
+	}
+	if party.LegalRegistrationID != "" {
+		p.LegalOrganization = &ciiLegalOrganization{ID: party.LegalRegistrationID}
+	}
+	if party.VATID != "" {
+		p.TaxRegistration = &ciiTaxRegistration{}
+		p.TaxRegistration.ID.SchemeID = "VA"
+		p.TaxRegistration.ID.Value = party.VATID
+	}
+	return p
+}
+
+// ciiDate converts a YYYY-MM-DD date to the CCYYMMDD format of CII
+func ciiDate(date string) ciiDateTime {
+	var dt ciiDateTime
+	dt.DateTimeString.Format = ciiDateFormat
+	dt.DateTimeString.Value = strings.ReplaceAll(date, "-", "")
+	return dt
+}
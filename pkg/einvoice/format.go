@@ -0,0 +1,57 @@
+package einvoice
+
+import (
+	"strconv"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	defaultTypeCode = "380"
+	// defaultUnitCode is the UN/ECE Recommendation 20 code for "one" (pieces)
+	defaultUnitCode = "C62"
+	// defaultVATCategory is the UNCL5305 code for the standard rate
+	defaultVATCategory = "S"
+)
+
+// formatAmount formats a monetary amount with two decimals
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+// formatDecimal formats a quantity or percentage with the shortest exact representation
+func formatDecimal(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// typeCode returns the invoice type code, defaulting to a commercial invoice
+func typeCode(invoice *types.Invoice) string {
+	if invoice.TypeCode == "" {
+		return defaultTypeCode
+	}
+	return invoice.TypeCode
+}
+
+// unitCode returns the line unit of measure, defaulting to pieces
+func unitCode(line types.InvoiceLine) string {
+	if line.UnitCode == "" {
+		return defaultUnitCode
+	}
+	return line.UnitCode
+}
+
+// vatCategory returns the VAT category code, defaulting to the standard rate
+func vatCategory(category string) string {
+	if category == "" {
+		return defaultVATCategory
+	}
+	return category
+}
+
+// lineID returns the line identifier, numbering lines from 1 when it is missing
+func lineID(line types.InvoiceLine, index int) string {
+	if line.ID == "" {
+		return strconv.Itoa(index + 1)
+	}
+	return line.ID
+}
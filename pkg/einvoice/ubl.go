@@ -0,0 +1,311 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// EN16931CustomizationID identifies the EN 16931 core specification in UBL and CII documents
+const EN16931CustomizationID = "urn:cen.eu:en16931:2017"
+
+const (
+	ublInvoiceNamespace    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	ublCreditNoteNamespace = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	ublCacNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	ublCbcNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+	creditNoteTypeCode     = "381"
+	// creditTransferMeansCode is the UNCL4461 code for SEPA credit transfers
+	creditTransferMeansCode = "58"
+	// undefinedMeansCode is the UNCL4461 code for a payment instrument not defined
+	undefinedMeansCode = "1"
+)
+
+type ublAmount struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type ublQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublTaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublTaxCategory struct {
+	ID        string       `xml:"cbc:ID"`
+	Percent   string       `xml:"cbc:Percent,omitempty"`
+	TaxScheme ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublCountry struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+type ublAddress struct {
+	StreetName string     `xml:"cbc:StreetName,omitempty"`
+	CityName   string     `xml:"cbc:CityName,omitempty"`
+	PostalZone string     `xml:"cbc:PostalZone,omitempty"`
+	Country    ublCountry `xml:"cac:Country"`
+}
+
+type ublPartyTaxScheme struct {
+	CompanyID string       `xml:"cbc:CompanyID"`
+	TaxScheme ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublLegalEntity struct {
+	RegistrationName string `xml:"cbc:RegistrationName"`
+	CompanyID        string `xml:"cbc:CompanyID,omitempty"`
+}
+
+type ublParty struct {
+	PostalAddress  ublAddress         `xml:"cac:PostalAddress"`
+	PartyTaxScheme *ublPartyTaxScheme `xml:"cac:PartyTaxScheme,omitempty"`
+	LegalEntity    ublLegalEntity     `xml:"cac:PartyLegalEntity"`
+}
+
+type ublPartyWrapper struct {
+	Party ublParty `xml:"cac:Party"`
+}
+
+type ublFinancialAccount struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublPaymentMeans struct {
+	PaymentMeansCode string               `xml:"cbc:PaymentMeansCode"`
+	PaymentDueDate   string               `xml:"cbc:PaymentDueDate,omitempty"`
+	PaymentID        string               `xml:"cbc:PaymentID,omitempty"`
+	PayeeAccount     *ublFinancialAccount `xml:"cac:PayeeFinancialAccount,omitempty"`
+}
+
+type ublPaymentTerms struct {
+	Note string `xml:"cbc:Note"`
+}
+
+type ublTaxSubtotal struct {
+	TaxableAmount ublAmount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     ublAmount      `xml:"cbc:TaxAmount"`
+	TaxCategory   ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount    ublAmount        `xml:"cbc:TaxAmount"`
+	TaxSubtotals []ublTaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtensionAmount ublAmount  `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount  ublAmount  `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount  ublAmount  `xml:"cbc:TaxInclusiveAmount"`
+	PrepaidAmount       *ublAmount `xml:"cbc:PrepaidAmount,omitempty"`
+	PayableAmount       ublAmount  `xml:"cbc:PayableAmount"`
+}
+
+type ublItem struct {
+	Name        string         `xml:"cbc:Name"`
+	TaxCategory ublTaxCategory `xml:"cac:ClassifiedTaxCategory"`
+}
+
+type ublPrice struct {
+	PriceAmount ublAmount `xml:"cbc:PriceAmount"`
+}
+
+type ublLine struct {
+	ID                  string      `xml:"cbc:ID"`
+	Quantity            ublQuantity `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount ublAmount   `xml:"cbc:LineExtensionAmount"`
+	Item                ublItem     `xml:"cac:Item"`
+	Price               ublPrice    `xml:"cac:Price"`
+}
+
+// ublCreditNoteLine is a credit note line, which credits its quantity rather than invoicing it
+type ublCreditNoteLine struct {
+	ID                  string      `xml:"cbc:ID"`
+	Quantity            ublQuantity `xml:"cbc:CreditedQuantity"`
+	LineExtensionAmount ublAmount   `xml:"cbc:LineExtensionAmount"`
+	Item                ublItem     `xml:"cac:Item"`
+	Price               ublPrice    `xml:"cac:Price"`
+}
+
+type ublOrderReference struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublInvoice struct {
+	XMLName            xml.Name
+	Xmlns              string              `xml:"xmlns,attr"`
+	XmlnsCac           string              `xml:"xmlns:cac,attr"`
+	XmlnsCbc           string              `xml:"xmlns:cbc,attr"`
+	CustomizationID    string              `xml:"cbc:CustomizationID"`
+	ID                 string              `xml:"cbc:ID"`
+	IssueDate          string              `xml:"cbc:IssueDate"`
+	DueDate            string              `xml:"cbc:DueDate,omitempty"`
+	TypeCode           string              `xml:"cbc:InvoiceTypeCode,omitempty"`
+	CreditNoteType     string              `xml:"cbc:CreditNoteTypeCode,omitempty"`
+	CurrencyCode       string              `xml:"cbc:DocumentCurrencyCode"`
+	BuyerReference     string              `xml:"cbc:BuyerReference,omitempty"`
+	OrderReference     *ublOrderReference  `xml:"cac:OrderReference,omitempty"`
+	Supplier           ublPartyWrapper     `xml:"cac:AccountingSupplierParty"`
+	Customer           ublPartyWrapper     `xml:"cac:AccountingCustomerParty"`
+	PaymentMeans       *ublPaymentMeans    `xml:"cac:PaymentMeans,omitempty"`
+	PaymentTerms       *ublPaymentTerms    `xml:"cac:PaymentTerms,omitempty"`
+	TaxTotal           ublTaxTotal         `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal ublMonetaryTotal    `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines       []ublLine           `xml:"cac:InvoiceLine,omitempty"`
+	CreditNoteLines    []ublCreditNoteLine `xml:"cac:CreditNoteLine,omitempty"`
+}
+
+// ToUBL serializes an invoice to OASIS UBL 2.1 XML following the EN 16931 core specification.
+// Credit notes (type code 381) are written as UBL CreditNote documents, which carry the due date
+// in their payment means.
+func ToUBL(invoice *types.Invoice) ([]byte, error) {
+	if err := validate(invoice); err != nil {
+		return nil, err
+	}
+	currency := invoice.Currency
+	amount := func(value float64) ublAmount {
+		return ublAmount{CurrencyID: currency, Value: formatAmount(value)}
+	}
+
+	doc := ublInvoice{
+		XmlnsCac:        ublCacNamespace,
+		XmlnsCbc:        ublCbcNamespace,
+		CustomizationID: EN16931CustomizationID,
+		ID:              invoice.Number,
+		IssueDate:       invoice.IssueDate,
+		CurrencyCode:    currency,
+		BuyerReference:  invoice.BuyerReference,
+		Supplier:        ublPartyWrapper{Party: toUBLParty(invoice.Seller)},
+		Customer:        ublPartyWrapper{Party: toUBLParty(invoice.Buyer)},
+		TaxTotal:        ublTaxTotal{TaxAmount: amount(invoice.TaxTotal)},
+		LegalMonetaryTotal: ublMonetaryTotal{
+			LineExtensionAmount: amount(invoice.LineTotal),
+			TaxExclusiveAmount:  amount(invoice.TaxExclusiveTotal),
+			TaxInclusiveAmount:  amount(invoice.TaxInclusiveTotal),
+			PayableAmount:       amount(invoice.PayableAmount),
+		},
+	}
+
+	creditNote := invoice.TypeCode == creditNoteTypeCode
+	if creditNote {
+		doc.XMLName = xml.Name{Local: "CreditNote"}
+		doc.Xmlns = ublCreditNoteNamespace
+		doc.CreditNoteType = invoice.TypeCode
+	} else {
+		doc.XMLName = xml.Name{Local: "Invoice"}
+		doc.Xmlns = ublInvoiceNamespace
+		doc.TypeCode = typeCode(invoice)
+		doc.DueDate = invoice.DueDate
+	}
+
+	if invoice.PurchaseOrderReference != "" {
+		doc.OrderReference = &ublOrderReference{ID: invoice.PurchaseOrderReference}
+	}
+	if invoice.PaymentIBAN != "" || invoice.PaymentReference != "" {
+		doc.PaymentMeans = &ublPaymentMeans{PaymentMeansCode: creditTransferMeansCode, PaymentID: invoice.PaymentReference}
+		if invoice.PaymentIBAN != "" {
+			doc.PaymentMeans.PayeeAccount = &ublFinancialAccount{ID: invoice.PaymentIBAN}
+		}
+	}
+	if creditNote && invoice.DueDate != "" {
+		if doc.PaymentMeans == nil {
+			doc.PaymentMeans = &ublPaymentMeans{PaymentMeansCode: undefinedMeansCode}
+		}
+		doc.PaymentMeans.PaymentDueDate = invoice.DueDate
+	}
+	if invoice.PaymentTerms != "" {
+		doc.PaymentTerms = &ublPaymentTerms{Note: invoice.PaymentTerms}
+	}
+	if invoice.PaidAmount != 0 {
+		paid := amount(invoice.PaidAmount)
+		doc.LegalMonetaryTotal.PrepaidAmount = &paid
+	}
+
+	for _, vat := range invoice.VATBreakdown {
+		doc.TaxTotal.TaxSubtotals = append(doc.TaxTotal.TaxSubtotals, ublTaxSubtotal{
+			TaxableAmount: amount(vat.TaxableAmount),
+			TaxAmount:     amount(vat.TaxAmount),
+			TaxCategory:   ublTaxCategory{ID: vatCategory(vat.Category), Percent: formatDecimal(vat.Rate), TaxScheme: ublTaxScheme{ID: "VAT"}},
+		})
+	}
+
+	for i, line := range invoice.Lines {
+		ubl := ublLine{
+			ID:                  lineID(line, i),
+			Quantity:            ublQuantity{UnitCode: unitCode(line), Value: formatDecimal(line.Quantity)},
+			LineExtensionAmount: amount(line.NetAmount),
+			Item: ublItem{
+				Name:        line.Name,
+				TaxCategory: ublTaxCategory{ID: vatCategory(line.VATCategory), Percent: formatDecimal(line.VATRate), TaxScheme: ublTaxScheme{ID: "VAT"}},
+			},
+			Price: ublPrice{PriceAmount: amount(line.NetPrice)},
+		}
+		if creditNote {
+			doc.CreditNoteLines = append(doc.CreditNoteLines, ublCreditNoteLine(ubl))
+		} else {
+			doc.InvoiceLines = append(doc.InvoiceLines, ubl)
+		}
+	}
+
+	return marshal(doc)
+}
+
+// toUBLParty converts an invoice party to its UBL representation
+func toUBLParty(party types.InvoiceParty) ublParty {
+	p := ublParty{
+		PostalAddress: ublAddress{
+			StreetName: party.Street,
+			CityName:   party.City,
+			PostalZone: party.PostalCode,
+			Country:    ublCountry{IdentificationCode: party.CountryCode},
+		},
+		LegalEntity: ublLegalEntity{RegistrationName: party.Name, CompanyID: party.LegalRegistrationID},
+	}
+	if party.VATID != "" {
+		p.PartyTaxScheme = &ublPartyTaxScheme{CompanyID: party.VATID, TaxScheme: ublTaxScheme{ID: "VAT"}}
+	}
+	return p
+}
+
+// validate checks the business terms every EN 16931 document must carry
+func validate(invoice *types.Invoice) error {
+	if invoice == nil {
+		return errors.New("invoice is required")
+	}
+	missing := make([]string, 0)
+	if invoice.Number == "" {
+		missing = append(missing, "invoice number (BT-1)")
+	}
+	if invoice.IssueDate == "" {
+		missing = append(missing, "issue date (BT-2)")
+	}
+	if invoice.Currency == "" {
+		missing = append(missing, "currency (BT-5)")
+	}
+	if invoice.Seller.Name == "" {
+		missing = append(missing, "seller name (BT-27)")
+	}
+	if invoice.Buyer.Name == "" {
+		missing = append(missing, "buyer name (BT-44)")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("invoice is missing required business terms: %v", missing)
+	}
+	return nil
+}
+
+// marshal serializes a document with an XML declaration
+func marshal(doc interface{}) ([]byte, error) {
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize invoice: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
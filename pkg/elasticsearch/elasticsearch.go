@@ -0,0 +1,298 @@
+// Package elasticsearch writes extraction results as documents to Elasticsearch or OpenSearch
+// through their REST API
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// Config configures a Writer
+type Config struct {
+	// URL is the base URL of the cluster, e.g. "https://localhost:9200" (required)
+	URL string
+	// Index is the name of the index the documents are written to (required)
+	Index string
+	// Username and Password enable basic authentication (optional)
+	Username string
+	Password string
+	// APIKey is an Elasticsearch API key, sent as "Authorization: ApiKey <APIKey>" (optional)
+	APIKey string
+	// IncludeText writes the full text of the documents in the "text" field
+	IncludeText bool
+	// Client is the HTTP client used for requests (default: http.DefaultClient)
+	Client *http.Client
+}
+
+// Writer writes extracted documents to an Elasticsearch or OpenSearch index. Every document has
+// its extracted data under "data", its "numPages" and "model", and its full text in "text" when
+// IncludeText is set. Documents are indexed by their ID, so writing a document again replaces it.
+//
+// Writer implements types.Indexer, so it can be set as ExtractorConfig.Indexer.
+type Writer struct {
+	config Config
+	client *http.Client
+}
+
+// New creates a writer for the index of config
+func New(config Config) (*Writer, error) {
+	if config.URL == "" {
+		return nil, errors.New("Elasticsearch URL is required")
+	}
+	if config.Index == "" {
+		return nil, errors.New("Elasticsearch index is required")
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Writer{config: config, client: client}, nil
+}
+
+// Endpoints returns the URL of the cluster
+func (w *Writer) Endpoints() []string {
+	return []string{w.config.URL}
+}
+
+// Mapping derives the index mapping of extracted documents from the JSON schema of the data:
+// strings are full-text fields with a "keyword" subfield for exact matches and aggregations,
+// except enums (keywords) and date formats (dates); numbers are doubles, integers are longs and
+// booleans are booleans. Arrays are mapped as their items.
+func (w *Writer) Mapping(jsonSchema map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{
+		"data":     schemaMapping(jsonSchema),
+		"numPages": map[string]interface{}{"type": "integer"},
+		"model":    map[string]interface{}{"type": "keyword"},
+	}
+	if w.config.IncludeText {
+		properties["text"] = map[string]interface{}{"type": "text"}
+	}
+	return map[string]interface{}{"properties": properties}
+}
+
+// CreateIndex creates the index with the mapping derived from the JSON schema of the data (see
+// Mapping). An index that already exists is left as it is.
+func (w *Writer) CreateIndex(ctx context.Context, jsonSchema map[string]interface{}) error {
+	status, _, err := w.do(ctx, http.MethodHead, "/"+url.PathEscape(w.config.Index), "", nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"mappings": w.Mapping(jsonSchema)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal index mapping: %w", err)
+	}
+	status, response, err := w.do(ctx, http.MethodPut, "/"+url.PathEscape(w.config.Index), "application/json", body)
+	if err != nil {
+		return err
+	}
+	if !succeeded(status) {
+		return fmt.Errorf("failed to create index %s (status %d): %s", w.config.Index, status, response)
+	}
+	return nil
+}
+
+// Index writes a document to the index
+func (w *Writer) Index(ctx context.Context, document types.IndexDocument) error {
+	if document.ID == "" {
+		return errors.New("document ID is required")
+	}
+	body, err := json.Marshal(w.source(document))
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	path := fmt.Sprintf("/%s/_doc/%s", url.PathEscape(w.config.Index), url.PathEscape(document.ID))
+	status, response, err := w.do(ctx, http.MethodPut, path, "application/json", body)
+	if err != nil {
+		return err
+	}
+	if !succeeded(status) {
+		return fmt.Errorf("failed to index document %s (status %d): %s", document.ID, status, response)
+	}
+	return nil
+}
+
+// WriteBatch writes the successful results of a batch to the index in a single bulk request,
+// with the batch IDs as document IDs. Failed results are skipped.
+func (w *Writer) WriteBatch(ctx context.Context, results []types.BatchResult) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	count := 0
+	for _, result := range results {
+		if result.Status != types.BatchStatusSuccess || result.Result == nil {
+			continue
+		}
+		action := map[string]interface{}{"index": map[string]interface{}{"_index": w.config.Index, "_id": result.ID}}
+		document := types.IndexDocument{ID: result.ID, Data: result.Result.Data, Model: result.Result.Model}
+		if err := encoder.Encode(action); err != nil {
+			return fmt.Errorf("failed to marshal bulk action: %w", err)
+		}
+		if err := encoder.Encode(w.source(document)); err != nil {
+			return fmt.Errorf("failed to marshal document: %w", err)
+		}
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+
+	status, response, err := w.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if !succeeded(status) {
+		return fmt.Errorf("bulk request failed (status %d): %s", status, response)
+	}
+
+	// A bulk request succeeds even when some of its documents fail
+	var bulk struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(response, &bulk); err != nil {
+		return fmt.Errorf("failed to parse bulk response: %w", err)
+	}
+	if bulk.Errors {
+		for _, item := range bulk.Items {
+			for _, result := range item {
+				if len(result.Error) > 0 {
+					return fmt.Errorf("failed to index document %s: %s", result.ID, result.Error)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// source builds the indexed fields of a document
+func (w *Writer) source(document types.IndexDocument) map[string]interface{} {
+	source := map[string]interface{}{
+		"data":     document.Data,
+		"numPages": document.NumPages,
+		"model":    document.Model,
+	}
+	if w.config.IncludeText {
+		source["text"] = document.Text
+	}
+	return source
+}
+
+// do sends a request to the cluster and returns the status code and body of the response
+func (w *Writer) do(ctx context.Context, method, path, contentType string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, w.config.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	switch {
+	case w.config.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+w.config.APIKey)
+	case w.config.Username != "":
+		req.SetBasicAuth(w.config.Username, w.config.Password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to call Elasticsearch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	response, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, response, nil
+}
+
+// succeeded reports whether a status code is successful
+func succeeded(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// schemaMapping maps a JSON schema to the mapping of its values
+func schemaMapping(jsonSchema map[string]interface{}) map[string]interface{} {
+	// Nullable values are declared as a union with null
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if variants, ok := jsonSchema[key].([]interface{}); ok {
+			for _, variant := range variants {
+				if variant, ok := variant.(map[string]interface{}); ok && schemaType(variant) != "null" {
+					return schemaMapping(variant)
+				}
+			}
+		}
+	}
+
+	switch schemaType(jsonSchema) {
+	case "object":
+		properties := make(map[string]interface{})
+		if schemaProperties, ok := jsonSchema["properties"].(map[string]interface{}); ok {
+			for name, property := range schemaProperties {
+				if property, ok := property.(map[string]interface{}); ok {
+					properties[name] = schemaMapping(property)
+				}
+			}
+		}
+		return map[string]interface{}{"properties": properties}
+	case "array":
+		if items, ok := jsonSchema["items"].(map[string]interface{}); ok {
+			return schemaMapping(items)
+		}
+		return map[string]interface{}{"type": "keyword"}
+	case "integer":
+		return map[string]interface{}{"type": "long"}
+	case "number":
+		return map[string]interface{}{"type": "double"}
+	case "boolean":
+		return map[string]interface{}{"type": "boolean"}
+	default:
+		if _, ok := jsonSchema["enum"]; ok {
+			return map[string]interface{}{"type": "keyword"}
+		}
+		if format, _ := jsonSchema["format"].(string); format == "date" || format == "date-time" {
+			return map[string]interface{}{"type": "date"}
+		}
+		return map[string]interface{}{
+			"type":   "text",
+			"fields": map[string]interface{}{"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256}},
+		}
+	}
+}
+
+// schemaType returns the type of a JSON schema, ignoring null in type unions
+func schemaType(jsonSchema map[string]interface{}) string {
+	switch t := jsonSchema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, value := range t {
+			if name, ok := value.(string); ok && name != "null" {
+				return name
+			}
+		}
+		return "null"
+	}
+	if _, ok := jsonSchema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// ErrEgressRefused is returned when air-gapped mode refuses a connection, or a configuration, to an
+// endpoint that is not local
+var ErrEgressRefused = errors.New("network egress refused in air-gapped mode")
+
+// localDomains are the domains of the hosts of local networks, e.g. model-runner.docker.internal
+var localDomains = []string{".localhost", ".local", ".internal"}
+
+// maxRedirects is the number of redirects followed by the HTTP client, as by default
+const maxRedirects = 10
+
+// validateAirGapped checks that the model endpoint, the proxy and the endpoints of the components of
+// an air-gapped configuration, such as remote OCR backends, indexers and publishers implementing
+// types.RemoteService, are local
+func validateAirGapped(config types.ExtractorConfig) error {
+	type egress struct {
+		name      string
+		endpoints []string
+	}
+	checked := []egress{{"BaseURL", []string{config.BaseURL}}}
+	if config.ProxyURL != "" {
+		checked = append(checked, egress{"ProxyURL", []string{config.ProxyURL}})
+	}
+	component := func(name string, component interface{}) {
+		if remote, ok := component.(types.RemoteService); ok {
+			checked = append(checked, egress{name, remote.Endpoints()})
+		}
+	}
+	component("KeyProvider", config.KeyProvider)
+	component("Signer", config.Signer)
+	component("Indexer", config.Indexer)
+	component("FeedbackStore", config.FeedbackStore)
+	component("EntityResolver", config.EntityResolver)
+	component("Publisher", config.Publisher)
+	component("Recorder", config.Recorder)
+	component("ImageUploader", config.ImageUploader)
+	component("ArtifactStore", config.ArtifactStore)
+	for _, name := range []string{config.TextBackend, config.OCRBackend, config.CrossCheckBackend, config.Renderer} {
+		if backend, ok := parser.LookupBackend(name); ok && name != "" {
+			component("backend "+name, backend)
+		}
+	}
+
+	for _, entry := range checked {
+		for _, endpoint := range entry.endpoints {
+			if !localEndpoint(endpoint, config.LocalHosts) {
+				return fmt.Errorf("%w: %s connects to %s, which is not a local endpoint (add its host to LocalHosts if it is on-prem)", ErrEgressRefused, entry.name, endpoint)
+			}
+		}
+	}
+	return nil
+}
+
+// restrictEgress limits the connections of an HTTP client to local endpoints. The hosts of
+// localHosts are connected to as they are; other hosts only once resolved to loopback and private
+// addresses, so that no name resolving to a public address is reached. The proxies of the
+// environment are ignored, and redirects to hosts that are not local refused.
+func restrictEgress(client *http.Client, transport *http.Transport, config types.ExtractorConfig) {
+	if config.ProxyURL == "" {
+		transport.Proxy = nil
+	}
+	direct := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	resolved := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !localIP(ip) {
+				return fmt.Errorf("%w: %s is not a local address", ErrEgressRefused, host)
+			}
+			return nil
+		},
+	}
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(address); err == nil && allowedHost(host, config.LocalHosts) {
+			return direct.DialContext(ctx, network, address)
+		}
+		return resolved.DialContext(ctx, network, address)
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !localHost(req.URL.Hostname(), config.LocalHosts) {
+			return fmt.Errorf("%w: redirect to %s", ErrEgressRefused, req.URL.Host)
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+}
+
+// localEndpoint reports whether the host of an endpoint, a URL or a host:port address, is local
+func localEndpoint(endpoint string, localHosts []string) bool {
+	host := endpoint
+	if strings.Contains(endpoint, "://") {
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			return false
+		}
+		host = parsed.Hostname()
+	} else if name, _, err := net.SplitHostPort(endpoint); err == nil {
+		host = name
+	}
+	return localHost(host, localHosts)
+}
+
+// localHost reports whether a host is local: one of localHosts, a loopback or private address, a
+// single-label name such as the service names of Docker Compose and Kubernetes, or a name of the
+// .localhost, .local and .internal domains
+func localHost(host string, localHosts []string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	switch {
+	case host == "":
+		return false
+	case allowedHost(host, localHosts):
+		return true
+	case net.ParseIP(host) != nil:
+		return localIP(net.ParseIP(host))
+	case host == "localhost" || !strings.Contains(host, "."):
+		return true
+	}
+	for _, domain := range localDomains {
+		if strings.HasSuffix(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// localIP reports whether an address is a loopback, private or link-local address
+func localIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+}
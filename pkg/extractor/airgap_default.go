@@ -0,0 +1,6 @@
+//go:build !airgapped
+
+package extractor
+
+// airGappedBuild forces ExtractorConfig.AirGapped in builds with the airgapped tag
+const airGappedBuild = false
@@ -0,0 +1,174 @@
+package extractor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// PromptVersion is the version of the built-in prompts and instructions of the extractor, recorded
+// in audit records. It changes whenever their wording changes.
+const PromptVersion = 1
+
+// auditKey marks the context of an audited extraction, holding its auditTrail
+type auditKey struct{}
+
+// auditTrail collects the model calls of an audited extraction
+type auditTrail struct {
+	mu    sync.Mutex
+	calls []types.ModelCall
+}
+
+// auditCall adds a chat completion request to the audit trail of the context, if any
+func auditCall(ctx context.Context, body map[string]interface{}) {
+	trail, ok := ctx.Value(auditKey{}).(*auditTrail)
+	if !ok {
+		return
+	}
+	call, err := modelCall(body)
+	if err != nil {
+		return
+	}
+	trail.mu.Lock()
+	defer trail.mu.Unlock()
+	trail.calls = append(trail.calls, call)
+}
+
+// modelCall describes a chat completion request body, the images of its messages by hash
+func modelCall(body map[string]interface{}) (types.ModelCall, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return types.ModelCall{}, err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return types.ModelCall{}, err
+	}
+
+	call := types.ModelCall{Parameters: make(map[string]interface{}), Messages: make([]types.ModelMessage, 0)}
+	call.Model, _ = decoded["model"].(string)
+	messages, _ := decoded["messages"].([]interface{})
+	delete(decoded, "model")
+	delete(decoded, "messages")
+	call.Parameters = decoded
+
+	for _, item := range messages {
+		message, _ := item.(map[string]interface{})
+		role, _ := message["role"].(string)
+		recorded := types.ModelMessage{Role: role}
+		switch content := message["content"].(type) {
+		case string:
+			recorded.Content = content
+		case []interface{}:
+			texts := make([]string, 0)
+			for _, item := range content {
+				part, _ := item.(map[string]interface{})
+				if text, ok := part["text"].(string); ok {
+					texts = append(texts, text)
+				}
+				if image, ok := part["image_url"].(map[string]interface{}); ok {
+					url, _ := image["url"].(string)
+					sum := sha256.Sum256([]byte(url))
+					recorded.Images = append(recorded.Images, hex.EncodeToString(sum[:]))
+				}
+			}
+			recorded.Content = strings.Join(texts, "\n")
+		}
+		call.Messages = append(call.Messages, recorded)
+	}
+	return call, nil
+}
+
+// record builds the audit record of an extraction from its trail, sets it on the result, and
+// hands it to the configured recorder, if any
+func (e *Extractor) record(ctx context.Context, trail *auditTrail, options types.ExtractionOptions, result *types.ExtractionResult, extractErr error, duration time.Duration) error {
+	// Failed extractions may not have a readable PDF to identify them
+	id, _ := documentID(options)
+	provider := e.config.Provider
+	if provider == "" {
+		provider = types.ProviderOpenAI
+	}
+	trail.mu.Lock()
+	calls := trail.calls
+	trail.mu.Unlock()
+	if calls == nil {
+		calls = make([]types.ModelCall, 0)
+	}
+	record := types.ExtractionRecord{
+		SchemaVersion:  types.ExtractionRecordSchemaVersion,
+		DocumentID:     id,
+		Schema:         schemaName(options),
+		SchemaRevision: options.SchemaVersion,
+		LibraryVersion: LibraryVersion(),
+		PromptVersion:  PromptVersion,
+		SystemPrompt:   e.systemPrompt,
+		Provider:       provider,
+		BaseURL:        e.baseURL,
+		Calls:          calls,
+		Time:           time.Now().UTC(),
+		DurationMs:     duration.Milliseconds(),
+	}
+	if schemaData, _, err := combinedSchema(options); err == nil {
+		record.SchemaHash = schemaHash(schemaData)
+	}
+	if len(calls) > 0 {
+		for _, message := range calls[0].Messages {
+			if message.Role == "user" {
+				record.UserPrompt = message.Content
+				break
+			}
+		}
+	}
+	if options.Minimize {
+		minimizeRecord(&record)
+	}
+	if extractErr != nil {
+		record.Error = extractErr.Error()
+	} else {
+		record.Template = result.Template
+		record.Model = result.Model
+		record.Data = result.Data
+		record.TokensUsed = result.TokensUsed
+		result.Record = &record
+	}
+
+	if e.config.Recorder == nil {
+		return nil
+	}
+	if err := e.config.Recorder.Record(ctx, record); err != nil {
+		return fmt.Errorf("failed to record extraction: %w", err)
+	}
+	return nil
+}
+
+// minimizeRecord replaces the prompts of an audit record holding the content of the document by
+// their SHA-256, in hex, for extractions with ExtractionOptions.Minimize set
+func minimizeRecord(record *types.ExtractionRecord) {
+	hash := func(text string) string {
+		if text == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(text))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+	record.UserPrompt = hash(record.UserPrompt)
+	calls := make([]types.ModelCall, len(record.Calls))
+	for i, call := range record.Calls {
+		calls[i] = call
+		calls[i].Messages = make([]types.ModelMessage, len(call.Messages))
+		for j, message := range call.Messages {
+			if message.Role != "system" {
+				message.Content = hash(message.Content)
+			}
+			calls[i].Messages[j] = message
+		}
+	}
+	record.Calls = calls
+}
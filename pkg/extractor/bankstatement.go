@@ -0,0 +1,153 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const bankStatementInstructions = "The document is a bank statement. List every row of the transaction table, in printed order, without skipping or merging rows. " +
+	"Give dates as YYYY-MM-DD and amounts as signed numbers: negative for debits (withdrawals, payments, fees) and positive for credits (deposits), also when debits and credits are printed in separate columns. " +
+	"Set balance to the running balance printed on the row, or null when the row has none, and page to the page the row is printed on. " +
+	"Set openingBalance and closingBalance only when the statement's opening or closing balance is printed in the text, not for balances carried forward between pages; use null otherwise. Use an empty string for texts that are not stated."
+
+var nullableNumberProperty = map[string]interface{}{"type": []string{"number", "null"}}
+
+// bankStatementSchema is the built-in JSON schema used for bank statements
+var bankStatementSchema = strictObject(map[string]interface{}{
+	"bankName":       stringProperty,
+	"accountHolder":  stringProperty,
+	"accountNumber":  stringProperty,
+	"currency":       stringProperty,
+	"periodStart":    stringProperty,
+	"periodEnd":      stringProperty,
+	"openingBalance": nullableNumberProperty,
+	"closingBalance": nullableNumberProperty,
+	"transactions": map[string]interface{}{
+		"type": "array",
+		"items": strictObject(map[string]interface{}{
+			"date":        stringProperty,
+			"description": stringProperty,
+			"amount":      numberProperty,
+			"balance":     nullableNumberProperty,
+			"page":        map[string]interface{}{"type": "integer"},
+		}),
+	},
+})
+
+// ExtractBankStatement extracts the account metadata and the full transaction table of a bank
+// statement given as a file path or a byte slice. Long statements are extracted in chunks of
+// pages so that no rows are dropped, and the transactions are checked against the running and
+// closing balances; inconsistencies, which usually point at missed rows, are reported as warnings.
+func (e *Extractor) ExtractBankStatement(ctx context.Context, pdf interface{}, options types.BankStatementOptions) (*types.BankStatementResult, error) {
+	extractionOptions, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	extractionOptions.Schema = bankStatementSchema
+	extractionOptions.Instructions = bankStatementInstructions
+
+	// The chunks make a single extraction, recorded, saved and published with the merged statement
+	result := &types.BankStatementResult{}
+	_, err = e.tracked(ctx, extractionOptions, func(ctx context.Context) (*types.ExtractionResult, error) {
+		parsedPdf, err := e.parse(ctx, extractionOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		merge := func(chunk *types.ExtractionResult) error {
+			var part types.BankStatement
+			if err := decodeData(chunk.Data, &part); err != nil {
+				return err
+			}
+			mergeStatement(&result.Statement, &part)
+			result.TokensUsed += chunk.TokensUsed
+			result.Model = chunk.Model
+			return nil
+		}
+
+		progress, err := e.extractChunks(ctx, parsedPdf, extractionOptions, options.ChunkSize, options.ImagePagesPerChunk, false, merge)
+		if err == nil {
+			err = progress.err()
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Chunks, result.FailedChunks, result.Completeness = progress.chunks, progress.failures, progress.completeness()
+		reconcileStatement(result)
+		return chunkedResult(result, result.TokensUsed, result.Model, result.Warnings, extractionOptions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// mergeStatement merges the extraction of a statement chunk into the statement. The opening
+// balance and period start come from the first chunk stating them, the closing balance and
+// period end from the last one.
+func mergeStatement(statement, part *types.BankStatement) {
+	for _, field := range []struct {
+		into  *string
+		value string
+	}{
+		{&statement.BankName, part.BankName},
+		{&statement.AccountHolder, part.AccountHolder},
+		{&statement.AccountNumber, part.AccountNumber},
+		{&statement.Currency, part.Currency},
+		{&statement.PeriodStart, part.PeriodStart},
+	} {
+		if *field.into == "" {
+			*field.into = field.value
+		}
+	}
+	if part.PeriodEnd != "" {
+		statement.PeriodEnd = part.PeriodEnd
+	}
+	if statement.OpeningBalance == nil {
+		statement.OpeningBalance = part.OpeningBalance
+	}
+	if part.ClosingBalance != nil {
+		statement.ClosingBalance = part.ClosingBalance
+	}
+	statement.Transactions = append(statement.Transactions, part.Transactions...)
+}
+
+// reconcileStatement follows the balance from the opening balance through every transaction and
+// records the rows whose printed running balance, or the closing balance, does not follow
+func reconcileStatement(result *types.BankStatementResult) {
+	statement := &result.Statement
+
+	var balance *float64
+	if statement.OpeningBalance != nil {
+		opening := *statement.OpeningBalance
+		balance = &opening
+	}
+
+	for i, transaction := range statement.Transactions {
+		if balance != nil {
+			expected := *balance + transaction.Amount
+			if transaction.Balance != nil && !amountsEqual(expected, *transaction.Balance) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf(
+					"row %d (page %d, %s %q): balance %.2f does not follow from previous balance %.2f and amount %.2f; %.2f is unaccounted for, a row may be missing or misread",
+					i+1, transaction.Page, transaction.Date, transaction.Description, *transaction.Balance, *balance, transaction.Amount, *transaction.Balance-expected))
+			}
+			balance = &expected
+		}
+		// Resynchronize on every printed balance so that a single gap is reported once
+		if transaction.Balance != nil {
+			printed := *transaction.Balance
+			balance = &printed
+		}
+	}
+
+	if balance != nil && statement.ClosingBalance != nil && !amountsEqual(*balance, *statement.ClosingBalance) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"transactions lead to a balance of %.2f but the closing balance is %.2f; %.2f is unaccounted for, rows may be missing or misread",
+			*balance, *statement.ClosingBalance, *statement.ClosingBalance-*balance))
+	}
+
+	result.Reconciled = len(result.Warnings) == 0
+}
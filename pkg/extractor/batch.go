@@ -0,0 +1,152 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const defaultBatchConcurrency = 4
+
+// ExtractBatch extracts structured data from several documents in parallel and streams their
+// results, in completion order, on the returned channel. The channel is closed once every
+// document has been processed. A failed document does not stop the batch: its result carries the
+// error. When ctx is canceled, the remaining documents fail with the context error.
+//
+// With BatchOptions.Journal set, the record of every extracted document is saved to the journal,
+// and the documents it holds a success record of are not extracted again: their results are read
+// from the journal, with Resumed set. Documents are matched by ID, so set stable IDs to resume a
+// batch whose documents are not given in the same order.
+func (e *Extractor) ExtractBatch(ctx context.Context, documents []types.BatchDocument, options types.BatchOptions) <-chan types.BatchResult {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	var completed map[string]types.JSONLRecord
+	var journalErr error
+	if options.Journal != nil {
+		completed, journalErr = completedRecords(ctx, options.Journal)
+	}
+
+	jobs := make(chan int)
+	results := make(chan types.BatchResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				id := batchDocumentID(i, documents[i])
+				if journalErr != nil {
+					results <- types.BatchResult{ID: id, Status: types.BatchStatusError, Err: journalErr}
+					continue
+				}
+				if record, ok := completed[id]; ok {
+					results <- resumedResult(record)
+					continue
+				}
+				result := e.extractBatchDocument(ctx, id, documents[i])
+				// Documents failed by a canceled batch were not processed, so they are left out of the
+				// journal; extracted documents are saved even then
+				if options.Journal != nil && (result.Status == types.BatchStatusSuccess || ctx.Err() == nil) {
+					if err := options.Journal.Save(context.WithoutCancel(ctx), types.NewJSONLRecord(result)); err != nil {
+						result.Err = errors.Join(result.Err, fmt.Errorf("failed to save the batch journal: %w", err))
+						result.Status = types.BatchStatusError
+					}
+				}
+				results <- result
+			}
+		}()
+	}
+
+	go func() {
+		for i := range documents {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// batchDocumentID returns the ID of a batch document: its ID, its PDF path, or its index
+func batchDocumentID(index int, document types.BatchDocument) string {
+	if document.ID != "" {
+		return document.ID
+	}
+	if document.Options.PDFPath != "" {
+		return document.Options.PDFPath
+	}
+	return fmt.Sprintf("%d", index)
+}
+
+// completedRecords returns the success records of a batch journal, by document ID. The last record
+// of a document wins, so that a document extracted again replaces its earlier record.
+func completedRecords(ctx context.Context, journal types.BatchJournal) (map[string]types.JSONLRecord, error) {
+	records, err := journal.Records(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the batch journal: %w", err)
+	}
+	completed := make(map[string]types.JSONLRecord)
+	for _, record := range records {
+		if record.Status == types.BatchStatusSuccess {
+			completed[record.DocumentID] = record
+		} else {
+			delete(completed, record.DocumentID)
+		}
+	}
+	return completed, nil
+}
+
+// resumedResult returns the result of a document read from a success record of a batch journal
+func resumedResult(record types.JSONLRecord) types.BatchResult {
+	return types.BatchResult{
+		ID:     record.DocumentID,
+		Status: types.BatchStatusSuccess,
+		Result: &types.ExtractionResult{
+			Data:           record.Data,
+			Model:          record.Model,
+			TokensUsed:     record.TokensUsed,
+			SchemaHash:     record.SchemaHash,
+			SchemaName:     record.Schema,
+			SchemaVersion:  record.SchemaRevision,
+			LibraryVersion: record.LibraryVersion,
+		},
+		Duration: time.Duration(record.DurationMs) * time.Millisecond,
+		Resumed:  true,
+	}
+}
+
+// extractBatchDocument extracts a single document of a batch
+func (e *Extractor) extractBatchDocument(ctx context.Context, id string, document types.BatchDocument) types.BatchResult {
+	start := time.Now()
+	batchResult := types.BatchResult{ID: id}
+	if err := ctx.Err(); err != nil {
+		batchResult.Status = types.BatchStatusError
+		batchResult.Err = err
+		return batchResult
+	}
+
+	options := document.Options
+	if options.DocumentID == "" {
+		options.DocumentID = id
+	}
+	result, err := e.ExtractWithContext(ctx, options)
+	batchResult.Duration = time.Since(start)
+	if err != nil {
+		batchResult.Status = types.BatchStatusError
+		batchResult.Err = err
+		return batchResult
+	}
+	batchResult.Status = types.BatchStatusSuccess
+	batchResult.Result = result
+	return batchResult
+}
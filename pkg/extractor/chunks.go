@@ -0,0 +1,362 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	defaultChunkSize  = 24000
+	defaultImagePages = 8
+)
+
+// textChunk is a part of a document's text sent in a single request
+type textChunk struct {
+	// Text is the chunk text, with a [Page N] marker before each page
+	Text string
+	// FirstPage and LastPage are the pages (1-indexed) covered by the chunk
+	FirstPage, LastPage int
+	// Section is the title of the outline section of the chunk (when chunking by outline)
+	Section string
+}
+
+// pageSection is a range of pages of a document starting at an entry of its outline
+type pageSection struct {
+	// Title is the title of the outline entry
+	Title string
+	// FirstPage and LastPage are the pages (1-indexed) of the section
+	FirstPage, LastPage int
+}
+
+// chunkProgress counts the chunks of a document extracted in several requests, and records those
+// that failed
+type chunkProgress struct {
+	chunks   int
+	failures []types.ChunkFailure
+}
+
+// extract extracts a chunk and merges its result. A failed chunk is recorded, so that the other
+// chunks still make a partial result; it returns an error only when the context is done, as the
+// other chunks would fail too.
+func (p *chunkProgress) extract(ctx context.Context, chunk pageSection, extract func() (*types.ExtractionResult, error), merge func(*types.ExtractionResult) error) error {
+	p.chunks++
+	result, err := extract()
+	if err == nil {
+		err = merge(result)
+	}
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("failed to extract pages %d-%d: %w", chunk.FirstPage, chunk.LastPage, err)
+	if ctx.Err() != nil {
+		return err
+	}
+	p.failures = append(p.failures, types.ChunkFailure{FirstPage: chunk.FirstPage, LastPage: chunk.LastPage, Section: chunk.Title, Err: err})
+	return nil
+}
+
+// add counts the chunks of another extraction of the same document
+func (p *chunkProgress) add(other chunkProgress) {
+	p.chunks += other.chunks
+	p.failures = append(p.failures, other.failures...)
+}
+
+// err returns the errors of the chunks when none was extracted, as there is no partial result
+func (p *chunkProgress) err() error {
+	if len(p.failures) == 0 || len(p.failures) < p.chunks {
+		return nil
+	}
+	errs := make([]error, len(p.failures))
+	for i, failure := range p.failures {
+		errs[i] = failure.Err
+	}
+	return errors.Join(errs...)
+}
+
+// completeness returns the fraction of the chunks extracted, 1 for a document without chunks
+func (p *chunkProgress) completeness() float64 {
+	if p.chunks == 0 {
+		return 1
+	}
+	return float64(p.chunks-len(p.failures)) / float64(p.chunks)
+}
+
+// extractChunks extracts structured data from a long document in several requests. Text is
+// split into chunks of at most chunkSize characters and page images into groups of at most
+// imagePages pages (0 selects the defaults). With byOutline, chunks are first split at the
+// sections of the outline of the document, when it has one. The instructions of the options are
+// completed with the pages each chunk covers, and merge is called with the result of every chunk,
+// in page order. Chunks that fail are recorded in the progress, which callers check for a partial
+// result.
+func (e *Extractor) extractChunks(ctx context.Context, parsedPdf *types.ParsedPdf, options types.ExtractionOptions, chunkSize, imagePages int, byOutline bool, merge func(*types.ExtractionResult) error) (chunkProgress, error) {
+	var progress chunkProgress
+	instructions := options.Instructions
+	var sections []pageSection
+	if byOutline {
+		sections = outlineSections(parsedPdf.Outline, parsedPdf.NumPages)
+	}
+
+	if parsedPdf.Content.Type == "text" {
+		if chunkSize <= 0 {
+			chunkSize = defaultChunkSize
+		}
+		chunks := chunkSections(parsedPdf.Content.TextPages, sections, chunkSize)
+		for i, chunk := range chunks {
+			options.Instructions = chunkInstructions(instructions, i, len(chunks), chunk.FirstPage, chunk.LastPage) +
+				sectionInstructions(chunk.Section) + " Each page of the text starts with a [Page N] marker."
+			err := progress.extract(ctx, pageSection{Title: chunk.Section, FirstPage: chunk.FirstPage, LastPage: chunk.LastPage}, func() (*types.ExtractionResult, error) {
+				return e.extractFromText(ctx, chunk.Text, options.Schema, options)
+			}, merge)
+			if err != nil {
+				return progress, err
+			}
+		}
+		return progress, nil
+	}
+
+	if imagePages <= 0 {
+		imagePages = defaultImagePages
+	}
+	if parsedPdf.Content.Type == "mixed" {
+		err := e.extractMixedChunks(ctx, &progress, parsedPdf, options, imagePages, sections, merge)
+		return progress, err
+	}
+
+	// Group the images of each section, or of the whole document
+	images := parsedPdf.Content.ImageContent
+	sectionImages, sectionTitles := [][]types.PdfPageImage{images}, []string{""}
+	if len(sections) > 0 {
+		sectionImages, sectionTitles = make([][]types.PdfPageImage, len(sections)), make([]string, len(sections))
+		for i, section := range sections {
+			sectionTitles[i] = section.Title
+			for _, image := range images {
+				if image.Page >= section.FirstPage && image.Page <= section.LastPage {
+					sectionImages[i] = append(sectionImages[i], image)
+				}
+			}
+		}
+	}
+	var groups [][]types.PdfPageImage
+	var titles []string
+	for i, pages := range sectionImages {
+		for start := 0; start < len(pages); start += imagePages {
+			groups = append(groups, pages[start:min(len(pages), start+imagePages)])
+			titles = append(titles, sectionTitles[i])
+		}
+	}
+	for i, pages := range groups {
+		first, last := pages[0].Page, pages[len(pages)-1].Page
+		options.Instructions = chunkInstructions(instructions, i, len(groups), first, last) +
+			sectionInstructions(titles[i]) + fmt.Sprintf(" The images are pages %d to %d, in order.", first, last)
+		err := progress.extract(ctx, pageSection{Title: titles[i], FirstPage: first, LastPage: last}, func() (*types.ExtractionResult, error) {
+			return e.extractFromImages(ctx, pages, options.Schema, options)
+		}, merge)
+		if err != nil {
+			return progress, err
+		}
+	}
+	return progress, nil
+}
+
+// extractMixedChunks extracts structured data from a document mixing text and scanned pages in
+// groups of at most pagesPerChunk pages of the same section, if any
+func (e *Extractor) extractMixedChunks(ctx context.Context, progress *chunkProgress, parsedPdf *types.ParsedPdf, options types.ExtractionOptions, pagesPerChunk int, sections []pageSection, merge func(*types.ExtractionResult) error) error {
+	instructions := options.Instructions
+	numPages := len(parsedPdf.Content.PageTypes)
+	if len(sections) == 0 {
+		sections = []pageSection{{FirstPage: 1, LastPage: numPages}}
+	}
+	var chunks []pageSection
+	for _, section := range sections {
+		for first := section.FirstPage; first <= min(section.LastPage, numPages); first += pagesPerChunk {
+			chunks = append(chunks, pageSection{Title: section.Title, FirstPage: first, LastPage: min(section.LastPage, numPages, first+pagesPerChunk-1)})
+		}
+	}
+	for i, chunk := range chunks {
+		first, last := chunk.FirstPage, chunk.LastPage
+		options.Instructions = chunkInstructions(instructions, i, len(chunks), first, last) + sectionInstructions(chunk.Title)
+		request, err := e.mixedRequest(parsedPdf, first, last, options.Schema, options)
+		if err != nil {
+			return err
+		}
+		if err := progress.extract(ctx, chunk, func() (*types.ExtractionResult, error) {
+			return e.callOpenAI(ctx, request)
+		}, merge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkedResult returns the merged result of a document extracted in chunks as the result of its
+// tracked extraction. The data, recorded, saved and published, is the merged value serialized as
+// JSON, without the chunk counts and usage, which the result holds.
+func chunkedResult(merged interface{}, tokensUsed int, model string, warnings []string, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	encoded, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged data: %w", err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode merged data: %w", err)
+	}
+	for _, key := range []string{"Chunks", "FailedChunks", "Completeness", "TokensUsed", "Model"} {
+		delete(data, key)
+	}
+	result := &types.ExtractionResult{Data: data, TokensUsed: tokensUsed, Model: model, Warnings: warnings}
+	stamp(result, options.Schema, options)
+	return result, nil
+}
+
+// chunkInstructions completes instructions with the position of a chunk in the document
+func chunkInstructions(instructions string, index, total, firstPage, lastPage int) string {
+	if total <= 1 {
+		return instructions
+	}
+	return fmt.Sprintf("%s\n\nThis is part %d of %d of the document, covering pages %d to %d. Report only what is stated in this part.",
+		instructions, index+1, total, firstPage, lastPage)
+}
+
+// sectionInstructions names the outline section a chunk belongs to, if any
+func sectionInstructions(title string) string {
+	if title == "" {
+		return ""
+	}
+	return fmt.Sprintf(" It belongs to the section %q.", title)
+}
+
+// outlineSections splits the pages of a document at the entries of the top level of its outline,
+// or of the level below when the outline has a single root such as the title of the document.
+// Pages before the first entry belong to the first section. It returns nil when the outline has
+// fewer than two entries pointing to distinct pages.
+func outlineSections(outline []types.OutlineItem, numPages int) []pageSection {
+	items := outline
+	for len(items) == 1 && len(items[0].Children) > 0 {
+		items = items[0].Children
+	}
+
+	entries := make([]types.OutlineItem, 0, len(items))
+	for _, item := range items {
+		if item.Page >= 1 && item.Page <= numPages {
+			entries = append(entries, item)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Page < entries[j].Page })
+
+	var sections []pageSection
+	for _, entry := range entries {
+		if len(sections) > 0 && sections[len(sections)-1].FirstPage == entry.Page {
+			// Entries pointing to the same page start the same section
+			continue
+		}
+		if len(sections) > 0 {
+			sections[len(sections)-1].LastPage = entry.Page - 1
+		}
+		sections = append(sections, pageSection{Title: entry.Title, FirstPage: entry.Page, LastPage: numPages})
+	}
+	if len(sections) < 2 {
+		return nil
+	}
+	sections[0].FirstPage = 1
+	return sections
+}
+
+// chunkSections chunks page texts like chunkPages, without chunks spanning several sections
+func chunkSections(pages []string, sections []pageSection, size int) []textChunk {
+	if len(sections) == 0 {
+		return chunkPages(pages, size)
+	}
+	chunks := make([]textChunk, 0, len(sections))
+	for _, section := range sections {
+		// Blank pages are skipped, so blank out the pages of the other sections
+		sectionPages := make([]string, len(pages))
+		if section.FirstPage <= len(pages) {
+			last := min(section.LastPage, len(pages))
+			copy(sectionPages[section.FirstPage-1:last], pages[section.FirstPage-1:last])
+		}
+		for _, chunk := range chunkPages(sectionPages, size) {
+			chunk.Section = section.Title
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// chunkPages groups page texts into chunks of at most size characters, each page preceded by a
+// [Page N] marker. Chunks break between pages; a page longer than size is split between
+// paragraphs, or between lines when a single paragraph is too long.
+func chunkPages(pages []string, size int) []textChunk {
+	chunks := make([]textChunk, 0)
+	var current strings.Builder
+	first, last := 0, 0
+
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, textChunk{Text: current.String(), FirstPage: first, LastPage: last})
+			current.Reset()
+		}
+	}
+	write := func(pageNum int, marker, text string) {
+		if current.Len()+len(marker)+len(text) > size {
+			flush()
+		}
+		if current.Len() == 0 {
+			first = pageNum
+		}
+		last = pageNum
+		current.WriteString(marker)
+		current.WriteString(text)
+		current.WriteString("\n\n")
+	}
+
+	for i, page := range pages {
+		page = strings.TrimSpace(page)
+		if page == "" {
+			continue
+		}
+		marker := fmt.Sprintf("[Page %d]\n", i+1)
+
+		// Oversized pages are written piece by piece, repeating the page marker in each chunk
+		for _, piece := range splitText(page, size-len(marker)) {
+			write(i+1, marker, piece)
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// splitText splits text into pieces of at most size characters, preferring paragraph breaks,
+// then line breaks, then spaces
+func splitText(text string, size int) []string {
+	pieces := make([]string, 0)
+	size = max(size, 1)
+	for len(text) > size {
+		cut := -1
+		for _, separator := range []string{"\n\n", "\n", " "} {
+			if i := strings.LastIndex(text[:size], separator); i > 0 {
+				cut = i
+				break
+			}
+		}
+		if cut < 0 {
+			cut = size
+			for cut > 1 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		pieces = append(pieces, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		pieces = append(pieces, text)
+	}
+	return pieces
+}
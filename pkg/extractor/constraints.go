@@ -0,0 +1,304 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// ErrConstraintViolation is returned, wrapped in a *ConstraintError, when extracted values still
+// break the enum or pattern constraints of the schema after correction
+var ErrConstraintViolation = errors.New("constraint violation")
+
+// ConstraintError reports the extracted values breaking the enum or pattern constraints of the
+// schema. It matches ErrConstraintViolation with errors.Is.
+type ConstraintError struct {
+	// Violations are the offending values
+	Violations []schema.Violation
+}
+
+// Error lists the offending values
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrConstraintViolation, strings.Join(violationList(e.Violations), "; "))
+}
+
+// Unwrap returns ErrConstraintViolation
+func (e *ConstraintError) Unwrap() error {
+	return ErrConstraintViolation
+}
+
+// enforceConstraints checks the extracted data of a request against the enum and pattern
+// constraints of its schema. Violations are corrected locally when the intended value is obvious,
+// and otherwise the model is asked once to fix them, before failing with a *ConstraintError.
+func (e *Extractor) enforceConstraints(ctx context.Context, request *types.ModelRequest, result *types.ExtractionResult) (*types.ExtractionResult, error) {
+	format, _ := request.Body["response_format"].(map[string]interface{})
+	jsonSchema, _ := format["json_schema"].(map[string]interface{})
+	schemaData, _ := jsonSchema["schema"].(map[string]interface{})
+	if schemaData == nil {
+		return result, nil
+	}
+
+	violations, err := correctConstraints(result.Data, schemaData)
+	if err != nil || len(violations) == 0 {
+		return result, err
+	}
+
+	corrected, err := e.correct(ctx, request, result, "correction", "Some values of your answer break the constraints of the schema:\n- "+
+		strings.Join(violationList(violations), "\n- ")+"\nRead the document again and answer with the corrected JSON object.")
+	if err != nil {
+		return nil, fmt.Errorf("failed to correct constraint violations: %w", err)
+	}
+	if violations, err = correctConstraints(corrected.Data, schemaData); err != nil {
+		return nil, err
+	}
+	if len(violations) > 0 {
+		return nil, &ConstraintError{Violations: violations}
+	}
+	return corrected, nil
+}
+
+// correct shows the model its answer to a request along with what is wrong with it, and returns
+// the corrected result. The usage and timings of the first answer are added to those of the
+// correction, whose idempotency key gets a suffix.
+func (e *Extractor) correct(ctx context.Context, request *types.ModelRequest, result *types.ExtractionResult, suffix, feedback string) (*types.ExtractionResult, error) {
+	answer, err := json.Marshal(result.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal extracted data: %w", err)
+	}
+	body := make(map[string]interface{}, len(request.Body))
+	for key, value := range request.Body {
+		body[key] = value
+	}
+	body["messages"] = appendMessages(request.Body["messages"],
+		map[string]interface{}{"role": "assistant", "content": string(answer)},
+		map[string]interface{}{"role": "user", "content": feedback},
+	)
+	retry := *request
+	retry.Body = body
+	if retry.IdempotencyKey != "" {
+		// The correction is another request, which must not be answered with the first response
+		retry.IdempotencyKey += "-" + suffix
+	}
+
+	start := time.Now()
+	response, err := e.CallModel(ctx, &retry)
+	if err != nil {
+		return nil, err
+	}
+	requestDuration := time.Since(start)
+	corrected, err := e.ParseResult(response)
+	if err != nil {
+		return nil, err
+	}
+	corrected.TokensUsed += result.TokensUsed
+	corrected.Usage.Add(result.Usage)
+	corrected.Timings = result.Timings
+	corrected.Timings.Request += requestDuration
+	corrected.Timings.Retries++
+	return corrected, nil
+}
+
+// correctConstraints fixes in place the values of data breaking an enum or pattern constraint of
+// the schema when the intended value is obvious, and returns the violations left
+func correctConstraints(data, schemaData map[string]interface{}) ([]schema.Violation, error) {
+	violations, err := schema.CheckConstraints(data, schemaData)
+	if err != nil {
+		return nil, err
+	}
+
+	var left []schema.Violation
+	for _, violation := range violations {
+		valueSchema := schemaAtValuePath(schemaData, violation.Path)
+		text, isText := violation.Value.(string)
+		var corrected interface{}
+		found := false
+		switch {
+		case !isText || valueSchema == nil:
+		case violation.Keyword == "enum":
+			values, _ := valueSchema["enum"].([]interface{})
+			if values == nil {
+				if list, ok := valueSchema["enum"].([]string); ok {
+					for _, value := range list {
+						values = append(values, value)
+					}
+				}
+			}
+			corrected, found = nearestEnum(text, values)
+		case violation.Keyword == "pattern":
+			pattern, _ := valueSchema["pattern"].(string)
+			corrected, found = patternMatch(text, pattern)
+		}
+		if !found || setValuePath(data, violation.Path, corrected) != nil {
+			left = append(left, violation)
+		}
+	}
+	return left, nil
+}
+
+// nearestEnum returns the string enum value a text stands for: the one equal to it but for case,
+// spacing and punctuation, or else the one within a few typos of it, if it is the only one
+func nearestEnum(text string, values []interface{}) (interface{}, bool) {
+	normalized := normalizeEnum(text)
+	if normalized == "" {
+		return nil, false
+	}
+	var nearest interface{}
+	best, ties := -1, 0
+	for _, value := range values {
+		candidate, ok := value.(string)
+		if !ok {
+			continue
+		}
+		distance := editDistance(normalized, normalizeEnum(candidate))
+		switch {
+		case best < 0 || distance < best:
+			nearest, best, ties = candidate, distance, 1
+		case distance == best:
+			ties++
+		}
+	}
+	// Allow about one typo every four characters
+	if best < 0 || ties > 1 || best > len([]rune(normalized))/4 {
+		return nil, false
+	}
+	return nearest, true
+}
+
+// normalizeEnum lowercases a text and keeps its letters and digits only
+func normalizeEnum(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, text)
+}
+
+// editDistance returns the Levenshtein distance between two texts
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// patternMatch returns the variant of a text matching a pattern: trimmed, without spaces, or in
+// upper or lower case
+func patternMatch(text, pattern string) (interface{}, bool) {
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false
+	}
+	trimmed := strings.TrimSpace(text)
+	compact := strings.Join(strings.Fields(trimmed), "")
+	for _, candidate := range []string{trimmed, compact, strings.ToUpper(trimmed), strings.ToUpper(compact), strings.ToLower(trimmed), strings.ToLower(compact)} {
+		if expression.MatchString(candidate) {
+			return candidate, true
+		}
+	}
+	return nil, false
+}
+
+// schemaAtValuePath returns the schema of the value at a path of the data, following the
+// properties of objects and the items of arrays
+func schemaAtValuePath(schemaData map[string]interface{}, path string) map[string]interface{} {
+	current := schemaData
+	if path == "" {
+		return current
+	}
+	for _, key := range strings.Split(path, ".") {
+		var child map[string]interface{}
+		if _, err := strconv.Atoi(key); err == nil {
+			child, _ = current["items"].(map[string]interface{})
+		}
+		if child == nil {
+			properties, _ := current["properties"].(map[string]interface{})
+			child, _ = properties[key].(map[string]interface{})
+		}
+		if child == nil {
+			return nil
+		}
+		current = child
+	}
+	return current
+}
+
+// setValuePath replaces the value at a path of the data, where array items are given by index
+func setValuePath(data map[string]interface{}, path string, value interface{}) error {
+	keys := strings.Split(path, ".")
+	var current interface{} = data
+	for i, key := range keys {
+		last := i == len(keys)-1
+		switch container := current.(type) {
+		case map[string]interface{}:
+			if last {
+				container[key] = value
+				return nil
+			}
+			current = container[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(container) {
+				return fmt.Errorf("no value at %q", path)
+			}
+			if last {
+				container[index] = value
+				return nil
+			}
+			current = container[index]
+		default:
+			return fmt.Errorf("no value at %q", path)
+		}
+	}
+	return fmt.Errorf("no value at %q", path)
+}
+
+// violationList describes each violation with its path and value
+func violationList(violations []schema.Violation) []string {
+	list := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		value, _ := json.Marshal(violation.Value)
+		list = append(list, fmt.Sprintf("%s = %s: %s", violation.Path, value, violation.Description))
+	}
+	return list
+}
+
+// appendMessages returns a copy of the messages of a request with more messages appended.
+// Messages are maps, or generic values once a request went through JSON.
+func appendMessages(messages interface{}, more ...map[string]interface{}) interface{} {
+	switch messages := messages.(type) {
+	case []map[string]interface{}:
+		return append(append(make([]map[string]interface{}, 0, len(messages)+len(more)), messages...), more...)
+	case []interface{}:
+		list := append(make([]interface{}, 0, len(messages)+len(more)), messages...)
+		for _, message := range more {
+			list = append(list, message)
+		}
+		return list
+	default:
+		return messages
+	}
+}
@@ -0,0 +1,240 @@
+package extractor
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	// defaultOutputReserve is the number of tokens kept free for the response when MaxTokens is not set
+	defaultOutputReserve = 4096
+	// minTruncatedTokens is the smallest text, in tokens, worth sending after truncation
+	minTruncatedTokens = 256
+	// messageTokens is the overhead of each message of a chat completion
+	messageTokens = 4
+)
+
+// contextWindows are the context windows, in tokens, of known models by model name prefix
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo": 16385,
+	"gpt-4":         8192,
+	"gpt-4-turbo":   128000,
+	"gpt-4o":        128000,
+	"gpt-4.1":       1047576,
+	"gpt-5":         400000,
+	"o1":            200000,
+	"o1-mini":       128000,
+	"o3":            200000,
+	"o4-mini":       200000,
+}
+
+// ErrContextLengthExceeded is returned, wrapped in a *ContextLengthError, when a request does not
+// fit in the context window of the model
+var ErrContextLengthExceeded = errors.New("context length exceeded")
+
+// ContextLengthError reports a request that does not fit in the context window of the model. It
+// matches ErrContextLengthExceeded with errors.Is.
+type ContextLengthError struct {
+	// Model is the model of the request
+	Model string
+	// Tokens is the estimated size of the request, including the tokens reserved for the response
+	Tokens int
+	// Limit is the context window of the model
+	Limit int
+}
+
+// Error describes the measured size and the limit
+func (e *ContextLengthError) Error() string {
+	return fmt.Sprintf("%s: request needs about %d tokens but %s has a context window of %d tokens", ErrContextLengthExceeded, e.Tokens, e.Model, e.Limit)
+}
+
+// Unwrap returns ErrContextLengthExceeded
+func (e *ContextLengthError) Unwrap() error {
+	return ErrContextLengthExceeded
+}
+
+// contextWindow returns the context window of a model: the configured one, or the one of the
+// longest known prefix of the model name. It returns 0 for unknown models, which are not checked.
+func (e *Extractor) contextWindow(model string) int {
+	if e.config.ContextWindow > 0 {
+		return e.config.ContextWindow
+	}
+	window, prefixLength := 0, 0
+	for prefix, size := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > prefixLength {
+			window, prefixLength = size, len(prefix)
+		}
+	}
+	return window
+}
+
+// estimateTokens estimates the number of tokens of a text without the model's tokenizer: about
+// four characters per token for alphabetic scripts and a token per character for CJK scripts
+func estimateTokens(text string) int {
+	tokens := 0.0
+	for _, r := range text {
+		tokens += runeTokens(r)
+	}
+	return int(math.Ceil(tokens))
+}
+
+// runeTokens estimates the share of a token taken by a character
+func runeTokens(r rune) float64 {
+	if r >= 0x2E80 {
+		return 1
+	}
+	return 0.25
+}
+
+// estimateRequestTokens estimates the number of prompt tokens of a chat completion request,
+// including its response format
+func estimateRequestTokens(requestBody map[string]interface{}) int {
+	tokens := 0
+	if format, ok := requestBody["response_format"]; ok {
+		if data, err := json.Marshal(format); err == nil {
+			tokens += estimateTokens(string(data))
+		}
+	}
+
+	messages, _ := requestBody["messages"].([]map[string]interface{})
+	for _, message := range messages {
+		tokens += messageTokens
+		switch content := message["content"].(type) {
+		case string:
+			tokens += estimateTokens(content)
+		case []map[string]interface{}:
+			for _, part := range content {
+				switch part["type"] {
+				case "text":
+					text, _ := part["text"].(string)
+					tokens += estimateTokens(text)
+				case "image_url":
+					image, _ := part["image_url"].(map[string]interface{})
+					url, _ := image["url"].(string)
+					if image["detail"] == types.ImageDetailLow {
+						tokens += lowDetailImageTokens
+						break
+					}
+					tokens += imageTokens(url)
+				}
+			}
+		}
+	}
+	return tokens
+}
+
+// lowDetailImageTokens are the tokens of an image sent at low detail, whatever its size
+const lowDetailImageTokens = 85
+
+// imageTokens estimates the tokens of an image sent at high detail: the image is scaled to fit in
+// 2048x2048, then its shortest side to 768 pixels, and costs 170 tokens per 512-pixel tile plus 85
+func imageTokens(dataURL string) int {
+	width, height, ok := pngSize(dataURL)
+	if !ok {
+		// Assume a page rendered on four tiles
+		return lowDetailImageTokens + 170*4
+	}
+	scale := min(1, 2048/max(width, height))
+	width, height = width*scale, height*scale
+	scale = min(1, 768/min(width, height))
+	width, height = width*scale, height*scale
+	tiles := math.Ceil(width/512) * math.Ceil(height/512)
+	return lowDetailImageTokens + 170*int(tiles)
+}
+
+// pngSize reads the size of a base64 PNG data URL from its header
+func pngSize(dataURL string) (width, height float64, ok bool) {
+	_, encoded, found := strings.Cut(dataURL, ";base64,")
+	if !found || len(encoded) < 32 {
+		return 0, 0, false
+	}
+	// The signature and the IHDR chunk holding the size take the first 24 bytes
+	header, err := base64.StdEncoding.DecodeString(encoded[:32])
+	if err != nil || string(header[12:16]) != "IHDR" {
+		return 0, 0, false
+	}
+	width = float64(binary.BigEndian.Uint32(header[16:20]))
+	height = float64(binary.BigEndian.Uint32(header[20:24]))
+	return width, height, width > 0 && height > 0
+}
+
+// truncateText shortens text to about budget tokens, keeping its beginning and its end, which
+// usually hold the parties, references and totals of a document, and cutting at line breaks. It
+// returns the text and the number of characters omitted.
+func truncateText(text string, budget int) (string, int) {
+	headBudget := float64(budget) * 2 / 3
+	tailBudget := float64(budget) - headBudget
+
+	head, tokens := len(text), 0.0
+	for i, r := range text {
+		if tokens += runeTokens(r); tokens > headBudget {
+			head = i
+			break
+		}
+	}
+	tail, tokens := len(text), 0.0
+	for tail > head {
+		r, size := utf8.DecodeLastRuneInString(text[:tail])
+		if tokens += runeTokens(r); tokens > tailBudget {
+			break
+		}
+		tail -= size
+	}
+
+	if tail <= head {
+		return text, 0
+	}
+
+	// Cut at line breaks when they are not too far
+	if i := strings.LastIndex(text[:head], "\n"); i > head/2 {
+		head = i
+	}
+	if i := strings.Index(text[tail:], "\n"); i >= 0 && i < (len(text)-tail)/2 {
+		tail += i + 1
+	}
+
+	omitted := utf8.RuneCountInString(text[head:tail])
+	return fmt.Sprintf("%s\n\n[... %d characters omitted to fit the context window ...]\n\n%s", text[:head], omitted, text[tail:]), omitted
+}
+
+// fitContext checks that a request fits in the context window of its model, along with the tokens
+// reserved for the response. When it does not, the text of text requests is truncated if
+// TruncateToFit is set, and a *ContextLengthError is returned otherwise.
+func (e *Extractor) fitContext(request *types.ModelRequest, parsedPdf *types.ParsedPdf, options types.ExtractionOptions) error {
+	model, _ := request.Body["model"].(string)
+	limit := e.contextWindow(model)
+	if limit == 0 {
+		return nil
+	}
+	reserve := defaultOutputReserve
+	if options.MaxTokens != nil {
+		reserve = *options.MaxTokens
+	}
+	tokens := estimateRequestTokens(request.Body) + reserve
+	if tokens <= limit {
+		return nil
+	}
+
+	contextErr := &ContextLengthError{Model: model, Tokens: tokens, Limit: limit}
+	if !options.TruncateToFit || parsedPdf.Content.Type != "text" {
+		return contextErr
+	}
+	text := parsedPdf.Content.TextContent
+	budget := estimateTokens(text) - (tokens - limit)
+	if budget < minTruncatedTokens {
+		return contextErr
+	}
+
+	truncated, omitted := truncateText(text, budget)
+	request.Body = e.textRequest(truncated, options.Schema, options)
+	request.Warnings = append(request.Warnings, fmt.Sprintf("text truncated to fit the context window of %s (%d tokens): %d characters omitted from the middle of the document", model, limit, omitted))
+	return nil
+}
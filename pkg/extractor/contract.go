@@ -0,0 +1,206 @@
+package extractor
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const contractInstructions = "The document is a legal contract. Cite the pages every item is stated on. " +
+	"Identify the parties and their roles, the effective date, the termination or expiry date and the renewal terms (give dates as YYYY-MM-DD and use an empty value with no pages when a term is not stated). " +
+	"Classify every clause into one of the listed types, using other when none fits, and list the obligations each party takes on with their deadlines."
+
+// clauseTypes are the clause categories offered to the model
+var clauseTypes = []types.ClauseType{
+	types.ClauseConfidentiality,
+	types.ClauseTermination,
+	types.ClauseRenewal,
+	types.ClausePayment,
+	types.ClauseLiability,
+	types.ClauseIndemnification,
+	types.ClauseIntellectualProperty,
+	types.ClauseWarranty,
+	types.ClauseNonCompete,
+	types.ClauseDataProtection,
+	types.ClauseAssignment,
+	types.ClauseForceMajeure,
+	types.ClauseGoverningLaw,
+	types.ClauseDisputeResolution,
+	types.ClauseOther,
+}
+
+var pagesProperty = map[string]interface{}{
+	"type":  "array",
+	"items": map[string]interface{}{"type": "integer"},
+}
+
+// contractTermSchema is the JSON schema of a contract term with its page citations
+var contractTermSchema = strictObject(map[string]interface{}{
+	"value": stringProperty,
+	"pages": pagesProperty,
+})
+
+// contractSchema is the built-in JSON schema used for contract analysis
+var contractSchema = strictObject(map[string]interface{}{
+	"parties": map[string]interface{}{
+		"type": "array",
+		"items": strictObject(map[string]interface{}{
+			"name":  stringProperty,
+			"role":  stringProperty,
+			"pages": pagesProperty,
+		}),
+	},
+	"effectiveDate":   contractTermSchema,
+	"terminationDate": contractTermSchema,
+	"renewalTerms":    contractTermSchema,
+	"clauses": map[string]interface{}{
+		"type": "array",
+		"items": strictObject(map[string]interface{}{
+			"type":    map[string]interface{}{"type": "string", "enum": clauseTypes},
+			"title":   stringProperty,
+			"summary": stringProperty,
+			"pages":   pagesProperty,
+		}),
+	},
+	"obligations": map[string]interface{}{
+		"type": "array",
+		"items": strictObject(map[string]interface{}{
+			"party":       stringProperty,
+			"description": stringProperty,
+			"deadline":    stringProperty,
+			"pages":       pagesProperty,
+		}),
+	},
+})
+
+// AnalyzeContract extracts parties, effective and termination dates, renewal terms, classified
+// clauses and obligations from a contract given as a file path or a byte slice. Every item cites
+// the pages it is stated on. Long contracts are split into chunks at page and paragraph
+// boundaries, or at the sections of their outline with ChunkByOutline, analyzed chunk by chunk and
+// merged.
+func (e *Extractor) AnalyzeContract(ctx context.Context, pdf interface{}, options types.ContractOptions) (*types.ContractResult, error) {
+	extractionOptions, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	extractionOptions.Schema = contractSchema
+	extractionOptions.Instructions = contractInstructions
+	extractionOptions.Outline = options.ChunkByOutline
+
+	// The chunks make a single extraction, recorded, saved and published with the merged contract
+	contract := &types.ContractResult{}
+	_, err = e.tracked(ctx, extractionOptions, func(ctx context.Context) (*types.ExtractionResult, error) {
+		parsedPdf, err := e.parse(ctx, extractionOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		merge := func(result *types.ExtractionResult) error {
+			var part types.ContractResult
+			if err := decodeData(result.Data, &part); err != nil {
+				return err
+			}
+			mergeContract(contract, &part)
+			contract.TokensUsed += result.TokensUsed
+			contract.Model = result.Model
+			return nil
+		}
+
+		progress, err := e.extractChunks(ctx, parsedPdf, extractionOptions, options.ChunkSize, options.ImagePagesPerChunk, options.ChunkByOutline, merge)
+		if err == nil {
+			err = progress.err()
+		}
+		if err != nil {
+			return nil, err
+		}
+		contract.Chunks, contract.FailedChunks, contract.Completeness = progress.chunks, progress.failures, progress.completeness()
+		return chunkedResult(contract, contract.TokensUsed, contract.Model, nil, extractionOptions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// mergeContract merges the analysis of a contract chunk into the contract, deduplicating parties,
+// clauses and obligations reported by several chunks
+func mergeContract(contract, part *types.ContractResult) {
+	for _, party := range part.Parties {
+		i := indexOf(len(contract.Parties), func(i int) bool {
+			return strings.EqualFold(contract.Parties[i].Name, party.Name)
+		})
+		if i < 0 {
+			contract.Parties = append(contract.Parties, party)
+			continue
+		}
+		if contract.Parties[i].Role == "" {
+			contract.Parties[i].Role = party.Role
+		}
+		contract.Parties[i].Pages = mergePages(contract.Parties[i].Pages, party.Pages)
+	}
+
+	mergeTerm(&contract.EffectiveDate, part.EffectiveDate)
+	mergeTerm(&contract.TerminationDate, part.TerminationDate)
+	mergeTerm(&contract.RenewalTerms, part.RenewalTerms)
+
+	for _, clause := range part.Clauses {
+		i := indexOf(len(contract.Clauses), func(i int) bool {
+			return contract.Clauses[i].Type == clause.Type && strings.EqualFold(contract.Clauses[i].Title, clause.Title)
+		})
+		if i < 0 {
+			contract.Clauses = append(contract.Clauses, clause)
+			continue
+		}
+		contract.Clauses[i].Pages = mergePages(contract.Clauses[i].Pages, clause.Pages)
+	}
+
+	for _, obligation := range part.Obligations {
+		i := indexOf(len(contract.Obligations), func(i int) bool {
+			return strings.EqualFold(contract.Obligations[i].Party, obligation.Party) &&
+				strings.EqualFold(contract.Obligations[i].Description, obligation.Description)
+		})
+		if i < 0 {
+			contract.Obligations = append(contract.Obligations, obligation)
+			continue
+		}
+		contract.Obligations[i].Pages = mergePages(contract.Obligations[i].Pages, obligation.Pages)
+	}
+}
+
+// mergeTerm keeps the first stated value of a term, adding the pages that restate it
+func mergeTerm(term *types.ContractTerm, part types.ContractTerm) {
+	switch {
+	case part.Value == "":
+	case term.Value == "":
+		*term = part
+	case strings.EqualFold(term.Value, part.Value):
+		term.Pages = mergePages(term.Pages, part.Pages)
+	}
+}
+
+// mergePages returns the sorted union of two page lists
+func mergePages(a, b []int) []int {
+	seen := make(map[int]bool, len(a)+len(b))
+	pages := make([]int, 0, len(a)+len(b))
+	for _, page := range append(append([]int{}, a...), b...) {
+		if !seen[page] {
+			seen[page] = true
+			pages = append(pages, page)
+		}
+	}
+	sort.Ints(pages)
+	return pages
+}
+
+// indexOf returns the first index below n for which match is true, or -1
+func indexOf(n int, match func(i int) bool) int {
+	for i := 0; i < n; i++ {
+		if match(i) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,158 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	// maxCheckedStringLength is the longest string value checked against the OCR text, longer ones
+	// being descriptions rather than numbers or identifiers
+	maxCheckedStringLength = 64
+	// numberTolerance is the difference below which an extracted number matches a number of the text
+	numberTolerance = 1e-6
+)
+
+// crossCheck returns the dot-separated paths of the numbers and identifiers of the data that do
+// not literally appear in the text recognized by OCR on the pages, sorted, along with a warning
+// for each of them
+func (e *Extractor) crossCheck(ctx context.Context, parsedPdf *types.ParsedPdf, data map[string]interface{}, options types.ExtractionOptions) ([]string, []string, error) {
+	text, err := e.crossCheckText(ctx, parsedPdf, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if parsedPdf.Content.Type == "mixed" {
+		// The text layer of the digital pages is exact
+		text += "\n" + strings.Join(parsedPdf.Content.TextPages, "\n")
+	}
+
+	compact := compactText(text)
+	// OCR text may come from documents of either convention
+	numbers := append(parser.ExtractNumbers(text, "."), parser.ExtractNumbers(text, ",")...)
+	var unverified []string
+	warnings := make(map[string]string)
+	walkValues(data, "", func(path string, value interface{}) {
+		switch value := value.(type) {
+		case float64:
+			if !numberFound(numbers, value) {
+				unverified = append(unverified, path)
+				warnings[path] = fmt.Sprintf("%s = %s not found in the OCR text of the pages: it may be misread or made up", path, formatNumber(value))
+			}
+		case string:
+			if isCheckedString(value) && !strings.Contains(compact, compactText(value)) {
+				unverified = append(unverified, path)
+				warnings[path] = fmt.Sprintf("%s = %q not found in the OCR text of the pages: it may be misread or made up", path, value)
+			}
+		}
+	})
+	sort.Strings(unverified)
+	list := make([]string, len(unverified))
+	for i, path := range unverified {
+		list[i] = warnings[path]
+	}
+	return unverified, list, nil
+}
+
+// crossCheckText recognizes the text of the pages with the cross-check backend, or returns the
+// text of the OCR backend read while parsing
+func (e *Extractor) crossCheckText(ctx context.Context, parsedPdf *types.ParsedPdf, options types.ExtractionOptions) (string, error) {
+	name := e.config.CrossCheckBackend
+	if name == "" {
+		if parsedPdf.Content.OCRText != "" {
+			return parsedPdf.Content.OCRText, nil
+		}
+		name = e.config.OCRBackend
+	}
+	if name == "" {
+		return "", errors.New("an OCR backend is required: set ExtractorConfig.CrossCheckBackend")
+	}
+	backend, ok := parser.LookupBackend(name)
+	if !ok {
+		return "", fmt.Errorf("unknown OCR backend %q", name)
+	}
+	recognizer, ok := backend.(parser.TextExtractor)
+	if !ok {
+		return "", fmt.Errorf("backend %q cannot extract text", name)
+	}
+
+	buffer, err := readPdf(options)
+	if err != nil {
+		return "", err
+	}
+	pages, err := e.recognizeText(ctx, buffer, name, recognizer)
+	if err != nil {
+		return "", fmt.Errorf("backend %s failed: %w", name, err)
+	}
+	return strings.Join(pages, "\n"), nil
+}
+
+// walkValues calls visit with every scalar and null of the data and its dot-separated path, where
+// array items are given by index
+func walkValues(value interface{}, path string, visit func(path string, value interface{})) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			walkValues(child, join(key), visit)
+		}
+	case []interface{}:
+		for i, child := range value {
+			walkValues(child, join(strconv.Itoa(i)), visit)
+		}
+	case bool:
+	default:
+		visit(path, value)
+	}
+}
+
+// isCheckedString reports whether a string value is an identifier or a figure to find in the text:
+// a short string with digits that is not a date, which models reformat
+func isCheckedString(value string) bool {
+	if len(value) > maxCheckedStringLength || !strings.ContainsFunc(value, unicode.IsDigit) {
+		return false
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339, time.DateTime} {
+		if _, err := time.Parse(layout, value); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// compactText returns the lowercased letters and digits of a text, so that values match the text
+// regardless of spacing and punctuation, e.g. "INV 001" and "inv-001"
+func compactText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, text)
+}
+
+// numberFound reports whether a number is among the numbers of the text, regardless of its sign,
+// or is a percentage of the text given as a fraction
+func numberFound(numbers []float64, value float64) bool {
+	value = math.Abs(value)
+	for _, number := range numbers {
+		if math.Abs(number-value) < numberTolerance || math.Abs(number-value*100) < numberTolerance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// dateLayouts are the layouts extracted dates are read with
+var dateLayouts = []string{time.DateOnly, time.RFC3339, time.DateTime, "2006-01-02T15:04:05"}
+
+// enforceDateRules checks the dates of the extracted data against the date rules of the options.
+// With DateRulePolicyRetry, the model is first asked once to read the dates breaking the rules
+// again. The dates still breaking them are reported as warnings.
+func (e *Extractor) enforceDateRules(ctx context.Context, request *types.ModelRequest, result *types.ExtractionResult, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	if len(options.DateRules) == 0 {
+		return result, nil
+	}
+	switch options.DateRulePolicy {
+	case "", types.DateRulePolicyWarn, types.DateRulePolicyRetry:
+	default:
+		return nil, fmt.Errorf("unknown date rule policy %q", options.DateRulePolicy)
+	}
+
+	violations := dateViolations(result.Data, options.DateRules, time.Now())
+	if len(violations) > 0 && options.DateRulePolicy == types.DateRulePolicyRetry {
+		corrected, err := e.correct(ctx, request, result, "dates", "Some dates of your answer are implausible:\n- "+
+			strings.Join(violationList(violations), "\n- ")+"\nRead these dates in the document again and answer with the corrected JSON object.")
+		if err != nil {
+			return nil, fmt.Errorf("failed to correct dates: %w", err)
+		}
+		result = corrected
+		violations = dateViolations(result.Data, options.DateRules, time.Now())
+	}
+	for _, violation := range violationList(violations) {
+		result.Warnings = append(result.Warnings, "implausible date "+violation)
+	}
+	return result, nil
+}
+
+// dateViolations returns the dates of the data breaking the rules at a time
+func dateViolations(data map[string]interface{}, rules []types.DateRule, now time.Time) []schema.Violation {
+	var violations []schema.Violation
+	for _, rule := range rules {
+		var after time.Time
+		if rule.After != "" {
+			value, _ := dataAtPath(data, rule.After)
+			text, _ := value.(string)
+			after, _ = parseDate(text)
+		}
+		walkValues(data, "", func(path string, value interface{}) {
+			text, ok := value.(string)
+			if !ok || !fieldMatches(path, rule.Field) {
+				return
+			}
+			violation := schema.Violation{Path: path, Keyword: "date", Value: value}
+			date, err := parseDate(text)
+			switch {
+			case err != nil:
+				violation.Description = "not an ISO 8601 date"
+			case rule.MaxAge > 0 && date.Before(now.Add(-rule.MaxAge)):
+				violation.Description = fmt.Sprintf("more than %s in the past", formatWindow(rule.MaxAge))
+			case rule.MaxAhead > 0 && date.After(now.Add(rule.MaxAhead)):
+				violation.Description = fmt.Sprintf("more than %s in the future", formatWindow(rule.MaxAhead))
+			case !after.IsZero() && date.Before(after):
+				violation.Description = fmt.Sprintf("before %s (%s)", rule.After, after.Format(time.DateOnly))
+			default:
+				return
+			}
+			violations = append(violations, violation)
+		})
+	}
+	return violations
+}
+
+// fieldMatches reports whether the path of a value matches the path of a rule, where "*" stands
+// for any array item
+func fieldMatches(path, field string) bool {
+	keys, pattern := strings.Split(path, "."), strings.Split(field, ".")
+	if len(keys) != len(pattern) {
+		return false
+	}
+	for i, key := range pattern {
+		if key != "*" && key != keys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDate reads an ISO 8601 date or date-time
+func parseDate(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, text); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", text)
+}
+
+// formatWindow formats a window of time in days, or in years when it is a number of years
+func formatWindow(window time.Duration) string {
+	const day, year = 24 * time.Hour, 365 * 24 * time.Hour
+	switch {
+	case window%year == 0:
+		return fmt.Sprintf("%d years", window/year)
+	case window >= day:
+		return fmt.Sprintf("%d days", window/day)
+	}
+	return window.String()
+}
@@ -0,0 +1,73 @@
+package extractor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// flight is a model call in progress, shared by the identical requests made meanwhile
+type flight struct {
+	done chan struct{}
+	body []byte
+	err  error
+	// waiters is the number of callers waiting for the call, which is canceled when they all left
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// requestHash identifies a serialized request along with its idempotency key
+func requestHash(jsonData []byte, idempotencyKey string) string {
+	hash := sha256.New()
+	hash.Write(jsonData)
+	hash.Write([]byte{0})
+	hash.Write([]byte(idempotencyKey))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// coalesce runs call, unless a call with the same key is already in progress, in which case it
+// waits for that call and returns its result. The call runs with the values of the context of its
+// first caller but without its cancellation, so that a caller giving up does not fail the others:
+// each caller stops waiting when its ctx is done, and the call is canceled once every caller has.
+func (e *Extractor) coalesce(ctx context.Context, key string, call func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	e.inflightMu.Lock()
+	current, ok := e.inflight[key]
+	if ok {
+		current.waiters++
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		current = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		if e.inflight == nil {
+			e.inflight = make(map[string]*flight)
+		}
+		e.inflight[key] = current
+		go func() {
+			defer cancel()
+			current.body, current.err = call(callCtx)
+			e.inflightMu.Lock()
+			if e.inflight[key] == current {
+				delete(e.inflight, key)
+			}
+			e.inflightMu.Unlock()
+			close(current.done)
+		}()
+	}
+	e.inflightMu.Unlock()
+
+	select {
+	case <-current.done:
+		return current.body, current.err
+	case <-ctx.Done():
+		e.inflightMu.Lock()
+		current.waiters--
+		if current.waiters == 0 {
+			// Later identical requests make a new call rather than share the canceled one
+			current.cancel()
+			if e.inflight[key] == current {
+				delete(e.inflight, key)
+			}
+		}
+		e.inflightMu.Unlock()
+		return nil, ctx.Err()
+	}
+}
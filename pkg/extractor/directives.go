@@ -0,0 +1,356 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// Schema extension keywords of the field directives, interpreted by the extractor and left out of
+// the schema sent to the model
+const (
+	formatKeyword   = "x-format"
+	languageKeyword = "x-language"
+	unitKeyword     = "x-unit"
+)
+
+// centsUnit is the x-unit of amounts in minor units of their currency
+const centsUnit = "cents"
+
+// fieldFormat is a value of x-format: how the model is told to write the values, how they are
+// checked, and how the values written otherwise are rewritten when the intended value is obvious
+type fieldFormat struct {
+	description string
+	valid       func(text string) bool
+	// normalize is optional
+	normalize func(text string) (string, bool)
+}
+
+// fieldFormats are the values of x-format
+var fieldFormats = map[string]fieldFormat{
+	"date":       {"a date as YYYY-MM-DD", layoutValid(time.DateOnly), normalizeDate},
+	"date-time":  {"a date and time as YYYY-MM-DDTHH:MM:SS with the UTC offset, e.g. 2024-01-31T14:30:00+01:00", layoutValid(time.RFC3339), nil},
+	"time":       {"a time of day as HH:MM, on 24 hours", layoutValid("15:04"), normalizeTime},
+	"year-month": {"a month as YYYY-MM", layoutValid("2006-01"), nil},
+	"uppercase":  {"in uppercase", func(text string) bool { return text == strings.ToUpper(text) }, func(text string) (string, bool) { return strings.ToUpper(text), true }},
+	"lowercase":  {"in lowercase", func(text string) bool { return text == strings.ToLower(text) }, func(text string) (string, bool) { return strings.ToLower(text), true }},
+	"currency":   {"an ISO 4217 currency code, e.g. EUR", isCurrencyCode, normalizeCurrency},
+}
+
+// dateInputLayouts are the unambiguous layouts of dates rewritten as YYYY-MM-DD
+var dateInputLayouts = []string{time.RFC3339, time.DateTime, "2006-01-02T15:04:05", "2006/01/02", "2006.01.02", "2 January 2006", "January 2, 2006", "2 Jan 2006", "Jan 2, 2006"}
+
+// languageNames are the names of common languages by ISO 639-1 code
+var languageNames = map[string]string{
+	"ar": "Arabic", "de": "German", "en": "English", "es": "Spanish", "fr": "French", "it": "Italian", "ja": "Japanese",
+	"ko": "Korean", "nl": "Dutch", "pl": "Polish", "pt": "Portuguese", "ru": "Russian", "sv": "Swedish", "zh": "Chinese",
+}
+
+// scriptTables are the Unicode scripts by ISO 15924 code
+var scriptTables = map[string]string{
+	"Arab": "Arabic", "Cyrl": "Cyrillic", "Deva": "Devanagari", "Grek": "Greek", "Hang": "Hangul", "Hani": "Han",
+	"Hebr": "Hebrew", "Hira": "Hiragana", "Kana": "Katakana", "Latn": "Latin", "Thai": "Thai",
+}
+
+// fieldDirective is the set of directives of a field of a schema
+type fieldDirective struct {
+	// path is the dot-separated path of the field, where "*" stands for any array item
+	path   string
+	format string
+	// language is the language of x-language, and script its script subtag or a script alone
+	language, script string
+	unit             string
+}
+
+// schemaDirectives returns the field directives of a schema, sorted by path. It fails on unknown
+// formats and scripts.
+func schemaDirectives(schemaData map[string]interface{}) ([]fieldDirective, error) {
+	directives := make([]fieldDirective, 0)
+	var walk func(node map[string]interface{}, path string) error
+	walk = func(node map[string]interface{}, path string) error {
+		directive := fieldDirective{path: path}
+		if format, ok := node[formatKeyword]; ok {
+			name, _ := format.(string)
+			if _, known := fieldFormats[name]; !known {
+				return fmt.Errorf("unknown %s %v of %s", formatKeyword, format, fieldName(path))
+			}
+			directive.format = name
+		}
+		if language, ok := node[languageKeyword]; ok {
+			tag, _ := language.(string)
+			var err error
+			if directive.language, directive.script, err = parseLanguageTag(tag); err != nil {
+				return fmt.Errorf("invalid %s of %s: %w", languageKeyword, fieldName(path), err)
+			}
+		}
+		if unit, ok := node[unitKeyword]; ok {
+			if directive.unit, _ = unit.(string); strings.TrimSpace(directive.unit) == "" {
+				return fmt.Errorf("invalid %s %v of %s", unitKeyword, unit, fieldName(path))
+			}
+		}
+		if directive != (fieldDirective{path: path}) {
+			directives = append(directives, directive)
+		}
+
+		join := func(key string) string {
+			if path == "" {
+				return key
+			}
+			return path + "." + key
+		}
+		properties, _ := node["properties"].(map[string]interface{})
+		for name, property := range properties {
+			if property, ok := property.(map[string]interface{}); ok {
+				if err := walk(property, join(name)); err != nil {
+					return err
+				}
+			}
+		}
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			if err := walk(items, join("*")); err != nil {
+				return err
+			}
+		}
+		for _, key := range []string{"anyOf", "oneOf"} {
+			variants, _ := node[key].([]interface{})
+			for _, variant := range variants {
+				if variant, ok := variant.(map[string]interface{}); ok {
+					if err := walk(variant, path); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(schemaData, ""); err != nil {
+		return nil, err
+	}
+	sort.Slice(directives, func(i, j int) bool { return directives[i].path < directives[j].path })
+	return directives, nil
+}
+
+// parseLanguageTag reads a BCP 47 language tag, such as "de" or "sr-Latn", or an ISO 15924 script
+// alone, such as "Latn"
+func parseLanguageTag(tag string) (language, script string, err error) {
+	for i, part := range strings.Split(tag, "-") {
+		switch {
+		case len(part) == 4 && unicode.IsUpper(rune(part[0])):
+			if _, ok := scriptTables[part]; !ok {
+				return "", "", fmt.Errorf("unknown script %q", part)
+			}
+			script = part
+		case i == 0 && (len(part) == 2 || len(part) == 3):
+			language = strings.ToLower(part)
+		case i == 0:
+			return "", "", fmt.Errorf("invalid language tag %q", tag)
+		}
+	}
+	return language, script, nil
+}
+
+// describe describes the directives of a field for the prompt
+func (d fieldDirective) describe() string {
+	parts := make([]string, 0, 3)
+	if d.format != "" {
+		parts = append(parts, fieldFormats[d.format].description)
+	}
+	switch {
+	case d.unit == centsUnit:
+		parts = append(parts, "an amount in cents, i.e. in the minor unit of its currency, as an integer, e.g. 1250 for 12.50")
+	case d.unit != "":
+		parts = append(parts, fmt.Sprintf("in %s, converted if the document uses another unit", d.unit))
+	}
+	if d.language != "" {
+		name := languageNames[d.language]
+		if name == "" {
+			name = fmt.Sprintf("the language %q", d.language)
+		}
+		parts = append(parts, fmt.Sprintf("written in %s, translated if the document uses another language", name))
+	}
+	if d.script != "" {
+		parts = append(parts, fmt.Sprintf("in %s script, transliterated if the document uses another script", scriptTables[d.script]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// directiveInstructions tells the model about the directives of the fields of a schema
+func directiveInstructions(directives []fieldDirective) string {
+	var b strings.Builder
+	b.WriteString("Write the values of these fields as follows:")
+	for _, directive := range directives {
+		fmt.Fprintf(&b, "\n- %s: %s", fieldName(directive.path), directive.describe())
+	}
+	return b.String()
+}
+
+// withoutDirectives returns a copy of a schema without the keywords of the field directives, which
+// structured output does not accept
+func withoutDirectives(node interface{}) interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		stripped := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			if key != formatKeyword && key != languageKeyword && key != unitKeyword {
+				stripped[key] = withoutDirectives(value)
+			}
+		}
+		return stripped
+	case []interface{}:
+		stripped := make([]interface{}, len(node))
+		for i, value := range node {
+			stripped[i] = withoutDirectives(value)
+		}
+		return stripped
+	default:
+		return node
+	}
+}
+
+// enforceDirectives checks the extracted data of a request against the field directives of its
+// schema. Values written otherwise are rewritten when the intended value is obvious, e.g. a date
+// as "2024/01/31"; with FieldDirectivePolicyRetry, the model is then asked once to fix the others.
+// The values still breaking a directive are reported as warnings.
+func (e *Extractor) enforceDirectives(ctx context.Context, request *types.ModelRequest, result *types.ExtractionResult, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	switch options.FieldDirectivePolicy {
+	case "", types.FieldDirectivePolicyWarn, types.FieldDirectivePolicyRetry:
+	default:
+		return nil, fmt.Errorf("unknown field directive policy %q", options.FieldDirectivePolicy)
+	}
+	directives, err := schemaDirectives(request.Schema)
+	if err != nil || len(directives) == 0 {
+		return result, err
+	}
+
+	violations, warnings := directiveViolations(result.Data, directives)
+	if len(violations) > 0 && options.FieldDirectivePolicy == types.FieldDirectivePolicyRetry {
+		corrected, err := e.correct(ctx, request, result, "directives", "Some values of your answer are not written as requested:\n- "+
+			strings.Join(violationList(violations), "\n- ")+"\nAnswer with the corrected JSON object.")
+		if err != nil {
+			return nil, fmt.Errorf("failed to correct field directives: %w", err)
+		}
+		result = corrected
+		violations, warnings = directiveViolations(result.Data, directives)
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	for _, violation := range violationList(violations) {
+		result.Warnings = append(result.Warnings, "misformatted value "+violation)
+	}
+	return result, nil
+}
+
+// directiveViolations rewrites in place the values of data breaking a directive when the intended
+// value is obvious, and returns the violations left and a warning for every value rewritten,
+// sorted. Null values are not checked.
+func directiveViolations(data map[string]interface{}, directives []fieldDirective) ([]schema.Violation, []string) {
+	var violations []schema.Violation
+	var warnings []string
+	changes := make(map[string]interface{})
+	walkValues(data, "", func(path string, value interface{}) {
+		for _, directive := range directives {
+			if !fieldMatches(path, directive.path) {
+				continue
+			}
+			violation := schema.Violation{Path: path, Value: value}
+			text, isText := value.(string)
+			format := fieldFormats[directive.format]
+			switch {
+			case directive.format != "" && isText && !format.valid(text):
+				if format.normalize != nil {
+					if normalized, ok := format.normalize(text); ok && format.valid(normalized) {
+						changes[path] = normalized
+						warnings = append(warnings, fmt.Sprintf("%s = %q rewritten as %q", path, text, normalized))
+						text = normalized
+						break
+					}
+				}
+				violation.Keyword, violation.Description = formatKeyword, "not "+format.description
+				violations = append(violations, violation)
+				continue
+			}
+			if number, ok := value.(float64); ok && directive.unit == centsUnit && number != math.Trunc(number) {
+				violation.Keyword, violation.Description = unitKeyword, "not an integer amount in cents"
+				violations = append(violations, violation)
+				continue
+			}
+			if directive.script != "" && isText && !inScript(text, directive.script) {
+				violation.Keyword, violation.Description = languageKeyword, fmt.Sprintf("not in %s script", scriptTables[directive.script])
+				violations = append(violations, violation)
+			}
+		}
+	})
+	for path, value := range changes {
+		setValuePath(data, path, value)
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	sort.Strings(warnings)
+	return violations, warnings
+}
+
+// fieldName names a field of a directive, or the whole answer for the root
+func fieldName(path string) string {
+	if path == "" {
+		return "the answer"
+	}
+	return path
+}
+
+// layoutValid returns a check that a text is a time in a layout
+func layoutValid(layout string) func(text string) bool {
+	return func(text string) bool {
+		_, err := time.Parse(layout, text)
+		return err == nil
+	}
+}
+
+// normalizeDate rewrites a date of an unambiguous layout as YYYY-MM-DD
+func normalizeDate(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	for _, layout := range append([]string{time.DateOnly}, dateInputLayouts...) {
+		if date, err := time.Parse(layout, text); err == nil {
+			return date.Format(time.DateOnly), true
+		}
+	}
+	return "", false
+}
+
+// normalizeTime rewrites a time with seconds or on 12 hours as HH:MM
+func normalizeTime(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	for _, layout := range []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04 pm", "3:04pm", "15.04"} {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return parsed.Format("15:04"), true
+		}
+	}
+	return "", false
+}
+
+// isCurrencyCode reports whether a text is an ISO 4217 currency code
+func isCurrencyCode(text string) bool {
+	return len(text) == 3 && parser.CurrencyCode(text) == text
+}
+
+// normalizeCurrency rewrites a currency symbol or a code in lowercase as an ISO 4217 code
+func normalizeCurrency(text string) (string, bool) {
+	code := parser.CurrencyCode(strings.TrimSpace(text))
+	return code, code != ""
+}
+
+// inScript reports whether the letters of a text are all of a script
+func inScript(text, script string) bool {
+	table := unicode.Scripts[scriptTables[script]]
+	for _, r := range text {
+		if unicode.IsLetter(r) && !unicode.Is(table, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,214 @@
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// ErrSchemaDrift is returned when the data returned by the model does not match the keys of the
+// schema and ExtractionOptions.SchemaDrift is SchemaDriftFail
+var ErrSchemaDrift = errors.New("schema drift")
+
+// maxReferenceDepth bounds the chains of references and branches resolved, against cycles
+const maxReferenceDepth = 32
+
+// validateSchemaDrift checks the schema drift policy of the options
+func validateSchemaDrift(policy string) error {
+	switch policy {
+	case "", types.SchemaDriftPrune, types.SchemaDriftKeep, types.SchemaDriftFail:
+		return nil
+	default:
+		return fmt.Errorf("unknown schema drift policy %q", policy)
+	}
+}
+
+// checkDrift compares the keys of the data returned by the model with the schema it was asked for,
+// as providers and gateways ignoring strict mode return keys the schema does not have or leave out
+// required ones. Unexpected keys are removed from the data with SchemaDriftPrune, the default, and
+// kept with SchemaDriftKeep; SchemaDriftFail fails with ErrSchemaDrift. It returns nil when the data
+// matches the schema.
+func checkDrift(data, schemaData map[string]interface{}, policy string) (*types.SchemaDrift, error) {
+	if schemaData == nil {
+		return nil, nil
+	}
+	drift := &types.SchemaDrift{}
+	walkDrift(data, schemaData, schemaData, "", drift, policy != types.SchemaDriftKeep && policy != types.SchemaDriftFail)
+	if len(drift.Unexpected) == 0 && len(drift.Missing) == 0 {
+		return nil, nil
+	}
+	sort.Strings(drift.Unexpected)
+	sort.Strings(drift.Missing)
+	if policy == types.SchemaDriftFail {
+		return nil, fmt.Errorf("%w: %s", ErrSchemaDrift, strings.Join(driftWarnings(drift, false), "; "))
+	}
+	return drift, nil
+}
+
+// walkDrift checks the keys of a value and its children against their schema, where the root
+// schema resolves local references. Objects are checked against schemas listing their properties
+// or refusing additional ones; array items are given by index in the paths.
+func walkDrift(value interface{}, node, root map[string]interface{}, path string, drift *types.SchemaDrift, prune bool) {
+	node = resolveDriftSchema(value, node, root)
+	if node == nil {
+		return
+	}
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, listed := node["properties"].(map[string]interface{})
+		additional, _ := node["additionalProperties"].(map[string]interface{})
+		allowed, _ := node["additionalProperties"].(bool)
+		if _, set := node["additionalProperties"]; !set {
+			// Strict mode refuses the keys missing from the properties, which are all that can be judged
+			allowed = !listed
+		}
+		for key, child := range value {
+			switch childSchema, ok := properties[key].(map[string]interface{}); {
+			case ok:
+				walkDrift(child, childSchema, root, join(key), drift, prune)
+			case additional != nil:
+				walkDrift(child, additional, root, join(key), drift, prune)
+			case !allowed:
+				drift.Unexpected = append(drift.Unexpected, join(key))
+				if prune {
+					delete(value, key)
+				}
+			}
+		}
+		for _, key := range requiredKeys(node) {
+			// The status of nullable fields left out defaults to what the data shows
+			if _, ok := value[key]; !ok && join(key) != fieldStatusProperty {
+				drift.Missing = append(drift.Missing, join(key))
+			}
+		}
+	case []interface{}:
+		items, _ := node["items"].(map[string]interface{})
+		if items == nil {
+			return
+		}
+		for i, child := range value {
+			walkDrift(child, items, root, join(strconv.Itoa(i)), drift, prune)
+		}
+	}
+}
+
+// resolveDriftSchema returns the schema a value is checked against: the schema of a local
+// reference, or the branch of anyOf or oneOf of the type of the value. It returns nil when the
+// schema cannot be resolved.
+func resolveDriftSchema(value interface{}, node, root map[string]interface{}) map[string]interface{} {
+	for depth := 0; node != nil && depth < maxReferenceDepth; depth++ {
+		if ref, ok := node["$ref"].(string); ok {
+			node = schemaPointer(root, ref)
+			continue
+		}
+		branches, _ := node["anyOf"].([]interface{})
+		if branches == nil {
+			branches, _ = node["oneOf"].([]interface{})
+		}
+		if branches == nil {
+			return node
+		}
+		var matched map[string]interface{}
+		for _, branch := range branches {
+			if branchSchema, ok := branch.(map[string]interface{}); ok && schemaAccepts(branchSchema, value) {
+				matched = branchSchema
+				break
+			}
+		}
+		node = matched
+	}
+	return nil
+}
+
+// schemaAccepts reports whether the type of a schema, if any, accepts the JSON type of a value
+func schemaAccepts(node map[string]interface{}, value interface{}) bool {
+	var kind string
+	switch value.(type) {
+	case map[string]interface{}:
+		kind = "object"
+	case []interface{}:
+		kind = "array"
+	default:
+		// Scalars have no keys to check
+		return false
+	}
+	switch typed := node["type"].(type) {
+	case string:
+		return typed == kind
+	case []interface{}:
+		for _, item := range typed {
+			if item == kind {
+				return true
+			}
+		}
+		return false
+	default:
+		_, isRef := node["$ref"]
+		return isRef || node["properties"] != nil || node["items"] != nil
+	}
+}
+
+// schemaPointer returns the schema of a local reference, such as "#/$defs/address", or nil
+func schemaPointer(root map[string]interface{}, ref string) map[string]interface{} {
+	if !strings.HasPrefix(ref, "#") {
+		return nil
+	}
+	current := root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		child, ok := current[token].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = child
+	}
+	return current
+}
+
+// requiredKeys returns the required keys of an object schema
+func requiredKeys(node map[string]interface{}) []string {
+	switch required := node["required"].(type) {
+	case []string:
+		return required
+	case []interface{}:
+		keys := make([]string, 0, len(required))
+		for _, key := range required {
+			if name, ok := key.(string); ok {
+				keys = append(keys, name)
+			}
+		}
+		return keys
+	default:
+		return nil
+	}
+}
+
+// driftWarnings describes a schema drift, telling whether unexpected keys were removed
+func driftWarnings(drift *types.SchemaDrift, pruned bool) []string {
+	var warnings []string
+	if len(drift.Unexpected) > 0 {
+		warning := "schema drift: the model returned keys missing from the schema: " + strings.Join(drift.Unexpected, ", ")
+		if pruned {
+			warning += " (removed)"
+		}
+		warnings = append(warnings, warning)
+	}
+	if len(drift.Missing) > 0 {
+		warnings = append(warnings, "schema drift: the model left out required keys: "+strings.Join(drift.Missing, ", "))
+	}
+	return warnings
+}
@@ -0,0 +1,124 @@
+package extractor
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const entityInstructions = "Find every named entity in the document: persons, organizations, dates, monetary amounts and locations. " +
+	"Report each distinct entity once, with its text copied exactly as it appears in the document. " +
+	"For dates, set value to the date in YYYY-MM-DD format; for amounts, set value to the decimal number without currency symbols or thousands separators; otherwise set value to an empty string."
+
+// entitySchema is the built-in JSON schema used for entity extraction
+var entitySchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"entities": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"type": map[string]interface{}{
+						"type": "string",
+						"enum": []string{
+							types.EntityTypePerson,
+							types.EntityTypeOrganization,
+							types.EntityTypeDate,
+							types.EntityTypeAmount,
+							types.EntityTypeLocation,
+						},
+					},
+					"text": map[string]interface{}{
+						"type": "string",
+					},
+					"value": map[string]interface{}{
+						"type": "string",
+					},
+				},
+				"required":             []string{"type", "text", "value"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"entities"},
+	"additionalProperties": false,
+}
+
+// ExtractEntities finds persons, organizations, dates, amounts and locations in a PDF given as a
+// file path or a byte slice. For text-based PDFs each entity carries the offsets of its mentions
+// in the extracted text, which is returned alongside.
+func (e *Extractor) ExtractEntities(ctx context.Context, pdf interface{}) (*types.EntityResult, error) {
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	options.Schema = entitySchema
+	options.Instructions = entityInstructions
+
+	// Parse the PDF here for the text the spans point into, and extract the parsed PDF as any
+	// other extraction, recorded, saved and published. The PDF is kept to identify the document.
+	parsedPdf, err := e.parse(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	options.ParsedPdf = parsedPdf
+
+	result, err := e.ExtractWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Entities []types.Entity
+	}
+	if err := decodeData(result.Data, &data); err != nil {
+		return nil, err
+	}
+
+	text := parsedPdf.Content.TextContent
+	for i := range data.Entities {
+		data.Entities[i].Spans = findSpans(text, data.Entities[i].Text)
+	}
+
+	return &types.EntityResult{
+		Entities:   data.Entities,
+		Text:       text,
+		TokensUsed: result.TokensUsed,
+		Model:      result.Model,
+	}, nil
+}
+
+// findSpans locates every occurrence of needle in text. Whitespace in needle matches any run of
+// whitespace, since the model usually collapses the line breaks of the PDF text layer.
+func findSpans(text, needle string) []types.TextSpan {
+	words := strings.Fields(needle)
+	if text == "" || len(words) == 0 {
+		return nil
+	}
+
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	pattern, err := regexp.Compile(strings.Join(words, `\s+`))
+	if err != nil {
+		return nil
+	}
+
+	matches := pattern.FindAllStringIndex(text, -1)
+	spans := make([]types.TextSpan, 0, len(matches))
+	runeOffset, byteOffset := 0, 0
+	for _, match := range matches {
+		// Convert byte offsets to character offsets incrementally
+		runeOffset += utf8.RuneCountInString(text[byteOffset:match[0]])
+		start := runeOffset
+		runeOffset += utf8.RuneCountInString(text[match[0]:match[1]])
+		byteOffset = match[1]
+		spans = append(spans, types.TextSpan{Start: start, End: runeOffset})
+	}
+
+	return spans
+}
@@ -0,0 +1,36 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// publish publishes the completion or failure of an extraction to the configured publisher
+func (e *Extractor) publish(ctx context.Context, options types.ExtractionOptions, result *types.ExtractionResult, extractErr error, duration time.Duration) error {
+	// Failed extractions may not have a readable PDF to identify them
+	id, _ := documentID(options)
+	event := types.Event{
+		SchemaVersion: types.EventSchemaVersion,
+		Type:          types.EventExtractionCompleted,
+		DocumentID:    id,
+		Schema:        schemaName(options),
+		Time:          time.Now().UTC(),
+		DurationMs:    duration.Milliseconds(),
+	}
+	if extractErr != nil {
+		event.Type = types.EventExtractionFailed
+		event.Error = extractErr.Error()
+	} else {
+		event.Data = result.Data
+		event.Model = result.Model
+		event.TokensUsed = result.TokensUsed
+	}
+
+	if err := e.config.Publisher.Publish(ctx, event); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", event.Type, err)
+	}
+	return nil
+}
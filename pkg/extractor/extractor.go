@@ -2,14 +2,20 @@ package extractor
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
-	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/sandbox"
 	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
 )
 
@@ -19,36 +25,118 @@ const (
 	defaultSystemPrompt  = "You are a helpful assistant that extracts structured data from text. Extract the requested information accurately from the provided text."
 	defaultVisionEnabled = true
 	defaultTextThreshold = 100
+	handwritingDPI       = 400.0
+	handwritingPrompt    = "These document pages contain handwritten content. Read the handwriting carefully, using the surrounding printed labels and context to disambiguate letters and digits. Do not guess illegible values; leave them empty instead. Extract the following structured information:"
 )
 
 // Extractor is the main class for extracting structured data from PDFs using OpenAI
 type Extractor struct {
-	client       *http.Client
-	apiKey       string
-	baseURL      string
-	model        string
-	textModel    string
-	visionModel  string
-	config       types.ExtractorConfig
-	systemPrompt string
+	client         *http.Client
+	apiKey         string
+	baseURL        string
+	model          string
+	textModel      string
+	visionModel    string
+	config         types.ExtractorConfig
+	systemPrompt   string
+	responseFormat string
+
+	// inflight holds the model calls in progress by request hash, to coalesce identical requests
+	inflightMu sync.Mutex
+	inflight   map[string]*flight
+
+	// uploads holds the images uploaded with the ImageUploader by the hash of their data URL
+	uploadsMu sync.Mutex
+	uploads   map[string]upload
+
+	// keyMu guards apiKey and keyFetched, the time it was fetched from the KeyProvider
+	keyMu      sync.Mutex
+	keyFetched time.Time
+
+	// templates are the layout templates matched against documents before calling the model
+	templatesMu sync.RWMutex
+	templates   []types.LayoutTemplate
+
+	// remembered holds the latest extractions by document ID, for the feedback recorded on them,
+	// and rememberedOrder their IDs from the oldest (when FeedbackStore is set)
+	feedbackMu      sync.Mutex
+	remembered      map[string]remembered
+	rememberedOrder []string
+
+	// lifecycleMu guards closed, set once Shutdown was called, released, set once the sinks were
+	// closed, and inFlight. running and inFlight count the extractions and model calls in flight.
+	lifecycleMu sync.Mutex
+	closed      bool
+	released    bool
+	running     sync.WaitGroup
+	inFlight    int
 }
 
 // New creates a new PDF data extractor
 func New(config types.ExtractorConfig) (*Extractor, error) {
-	if config.OpenAIAPIKey == "" {
+	provider := config.Provider
+	if provider == "" {
+		provider = types.ProviderOpenAI
+	}
+	preset, ok := LookupPreset(provider)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", config.Provider)
+	}
+	if config.OpenAIAPIKey == "" && config.KeyProvider == nil && config.Signer == nil && preset.RequiresAPIKey {
 		return nil, errors.New("OpenAI API key is required")
 	}
 
 	// Set defaults
 	if config.Model == "" {
+		if provider != types.ProviderOpenAI && (config.TextModel == "" || config.VisionModel == "") {
+			// Local servers serve whatever models were pulled or loaded
+			return nil, fmt.Errorf("model is required for provider %s", provider)
+		}
 		config.Model = defaultModel
 	}
 	if config.BaseURL == "" {
-		config.BaseURL = defaultBaseURL
+		config.BaseURL = preset.BaseURL
+	}
+	if config.ResponseFormat == "" {
+		config.ResponseFormat = preset.ResponseFormat
+	}
+	if config.SchemaLimits == nil && config.ResponseFormat == types.ResponseFormatJSONSchema {
+		limits := preset.SchemaLimits
+		config.SchemaLimits = &limits
+	}
+	if airGappedBuild {
+		config.AirGapped = true
+	}
+	if config.AirGapped {
+		if err := validateAirGapped(config); err != nil {
+			return nil, err
+		}
+	}
+	switch config.ResponseFormat {
+	case types.ResponseFormatJSONSchema, types.ResponseFormatJSONObject, types.ResponseFormatPrompt:
+	default:
+		return nil, fmt.Errorf("unknown response format %q", config.ResponseFormat)
+	}
+	switch {
+	case config.ImageFormat == "" || config.ImageFormat == types.ImageFormatPNG:
+	case config.ImageFormat != types.ImageFormatWebP && config.ImageFormat != types.ImageFormatAVIF:
+		return nil, fmt.Errorf("unknown image format %q", config.ImageFormat)
+	case !slices.Contains(preset.ImageFormats, config.ImageFormat):
+		return nil, fmt.Errorf("provider %s does not accept %s images", provider, config.ImageFormat)
+	}
+	switch config.ImageDetail {
+	case "", types.ImageDetailLow, types.ImageDetailHigh, types.ImageDetailAuto, types.ImageDetailAdaptive:
+	default:
+		return nil, fmt.Errorf("unknown image detail %q", config.ImageDetail)
 	}
 	if config.TextThreshold == 0 {
 		config.TextThreshold = defaultTextThreshold
 	}
+	for _, template := range config.Templates {
+		if err := validateTemplate(template); err != nil {
+			return nil, err
+		}
+	}
 
 	systemPrompt := config.SystemPrompt
 	if systemPrompt == "" {
@@ -66,39 +154,348 @@ func New(config types.ExtractorConfig) (*Extractor, error) {
 		visionModel = config.Model
 	}
 
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Extractor{
-		client:       &http.Client{},
-		apiKey:       config.OpenAIAPIKey,
-		baseURL:      config.BaseURL,
-		model:        config.Model,
-		textModel:    textModel,
-		visionModel:  visionModel,
-		config:       config,
-		systemPrompt: systemPrompt,
+		client:         client,
+		apiKey:         config.OpenAIAPIKey,
+		baseURL:        config.BaseURL,
+		model:          config.Model,
+		textModel:      textModel,
+		visionModel:    visionModel,
+		config:         config,
+		systemPrompt:   systemPrompt,
+		responseFormat: config.ResponseFormat,
+		templates:      slices.Clone(config.Templates),
 	}, nil
 }
 
 // Extract extracts structured data from a PDF file
 func (e *Extractor) Extract(options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	return e.ExtractWithContext(context.Background(), options)
+}
+
+// ExtractWithContext extracts structured data from a PDF file, using ctx for the API request
+func (e *Extractor) ExtractWithContext(ctx context.Context, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	return e.tracked(ctx, options, func(ctx context.Context) (*types.ExtractionResult, error) {
+		return e.extract(ctx, options)
+	})
+}
+
+// tracked runs an extraction of the options, tracked until it returns, and records, saves and
+// publishes its result once. The presets extracting a document in several model calls, such as
+// its chunks, run them all in one tracked extraction returning the merged result.
+func (e *Extractor) tracked(ctx context.Context, options types.ExtractionOptions, run func(ctx context.Context) (*types.ExtractionResult, error)) (*types.ExtractionResult, error) {
+	ctx, end, err := e.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+
+	var trail *auditTrail
+	if e.config.Recorder != nil || options.Audit {
+		trail = &auditTrail{}
+		ctx = context.WithValue(ctx, auditKey{}, trail)
+	}
+	if options.Minimize {
+		ctx = context.WithValue(ctx, minimizedKey{}, true)
+	}
+
+	start := time.Now()
+	result, err := run(ctx)
+	if trail != nil {
+		if recordErr := e.record(ctx, trail, options, result, err, time.Since(start)); recordErr != nil {
+			return nil, errors.Join(err, recordErr)
+		}
+	}
+	if e.config.Store != nil {
+		if saveErr := e.save(ctx, options, result, err, time.Since(start)); saveErr != nil {
+			return nil, errors.Join(err, saveErr)
+		}
+	}
+	if e.config.Publisher == nil {
+		return result, err
+	}
+	if publishErr := e.publish(ctx, options, result, err, time.Since(start)); publishErr != nil {
+		return nil, errors.Join(err, publishErr)
+	}
+	return result, err
+}
+
+// extract extracts structured data from a PDF file
+func (e *Extractor) extract(ctx context.Context, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	start := time.Now()
+
+	// Validate schema, and inline its references for the steps using it directly
+	schemaData, _, err := combinedSchema(options)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkSchemaLimits(schemaData); err != nil {
+		return nil, err
+	}
+	if options, err = inlineSchemaRefs(options); err != nil {
+		return nil, err
+	}
+	if len(options.ResolveEntities) > 0 && e.config.EntityResolver == nil {
+		return nil, errors.New("entity resolution requires an EntityResolver in the extractor config")
+	}
+	if len(options.FormFields) > 0 {
+		if err := validateFormFields(options.FormFields); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.validateMinimize(options); err != nil {
+		return nil, err
+	}
+
+	parsedPdf, err := e.parse(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	parseDuration := time.Since(start)
+
+	// Extract documents of a known layout from the positions of their words, and others with the model
+	result, warnings := e.extractTemplate(ctx, parsedPdf, options)
+	if result == nil {
+		modelOptions, err := e.withFeedbackExamples(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = e.extractParsed(ctx, parsedPdf, modelOptions); err != nil {
+			return nil, err
+		}
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	stamp(result, schemaData, options)
+	result.Timings.Parse = parseDuration
+	if options.ParsedPdf == nil {
+		result.Timings.Render = parsedPdf.RenderDuration
+	}
+
+	// Detect checkboxes and signatures on their cropped regions and merge them into the data
+	if len(options.FormFields) > 0 {
+		buffer, err := readPdf(options)
+		if err != nil {
+			return nil, err
+		}
+		detection, err := e.detectFormFields(ctx, buffer, options.FormFields, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect form fields: %w", err)
+		}
+		for _, field := range options.FormFields {
+			if err := setDataPath(result.Data, field.Name, detection.Values[field.Name]); err != nil {
+				return nil, err
+			}
+		}
+		result.TokensUsed += detection.TokensUsed
+		result.Usage.Add(detection.Usage)
+	}
+
+	// Match the values of fields with a vocabulary to their closest allowed values
+	if len(options.Vocabularies) > 0 {
+		matches, warnings, err := matchVocabularies(result.Data, options.Vocabularies, options.VocabularyThreshold)
+		if err != nil {
+			return nil, err
+		}
+		result.VocabularyMatches = matches
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
+	// Resolve the names of customers, vendors and other parties to the IDs of the master data
+	if len(options.ResolveEntities) > 0 {
+		entities, warnings, err := e.resolveEntities(ctx, result.Data, options.ResolveEntities)
+		if err != nil {
+			return nil, err
+		}
+		result.ResolvedEntities = entities
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
+	// Detect the currency and units of the document and normalize the currency fields
+	if options.DetectUnits {
+		warnings, err := detectUnits(parsedPdf, result)
+		if err != nil {
+			return nil, err
+		}
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
+	// Parse amounts with the decimal separator of the document and catch misread separators
+	if options.CheckNumbers {
+		schemaData, _, err := combinedSchema(options)
+		if err != nil {
+			return nil, err
+		}
+		warnings, err := checkNumbers(parsedPdf, schemaData, result.Data, options.DecimalSeparator)
+		if err != nil {
+			return nil, err
+		}
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
+	// Check the values read from page images against the text recognized by OCR
+	if options.CrossCheckOCR && parsedPdf.Content.Type != "text" {
+		unverified, warnings, err := e.crossCheck(ctx, parsedPdf, result.Data, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cross-check with OCR: %w", err)
+		}
+		result.Unverified = unverified
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
+	// Transcribe scanned pages into an invisible text layer
+	if options.SearchablePDF && parsedPdf.Content.Type != "text" {
+		buffer, err := readPdf(options)
+		if err != nil {
+			return nil, err
+		}
+		searchable, err := e.searchablePdf(ctx, buffer, parsedPdf.Content.ImageContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make PDF searchable: %w", err)
+		}
+		result.SearchablePDF = searchable.PDF
+		result.TokensUsed += searchable.TokensUsed
+		result.Usage.Add(searchable.Usage)
+		// The parsed PDF may be shared with other extractions, so update a copy
+		copied := *parsedPdf
+		copied.Content.TextPages = slices.Clone(parsedPdf.Content.TextPages)
+		parsedPdf = &copied
+		if parsedPdf.Content.Type == "mixed" {
+			// Fill in the text of the scanned pages only
+			for _, page := range searchable.Pages {
+				parsedPdf.Content.TextPages[page.Page-1] = transcriptionText([]types.PageLayout{page})
+			}
+			parsedPdf.Content.TextContent = strings.Join(parsedPdf.Content.TextPages, "\n") + "\n"
+		} else {
+			parsedPdf.Content.TextContent = transcriptionText(searchable.Pages)
+		}
+	}
+
+	if options.Fingerprint {
+		buffer, err := readPdf(options)
+		if err != nil {
+			return nil, err
+		}
+		if result.Fingerprint, err = e.fingerprint(ctx, buffer); err != nil {
+			return nil, fmt.Errorf("failed to fingerprint PDF: %w", err)
+		}
+	}
+
+	// Minimized extractions keep no content of the document
+	if e.config.Indexer != nil && !options.Minimize {
+		if err := e.index(ctx, parsedPdf, result, options); err != nil {
+			return nil, err
+		}
+	}
+	if e.config.FeedbackStore != nil && !options.Minimize {
+		e.remember(parsedPdf, result, options)
+	}
+
+	result.Timings.Total = time.Since(start)
+	return result, nil
+}
+
+// parse validates the PDF input of the options and parses it, or returns the parsed PDF of the
+// options, with the redactions of the options applied
+func (e *Extractor) parse(ctx context.Context, options types.ExtractionOptions) (*types.ParsedPdf, error) {
+	parsedPdf := options.ParsedPdf
+	if parsedPdf == nil {
+		var err error
+		if parsedPdf, err = e.parseWith(ctx, options, e.parseOptions(options)); err != nil {
+			return nil, err
+		}
+	}
+	return redactPages(parsedPdf, options)
+}
+
+// parseOptions derives the parser options from the extractor configuration and the extraction options
+func (e *Extractor) parseOptions(options types.ExtractionOptions) *types.ParseOptions {
+	threshold := e.config.TextThreshold
+	if options.TextThreshold > 0 {
+		threshold = options.TextThreshold
+	}
+	parseOptions := &types.ParseOptions{
+		TextThreshold:        threshold,
+		MinTextQuality:       e.config.MinTextQuality,
+		DecodeBarcodes:       options.DecodeBarcodes,
+		Markdown:             options.Markdown,
+		LayoutMode:           options.LayoutMode,
+		ReadColumns:          options.ReadColumns,
+		StripBoilerplate:     options.StripBoilerplate,
+		NormalizeText:        options.NormalizeText,
+		MixedPages:           options.MixedPages,
+		TextBackend:          e.config.TextBackend,
+		OCRBackend:           e.config.OCRBackend,
+		Renderer:             e.config.Renderer,
+		RendererPath:         e.config.RendererPath,
+		IsolateRendering:     e.config.IsolateRendering,
+		PixelBudget:          e.config.PixelBudget,
+		ArtifactStore:        e.config.ArtifactStore,
+		MaxRenderMemoryBytes: e.config.MaxRenderMemoryBytes,
+		MaxRenderDuration:    e.config.MaxRenderDuration,
+		ParsePolicy:          e.config.ParsePolicy,
+		Outline:              options.Outline || options.Section != "",
+		Annotations:          options.Annotations,
+		Links:                options.Links,
+	}
+	if options.Minimize {
+		// Page images are content of the document, so do not cache them
+		parseOptions.ArtifactStore = nil
+	}
+	if len(options.RedactPatterns) > 0 {
+		// Redaction patterns are blacked out where their words are on the page images
+		parseOptions.TextLayout = true
+	}
+	switch options.ForceMode {
+	case types.ForceModeText:
+		parseOptions.ForceText = true
+	case types.ForceModeVision:
+		parseOptions.ForceImages = true
+	}
+	if options.HandwritingMode {
+		// Handwriting is invisible to the text layer, so always go through vision
+		parseOptions.ForceImages = true
+		parseOptions.DPI = handwritingDPI
+		parseOptions.EnhanceContrast = true
+	}
+	return parseOptions
+}
+
+// parseWith validates the PDF input of the options and parses it with the given parser options,
+// canceling the sandboxed parser when ctx is done
+func (e *Extractor) parseWith(ctx context.Context, options types.ExtractionOptions, parseOptions *types.ParseOptions) (*types.ParsedPdf, error) {
+	switch options.ForceMode {
+	case "", types.ForceModeAuto, types.ForceModeVision:
+	case types.ForceModeText:
+		if options.HandwritingMode {
+			return nil, errors.New("handwriting mode requires vision and cannot be combined with the text force mode")
+		}
+	default:
+		return nil, fmt.Errorf("unknown force mode %q", options.ForceMode)
+	}
+
 	// Validate inputs
 	if options.PDFPath == "" && options.PDFBuffer == nil {
+		if options.ParsedPdf != nil {
+			return nil, errors.New("this extraction parses the PDF with its own options: PDFPath or PDFBuffer must be provided")
+		}
 		return nil, errors.New("either PDFPath or PDFBuffer must be provided")
 	}
 
-	// Validate schema
-	if err := schema.ValidateSchema(options.Schema); err != nil {
-		return nil, fmt.Errorf("invalid JSON schema: %w", err)
-	}
-
 	// Parse the PDF
 	var parsedPdf *types.ParsedPdf
 	var err error
 
-	parseOptions := &types.ParseOptions{
-		TextThreshold: e.config.TextThreshold,
-	}
-
-	if options.PDFPath != "" {
+	if e.config.Sandbox != nil {
+		var buffer []byte
+		if buffer, err = readPdf(options); err == nil {
+			parsedPdf, err = sandbox.Parse(ctx, buffer, parseOptions, *e.config.Sandbox)
+		}
+	} else if options.PDFPath != "" {
 		parsedPdf, err = parser.ParsePdfFromPath(options.PDFPath, parseOptions)
 	} else {
 		parsedPdf, err = parser.ParsePdfFromBuffer(options.PDFBuffer, parseOptions)
@@ -108,16 +505,121 @@ func (e *Extractor) Extract(options types.ExtractionOptions) (*types.ExtractionR
 		return nil, fmt.Errorf("failed to parse PDF: %w", err)
 	}
 
-	// Extract based on content type
-	if parsedPdf.Content.Type == "text" {
-		return e.extractFromText(parsedPdf.Content.TextContent, options.Schema, options)
+	return parsedPdf, nil
+}
+
+// visionEnabled reports whether the vision model may be used for an extraction
+func (e *Extractor) visionEnabled(options types.ExtractionOptions) bool {
+	if options.VisionEnabled != nil {
+		return *options.VisionEnabled
 	}
+	return e.config.VisionEnabled
+}
 
-	return e.extractFromImages(parsedPdf.Content.ImageContent, options.Schema, options)
+// readPdf returns the contents of the PDF referenced by the options
+func readPdf(options types.ExtractionOptions) ([]byte, error) {
+	if options.PDFPath == "" && options.PDFBuffer == nil {
+		if options.ParsedPdf != nil {
+			return nil, errors.New("the PDF itself is needed besides the parsed PDF: PDFPath or PDFBuffer must be provided")
+		}
+		return nil, errors.New("either PDFPath or PDFBuffer must be provided")
+	}
+	if options.PDFBuffer != nil {
+		return options.PDFBuffer, nil
+	}
+
+	data, err := os.ReadFile(options.PDFPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF from path: %w", err)
+	}
+	return data, nil
+}
+
+// extractParsed extracts structured data from an already parsed PDF
+func (e *Extractor) extractParsed(ctx context.Context, parsedPdf *types.ParsedPdf, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	request, err := e.BuildRequest(parsedPdf, options)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	response, err := e.CallModel(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	requestDuration := time.Since(start)
+	result, err := e.ParseResult(response)
+	if err != nil {
+		return nil, err
+	}
+	result.Timings.Request = requestDuration
+	if result, err = e.enforceConstraints(ctx, request, result); err != nil {
+		return nil, err
+	}
+	if result, err = e.enforceDirectives(ctx, request, result, options); err != nil {
+		return nil, err
+	}
+	return e.enforceDateRules(ctx, request, result, options)
+}
+
+// barcodeInstructions lists decoded barcodes for the prompt
+func barcodeInstructions(barcodes []types.Barcode) string {
+	var b strings.Builder
+	b.WriteString("The following barcodes and QR codes were decoded from the document. Their contents are exact: prefer them over the printed text when they contain the same values (e.g. reference numbers, IBANs, amounts).")
+	for _, barcode := range barcodes {
+		fmt.Fprintf(&b, "\n- Page %d, %s: %s", barcode.Page, barcode.Format, barcode.Text)
+	}
+	return b.String()
+}
+
+// annotationInstructions lists the annotations of a document for the prompt
+func annotationInstructions(annotations []types.Annotation) string {
+	var b strings.Builder
+	b.WriteString("The following annotations were added to the document by its readers, such as highlights, comments and stamps. They may point to or correct the values to extract.")
+	for _, annotation := range annotations {
+		fmt.Fprintf(&b, "\n- Page %d, %s", annotation.Page, annotation.Type)
+		if annotation.Author != "" {
+			fmt.Fprintf(&b, " by %s", annotation.Author)
+		}
+		var details []string
+		if annotation.Text != "" {
+			details = append(details, fmt.Sprintf("marks %q", annotation.Text))
+		}
+		if annotation.Contents != "" {
+			details = append(details, fmt.Sprintf("comment %q", annotation.Contents))
+		}
+		if len(details) > 0 {
+			b.WriteString(": " + strings.Join(details, ", "))
+		}
+	}
+	return b.String()
+}
+
+// linkInstructions lists the hyperlinks of a document for the prompt
+func linkInstructions(links []types.Link) string {
+	var b strings.Builder
+	b.WriteString("The following hyperlinks were read from the document. Their targets are exact, while their text on the page may be shortened: prefer the targets for email addresses and web addresses.")
+	for _, link := range links {
+		fmt.Fprintf(&b, "\n- Page %d", link.Page)
+		if link.Text != "" {
+			fmt.Fprintf(&b, ", %q", link.Text)
+		}
+		fmt.Fprintf(&b, ": %s", link.URI)
+	}
+	return b.String()
+}
+
+// ocrInstructions gives the text recognized by an OCR service for the prompt
+func ocrInstructions(text string) string {
+	return "The following text was recognized from the page images by an OCR service. Use it to read characters the images leave ambiguous (e.g. names, emails, reference numbers), but rely on the images for the layout and when the text contradicts them.\n\n" + text
 }
 
 // extractFromText extracts structured data from text content
-func (e *Extractor) extractFromText(text string, schemaData map[string]interface{}, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+func (e *Extractor) extractFromText(ctx context.Context, text string, schemaData map[string]interface{}, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	return e.callOpenAI(ctx, e.textRequest(text, schemaData, options))
+}
+
+// textRequest builds the chat completion request extracting structured data from text content
+func (e *Extractor) textRequest(text string, schemaData map[string]interface{}, options types.ExtractionOptions) map[string]interface{} {
 	// Build messages array
 	messages := make([]map[string]interface{}, 0)
 
@@ -131,7 +633,7 @@ func (e *Extractor) extractFromText(text string, schemaData map[string]interface
 
 	messages = append(messages, map[string]interface{}{
 		"role":    "user",
-		"content": fmt.Sprintf("Extract the following information from this text:\n\n%s", text),
+		"content": fmt.Sprintf("%s\n\n%s", buildPrompt("Extract the following information from this text:", options), text),
 	})
 
 	// Prepare request body
@@ -143,7 +645,7 @@ func (e *Extractor) extractFromText(text string, schemaData map[string]interface
 			"json_schema": map[string]interface{}{
 				"name":   "extracted_data",
 				"strict": true,
-				"schema": schemaData,
+				"schema": withoutDirectives(schemaData),
 			},
 		},
 	}
@@ -159,35 +661,92 @@ func (e *Extractor) extractFromText(text string, schemaData map[string]interface
 		requestBody["max_tokens"] = *options.MaxTokens
 	}
 
-	return e.callOpenAI(requestBody)
+	return requestBody
 }
 
 // extractFromImages extracts structured data from image content using vision API
-func (e *Extractor) extractFromImages(images []types.PdfPageImage, schemaData map[string]interface{}, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+func (e *Extractor) extractFromImages(ctx context.Context, images []types.PdfPageImage, schemaData map[string]interface{}, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	requestBody, err := e.imageRequest(images, schemaData, options)
+	if err != nil {
+		return nil, err
+	}
+	return e.callOpenAI(ctx, requestBody)
+}
+
+// imageRequest builds the chat completion request extracting structured data from image content
+func (e *Extractor) imageRequest(images []types.PdfPageImage, schemaData map[string]interface{}, options types.ExtractionOptions) (map[string]interface{}, error) {
 	// Verify vision is enabled
-	if !e.config.VisionEnabled {
+	if !e.visionEnabled(options) {
 		return nil, errors.New("PDF contains no extractable text and vision mode is disabled")
 	}
 
-	// Build vision API content
-	content := make([]map[string]interface{}, 0)
+	// Add all page images, tiling large pages
+	pages := make([]map[string]interface{}, 0, len(images))
+	tiled := false
+	for _, img := range images {
+		parts, pageTiled, err := e.pageImageParts(img)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, parts...)
+		tiled = tiled || pageTiled
+	}
+	if tiled {
+		options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" + tileInstructions)
+	}
 
-	// Add text instruction
-	content = append(content, map[string]interface{}{
+	// Build vision API content, starting with the text instruction
+	content := append([]map[string]interface{}{{
 		"type": "text",
-		"text": "Extract the following structured information from these document pages:",
-	})
+		"text": buildPrompt(imagePrompt(options), options),
+	}}, pages...)
 
-	// Add all page images
-	for _, img := range images {
-		content = append(content, map[string]interface{}{
-			"type": "image_url",
-			"image_url": map[string]interface{}{
-				"url": fmt.Sprintf("data:image/png;base64,%s", img.Base64),
-			},
-		})
+	return e.visionRequest(content, schemaData, options), nil
+}
+
+// mixedRequest builds the chat completion request extracting structured data from pages first to
+// last (1-indexed) of a document mixing text and scanned pages: every page in order, as text or as
+// an image
+func (e *Extractor) mixedRequest(parsedPdf *types.ParsedPdf, first, last int, schemaData map[string]interface{}, options types.ExtractionOptions) (map[string]interface{}, error) {
+	if !e.visionEnabled(options) {
+		return nil, errors.New("PDF contains scanned pages and vision mode is disabled")
+	}
+
+	images := make(map[int]types.PdfPageImage, len(parsedPdf.Content.ImageContent))
+	for _, img := range parsedPdf.Content.ImageContent {
+		images[img.Page] = img
+	}
+	pages := make([]map[string]interface{}, 0, last-first+1)
+	tiled := false
+	for i := first - 1; i < last && i < len(parsedPdf.Content.PageTypes); i++ {
+		pageType := parsedPdf.Content.PageTypes[i]
+		if img, ok := images[i+1]; ok && pageType == "images" {
+			parts, pageTiled, err := e.pageImageParts(img)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, parts...)
+			tiled = tiled || pageTiled
+		} else if pageType == "text" && i < len(parsedPdf.Content.TextPages) {
+			pages = append(pages, map[string]interface{}{
+				"type": "text",
+				"text": fmt.Sprintf("[Page %d]\n%s", i+1, parsedPdf.Content.TextPages[i]),
+			})
+		}
+	}
+	if tiled {
+		options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" + tileInstructions)
 	}
 
+	content := append([]map[string]interface{}{{
+		"type": "text",
+		"text": buildPrompt("Extract the following structured information from these document pages. Pages with a text layer are given as text starting with a [Page N] marker, and scanned pages as images, all in page order:", options),
+	}}, pages...)
+	return e.visionRequest(content, schemaData, options), nil
+}
+
+// visionRequest builds a chat completion request for the vision model with the given user content
+func (e *Extractor) visionRequest(content []map[string]interface{}, schemaData map[string]interface{}, options types.ExtractionOptions) map[string]interface{} {
 	// Build messages array
 	messages := make([]map[string]interface{}, 0)
 
@@ -213,7 +772,7 @@ func (e *Extractor) extractFromImages(images []types.PdfPageImage, schemaData ma
 			"json_schema": map[string]interface{}{
 				"name":   "extracted_data",
 				"strict": true,
-				"schema": schemaData,
+				"schema": withoutDirectives(schemaData),
 			},
 		},
 	}
@@ -229,32 +788,136 @@ func (e *Extractor) extractFromImages(images []types.PdfPageImage, schemaData ma
 		requestBody["max_tokens"] = *options.MaxTokens
 	}
 
-	return e.callOpenAI(requestBody)
+	return requestBody
+}
+
+// pdfOptions builds extraction options for a PDF given as a file path, a byte slice or a parsed PDF
+func pdfOptions(pdf interface{}) (types.ExtractionOptions, error) {
+	switch v := pdf.(type) {
+	case string:
+		return types.ExtractionOptions{PDFPath: v}, nil
+	case []byte:
+		return types.ExtractionOptions{PDFBuffer: v}, nil
+	case *types.ParsedPdf:
+		return types.ExtractionOptions{ParsedPdf: v}, nil
+	default:
+		return types.ExtractionOptions{}, fmt.Errorf("unsupported PDF input type %T: expected a file path, a byte slice or a parsed PDF", pdf)
+	}
+}
+
+// decodeData converts extracted data into the typed value pointed to by out
+func decodeData(data map[string]interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal extracted data: %w", err)
+	}
+	if err := json.Unmarshal(jsonData, out); err != nil {
+		return fmt.Errorf("failed to decode extracted data: %w", err)
+	}
+	return nil
+}
+
+// imagePrompt returns the base user prompt for vision extraction
+func imagePrompt(options types.ExtractionOptions) string {
+	if options.HandwritingMode {
+		return handwritingPrompt
+	}
+	return "Extract the following structured information from these document pages:"
+}
+
+// buildPrompt appends the caller's instructions, if any, to the base user prompt
+func buildPrompt(base string, options types.ExtractionOptions) string {
+	if options.Instructions == "" {
+		return base
+	}
+	return fmt.Sprintf("%s\n\n%s", base, options.Instructions)
 }
 
 // callOpenAI makes a request to the OpenAI API
-func (e *Extractor) callOpenAI(requestBody map[string]interface{}) (*types.ExtractionResult, error) {
+func (e *Extractor) callOpenAI(ctx context.Context, requestBody map[string]interface{}) (*types.ExtractionResult, error) {
+	body, err := e.postOpenAI(ctx, requestBody, "")
+	if err != nil {
+		return nil, err
+	}
+	return e.parseResponse(body)
+}
+
+// postOpenAI sends a chat completion request to the OpenAI API and returns the response body.
+// Concurrent identical requests are coalesced into a single API call.
+func (e *Extractor) postOpenAI(ctx context.Context, requestBody map[string]interface{}, idempotencyKey string) ([]byte, error) {
+	ctx, end, err := e.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+
+	auditCall(ctx, requestBody)
+
+	// Reference uploaded images instead of inlining them
+	var body interface{} = e.adaptRequest(requestBody)
+	if e.config.ImageUploader != nil {
+		if body, err = e.uploadImages(ctx, body); err != nil {
+			return nil, err
+		}
+	}
+
 	// Serialize request body
-	jsonData, err := json.Marshal(requestBody)
+	jsonData, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	return e.coalesce(ctx, requestHash(jsonData, idempotencyKey), func(ctx context.Context) ([]byte, error) {
+		return e.sendOpenAI(ctx, jsonData, idempotencyKey)
+	})
+}
+
+// sendOpenAI sends a serialized chat completion request to the OpenAI API, with the idempotency
+// key, if any, for providers that deduplicate retried requests. A key of the KeyProvider or a token
+// of the signer rejected as unauthorized, e.g. after a rotation, is fetched again and the request
+// retried once.
+func (e *Extractor) sendOpenAI(ctx context.Context, jsonData []byte, idempotencyKey string) ([]byte, error) {
+	status, body, err := e.postChatCompletion(ctx, jsonData, idempotencyKey)
+	if err == nil && status == http.StatusUnauthorized && e.expireCredentials() {
+		status, body, err = e.postChatCompletion(ctx, jsonData, idempotencyKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for HTTP errors
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("OpenAI API error (status %d): %s", status, string(body))
+	}
+
+	return body, nil
+}
+
+// postChatCompletion makes a single chat completion request and returns the status and body of
+// the response
+func (e *Extractor) postChatCompletion(ctx context.Context, jsonData []byte, idempotencyKey string) (int, []byte, error) {
 	// Create HTTP request
 	url := fmt.Sprintf("%s/chat/completions", e.baseURL)
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", e.apiKey))
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	if err := e.authorize(req, jsonData); err != nil {
+		return 0, nil, err
+	}
+
+	e.logDebug(ctx, "chat completion request", jsonData, "url", url)
 
 	// Make the request
 	resp, err := e.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call OpenAI API: %w", err)
+		return 0, nil, fmt.Errorf("failed to call OpenAI API: %w", err)
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -266,14 +929,15 @@ func (e *Extractor) callOpenAI(requestBody map[string]interface{}) (*types.Extra
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Check for HTTP errors
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, string(body))
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
 	}
+	e.logDebug(ctx, "chat completion response", body, "status", resp.StatusCode)
+	return resp.StatusCode, body, nil
+}
 
+// parseResponse reads the extracted data and usage of a chat completion response, repairing
+// malformed JSON when RepairJSON is set
+func (e *Extractor) parseResponse(body []byte) (*types.ExtractionResult, error) {
 	// Parse response
 	var response struct {
 		Choices []struct {
@@ -281,10 +945,8 @@ func (e *Extractor) callOpenAI(requestBody map[string]interface{}) (*types.Extra
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
-		Usage struct {
-			TotalTokens int `json:"total_tokens"`
-		} `json:"usage"`
-		Model string `json:"model"`
+		Usage map[string]interface{} `json:"usage"`
+		Model string                 `json:"model"`
 	}
 
 	if err := json.Unmarshal(body, &response); err != nil {
@@ -298,17 +960,46 @@ func (e *Extractor) callOpenAI(requestBody map[string]interface{}) (*types.Extra
 
 	// Parse extracted data
 	var extractedData map[string]interface{}
-	if err := json.Unmarshal([]byte(response.Choices[0].Message.Content), &extractedData); err != nil {
-		return nil, fmt.Errorf("failed to parse extracted data: %w", err)
+	content := response.Choices[0].Message.Content
+	// Parsing is strict, but for the code fence of servers asked for JSON in the prompt
+	strict := content
+	if e.responseFormat == types.ResponseFormatPrompt {
+		strict = trimCodeFence(content)
+	}
+	if err := json.Unmarshal([]byte(strict), &extractedData); err != nil {
+		if !e.config.RepairJSON {
+			return nil, fmt.Errorf("failed to parse extracted data: %w", err)
+		}
+		extractedData = nil
+		if repairErr := json.Unmarshal([]byte(repairJSON(content)), &extractedData); repairErr != nil {
+			return nil, fmt.Errorf("failed to parse extracted data, even after repair: %w", err)
+		}
 	}
 
+	usage := parseUsage(response.Usage)
 	return &types.ExtractionResult{
 		Data:       extractedData,
-		TokensUsed: response.Usage.TotalTokens,
+		TokensUsed: usage.TotalTokens,
+		Usage:      usage,
 		Model:      response.Model,
 	}, nil
 }
 
+// trimCodeFence removes the Markdown code fence models without structured output tend to wrap
+// JSON responses in
+func trimCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") || !strings.HasSuffix(content, "```") || len(content) < 6 {
+		return content
+	}
+	content = strings.TrimSuffix(content[3:], "```")
+	if newline := strings.IndexByte(content, '\n'); newline >= 0 {
+		// Drop the language of the fence, e.g. json
+		content = content[newline+1:]
+	}
+	return strings.TrimSpace(content)
+}
+
 // GetModel returns the default model configured for the extractor
 func (e *Extractor) GetModel() string {
 	return e.model
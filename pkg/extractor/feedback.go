@@ -0,0 +1,236 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	// maxRememberedDocuments is the most extractions remembered for the feedback recorded on them
+	maxRememberedDocuments = 1000
+	// maxExampleTextLength is the longest text of a document given to the model as an example, in
+	// characters
+	maxExampleTextLength = 4000
+)
+
+// remembered is an extraction remembered for the feedback recorded on it
+type remembered struct {
+	schema string
+	text   string
+	data   map[string]interface{}
+}
+
+// RecordFeedback stores the data of a document as corrected by a reviewer in the FeedbackStore of
+// the config, along with the data as extracted and the text of the document when it was extracted
+// by this extractor recently. The records make the few-shot examples of later extractions, with
+// FeedbackExamples, and evaluation datasets, see FeedbackDataset.
+func (e *Extractor) RecordFeedback(ctx context.Context, documentID string, corrected map[string]interface{}) error {
+	if e.config.FeedbackStore == nil {
+		return errors.New("recording feedback requires a FeedbackStore in the extractor config")
+	}
+	if documentID == "" {
+		return errors.New("document ID is required")
+	}
+	corrected, err := jsonData(corrected)
+	if err != nil {
+		return fmt.Errorf("invalid corrected data: %w", err)
+	}
+
+	record := types.FeedbackRecord{DocumentID: documentID, Corrected: corrected, Time: time.Now().UTC()}
+	e.feedbackMu.Lock()
+	extraction, ok := e.remembered[documentID]
+	e.feedbackMu.Unlock()
+	if ok {
+		record.Schema, record.Text, record.Extracted = extraction.schema, extraction.text, extraction.data
+		record.Corrections = changedPaths(extraction.data, corrected)
+	}
+	if err := e.config.FeedbackStore.Save(ctx, record); err != nil {
+		return fmt.Errorf("failed to save feedback on document %s: %w", documentID, err)
+	}
+	return nil
+}
+
+// FeedbackDataset returns the documents of a schema with their data as last corrected by a
+// reviewer, in the order they were first corrected, as an evaluation dataset. An empty schema name
+// returns the documents of every schema.
+func (e *Extractor) FeedbackDataset(ctx context.Context, schemaName string) ([]types.FeedbackExample, error) {
+	if e.config.FeedbackStore == nil {
+		return nil, errors.New("feedback datasets require a FeedbackStore in the extractor config")
+	}
+	records, err := e.config.FeedbackStore.Records(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feedback: %w", err)
+	}
+
+	examples := make([]types.FeedbackExample, 0)
+	index := make(map[string]int)
+	for _, record := range records {
+		if schemaName != "" && record.Schema != schemaName {
+			continue
+		}
+		example := types.FeedbackExample{DocumentID: record.DocumentID, Schema: record.Schema, Text: record.Text, Expected: record.Corrected}
+		if i, ok := index[record.DocumentID]; ok {
+			if example.Text == "" {
+				example.Text = examples[i].Text
+			}
+			examples[i] = example
+			continue
+		}
+		index[record.DocumentID] = len(examples)
+		examples = append(examples, example)
+	}
+	return examples, nil
+}
+
+// remember keeps the schema, text and data of an extraction for the feedback recorded on it,
+// forgetting the oldest extractions beyond maxRememberedDocuments
+func (e *Extractor) remember(parsedPdf *types.ParsedPdf, result *types.ExtractionResult, options types.ExtractionOptions) {
+	id, err := documentID(options)
+	if err != nil {
+		return
+	}
+	data, err := jsonData(result.Data)
+	if err != nil {
+		return
+	}
+
+	e.feedbackMu.Lock()
+	defer e.feedbackMu.Unlock()
+	if e.remembered == nil {
+		e.remembered = make(map[string]remembered)
+	}
+	if _, ok := e.remembered[id]; !ok {
+		e.rememberedOrder = append(e.rememberedOrder, id)
+	}
+	e.remembered[id] = remembered{schema: schemaName(options), text: parsedPdf.Content.TextContent, data: data}
+	for len(e.rememberedOrder) > maxRememberedDocuments {
+		delete(e.remembered, e.rememberedOrder[0])
+		e.rememberedOrder = e.rememberedOrder[1:]
+	}
+}
+
+// withFeedbackExamples adds to the instructions of the options the latest corrected documents of
+// the same schema, up to FeedbackExamples, as examples for the model. Documents without text,
+// such as scanned documents, and the document being extracted are left out.
+func (e *Extractor) withFeedbackExamples(ctx context.Context, options types.ExtractionOptions) (types.ExtractionOptions, error) {
+	if e.config.FeedbackStore == nil || e.config.FeedbackExamples <= 0 {
+		return options, nil
+	}
+	name := schemaName(options)
+	if name == "" {
+		return options, nil
+	}
+	dataset, err := e.FeedbackDataset(ctx, name)
+	if err != nil {
+		return options, err
+	}
+	current, _ := documentID(options)
+
+	var b strings.Builder
+	count := 0
+	for i := len(dataset) - 1; i >= 0 && count < e.config.FeedbackExamples; i-- {
+		example := dataset[i]
+		if example.Text == "" || example.DocumentID == current {
+			continue
+		}
+		expected, err := json.Marshal(example.Expected)
+		if err != nil {
+			continue
+		}
+		text := strings.TrimSpace(example.Text)
+		if runes := []rune(text); len(runes) > maxExampleTextLength {
+			text = string(runes[:maxExampleTextLength]) + "\n[...]"
+		}
+		count++
+		fmt.Fprintf(&b, "\n\nExample %d document:\n%s\n\nExample %d data:\n%s", count, text, count, expected)
+	}
+	if count == 0 {
+		return options, nil
+	}
+	options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" +
+		"The following documents were extracted before and their data corrected by reviewers. Follow the same conventions to fill in the data." + b.String())
+	return options, nil
+}
+
+// schemaName names the schema of the options: its SchemaName, or the title of the schema
+func schemaName(options types.ExtractionOptions) string {
+	if options.SchemaName != "" {
+		return options.SchemaName
+	}
+	name, _ := options.Schema["title"].(string)
+	return name
+}
+
+// jsonData copies data through JSON, so that it holds the types of decoded JSON only
+func jsonData(data map[string]interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// changedPaths returns the sorted dot-separated paths of the values that differ between two
+// versions of data, including the values of only one of them
+func changedPaths(before, after map[string]interface{}) []string {
+	flat := func(data map[string]interface{}) map[string]string {
+		values := make(map[string]string)
+		var walk func(value interface{}, path string)
+		walk = func(value interface{}, path string) {
+			join := func(key string) string {
+				if path == "" {
+					return key
+				}
+				return path + "." + key
+			}
+			switch value := value.(type) {
+			case map[string]interface{}:
+				if len(value) == 0 && path != "" {
+					values[path] = "{}"
+				}
+				for key, child := range value {
+					walk(child, join(key))
+				}
+			case []interface{}:
+				if len(value) == 0 {
+					values[path] = "[]"
+				}
+				for i, child := range value {
+					walk(child, join(strconv.Itoa(i)))
+				}
+			default:
+				encoded, _ := json.Marshal(value)
+				values[path] = string(encoded)
+			}
+		}
+		walk(data, "")
+		return values
+	}
+
+	a, b := flat(before), flat(after)
+	paths := make([]string, 0)
+	for path, value := range a {
+		if other, ok := b[path]; !ok || other != value {
+			paths = append(paths, path)
+		}
+	}
+	for path := range b {
+		if _, ok := a[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
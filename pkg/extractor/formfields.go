@@ -0,0 +1,164 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const formFieldInstructions = "Each image is a region cropped from a form, in the order of the fields of the schema. " +
+	"For checkbox fields, answer true only if the box is checked, crossed or filled. " +
+	"For signature fields, answer true only if the box contains a handwritten signature or initials."
+
+// DetectFormFields crops the given checkbox and signature regions out of a PDF given as a file
+// path or a byte slice and asks the vision model whether each one is checked or signed
+func (e *Extractor) DetectFormFields(ctx context.Context, pdf interface{}, fields []types.FormField) (*types.FormFieldResult, error) {
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := readPdf(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.detectFormFields(ctx, buffer, fields, types.ExtractionOptions{})
+}
+
+// detectFormFields runs form field detection on a PDF buffer, honoring the model parameters of options
+func (e *Extractor) detectFormFields(ctx context.Context, buffer []byte, fields []types.FormField, options types.ExtractionOptions) (*types.FormFieldResult, error) {
+	if !e.visionEnabled(options) {
+		return nil, errors.New("form field detection requires vision mode to be enabled")
+	}
+	if err := validateFormFields(fields); err != nil {
+		return nil, err
+	}
+
+	// Render each page referenced by a field once
+	pageIndex := make(map[int]int)
+	pages := make([]int, 0)
+	for _, field := range fields {
+		if _, ok := pageIndex[field.Page]; !ok {
+			pageIndex[field.Page] = len(pages)
+			pages = append(pages, field.Page)
+		}
+	}
+	renders, err := e.renderPages(ctx, buffer, pages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+	if len(options.RedactRegions) > 0 {
+		redacted, err := redactPages(&types.ParsedPdf{Content: types.ParsedPdfContent{ImageContent: renders}}, types.ExtractionOptions{RedactRegions: options.RedactRegions})
+		if err != nil {
+			return nil, err
+		}
+		renders = redacted.Content.ImageContent
+	}
+
+	// Crop every field and describe it in the schema, keyed by position
+	crops := make([]types.PdfPageImage, 0, len(fields))
+	properties := make(map[string]interface{}, len(fields))
+	required := make([]string, 0, len(fields))
+	for i, field := range fields {
+		crop, err := parser.CropPageImage(renders[pageIndex[field.Page]], field.Region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to crop form field %q: %w", field.Name, err)
+		}
+		crops = append(crops, types.PdfPageImage{Page: field.Page, Base64: crop})
+
+		key := fmt.Sprintf("field%d", i+1)
+		description := fmt.Sprintf("Whether the checkbox in image %d is checked", i+1)
+		if field.Kind == types.FormFieldSignature {
+			description = fmt.Sprintf("Whether the signature box in image %d is signed", i+1)
+		}
+		properties[key] = map[string]interface{}{
+			"type":        "boolean",
+			"description": description,
+		}
+		required = append(required, key)
+	}
+
+	options.Schema = map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	options.Instructions = formFieldInstructions
+
+	result, err := e.extractFromImages(ctx, crops, options.Schema, options)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]bool, len(fields))
+	for i, field := range fields {
+		value, _ := result.Data[fmt.Sprintf("field%d", i+1)].(bool)
+		values[field.Name] = value
+	}
+
+	return &types.FormFieldResult{
+		Values:     values,
+		TokensUsed: result.TokensUsed,
+		Usage:      result.Usage,
+		Model:      result.Model,
+	}, nil
+}
+
+// validateFormFields checks that form fields are named, of a known kind and inside their page
+func validateFormFields(fields []types.FormField) error {
+	if len(fields) == 0 {
+		return errors.New("at least one form field must be provided")
+	}
+
+	names := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field.Name == "" {
+			return errors.New("form field name is required")
+		}
+		if names[field.Name] {
+			return fmt.Errorf("duplicate form field %q", field.Name)
+		}
+		names[field.Name] = true
+
+		if field.Kind != types.FormFieldCheckbox && field.Kind != types.FormFieldSignature {
+			return fmt.Errorf("form field %q has invalid kind %q: must be checkbox or signature", field.Name, field.Kind)
+		}
+		if field.Page < 1 {
+			return fmt.Errorf("form field %q has invalid page %d", field.Name, field.Page)
+		}
+		r := field.Region
+		if r.Width <= 0 || r.Height <= 0 || r.X < 0 || r.Y < 0 || r.X+r.Width > 1 || r.Y+r.Height > 1 {
+			return fmt.Errorf("form field %q region must lie within the page (values between 0 and 1)", field.Name)
+		}
+	}
+
+	return nil
+}
+
+// setDataPath stores value in data at a dot-separated path, creating intermediate objects as needed
+func setDataPath(data map[string]interface{}, path string, value interface{}) error {
+	keys := strings.Split(path, ".")
+	current := data
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key]
+		if !ok || next == nil {
+			child := make(map[string]interface{})
+			current[key] = child
+			current = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot set %q: %q is not an object", path, key)
+		}
+		current = child
+	}
+	current[keys[len(keys)-1]] = value
+	return nil
+}
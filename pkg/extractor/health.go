@@ -0,0 +1,82 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout bounds the provider check of a readiness probe
+const readinessTimeout = 5 * time.Second
+
+// HealthCheck verifies that the provider is reachable and accepts the configured credentials, and
+// that it serves the configured text and vision models, with a single models list call
+func (e *Extractor) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := e.authorize(req, nil); err != nil {
+		return err
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OpenAI API is unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("OpenAI API rejected the credentials (status %d): %s", resp.StatusCode, string(body))
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var models struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &models); err != nil {
+		return fmt.Errorf("failed to parse models list: %w", err)
+	}
+	// Some OpenAI-compatible endpoints do not list their models
+	if len(models.Data) == 0 {
+		return nil
+	}
+	available := make(map[string]bool, len(models.Data))
+	for _, model := range models.Data {
+		available[model.ID] = true
+	}
+	for _, model := range []string{e.textModel, e.visionModel} {
+		if !available[model] {
+			return fmt.Errorf("model %s is not available", model)
+		}
+	}
+	return nil
+}
+
+// ReadinessHandler returns an HTTP handler for readiness probes (e.g. /readyz): it answers 200
+// when HealthCheck passes and 503 with the error otherwise
+func (e *Extractor) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := e.HealthCheck(ctx); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, err)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
@@ -0,0 +1,140 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/mrz"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const identityInstructions = "The document is an identity document (passport, identity card, driver license or residence permit). " +
+	"Read the visual inspection zone for the holder's details. Give dates in YYYY-MM-DD format and countries as ISO 3166-1 alpha-3 codes. " +
+	"If the document has a machine readable zone (the lines of uppercase letters, digits and '<' characters at the bottom), " +
+	"transcribe each of its lines exactly, character by character, including every '<'; otherwise return an empty list."
+
+// identitySchema is the built-in JSON schema used for identity documents
+var identitySchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"documentType": map[string]interface{}{
+			"type": "string",
+			"enum": []string{
+				types.IdentityDocumentPassport,
+				types.IdentityDocumentIDCard,
+				types.IdentityDocumentDriverLicense,
+				types.IdentityDocumentResidencePermit,
+				types.IdentityDocumentOther,
+			},
+		},
+		"documentNumber": map[string]interface{}{"type": "string"},
+		"issuingCountry": map[string]interface{}{"type": "string"},
+		"surname":        map[string]interface{}{"type": "string"},
+		"givenNames":     map[string]interface{}{"type": "string"},
+		"nationality":    map[string]interface{}{"type": "string"},
+		"dateOfBirth":    map[string]interface{}{"type": "string"},
+		"sex": map[string]interface{}{
+			"type": "string",
+			"enum": []string{"M", "F", "X"},
+		},
+		"expiryDate": map[string]interface{}{"type": "string"},
+		"mrzLines": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "string",
+			},
+		},
+	},
+	"required": []string{
+		"documentType", "documentNumber", "issuingCountry", "surname", "givenNames",
+		"nationality", "dateOfBirth", "sex", "expiryDate", "mrzLines",
+	},
+	"additionalProperties": false,
+}
+
+// ExtractIdentityDocument extracts the holder's details from a passport, identity card or driver
+// license given as a file path or a byte slice. Pages always go through the vision model. When the
+// document has a machine readable zone, its check digits are verified locally and its values,
+// which are protected by them, take precedence over the visual fields they disagree with.
+func (e *Extractor) ExtractIdentityDocument(ctx context.Context, pdf interface{}) (*types.IdentityDocumentResult, error) {
+	if !e.config.VisionEnabled {
+		return nil, errors.New("identity document extraction requires vision mode to be enabled")
+	}
+
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	options.Schema = identitySchema
+	options.Instructions = identityInstructions
+	options.ForceMode = types.ForceModeVision
+
+	result, err := e.ExtractWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		types.IdentityDocumentResult
+		MRZLines []string
+	}
+	if err := decodeData(result.Data, &data); err != nil {
+		return nil, err
+	}
+	document := data.IdentityDocumentResult
+	document.TokensUsed = result.TokensUsed
+	document.Model = result.Model
+
+	if len(data.MRZLines) > 0 {
+		reconcileMRZ(&document, data.MRZLines)
+	}
+
+	return &document, nil
+}
+
+// reconcileMRZ parses the MRZ lines, records check digit failures and, when the MRZ is valid,
+// replaces the visual fields that disagree with it
+func reconcileMRZ(document *types.IdentityDocumentResult, lines []string) {
+	parsed, err := mrz.Parse(lines)
+	if err != nil {
+		document.Warnings = append(document.Warnings, fmt.Sprintf("MRZ could not be parsed: %v", err))
+		return
+	}
+	document.MRZ = parsed
+
+	if !parsed.Valid {
+		document.Warnings = append(document.Warnings, parsed.Errors...)
+		return
+	}
+
+	fields := []struct {
+		name   string
+		visual *string
+		mrz    string
+	}{
+		{"documentNumber", &document.DocumentNumber, parsed.DocumentNumber},
+		{"issuingCountry", &document.IssuingCountry, parsed.IssuingCountry},
+		{"nationality", &document.Nationality, parsed.Nationality},
+		{"dateOfBirth", &document.DateOfBirth, parsed.DateOfBirth},
+		{"expiryDate", &document.ExpiryDate, parsed.ExpiryDate},
+		{"sex", &document.Sex, parsed.Sex},
+	}
+
+	consistent := true
+	for _, field := range fields {
+		if field.mrz == "" || normalizeMRZField(*field.visual) == normalizeMRZField(field.mrz) {
+			continue
+		}
+		consistent = false
+		document.Warnings = append(document.Warnings, fmt.Sprintf("%s %q does not match MRZ value %q, using the MRZ value", field.name, *field.visual, field.mrz))
+		*field.visual = field.mrz
+	}
+	document.Validated = consistent
+}
+
+// normalizeMRZField makes visual and MRZ values comparable
+func normalizeMRZField(value string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(value), ""))
+}
@@ -0,0 +1,129 @@
+package extractor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"math"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	// maxLowDetailSide is the largest side, in pixels, of images the model gets as much of at low
+	// detail as at high detail
+	maxLowDetailSide = 512
+	// maxSimpleInk is the largest share of dark pixels of a simple page, such as a blank page or a
+	// page with a few lines, sent at low detail in adaptive mode
+	maxSimpleInk = 0.01
+	// inkLuma is the luma below which a pixel is dark
+	inkLuma = 160
+)
+
+// tileInstructions tells the model how to read pages sent as tiles
+const tileInstructions = "Large pages are given as overlapping tiles, each preceded by its position on the page. Text in the overlap of two tiles appears in both: read it once."
+
+// pageImageParts returns the content parts of a page image for the vision model: the image itself,
+// or its tiles with their position when it is larger than the tile size of the config. It reports
+// whether the page was tiled.
+func (e *Extractor) pageImageParts(img types.PdfPageImage) ([]map[string]interface{}, bool, error) {
+	tiles := []types.PageTile{{Page: img.Page, Base64: img.Base64}}
+	if e.config.TileSize > 0 {
+		var err error
+		if tiles, err = parser.TilePageImage(img, e.config.TileSize); err != nil {
+			return nil, false, err
+		}
+	}
+	parts := make([]map[string]interface{}, 0, 2*len(tiles))
+	for i, tile := range tiles {
+		if len(tiles) > 1 {
+			parts = append(parts, map[string]interface{}{
+				"type": "text",
+				"text": fmt.Sprintf("[Page %d, tile %d of %d: %s]", tile.Page, i+1, len(tiles), tilePosition(tile.Region)),
+			})
+		}
+		part, err := e.imagePart(tile.Base64, e.imageDetail(img.Detail, tile.Base64))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to encode page %d image: %w", tile.Page, err)
+		}
+		parts = append(parts, part)
+	}
+	return parts, len(tiles) > 1, nil
+}
+
+// imagePart returns the content part of a base64-encoded PNG image, in the image format of the
+// config and at the given detail level, if any
+func (e *Extractor) imagePart(base64PNG, detail string) (map[string]interface{}, error) {
+	encoded, mimeType, err := parser.EncodePageImage(base64PNG, e.config.ImageFormat, e.config.ImageEncoderPath)
+	if err != nil {
+		return nil, err
+	}
+	imageURL := map[string]interface{}{
+		"url": fmt.Sprintf("data:%s;base64,%s", mimeType, encoded),
+	}
+	if detail != "" {
+		imageURL["detail"] = detail
+	}
+	return map[string]interface{}{
+		"type":      "image_url",
+		"image_url": imageURL,
+	}, nil
+}
+
+// imageDetail returns the detail level of an image: the detail of the page image, if any, or else
+// the detail of the config, resolved for the image in adaptive mode
+func (e *Extractor) imageDetail(pageDetail, base64PNG string) string {
+	detail := pageDetail
+	if detail == "" {
+		detail = e.config.ImageDetail
+	}
+	if detail != types.ImageDetailAdaptive {
+		return detail
+	}
+	if isSimpleImage(base64PNG) {
+		return types.ImageDetailLow
+	}
+	return types.ImageDetailHigh
+}
+
+// isSimpleImage reports whether a base64-encoded PNG image loses nothing at low detail: it fits
+// in maxLowDetailSide pixels, or it has almost no ink, such as a blank or separator page
+func isSimpleImage(base64PNG string) bool {
+	raw, err := base64.StdEncoding.DecodeString(base64PNG)
+	if err != nil {
+		return false
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return false
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() <= maxLowDetailSide && bounds.Dy() <= maxLowDetailSide {
+		return true
+	}
+
+	// Sample a grid of about 256x256 pixels, enough to tell sparse pages from text
+	step := max(1, max(bounds.Dx(), bounds.Dy())/256)
+	samples, ink := 0, 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			// ITU-R BT.601 luma, on 8 bits
+			if (299*r+587*g+114*b)/1000>>8 < inkLuma {
+				ink++
+			}
+			samples++
+		}
+	}
+	return float64(ink) <= maxSimpleInk*float64(samples)
+}
+
+// tilePosition describes the region of a tile as percentages of the page, e.g.
+// "x 0-55%, y 45-100%"
+func tilePosition(region types.Rect) string {
+	percent := func(value float64) int { return int(math.Round(value * 100)) }
+	return fmt.Sprintf("x %d-%d%%, y %d-%d%%",
+		percent(region.X), percent(region.X+region.Width), percent(region.Y), percent(region.Y+region.Height))
+}
@@ -0,0 +1,61 @@
+package extractor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// index hands the text and extracted data of a document to the configured indexer
+func (e *Extractor) index(ctx context.Context, parsedPdf *types.ParsedPdf, result *types.ExtractionResult, options types.ExtractionOptions) error {
+	id, err := documentID(options)
+	if err != nil {
+		return err
+	}
+
+	document := types.IndexDocument{
+		ID:       id,
+		Text:     parsedPdf.Content.TextContent,
+		Data:     result.Data,
+		NumPages: parsedPdf.NumPages,
+		Model:    result.Model,
+	}
+	if err := e.config.Indexer.Index(ctx, document); err != nil {
+		return fmt.Errorf("failed to index document %s: %w", id, err)
+	}
+	return nil
+}
+
+// documentID identifies the document of the options: its DocumentID, its path, or the SHA-256 of
+// the PDF
+func documentID(options types.ExtractionOptions) (string, error) {
+	if options.DocumentID != "" {
+		return options.DocumentID, nil
+	}
+	if options.PDFPath != "" {
+		return options.PDFPath, nil
+	}
+	buffer, err := readPdf(options)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buffer)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// transcriptionText joins the transcribed lines of pages into the text of the document
+func transcriptionText(pages []types.PageLayout) string {
+	var b strings.Builder
+	for _, page := range pages {
+		for _, line := range page.Lines {
+			b.WriteString(line.Text)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package extractor
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const invoiceInstructions = "The document is an invoice. Map it to the European e-invoicing semantic model (EN 16931). " +
+	"Give dates as YYYY-MM-DD, the currency as an ISO 4217 code, countries as ISO 3166-1 alpha-2 codes, units of measure as UN/ECE Recommendation 20 codes (C62 for pieces) and VAT categories as UNCL5305 codes (S standard, Z zero rated, E exempt, AE reverse charge). " +
+	"Use type code 380 for invoices and 381 for credit notes. Use 0 for amounts and an empty string for texts that are not on the invoice."
+
+// invoiceSchema is the built-in JSON schema used for invoices
+var invoiceSchema = strictObject(map[string]interface{}{
+	"number":                 stringProperty,
+	"issueDate":              stringProperty,
+	"typeCode":               stringProperty,
+	"currency":               stringProperty,
+	"dueDate":                stringProperty,
+	"buyerReference":         stringProperty,
+	"purchaseOrderReference": stringProperty,
+	"paymentTerms":           stringProperty,
+	"seller":                 invoicePartySchema,
+	"buyer":                  invoicePartySchema,
+	"paymentIban":            stringProperty,
+	"paymentReference":       stringProperty,
+	"lines": map[string]interface{}{
+		"type": "array",
+		"items": strictObject(map[string]interface{}{
+			"id":          stringProperty,
+			"name":        stringProperty,
+			"quantity":    numberProperty,
+			"unitCode":    stringProperty,
+			"netPrice":    numberProperty,
+			"netAmount":   numberProperty,
+			"vatCategory": stringProperty,
+			"vatRate":     numberProperty,
+		}),
+	},
+	"vatBreakdown": map[string]interface{}{
+		"type": "array",
+		"items": strictObject(map[string]interface{}{
+			"category":      stringProperty,
+			"rate":          numberProperty,
+			"taxableAmount": numberProperty,
+			"taxAmount":     numberProperty,
+		}),
+	},
+	"lineTotal":         numberProperty,
+	"taxExclusiveTotal": numberProperty,
+	"taxTotal":          numberProperty,
+	"taxInclusiveTotal": numberProperty,
+	"paidAmount":        numberProperty,
+	"payableAmount":     numberProperty,
+})
+
+// invoicePartySchema is the JSON schema of an invoice seller or buyer
+var invoicePartySchema = strictObject(map[string]interface{}{
+	"name":                stringProperty,
+	"vatId":               stringProperty,
+	"legalRegistrationId": stringProperty,
+	"street":              stringProperty,
+	"city":                stringProperty,
+	"postalCode":          stringProperty,
+	"countryCode":         stringProperty,
+})
+
+var (
+	stringProperty = map[string]interface{}{"type": "string"}
+	numberProperty = map[string]interface{}{"type": "number"}
+)
+
+// strictObject builds an object schema for strict structured outputs, where every property is
+// required and no other property is allowed
+func strictObject(properties map[string]interface{}) map[string]interface{} {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// ExtractInvoice extracts an invoice given as a file path or a byte slice into the European
+// e-invoicing semantic model (EN 16931). The result can be serialized to UBL or CII XML with the
+// einvoice package.
+func (e *Extractor) ExtractInvoice(ctx context.Context, pdf interface{}) (*types.InvoiceResult, error) {
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	options.Schema = invoiceSchema
+	options.Instructions = invoiceInstructions
+
+	result, err := e.ExtractWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := &types.InvoiceResult{}
+	if err := decodeData(result.Data, &invoice.Invoice); err != nil {
+		return nil, err
+	}
+	if invoice.Invoice.TypeCode == "" {
+		invoice.Invoice.TypeCode = "380"
+	}
+	invoice.TokensUsed = result.TokensUsed
+	invoice.Model = result.Model
+
+	return invoice, nil
+}
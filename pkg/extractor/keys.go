@@ -0,0 +1,56 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultKeyRefreshInterval is how long a key of the KeyProvider is used before it is fetched again
+const defaultKeyRefreshInterval = 5 * time.Minute
+
+// currentAPIKey returns the API key of the next request: the static key, or the key of the
+// KeyProvider, fetched again once the refresh interval has passed or the key was rejected
+func (e *Extractor) currentAPIKey(ctx context.Context) (string, error) {
+	if e.config.KeyProvider == nil {
+		return e.apiKey, nil
+	}
+
+	e.keyMu.Lock()
+	defer e.keyMu.Unlock()
+	interval := e.config.KeyRefreshInterval
+	if interval == 0 {
+		interval = defaultKeyRefreshInterval
+	}
+	if e.keyFetched.IsZero() || time.Since(e.keyFetched) >= interval {
+		key, err := e.config.KeyProvider.APIKey(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get API key: %w", err)
+		}
+		e.apiKey, e.keyFetched = key, time.Now()
+	}
+	return e.apiKey, nil
+}
+
+// expirer is implemented by signers whose credentials can be fetched anew, such as OAuth2 tokens
+type expirer interface {
+	Expire()
+}
+
+// expireCredentials makes the next request fetch the key of the KeyProvider, or the credentials of
+// the signer, again after the provider rejected them. It reports whether there are credentials to
+// fetch anew.
+func (e *Extractor) expireCredentials() bool {
+	expired := false
+	if e.config.KeyProvider != nil {
+		e.keyMu.Lock()
+		e.keyFetched = time.Time{}
+		e.keyMu.Unlock()
+		expired = true
+	}
+	if signer, ok := e.config.Signer.(expirer); ok {
+		signer.Expire()
+		expired = true
+	}
+	return expired
+}
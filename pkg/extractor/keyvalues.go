@@ -0,0 +1,62 @@
+package extractor
+
+import (
+	"context"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const keyValueInstructions = "List every label:value pair in the document, such as form fields, header fields and labelled totals, in reading order. " +
+	"Copy labels and values exactly as printed, without the trailing colon of the label. " +
+	"Use an empty string as the value of labels that have been left blank."
+
+// keyValueSchema is the built-in JSON schema used for key-value extraction
+var keyValueSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"pairs": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"key": map[string]interface{}{
+						"type": "string",
+					},
+					"value": map[string]interface{}{
+						"type": "string",
+					},
+				},
+				"required":             []string{"key", "value"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"pairs"},
+	"additionalProperties": false,
+}
+
+// ExtractKeyValues returns all label:value pairs found in a PDF given as a file path or a byte
+// slice. It needs no schema, which makes it useful to explore form-like documents before
+// writing one.
+func (e *Extractor) ExtractKeyValues(ctx context.Context, pdf interface{}) (*types.KeyValueResult, error) {
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	options.Schema = keyValueSchema
+	options.Instructions = keyValueInstructions
+
+	result, err := e.ExtractWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	keyValues := &types.KeyValueResult{}
+	if err := decodeData(result.Data, keyValues); err != nil {
+		return nil, err
+	}
+	keyValues.TokensUsed = result.TokensUsed
+	keyValues.Model = result.Model
+
+	return keyValues, nil
+}
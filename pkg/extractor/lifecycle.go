@@ -0,0 +1,97 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrClosed is returned by extractions started after the extractor was closed
+var ErrClosed = errors.New("extractor is closed")
+
+// inFlightKey marks the context of a tracked operation, so that the calls it makes are not
+// rejected when the extractor is closed before they start
+type inFlightKey struct{}
+
+// begin tracks an extraction or model call until the returned function is called, or fails with
+// ErrClosed once the extractor is closed. Operations nested in a tracked one are tracked with it.
+func (e *Extractor) begin(ctx context.Context) (context.Context, func(), error) {
+	if ctx.Value(inFlightKey{}) == e {
+		return ctx, func() {}, nil
+	}
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+	if e.closed {
+		return nil, nil, ErrClosed
+	}
+	e.running.Add(1)
+	e.inFlight++
+	done := func() {
+		e.lifecycleMu.Lock()
+		e.inFlight--
+		e.lifecycleMu.Unlock()
+		e.running.Done()
+	}
+	return context.WithValue(ctx, inFlightKey{}, e), done, nil
+}
+
+// InFlight returns the number of extractions and model calls in flight, the calls nested in an
+// extraction counted with it
+func (e *Extractor) InFlight() int {
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+	return e.inFlight
+}
+
+// Shutdown stops the extractor gracefully, for long-running services and batch jobs: new
+// extractions fail with ErrClosed, the ones in flight are waited for until ctx is done, and then
+// the Publisher, Recorder, Indexer and FeedbackStore are flushed and closed when they implement io.Closer, the cached API
+// key is dropped and idle connections are closed. MuPDF documents are closed by the extractions
+// that opened them, so none is left open once they are done. Shutdown can be called again, e.g.
+// after ctx expired, to wait for the remaining extractions.
+func (e *Extractor) Shutdown(ctx context.Context) error {
+	e.lifecycleMu.Lock()
+	e.closed = true
+	e.lifecycleMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		e.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("extractions still in flight: %w", ctx.Err())
+	}
+
+	e.lifecycleMu.Lock()
+	defer e.lifecycleMu.Unlock()
+	if e.released {
+		return nil
+	}
+	e.released = true
+
+	var errs []error
+	for _, sink := range []interface{}{e.config.Publisher, e.config.Recorder, e.config.Indexer, e.config.FeedbackStore} {
+		if closer, ok := sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	e.keyMu.Lock()
+	if e.config.KeyProvider != nil {
+		e.apiKey, e.keyFetched = "", time.Time{}
+	}
+	e.keyMu.Unlock()
+	e.client.CloseIdleConnections()
+	return errors.Join(errs...)
+}
+
+// Close shuts the extractor down, waiting for all extractions in flight; see Shutdown
+func (e *Extractor) Close() error {
+	return e.Shutdown(context.Background())
+}
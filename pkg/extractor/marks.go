@@ -0,0 +1,114 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const markInstructions = "The images are the pages of the document, in order, starting at page 1. " +
+	"Report whether the document contains a handwritten signature, a stamp or seal, and a logo. " +
+	"List every such mark with its page number and its bounding box, given as fractions of the page width and height (0 to 1) measured from the top-left corner."
+
+// markSchema is the built-in JSON schema used for mark detection
+var markSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"hasSignature": map[string]interface{}{
+			"type": "boolean",
+		},
+		"hasStamp": map[string]interface{}{
+			"type": "boolean",
+		},
+		"hasLogo": map[string]interface{}{
+			"type": "boolean",
+		},
+		"marks": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"kind": map[string]interface{}{
+						"type": "string",
+						"enum": []string{types.MarkKindSignature, types.MarkKindStamp, types.MarkKindLogo},
+					},
+					"page": map[string]interface{}{
+						"type": "integer",
+					},
+					"bounds": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"x":      map[string]interface{}{"type": "number"},
+							"y":      map[string]interface{}{"type": "number"},
+							"width":  map[string]interface{}{"type": "number"},
+							"height": map[string]interface{}{"type": "number"},
+						},
+						"required":             []string{"x", "y", "width", "height"},
+						"additionalProperties": false,
+					},
+				},
+				"required":             []string{"kind", "page", "bounds"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"hasSignature", "hasStamp", "hasLogo", "marks"},
+	"additionalProperties": false,
+}
+
+// DetectMarks asks the vision model whether a PDF given as a file path or a byte slice contains
+// signatures, stamps or logos. Every page is rendered and sent, whether or not it has a text
+// layer, and each detected mark is returned with its image cropped from the page render.
+func (e *Extractor) DetectMarks(ctx context.Context, pdf interface{}) (*types.MarkDetectionResult, error) {
+	if !e.config.VisionEnabled {
+		return nil, errors.New("mark detection requires vision mode to be enabled")
+	}
+
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	options.Schema = markSchema
+	options.Instructions = markInstructions
+
+	buffer, err := readPdf(options)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := e.renderPages(ctx, buffer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	result, err := e.extractFromImages(ctx, pages, options.Schema, options)
+	if err != nil {
+		return nil, err
+	}
+
+	detection := &types.MarkDetectionResult{}
+	if err := decodeData(result.Data, detection); err != nil {
+		return nil, err
+	}
+	detection.TokensUsed = result.TokensUsed
+	detection.Model = result.Model
+
+	// Keep the marks that point at a real page and attach their crops
+	marks := make([]types.Mark, 0, len(detection.Marks))
+	for _, mark := range detection.Marks {
+		if mark.Page < 1 || mark.Page > len(pages) {
+			continue
+		}
+		crop, err := parser.CropPageImage(pages[mark.Page-1], mark.Bounds)
+		if err != nil {
+			continue
+		}
+		mark.Base64 = crop
+		marks = append(marks, mark)
+	}
+	detection.Marks = marks
+
+	return detection, nil
+}
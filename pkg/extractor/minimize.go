@@ -0,0 +1,265 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	// minimizeLinesBefore and minimizeLinesAfter are the lines kept around a line mentioning an
+	// anchor, as labels and their values are often on separate lines
+	minimizeLinesBefore = 1
+	minimizeLinesAfter  = 2
+	// minAnchorWordLength is the length of the shortest word of a schema used as an anchor
+	minAnchorWordLength = 3
+	// minAnchorPrefix is the length of the shortest word matching the words it starts, e.g.
+	// "invoice" and "invoiced"
+	minAnchorPrefix = 4
+	// omittedMarker replaces the lines left out of minimized text
+	omittedMarker = "[...]"
+)
+
+// minimizeInstructions tell the model about the passages left out of minimized documents
+const minimizeInstructions = "Only the passages of the document relevant to the requested data are given; the other passages are left out and marked with [...]."
+
+// genericWords are the words of field names that do not mark relevant passages
+var genericWords = map[string]bool{"and": true, "the": true, "for": true, "with": true, "from": true, "value": true, "data": true, "info": true, "list": true, "item": true, "items": true}
+
+// minimizedKey marks the context of an extraction with ExtractionOptions.Minimize set
+type minimizedKey struct{}
+
+// minimized reports whether the context is of an extraction with ExtractionOptions.Minimize set
+func minimized(ctx context.Context) bool {
+	set, _ := ctx.Value(minimizedKey{}).(bool)
+	return set
+}
+
+// validateMinimize checks that an extraction with ExtractionOptions.Minimize set writes no content
+// of the document to disk
+func (e *Extractor) validateMinimize(options types.ExtractionOptions) error {
+	if !options.Minimize {
+		return nil
+	}
+	if e.config.Renderer == types.RendererPdftoppm || e.config.Renderer == types.RendererGhostscript {
+		return fmt.Errorf("data minimization cannot be used with the %s renderer, which writes the PDF to a temporary file", e.config.Renderer)
+	}
+	if e.config.ImageFormat == types.ImageFormatWebP || e.config.ImageFormat == types.ImageFormatAVIF {
+		return fmt.Errorf("data minimization cannot be used with %s images, whose encoder reads the pages from temporary files", e.config.ImageFormat)
+	}
+	return nil
+}
+
+// minimizePdf narrows a parsed PDF to the passages relevant to a schema when the options set
+// Minimize: the lines mentioning the words of its field names, titles and enum values, or
+// ExtractionOptions.MinimizeAnchors, with the lines around them. The text recognized by the OCR
+// backend is sent instead of the page images of scanned documents. Scanned pages without text to
+// search are sent whole, with a warning.
+func minimizePdf(parsedPdf *types.ParsedPdf, schemaData map[string]interface{}, options types.ExtractionOptions) (*types.ParsedPdf, types.ExtractionOptions, []string) {
+	if !options.Minimize {
+		return parsedPdf, options, nil
+	}
+	anchors := schemaAnchors(schemaData)
+	for _, anchor := range options.MinimizeAnchors {
+		if words := titleWords(anchor); len(words) > 0 {
+			anchors = append(anchors, words)
+		}
+	}
+
+	// The parsed PDF may be shared with other extractions, so narrow a copy
+	narrowed := *parsedPdf
+	content := &narrowed.Content
+	var warnings []string
+	switch {
+	case content.Type == "text":
+		content.TextPages = make([]string, len(parsedPdf.Content.TextPages))
+		for i, page := range parsedPdf.Content.TextPages {
+			content.TextPages[i], _ = minimizeText(page, anchors)
+		}
+		content.TextContent = strings.Join(content.TextPages, "\n") + "\n"
+	case content.Type == "mixed":
+		content.TextPages = make([]string, len(parsedPdf.Content.TextPages))
+		content.PageTypes = append([]string(nil), parsedPdf.Content.PageTypes...)
+		scanned := make([]int, 0)
+		for i, pageType := range content.PageTypes {
+			if pageType == "images" {
+				scanned = append(scanned, i+1)
+				continue
+			}
+			if i < len(content.TextPages) {
+				var found bool
+				if content.TextPages[i], found = minimizeText(parsedPdf.Content.TextPages[i], anchors); !found {
+					// Leave pages without relevant passages out of the request
+					content.PageTypes[i] = ""
+				}
+			}
+		}
+		content.TextContent = strings.Join(content.TextPages, "\n") + "\n"
+		if len(scanned) > 0 {
+			warnings = append(warnings, fmt.Sprintf("data minimization: scanned pages %s have no text to find the relevant passages in and are sent whole", pageList(scanned)))
+		}
+	case content.OCRText != "":
+		text, _ := minimizeText(content.OCRText, anchors)
+		content.Type = "text"
+		content.TextPages = []string{text}
+		content.TextContent = text + "\n"
+		content.ImageContent = nil
+		content.OCRText = ""
+	default:
+		pages := make([]int, 0, len(content.ImageContent))
+		for _, image := range content.ImageContent {
+			pages = append(pages, image.Page)
+		}
+		warnings = append(warnings, fmt.Sprintf("data minimization: scanned pages %s have no text to find the relevant passages in and are sent whole", pageList(pages)))
+	}
+	if content.Type == "text" && strings.TrimSpace(strings.ReplaceAll(content.TextContent, omittedMarker, "")) == "" {
+		warnings = append(warnings, "data minimization: no passage of the document mentions the fields of the schema, set ExtractionOptions.MinimizeAnchors to the labels of the values to extract")
+	}
+	options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" + minimizeInstructions)
+	return &narrowed, options, warnings
+}
+
+// minimizeText keeps the lines of a text mentioning an anchor, and the lines around them,
+// replacing every run of other lines by a marker. It reports whether a line mentions an anchor.
+func minimizeText(text string, anchors [][]string) (string, bool) {
+	lines := strings.Split(text, "\n")
+	keep := make([]bool, len(lines))
+	found := false
+	for i, line := range lines {
+		if !mentionsAnchor(titleWords(line), anchors) {
+			continue
+		}
+		found = true
+		for j := max(0, i-minimizeLinesBefore); j <= min(len(lines)-1, i+minimizeLinesAfter); j++ {
+			keep[j] = true
+		}
+	}
+
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		switch {
+		case keep[i]:
+			kept = append(kept, line)
+		case strings.TrimSpace(line) == "":
+		case len(kept) == 0 || kept[len(kept)-1] != omittedMarker:
+			kept = append(kept, omittedMarker)
+		}
+	}
+	return strings.Join(kept, "\n"), found
+}
+
+// mentionsAnchor reports whether the words of a line hold an anchor. A single word also matches
+// the words it starts or that start it, e.g. "date" and "dated"; phrases match exactly.
+func mentionsAnchor(words []string, anchors [][]string) bool {
+	for _, anchor := range anchors {
+		if len(anchor) > 1 {
+			if strings.Contains(" "+strings.Join(words, " ")+" ", " "+strings.Join(anchor, " ")+" ") {
+				return true
+			}
+			continue
+		}
+		for _, word := range words {
+			if word == anchor[0] || (len(word) >= minAnchorPrefix && len(anchor[0]) >= minAnchorPrefix &&
+				(strings.HasPrefix(word, anchor[0]) || strings.HasPrefix(anchor[0], word))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaAnchors returns the words of the field names and titles of a schema, and its enum values as
+// phrases, that mark the passages of a document relevant to it
+func schemaAnchors(schemaData map[string]interface{}) [][]string {
+	seen := make(map[string]bool)
+	anchors := make([][]string, 0)
+	add := func(words []string) {
+		key := strings.Join(words, " ")
+		if len(words) == 0 || seen[key] {
+			return
+		}
+		seen[key] = true
+		anchors = append(anchors, words)
+	}
+	addWords := func(text string) {
+		for _, word := range titleWords(splitFieldName(text)) {
+			if len(word) >= minAnchorWordLength && !genericWords[word] {
+				add([]string{word})
+			}
+		}
+	}
+
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			if properties, ok := node["properties"].(map[string]interface{}); ok {
+				names := make([]string, 0, len(properties))
+				for name := range properties {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					addWords(name)
+					walk(properties[name])
+				}
+			}
+			if title, ok := node["title"].(string); ok {
+				addWords(title)
+			}
+			if values, ok := node["enum"].([]interface{}); ok {
+				for _, value := range values {
+					if text, ok := value.(string); ok {
+						add(titleWords(text))
+					}
+				}
+			}
+			for _, key := range []string{"items", "anyOf", "oneOf", "allOf"} {
+				walk(node[key])
+			}
+			for _, key := range []string{"$defs", "definitions"} {
+				definitions, _ := node[key].(map[string]interface{})
+				for _, definition := range definitions {
+					walk(definition)
+				}
+			}
+		case []interface{}:
+			for _, item := range node {
+				walk(item)
+			}
+		}
+	}
+	walk(schemaData)
+	return anchors
+}
+
+// splitFieldName separates the words of a camelCase field name, e.g. "invoiceNumber" into
+// "invoice Number" and "IBANCode" into "IBAN Code"
+func splitFieldName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// pageList formats page numbers, e.g. "2, 3 and 5"
+func pageList(pages []int) string {
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		texts[i] = fmt.Sprint(page)
+	}
+	if len(texts) <= 1 {
+		return strings.Join(texts, "")
+	}
+	return strings.Join(texts[:len(texts)-1], ", ") + " and " + texts[len(texts)-1]
+}
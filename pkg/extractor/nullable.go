@@ -0,0 +1,132 @@
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// fieldStatusProperty is the property of the schema where the model reports the status of the
+// nullable fields
+const fieldStatusProperty = "_field_status"
+
+const nullableInstructions = "Never guess the fields listed in " + fieldStatusProperty + ". When the document does not contain one, " +
+	"return null for it and not_present as its status; when the document contains it but it cannot be read, return null and illegible. " +
+	"Otherwise return its value and found."
+
+// nullableSchema returns a copy of a schema where the fields at the given paths accept null, with a
+// required property reporting the status of each of them
+func nullableSchema(schemaData map[string]interface{}, fields []string) (map[string]interface{}, error) {
+	nullable, _ := cloneData(schemaData).(map[string]interface{})
+	properties, _ := nullable["properties"].(map[string]interface{})
+	if properties == nil {
+		return nil, errors.New("nullable fields require an object schema")
+	}
+	if _, ok := properties[fieldStatusProperty]; ok {
+		return nil, fmt.Errorf("schema property %q is reserved for nullable fields", fieldStatusProperty)
+	}
+
+	statuses := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		fieldSchema, err := schemaAtPath(nullable, field)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := statuses[field]; ok {
+			return nil, fmt.Errorf("duplicate nullable field %q", field)
+		}
+		allowNull(fieldSchema)
+		statuses[field] = map[string]interface{}{
+			"type": "string",
+			"enum": []string{types.FieldStatusFound, types.FieldStatusNotPresent, types.FieldStatusIllegible},
+		}
+	}
+
+	properties[fieldStatusProperty] = map[string]interface{}{
+		"type":                 "object",
+		"properties":           statuses,
+		"required":             slices.Clone(fields),
+		"additionalProperties": false,
+	}
+	var required []interface{}
+	switch list := nullable["required"].(type) {
+	case []string:
+		for _, name := range list {
+			required = append(required, name)
+		}
+	case []interface{}:
+		required = append(required, list...)
+	}
+	nullable["required"] = append(required, fieldStatusProperty)
+	return nullable, nil
+}
+
+// allowNull makes a field schema accept null, in place
+func allowNull(fieldSchema map[string]interface{}) {
+	switch kind := fieldSchema["type"].(type) {
+	case string:
+		if kind != "null" {
+			fieldSchema["type"] = []interface{}{kind, "null"}
+		}
+	case []interface{}:
+		if !slices.Contains(kind, interface{}("null")) {
+			fieldSchema["type"] = append(slices.Clone(kind), "null")
+		}
+	case []string:
+		if !slices.Contains(kind, "null") {
+			fieldSchema["type"] = append(slices.Clone(kind), "null")
+		}
+	default:
+		// Schemas without a type, such as anyOf unions, accept null as another alternative
+		alternative := make(map[string]interface{}, len(fieldSchema))
+		for key, value := range fieldSchema {
+			if key != "description" {
+				alternative[key] = value
+				delete(fieldSchema, key)
+			}
+		}
+		fieldSchema["anyOf"] = []interface{}{alternative, map[string]interface{}{"type": "null"}}
+		return
+	}
+
+	switch values := fieldSchema["enum"].(type) {
+	case []interface{}:
+		if !slices.Contains(values, nil) {
+			fieldSchema["enum"] = append(slices.Clone(values), nil)
+		}
+	case []string:
+		enum := make([]interface{}, 0, len(values)+1)
+		for _, value := range values {
+			enum = append(enum, value)
+		}
+		fieldSchema["enum"] = append(enum, nil)
+	}
+}
+
+// fieldStatus removes the reported status of the nullable fields from extracted data and returns
+// it by path. Fields the model did not find are set to null, and null fields without a status are
+// reported as not present.
+func fieldStatus(data map[string]interface{}, fields []string) map[string]string {
+	reported, _ := data[fieldStatusProperty].(map[string]interface{})
+	delete(data, fieldStatusProperty)
+
+	statuses := make(map[string]string, len(fields))
+	for _, field := range fields {
+		status, _ := reported[field].(string)
+		value, _ := dataAtPath(data, field)
+		switch {
+		case status == types.FieldStatusNotPresent || status == types.FieldStatusIllegible:
+			if value != nil {
+				setDataPath(data, field, nil)
+			}
+		case value == nil:
+			status = types.FieldStatusNotPresent
+		default:
+			status = types.FieldStatusFound
+		}
+		statuses[field] = status
+	}
+	return statuses
+}
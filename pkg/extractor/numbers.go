@@ -0,0 +1,93 @@
+package extractor
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// magnitudeScales are the factors by which a misread decimal separator changes an amount: "1.234"
+// read as 1.234 instead of 1234, or "12,50" read as 1250 instead of 12.5
+var magnitudeScales = []float64{1000, 0.001, 100, 0.01}
+
+// checkNumbers fixes in place the number fields of the data: amounts extracted as strings, such as
+// "1.234,56", are parsed with the decimal separator of the document, detected from its text unless
+// given, and numbers absent from the text whose magnitude is off by a misread decimal separator
+// are replaced by the number of the text. It returns a warning for every value changed, sorted.
+func checkNumbers(parsedPdf *types.ParsedPdf, schemaData, data map[string]interface{}, decimalSeparator string) ([]string, error) {
+	text := parsedPdf.Content.TextContent + "\n" + parsedPdf.Content.OCRText
+	if decimalSeparator == "" {
+		decimalSeparator = parser.DetectDecimalSeparator(text)
+	}
+	if decimalSeparator != "." && decimalSeparator != "," {
+		return nil, fmt.Errorf("invalid decimal separator %q", decimalSeparator)
+	}
+	numbers := parser.ExtractNumbers(text, decimalSeparator)
+
+	var warnings []string
+	changes := make(map[string]interface{})
+	walkValues(data, "", func(path string, value interface{}) {
+		fieldSchema := schemaAtValuePath(schemaData, path)
+		integer := schemaTypeIs(fieldSchema, "integer")
+		if !integer && !schemaTypeIs(fieldSchema, "number") {
+			return
+		}
+		switch value := value.(type) {
+		case string:
+			number, err := parser.ParseNumber(value, decimalSeparator)
+			if err != nil || (integer && number != math.Trunc(number)) {
+				return
+			}
+			changes[path] = number
+			warnings = append(warnings, fmt.Sprintf("%s = %q parsed as %s with %q as decimal separator", path, value, formatNumber(number), decimalSeparator))
+		case float64:
+			if value == 0 || len(numbers) == 0 || numberFound(numbers, value) {
+				return
+			}
+			for _, scale := range magnitudeScales {
+				scaled := value * scale
+				if rounded := math.Round(scaled*100) / 100; math.Abs(rounded-scaled) < numberTolerance*math.Max(1, math.Abs(scaled)) {
+					scaled = rounded
+				}
+				if (integer && scaled != math.Trunc(scaled)) || !slices.ContainsFunc(numbers, func(number float64) bool {
+					return math.Abs(number-math.Abs(scaled)) < numberTolerance
+				}) {
+					continue
+				}
+				changes[path] = scaled
+				warnings = append(warnings, fmt.Sprintf("%s = %s corrected to %s, the number of the document read with %q as decimal separator", path, formatNumber(value), formatNumber(scaled), decimalSeparator))
+				return
+			}
+		}
+	})
+	for path, value := range changes {
+		if err := setValuePath(data, path, value); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(warnings)
+	return warnings, nil
+}
+
+// schemaTypeIs reports whether the type of a schema is, or includes, a JSON type
+func schemaTypeIs(fieldSchema map[string]interface{}, kind string) bool {
+	switch declared := fieldSchema["type"].(type) {
+	case string:
+		return declared == kind
+	case []interface{}:
+		return slices.Contains(declared, interface{}(kind))
+	case []string:
+		return slices.Contains(declared, kind)
+	}
+	return false
+}
+
+// formatNumber formats a number as in JSON
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
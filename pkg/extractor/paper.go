@@ -0,0 +1,212 @@
+package extractor
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// paperFrontPages is the number of leading pages searched for bibliographic metadata
+const paperFrontPages = 2
+
+const (
+	paperInstructions = "The document is a scientific paper. Extract its bibliographic metadata: the title, the authors in order with their affiliations and emails, the abstract, the keywords, its own DOI, venue and publication year. " +
+		"Use an empty string for texts and 0 for years that are not stated."
+	referenceInstructions = "Parse every entry of the reference list, in printed order, into a structured citation. Copy the entry as printed in raw, list the authors' names in order, " +
+		"and give the title of the cited work, its publication year (0 when not stated), its venue (journal, conference or publisher) and its DOI when printed. Use an empty string for texts that are not stated."
+)
+
+var (
+	// referencesHeadingPattern matches the heading of a reference list on its own line
+	referencesHeadingPattern = regexp.MustCompile(`(?im)^[ \t]*(?:[0-9]+\.?|[IVX]+\.)?[ \t]*(?:references|bibliography|works cited|literature cited)[ \t]*$`)
+	// doiPattern matches a DOI, with or without resolver prefix
+	doiPattern = regexp.MustCompile(`(?i)\b10\.\d{4,9}/[^\s"<>]+`)
+)
+
+// citationSchema is the JSON schema of a structured citation
+var citationSchema = strictObject(map[string]interface{}{
+	"raw":     stringProperty,
+	"authors": map[string]interface{}{"type": "array", "items": stringProperty},
+	"title":   stringProperty,
+	"year":    map[string]interface{}{"type": "integer"},
+	"venue":   stringProperty,
+	"doi":     stringProperty,
+})
+
+var (
+	// paperMetadataSchema is the built-in JSON schema used for paper metadata
+	paperMetadataSchema = strictObject(paperProperties(false))
+	// referencesSchema is the built-in JSON schema used for reference lists
+	referencesSchema = strictObject(map[string]interface{}{
+		"references": map[string]interface{}{"type": "array", "items": citationSchema},
+	})
+	// paperSchema is the built-in JSON schema used for paper metadata and references together
+	paperSchema = strictObject(paperProperties(true))
+)
+
+// paperProperties returns the schema properties of paper metadata, and of the reference list when
+// withReferences is set
+func paperProperties(withReferences bool) map[string]interface{} {
+	properties := map[string]interface{}{
+		"title": stringProperty,
+		"authors": map[string]interface{}{
+			"type": "array",
+			"items": strictObject(map[string]interface{}{
+				"name":        stringProperty,
+				"affiliation": stringProperty,
+				"email":       stringProperty,
+			}),
+		},
+		"abstract": stringProperty,
+		"keywords": map[string]interface{}{"type": "array", "items": stringProperty},
+		"doi":      stringProperty,
+		"venue":    stringProperty,
+		"year":     map[string]interface{}{"type": "integer"},
+	}
+	if withReferences {
+		properties["references"] = map[string]interface{}{"type": "array", "items": citationSchema}
+	}
+	return properties
+}
+
+// ExtractPaper extracts the bibliographic metadata of a scientific paper given as a file path or
+// a byte slice, and parses its reference list into structured citations. For text-based PDFs the
+// metadata is read from the first pages and only the reference section is sent for citation
+// parsing, in chunks when it is long; DOIs are normalized and recovered from the printed
+// references when the model misses them.
+func (e *Extractor) ExtractPaper(ctx context.Context, pdf interface{}) (*types.PaperResult, error) {
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	options.Schema = paperSchema
+
+	// The metadata and reference chunks make a single extraction, recorded, saved and published
+	// with the merged paper
+	result := &types.PaperResult{}
+	paperOptions := options
+	_, err = e.tracked(ctx, paperOptions, func(ctx context.Context) (*types.ExtractionResult, error) {
+		parsedPdf, err := e.parse(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+
+		paper := &result.Paper
+		merge := func(chunk *types.ExtractionResult) error {
+			var part types.Paper
+			if err := decodeData(chunk.Data, &part); err != nil {
+				return err
+			}
+			mergePaper(paper, &part)
+			result.TokensUsed += chunk.TokensUsed
+			result.Model = chunk.Model
+			return nil
+		}
+
+		var progress chunkProgress
+		referencePages := referenceSection(parsedPdf.Content.TextPages)
+		if parsedPdf.Content.Type != "text" || referencePages == nil {
+			// Without a recognizable reference section, read everything in a single pass
+			options.Instructions = paperInstructions + " " + referenceInstructions
+			if progress, err = e.extractChunks(ctx, parsedPdf, options, 0, 0, false, merge); err != nil {
+				return nil, err
+			}
+		} else {
+			pages := parsedPdf.Content.TextPages
+			front := strings.Join(pages[:min(paperFrontPages, len(pages))], "\n")
+			if pieces := splitText(front, defaultChunkSize); len(pieces) > 0 {
+				front = pieces[0]
+			}
+			options.Schema = paperMetadataSchema
+			options.Instructions = paperInstructions
+			// The metadata is a chunk of its own, so that the references make a partial result without it
+			err := progress.extract(ctx, pageSection{FirstPage: 1, LastPage: min(paperFrontPages, len(pages))}, func() (*types.ExtractionResult, error) {
+				return e.extractFromText(ctx, front, paperMetadataSchema, options)
+			}, merge)
+			if err != nil {
+				return nil, err
+			}
+
+			references := &types.ParsedPdf{Content: types.ParsedPdfContent{Type: "text", TextPages: referencePages}}
+			options.Schema = referencesSchema
+			options.Instructions = referenceInstructions
+			referenceProgress, err := e.extractChunks(ctx, references, options, 0, 0, false, merge)
+			if err != nil {
+				return nil, err
+			}
+			progress.add(referenceProgress)
+		}
+		if err := progress.err(); err != nil {
+			return nil, err
+		}
+		result.FailedChunks, result.Completeness = progress.failures, progress.completeness()
+
+		paper.DOI = normalizeDOI(paper.DOI)
+		for i := range paper.References {
+			citation := &paper.References[i]
+			citation.DOI = normalizeDOI(citation.DOI)
+			if citation.DOI == "" {
+				citation.DOI = normalizeDOI(doiPattern.FindString(citation.Raw))
+			}
+		}
+		return chunkedResult(result, result.TokensUsed, result.Model, nil, paperOptions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// referenceSection returns the pages holding the reference list: the text before the last
+// references heading is blanked so that page numbers are preserved. It returns nil when no
+// heading is found.
+func referenceSection(pages []string) []string {
+	for i := len(pages) - 1; i >= 0; i-- {
+		headings := referencesHeadingPattern.FindAllStringIndex(pages[i], -1)
+		if len(headings) == 0 {
+			continue
+		}
+		section := make([]string, len(pages))
+		section[i] = pages[i][headings[len(headings)-1][1]:]
+		copy(section[i+1:], pages[i+1:])
+		return section
+	}
+	return nil
+}
+
+// mergePaper merges the extraction of a part of a paper into the paper, keeping the first stated
+// metadata and appending references
+func mergePaper(paper, part *types.Paper) {
+	if paper.Title == "" {
+		paper.Title = part.Title
+	}
+	if len(paper.Authors) == 0 {
+		paper.Authors = part.Authors
+	}
+	if paper.Abstract == "" {
+		paper.Abstract = part.Abstract
+	}
+	if len(paper.Keywords) == 0 {
+		paper.Keywords = part.Keywords
+	}
+	if paper.DOI == "" {
+		paper.DOI = part.DOI
+	}
+	if paper.Venue == "" {
+		paper.Venue = part.Venue
+	}
+	if paper.Year == 0 {
+		paper.Year = part.Year
+	}
+	paper.References = append(paper.References, part.References...)
+}
+
+// normalizeDOI strips resolver prefixes and trailing punctuation from a DOI, and returns an empty
+// string when the value is not a DOI
+func normalizeDOI(doi string) string {
+	doi = doiPattern.FindString(strings.TrimSpace(doi))
+	return strings.TrimRight(doi, ".,;:)]}")
+}
@@ -0,0 +1,172 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// modelPrices are the prices, in USD per million tokens, of known models by model name prefix
+var modelPrices = map[string]types.ModelPrice{
+	"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+	"gpt-4-turbo":   {Input: 10, Output: 30},
+	"gpt-4o":        {Input: 2.50, Output: 10},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.60},
+	"gpt-4.1":       {Input: 2, Output: 8},
+	"gpt-4.1-mini":  {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":  {Input: 0.10, Output: 0.40},
+	"gpt-5":         {Input: 1.25, Output: 10},
+	"gpt-5-mini":    {Input: 0.25, Output: 2},
+	"gpt-5-nano":    {Input: 0.05, Output: 0.40},
+	"o3":            {Input: 2, Output: 8},
+	"o4-mini":       {Input: 1.10, Output: 4.40},
+}
+
+// modelPrice returns the price of a model: the one of the longest prefix of the model name among
+// ExtractorConfig.ModelPrices, or else among the known models
+func (e *Extractor) modelPrice(model string) (types.ModelPrice, bool) {
+	for _, prices := range []map[string]types.ModelPrice{e.config.ModelPrices, modelPrices} {
+		var price types.ModelPrice
+		prefixLength := -1
+		for prefix, candidate := range prices {
+			if strings.HasPrefix(model, prefix) && len(prefix) > prefixLength {
+				price, prefixLength = candidate, len(prefix)
+			}
+		}
+		if prefixLength >= 0 {
+			return price, true
+		}
+	}
+	return types.ModelPrice{}, false
+}
+
+// Plan estimates the pages, routing, tokens and cost of the extraction of a document without
+// calling the model: the document is parsed, by the OCR backend too when one is set, and its
+// request is built and measured like in BuildRequest. The estimates cover a single request; the
+// chunks of long documents, retries, repairs and cross-checks are not counted. A request that
+// would fail, e.g. as it exceeds the context window of the model, sets the Err of the plan.
+func (e *Extractor) Plan(options types.ExtractionOptions) *types.DocumentPlan {
+	return e.plan(context.Background(), options)
+}
+
+// plan estimates the extraction of a document like Plan, canceling the sandboxed parser when ctx is
+// done
+func (e *Extractor) plan(ctx context.Context, options types.ExtractionOptions) *types.DocumentPlan {
+	plan := &types.DocumentPlan{}
+	parsedPdf, err := e.parse(ctx, options)
+	if err != nil {
+		plan.Err = err
+		return plan
+	}
+	plan.Pages, plan.Route = parsedPdf.NumPages, parsedPdf.Content.Type
+	switch parsedPdf.Content.Type {
+	case "text":
+		plan.TextPages = parsedPdf.NumPages
+	case "mixed":
+		for _, pageType := range parsedPdf.Content.PageTypes {
+			if pageType == "images" {
+				plan.ImagePages++
+			} else {
+				plan.TextPages++
+			}
+		}
+	default:
+		plan.ImagePages = len(parsedPdf.Content.ImageContent)
+	}
+
+	request, err := e.BuildRequest(parsedPdf, options)
+	if err != nil {
+		plan.Err = err
+		return plan
+	}
+	plan.Model, _ = request.Body["model"].(string)
+	plan.PromptTokens = estimateRequestTokens(request.Body)
+	plan.MaxCompletionTokens = defaultOutputReserve
+	if options.MaxTokens != nil {
+		plan.MaxCompletionTokens = *options.MaxTokens
+	}
+	// The answer repeats the keys of the schema, with the values
+	plan.CompletionTokens = plan.MaxCompletionTokens
+	if schema, err := json.Marshal(request.Schema); err == nil {
+		plan.CompletionTokens = min(estimateTokens(string(schema)), plan.MaxCompletionTokens)
+	}
+
+	if price, ok := e.modelPrice(plan.Model); ok {
+		plan.Priced = true
+		plan.Cost = (float64(plan.PromptTokens)*price.Input + float64(plan.CompletionTokens)*price.Output) / 1e6
+		plan.MaxCost = (float64(plan.PromptTokens)*price.Input + float64(plan.MaxCompletionTokens)*price.Output) / 1e6
+	}
+	return plan
+}
+
+// PlanBatch estimates the pages, routing, tokens and cost of a batch extraction without calling the
+// model, planning its documents in parallel like ExtractBatch. The documents BatchOptions.Journal
+// holds a success record of are skipped, as ExtractBatch does not extract them again. It fails only
+// when the journal cannot be read or ctx is canceled; the documents that would fail have an Err.
+func (e *Extractor) PlanBatch(ctx context.Context, documents []types.BatchDocument, options types.BatchOptions) (*types.BatchPlan, error) {
+	var completed map[string]types.JSONLRecord
+	if options.Journal != nil {
+		var err error
+		if completed, err = completedRecords(ctx, options.Journal); err != nil {
+			return nil, err
+		}
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	plans := make([]types.DocumentPlan, len(documents))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				id := batchDocumentID(i, documents[i])
+				if _, ok := completed[id]; ok {
+					plans[i] = types.DocumentPlan{ID: id, Skipped: true}
+					continue
+				}
+				plans[i] = *e.plan(ctx, documents[i].Options)
+				plans[i].ID = id
+			}
+		}()
+	}
+	for i := range documents {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	plan := &types.BatchPlan{Documents: plans}
+	unpriced := make(map[string]bool)
+	for _, document := range plans {
+		if document.Skipped || document.Err != nil {
+			continue
+		}
+		plan.Pages += document.Pages
+		plan.PromptTokens += document.PromptTokens
+		plan.CompletionTokens += document.CompletionTokens
+		plan.MaxCompletionTokens += document.MaxCompletionTokens
+		plan.Cost += document.Cost
+		plan.MaxCost += document.MaxCost
+		if !document.Priced && !unpriced[document.Model] {
+			unpriced[document.Model] = true
+			plan.UnpricedModels = append(plan.UnpricedModels, document.Model)
+		}
+	}
+	sort.Strings(plan.UnpricedModels)
+	return plan, nil
+}
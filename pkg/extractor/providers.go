@@ -0,0 +1,177 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// ProviderPreset describes how to talk to a known OpenAI-compatible server
+type ProviderPreset struct {
+	// Name identifies the preset in ExtractorConfig.Provider
+	Name string
+	// BaseURL is the default base URL of the server
+	BaseURL string
+	// RequiresAPIKey reports whether the server checks an API key. Servers that do not are called
+	// without an Authorization header when no key is configured.
+	RequiresAPIKey bool
+	// ResponseFormat is how the server accepts structured output requests
+	ResponseFormat string
+	// SchemaLimits are the limits on the size of the schemas of structured output requests
+	SchemaLimits schema.Limits
+	// ImageFormats are the formats of the page images the server accepts besides PNG, see
+	// ExtractorConfig.ImageFormat
+	ImageFormats []string
+	// Notes describes the quirks of the server
+	Notes string
+}
+
+// presets are the built-in provider presets by name
+var presets = map[string]ProviderPreset{
+	types.ProviderOpenAI: {
+		Name:           types.ProviderOpenAI,
+		BaseURL:        defaultBaseURL,
+		RequiresAPIKey: true,
+		ResponseFormat: types.ResponseFormatJSONSchema,
+		SchemaLimits:   schema.OpenAILimits,
+		ImageFormats:   []string{types.ImageFormatWebP},
+	},
+	types.ProviderDockerModelRunner: {
+		Name:           types.ProviderDockerModelRunner,
+		BaseURL:        "http://localhost:12434/engines/v1",
+		ResponseFormat: types.ResponseFormatJSONSchema,
+		Notes:          "Host TCP access must be enabled (docker desktop enable model-runner --tcp 12434); from containers use http://model-runner.docker.internal/engines/v1. Models are named like ai/gemma3 and must be pulled first.",
+	},
+	types.ProviderLlamaCpp: {
+		Name:           types.ProviderLlamaCpp,
+		BaseURL:        "http://localhost:8080/v1",
+		ResponseFormat: types.ResponseFormatJSONObject,
+		Notes:          "Serves the single model it was started with. Structured output is a json_object response format with the schema converted to a grammar, which ignores strict mode. Vision requires a model started with --mmproj.",
+	},
+	types.ProviderLMStudio: {
+		Name:           types.ProviderLMStudio,
+		BaseURL:        "http://localhost:1234/v1",
+		ResponseFormat: types.ResponseFormatJSONSchema,
+		Notes:          "Rejects json_object response formats. The model must be loaded, or just-in-time loading enabled.",
+	},
+	types.ProviderVLLM: {
+		Name:           types.ProviderVLLM,
+		BaseURL:        "http://localhost:8000/v1",
+		ResponseFormat: types.ResponseFormatJSONSchema,
+		ImageFormats:   []string{types.ImageFormatWebP, types.ImageFormatAVIF},
+		Notes:          "Checks the API key only when started with --api-key. The model is the served model name, the Hugging Face id by default.",
+	},
+}
+
+// Presets returns the built-in provider presets, sorted by name
+func Presets() []ProviderPreset {
+	list := make([]ProviderPreset, 0, len(presets))
+	for _, preset := range presets {
+		list = append(list, preset)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LookupPreset returns the built-in preset of a provider
+func LookupPreset(name string) (ProviderPreset, bool) {
+	preset, ok := presets[name]
+	return preset, ok
+}
+
+// authorize sets the API key of a request with the given body to the provider, if any, along with
+// the organization and project it is attributed to, and signs it with the signer, if any
+func (e *Extractor) authorize(req *http.Request, body []byte) error {
+	key, err := e.currentAPIKey(req.Context())
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
+	}
+	if e.config.OpenAIOrganization != "" {
+		req.Header.Set("OpenAI-Organization", e.config.OpenAIOrganization)
+	}
+	if e.config.OpenAIProject != "" {
+		req.Header.Set("OpenAI-Project", e.config.OpenAIProject)
+	}
+	if e.config.Signer != nil {
+		if err := e.config.Signer.SignRequest(req, body); err != nil {
+			return fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+	return nil
+}
+
+// adaptRequest rewrites the response format of a chat completion request for the configured
+// response format. The request is copied, not modified.
+func (e *Extractor) adaptRequest(requestBody map[string]interface{}) map[string]interface{} {
+	format, ok := requestBody["response_format"].(map[string]interface{})
+	if !ok || e.responseFormat == types.ResponseFormatJSONSchema || format["type"] != "json_schema" {
+		return requestBody
+	}
+	jsonSchema, _ := format["json_schema"].(map[string]interface{})
+
+	adapted := make(map[string]interface{}, len(requestBody))
+	for key, value := range requestBody {
+		adapted[key] = value
+	}
+	switch e.responseFormat {
+	case types.ResponseFormatJSONObject:
+		adapted["response_format"] = map[string]interface{}{"type": "json_object", "schema": jsonSchema["schema"]}
+	case types.ResponseFormatPrompt:
+		delete(adapted, "response_format")
+		adapted["messages"] = appendToUserPrompt(requestBody["messages"], schemaPrompt(jsonSchema["schema"]))
+	}
+	return adapted
+}
+
+// schemaPrompt asks for a JSON response matching a schema, for servers without structured output
+func schemaPrompt(schemaData interface{}) string {
+	data, _ := json.MarshalIndent(schemaData, "", "  ")
+	return fmt.Sprintf("Respond only with a JSON object matching this JSON schema, without any other text:\n%s", data)
+}
+
+// appendToUserPrompt returns a copy of messages with text appended to the last user message.
+// Messages are maps, or generic values once a request went through JSON.
+func appendToUserPrompt(messages interface{}, text string) interface{} {
+	var list []map[string]interface{}
+	switch messages := messages.(type) {
+	case []map[string]interface{}:
+		list = append(list, messages...)
+	case []interface{}:
+		for _, message := range messages {
+			if message, ok := message.(map[string]interface{}); ok {
+				list = append(list, message)
+			}
+		}
+	default:
+		return messages
+	}
+
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i]["role"] != "user" {
+			continue
+		}
+		message := make(map[string]interface{}, len(list[i]))
+		for key, value := range list[i] {
+			message[key] = value
+		}
+		textPart := map[string]interface{}{"type": "text", "text": text}
+		switch content := message["content"].(type) {
+		case string:
+			message["content"] = content + "\n\n" + text
+		case []map[string]interface{}:
+			message["content"] = append(append(make([]map[string]interface{}, 0, len(content)+1), content...), textPart)
+		case []interface{}:
+			message["content"] = append(append(make([]interface{}, 0, len(content)+1), content...), textPart)
+		}
+		list[i] = message
+		break
+	}
+	return list
+}
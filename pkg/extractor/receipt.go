@@ -0,0 +1,157 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// receiptTolerance is the rounding difference accepted when reconciling receipt amounts
+const receiptTolerance = 0.011
+
+const receiptInstructions = "The document is a purchase receipt. Copy the merchant name as printed. Give the date as YYYY-MM-DD, the time as HH:MM and the currency as an ISO 4217 code. " +
+	"List every purchased item; use a quantity of 1 when none is printed. Report the discount as a positive amount. " +
+	"Use 0 for amounts and an empty string for texts that are not printed on the receipt. Set taxIncluded to true when item prices already include tax (e.g. VAT shown as 'incl.')."
+
+var (
+	// merchantNoisePattern matches store numbers and similar suffixes in merchant names
+	merchantNoisePattern = regexp.MustCompile(`(?i)\s*(\b(store|branch|filiale|no\.?)\s*#?|#)\s*\d+\s*$`)
+	receiptAmount        = map[string]interface{}{"type": "number"}
+	receiptText          = map[string]interface{}{"type": "string"}
+)
+
+// receiptSchema is the built-in JSON schema used for receipts
+var receiptSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"merchantRaw": receiptText,
+		"date":        receiptText,
+		"time":        receiptText,
+		"currency":    receiptText,
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"description": receiptText,
+					"quantity":    receiptAmount,
+					"unitPrice":   receiptAmount,
+					"amount":      receiptAmount,
+				},
+				"required":             []string{"description", "quantity", "unitPrice", "amount"},
+				"additionalProperties": false,
+			},
+		},
+		"subtotal":      receiptAmount,
+		"tax":           receiptAmount,
+		"taxIncluded":   map[string]interface{}{"type": "boolean"},
+		"tip":           receiptAmount,
+		"discount":      receiptAmount,
+		"total":         receiptAmount,
+		"paymentMethod": receiptText,
+	},
+	"required": []string{
+		"merchantRaw", "date", "time", "currency", "items", "subtotal", "tax",
+		"taxIncluded", "tip", "discount", "total", "paymentMethod",
+	},
+	"additionalProperties": false,
+}
+
+// ExtractReceipt extracts merchant, line items and totals from a receipt given as a file path or
+// a byte slice. The merchant name is normalized, and the items, subtotal, tax, tip, discount and
+// total are reconciled; amounts that do not add up are reported as warnings.
+func (e *Extractor) ExtractReceipt(ctx context.Context, pdf interface{}) (*types.ReceiptResult, error) {
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	options.Schema = receiptSchema
+	options.Instructions = receiptInstructions
+
+	result, err := e.ExtractWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &types.ReceiptResult{}
+	if err := decodeData(result.Data, receipt); err != nil {
+		return nil, err
+	}
+	receipt.TokensUsed = result.TokensUsed
+	receipt.Model = result.Model
+	receipt.Merchant = NormalizeMerchant(receipt.MerchantRaw)
+	reconcileReceipt(receipt)
+
+	return receipt, nil
+}
+
+// NormalizeMerchant cleans up a merchant name as printed on a receipt: whitespace is collapsed,
+// store numbers are removed and all-caps names are title-cased
+func NormalizeMerchant(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	name = merchantNoisePattern.ReplaceAllString(name, "")
+	name = strings.Trim(name, " -,.*")
+
+	if name != strings.ToUpper(name) {
+		return name
+	}
+
+	words := strings.Fields(strings.ToLower(name))
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// reconcileReceipt checks that the receipt amounts add up and records the discrepancies
+func reconcileReceipt(receipt *types.ReceiptResult) {
+	receipt.Discount = math.Abs(receipt.Discount)
+
+	itemsTotal := 0.0
+	for _, item := range receipt.Items {
+		if item.UnitPrice != 0 && item.Quantity != 0 && !amountsEqual(item.UnitPrice*item.Quantity, item.Amount) {
+			receipt.Warnings = append(receipt.Warnings, fmt.Sprintf("item %q: quantity %g x unit price %.2f does not match amount %.2f", item.Description, item.Quantity, item.UnitPrice, item.Amount))
+		}
+		itemsTotal += item.Amount
+	}
+
+	switch {
+	case receipt.Subtotal == 0 && len(receipt.Items) > 0:
+		receipt.Subtotal = round2(itemsTotal)
+	case len(receipt.Items) > 0 && !amountsEqual(itemsTotal, receipt.Subtotal):
+		// Some receipts print the subtotal after the discount
+		if !amountsEqual(itemsTotal-receipt.Discount, receipt.Subtotal) {
+			receipt.Warnings = append(receipt.Warnings, fmt.Sprintf("items add up to %.2f but subtotal is %.2f", itemsTotal, receipt.Subtotal))
+		}
+	}
+
+	expected := receipt.Subtotal + receipt.Tip - receipt.Discount
+	if !receipt.TaxIncluded {
+		expected += receipt.Tax
+	}
+	if !amountsEqual(expected, receipt.Total) {
+		// Accept the discount being already applied to the subtotal
+		if !amountsEqual(expected+receipt.Discount, receipt.Total) {
+			receipt.Warnings = append(receipt.Warnings, fmt.Sprintf("subtotal, tax, tip and discount add up to %.2f but total is %.2f", expected, receipt.Total))
+		}
+	}
+
+	receipt.Reconciled = len(receipt.Warnings) == 0
+}
+
+// amountsEqual compares two amounts within rounding tolerance
+func amountsEqual(a, b float64) bool {
+	return math.Abs(a-b) < receiptTolerance
+}
+
+// round2 rounds an amount to cents
+func round2(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,151 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces the values of the sensitive fields in debug logs
+const redactedValue = "[REDACTED]"
+
+// piiPatterns are the common kinds of personal data replaced in debug logs, in order: payment card
+// numbers go before phone numbers, which would otherwise take their digits
+var piiPatterns = []struct {
+	placeholder string
+	pattern     *regexp.Regexp
+	// valid tells whether a match, between start and end in the text, is really of the kind when
+	// the pattern is not enough (optional)
+	valid func(text string, start, end int) bool
+}{
+	{"[EMAIL]", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), nil},
+	{"[IBAN]", regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`), nil},
+	{"[CARD]", regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), func(text string, start, end int) bool {
+		return luhnValid(text[start:end])
+	}},
+	{"[SSN]", regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), nil},
+	{"[PHONE]", regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[ .-]\d{3,4}[ .-]\d{3,4}\b`), standalone},
+}
+
+// RedactPII replaces the email addresses, IBANs, payment card numbers, US social security numbers
+// and phone numbers of a text by placeholders such as "[EMAIL]" and "[CARD]"
+func RedactPII(text string) string {
+	for _, kind := range piiPatterns {
+		var redacted strings.Builder
+		last := 0
+		for _, match := range kind.pattern.FindAllStringIndex(text, -1) {
+			if kind.valid != nil && !kind.valid(text, match[0], match[1]) {
+				continue
+			}
+			redacted.WriteString(text[last:match[0]])
+			redacted.WriteString(kind.placeholder)
+			last = match[1]
+		}
+		redacted.WriteString(text[last:])
+		text = redacted.String()
+	}
+	return text
+}
+
+// standalone reports whether a match is not part of a longer group of digits, such as a number
+// that is no payment card number, on either side
+func standalone(text string, start, end int) bool {
+	isDigit := func(i int) bool { return i >= 0 && i < len(text) && text[i] >= '0' && text[i] <= '9' }
+	isSeparator := func(i int) bool { return i >= 0 && i < len(text) && strings.IndexByte(" .-", text[i]) >= 0 }
+	before := isDigit(start-1) || (isSeparator(start-1) && isDigit(start-2))
+	after := isDigit(end) || (isSeparator(end) && isDigit(end+1))
+	return !before && !after
+}
+
+// luhnValid reports whether the digits of a number pass the Luhn checksum of payment card numbers
+func luhnValid(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			continue
+		}
+		digit := int(number[i] - '0')
+		if double {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// logDebug logs a chat completion request or response body to the debug logger, if any, with the
+// values of the sensitive fields, personal data and page images redacted
+func (e *Extractor) logDebug(ctx context.Context, message string, body []byte, attrs ...any) {
+	logger := e.config.DebugLogger
+	if logger == nil || !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	// Minimized extractions log no content of the document
+	if minimized(ctx) {
+		logger.DebugContext(ctx, message, attrs...)
+		return
+	}
+	logged := RedactPII(string(body))
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		if encoded, err := json.Marshal(e.redact(decoded, false)); err == nil {
+			logged = string(encoded)
+		}
+	}
+	logger.DebugContext(ctx, message, append(attrs, "body", logged)...)
+}
+
+// redact returns a copy of a decoded JSON value with the values of the fields of
+// ExtractorConfig.RedactFields replaced, personal data replaced in strings, and page images left
+// out. JSON held in strings, such as the answers of the model, is redacted too. The keys of the
+// properties of schemas are field definitions, not values, so they are kept.
+func (e *Extractor) redact(value interface{}, properties bool) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			if !properties && e.redactField(key) {
+				redacted[key] = redactedValue
+				continue
+			}
+			redacted[key] = e.redact(item, key == "properties" && !properties)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(value))
+		for i, item := range value {
+			redacted[i] = e.redact(item, false)
+		}
+		return redacted
+	case string:
+		if strings.HasPrefix(value, "data:") {
+			return "[IMAGE]"
+		}
+		if trimmed := strings.TrimSpace(value); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(trimmed), &decoded); err == nil {
+				if encoded, err := json.Marshal(e.redact(decoded, false)); err == nil {
+					return string(encoded)
+				}
+			}
+		}
+		return RedactPII(value)
+	default:
+		return value
+	}
+}
+
+// redactField reports whether a field is one of ExtractorConfig.RedactFields, regardless of case
+func (e *Extractor) redactField(name string) bool {
+	for _, field := range e.config.RedactFields {
+		if strings.EqualFold(field, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package extractor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// redactionPadding is the margin, in points, blacked out around the words matching a redaction
+// pattern, so that no stroke of their glyphs is left
+const redactionPadding = 1.0
+
+// redactPages blacks out the regions of ExtractionOptions.RedactRegions and the words matching
+// RedactPatterns on the page images of a parsed PDF, and replaces the matches in its text. The
+// words of the pages are found in the text layout of the parsed PDF; it fails when a page image
+// has none, as its matches could not be blacked out.
+func redactPages(parsedPdf *types.ParsedPdf, options types.ExtractionOptions) (*types.ParsedPdf, error) {
+	if len(options.RedactRegions) == 0 && len(options.RedactPatterns) == 0 {
+		return parsedPdf, nil
+	}
+	patterns := make([]*regexp.Regexp, 0, len(options.RedactPatterns))
+	for _, pattern := range options.RedactPatterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, compiled)
+	}
+	for _, redaction := range options.RedactRegions {
+		if redaction.Page < 0 || !validRegion(redaction.Region) {
+			return nil, fmt.Errorf("invalid redaction region %+v on page %d", redaction.Region, redaction.Page)
+		}
+	}
+
+	// The parsed PDF may be shared with other extractions, so redact a copy
+	redacted := *parsedPdf
+	content := &redacted.Content
+	content.TextContent = redactMatches(content.TextContent, patterns)
+	content.OCRText = redactMatches(content.OCRText, patterns)
+	content.TextPages = make([]string, len(parsedPdf.Content.TextPages))
+	for i, page := range parsedPdf.Content.TextPages {
+		content.TextPages[i] = redactMatches(page, patterns)
+	}
+
+	content.ImageContent = make([]types.PdfPageImage, len(parsedPdf.Content.ImageContent))
+	for i, img := range parsedPdf.Content.ImageContent {
+		regions := make([]types.Rect, 0)
+		for _, redaction := range options.RedactRegions {
+			if redaction.Page == 0 || redaction.Page == img.Page {
+				regions = append(regions, redaction.Region)
+			}
+		}
+		if len(patterns) > 0 {
+			layout := pageLayoutAt(parsedPdf.Layout, img.Page)
+			if layout == nil {
+				return nil, fmt.Errorf("cannot find the redaction patterns on page %d, which has no text layout: set an OCR backend reading layouts", img.Page)
+			}
+			regions = append(regions, matchRegions(layout, patterns)...)
+		}
+		if len(regions) == 0 {
+			content.ImageContent[i] = img
+			continue
+		}
+		page, err := parser.RedactPageImage(img, regions)
+		if err != nil {
+			return nil, err
+		}
+		content.ImageContent[i] = page
+	}
+	return &redacted, nil
+}
+
+// redactMatches replaces the matches of patterns in a text
+func redactMatches(text string, patterns []*regexp.Regexp) string {
+	for _, pattern := range patterns {
+		text = pattern.ReplaceAllLiteralString(text, redactedValue)
+	}
+	return text
+}
+
+// pageLayoutAt returns the layout of a page (1-indexed) with words, or nil
+func pageLayoutAt(layouts []types.PageLayout, page int) *types.PageLayout {
+	for i := range layouts {
+		layout := &layouts[i]
+		if (layout.Page == page || (layout.Page == 0 && i == page-1)) && len(layout.Lines) > 0 && layout.Width > 0 && layout.Height > 0 {
+			return layout
+		}
+	}
+	return nil
+}
+
+// matchRegions returns the areas of the words of a page matching patterns, relative to the page
+// size. Words are matched line by line, separated by single spaces.
+func matchRegions(layout *types.PageLayout, patterns []*regexp.Regexp) []types.Rect {
+	regions := make([]types.Rect, 0)
+	for _, line := range layout.Lines {
+		var text strings.Builder
+		starts := make([]int, len(line.Words))
+		for i, word := range line.Words {
+			if i > 0 {
+				text.WriteByte(' ')
+			}
+			starts[i] = text.Len()
+			text.WriteString(word.Text)
+		}
+		for _, pattern := range patterns {
+			for _, match := range pattern.FindAllStringIndex(text.String(), -1) {
+				var bounds *types.Rect
+				for i, word := range line.Words {
+					if starts[i] >= match[1] || starts[i]+len(word.Text) <= match[0] {
+						continue
+					}
+					if bounds == nil {
+						bounds = &types.Rect{X: word.Bounds.X, Y: word.Bounds.Y, Width: word.Bounds.Width, Height: word.Bounds.Height}
+						continue
+					}
+					right, bottom := max(bounds.X+bounds.Width, word.Bounds.X+word.Bounds.Width), max(bounds.Y+bounds.Height, word.Bounds.Y+word.Bounds.Height)
+					bounds.X, bounds.Y = min(bounds.X, word.Bounds.X), min(bounds.Y, word.Bounds.Y)
+					bounds.Width, bounds.Height = right-bounds.X, bottom-bounds.Y
+				}
+				if bounds == nil {
+					continue
+				}
+				regions = append(regions, types.Rect{
+					X:      (bounds.X - redactionPadding) / layout.Width,
+					Y:      (bounds.Y - redactionPadding) / layout.Height,
+					Width:  (bounds.Width + 2*redactionPadding) / layout.Width,
+					Height: (bounds.Height + 2*redactionPadding) / layout.Height,
+				})
+			}
+		}
+	}
+	return regions
+}
@@ -0,0 +1,256 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// minAnchorLength is the shortest previous value searched for in the pages to focus a re-extraction
+const minAnchorLength = 3
+
+// ReExtractFields re-extracts only the given fields of a previous result, e.g. the ones a reviewer
+// flagged as wrong, instead of extracting the whole document again. Fields are dot-separated paths
+// into the schema of the options, which must describe the same document and schema as the
+// previous extraction. The model is told the previous values were wrong and, when they are found
+// in the text of the document, only sees the pages around them. The result is a copy of the
+// previous one with the fields replaced; TokensUsed counts the re-extraction only.
+func (e *Extractor) ReExtractFields(ctx context.Context, previous *types.ExtractionResult, fields []string, options types.ExtractionOptions) (*types.ExtractionResult, error) {
+	start := time.Now()
+	ctx, end, err := e.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	if previous == nil {
+		return nil, errors.New("previous result is nil")
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("at least one field must be provided")
+	}
+	schemaData, names, err := combinedSchema(options)
+	if err != nil {
+		return nil, err
+	}
+	// The options are narrowed to the fields below, the result is stamped with the full schema
+	stamped := options
+
+	// Describe the fields in a schema of their own, keyed by path
+	properties := make(map[string]interface{}, len(fields))
+	anchors := make([]string, 0, len(fields))
+	var corrections strings.Builder
+	for _, field := range fields {
+		fieldSchema, err := schemaAtPath(schemaData, field)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := properties[field]; ok {
+			return nil, fmt.Errorf("duplicate field %q", field)
+		}
+		properties[field] = fieldSchema
+
+		value, _ := dataAtPath(previous.Data, field)
+		fmt.Fprintf(&corrections, "\n- %s (previously extracted: %s)", field, previousValue(value))
+		anchors = append(anchors, anchorText(value))
+	}
+
+	parsedPdf, err := e.parse(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	parseDuration := time.Since(start)
+	// Narrow to the section before focusing on the previous values
+	focused, options, err := narrowSection(parsedPdf, options)
+	if err != nil {
+		return nil, err
+	}
+
+	options.Schemas = nil
+	options.NullableFields = nil
+	options.Schema = map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             slices.Clone(fields),
+		"additionalProperties": false,
+	}
+	options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" +
+		"A reviewer flagged the previously extracted values of the following fields as wrong. Read the document again carefully and extract them anew:" +
+		corrections.String())
+
+	result, err := e.extractParsed(ctx, focusPages(focused, anchors), options)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _ := cloneData(previous.Data).(map[string]interface{})
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	for _, field := range fields {
+		if err := setDataPath(data, field, result.Data[field]); err != nil {
+			return nil, err
+		}
+	}
+
+	reExtracted := &types.ExtractionResult{
+		Data:        data,
+		TokensUsed:  result.TokensUsed,
+		Usage:       result.Usage,
+		Timings:     result.Timings,
+		Model:       result.Model,
+		Barcodes:    previous.Barcodes,
+		Links:       previous.Links,
+		FailedPages: result.FailedPages,
+		Warnings:    result.Warnings,
+	}
+	if len(names) > 0 {
+		reExtracted.SchemaData = splitData(data, names)
+	}
+	if previous.FieldStatus != nil {
+		// Re-extracted nullable fields are found unless the model still returns null
+		reExtracted.FieldStatus = make(map[string]string, len(previous.FieldStatus))
+		for field, status := range previous.FieldStatus {
+			reExtracted.FieldStatus[field] = status
+		}
+		for _, field := range fields {
+			if _, ok := reExtracted.FieldStatus[field]; !ok {
+				continue
+			}
+			reExtracted.FieldStatus[field] = types.FieldStatusFound
+			if result.Data[field] == nil {
+				reExtracted.FieldStatus[field] = types.FieldStatusNotPresent
+			}
+		}
+	}
+	stamp(reExtracted, schemaData, stamped)
+	reExtracted.Timings.Parse = parseDuration
+	if options.ParsedPdf == nil {
+		reExtracted.Timings.Render = parsedPdf.RenderDuration
+	}
+	reExtracted.Timings.Total = time.Since(start)
+	return reExtracted, nil
+}
+
+// schemaAtPath returns the schema of the property at a dot-separated path of an object schema
+func schemaAtPath(schemaData map[string]interface{}, path string) (map[string]interface{}, error) {
+	current := schemaData
+	for _, key := range strings.Split(path, ".") {
+		properties, _ := current["properties"].(map[string]interface{})
+		child, ok := properties[key].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %q is not in the schema", path)
+		}
+		current = child
+	}
+	return current, nil
+}
+
+// dataAtPath returns the value stored in data at a dot-separated path
+func dataAtPath(data map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = data
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// cloneData deep copies the objects and arrays of extracted data
+func cloneData(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, child := range value {
+			copied[key] = cloneData(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, child := range value {
+			copied[i] = cloneData(child)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// previousValue formats a previously extracted value for the prompt
+func previousValue(value interface{}) string {
+	if value == nil {
+		return "nothing"
+	}
+	if text, ok := value.(string); ok {
+		return strconv.Quote(text)
+	}
+	return fmt.Sprint(value)
+}
+
+// anchorText returns the text of a previously extracted value to look for in the pages, or "" when
+// the value is not a scalar long enough to be told apart
+func anchorText(value interface{}) string {
+	var text string
+	switch value := value.(type) {
+	case string:
+		text = strings.TrimSpace(value)
+	case float64:
+		text = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	if len([]rune(text)) < minAnchorLength {
+		return ""
+	}
+	return strings.ToLower(text)
+}
+
+// focusPages narrows a parsed PDF to the pages holding the anchors, along with the page before and
+// after them. The whole PDF is kept when an anchor is empty or not found, or the text of the pages
+// is unknown.
+func focusPages(parsedPdf *types.ParsedPdf, anchors []string) *types.ParsedPdf {
+	pages := parsedPdf.Content.TextPages
+	if len(pages) == 0 || len(pages) != parsedPdf.NumPages {
+		return parsedPdf
+	}
+
+	first, last := len(pages), 1
+	for _, anchor := range anchors {
+		found := false
+		for i, page := range pages {
+			if anchor != "" && strings.Contains(strings.ToLower(page), anchor) {
+				first, last, found = min(first, i+1), max(last, i+1), true
+			}
+		}
+		if !found {
+			return parsedPdf
+		}
+	}
+	first, last = max(1, first-1), min(len(pages), last+1)
+	if first == 1 && last == len(pages) {
+		return parsedPdf
+	}
+
+	// The parsed PDF may be shared with other extractions, so narrow a copy
+	focused := *parsedPdf
+	switch parsedPdf.Content.Type {
+	case "text":
+		focused.Content.TextPages = pages[first-1 : last]
+		focused.Content.TextContent = strings.Join(focused.Content.TextPages, "\n") + "\n"
+	case "mixed":
+		// Pages without a type are left out of mixed requests
+		focused.Content.PageTypes = make([]string, len(parsedPdf.Content.PageTypes))
+		copy(focused.Content.PageTypes[first-1:last], parsedPdf.Content.PageTypes[first-1:last])
+	default:
+		return parsedPdf
+	}
+	return &focused
+}
@@ -0,0 +1,191 @@
+package extractor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// repairJSON rewrites the malformed JSON object some models answer with into valid JSON, on a best
+// effort basis. It keeps the outermost object of answers wrapped in prose, and fixes:
+//   - comments and trailing commas;
+//   - single-quoted strings and unquoted keys;
+//   - Python and JavaScript literals such as True, None and undefined;
+//   - objects and strings left open by truncated answers.
+func repairJSON(content string) string {
+	content = trimCodeFence(content)
+	if start := strings.IndexByte(content, '{'); start >= 0 {
+		content = content[start:]
+		if end := strings.LastIndexByte(content, '}'); end >= 0 && balanced(content[:end+1]) {
+			content = content[:end+1]
+		}
+	}
+
+	runes := []rune(content)
+	var b strings.Builder
+	var open []rune
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '"' || r == '\'':
+			i = copyString(&b, runes, i)
+		case r == '/' && i+1 < len(runes) && (runes[i+1] == '/' || runes[i+1] == '*'):
+			i = skipComment(runes, i) - 1
+		case r == '{' || r == '[':
+			open = append(open, r)
+			b.WriteRune(r)
+		case r == '}' || r == ']':
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+			b.WriteRune(r)
+		case r == ',':
+			// Drop commas that close nothing
+			next := skipSpace(runes, i+1)
+			if next < len(runes) && runes[next] != '}' && runes[next] != ']' {
+				b.WriteRune(r)
+			}
+		case unicode.IsDigit(r) || r == '-':
+			// Copy numbers whole, so that exponents are not read as words
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || strings.ContainsRune(".eE+-", runes[end])) {
+				end++
+			}
+			b.WriteString(string(runes[i:end]))
+			i = end - 1
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '$') {
+				end++
+			}
+			word := string(runes[i:end])
+			if next := skipSpace(runes, end); next < len(runes) && runes[next] == ':' {
+				b.WriteString(`"` + word + `"`)
+			} else {
+				b.WriteString(jsonLiteral(word))
+			}
+			i = end - 1
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	// Close what a truncated answer left open
+	repaired := strings.TrimRight(strings.TrimSpace(b.String()), ",:")
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i] == '{' {
+			repaired += "}"
+		} else {
+			repaired += "]"
+		}
+	}
+	return repaired
+}
+
+// copyString writes the string starting at runes[start] as a double-quoted JSON string, closing it
+// if it is never closed, and returns the index of its closing quote
+func copyString(b *strings.Builder, runes []rune, start int) int {
+	quote := runes[start]
+	b.WriteByte('"')
+	i := start + 1
+	for ; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			if runes[i] == '\'' {
+				// \' is no JSON escape
+				b.WriteRune('\'')
+			} else {
+				b.WriteRune('\\')
+				b.WriteRune(runes[i])
+			}
+		case r == quote:
+			b.WriteByte('"')
+			return i
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return i
+}
+
+// skipComment returns the index after the // or /* */ comment starting at runes[start]
+func skipComment(runes []rune, start int) int {
+	if runes[start+1] == '/' {
+		for i := start + 2; i < len(runes); i++ {
+			if runes[i] == '\n' {
+				return i
+			}
+		}
+		return len(runes)
+	}
+	for i := start + 2; i+1 < len(runes); i++ {
+		if runes[i] == '*' && runes[i+1] == '/' {
+			return i + 2
+		}
+	}
+	return len(runes)
+}
+
+// skipSpace returns the index of the first rune from start that is neither space nor comment
+func skipSpace(runes []rune, start int) int {
+	i := start
+	for i < len(runes) {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			i++
+		case runes[i] == '/' && i+1 < len(runes) && (runes[i+1] == '/' || runes[i+1] == '*'):
+			i = skipComment(runes, i)
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// jsonLiteral returns the JSON literal of a bare word, or the word as a string
+func jsonLiteral(word string) string {
+	switch word {
+	case "true", "True", "TRUE":
+		return "true"
+	case "false", "False", "FALSE":
+		return "false"
+	case "null", "None", "NULL", "nil", "undefined", "NaN", "Infinity":
+		return "null"
+	}
+	return `"` + word + `"`
+}
+
+// balanced reports whether the brackets of a text, outside of strings, are balanced
+func balanced(text string) bool {
+	depth := 0
+	var quote rune
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '{' || r == '[':
+			depth++
+		case r == '}' || r == ']':
+			depth--
+		}
+	}
+	return depth == 0 && quote == 0
+}
@@ -0,0 +1,132 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// defaultResolverThreshold is the lowest similarity at which FuzzyResolver resolves a name
+const defaultResolverThreshold = 0.85
+
+// legalForms are the legal forms of companies left out of names by NormalizeEntityName
+var legalForms = map[string]bool{
+	"inc": true, "incorporated": true, "corp": true, "corporation": true, "co": true, "company": true,
+	"llc": true, "llp": true, "lp": true, "ltd": true, "limited": true, "plc": true,
+	"gmbh": true, "ag": true, "kg": true, "mbh": true, "ug": true,
+	"sa": true, "sl": true, "slu": true, "sas": true, "sarl": true, "srl": true, "spa": true,
+	"bv": true, "nv": true, "ab": true, "oy": true, "pty": true,
+}
+
+// resolveEntities resolves the names of the fields of ExtractionOptions.ResolveEntities with the
+// entity resolver of the extractor. It returns the entities by path, and a warning for every name
+// resolved to no entity.
+func (e *Extractor) resolveEntities(ctx context.Context, data map[string]interface{}, fields map[string]string) (map[string]types.EntityMatch, []string, error) {
+	patterns := make([]string, 0, len(fields))
+	for field := range fields {
+		patterns = append(patterns, field)
+	}
+	sort.Strings(patterns)
+
+	queries := make([]types.EntityQuery, 0)
+	walkValues(data, "", func(path string, value interface{}) {
+		name, ok := value.(string)
+		if !ok || strings.TrimSpace(name) == "" {
+			return
+		}
+		for _, field := range patterns {
+			if fieldMatches(path, field) {
+				queries = append(queries, types.EntityQuery{Field: path, Kind: fields[field], Name: name, Data: data})
+				return
+			}
+		}
+	})
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Field < queries[j].Field })
+
+	entities := make(map[string]types.EntityMatch, len(queries))
+	warnings := make([]string, 0)
+	for _, query := range queries {
+		match, found, err := e.config.EntityResolver.Resolve(ctx, query)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve %s %q: %w", query.Field, query.Name, err)
+		}
+		if !found {
+			warnings = append(warnings, fmt.Sprintf("%s = %q resolved to no %s", query.Field, query.Name, entityKind(query.Kind)))
+			continue
+		}
+		entities[query.Field] = match
+	}
+	return entities, warnings, nil
+}
+
+// entityKind names the kind of entity of a query in warnings
+func entityKind(kind string) string {
+	if kind == "" {
+		return "entity"
+	}
+	return kind
+}
+
+// FuzzyResolver is an EntityResolver matching names against a list of entities, by their name and
+// aliases, regardless of case, punctuation, legal forms and abbreviated words
+type FuzzyResolver struct {
+	entities  []types.MasterEntity
+	threshold float64
+}
+
+// NewFuzzyResolver creates a resolver of the names matching one of the entities with a similarity
+// of at least threshold, from 0 to 1 (default: 0.85)
+func NewFuzzyResolver(entities []types.MasterEntity, threshold float64) *FuzzyResolver {
+	if threshold <= 0 {
+		threshold = defaultResolverThreshold
+	}
+	return &FuzzyResolver{entities: entities, threshold: threshold}
+}
+
+// Resolve returns the entity of the kind of the query whose name or alias is the most similar to
+// the name of the query, the first one on ties, with the similarity as confidence
+func (r *FuzzyResolver) Resolve(_ context.Context, query types.EntityQuery) (types.EntityMatch, bool, error) {
+	var best types.EntityMatch
+	for _, entity := range r.entities {
+		if entity.Kind != "" && query.Kind != "" && entity.Kind != query.Kind {
+			continue
+		}
+		for _, name := range append([]string{entity.Name}, entity.Aliases...) {
+			if score := EntitySimilarity(query.Name, name); score > best.Confidence {
+				best = types.EntityMatch{ID: entity.ID, Name: entity.Name, Confidence: score}
+			}
+		}
+	}
+	if best.Confidence < r.threshold {
+		return types.EntityMatch{}, false, nil
+	}
+	best.Confidence = math.Round(best.Confidence*1000) / 1000
+	return best, true, nil
+}
+
+// EntitySimilarity scores how close two names of companies or people are, from 0 to 1: the best
+// Similarity of the names as written and without their legal forms, e.g. "ACME Inc." and "Acme"
+func EntitySimilarity(a, b string) float64 {
+	score := Similarity(a, b)
+	if na, nb := NormalizeEntityName(a), NormalizeEntityName(b); na != "" && nb != "" {
+		score = max(score, Similarity(na, nb))
+	}
+	return score
+}
+
+// NormalizeEntityName lowercases a name and leaves out its punctuation and the legal forms of
+// companies, such as "Inc", "GmbH" or "S.L.", to compare names of the same company
+func NormalizeEntityName(name string) string {
+	words := titleWords(strings.ReplaceAll(name, ".", ""))
+	kept := make([]string, 0, len(words))
+	for _, word := range words {
+		if !legalForms[word] {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
@@ -0,0 +1,165 @@
+package extractor
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const resumeInstructions = "The document is a resume or CV. Give dates as YYYY-MM, or YYYY when the month is not stated; leave the end date empty and set current to true for positions the candidate still holds. " +
+	"List the skills used in each position, and every skill of the skills sections in skills, copying skill names as written. Use an empty string for texts that are not stated."
+
+// skillAliases maps normalized spellings of common skills to a canonical key
+var skillAliases = map[string]string{
+	"golang":              "go",
+	"js":                  "javascript",
+	"ts":                  "typescript",
+	"k8s":                 "kubernetes",
+	"postgres":            "postgresql",
+	"amazonwebservices":   "aws",
+	"gcp":                 "googlecloud",
+	"googlecloudplatform": "googlecloud",
+	"ml":                  "machinelearning",
+}
+
+// resumeSchema is the built-in JSON schema used for resumes
+var resumeSchema = strictObject(map[string]interface{}{
+	"givenName":  stringProperty,
+	"familyName": stringProperty,
+	"email":      stringProperty,
+	"phone":      stringProperty,
+	"location":   stringProperty,
+	"summary":    stringProperty,
+	"experience": map[string]interface{}{
+		"type": "array",
+		"items": strictObject(map[string]interface{}{
+			"title":       stringProperty,
+			"employer":    stringProperty,
+			"location":    stringProperty,
+			"startDate":   stringProperty,
+			"endDate":     stringProperty,
+			"current":     map[string]interface{}{"type": "boolean"},
+			"description": stringProperty,
+			"skills":      map[string]interface{}{"type": "array", "items": stringProperty},
+		}),
+	},
+	"education": map[string]interface{}{
+		"type": "array",
+		"items": strictObject(map[string]interface{}{
+			"institution": stringProperty,
+			"degree":      stringProperty,
+			"field":       stringProperty,
+			"startDate":   stringProperty,
+			"endDate":     stringProperty,
+		}),
+	},
+	"skills": map[string]interface{}{"type": "array", "items": stringProperty},
+})
+
+// ExtractResume extracts a normalized candidate profile from a resume given as a file path or a
+// byte slice. Positions and education are sorted most recent first, and skills mentioned several
+// times (in the skills section and in positions, with different spellings) are merged into one
+// entry spanning the dates of the positions that use them. The profile can be serialized to
+// HR Open Standards JSON with the hropen package.
+func (e *Extractor) ExtractResume(ctx context.Context, pdf interface{}) (*types.ResumeResult, error) {
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	options.Schema = resumeSchema
+	options.Instructions = resumeInstructions
+
+	result, err := e.ExtractWithContext(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		types.Resume
+		Skills []string
+	}
+	if err := decodeData(result.Data, &data); err != nil {
+		return nil, err
+	}
+
+	resume := data.Resume
+	resume.Skills = mergeSkills(resume.Experience, data.Skills)
+	sort.SliceStable(resume.Experience, func(i, j int) bool {
+		return resume.Experience[i].StartDate > resume.Experience[j].StartDate
+	})
+	sort.SliceStable(resume.Education, func(i, j int) bool {
+		return resume.Education[i].StartDate > resume.Education[j].StartDate
+	})
+
+	return &types.ResumeResult{
+		Resume:     resume,
+		TokensUsed: result.TokensUsed,
+		Model:      result.Model,
+	}, nil
+}
+
+// mergeSkills deduplicates the skills mentioned in positions and skills sections. Each skill spans
+// from the earliest start to the latest end of the positions using it.
+func mergeSkills(experience []types.ResumeExperience, listed []string) []types.ResumeSkill {
+	skills := make([]types.ResumeSkill, 0)
+	index := make(map[string]int)
+
+	mention := func(name string) *types.ResumeSkill {
+		name = strings.TrimSpace(name)
+		key := skillKey(name)
+		if key == "" {
+			return nil
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(skills)
+			index[key] = i
+			skills = append(skills, types.ResumeSkill{Name: name})
+		}
+		skills[i].Mentions++
+		return &skills[i]
+	}
+
+	for _, position := range experience {
+		for _, name := range position.Skills {
+			skill := mention(name)
+			if skill == nil {
+				continue
+			}
+			if position.StartDate != "" && (skill.FirstUsed == "" || position.StartDate < skill.FirstUsed) {
+				skill.FirstUsed = position.StartDate
+			}
+			switch {
+			case position.Current || position.EndDate == "":
+				skill.LastUsed = ""
+			case skill.Mentions == 1 || (skill.LastUsed != "" && position.EndDate > skill.LastUsed):
+				skill.LastUsed = position.EndDate
+			}
+		}
+	}
+	for _, name := range listed {
+		mention(name)
+	}
+
+	return skills
+}
+
+// skillKey normalizes a skill name so that spelling variants ("Node.js", "NodeJS", "Golang",
+// "Go") share the same key
+func skillKey(name string) string {
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '+', r == '#':
+			return unicode.ToLower(r)
+		default:
+			return -1
+		}
+	}, name)
+	if alias, ok := skillAliases[key]; ok {
+		return alias
+	}
+	return key
+}
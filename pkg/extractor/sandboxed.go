@@ -0,0 +1,55 @@
+package extractor
+
+import (
+	"context"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/sandbox"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// The features that open a PDF outside of parsing it go through these helpers, which open it in
+// the helper process of ExtractorConfig.Sandbox when it is set, like parseWith.
+
+// renderPages renders the given pages (1-indexed) of a PDF buffer, or all of them when pages is empty
+func (e *Extractor) renderPages(ctx context.Context, buffer []byte, pages []int) ([]types.PdfPageImage, error) {
+	if e.config.Sandbox != nil {
+		return sandbox.Render(ctx, buffer, pages, *e.config.Sandbox)
+	}
+	if len(pages) == 0 {
+		return parser.RenderPdfToImages(buffer)
+	}
+	return parser.RenderPdfPagesToImages(buffer, pages)
+}
+
+// textLayout reads the words and lines of the pages of a PDF buffer
+func (e *Extractor) textLayout(ctx context.Context, buffer []byte) ([]types.PageLayout, error) {
+	if e.config.Sandbox != nil {
+		return sandbox.TextLayout(ctx, buffer, *e.config.Sandbox)
+	}
+	return parser.ExtractTextLayoutFromBuffer(buffer)
+}
+
+// fingerprint fingerprints a PDF buffer
+func (e *Extractor) fingerprint(ctx context.Context, buffer []byte) (*types.Fingerprint, error) {
+	if e.config.Sandbox != nil {
+		return sandbox.Fingerprint(ctx, buffer, *e.config.Sandbox)
+	}
+	return parser.Fingerprint(buffer)
+}
+
+// recognizeText reads the text of each page of a PDF buffer with a text backend
+func (e *Extractor) recognizeText(ctx context.Context, buffer []byte, name string, recognizer parser.TextExtractor) ([]string, error) {
+	if e.config.Sandbox != nil {
+		return sandbox.ExtractText(ctx, buffer, name, *e.config.Sandbox)
+	}
+	return recognizer.ExtractText(buffer)
+}
+
+// addTextLayer adds an invisible text layer to a PDF buffer
+func (e *Extractor) addTextLayer(ctx context.Context, buffer []byte, layouts []types.PageLayout) ([]byte, error) {
+	if e.config.Sandbox != nil {
+		return sandbox.AddTextLayer(ctx, buffer, layouts, *e.config.Sandbox)
+	}
+	return parser.AddTextLayer(buffer, layouts)
+}
@@ -0,0 +1,101 @@
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// combinedSchema returns the validated schema of an extraction: the Schema of the options, or a
+// parent schema with a required property for each of its Schemas, along with their sorted names.
+// The references of the schemas are inlined.
+func combinedSchema(options types.ExtractionOptions) (map[string]interface{}, []string, error) {
+	options, err := inlineSchemaRefs(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(options.Schemas) == 0 {
+		if err := schema.ValidateSchema(options.Schema); err != nil {
+			return nil, nil, fmt.Errorf("invalid JSON schema: %w", err)
+		}
+		return options.Schema, nil, nil
+	}
+	if options.Schema != nil {
+		return nil, nil, errors.New("Schema and Schemas cannot both be set")
+	}
+
+	names := make([]string, 0, len(options.Schemas))
+	properties := make(map[string]interface{}, len(options.Schemas))
+	for name, schemaData := range options.Schemas {
+		if err := schema.ValidateSchema(schemaData); err != nil {
+			return nil, nil, fmt.Errorf("invalid JSON schema %q: %w", name, err)
+		}
+		names = append(names, name)
+		properties[name] = schemaData
+	}
+	sort.Strings(names)
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             names,
+		"additionalProperties": false,
+	}, names, nil
+}
+
+// checkSchemaLimits checks the schema sent to the model against the limits of the provider, so
+// that a schema too large fails with a *schema.LimitError rather than a rejected request
+func (e *Extractor) checkSchemaLimits(schemaData map[string]interface{}) error {
+	if e.config.SchemaLimits == nil {
+		return nil
+	}
+	sent, _ := withoutDirectives(schemaData).(map[string]interface{})
+	return schema.CheckLimits(sent, *e.config.SchemaLimits)
+}
+
+// inlineSchemaRefs replaces the $ref references of the Schema and Schemas of the options by the
+// sub-schemas they point to, in the schemas themselves or in SchemaRefs
+func inlineSchemaRefs(options types.ExtractionOptions) (types.ExtractionOptions, error) {
+	load := func(uri string) (map[string]interface{}, error) {
+		document, ok := options.SchemaRefs[uri]
+		if !ok {
+			return nil, fmt.Errorf("no schema %s in SchemaRefs", uri)
+		}
+		return document, nil
+	}
+	if options.Schema != nil {
+		inlined, err := schema.InlineRefs(options.Schema, load)
+		if err != nil {
+			return options, fmt.Errorf("invalid JSON schema: %w", err)
+		}
+		options.Schema = inlined
+	}
+	if len(options.Schemas) > 0 {
+		schemas := make(map[string]map[string]interface{}, len(options.Schemas))
+		for name, schemaData := range options.Schemas {
+			inlined, err := schema.InlineRefs(schemaData, load)
+			if err != nil {
+				return options, fmt.Errorf("invalid JSON schema %q: %w", name, err)
+			}
+			schemas[name] = inlined
+		}
+		options.Schemas = schemas
+	}
+	return options, nil
+}
+
+// splitData splits the data extracted with a combined schema into the data of each schema
+func splitData(data map[string]interface{}, names []string) map[string]map[string]interface{} {
+	split := make(map[string]map[string]interface{}, len(names))
+	for _, name := range names {
+		part, _ := data[name].(map[string]interface{})
+		if part == nil {
+			part = map[string]interface{}{}
+		}
+		split[name] = part
+	}
+	return split
+}
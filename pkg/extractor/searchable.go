@@ -0,0 +1,110 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const transcriptionInstructions = "Transcribe all the text of this page, line by line in reading order, exactly as printed. " +
+	"Give the bounding box of each line as fractions of the page width and height (0 to 1) measured from the top-left corner, tight around the printed characters."
+
+// transcriptionSchema is the built-in JSON schema used to transcribe pages with the position of their lines
+var transcriptionSchema = strictObject(map[string]interface{}{
+	"lines": map[string]interface{}{
+		"type": "array",
+		"items": strictObject(map[string]interface{}{
+			"text": stringProperty,
+			"bounds": strictObject(map[string]interface{}{
+				"x":      numberProperty,
+				"y":      numberProperty,
+				"width":  numberProperty,
+				"height": numberProperty,
+			}),
+		}),
+	},
+})
+
+// MakeSearchable makes a scanned PDF given as a file path or a byte slice searchable: the pages
+// without a text layer are rendered and transcribed by the vision model, and the transcribed lines
+// are added over them as invisible text. Pages that already have text are left as they are.
+func (e *Extractor) MakeSearchable(ctx context.Context, pdf interface{}) (*types.SearchablePdfResult, error) {
+	if !e.config.VisionEnabled {
+		return nil, errors.New("making a PDF searchable requires vision mode to be enabled")
+	}
+
+	options, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := readPdf(options)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedPdf, err := e.parseWith(ctx, types.ExtractionOptions{PDFBuffer: buffer}, &types.ParseOptions{TextThreshold: 1})
+	if err != nil {
+		return nil, err
+	}
+	pages := parsedPdf.Content.ImageContent
+	if parsedPdf.Content.Type == "text" {
+		// Only transcribe the pages without text
+		scanned := make([]int, 0)
+		for i, text := range parsedPdf.Content.TextPages {
+			if strings.TrimSpace(text) == "" {
+				scanned = append(scanned, i+1)
+			}
+		}
+		if len(scanned) == 0 {
+			return &types.SearchablePdfResult{PDF: buffer, Pages: []types.PageLayout{}}, nil
+		}
+		if pages, err = e.renderPages(ctx, buffer, scanned); err != nil {
+			return nil, fmt.Errorf("failed to render PDF: %w", err)
+		}
+	}
+	return e.searchablePdf(ctx, buffer, pages)
+}
+
+// searchablePdf transcribes rendered pages and adds their text as an invisible layer to the PDF
+func (e *Extractor) searchablePdf(ctx context.Context, buffer []byte, pages []types.PdfPageImage) (*types.SearchablePdfResult, error) {
+	result := &types.SearchablePdfResult{Pages: make([]types.PageLayout, 0, len(pages))}
+	options := types.ExtractionOptions{Schema: transcriptionSchema, Instructions: transcriptionInstructions}
+
+	// One page per request, since the line positions are relative to the page
+	for _, page := range pages {
+		transcription, err := e.extractFromImages(ctx, []types.PdfPageImage{page}, transcriptionSchema, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transcribe page %d: %w", page.Page, err)
+		}
+		var data struct {
+			Lines []types.TextLine
+		}
+		if err := decodeData(transcription.Data, &data); err != nil {
+			return nil, err
+		}
+		result.TokensUsed += transcription.TokensUsed
+		result.Usage.Add(transcription.Usage)
+		result.Model = transcription.Model
+
+		layout := types.PageLayout{Page: page.Page, Width: 1, Height: 1, Lines: make([]types.TextLine, 0, len(data.Lines))}
+		for _, line := range data.Lines {
+			bounds := line.Bounds
+			if line.Text == "" || bounds.Width <= 0 || bounds.Height <= 0 || bounds.X < 0 || bounds.Y < 0 ||
+				bounds.X+bounds.Width > 1 || bounds.Y+bounds.Height > 1 {
+				continue
+			}
+			layout.Lines = append(layout.Lines, types.TextLine{Text: line.Text, Bounds: bounds})
+		}
+		result.Pages = append(result.Pages, layout)
+	}
+
+	pdf, err := e.addTextLayer(ctx, buffer, result.Pages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add text layer: %w", err)
+	}
+	result.PDF = pdf
+	return result, nil
+}
@@ -0,0 +1,274 @@
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// ErrSectionNotFound is returned when the section of ExtractionOptions.Section is neither in the
+// outline nor among the headings of the document
+var ErrSectionNotFound = errors.New("section not found")
+
+const (
+	// sectionPathSeparator separates the titles of a path of outline entries in ExtractionOptions.Section
+	sectionPathSeparator = ">"
+	// maxHeadingWords is the most words of a line read as a heading
+	maxHeadingWords = 12
+	// maxHeadingLength is the longest line, in characters, read as a heading
+	maxHeadingLength = 120
+)
+
+// narrowSection narrows a parsed PDF to the section of the options, if any, and tells the model
+// about it. The options returned have no section, so that the PDF is not narrowed twice.
+func narrowSection(parsedPdf *types.ParsedPdf, options types.ExtractionOptions) (*types.ParsedPdf, types.ExtractionOptions, error) {
+	if options.Section == "" {
+		return parsedPdf, options, nil
+	}
+	narrowed, err := sectionPdf(parsedPdf, options.Section)
+	if err != nil {
+		return nil, options, err
+	}
+	options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" +
+		fmt.Sprintf("Only the section %q of the document is given. Extract the information from this section.", options.Section))
+	options.Section = ""
+	return narrowed, options, nil
+}
+
+// sectionPdf narrows a parsed PDF to a section, given by the title of its heading or by a path of
+// outline titles. The pages of the section are found in the outline of the PDF, when it has one,
+// and otherwise the text of the section is found from its heading to the next heading of the same
+// kind. It fails with ErrSectionNotFound when the section is found in neither.
+func sectionPdf(parsedPdf *types.ParsedPdf, section string) (*types.ParsedPdf, error) {
+	path := make([][]string, 0)
+	for _, title := range strings.Split(section, sectionPathSeparator) {
+		if words := titleWords(title); len(words) > 0 {
+			path = append(path, words)
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrSectionNotFound, section)
+	}
+
+	// The parsed PDF may be shared with other extractions, so narrow a copy
+	narrowed := *parsedPdf
+	content := &narrowed.Content
+	numPages := max(parsedPdf.NumPages, len(content.TextPages), len(content.PageTypes))
+	first, last, found := outlineSection(parsedPdf.Outline, path, numPages)
+	switch {
+	case found && content.Type == "text":
+		pages := content.TextPages[min(first-1, len(content.TextPages)):min(last, len(content.TextPages))]
+		content.TextPages = pages
+		content.TextContent = strings.Join(pages, "\n") + "\n"
+	case found && content.Type == "mixed":
+		content.PageTypes = keepPageTypes(parsedPdf.Content.PageTypes, first, last)
+	case found:
+		content.ImageContent = make([]types.PdfPageImage, 0, last-first+1)
+		for _, image := range parsedPdf.Content.ImageContent {
+			if image.Page >= first && image.Page <= last {
+				content.ImageContent = append(content.ImageContent, image)
+			}
+		}
+	case content.Type == "text" || content.Type == "mixed":
+		// Without an outline, look for the heading of the last title of the path
+		var pages []string
+		if first, last, pages, found = headingSection(content.TextPages, path[len(path)-1]); !found {
+			return nil, fmt.Errorf("%w: %q", ErrSectionNotFound, section)
+		}
+		if content.Type == "text" {
+			content.TextPages = pages[first-1 : last]
+			content.TextContent = strings.Join(content.TextPages, "\n") + "\n"
+			break
+		}
+		content.TextPages = pages
+		content.TextContent = strings.Join(pages, "\n") + "\n"
+		content.PageTypes = keepPageTypes(parsedPdf.Content.PageTypes, first, last)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrSectionNotFound, section)
+	}
+	return &narrowed, nil
+}
+
+// keepPageTypes returns a copy of the page types of a mixed document without the types of the pages
+// outside a range, which leaves them out of requests
+func keepPageTypes(pageTypes []string, first, last int) []string {
+	kept := make([]string, len(pageTypes))
+	if first <= len(pageTypes) {
+		copy(kept[first-1:], pageTypes[first-1:min(last, len(pageTypes))])
+	}
+	return kept
+}
+
+// outlineSection returns the pages of the entry of an outline matching a path of titles, given as
+// words: the entry whose title matches the last title of the path, under entries matching the
+// others. The section ends before the next entry of the same or a higher level. Entries matching
+// the titles exactly are preferred over entries whose title only starts with them.
+func outlineSection(outline []types.OutlineItem, path [][]string, numPages int) (first, last int, found bool) {
+	type entry struct {
+		item  types.OutlineItem
+		level int
+		// titles are the words of the titles of the entry and its ancestors, root first
+		titles [][]string
+	}
+	var entries []entry
+	var walk func(items []types.OutlineItem, level int, titles [][]string)
+	walk = func(items []types.OutlineItem, level int, titles [][]string) {
+		for _, item := range items {
+			itemTitles := append(titles[:len(titles):len(titles)], titleWords(item.Title))
+			entries = append(entries, entry{item: item, level: level, titles: itemTitles})
+			walk(item.Children, level+1, itemTitles)
+		}
+	}
+	walk(outline, 0, nil)
+
+	for _, exact := range []bool{true, false} {
+		for i, candidate := range entries {
+			if candidate.item.Page < 1 || candidate.item.Page > numPages || !pathMatches(candidate.titles, path, exact) {
+				continue
+			}
+			first, last = candidate.item.Page, numPages
+			for _, next := range entries[i+1:] {
+				if next.level <= candidate.level && next.item.Page >= 1 {
+					last = max(first, next.item.Page-1)
+					break
+				}
+			}
+			return first, last, true
+		}
+	}
+	return 0, 0, false
+}
+
+// pathMatches reports whether the titles of an outline entry, root first, end with the titles of a
+// path, an ancestor being allowed in between
+func pathMatches(titles, path [][]string, exact bool) bool {
+	if len(titles) == 0 || !titleMatches(titles[len(titles)-1], path[len(path)-1], exact) {
+		return false
+	}
+	ancestors := titles[:len(titles)-1]
+	for i := len(path) - 2; i >= 0; i-- {
+		for len(ancestors) > 0 && !titleMatches(ancestors[len(ancestors)-1], path[i], exact) {
+			ancestors = ancestors[:len(ancestors)-1]
+		}
+		if len(ancestors) == 0 {
+			return false
+		}
+		ancestors = ancestors[:len(ancestors)-1]
+	}
+	return true
+}
+
+// titleMatches reports whether the words of a title are those of the wanted title, or start with
+// them unless exact is set, e.g. "Schedule A - Pricing" for "Schedule A". The number of a numbered
+// title may be left out of the wanted title, e.g. "Fees" for "2. Fees".
+func titleMatches(title, want []string, exact bool) bool {
+	if len(title) > 0 && len(want) > 0 && isNumber(title[0]) && !isNumber(want[0]) {
+		title = title[1:]
+	}
+	if len(title) < len(want) || (exact && len(title) != len(want)) {
+		return false
+	}
+	for i, word := range want {
+		if title[i] != word {
+			return false
+		}
+	}
+	return true
+}
+
+// headingSection finds the text under a heading in the text of the pages: from the first short line
+// starting with the words of the heading to the next heading of the same kind, e.g. "Schedule B"
+// after "Schedule A", "3. Fees" after "2. Services", or a Markdown heading of the same or a higher
+// level. It returns the first and last pages (1-indexed) of the section and the text of the pages
+// with everything outside the section left out.
+func headingSection(pages []string, heading []string) (first, last int, texts []string, found bool) {
+	texts = make([]string, len(pages))
+	var markdownLevel int
+	var sibling func(line string, words []string) bool
+	for i, page := range pages {
+		lines := strings.Split(page, "\n")
+		start, end := 0, len(lines)
+		for j, line := range lines {
+			words := titleWords(line)
+			if !found {
+				if !isHeadingLine(line, words) || !titleMatches(words, heading, false) {
+					continue
+				}
+				found, first, start = true, i+1, j
+				markdownLevel = markdownHeadingLevel(line)
+				sibling = siblingHeading(line, words)
+				continue
+			}
+			level := markdownHeadingLevel(line)
+			if (markdownLevel > 0 && level > 0 && level <= markdownLevel) ||
+				(markdownLevel == 0 && isHeadingLine(line, words) && sibling(line, words)) {
+				end = j
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		last = i + 1
+		texts[i] = strings.Join(lines[start:end], "\n")
+		if end < len(lines) {
+			return first, last, texts, true
+		}
+	}
+	return first, last, texts, found
+}
+
+// siblingHeading returns a function telling whether a heading line is of the same kind as a heading
+// line, given with its words: the next number of a numbered heading, with the
+// same punctuation, or another heading starting with the same word followed by a label, such as
+// "Schedule B" or "Article 6" after "Schedule A" or "Article 5"
+func siblingHeading(headingLine string, heading []string) func(line string, words []string) bool {
+	if number, err := strconv.Atoi(heading[0]); err == nil {
+		next := strconv.Itoa(number + 1)
+		// Keep the punctuation after the number, so that "3 months" does not follow "2. Services"
+		rest := strings.TrimLeft(strings.TrimSpace(strings.TrimLeft(headingLine, "# ")), "0123456789")
+		if rest != "" && strings.ContainsRune(".):", rune(rest[0])) {
+			next += rest[:1]
+		}
+		return func(line string, _ []string) bool {
+			return strings.HasPrefix(strings.TrimSpace(strings.TrimLeft(line, "# ")), next)
+		}
+	}
+	if len(heading) >= 2 {
+		return func(_ string, words []string) bool {
+			return len(words) >= 2 && words[0] == heading[0] && words[1] != heading[1]
+		}
+	}
+	return func(string, []string) bool { return false }
+}
+
+// isNumber reports whether a word is made of digits only
+func isNumber(word string) bool {
+	_, err := strconv.Atoi(word)
+	return err == nil
+}
+
+// isHeadingLine reports whether a line, given with its words, is short enough to be a heading
+func isHeadingLine(line string, words []string) bool {
+	return len(words) > 0 && len(words) <= maxHeadingWords && len(strings.TrimSpace(line)) <= maxHeadingLength
+}
+
+// markdownHeadingLevel returns the level of a Markdown heading line, or 0
+func markdownHeadingLevel(line string) int {
+	trimmed := strings.TrimLeft(line, "#")
+	if level := len(line) - len(trimmed); level > 0 && strings.HasPrefix(trimmed, " ") {
+		return level
+	}
+	return 0
+}
+
+// titleWords returns the lowercased words of a title, without spacing and punctuation
+func titleWords(title string) []string {
+	return strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,160 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// The steps below split an extraction so that it can be embedded in a workflow engine such as
+// Temporal or Cadence, with each step as a retryable activity:
+//
+//	parsedPdf, err := ext.Parse(options)
+//	request, err := ext.BuildRequest(parsedPdf, options)
+//	response, err := ext.CallModel(ctx, request)
+//	result, err := ext.ParseResult(response)
+//
+// Every intermediate state serializes to JSON. Only CallModel talks to the model; the other steps
+// are deterministic. The steps cover the model extraction only: form fields, searchable PDFs,
+// indexing and events are handled by ExtractWithContext.
+
+// Parse validates the PDF input of the options and parses it, the first step of an extraction. The
+// parsed PDF can be passed back in ExtractionOptions.ParsedPdf to extract other schemas from the
+// same document without parsing it again.
+func (e *Extractor) Parse(options types.ExtractionOptions) (*types.ParsedPdf, error) {
+	return e.parse(context.Background(), options)
+}
+
+// BuildRequest builds the model request extracting the schema of the options from a parsed PDF
+func (e *Extractor) BuildRequest(parsedPdf *types.ParsedPdf, options types.ExtractionOptions) (*types.ModelRequest, error) {
+	if parsedPdf == nil {
+		return nil, errors.New("parsed PDF is nil")
+	}
+	schemaData, names, err := combinedSchema(options)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSchemaDrift(options.SchemaDrift); err != nil {
+		return nil, err
+	}
+	if parsedPdf, options, err = narrowSection(parsedPdf, options); err != nil {
+		return nil, err
+	}
+	parsedPdf, options, minimizeWarnings := minimizePdf(parsedPdf, schemaData, options)
+	if len(options.NullableFields) > 0 {
+		if schemaData, err = nullableSchema(schemaData, options.NullableFields); err != nil {
+			return nil, err
+		}
+		options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" + nullableInstructions)
+	}
+	options.Schema = schemaData
+	if err := e.checkSchemaLimits(schemaData); err != nil {
+		return nil, err
+	}
+	directives, err := schemaDirectives(schemaData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if len(directives) > 0 {
+		options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" + directiveInstructions(directives))
+	}
+
+	// Decoded barcodes are exact, so hand them to the model as ground truth
+	if len(parsedPdf.Barcodes) > 0 {
+		options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" + barcodeInstructions(parsedPdf.Barcodes))
+	}
+	// Reviewers often note the values to extract in comments
+	if annotations, _ := parsedPdf.Info[types.InfoAnnotations].([]types.Annotation); len(annotations) > 0 {
+		options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" + annotationInstructions(annotations))
+	}
+	// The text of links to emails and web addresses is often shortened on the page
+	if len(parsedPdf.Links) > 0 {
+		options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" + linkInstructions(parsedPdf.Links))
+	}
+	if parsedPdf.Content.Type != "text" && parsedPdf.Content.OCRText != "" {
+		options.Instructions = strings.TrimSpace(options.Instructions + "\n\n" + ocrInstructions(parsedPdf.Content.OCRText))
+	}
+
+	request := &types.ModelRequest{
+		Barcodes:       parsedPdf.Barcodes,
+		Links:          parsedPdf.Links,
+		FailedPages:    parsedPdf.FailedPages,
+		Schemas:        names,
+		NullableFields: options.NullableFields,
+		IdempotencyKey: options.IdempotencyKey,
+		Warnings:       minimizeWarnings,
+		Schema:         options.Schema,
+		SchemaDrift:    options.SchemaDrift,
+	}
+	switch parsedPdf.Content.Type {
+	case "text":
+		request.Body = e.textRequest(parsedPdf.Content.TextContent, options.Schema, options)
+	case "mixed":
+		body, err := e.mixedRequest(parsedPdf, 1, len(parsedPdf.Content.PageTypes), options.Schema, options)
+		if err != nil {
+			return nil, err
+		}
+		request.Body = body
+	default:
+		body, err := e.imageRequest(parsedPdf.Content.ImageContent, options.Schema, options)
+		if err != nil {
+			return nil, err
+		}
+		request.Body = body
+	}
+
+	if err := e.fitContext(request, parsedPdf, options); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// CallModel sends a model request and returns the raw response. It is the only step calling the
+// model, and the one to retry on transient errors.
+func (e *Extractor) CallModel(ctx context.Context, request *types.ModelRequest) (*types.ModelResponse, error) {
+	if request == nil {
+		return nil, errors.New("model request is nil")
+	}
+	body, err := e.postOpenAI(ctx, request.Body, request.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ModelResponse{Body: body, Barcodes: request.Barcodes, Links: request.Links, FailedPages: request.FailedPages, Warnings: request.Warnings, Schemas: request.Schemas, NullableFields: request.NullableFields,
+		Schema: request.Schema, SchemaDrift: request.SchemaDrift}, nil
+}
+
+// ParseResult reads the extracted data and usage of a model response, the last step of an
+// extraction
+func (e *Extractor) ParseResult(response *types.ModelResponse) (*types.ExtractionResult, error) {
+	if response == nil {
+		return nil, errors.New("model response is nil")
+	}
+	result, err := e.parseResponse(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	result.Barcodes = response.Barcodes
+	result.Links = response.Links
+	result.FailedPages = response.FailedPages
+	result.Warnings = response.Warnings
+	// Check the keys of the data before the status of nullable fields and the schemas are split out
+	drift, err := checkDrift(result.Data, response.Schema, response.SchemaDrift)
+	if err != nil {
+		return nil, err
+	}
+	if drift != nil {
+		result.Drift = drift
+		result.Warnings = append(slices.Clone(result.Warnings), driftWarnings(drift, response.SchemaDrift != types.SchemaDriftKeep)...)
+	}
+	if len(response.NullableFields) > 0 {
+		result.FieldStatus = fieldStatus(result.Data, response.NullableFields)
+	}
+	if len(response.Schemas) > 0 {
+		result.SchemaData = splitData(result.Data, response.Schemas)
+	}
+	return result, nil
+}
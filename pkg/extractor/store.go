@@ -0,0 +1,50 @@
+package extractor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// save adds an extraction, completed or failed, to the history of the configured store
+func (e *Extractor) save(ctx context.Context, options types.ExtractionOptions, result *types.ExtractionResult, extractErr error, duration time.Duration) error {
+	// Failed extractions may not have a readable PDF to identify them
+	id, _ := documentID(options)
+	extraction := types.StoredExtraction{
+		DocumentID: id,
+		Status:     types.BatchStatusSuccess,
+		Time:       time.Now().UTC(),
+		DurationMs: duration.Milliseconds(),
+	}
+	if buffer, err := readPdf(options); err == nil {
+		sum := sha256.Sum256(buffer)
+		extraction.DocumentHash = hex.EncodeToString(sum[:])
+	}
+	if extractErr != nil {
+		extraction.Status = types.BatchStatusError
+		extraction.Error = extractErr.Error()
+		extraction.SchemaName = schemaName(options)
+		extraction.SchemaVersion = options.SchemaVersion
+		extraction.LibraryVersion = LibraryVersion()
+		if schemaData, _, err := combinedSchema(options); err == nil {
+			extraction.SchemaHash = schemaHash(schemaData)
+		}
+	} else {
+		extraction.Data = result.Data
+		extraction.Model = result.Model
+		extraction.TokensUsed = result.TokensUsed
+		extraction.SchemaName = result.SchemaName
+		extraction.SchemaHash = result.SchemaHash
+		extraction.SchemaVersion = result.SchemaVersion
+		extraction.LibraryVersion = result.LibraryVersion
+	}
+
+	if err := e.config.Store.SaveExtraction(ctx, extraction); err != nil {
+		return fmt.Errorf("failed to save extraction: %w", err)
+	}
+	return nil
+}
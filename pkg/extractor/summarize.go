@@ -0,0 +1,92 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// summaryLengthGuides maps summary lengths to the guidance given to the model
+var summaryLengthGuides = map[string]string{
+	types.SummaryLengthShort:  "Write an abstract of 1-2 sentences and at most 3 key points.",
+	types.SummaryLengthMedium: "Write an abstract of one paragraph and 3 to 6 key points.",
+	types.SummaryLengthLong:   "Write an abstract of several paragraphs and up to 12 key points.",
+}
+
+// summarySchema is the built-in JSON schema used for summaries
+var summarySchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"title": map[string]interface{}{
+			"type": "string",
+		},
+		"abstract": map[string]interface{}{
+			"type": "string",
+		},
+		"keyPoints": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "string",
+			},
+		},
+		"entities": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type": "string",
+					},
+					"type": map[string]interface{}{
+						"type": "string",
+						"enum": []string{"person", "organization", "location", "date", "amount", "product", "other"},
+					},
+				},
+				"required":             []string{"name", "type"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"title", "abstract", "keyPoints", "entities"},
+	"additionalProperties": false,
+}
+
+// Summarize produces a structured summary of a PDF given as a file path or a byte slice.
+// Text-based and scanned PDFs are routed exactly as in Extract.
+func (e *Extractor) Summarize(ctx context.Context, pdf interface{}, options types.SummarizeOptions) (*types.SummaryResult, error) {
+	length := options.Length
+	if length == "" {
+		length = types.SummaryLengthMedium
+	}
+	lengthGuide, ok := summaryLengthGuides[length]
+	if !ok {
+		return nil, fmt.Errorf("invalid summary length %q: must be one of short, medium or long", options.Length)
+	}
+
+	extractionOptions, err := pdfOptions(pdf)
+	if err != nil {
+		return nil, err
+	}
+
+	instructions := "Summarize the document. Provide its title, an abstract, the key points and the notable entities it mentions. " + lengthGuide
+	if options.Focus != "" {
+		instructions += fmt.Sprintf(" Focus the summary on: %s.", options.Focus)
+	}
+	extractionOptions.Schema = summarySchema
+	extractionOptions.Instructions = instructions
+
+	result, err := e.ExtractWithContext(ctx, extractionOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &types.SummaryResult{}
+	if err := decodeData(result.Data, summary); err != nil {
+		return nil, err
+	}
+	summary.TokensUsed = result.TokensUsed
+	summary.Model = result.Model
+
+	return summary, nil
+}
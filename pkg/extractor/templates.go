@@ -0,0 +1,217 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// RegisterTemplate adds a layout template to those matched against documents before calling the
+// model. Templates are tried in the order they were registered, after those of the config.
+func (e *Extractor) RegisterTemplate(template types.LayoutTemplate) error {
+	if err := validateTemplate(template); err != nil {
+		return err
+	}
+	e.templatesMu.Lock()
+	defer e.templatesMu.Unlock()
+	e.templates = append(e.templates, template)
+	return nil
+}
+
+// validateTemplate checks that a template has anchors and fields, with regions within the page
+// and valid patterns
+func validateTemplate(template types.LayoutTemplate) error {
+	if template.Name == "" {
+		return errors.New("template name is required")
+	}
+	if len(template.Anchors) == 0 {
+		return fmt.Errorf("template %q has no anchors", template.Name)
+	}
+	for _, anchor := range template.Anchors {
+		if strings.TrimSpace(anchor.Text) == "" {
+			return fmt.Errorf("template %q has an anchor without text", template.Name)
+		}
+		if anchor.Page < 0 {
+			return fmt.Errorf("template %q anchor %q has invalid page %d", template.Name, anchor.Text, anchor.Page)
+		}
+		if anchor.Region != (types.Rect{}) && !validRegion(anchor.Region) {
+			return fmt.Errorf("template %q anchor %q region must lie within the page (values between 0 and 1)", template.Name, anchor.Text)
+		}
+	}
+	if len(template.Fields) == 0 {
+		return fmt.Errorf("template %q has no fields", template.Name)
+	}
+	names := make(map[string]bool, len(template.Fields))
+	for _, field := range template.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("template %q has a field without name", template.Name)
+		}
+		if names[field.Name] {
+			return fmt.Errorf("template %q has duplicate field %q", template.Name, field.Name)
+		}
+		names[field.Name] = true
+		if field.Page < 1 {
+			return fmt.Errorf("template %q field %q has invalid page %d", template.Name, field.Name, field.Page)
+		}
+		if !validRegion(field.Region) {
+			return fmt.Errorf("template %q field %q region must lie within the page (values between 0 and 1)", template.Name, field.Name)
+		}
+		if _, err := regexp.Compile(field.Pattern); err != nil {
+			return fmt.Errorf("template %q field %q has invalid pattern: %w", template.Name, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// validRegion reports whether a region relative to the page size lies within the page
+func validRegion(r types.Rect) bool {
+	return r.Width > 0 && r.Height > 0 && r.X >= 0 && r.Y >= 0 && r.X+r.Width <= 1 && r.Y+r.Height <= 1
+}
+
+// extractTemplate extracts the data of a document matching a layout template from the positions
+// of its words. It returns nil when no template matches, or when the data of the matching template
+// does not fit the schema, with a warning, so that the document is extracted by the model instead.
+func (e *Extractor) extractTemplate(ctx context.Context, parsedPdf *types.ParsedPdf, options types.ExtractionOptions) (*types.ExtractionResult, []string) {
+	e.templatesMu.RLock()
+	templates := e.templates
+	e.templatesMu.RUnlock()
+	// Templates fill a single schema from the whole document
+	if len(templates) == 0 || options.SkipTemplates || len(options.Schemas) > 0 || options.Section != "" {
+		return nil, nil
+	}
+
+	layouts := parsedPdf.Layout
+	if len(layouts) == 0 && parsedPdf.Content.Type != "images" {
+		buffer, err := readPdf(options)
+		if err != nil {
+			return nil, nil
+		}
+		if layouts, err = e.textLayout(ctx, buffer); err != nil {
+			return nil, nil
+		}
+	}
+	pages := make(map[int]*types.PageLayout, len(layouts))
+	var text strings.Builder
+	for i := range layouts {
+		pages[layouts[i].Page] = &layouts[i]
+		for _, line := range layouts[i].Lines {
+			text.WriteString(line.Text + "\n")
+		}
+	}
+	if len(pages) == 0 {
+		return nil, nil
+	}
+	decimalSeparator := options.DecimalSeparator
+	if decimalSeparator == "" {
+		decimalSeparator = parser.DetectDecimalSeparator(text.String())
+	}
+
+	for _, template := range templates {
+		if !templateMatches(template, pages) {
+			continue
+		}
+		data, err := templateData(template, pages, options.Schema, decimalSeparator)
+		if err == nil {
+			var errs []string
+			if errs, err = schema.ValidateData(data, options.Schema); err == nil && len(errs) > 0 {
+				err = errors.New(strings.Join(errs, "; "))
+			}
+		}
+		if err != nil {
+			return nil, []string{fmt.Sprintf("template %q matched but its data does not fit the schema, extracted with the model: %v", template.Name, err)}
+		}
+		return &types.ExtractionResult{
+			Data:        data,
+			Template:    template.Name,
+			Barcodes:    parsedPdf.Barcodes,
+			Links:       parsedPdf.Links,
+			FailedPages: parsedPdf.FailedPages,
+		}, nil
+	}
+	return nil, nil
+}
+
+// templateMatches reports whether every anchor of a template is found on its page and in its region
+func templateMatches(template types.LayoutTemplate, pages map[int]*types.PageLayout) bool {
+	for _, anchor := range template.Anchors {
+		found := false
+		for number, page := range pages {
+			if anchor.Page != 0 && anchor.Page != number {
+				continue
+			}
+			for _, bounds := range page.Find(anchor.Text) {
+				if anchor.Region == (types.Rect{}) || inRegion(bounds, anchor.Region, page) {
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// inRegion reports whether the center of a rectangle, in points, lies in a region relative to the
+// size of a page
+func inRegion(bounds, region types.Rect, page *types.PageLayout) bool {
+	x, y := (bounds.X+bounds.Width/2)/page.Width, (bounds.Y+bounds.Height/2)/page.Height
+	return x >= region.X && x <= region.X+region.Width && y >= region.Y && y <= region.Y+region.Height
+}
+
+// templateData reads the fields of a template from the regions of the pages, as numbers for the
+// number and integer fields of the schema. Fields whose region is empty are left out.
+func templateData(template types.LayoutTemplate, pages map[int]*types.PageLayout, schemaData map[string]interface{}, decimalSeparator string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for _, field := range template.Fields {
+		page, ok := pages[field.Page]
+		if !ok {
+			continue
+		}
+		r := field.Region
+		text := page.TextIn(types.Rect{X: r.X * page.Width, Y: r.Y * page.Height, Width: r.Width * page.Width, Height: r.Height * page.Height})
+		if field.Pattern != "" {
+			match := regexp.MustCompile(field.Pattern).FindStringSubmatch(text)
+			switch {
+			case match == nil:
+				text = ""
+			case len(match) > 1:
+				text = match[1]
+			default:
+				text = match[0]
+			}
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+
+		var value interface{} = text
+		fieldSchema := schemaAtValuePath(schemaData, field.Name)
+		if schemaTypeIs(fieldSchema, "number") || schemaTypeIs(fieldSchema, "integer") {
+			number, err := parser.ParseNumber(text, decimalSeparator)
+			if err != nil {
+				return nil, fmt.Errorf("%s = %q is no number", field.Name, text)
+			}
+			if schemaTypeIs(fieldSchema, "integer") && number == math.Trunc(number) {
+				value = int64(number)
+			} else {
+				value = number
+			}
+		}
+		if err := setDataPath(data, field.Name, value); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
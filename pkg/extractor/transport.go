@@ -0,0 +1,109 @@
+package extractor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// ErrHostNotAllowed is returned for the model requests, and their redirects, to hosts missing from
+// ExtractorConfig.AllowedHosts
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// newHTTPClient returns the HTTP client of the model requests, with the proxy, CA bundle and
+// client certificate of the configuration, limited to the allowed hosts, and to local endpoints in
+// air-gapped mode
+func newHTTPClient(config types.ExtractorConfig) (*http.Client, error) {
+	if !config.AirGapped && len(config.AllowedHosts) == 0 && config.ProxyURL == "" && config.CACertFile == "" && config.ClientCertFile == "" && config.ClientKeyFile == "" {
+		return &http.Client{}, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", config.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.CACertFile != "" {
+		bundle, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		// Trust the bundle on top of the system roots, e.g. for a TLS-intercepting corporate proxy
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no PEM certificate found in CA bundle %s", config.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		if config.ClientCertFile == "" || config.ClientKeyFile == "" {
+			return nil, errors.New("ClientCertFile and ClientKeyFile must be set together")
+		}
+		certificate, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	client := &http.Client{Transport: transport}
+	if config.AirGapped {
+		restrictEgress(client, transport, config)
+	}
+	if len(config.AllowedHosts) > 0 {
+		client.Transport = &allowedHostsTransport{next: transport, hosts: config.AllowedHosts}
+	}
+	return client, nil
+}
+
+// allowedHostsTransport refuses the requests to hosts missing from ExtractorConfig.AllowedHosts. It
+// checks every request sent, so redirects to other hosts are refused too.
+type allowedHostsTransport struct {
+	next  *http.Transport
+	hosts []string
+}
+
+// RoundTrip sends a request to an allowed host
+func (t *allowedHostsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !allowedHost(req.URL.Hostname(), t.hosts) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %s is not one of the allowed hosts", ErrHostNotAllowed, req.URL.Hostname())
+	}
+	return t.next.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the transport
+func (t *allowedHostsTransport) CloseIdleConnections() {
+	t.next.CloseIdleConnections()
+}
+
+// allowedHost reports whether a host is one of hosts, regardless of case, or a subdomain of a
+// "*.domain" entry
+func allowedHost(host string, hosts []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(strings.TrimSuffix(allowed, "."))
+		if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+			return true
+		}
+	}
+	return false
+}
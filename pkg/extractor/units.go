@@ -0,0 +1,50 @@
+package extractor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// currencyFields are the lowercased names of the fields holding a currency
+var currencyFields = map[string]bool{"currency": true, "currencycode": true, "currency_code": true}
+
+// detectUnits detects the currency and units of measure of a parsed PDF from its text into the
+// result, and normalizes the currency fields of the data in place: currency symbols become ISO 4217
+// codes and empty currency fields get the currency of the document. It returns a warning for every
+// value changed, sorted.
+func detectUnits(parsedPdf *types.ParsedPdf, result *types.ExtractionResult) ([]string, error) {
+	text := parsedPdf.Content.TextContent + "\n" + parsedPdf.Content.OCRText
+	result.Currency = parser.DetectCurrency(text)
+	result.Units = parser.DetectUnits(text)
+
+	var warnings []string
+	changes := make(map[string]interface{})
+	walkValues(result.Data, "", func(path string, value interface{}) {
+		if !currencyFields[strings.ToLower(path[strings.LastIndex(path, ".")+1:])] {
+			return
+		}
+		text, _ := value.(string)
+		if code := parser.CurrencyCode(text); code != "" {
+			if code != text {
+				changes[path] = code
+				warnings = append(warnings, fmt.Sprintf("%s = %q normalized to %s", path, text, code))
+			}
+			return
+		}
+		if strings.TrimSpace(text) == "" && result.Currency != "" {
+			changes[path] = result.Currency
+			warnings = append(warnings, fmt.Sprintf("%s set to %s, the currency of the document", path, result.Currency))
+		}
+	})
+	for path, value := range changes {
+		if err := setValuePath(result.Data, path, value); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(warnings)
+	return warnings, nil
+}
@@ -0,0 +1,110 @@
+package extractor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// uploadReuse is how long an uploaded image is referenced again instead of being uploaded anew,
+// long enough for the retries of a request
+const uploadReuse = 10 * time.Minute
+
+// upload is an image uploaded with the ImageUploader of the config
+type upload struct {
+	url string
+	at  time.Time
+}
+
+// uploadImages returns a copy of a request body with the data URLs of its images replaced by the
+// URLs of the images uploaded with the ImageUploader of the config. Images uploaded recently, e.g.
+// for a previous attempt of the request, are not uploaded again. Bodies are maps, or generic values
+// once a request went through JSON.
+func (e *Extractor) uploadImages(ctx context.Context, value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			if url, ok := item.(string); ok && key == "url" && strings.HasPrefix(url, "data:") {
+				uploaded, err := e.uploadImage(ctx, url)
+				if err != nil {
+					return nil, err
+				}
+				copied[key] = uploaded
+				continue
+			}
+			item, err := e.uploadImages(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			copied[key] = item
+		}
+		return copied, nil
+	case []map[string]interface{}:
+		copied := make([]map[string]interface{}, len(value))
+		for i, item := range value {
+			uploaded, err := e.uploadImages(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			copied[i], _ = uploaded.(map[string]interface{})
+		}
+		return copied, nil
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			uploaded, err := e.uploadImages(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			copied[i] = uploaded
+		}
+		return copied, nil
+	default:
+		return value, nil
+	}
+}
+
+// uploadImage uploads the image of a base64 data URL, or returns the URL of its recent upload
+func (e *Extractor) uploadImage(ctx context.Context, dataURL string) (string, error) {
+	header, encoded, found := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ";base64,")
+	if !found {
+		return dataURL, nil
+	}
+	sum := sha256.Sum256([]byte(dataURL))
+	key := hex.EncodeToString(sum[:])
+
+	e.uploadsMu.Lock()
+	now := time.Now()
+	for hash, previous := range e.uploads {
+		if now.Sub(previous.at) > uploadReuse {
+			delete(e.uploads, hash)
+		}
+	}
+	previous, ok := e.uploads[key]
+	e.uploadsMu.Unlock()
+	if ok {
+		return previous.url, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	url, err := e.config.ImageUploader.UploadImage(ctx, data, header)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+
+	e.uploadsMu.Lock()
+	if e.uploads == nil {
+		e.uploads = make(map[string]upload)
+	}
+	e.uploads[key] = upload{url: url, at: now}
+	e.uploadsMu.Unlock()
+	return url, nil
+}
@@ -0,0 +1,60 @@
+package extractor
+
+import "github.com/ilopezluna/go-pdf-extractor/pkg/types"
+
+// Usage fields of OpenAI-compatible servers, in order of preference, by dot-separated path
+var (
+	promptTokenFields     = []string{"prompt_tokens", "input_tokens"}
+	completionTokenFields = []string{"completion_tokens", "output_tokens"}
+	// Cached tokens are reported by OpenAI, DeepSeek and Anthropic-compatible servers, and others
+	cachedTokenFields = []string{"prompt_tokens_details.cached_tokens", "input_tokens_details.cached_tokens",
+		"prompt_cache_hit_tokens", "cache_read_input_tokens", "cached_tokens"}
+	reasoningTokenFields = []string{"completion_tokens_details.reasoning_tokens", "output_tokens_details.reasoning_tokens", "reasoning_tokens"}
+)
+
+// parseUsage reads the usage of a chat completion response, whatever its shape
+func parseUsage(raw map[string]interface{}) types.Usage {
+	details := make(map[string]int)
+	flattenUsage(details, "", raw)
+	if len(details) == 0 {
+		return types.Usage{}
+	}
+
+	usage := types.Usage{
+		PromptTokens:     firstUsage(details, promptTokenFields),
+		CompletionTokens: firstUsage(details, completionTokenFields),
+		CachedTokens:     firstUsage(details, cachedTokenFields),
+		ReasoningTokens:  firstUsage(details, reasoningTokenFields),
+		Details:          details,
+	}
+	usage.TotalTokens = firstUsage(details, []string{"total_tokens"})
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	return usage
+}
+
+// flattenUsage stores the numeric fields of a usage object in details, by dot-separated path
+func flattenUsage(details map[string]int, prefix string, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenUsage(details, key, child)
+		}
+	case float64:
+		details[prefix] = int(value)
+	}
+}
+
+// firstUsage returns the first of the given usage fields that is reported
+func firstUsage(details map[string]int, fields []string) int {
+	for _, field := range fields {
+		if value, ok := details[field]; ok {
+			return value
+		}
+	}
+	return 0
+}
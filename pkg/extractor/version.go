@@ -0,0 +1,62 @@
+package extractor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"runtime/debug"
+	"sync"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// modulePath is the path of the module of the extractor in the build information
+const modulePath = "github.com/ilopezluna/go-pdf-extractor"
+
+// libraryVersion reads the version of the module from the build information once
+var libraryVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dependency := range info.Deps {
+		if dependency.Path != modulePath {
+			continue
+		}
+		if dependency.Replace != nil && dependency.Replace.Version != "" {
+			return dependency.Replace.Version
+		}
+		return dependency.Version
+	}
+	return ""
+})
+
+// LibraryVersion returns the version of the go-pdf-extractor module the binary is built with, such
+// as "v1.4.0", "(devel)" when it is built in the source tree of the module, or "" when the binary
+// has no build information
+func LibraryVersion() string {
+	return libraryVersion()
+}
+
+// stamp sets the hash, name and version of the schema of the options and the library version on a
+// result, so that stored results can be told apart when the schema changes
+func stamp(result *types.ExtractionResult, schemaData map[string]interface{}, options types.ExtractionOptions) {
+	result.SchemaHash = schemaHash(schemaData)
+	result.SchemaName = schemaName(options)
+	result.SchemaVersion = options.SchemaVersion
+	result.LibraryVersion = LibraryVersion()
+}
+
+// schemaHash returns the SHA-256, in hex, of a schema serialized as JSON with sorted keys, or ""
+// when it cannot be serialized
+func schemaHash(schemaData map[string]interface{}) string {
+	encoded, err := json.Marshal(schemaData)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,139 @@
+package extractor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	// defaultVocabularyThreshold is the lowest confidence at which values are replaced by the
+	// closest value of their vocabulary
+	defaultVocabularyThreshold = 0.8
+	// prefixWordScore is the score of a word matching an abbreviation of it, e.g. "Corp" and
+	// "Corporation"
+	prefixWordScore = 0.9
+	// minPrefixLength is the shortest abbreviation of a word
+	minPrefixLength = 3
+)
+
+// matchVocabularies replaces in place the values of the fields with a vocabulary by the closest
+// allowed value, when it is close enough. It returns the match of every value by path, and a warning
+// for every value changed or matching no allowed value.
+func matchVocabularies(data map[string]interface{}, vocabularies map[string][]string, threshold float64) (map[string]types.VocabularyMatch, []string, error) {
+	if threshold <= 0 {
+		threshold = defaultVocabularyThreshold
+	}
+	if threshold > 1 {
+		return nil, nil, fmt.Errorf("invalid vocabulary threshold %g: must be between 0 and 1", threshold)
+	}
+	fields := make([]string, 0, len(vocabularies))
+	for field := range vocabularies {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	matches := make(map[string]types.VocabularyMatch)
+	walkValues(data, "", func(path string, value interface{}) {
+		text, ok := value.(string)
+		if !ok || strings.TrimSpace(text) == "" {
+			return
+		}
+		for _, field := range fields {
+			if !fieldMatches(path, field) {
+				continue
+			}
+			match := closestValue(text, vocabularies[field])
+			match.Matched = match.Value != "" && match.Confidence >= threshold
+			matches[path] = match
+			return
+		}
+	})
+	paths := make([]string, 0, len(matches))
+	for path := range matches {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	warnings := make([]string, 0)
+	for _, path := range paths {
+		match := matches[path]
+		switch {
+		case !match.Matched && match.Value == "":
+			warnings = append(warnings, fmt.Sprintf("%s = %q matches no value of the vocabulary", path, match.Extracted))
+		case !match.Matched:
+			warnings = append(warnings, fmt.Sprintf("%s = %q not in the vocabulary, the closest value %q has a confidence of %g", path, match.Extracted, match.Value, match.Confidence))
+		case match.Value != match.Extracted:
+			if err := setValuePath(data, path, match.Value); err != nil {
+				return nil, nil, err
+			}
+			warnings = append(warnings, fmt.Sprintf("%s = %q matched to %q with a confidence of %g", path, match.Extracted, match.Value, match.Confidence))
+		}
+	}
+	return matches, warnings, nil
+}
+
+// closestValue returns the allowed value closest to a text, the first one on ties
+func closestValue(text string, allowed []string) types.VocabularyMatch {
+	match := types.VocabularyMatch{Extracted: text}
+	for _, value := range allowed {
+		if confidence := Similarity(text, value); confidence > match.Confidence {
+			match.Value, match.Confidence = value, confidence
+		}
+	}
+	match.Confidence = math.Round(match.Confidence*1000) / 1000
+	return match
+}
+
+// Similarity scores how close two texts are, from 0 to 1 for identical texts regardless of case,
+// spacing and punctuation: the best of their edit distance and of the share of their words
+// matching exactly or as abbreviations, e.g. "ACME Corp." and "Acme Corporation"
+func Similarity(a, b string) float64 {
+	na, nb := normalizeEnum(a), normalizeEnum(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+	longest := max(len([]rune(na)), len([]rune(nb)))
+	score := 1 - float64(editDistance(na, nb))/float64(longest)
+	return max(score, wordSimilarity(titleWords(a), titleWords(b)))
+}
+
+// wordSimilarity scores the words of two texts matching exactly, or as an abbreviation, each
+// word of the other text matching once
+func wordSimilarity(a, b []string) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(a) == 0 {
+		return 0
+	}
+	used := make([]bool, len(b))
+	total := 0.0
+	for _, word := range a {
+		best, at := 0.0, -1
+		for i, other := range b {
+			score := 0.0
+			switch {
+			case used[i]:
+			case word == other:
+				score = 1
+			case min(len(word), len(other)) >= minPrefixLength && (strings.HasPrefix(other, word) || strings.HasPrefix(word, other)):
+				score = prefixWordScore
+			}
+			if score > best {
+				best, at = score, i
+			}
+		}
+		if at >= 0 {
+			used[at] = true
+			total += best
+		}
+	}
+	return total / float64(len(b))
+}
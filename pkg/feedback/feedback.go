@@ -0,0 +1,121 @@
+// Package feedback provides stores for the corrections of extracted data recorded by reviewers:
+// in memory, e.g. for tests, and in a JSON Lines file. Set one as ExtractorConfig.FeedbackStore.
+package feedback
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// maxLineSize is the longest line of a feedback file, a record holding the text of its document
+const maxLineSize = 64 << 20
+
+// Memory is a feedback store keeping the records in memory. It is safe for concurrent use.
+type Memory struct {
+	mu      sync.Mutex
+	records []types.FeedbackRecord
+}
+
+// NewMemory creates an empty feedback store in memory
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Save adds a record
+func (m *Memory) Save(_ context.Context, record types.FeedbackRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, record)
+	return nil
+}
+
+// Records returns the records, in the order they were saved
+func (m *Memory) Records(_ context.Context) ([]types.FeedbackRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.records), nil
+}
+
+// File is a feedback store appending the records to a JSON Lines file, one record per line, which
+// doubles as a dataset for other tools. The records are read once, when the store is created. It is
+// safe for concurrent use, but not for several processes writing the same file.
+type File struct {
+	mu      sync.Mutex
+	file    *os.File
+	records []types.FeedbackRecord
+}
+
+// NewFile opens a feedback store in a JSON Lines file, reading the records it already holds and
+// creating it if needed
+func NewFile(path string) (*File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open feedback file: %w", err)
+	}
+
+	records := make([]types.FeedbackRecord, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record types.FeedbackRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("invalid feedback record on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read feedback file: %w", err)
+	}
+	return &File{file: file, records: records}, nil
+}
+
+// Save appends a record to the file
+func (f *File) Save(_ context.Context, record types.FeedbackRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode feedback record: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return errors.New("feedback file is closed")
+	}
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write feedback record: %w", err)
+	}
+	f.records = append(f.records, record)
+	return nil
+}
+
+// Records returns the records of the file, in the order they were saved
+func (f *File) Records(_ context.Context) ([]types.FeedbackRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.records), nil
+}
+
+// Close closes the file
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
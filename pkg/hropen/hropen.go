@@ -0,0 +1,166 @@
+package hropen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+type name struct {
+	FormattedName string `json:"formattedName,omitempty"`
+	Given         string `json:"given,omitempty"`
+	Family        string `json:"family,omitempty"`
+}
+
+type email struct {
+	Address string `json:"address"`
+}
+
+type phone struct {
+	FormattedNumber string `json:"formattedNumber"`
+}
+
+type address struct {
+	FormattedAddress string `json:"formattedAddress"`
+}
+
+type communication struct {
+	Email   []email   `json:"email,omitempty"`
+	Phone   []phone   `json:"phone,omitempty"`
+	Address []address `json:"address,omitempty"`
+}
+
+type person struct {
+	Name          name          `json:"name"`
+	Communication communication `json:"communication"`
+}
+
+type organization struct {
+	Name string `json:"name"`
+}
+
+type positionHistory struct {
+	Title       string `json:"title,omitempty"`
+	Start       string `json:"start,omitempty"`
+	End         string `json:"end,omitempty"`
+	Current     bool   `json:"current,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type employment struct {
+	Organization      organization      `json:"organization"`
+	Start             string            `json:"start,omitempty"`
+	End               string            `json:"end,omitempty"`
+	Current           bool              `json:"current,omitempty"`
+	Location          *address          `json:"location,omitempty"`
+	PositionHistories []positionHistory `json:"positionHistories"`
+}
+
+type educationDegree struct {
+	Name            string   `json:"name,omitempty"`
+	Specializations []string `json:"specializations,omitempty"`
+}
+
+type education struct {
+	Institution      organization      `json:"institution"`
+	Start            string            `json:"start,omitempty"`
+	End              string            `json:"end,omitempty"`
+	EducationDegrees []educationDegree `json:"educationDegrees,omitempty"`
+}
+
+type qualification struct {
+	CompetencyName string `json:"competencyName"`
+	Start          string `json:"start,omitempty"`
+	End            string `json:"end,omitempty"`
+}
+
+type profile struct {
+	ExecutiveSummary string          `json:"executiveSummary,omitempty"`
+	Employment       []employment    `json:"employment,omitempty"`
+	Education        []education     `json:"education,omitempty"`
+	Qualifications   []qualification `json:"qualifications,omitempty"`
+}
+
+type candidate struct {
+	Person   person    `json:"person"`
+	Profiles []profile `json:"profiles"`
+}
+
+// ToJSON serializes a resume to an HR Open Standards (formerly HR-XML) Candidate JSON document
+func ToJSON(resume *types.Resume) ([]byte, error) {
+	if resume == nil {
+		return nil, errors.New("resume is required")
+	}
+
+	doc := candidate{
+		Person: person{
+			Name: name{
+				FormattedName: strings.TrimSpace(resume.GivenName + " " + resume.FamilyName),
+				Given:         resume.GivenName,
+				Family:        resume.FamilyName,
+			},
+		},
+	}
+	if resume.Email != "" {
+		doc.Person.Communication.Email = []email{{Address: resume.Email}}
+	}
+	if resume.Phone != "" {
+		doc.Person.Communication.Phone = []phone{{FormattedNumber: resume.Phone}}
+	}
+	if resume.Location != "" {
+		doc.Person.Communication.Address = []address{{FormattedAddress: resume.Location}}
+	}
+
+	p := profile{ExecutiveSummary: resume.Summary}
+	for _, position := range resume.Experience {
+		entry := employment{
+			Organization: organization{Name: position.Employer},
+			Start:        position.StartDate,
+			End:          position.EndDate,
+			Current:      position.Current,
+			PositionHistories: []positionHistory{{
+				Title:       position.Title,
+				Start:       position.StartDate,
+				End:         position.EndDate,
+				Current:     position.Current,
+				Description: position.Description,
+			}},
+		}
+		if position.Location != "" {
+			entry.Location = &address{FormattedAddress: position.Location}
+		}
+		p.Employment = append(p.Employment, entry)
+	}
+	for _, entry := range resume.Education {
+		e := education{
+			Institution: organization{Name: entry.Institution},
+			Start:       entry.StartDate,
+			End:         entry.EndDate,
+		}
+		if entry.Degree != "" || entry.Field != "" {
+			degree := educationDegree{Name: entry.Degree}
+			if entry.Field != "" {
+				degree.Specializations = []string{entry.Field}
+			}
+			e.EducationDegrees = []educationDegree{degree}
+		}
+		p.Education = append(p.Education, e)
+	}
+	for _, skill := range resume.Skills {
+		p.Qualifications = append(p.Qualifications, qualification{
+			CompetencyName: skill.Name,
+			Start:          skill.FirstUsed,
+			End:            skill.LastUsed,
+		})
+	}
+	doc.Profiles = []profile{p}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize resume: %w", err)
+	}
+	return out, nil
+}
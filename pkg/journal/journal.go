@@ -0,0 +1,141 @@
+// Package journal provides journals of the progress of batch extractions: in memory, e.g. for
+// tests, and in a JSON Lines file, which resumes a batch after a crash. Set one as
+// BatchOptions.Journal.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// Memory is a journal keeping the records in memory. It is safe for concurrent use.
+type Memory struct {
+	mu      sync.Mutex
+	records []types.JSONLRecord
+}
+
+// NewMemory creates an empty journal in memory
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Save adds a record
+func (m *Memory) Save(_ context.Context, record types.JSONLRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, record)
+	return nil
+}
+
+// Records returns the records, in the order they were saved
+func (m *Memory) Records(_ context.Context) ([]types.JSONLRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.records), nil
+}
+
+// File is a journal appending the records to a JSON Lines file, in the format of
+// types.JSONLWriter, so that the output of a batch doubles as its journal. The records are read
+// once, when the journal is opened. It is safe for concurrent use, but not for several processes
+// writing the same file.
+type File struct {
+	mu      sync.Mutex
+	file    *os.File
+	records []types.JSONLRecord
+}
+
+// NewFile opens a journal in a JSON Lines file, reading the records it already holds and creating
+// it if needed. A last line left incomplete by a crash is dropped, and its document extracted
+// again.
+func NewFile(path string) (*File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal file: %w", err)
+	}
+
+	records := make([]types.JSONLRecord, 0)
+	reader := bufio.NewReader(file)
+	var size int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A line without newline was left by a process stopped while writing it
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read journal file: %w", err)
+		}
+		size += int64(len(data))
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var record types.JSONLRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("invalid journal record on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate journal file: %w", err)
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek journal file: %w", err)
+	}
+	return &File{file: file, records: records}, nil
+}
+
+// Save appends a record to the file, synced to disk so that it survives a crash
+func (f *File) Save(_ context.Context, record types.JSONLRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal record: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return errors.New("journal file is closed")
+	}
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal record: %w", err)
+	}
+	if err := f.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal file: %w", err)
+	}
+	f.records = append(f.records, record)
+	return nil
+}
+
+// Records returns the records of the file, in the order they were saved
+func (f *File) Records(_ context.Context) ([]types.JSONLRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.records), nil
+}
+
+// Close closes the file
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,93 @@
+//go:build kafka
+
+// Package kafkaevents publishes extraction events to Kafka topics. It is only built with the kafka
+// build tag, so that the Kafka client dependency stays optional.
+package kafkaevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/segmentio/kafka-go"
+)
+
+// Config configures a Publisher
+type Config struct {
+	// Brokers are the addresses of the Kafka brokers (required)
+	Brokers []string
+	// Topic receives the extraction.completed events, and the extraction.failed events unless
+	// FailedTopic is set (required)
+	Topic string
+	// FailedTopic receives the extraction.failed events (optional)
+	FailedTopic string
+	// Transport configures TLS and SASL authentication (default: kafka.DefaultTransport)
+	Transport kafka.RoundTripper
+}
+
+// Publisher publishes extraction events as JSON messages keyed by document ID, so that the events
+// of a document keep their order within a partition. The event type is also set in the "type"
+// header. Publisher implements types.Publisher.
+type Publisher struct {
+	writer      *kafka.Writer
+	topic       string
+	failedTopic string
+	brokers     []string
+}
+
+// New creates a publisher writing to the brokers of config
+func New(config Config) (*Publisher, error) {
+	if len(config.Brokers) == 0 {
+		return nil, errors.New("Kafka brokers are required")
+	}
+	if config.Topic == "" {
+		return nil, errors.New("Kafka topic is required")
+	}
+	failedTopic := config.FailedTopic
+	if failedTopic == "" {
+		failedTopic = config.Topic
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(config.Brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		Transport:    config.Transport,
+	}
+	return &Publisher{writer: writer, topic: config.Topic, failedTopic: failedTopic, brokers: config.Brokers}, nil
+}
+
+// Endpoints returns the addresses of the brokers
+func (p *Publisher) Endpoints() []string {
+	return p.brokers
+}
+
+// Publish writes an event to its topic and waits for the brokers to acknowledge it
+func (p *Publisher) Publish(ctx context.Context, event types.Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	topic := p.topic
+	if event.Type == types.EventExtractionFailed {
+		topic = p.failedTopic
+	}
+	message := kafka.Message{
+		Topic:   topic,
+		Key:     []byte(event.DocumentID),
+		Value:   value,
+		Headers: []kafka.Header{{Key: "type", Value: []byte(event.Type)}},
+	}
+	if err := p.writer.WriteMessages(ctx, message); err != nil {
+		return fmt.Errorf("failed to write Kafka message: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the connections to the brokers
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,151 @@
+// Package metrics collects extraction metrics and exposes them in the Prometheus text format
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// defaultBuckets are the upper bounds, in seconds, of the extraction duration histogram
+var defaultBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// labelEscaper escapes label values for the Prometheus text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// schemaMetrics are the metrics of the extractions of a schema
+type schemaMetrics struct {
+	completed int64
+	failed    int64
+	tokens    int64
+	// buckets counts the durations up to each bound of the histogram
+	buckets []int64
+	seconds float64
+}
+
+// Collector records the extraction events it receives and serves them as Prometheus metrics, by
+// schema (types.Event Schema, or "unknown"):
+//
+//   - pdf_extractor_extractions_total{schema,status}: extractions by status (completed or failed),
+//     from which failure rates are derived
+//   - pdf_extractor_extraction_duration_seconds{schema}: histogram of extraction latencies
+//   - pdf_extractor_tokens_total{schema}: tokens used
+//   - pdf_extractor_in_flight: extractions in flight, once TrackInFlight was called
+//
+// Collector implements types.Publisher, to be set as ExtractorConfig.Publisher, and http.Handler,
+// to be mounted on /metrics. It is safe for concurrent use.
+type Collector struct {
+	mu      sync.Mutex
+	buckets []float64
+	schemas map[string]*schemaMetrics
+	// inFlight returns the number of extractions in flight, if set
+	inFlight func() int
+}
+
+// New creates a collector with the default duration buckets
+func New() *Collector {
+	return &Collector{buckets: defaultBuckets, schemas: make(map[string]*schemaMetrics)}
+}
+
+// TrackInFlight sets the function the pdf_extractor_in_flight gauge is read from on every scrape,
+// typically the InFlight method of the extractor
+func (c *Collector) TrackInFlight(inFlight func() int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight = inFlight
+}
+
+// Publish records an extraction event
+func (c *Collector) Publish(_ context.Context, event types.Event) error {
+	schema := event.Schema
+	if schema == "" {
+		schema = "unknown"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	metrics, ok := c.schemas[schema]
+	if !ok {
+		metrics = &schemaMetrics{buckets: make([]int64, len(c.buckets))}
+		c.schemas[schema] = metrics
+	}
+
+	if event.Type == types.EventExtractionFailed {
+		metrics.failed++
+	} else {
+		metrics.completed++
+	}
+	metrics.tokens += int64(event.TokensUsed)
+	seconds := float64(event.DurationMs) / 1000
+	metrics.seconds += seconds
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			metrics.buckets[i]++
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := c.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Write writes the metrics in the Prometheus text format
+func (c *Collector) Write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	schemas := make([]string, 0, len(c.schemas))
+	for schema := range c.schemas {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+
+	b := bufio.NewWriter(w)
+	b.WriteString("# HELP pdf_extractor_extractions_total Extractions by schema and status.\n")
+	b.WriteString("# TYPE pdf_extractor_extractions_total counter\n")
+	for _, schema := range schemas {
+		label := labelEscaper.Replace(schema)
+		fmt.Fprintf(b, "pdf_extractor_extractions_total{schema=\"%s\",status=\"completed\"} %d\n", label, c.schemas[schema].completed)
+		fmt.Fprintf(b, "pdf_extractor_extractions_total{schema=\"%s\",status=\"failed\"} %d\n", label, c.schemas[schema].failed)
+	}
+
+	b.WriteString("# HELP pdf_extractor_extraction_duration_seconds Extraction latency by schema.\n")
+	b.WriteString("# TYPE pdf_extractor_extraction_duration_seconds histogram\n")
+	for _, schema := range schemas {
+		label := labelEscaper.Replace(schema)
+		metrics := c.schemas[schema]
+		for i, bound := range c.buckets {
+			fmt.Fprintf(b, "pdf_extractor_extraction_duration_seconds_bucket{schema=\"%s\",le=\"%s\"} %d\n", label, strconv.FormatFloat(bound, 'g', -1, 64), metrics.buckets[i])
+		}
+		count := metrics.completed + metrics.failed
+		fmt.Fprintf(b, "pdf_extractor_extraction_duration_seconds_bucket{schema=\"%s\",le=\"+Inf\"} %d\n", label, count)
+		fmt.Fprintf(b, "pdf_extractor_extraction_duration_seconds_sum{schema=\"%s\"} %s\n", label, strconv.FormatFloat(metrics.seconds, 'g', -1, 64))
+		fmt.Fprintf(b, "pdf_extractor_extraction_duration_seconds_count{schema=\"%s\"} %d\n", label, count)
+	}
+
+	b.WriteString("# HELP pdf_extractor_tokens_total Tokens used by schema.\n")
+	b.WriteString("# TYPE pdf_extractor_tokens_total counter\n")
+	for _, schema := range schemas {
+		fmt.Fprintf(b, "pdf_extractor_tokens_total{schema=\"%s\"} %d\n", labelEscaper.Replace(schema), c.schemas[schema].tokens)
+	}
+
+	if c.inFlight != nil {
+		b.WriteString("# HELP pdf_extractor_in_flight Extractions in flight.\n")
+		b.WriteString("# TYPE pdf_extractor_in_flight gauge\n")
+		fmt.Fprintf(b, "pdf_extractor_in_flight %d\n", c.inFlight())
+	}
+	return b.Flush()
+}
@@ -0,0 +1,219 @@
+package mrz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// checkWeights are the repeating weights of the ICAO 9303 check digit algorithm
+var checkWeights = [3]int{7, 3, 1}
+
+// Parse parses the lines of a machine readable zone (TD1, TD2 or TD3) and validates its check digits.
+// Spaces are removed and letters upper-cased before parsing, since OCR output often contains both.
+func Parse(lines []string) (*types.MRZ, error) {
+	cleaned := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(line), " ", ""))
+		if line != "" {
+			cleaned = append(cleaned, line)
+		}
+	}
+
+	for _, line := range cleaned {
+		for _, c := range line {
+			if c != '<' && (c < '0' || c > '9') && (c < 'A' || c > 'Z') {
+				return nil, fmt.Errorf("invalid MRZ character %q", c)
+			}
+		}
+	}
+
+	switch {
+	case len(cleaned) == 3 && len(cleaned[0]) == 30 && len(cleaned[1]) == 30 && len(cleaned[2]) == 30:
+		return parseTD1(cleaned), nil
+	case len(cleaned) == 2 && len(cleaned[0]) == 36 && len(cleaned[1]) == 36:
+		return parseTD2(cleaned), nil
+	case len(cleaned) == 2 && len(cleaned[0]) == 44 && len(cleaned[1]) == 44:
+		return parseTD3(cleaned), nil
+	default:
+		return nil, errors.New("unrecognized MRZ layout: expected 3 lines of 30, 2 lines of 36 or 2 lines of 44 characters")
+	}
+}
+
+// CheckDigit computes the ICAO 9303 check digit of a field
+func CheckDigit(field string) int {
+	sum := 0
+	for i, c := range field {
+		value := 0
+		switch {
+		case c >= '0' && c <= '9':
+			value = int(c - '0')
+		case c >= 'A' && c <= 'Z':
+			value = int(c-'A') + 10
+		}
+		sum += value * checkWeights[i%3]
+	}
+	return sum % 10
+}
+
+// parseTD1 parses the three 30-character lines of ID cards
+func parseTD1(lines []string) *types.MRZ {
+	l1, l2, l3 := lines[0], lines[1], lines[2]
+	m := &types.MRZ{
+		Format:         types.MRZFormatTD1,
+		DocumentCode:   trimFiller(l1[0:2]),
+		IssuingCountry: trimFiller(l1[2:5]),
+		DocumentNumber: trimFiller(l1[5:14]),
+		OptionalData:   joinOptional(l1[15:30], l2[18:29]),
+		DateOfBirth:    birthDate(l2[0:6]),
+		Sex:            sex(l2[7]),
+		ExpiryDate:     expiryDate(l2[8:14]),
+		Nationality:    trimFiller(l2[15:18]),
+	}
+	m.Surname, m.GivenNames = names(l3)
+
+	checks := []check{
+		{"document number", l1[5:14], l1[14]},
+		{"date of birth", l2[0:6], l2[6]},
+		{"date of expiry", l2[8:14], l2[14]},
+		{"composite", l1[5:30] + l2[0:7] + l2[8:15] + l2[18:29], l2[29]},
+	}
+	validate(m, checks)
+	return m
+}
+
+// parseTD2 parses the two 36-character lines of TD2 documents
+func parseTD2(lines []string) *types.MRZ {
+	l1, l2 := lines[0], lines[1]
+	m := &types.MRZ{
+		Format:         types.MRZFormatTD2,
+		DocumentCode:   trimFiller(l1[0:2]),
+		IssuingCountry: trimFiller(l1[2:5]),
+		DocumentNumber: trimFiller(l2[0:9]),
+		Nationality:    trimFiller(l2[10:13]),
+		DateOfBirth:    birthDate(l2[13:19]),
+		Sex:            sex(l2[20]),
+		ExpiryDate:     expiryDate(l2[21:27]),
+		OptionalData:   trimFiller(l2[28:35]),
+	}
+	m.Surname, m.GivenNames = names(l1[5:36])
+
+	checks := []check{
+		{"document number", l2[0:9], l2[9]},
+		{"date of birth", l2[13:19], l2[19]},
+		{"date of expiry", l2[21:27], l2[27]},
+		{"composite", l2[0:10] + l2[13:20] + l2[21:35], l2[35]},
+	}
+	validate(m, checks)
+	return m
+}
+
+// parseTD3 parses the two 44-character lines of passports
+func parseTD3(lines []string) *types.MRZ {
+	l1, l2 := lines[0], lines[1]
+	m := &types.MRZ{
+		Format:         types.MRZFormatTD3,
+		DocumentCode:   trimFiller(l1[0:2]),
+		IssuingCountry: trimFiller(l1[2:5]),
+		DocumentNumber: trimFiller(l2[0:9]),
+		Nationality:    trimFiller(l2[10:13]),
+		DateOfBirth:    birthDate(l2[13:19]),
+		Sex:            sex(l2[20]),
+		ExpiryDate:     expiryDate(l2[21:27]),
+		OptionalData:   trimFiller(l2[28:42]),
+	}
+	m.Surname, m.GivenNames = names(l1[5:44])
+
+	checks := []check{
+		{"document number", l2[0:9], l2[9]},
+		{"date of birth", l2[13:19], l2[19]},
+		{"date of expiry", l2[21:27], l2[27]},
+		{"composite", l2[0:10] + l2[13:20] + l2[21:43], l2[43]},
+	}
+	// The personal number check digit may be a filler when the field is empty
+	if l2[42] != '<' || strings.Trim(l2[28:42], "<") != "" {
+		checks = append(checks, check{"personal number", l2[28:42], l2[42]})
+	}
+	validate(m, checks)
+	return m
+}
+
+// check is a field protected by a check digit
+type check struct {
+	name  string
+	field string
+	digit byte
+}
+
+// validate verifies the check digits and records the failures on m
+func validate(m *types.MRZ, checks []check) {
+	for _, c := range checks {
+		if c.digit < '0' || c.digit > '9' || int(c.digit-'0') != CheckDigit(c.field) {
+			m.Errors = append(m.Errors, fmt.Sprintf("%s check digit mismatch", c.name))
+		}
+	}
+	m.Valid = len(m.Errors) == 0
+}
+
+// trimFiller removes the '<' fillers of a field
+func trimFiller(field string) string {
+	return strings.TrimSpace(strings.ReplaceAll(field, "<", " "))
+}
+
+// joinOptional joins optional data elements, dropping empty ones
+func joinOptional(fields ...string) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if trimmed := trimFiller(field); trimmed != "" {
+			parts = append(parts, trimmed)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// names splits the name field into surname and given names
+func names(field string) (string, string) {
+	parts := strings.SplitN(strings.TrimRight(field, "<"), "<<", 2)
+	surname := trimFiller(parts[0])
+	if len(parts) == 1 {
+		return surname, ""
+	}
+	return surname, strings.Join(strings.Fields(trimFiller(parts[1])), " ")
+}
+
+// sex normalizes the sex field, where '<' means unspecified
+func sex(c byte) string {
+	if c == '<' {
+		return "X"
+	}
+	return string(c)
+}
+
+// birthDate converts a YYMMDD birth date, assuming it is not in the future
+func birthDate(field string) string {
+	return mrzDate(field, time.Now().Year()%100)
+}
+
+// expiryDate converts a YYMMDD expiry date, assuming it falls in this century
+func expiryDate(field string) string {
+	return mrzDate(field, 99)
+}
+
+// mrzDate converts a YYMMDD date to YYYY-MM-DD. Years up to pivot are placed in the 2000s and
+// later years in the 1900s. Unparseable dates are returned unchanged.
+func mrzDate(field string, pivot int) string {
+	t, err := time.Parse("060102", field)
+	if err != nil {
+		return field
+	}
+	year := t.Year() % 100
+	if year <= pivot {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", year, t.Month(), t.Day())
+}
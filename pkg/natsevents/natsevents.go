@@ -0,0 +1,106 @@
+//go:build nats
+
+// Package natsevents publishes extraction events to NATS subjects. It is only built with the nats
+// build tag, so that the NATS client dependency stays optional.
+package natsevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/nats-io/nats.go"
+)
+
+// Config configures a Publisher
+type Config struct {
+	// URL is the URL of the NATS server (default: nats.DefaultURL)
+	URL string
+	// Subject receives the extraction.completed events, and the extraction.failed events unless
+	// FailedSubject is set (required)
+	Subject string
+	// FailedSubject receives the extraction.failed events (optional)
+	FailedSubject string
+	// Options configure the connection, e.g. nats.UserCredentials or nats.RootCAs (optional)
+	Options []nats.Option
+}
+
+// Publisher publishes extraction events as JSON messages, with the event type in the "type"
+// header and the document ID in the "Document-Id" header. Publisher implements types.Publisher.
+type Publisher struct {
+	conn          *nats.Conn
+	subject       string
+	failedSubject string
+	owned         bool
+}
+
+// New connects to the NATS server of config
+func New(config Config) (*Publisher, error) {
+	url := config.URL
+	if url == "" {
+		url = nats.DefaultURL
+	}
+	conn, err := nats.Connect(url, config.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	publisher, err := NewFromConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	publisher.owned = true
+	return publisher, nil
+}
+
+// NewFromConn creates a publisher on an existing connection, which Close leaves open
+func NewFromConn(conn *nats.Conn, config Config) (*Publisher, error) {
+	if config.Subject == "" {
+		return nil, errors.New("NATS subject is required")
+	}
+	failedSubject := config.FailedSubject
+	if failedSubject == "" {
+		failedSubject = config.Subject
+	}
+	return &Publisher{conn: conn, subject: config.Subject, failedSubject: failedSubject}, nil
+}
+
+// Endpoints returns the URLs of the servers of the connection
+func (p *Publisher) Endpoints() []string {
+	return p.conn.Servers()
+}
+
+// Publish sends an event to its subject and waits for the server to receive it
+func (p *Publisher) Publish(ctx context.Context, event types.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	subject := p.subject
+	if event.Type == types.EventExtractionFailed {
+		subject = p.failedSubject
+	}
+	message := nats.NewMsg(subject)
+	message.Data = data
+	message.Header.Set("type", string(event.Type))
+	message.Header.Set("Document-Id", event.DocumentID)
+	if err := p.conn.PublishMsg(message); err != nil {
+		return fmt.Errorf("failed to publish NATS message: %w", err)
+	}
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush NATS connection: %w", err)
+	}
+	return nil
+}
+
+// Close drains and closes the connection when the publisher opened it
+func (p *Publisher) Close() error {
+	if !p.owned {
+		return nil
+	}
+	return p.conn.Drain()
+}
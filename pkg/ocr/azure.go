@@ -0,0 +1,217 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	defaultAzureModel        = "prebuilt-read"
+	defaultAzureAPIVersion   = "2024-11-30"
+	defaultAzurePollInterval = time.Second
+	pointsPerInch            = 72.0
+)
+
+// AzureConfig configures an Azure AI Document Intelligence provider
+type AzureConfig struct {
+	// Endpoint is the endpoint of the resource, e.g. "https://<resource>.cognitiveservices.azure.com" (required)
+	Endpoint string
+	// APIKey is a key of the resource (required)
+	APIKey string
+	// Model is the analysis model (default: "prebuilt-read")
+	Model string
+	// APIVersion is the version of the REST API (default: "2024-11-30")
+	APIVersion string
+	// PollInterval is the delay between checks of the analysis status (default: 1 second)
+	PollInterval time.Duration
+	// Client is the HTTP client used for requests (default: http.DefaultClient)
+	Client *http.Client
+}
+
+// Azure recognizes documents with Azure AI Document Intelligence. An analysis runs asynchronously
+// and is polled until it completes.
+type Azure struct {
+	config AzureConfig
+	client *http.Client
+}
+
+// azureResult is the part of an analysis result holding the lines and words of the pages
+type azureResult struct {
+	Status string `json:"status"`
+	Error  *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	AnalyzeResult struct {
+		Pages []struct {
+			PageNumber int     `json:"pageNumber"`
+			Width      float64 `json:"width"`
+			Height     float64 `json:"height"`
+			Unit       string  `json:"unit"`
+			Lines      []struct {
+				Content string      `json:"content"`
+				Polygon []float64   `json:"polygon"`
+				Spans   []azureSpan `json:"spans"`
+			} `json:"lines"`
+			Words []struct {
+				Content string    `json:"content"`
+				Polygon []float64 `json:"polygon"`
+				Span    azureSpan `json:"span"`
+			} `json:"words"`
+		} `json:"pages"`
+	} `json:"analyzeResult"`
+}
+
+// azureSpan is a range of the content of a document, in code points
+type azureSpan struct {
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+}
+
+// NewAzure creates an Azure AI Document Intelligence provider
+func NewAzure(config AzureConfig) (*Azure, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("Azure endpoint is required")
+	}
+	if config.APIKey == "" {
+		return nil, errors.New("Azure API key is required")
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	if config.Model == "" {
+		config.Model = defaultAzureModel
+	}
+	if config.APIVersion == "" {
+		config.APIVersion = defaultAzureAPIVersion
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultAzurePollInterval
+	}
+
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Azure{config: config, client: client}, nil
+}
+
+// Endpoints returns the endpoint of the resource
+func (a *Azure) Endpoints() []string {
+	return []string{a.config.Endpoint}
+}
+
+// Recognize analyzes a PDF and returns the lines and words of its pages
+func (a *Azure) Recognize(ctx context.Context, pdf []byte) ([]types.PageLayout, error) {
+	body, err := json.Marshal(map[string]string{"base64Source": base64.StdEncoding.EncodeToString(pdf)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Azure request: %w", err)
+	}
+	query := url.Values{"api-version": {a.config.APIVersion}, "stringIndexType": {"unicodeCodePoint"}}
+	analyzeURL := fmt.Sprintf("%s/documentintelligence/documentModels/%s:analyze?%s", a.config.Endpoint, url.PathEscape(a.config.Model), query.Encode())
+
+	status, header, response, err := a.do(ctx, http.MethodPost, analyzeURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusAccepted {
+		return nil, fmt.Errorf("Azure analysis failed (status %d): %s", status, response)
+	}
+	operation := header.Get("Operation-Location")
+	if operation == "" {
+		return nil, errors.New("Azure analysis returned no Operation-Location")
+	}
+
+	for {
+		status, _, response, err = a.do(ctx, http.MethodGet, operation, nil)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("failed to get Azure analysis (status %d): %s", status, response)
+		}
+		var result azureResult
+		if err := json.Unmarshal(response, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse Azure analysis: %w", err)
+		}
+		switch result.Status {
+		case "succeeded":
+			return azureLayouts(result), nil
+		case "failed", "canceled":
+			if result.Error != nil {
+				return nil, fmt.Errorf("Azure analysis %s: %s: %s", result.Status, result.Error.Code, result.Error.Message)
+			}
+			return nil, fmt.Errorf("Azure analysis %s", result.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(a.config.PollInterval):
+		}
+	}
+}
+
+// do sends a request with the API key and returns the status, headers and body of the response
+func (a *Azure) do(ctx context.Context, method, url string, body []byte) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to create Azure request: %w", err)
+	}
+	req.Header.Set("Ocp-Apim-Subscription-Key", a.config.APIKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to send Azure request: %w", err)
+	}
+	defer resp.Body.Close()
+	response, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to read Azure response: %w", err)
+	}
+	return resp.StatusCode, resp.Header, response, nil
+}
+
+// azureLayouts converts the pages of an analysis result to page layouts. Pages of PDFs are
+// measured in inches, and images in pixels, which are kept as they are.
+func azureLayouts(result azureResult) []types.PageLayout {
+	layouts := make([]types.PageLayout, 0, len(result.AnalyzeResult.Pages))
+	for _, page := range result.AnalyzeResult.Pages {
+		scale := 1.0
+		if page.Unit == "inch" {
+			scale = pointsPerInch
+		}
+		layout := types.PageLayout{
+			Page:   page.PageNumber,
+			Width:  page.Width * scale,
+			Height: page.Height * scale,
+			Lines:  make([]types.TextLine, 0, len(page.Lines)),
+		}
+		for _, line := range page.Lines {
+			var words []types.TextWord
+			for _, word := range page.Words {
+				for _, span := range line.Spans {
+					if word.Span.Offset >= span.Offset && word.Span.Offset < span.Offset+span.Length {
+						words = append(words, types.TextWord{Text: word.Content, Bounds: polygonBounds(word.Polygon, scale, scale)})
+						break
+					}
+				}
+			}
+			layout.Lines = append(layout.Lines, textLine(line.Content, polygonBounds(line.Polygon, scale, scale), words))
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts
+}
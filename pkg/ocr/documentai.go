@@ -0,0 +1,189 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// DocumentAIConfig configures a Google Document AI provider
+type DocumentAIConfig struct {
+	// ProjectID, Location and ProcessorID identify the OCR processor (required)
+	ProjectID   string
+	Location    string
+	ProcessorID string
+	// Token returns an OAuth 2.0 access token for the request, e.g. from the token source of
+	// golang.org/x/oauth2/google (required)
+	Token func(ctx context.Context) (string, error)
+	// Endpoint is the base URL of the API (default: "https://<Location>-documentai.googleapis.com")
+	Endpoint string
+	// Client is the HTTP client used for requests (default: http.DefaultClient)
+	Client *http.Client
+}
+
+// DocumentAI recognizes documents with a Google Document AI OCR processor, processing them online.
+// Online processing is limited to small documents, see the quotas of the processor.
+type DocumentAI struct {
+	config DocumentAIConfig
+	client *http.Client
+}
+
+// documentAIAnchor locates text in the text of a document, in code points
+type documentAIAnchor struct {
+	TextSegments []struct {
+		StartIndex int `json:"startIndex,string"`
+		EndIndex   int `json:"endIndex,string"`
+	} `json:"textSegments"`
+}
+
+// documentAILayout is the position of an element of a page
+type documentAILayout struct {
+	TextAnchor   documentAIAnchor `json:"textAnchor"`
+	BoundingPoly struct {
+		NormalizedVertices []struct {
+			X float64 `json:"x"`
+			Y float64 `json:"y"`
+		} `json:"normalizedVertices"`
+	} `json:"boundingPoly"`
+}
+
+// documentAIResponse is the part of a process response holding the lines and tokens of the pages
+type documentAIResponse struct {
+	Document struct {
+		Text  string `json:"text"`
+		Pages []struct {
+			PageNumber int `json:"pageNumber"`
+			Lines      []struct {
+				Layout documentAILayout `json:"layout"`
+			} `json:"lines"`
+			Tokens []struct {
+				Layout documentAILayout `json:"layout"`
+			} `json:"tokens"`
+		} `json:"pages"`
+	} `json:"document"`
+}
+
+// NewDocumentAI creates a Google Document AI provider
+func NewDocumentAI(config DocumentAIConfig) (*DocumentAI, error) {
+	if config.ProjectID == "" || config.Location == "" || config.ProcessorID == "" {
+		return nil, errors.New("Document AI project, location and processor are required")
+	}
+	if config.Token == nil {
+		return nil, errors.New("Document AI token source is required")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://%s-documentai.googleapis.com", config.Location)
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &DocumentAI{config: config, client: client}, nil
+}
+
+// Endpoints returns the base URL of the API
+func (d *DocumentAI) Endpoints() []string {
+	return []string{d.config.Endpoint}
+}
+
+// Recognize processes a PDF and returns the lines and words of its pages
+func (d *DocumentAI) Recognize(ctx context.Context, pdf []byte) ([]types.PageLayout, error) {
+	sizes, err := pageSizes(pdf)
+	if err != nil {
+		return nil, err
+	}
+	token, err := d.config.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Document AI token: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"rawDocument": map[string]string{
+			"content":  base64.StdEncoding.EncodeToString(pdf),
+			"mimeType": "application/pdf",
+		},
+		"skipHumanReview": true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Document AI request: %w", err)
+	}
+	processURL := fmt.Sprintf("%s/v1/projects/%s/locations/%s/processors/%s:process",
+		d.config.Endpoint, d.config.ProjectID, d.config.Location, d.config.ProcessorID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, processURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Document AI request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send Document AI request: %w", err)
+	}
+	defer resp.Body.Close()
+	response, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Document AI response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Document AI processing failed (status %d): %s", resp.StatusCode, response)
+	}
+
+	var result documentAIResponse
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse Document AI response: %w", err)
+	}
+	return documentAILayouts(result, sizes), nil
+}
+
+// documentAILayouts converts the pages of a processed document to page layouts, scaling the
+// normalized coordinates to the size of the pages
+func documentAILayouts(result documentAIResponse, sizes []types.Rect) []types.PageLayout {
+	text := []rune(result.Document.Text)
+	layouts := make([]types.PageLayout, 0, len(result.Document.Pages))
+	for i, page := range result.Document.Pages {
+		var size types.Rect
+		if i < len(sizes) {
+			size = sizes[i]
+		}
+		layout := types.PageLayout{Page: page.PageNumber, Width: size.Width, Height: size.Height, Lines: make([]types.TextLine, 0, len(page.Lines))}
+		bounds := func(l documentAILayout) types.Rect {
+			polygon := make([]float64, 0, 2*len(l.BoundingPoly.NormalizedVertices))
+			for _, vertex := range l.BoundingPoly.NormalizedVertices {
+				polygon = append(polygon, vertex.X, vertex.Y)
+			}
+			return polygonBounds(polygon, size.Width, size.Height)
+		}
+
+		for _, line := range page.Lines {
+			var lineText strings.Builder
+			var words []types.TextWord
+			for _, segment := range line.Layout.TextAnchor.TextSegments {
+				lineText.WriteString(runeSlice(text, segment.StartIndex, segment.EndIndex))
+				for _, token := range page.Tokens {
+					for _, tokenSegment := range token.Layout.TextAnchor.TextSegments {
+						if tokenSegment.StartIndex >= segment.StartIndex && tokenSegment.StartIndex < segment.EndIndex {
+							words = append(words, types.TextWord{
+								Text:   runeSlice(text, tokenSegment.StartIndex, tokenSegment.EndIndex),
+								Bounds: bounds(token.Layout),
+							})
+						}
+					}
+				}
+			}
+			layout.Lines = append(layout.Lines, textLine(lineText.String(), bounds(line.Layout), words))
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts
+}
@@ -0,0 +1,130 @@
+// Package ocr recognizes the text of PDF documents with remote OCR services (AWS Textract, Google
+// Document AI and Azure AI Document Intelligence) or a local Tesseract binary, and plugs them into
+// the parser as backends, so that scanned documents go through the text extraction flow instead
+// of, or along with, vision
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// defaultTimeout bounds the recognition of a document by a Backend
+const defaultTimeout = 5 * time.Minute
+
+// Provider recognizes the text of PDF documents
+type Provider interface {
+	// Recognize returns the lines and words of every page of a PDF, in points from the top-left
+	// corner of the page
+	Recognize(ctx context.Context, pdf []byte) ([]types.PageLayout, error)
+}
+
+// Backend adapts a Provider to a parser backend. Register it with parser.RegisterBackend and
+// select it with ParseOptions.TextBackend to read the text of documents with the provider, or with
+// ParseOptions.OCRBackend to give its text to the vision model along with the page images.
+type Backend struct {
+	name     string
+	provider Provider
+	timeout  time.Duration
+}
+
+// NewBackend creates a backend named name recognizing documents with provider, each within
+// timeout (default: 5 minutes)
+func NewBackend(name string, provider Provider, timeout time.Duration) *Backend {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Backend{name: name, provider: provider, timeout: timeout}
+}
+
+// Name identifies the backend
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Endpoints returns the endpoints of the provider, if it implements types.RemoteService
+func (b *Backend) Endpoints() []string {
+	if remote, ok := b.provider.(types.RemoteService); ok {
+		return remote.Endpoints()
+	}
+	return nil
+}
+
+// ExtractText returns the lines of every page, one per line
+func (b *Backend) ExtractText(buffer []byte) ([]string, error) {
+	layouts, err := b.ExtractLayout(buffer)
+	if err != nil {
+		return nil, err
+	}
+	pages := make([]string, len(layouts))
+	for i, layout := range layouts {
+		texts := make([]string, len(layout.Lines))
+		for j, line := range layout.Lines {
+			texts[j] = line.Text
+		}
+		pages[i] = strings.Join(texts, "\n")
+	}
+	return pages, nil
+}
+
+// ExtractLayout recognizes the lines and words of every page
+func (b *Backend) ExtractLayout(buffer []byte) ([]types.PageLayout, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	return b.provider.Recognize(ctx, buffer)
+}
+
+// pageSizes returns the size of every page of a PDF, in points
+func pageSizes(pdf []byte) ([]types.Rect, error) {
+	dims, err := api.PageDims(bytes.NewReader(pdf), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page sizes: %w", err)
+	}
+	sizes := make([]types.Rect, len(dims))
+	for i, dim := range dims {
+		sizes[i] = types.Rect{Width: dim.Width, Height: dim.Height}
+	}
+	return sizes, nil
+}
+
+// polygonBounds returns the bounding box of a polygon given as alternating x and y coordinates,
+// scaled by sx and sy
+func polygonBounds(polygon []float64, sx, sy float64) types.Rect {
+	if len(polygon) < 2 {
+		return types.Rect{}
+	}
+	left, top := math.Inf(1), math.Inf(1)
+	right, bottom := math.Inf(-1), math.Inf(-1)
+	for i := 0; i+1 < len(polygon); i += 2 {
+		left, right = min(left, polygon[i]*sx), max(right, polygon[i]*sx)
+		top, bottom = min(top, polygon[i+1]*sy), max(bottom, polygon[i+1]*sy)
+	}
+	return types.Rect{X: left, Y: top, Width: right - left, Height: bottom - top}
+}
+
+// textLine builds a line from its text and bounds and its words, whose text is trimmed
+func textLine(text string, bounds types.Rect, words []types.TextWord) types.TextLine {
+	line := types.TextLine{Text: strings.TrimSpace(text), Bounds: bounds, Words: make([]types.TextWord, 0, len(words))}
+	for _, word := range words {
+		if word.Text = strings.TrimSpace(word.Text); word.Text != "" {
+			line.Words = append(line.Words, word)
+		}
+	}
+	return line
+}
+
+// runeSlice returns the runes of text from start to end, clamped to the text
+func runeSlice(text []rune, start, end int) string {
+	start, end = max(0, min(start, len(text))), max(0, min(end, len(text)))
+	if start >= end {
+		return ""
+	}
+	return string(text[start:end])
+}
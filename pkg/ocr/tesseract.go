@@ -0,0 +1,155 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// tesseractDPI is the default resolution pages are rendered at for Tesseract
+const tesseractDPI = 300
+
+// TesseractConfig configures a local Tesseract provider
+type TesseractConfig struct {
+	// Path is the path of the tesseract binary (default: "tesseract" found in the PATH)
+	Path string
+	// Languages are the Tesseract languages of the documents, e.g. "eng+deu" (default: "eng")
+	Languages string
+	// DPI is the resolution the pages are rendered at (default: 300)
+	DPI float64
+	// Renderer names the parser backend rendering the pages (default: the built-in renderer)
+	Renderer string
+	// RendererPath is the path of the pdftoppm or Ghostscript binary (optional)
+	RendererPath string
+}
+
+// Tesseract recognizes the text of PDF documents with a local Tesseract binary, run on renders of
+// the pages piped to its standard input, so that no document leaves the machine nor is written to
+// disk
+type Tesseract struct {
+	config TesseractConfig
+}
+
+// NewTesseract creates a provider running Tesseract
+func NewTesseract(config TesseractConfig) *Tesseract {
+	if config.Path == "" {
+		config.Path = "tesseract"
+	}
+	if config.Languages == "" {
+		config.Languages = "eng"
+	}
+	if config.DPI <= 0 {
+		config.DPI = tesseractDPI
+	}
+	return &Tesseract{config: config}
+}
+
+// Recognize renders every page of a PDF and recognizes its text
+func (t *Tesseract) Recognize(ctx context.Context, pdf []byte) ([]types.PageLayout, error) {
+	path, err := exec.LookPath(t.config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("tesseract not found: %w", err)
+	}
+	sizes, err := pageSizes(pdf)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{
+		ForceImages:  true,
+		DPI:          t.config.DPI,
+		Renderer:     t.config.Renderer,
+		RendererPath: t.config.RendererPath,
+		ParsePolicy:  types.ParsePolicyStrict,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render pages: %w", err)
+	}
+
+	layouts := make([]types.PageLayout, len(sizes))
+	for i, size := range sizes {
+		layouts[i] = types.PageLayout{Page: i + 1, Width: size.Width, Height: size.Height, Lines: make([]types.TextLine, 0)}
+	}
+	for _, page := range parsed.Content.ImageContent {
+		if page.Page < 1 || page.Page > len(layouts) {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(page.Base64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode page %d: %w", page.Page, err)
+		}
+		img, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode page %d: %w", page.Page, err)
+		}
+		// The page is piped rather than written to a file, so that data minimization persists nothing
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, "stdin", "stdout", "-l", t.config.Languages, "--dpi", strconv.FormatFloat(t.config.DPI, 'f', -1, 64), "tsv")
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(data), &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("tesseract failed on page %d: %w: %s", page.Page, err, strings.TrimSpace(stderr.String()))
+		}
+		layout := &layouts[page.Page-1]
+		layout.Lines, err = tesseractLines(stdout.String(), layout.Width/float64(img.Width), layout.Height/float64(img.Height))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tesseract output of page %d: %w", page.Page, err)
+		}
+	}
+	return layouts, nil
+}
+
+// tesseractLines reads the lines and words of the TSV output of Tesseract, whose rows are a line
+// (level 4) followed by its words (level 5), with bounds in pixels scaled by sx and sy
+func tesseractLines(tsv string, sx, sy float64) ([]types.TextLine, error) {
+	rows := strings.Split(strings.TrimSpace(tsv), "\n")
+	if len(rows) == 0 || !strings.HasPrefix(rows[0], "level") {
+		return nil, errors.New("missing TSV header")
+	}
+
+	lines := make([]types.TextLine, 0)
+	var bounds types.Rect
+	var words []types.TextWord
+	flush := func() {
+		if len(words) == 0 {
+			return
+		}
+		texts := make([]string, len(words))
+		for i, word := range words {
+			texts[i] = word.Text
+		}
+		lines = append(lines, textLine(strings.Join(texts, " "), bounds, words))
+		words = nil
+	}
+	for _, row := range rows[1:] {
+		// level page_num block_num par_num line_num word_num left top width height conf text
+		fields := strings.SplitN(row, "\t", 12)
+		if len(fields) < 11 {
+			continue
+		}
+		var box [4]float64
+		for i := range box {
+			box[i], _ = strconv.ParseFloat(fields[6+i], 64)
+		}
+		rect := types.Rect{X: box[0] * sx, Y: box[1] * sy, Width: box[2] * sx, Height: box[3] * sy}
+		switch fields[0] {
+		case "4":
+			flush()
+			bounds = rect
+		case "5":
+			if len(fields) == 12 && strings.TrimSpace(fields[11]) != "" {
+				words = append(words, types.TextWord{Text: fields[11], Bounds: rect})
+			}
+		}
+	}
+	flush()
+	return lines, nil
+}
@@ -0,0 +1,186 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/auth"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// TextractConfig configures an AWS Textract provider
+type TextractConfig struct {
+	// Region is the AWS region of the service, e.g. "us-east-1" (required)
+	Region string
+	// AccessKeyID and SecretAccessKey are the AWS credentials signing the requests (required)
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials (optional)
+	SessionToken string
+	// Endpoint is the base URL of the service (default: "https://textract.<Region>.amazonaws.com")
+	Endpoint string
+	// Client is the HTTP client used for requests (default: http.DefaultClient)
+	Client *http.Client
+}
+
+// Textract recognizes documents with AWS Textract. The synchronous DetectDocumentText API only
+// accepts single-page documents, so every page is sent on its own.
+type Textract struct {
+	config TextractConfig
+	client *http.Client
+	signer *auth.SigV4
+}
+
+// textractResponse is the part of a DetectDocumentText response holding the lines and words
+type textractResponse struct {
+	Blocks []struct {
+		ID        string `json:"Id"`
+		BlockType string `json:"BlockType"`
+		Text      string `json:"Text"`
+		Geometry  struct {
+			BoundingBox struct {
+				Width  float64 `json:"Width"`
+				Height float64 `json:"Height"`
+				Left   float64 `json:"Left"`
+				Top    float64 `json:"Top"`
+			} `json:"BoundingBox"`
+		} `json:"Geometry"`
+		Relationships []struct {
+			Type string   `json:"Type"`
+			IDs  []string `json:"Ids"`
+		} `json:"Relationships"`
+	} `json:"Blocks"`
+}
+
+// NewTextract creates an AWS Textract provider
+func NewTextract(config TextractConfig) (*Textract, error) {
+	if config.Region == "" {
+		return nil, errors.New("Textract region is required")
+	}
+	signer, err := auth.NewSigV4(auth.SigV4Config{
+		Region:          config.Region,
+		Service:         "textract",
+		AccessKeyID:     config.AccessKeyID,
+		SecretAccessKey: config.SecretAccessKey,
+		SessionToken:    config.SessionToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://textract.%s.amazonaws.com", config.Region)
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Textract{config: config, client: client, signer: signer}, nil
+}
+
+// Endpoints returns the endpoint of the service
+func (t *Textract) Endpoints() []string {
+	return []string{t.config.Endpoint}
+}
+
+// Recognize detects the text of every page of a PDF
+func (t *Textract) Recognize(ctx context.Context, pdf []byte) ([]types.PageLayout, error) {
+	sizes, err := pageSizes(pdf)
+	if err != nil {
+		return nil, err
+	}
+
+	layouts := make([]types.PageLayout, 0, len(sizes))
+	for i, size := range sizes {
+		var page bytes.Buffer
+		if err := api.Trim(bytes.NewReader(pdf), &page, []string{strconv.Itoa(i + 1)}, nil); err != nil {
+			return nil, fmt.Errorf("failed to extract page %d: %w", i+1, err)
+		}
+		result, err := t.detectDocumentText(ctx, page.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to recognize page %d: %w", i+1, err)
+		}
+		layouts = append(layouts, textractLayout(result, i+1, size))
+	}
+	return layouts, nil
+}
+
+// detectDocumentText calls the DetectDocumentText API on a single-page document
+func (t *Textract) detectDocumentText(ctx context.Context, document []byte) (*textractResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{"Document": map[string]interface{}{"Bytes": document}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Textract request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.config.Endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Textract request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Textract.DetectDocumentText")
+	if err := t.signer.SignRequest(req, body); err != nil {
+		return nil, fmt.Errorf("failed to sign Textract request: %w", err)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send Textract request: %w", err)
+	}
+	defer resp.Body.Close()
+	response, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Textract response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Textract request failed (status %d): %s", resp.StatusCode, response)
+	}
+
+	var result textractResponse
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse Textract response: %w", err)
+	}
+	return &result, nil
+}
+
+// textractLayout converts the lines and words detected on a page to its layout, scaling the
+// normalized bounding boxes to the size of the page
+func textractLayout(result *textractResponse, page int, size types.Rect) types.PageLayout {
+	layout := types.PageLayout{Page: page, Width: size.Width, Height: size.Height, Lines: make([]types.TextLine, 0)}
+	bounds := func(i int) types.Rect {
+		box := result.Blocks[i].Geometry.BoundingBox
+		return types.Rect{X: box.Left * size.Width, Y: box.Top * size.Height, Width: box.Width * size.Width, Height: box.Height * size.Height}
+	}
+
+	words := make(map[string]int)
+	for i, block := range result.Blocks {
+		if block.BlockType == "WORD" {
+			words[block.ID] = i
+		}
+	}
+	for i, block := range result.Blocks {
+		if block.BlockType != "LINE" {
+			continue
+		}
+		var lineWords []types.TextWord
+		for _, relationship := range block.Relationships {
+			if relationship.Type != "CHILD" {
+				continue
+			}
+			for _, id := range relationship.IDs {
+				if j, ok := words[id]; ok {
+					lineWords = append(lineWords, types.TextWord{Text: result.Blocks[j].Text, Bounds: bounds(j)})
+				}
+			}
+		}
+		layout.Lines = append(layout.Lines, textLine(block.Text, bounds(i), lineWords))
+	}
+	return layout
+}
@@ -0,0 +1,360 @@
+//go:build parquet
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const magic = "PAR1"
+
+// Parquet physical types
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+)
+
+// Parquet enum values used by the writer
+const (
+	repetitionOptional = 1
+	convertedTypeUTF8  = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageTypeData       = 0
+)
+
+// column is a typed column of the file
+type column struct {
+	name         string
+	physicalType int32
+}
+
+// Writer writes extraction results as a Parquet file with typed columns. The extracted data is
+// flattened like for CSV output (see types.FlattenConfig) into columns prefixed with "data.";
+// the columns documentId, status, error, model and tokensUsed describe each result. Column types
+// are inferred from the values: booleans, 64-bit integers, doubles, or UTF-8 strings when a column
+// mixes types. Every column is optional; missing values are written as nulls.
+//
+// Rows are buffered in memory and written as a single row group by Close.
+type Writer struct {
+	w      io.Writer
+	config types.FlattenConfig
+	rows   []map[string]interface{}
+	closed bool
+}
+
+// NewWriter creates a Parquet writer writing to w
+func NewWriter(w io.Writer, config types.FlattenConfig) *Writer {
+	if config.Separator == "" {
+		config.Separator = "."
+	}
+	return &Writer{w: w, config: config}
+}
+
+// Write adds the rows of a batch result to the file
+func (w *Writer) Write(result types.BatchResult) error {
+	if w.closed {
+		return errors.New("parquet writer is closed")
+	}
+
+	meta := map[string]interface{}{
+		"documentId": result.ID,
+		"status":     string(result.Status),
+	}
+	if result.Err != nil {
+		meta["error"] = result.Err.Error()
+	}
+	if result.Result == nil {
+		w.rows = append(w.rows, meta)
+		return nil
+	}
+	meta["model"] = result.Result.Model
+	meta["tokensUsed"] = json.Number(strconv.Itoa(result.Result.TokensUsed))
+
+	rows, err := types.FlattenValues(result.Result.Data, w.config)
+	if err != nil {
+		return err
+	}
+	for _, data := range rows {
+		row := make(map[string]interface{}, len(data)+len(meta))
+		for name, value := range meta {
+			row[name] = value
+		}
+		for name, value := range data {
+			row["data"+w.config.Separator+name] = value
+		}
+		w.rows = append(w.rows, row)
+	}
+	return nil
+}
+
+// Close writes the buffered rows and the file footer. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	columns := w.columns()
+	out := &countingWriter{w: w.w}
+	if _, err := io.WriteString(out, magic); err != nil {
+		return fmt.Errorf("failed to write parquet header: %w", err)
+	}
+
+	meta := &thriftWriter{}
+	meta.beginStruct()
+	meta.i32Field(1, 1)
+
+	// Schema: a root group followed by one optional leaf per column
+	meta.listField(2, thriftStruct, len(columns)+1)
+	meta.beginStruct()
+	meta.stringField(4, "schema")
+	meta.i32Field(5, int32(len(columns)))
+	meta.endStruct()
+	for _, col := range columns {
+		meta.beginStruct()
+		meta.i32Field(1, col.physicalType)
+		meta.i32Field(3, repetitionOptional)
+		meta.stringField(4, col.name)
+		if col.physicalType == typeByteArray {
+			meta.i32Field(6, convertedTypeUTF8)
+		}
+		meta.endStruct()
+	}
+	meta.i64Field(3, int64(len(w.rows)))
+
+	// A single row group holding one data page per column
+	meta.listField(4, thriftStruct, 1)
+	meta.beginStruct()
+	meta.listField(1, thriftStruct, len(columns))
+	var totalSize int64
+	for _, col := range columns {
+		offset := out.n
+		page, err := w.encodePage(col)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(page); err != nil {
+			return fmt.Errorf("failed to write parquet column %q: %w", col.name, err)
+		}
+		size := int64(len(page))
+		totalSize += size
+
+		meta.beginStruct()
+		meta.i64Field(2, offset)
+		meta.structField(3)
+		meta.i32Field(1, col.physicalType)
+		meta.listField(2, thriftI32, 2)
+		meta.zigzag32(encodingPlain)
+		meta.zigzag32(encodingRLE)
+		meta.listField(3, thriftBinary, 1)
+		meta.writeString(col.name)
+		meta.i32Field(4, codecUncompressed)
+		meta.i64Field(5, int64(len(w.rows)))
+		meta.i64Field(6, size)
+		meta.i64Field(7, size)
+		meta.i64Field(9, offset)
+		meta.endStruct()
+		meta.endStruct()
+	}
+	meta.i64Field(2, totalSize)
+	meta.i64Field(3, int64(len(w.rows)))
+	meta.endStruct()
+	meta.stringField(6, "go-pdf-extractor")
+	meta.endStruct()
+
+	footer := meta.buf.Bytes()
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	for _, part := range [][]byte{footer, length[:], []byte(magic)} {
+		if _, err := out.Write(part); err != nil {
+			return fmt.Errorf("failed to write parquet footer: %w", err)
+		}
+	}
+	return nil
+}
+
+// columns returns the file columns with their inferred types
+func (w *Writer) columns() []column {
+	names := w.config.Columns
+	if len(names) == 0 {
+		seen := make(map[string]bool)
+		for _, row := range w.rows {
+			for name := range row {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+		sort.Strings(names)
+	}
+
+	columns := make([]column, len(names))
+	for i, name := range names {
+		columns[i] = column{name: name, physicalType: w.inferType(name)}
+	}
+	return columns
+}
+
+// inferType returns the narrowest physical type holding every value of a column
+func (w *Writer) inferType(name string) int32 {
+	var physicalType int32 = -1
+	for _, row := range w.rows {
+		var valueType int32
+		switch v := row[name].(type) {
+		case nil:
+			continue
+		case bool:
+			valueType = typeBoolean
+		case json.Number:
+			valueType = typeDouble
+			if _, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+				valueType = typeInt64
+			}
+		default:
+			return typeByteArray
+		}
+
+		switch {
+		case physicalType == -1 || physicalType == valueType:
+			physicalType = valueType
+		case (physicalType == typeInt64 && valueType == typeDouble) || (physicalType == typeDouble && valueType == typeInt64):
+			physicalType = typeDouble
+		default:
+			return typeByteArray
+		}
+	}
+	if physicalType == -1 {
+		return typeByteArray
+	}
+	return physicalType
+}
+
+// encodePage encodes the values of a column as a PLAIN data page with RLE definition levels
+func (w *Writer) encodePage(col column) ([]byte, error) {
+	levels := make([]bool, len(w.rows))
+	var values bytes.Buffer
+	var bits, bitCount int
+
+	for i, row := range w.rows {
+		value := row[col.name]
+		if value == nil {
+			continue
+		}
+		levels[i] = true
+
+		switch col.physicalType {
+		case typeBoolean:
+			if value.(bool) {
+				bits |= 1 << bitCount
+			}
+			if bitCount++; bitCount == 8 {
+				values.WriteByte(byte(bits))
+				bits, bitCount = 0, 0
+			}
+		case typeInt64:
+			n, err := value.(json.Number).Int64()
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.name, err)
+			}
+			_ = binary.Write(&values, binary.LittleEndian, n)
+		case typeDouble:
+			f, err := value.(json.Number).Float64()
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.name, err)
+			}
+			_ = binary.Write(&values, binary.LittleEndian, math.Float64bits(f))
+		default:
+			s := formatValue(value)
+			_ = binary.Write(&values, binary.LittleEndian, uint32(len(s)))
+			values.WriteString(s)
+		}
+	}
+	if bitCount > 0 {
+		values.WriteByte(byte(bits))
+	}
+
+	// Definition levels: a single bit-packed run of bit width 1, prefixed with its length
+	groups := (len(levels) + 7) / 8
+	var rle bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	rle.Write(scratch[:binary.PutUvarint(scratch[:], uint64(groups<<1|1))])
+	packed := make([]byte, groups)
+	for i, defined := range levels {
+		if defined {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	rle.Write(packed)
+
+	var data bytes.Buffer
+	_ = binary.Write(&data, binary.LittleEndian, uint32(rle.Len()))
+	data.Write(rle.Bytes())
+	data.Write(values.Bytes())
+
+	header := &thriftWriter{}
+	header.beginStruct()
+	header.i32Field(1, pageTypeData)
+	header.i32Field(2, int32(data.Len()))
+	header.i32Field(3, int32(data.Len()))
+	header.structField(5)
+	header.i32Field(1, int32(len(w.rows)))
+	header.i32Field(2, encodingPlain)
+	header.i32Field(3, encodingRLE)
+	header.i32Field(4, encodingRLE)
+	header.endStruct()
+	header.endStruct()
+
+	return append(header.buf.Bytes(), data.Bytes()...), nil
+}
+
+// formatValue formats a value of a string column
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// WriteResults writes extraction results as a Parquet file, see Writer
+func WriteResults(w io.Writer, results []types.BatchResult, config types.FlattenConfig) error {
+	writer := NewWriter(w, config)
+	for _, result := range results {
+		if err := writer.Write(result); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// countingWriter tracks the number of bytes written, to record column chunk offsets
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,242 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// skippedAnnotations are the annotation subtypes that are no comments: links, the popup windows of
+// other annotations and form fields
+var skippedAnnotations = map[string]bool{"Link": true, "Popup": true, "Widget": true}
+
+// markupAnnotations are the annotation subtypes marking text of the page
+var markupAnnotations = map[string]bool{"Highlight": true, "Underline": true, "Squiggly": true, "StrikeOut": true}
+
+// ExtractAnnotationsFromPath reads the annotations of a PDF file
+func ExtractAnnotationsFromPath(pdfPath string) ([]types.Annotation, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF from path: %w", err)
+	}
+
+	return ExtractAnnotationsFromBuffer(data)
+}
+
+// ExtractAnnotationsFromBuffer reads the annotations of a PDF buffer, such as highlights, sticky
+// notes and stamps, in page order. Links, form fields and the popup windows of annotations are left
+// out. The text marked by highlights and other text markup is read from the page; it is left empty
+// when the text of the page cannot be read.
+func ExtractAnnotationsFromBuffer(buffer []byte) ([]types.Annotation, error) {
+	reader, err := newAnnotationReader(buffer)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	annotations := make([]types.Annotation, 0)
+	err = reader.each(func(pageNum int, subtype string, dict pdftypes.Dict, bounds types.Rect) {
+		if skippedAnnotations[subtype] {
+			return
+		}
+		annotation := types.Annotation{
+			Page:     pageNum,
+			Type:     subtype,
+			Author:   reader.text(dict["T"]),
+			Contents: reader.text(dict["Contents"]),
+			Modified: reader.text(dict["M"]),
+			Bounds:   bounds,
+		}
+		if name := dict.NameEntry("Name"); annotation.Contents == "" && subtype == "Stamp" && name != nil {
+			annotation.Contents = *name
+		}
+		if markupAnnotations[subtype] {
+			annotation.Text = reader.markedText(pageNum, dict, bounds)
+		}
+		annotations = append(annotations, annotation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return annotations, nil
+}
+
+// annotationReader reads the annotation dictionaries of the pages of a PDF with pdfcpu, and the
+// text of the pages they mark with the document backend
+type annotationReader struct {
+	ctx    *model.Context
+	buffer []byte
+	// doc is opened on first use, for the text of annotated pages only
+	doc     document
+	layouts map[int]*types.PageLayout
+	// boxes are the crop boxes of the pages, by page number
+	boxes map[int]*pdftypes.Rectangle
+}
+
+// newAnnotationReader reads a PDF buffer for its annotations
+func newAnnotationReader(buffer []byte) (*annotationReader, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	ctx, err := api.ReadContext(bytes.NewReader(buffer), conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, fmt.Errorf("failed to read PDF pages: %w", err)
+	}
+	return &annotationReader{ctx: ctx, buffer: buffer, layouts: make(map[int]*types.PageLayout), boxes: make(map[int]*pdftypes.Rectangle)}, nil
+}
+
+// each calls visit with every annotation of the pages, in page order, with its subtype and its
+// bounds in points from the top-left corner of the page
+func (r *annotationReader) each(visit func(pageNum int, subtype string, dict pdftypes.Dict, bounds types.Rect)) error {
+	for pageNum := 1; pageNum <= r.ctx.PageCount; pageNum++ {
+		pageDict, _, inherited, err := r.ctx.PageDict(pageNum, false)
+		if err != nil {
+			return fmt.Errorf("failed to read page %d: %w", pageNum, err)
+		}
+		entries, err := r.ctx.DereferenceArray(pageDict["Annots"])
+		if err != nil || len(entries) == 0 {
+			continue
+		}
+		r.boxes[pageNum] = inherited.CropBox
+		if r.boxes[pageNum] == nil {
+			r.boxes[pageNum] = inherited.MediaBox
+		}
+
+		for _, entry := range entries {
+			dict, err := r.ctx.DereferenceDict(entry)
+			if err != nil || dict == nil {
+				continue
+			}
+			subtype := dict.NameEntry("Subtype")
+			if subtype == nil {
+				continue
+			}
+			rect, _ := r.ctx.DereferenceArray(dict["Rect"])
+			bounds, _ := pdfRect(r.ctx, rect, r.boxes[pageNum])
+			visit(pageNum, *subtype, dict, bounds)
+		}
+	}
+	return nil
+}
+
+// text returns the text of a string object, or "" when it is no string
+func (r *annotationReader) text(object pdftypes.Object) string {
+	value, err := r.ctx.DereferenceText(object)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+// markedText returns the text of a page covered by the quadrilaterals of an annotation, or by its
+// bounds when it has none
+func (r *annotationReader) markedText(pageNum int, dict pdftypes.Dict, bounds types.Rect) string {
+	layout, ok := r.layouts[pageNum]
+	if !ok {
+		layout = r.pageLayout(pageNum)
+		r.layouts[pageNum] = layout
+	}
+	quads, _ := r.ctx.DereferenceArray(dict["QuadPoints"])
+	return markedText(layout, quadRects(r.ctx, quads, r.boxes[pageNum], bounds))
+}
+
+// pageLayout returns the layout of a page, or an empty layout when the page cannot be read
+func (r *annotationReader) pageLayout(pageNum int) *types.PageLayout {
+	if r.doc == nil {
+		doc, err := openDocument(r.buffer)
+		if err != nil {
+			return &types.PageLayout{}
+		}
+		r.doc = doc
+	}
+	width, height, glyphs, err := r.doc.Glyphs(pageNum - 1)
+	if err != nil {
+		return &types.PageLayout{}
+	}
+	layout := pageLayout(width, height, glyphs)
+	return &layout
+}
+
+// Close releases the document opened for the text of the pages
+func (r *annotationReader) Close() {
+	if r.doc != nil {
+		r.doc.Close()
+	}
+}
+
+// pdfRect converts the rectangle of a PDF array, in default user space, to points from the top-left
+// corner of the page box
+func pdfRect(ctx *model.Context, array pdftypes.Array, box *pdftypes.Rectangle) (types.Rect, bool) {
+	if len(array) != 4 || box == nil {
+		return types.Rect{}, false
+	}
+	var values [4]float64
+	for i, object := range array {
+		value, err := ctx.DereferenceNumber(object)
+		if err != nil {
+			return types.Rect{}, false
+		}
+		values[i] = value
+	}
+	return boxRect(min(values[0], values[2]), min(values[1], values[3]), max(values[0], values[2]), max(values[1], values[3]), box), true
+}
+
+// boxRect converts the corners of a rectangle in default user space to points from the top-left
+// corner of the page box
+func boxRect(left, bottom, right, top float64, box *pdftypes.Rectangle) types.Rect {
+	return types.Rect{X: left - box.LL.X, Y: box.UR.Y - top, Width: right - left, Height: top - bottom}
+}
+
+// quadRects returns the bounds of the quadrilaterals of text markup, each given by the four corners
+// of a span of marked text, or the bounds of the annotation when it has none
+func quadRects(ctx *model.Context, quads pdftypes.Array, box *pdftypes.Rectangle, bounds types.Rect) []types.Rect {
+	rects := make([]types.Rect, 0, len(quads)/8)
+	for i := 0; box != nil && i+8 <= len(quads); i += 8 {
+		left, bottom, right, top := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for j := i; j < i+8; j += 2 {
+			x, errX := ctx.DereferenceNumber(quads[j])
+			y, errY := ctx.DereferenceNumber(quads[j+1])
+			if errX != nil || errY != nil {
+				return []types.Rect{bounds}
+			}
+			left, bottom, right, top = min(left, x), min(bottom, y), max(right, x), max(top, y)
+		}
+		rects = append(rects, boxRect(left, bottom, right, top, box))
+	}
+	if len(rects) == 0 {
+		return []types.Rect{bounds}
+	}
+	return rects
+}
+
+// markedText returns the words of a page layout whose center lies in one of the rectangles, in
+// reading order
+func markedText(layout *types.PageLayout, rects []types.Rect) string {
+	words := make([]string, 0)
+	for _, line := range layout.Lines {
+		for _, word := range line.Words {
+			x, y := word.Bounds.X+word.Bounds.Width/2, word.Bounds.Y+word.Bounds.Height/2
+			for _, rect := range rects {
+				if x >= rect.X && x <= rect.X+rect.Width && y >= rect.Y && y <= rect.Y+rect.Height {
+					words = append(words, word.Text)
+					break
+				}
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// PageImageKey returns the key of the render of a page of a PDF buffer in an artifact store: the
+// SHA-256 of the PDF, the page number and the resolution, and whether its contrast was enhanced
+func PageImageKey(buffer []byte, page int, dpi float64, enhanceContrast bool) string {
+	sum := sha256.Sum256(buffer)
+	return pageImageKey(hex.EncodeToString(sum[:]), page, dpi, enhanceContrast)
+}
+
+// pageImageKey returns the key of the render of a page of the PDF of a hex-encoded SHA-256
+func pageImageKey(hash string, page int, dpi float64, enhanceContrast bool) string {
+	key := fmt.Sprintf("pages/%s/%d-%gdpi", hash, page, dpi)
+	if enhanceContrast {
+		key += "-contrast"
+	}
+	return key + ".png"
+}
+
+// pageCache looks up and stores the page renders of a PDF in an artifact store. Errors of the store
+// are not errors of parsing: a page that cannot be read from the store is rendered again, and a
+// render that cannot be stored is rendered again next time.
+type pageCache struct {
+	store           types.ArtifactStore
+	hash            string
+	enhanceContrast bool
+}
+
+// newPageCache returns the page cache of a PDF buffer, or nil without an artifact store
+func newPageCache(buffer []byte, options *types.ParseOptions) *pageCache {
+	if options == nil || options.ArtifactStore == nil {
+		return nil
+	}
+	sum := sha256.Sum256(buffer)
+	return &pageCache{store: options.ArtifactStore, hash: hex.EncodeToString(sum[:]), enhanceContrast: options.EnhanceContrast}
+}
+
+// load returns the base64-encoded PNG renders of the pages found in the store, by page number,
+// and the pages to render
+func (c *pageCache) load(pages []int, dpi float64) (map[int]string, []int) {
+	found := make(map[int]string)
+	if c == nil {
+		return found, pages
+	}
+	missing := make([]int, 0, len(pages))
+	for _, page := range pages {
+		data, ok, err := c.store.Get(context.Background(), pageImageKey(c.hash, page, dpi, c.enhanceContrast))
+		if err != nil || !ok || len(data) == 0 {
+			missing = append(missing, page)
+			continue
+		}
+		found[page] = base64.StdEncoding.EncodeToString(data)
+	}
+	return found, missing
+}
+
+// save stores the PNG render of a page
+func (c *pageCache) save(page int, dpi float64, data []byte) {
+	if c == nil {
+		return
+	}
+	_ = c.store.Put(context.Background(), pageImageKey(c.hash, page, dpi, c.enhanceContrast), data)
+}
@@ -0,0 +1,269 @@
+package parser
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// Backend reads PDF documents. A backend provides its capabilities by implementing TextExtractor,
+// Renderer or both, and is selected by name per call once registered, see RegisterBackend.
+type Backend interface {
+	// Name identifies the backend in ParseOptions
+	Name() string
+}
+
+// TextExtractor is a backend that reads the text of the pages of PDF documents
+type TextExtractor interface {
+	Backend
+	// ExtractText returns the text of every page of a PDF buffer, in page order
+	ExtractText(buffer []byte) ([]string, error)
+}
+
+// LayoutExtractor is a TextExtractor that also reads the position of the lines and words of the
+// pages, such as a remote OCR service. The text of a page is its lines, one per line, and the
+// layouts fill ParsedPdf.Layout when ParseOptions.TextLayout is set.
+type LayoutExtractor interface {
+	TextExtractor
+	// ExtractLayout returns the lines and words of every page of a PDF buffer, in page order
+	ExtractLayout(buffer []byte) ([]types.PageLayout, error)
+}
+
+// Renderer is a backend that renders the pages of PDF documents as images
+type Renderer interface {
+	Backend
+	// Render renders the given pages (1-indexed) of a PDF buffer at the given resolution and calls
+	// page with each render, in order, stopping at the first error
+	Render(buffer []byte, pages []int, dpi float64, page func(page int, img *image.RGBA) error) error
+}
+
+// Capability is something a backend can do with a PDF document
+type Capability string
+
+// Capabilities of backends
+const (
+	// CapabilityText is provided by TextExtractor backends
+	CapabilityText Capability = "text"
+	// CapabilityRender is provided by Renderer backends
+	CapabilityRender Capability = "render"
+)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Backend)
+)
+
+func init() {
+	RegisterBackend(NewPdftoppmBackend(""))
+	RegisterBackend(NewGhostscriptBackend(""))
+}
+
+// RegisterBackend makes a backend available under its name, replacing any backend registered
+// under the same name
+func RegisterBackend(backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[backend.Name()] = backend
+}
+
+// LookupBackend returns the backend registered under a name
+func LookupBackend(name string) (Backend, bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	backend, ok := backends[name]
+	return backend, ok
+}
+
+// Backends returns the names of the registered backends, sorted
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Capabilities returns the capabilities of a backend
+func Capabilities(backend Backend) []Capability {
+	capabilities := make([]Capability, 0, 2)
+	if _, ok := backend.(TextExtractor); ok {
+		capabilities = append(capabilities, CapabilityText)
+	}
+	if _, ok := backend.(Renderer); ok {
+		capabilities = append(capabilities, CapabilityRender)
+	}
+	return capabilities
+}
+
+// textExtractor returns the text backend selected by the options, or the built-in backend
+func textExtractor(options *types.ParseOptions) (TextExtractor, error) {
+	name := defaultBackend
+	if options != nil && options.TextBackend != "" {
+		name = options.TextBackend
+	}
+	backend, ok := LookupBackend(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown text backend %q", name)
+	}
+	extractor, ok := backend.(TextExtractor)
+	if !ok {
+		return nil, fmt.Errorf("backend %q cannot extract text", name)
+	}
+	return extractor, nil
+}
+
+// extractText reads the text of every page of a PDF buffer with a text backend, along with the
+// layout of the pages when the backend reads it
+func extractText(extractor TextExtractor, buffer []byte) ([]string, []types.PageLayout, error) {
+	layoutExtractor, ok := extractor.(LayoutExtractor)
+	if !ok {
+		pages, err := extractor.ExtractText(buffer)
+		return pages, nil, err
+	}
+
+	layouts, err := layoutExtractor.ExtractLayout(buffer)
+	if err != nil {
+		return nil, nil, err
+	}
+	pages := make([]string, len(layouts))
+	for i, layout := range layouts {
+		var text strings.Builder
+		for _, line := range layout.Lines {
+			text.WriteString(line.Text)
+			text.WriteString("\n")
+		}
+		pages[i] = text.String()
+	}
+	return pages, layouts, nil
+}
+
+// recognizeText reads the text of a PDF buffer with a named text backend, with a header before
+// the text of every page, and the layout of the pages when the backend reads it
+func recognizeText(buffer []byte, name string) (string, []types.PageLayout, error) {
+	extractor, err := textExtractor(&types.ParseOptions{TextBackend: name})
+	if err != nil {
+		return "", nil, err
+	}
+	pages, layouts, err := extractText(extractor, buffer)
+	if err != nil {
+		return "", nil, fmt.Errorf("backend %s failed: %w", name, err)
+	}
+
+	var text strings.Builder
+	for i, page := range pages {
+		fmt.Fprintf(&text, "--- Page %d ---\n%s\n", i+1, strings.TrimSpace(page))
+	}
+	return text.String(), layouts, nil
+}
+
+// renderer returns the renderer selected by the options, or the built-in backend, isolated and
+// limited when the options ask for it
+func renderer(options *types.ParseOptions) (Renderer, error) {
+	r, err := selectRenderer(options)
+	if err != nil || options == nil {
+		return r, err
+	}
+	if options.IsolateRendering {
+		r = isolatedRenderer{r}
+	}
+	if options.MaxRenderMemoryBytes > 0 || options.MaxRenderDuration > 0 {
+		r = limitedRenderer{Renderer: r, maxMemory: options.MaxRenderMemoryBytes, maxDuration: options.MaxRenderDuration}
+	}
+	return r, nil
+}
+
+// selectRenderer returns the renderer selected by the options, or the built-in backend
+func selectRenderer(options *types.ParseOptions) (Renderer, error) {
+	if options == nil || options.Renderer == "" {
+		backend, _ := LookupBackend(defaultBackend)
+		if r, ok := backend.(Renderer); ok {
+			return r, nil
+		}
+		return nil, ErrRenderingUnavailable
+	}
+
+	if options.RendererPath != "" {
+		switch options.Renderer {
+		case types.RendererPdftoppm:
+			return NewPdftoppmBackend(options.RendererPath), nil
+		case types.RendererGhostscript:
+			return NewGhostscriptBackend(options.RendererPath), nil
+		}
+	}
+	backend, ok := LookupBackend(options.Renderer)
+	if !ok {
+		return nil, fmt.Errorf("unknown renderer %q", options.Renderer)
+	}
+	r, ok := backend.(Renderer)
+	if !ok {
+		return nil, fmt.Errorf("backend %q cannot render pages", options.Renderer)
+	}
+	return r, nil
+}
+
+// documentTextBackend reads the text of PDFs with the document reader selected at build time
+type documentTextBackend struct {
+	name string
+}
+
+// Name identifies the backend
+func (b documentTextBackend) Name() string {
+	return b.name
+}
+
+// ExtractText reads the text of every page. Pages that fail to be read are left empty and
+// reported in a PageErrors error.
+func (b documentTextBackend) ExtractText(buffer []byte) ([]string, error) {
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+
+	pages := make([]string, doc.NumPage())
+	var pageErrs PageErrors
+	for pageNum := range pages {
+		text, err := doc.Text(pageNum)
+		if err != nil {
+			pageErrs = append(pageErrs, &PageError{Page: pageNum + 1, Stage: types.PageStageText, Err: err})
+			continue
+		}
+		pages[pageNum] = text
+	}
+	if len(pageErrs) > 0 {
+		return pages, pageErrs
+	}
+	return pages, nil
+}
+
+// documentBackend reads and renders PDFs with the document reader selected at build time
+type documentBackend struct {
+	documentTextBackend
+}
+
+// Render renders the pages one after the other
+func (b documentBackend) Render(buffer []byte, pages []int, dpi float64, page func(page int, img *image.RGBA) error) error {
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+
+	for _, p := range pages {
+		img, err := doc.Render(p-1, dpi)
+		if err != nil {
+			return &PageError{Page: p, Stage: types.PageStageRender, Err: err}
+		}
+		if err := page(p, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
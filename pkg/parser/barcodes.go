@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"errors"
+	"image"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	multiqrcode "github.com/makiuchi-d/gozxing/multi/qrcode"
+	"github.com/makiuchi-d/gozxing/oned"
+)
+
+// barcodeHints makes the decoders spend more effort, since pages are decoded once per parse
+var barcodeHints = map[gozxing.DecodeHintType]interface{}{
+	gozxing.DecodeHintType_TRY_HARDER: true,
+}
+
+// DecodeBarcodesFromBuffer renders every page of a PDF buffer and decodes the barcodes and QR codes found on them
+func DecodeBarcodesFromBuffer(buffer []byte, options *types.ParseOptions) ([]types.Barcode, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	dpi := defaultDPI
+	if options != nil && options.DPI > 0 {
+		dpi = options.DPI
+	}
+
+	numPages, err := pageCount(buffer, options)
+	if err != nil {
+		return nil, err
+	}
+	render, err := renderer(options)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]int, numPages)
+	for i := range pages {
+		pages[i] = i + 1
+	}
+	barcodes := make([]types.Barcode, 0)
+	err = render.Render(buffer, pages, dpi, func(page int, img *image.RGBA) error {
+		barcodes = append(barcodes, decodeBarcodes(img, page)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return barcodes, nil
+}
+
+// decodeBarcodes decodes the QR codes, Data Matrix codes and linear barcodes found on a page render.
+// Every QR code is decoded, while the other formats report at most one code per page.
+func decodeBarcodes(img image.Image, page int) []types.Barcode {
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	barcodes := make([]types.Barcode, 0)
+	add := func(result *gozxing.Result) {
+		key := result.GetBarcodeFormat().String() + "\x00" + result.GetText()
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		barcodes = append(barcodes, types.Barcode{
+			Page:   page,
+			Format: result.GetBarcodeFormat().String(),
+			Text:   result.GetText(),
+		})
+	}
+
+	if results, err := multiqrcode.NewQRCodeMultiReader().DecodeMultiple(bitmap, barcodeHints); err == nil {
+		for _, result := range results {
+			add(result)
+		}
+	}
+
+	readers := []gozxing.Reader{
+		datamatrix.NewDataMatrixReader(),
+		oned.NewMultiFormatUPCEANReader(barcodeHints),
+		oned.NewCode128Reader(),
+		oned.NewCode39Reader(),
+		oned.NewCode93Reader(),
+		oned.NewITFReader(),
+		oned.NewCodaBarReader(),
+	}
+	for _, reader := range readers {
+		if result, err := reader.Decode(bitmap, barcodeHints); err == nil {
+			add(result)
+		}
+	}
+
+	return barcodes
+}
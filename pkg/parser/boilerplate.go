@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// boilerplateLines is the number of lines at the top and bottom of a page searched for headers
+	// and footers
+	boilerplateLines = 3
+	// minBoilerplatePages is the smallest number of pages a header or footer must repeat on
+	minBoilerplatePages = 3
+)
+
+var (
+	digitsPattern     = regexp.MustCompile(`[0-9]+`)
+	spacesPattern     = regexp.MustCompile(`\s+`)
+	pageNumberPattern = regexp.MustCompile(`(?i)^[-–—\s]*(?:(?:page|p\.|pg\.?|seite|página|pagina)\s*[0-9ivxlc]+|[0-9]+)(?:\s*(?:/|of|de|sur|von|di)\s*[0-9]+)?[-–—\s]*$`)
+)
+
+// StripBoilerplate removes the running headers, footers and page numbers of the pages of a
+// document: lines that repeat, up to their numbers, at the same position among the first or last
+// lines of at least half of the pages (and at least three), and lines at these positions that only
+// hold a page number. Documents with a single page are returned as they are.
+func StripBoilerplate(pages []string) []string {
+	if len(pages) < 2 {
+		return pages
+	}
+
+	// Count on how many pages each edge line appears at its position, ignoring numbers
+	counts := make(map[string]int)
+	for _, page := range pages {
+		lines := strings.Split(page, "\n")
+		for position, index := range edgeLines(lines) {
+			counts[boilerplateKey(position, lines[index])]++
+		}
+	}
+	repeated := max(minBoilerplatePages, (len(pages)+1)/2)
+
+	stripped := make([]string, len(pages))
+	for i, page := range pages {
+		lines := strings.Split(page, "\n")
+		remove := make(map[int]bool)
+		for position, index := range edgeLines(lines) {
+			if counts[boilerplateKey(position, lines[index])] >= repeated || pageNumberPattern.MatchString(strings.TrimSpace(lines[index])) {
+				remove[index] = true
+			}
+		}
+
+		kept := make([]string, 0, len(lines))
+		for index, line := range lines {
+			if !remove[index] {
+				kept = append(kept, line)
+			}
+		}
+		stripped[i] = strings.Join(kept, "\n")
+	}
+	return stripped
+}
+
+// edgeLines returns the indexes of the first and last boilerplateLines non-blank lines of a page
+// by position: 0, 1... from the top and -1, -2... from the bottom
+func edgeLines(lines []string) map[int]int {
+	var nonBlank []int
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			nonBlank = append(nonBlank, i)
+		}
+	}
+
+	edges := make(map[int]int)
+	for i := 0; i < min(boilerplateLines, len(nonBlank)); i++ {
+		edges[i] = nonBlank[i]
+	}
+	for i := 1; i <= min(boilerplateLines, len(nonBlank)-boilerplateLines); i++ {
+		edges[-i] = nonBlank[len(nonBlank)-i]
+	}
+	return edges
+}
+
+// boilerplateKey normalizes a line at a position so that running headers and footers match across
+// pages despite their page numbers and dates
+func boilerplateKey(position int, line string) string {
+	line = spacesPattern.ReplaceAllString(strings.TrimSpace(line), " ")
+	return fmt.Sprintf("%d:%s", position, digitsPattern.ReplaceAllString(strings.ToLower(line), "#"))
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DetectColumnsFromPath detects the number of text columns of each page of a PDF file
+func DetectColumnsFromPath(pdfPath string) ([]int, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF from path: %w", err)
+	}
+
+	return DetectColumnsFromBuffer(data)
+}
+
+// DetectColumnsFromBuffer detects the number of text columns of each page of a PDF buffer: the most
+// columns of running text side by side on the page, 1 for single-column pages and 0 for pages
+// without text. Columns are found with the same recursive XY-cut as layout mode.
+func DetectColumnsFromBuffer(buffer []byte) ([]int, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+
+	columns := make([]int, doc.NumPage())
+	for pageNum := range columns {
+		lines, err := doc.Lines(pageNum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", pageNum+1, err)
+		}
+		columns[pageNum] = columnCount(lines)
+	}
+	return columns, nil
+}
+
+// columnPages reorders the text of the multi-column pages of a PDF buffer column by column, see
+// columnText. The text of other pages is kept as extracted.
+func columnPages(buffer []byte, pages []string) ([]string, error) {
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+
+	ordered := make([]string, len(pages))
+	copy(ordered, pages)
+	for pageNum := range ordered {
+		if pageNum >= doc.NumPage() {
+			break
+		}
+		lines, err := doc.Lines(pageNum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", pageNum+1, err)
+		}
+		if columnCount(lines) > 1 {
+			ordered[pageNum] = columnText(lines)
+		}
+	}
+	return ordered, nil
+}
+
+// columnText rebuilds the text of a page from its positioned lines with columns of running text
+// read one after the other. Unlike layoutText, the cells of rows are joined by a single space.
+func columnText(lines []textLine) string {
+	var b strings.Builder
+	var previous *textLine
+	for _, row := range layoutRows(lines) {
+		first := row.lines[0]
+		if previous != nil {
+			b.WriteString("\n")
+			if startsParagraph(previous, &first) {
+				b.WriteString("\n")
+			}
+		}
+		texts := make([]string, len(row.lines))
+		for i, line := range row.lines {
+			texts[i] = strings.TrimSpace(line.Text)
+		}
+		b.WriteString(strings.Join(texts, " "))
+		previous = &row.lines[len(row.lines)-1]
+	}
+	return b.String()
+}
+
+// columnCount returns the most columns of running text side by side among lines, cutting them as
+// cutRegion does
+func columnCount(lines []textLine) int {
+	if len(lines) == 0 {
+		return 0
+	}
+	top, bottom, rowGap := cutRows(lines)
+	leftSide, rightSide, columnGap := cutColumns(lines)
+	switch {
+	case leftSide != nil && columnGap >= rowGap:
+		return columnCount(leftSide) + columnCount(rightSide)
+	case top != nil:
+		return max(columnCount(top), columnCount(bottom))
+	default:
+		return 1
+	}
+}
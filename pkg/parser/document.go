@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"errors"
+	"image"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// ErrRenderingUnavailable is returned when rendering pages or extracting embedded images with the
+// pure-Go backend, selected by the nomupdf build tag, which only reads the text layer. Pages can
+// still be rendered by an external renderer, see types.ParseOptions.Renderer.
+var ErrRenderingUnavailable = errors.New("rendering is not available: built with the nomupdf tag")
+
+// document is an open PDF document, read by the backend selected at build time: MuPDF through
+// go-fitz by default, or a pure-Go text reader with the nomupdf build tag
+type document interface {
+	// NumPage returns the number of pages
+	NumPage() int
+	// Text returns the plain text of a page (0-indexed)
+	Text(pageNum int) (string, error)
+	// Lines returns the lines of text of a page in reading order, skipping blank lines
+	Lines(pageNum int) ([]textLine, error)
+	// Glyphs returns the size of a page in points and the glyphs drawn on it
+	Glyphs(pageNum int) (width, height float64, glyphs []glyph, err error)
+	// Images returns the images embedded in a page, with their bounds
+	Images(pageNum int) ([]types.PdfEmbeddedImage, error)
+	// Render renders a page at the given resolution
+	Render(pageNum int, dpi float64) (*image.RGBA, error)
+	// Outline returns the outline (bookmarks) of the document, or nil when it has none
+	Outline() ([]types.OutlineItem, error)
+	Close() error
+}
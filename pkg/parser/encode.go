@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// imageEncoder encodes PNG images to another format by running its command-line encoder
+type imageEncoder struct {
+	// binary is the default name of the encoder, found in the PATH
+	binary   string
+	mimeType string
+	args     func(input, output string) []string
+}
+
+// imageEncoders are the encoders of the image formats other than PNG
+var imageEncoders = map[string]imageEncoder{
+	types.ImageFormatWebP: {
+		binary:   "cwebp",
+		mimeType: "image/webp",
+		args: func(input, output string) []string {
+			return []string{"-quiet", "-q", "80", input, "-o", output}
+		},
+	},
+	types.ImageFormatAVIF: {
+		binary:   "avifenc",
+		mimeType: "image/avif",
+		args: func(input, output string) []string {
+			return []string{"-q", "60", "-s", "6", input, output}
+		},
+	},
+}
+
+// EncodePageImage encodes a base64-encoded PNG page image to an image format, with the encoder
+// found at encoderPath or in the PATH when it is empty. It returns the base64-encoded image and its
+// MIME type. The PNG image is returned as is for ImageFormatPNG or an empty format, and when the
+// encoded image is not smaller, as happens for pages of plain text.
+func EncodePageImage(base64PNG, format, encoderPath string) (string, string, error) {
+	if format == "" || format == types.ImageFormatPNG {
+		return base64PNG, "image/png", nil
+	}
+	encoder, ok := imageEncoders[format]
+	if !ok {
+		return "", "", fmt.Errorf("unknown image format %q", format)
+	}
+	if encoderPath == "" {
+		encoderPath = encoder.binary
+	}
+	path, err := exec.LookPath(encoderPath)
+	if err != nil {
+		return "", "", fmt.Errorf("image encoder %s not found: %w", encoder.binary, err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(base64PNG)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode page image: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "pdf-extractor-encode-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	input, output := filepath.Join(dir, "page.png"), filepath.Join(dir, "page."+format)
+	if err := os.WriteFile(input, raw, 0o600); err != nil {
+		return "", "", fmt.Errorf("failed to write page image for %s: %w", encoder.binary, err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(path, encoder.args(input, output)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("%s failed: %w: %s", encoder.binary, err, strings.TrimSpace(stderr.String()))
+	}
+	encoded, err := os.ReadFile(output)
+	if err != nil {
+		return "", "", fmt.Errorf("%s produced no image: %w", encoder.binary, err)
+	}
+	if len(encoded) == 0 || len(encoded) >= len(raw) {
+		return base64PNG, "image/png", nil
+	}
+	return base64.StdEncoding.EncodeToString(encoded), encoder.mimeType, nil
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// externalBackend renders the pages of a PDF by running pdftoppm or Ghostscript on a temporary
+// copy of it
+type externalBackend struct {
+	name string
+	// binary is the path of the tool, or its name to find it in the PATH
+	binary string
+	args   func(file, output, page, resolution string) []string
+}
+
+// NewPdftoppmBackend returns a Renderer running Poppler's pdftoppm found at path, or in the PATH
+// when path is empty
+func NewPdftoppmBackend(path string) Renderer {
+	if path == "" {
+		path = "pdftoppm"
+	}
+	return &externalBackend{
+		name:   types.RendererPdftoppm,
+		binary: path,
+		args: func(file, output, page, resolution string) []string {
+			// pdftoppm adds the extension to the output file
+			return []string{"-png", "-r", resolution, "-f", page, "-l", page, "-singlefile", file, strings.TrimSuffix(output, ".png")}
+		},
+	}
+}
+
+// NewGhostscriptBackend returns a Renderer running Ghostscript found at path, or in the PATH when
+// path is empty
+func NewGhostscriptBackend(path string) Renderer {
+	if path == "" {
+		path = "gs"
+	}
+	return &externalBackend{
+		name:   types.RendererGhostscript,
+		binary: path,
+		args: func(file, output, page, resolution string) []string {
+			return []string{
+				"-q", "-dSAFER", "-dBATCH", "-dNOPAUSE", "-sDEVICE=png16m",
+				"-dTextAlphaBits=4", "-dGraphicsAlphaBits=4", "-r" + resolution,
+				"-dFirstPage=" + page, "-dLastPage=" + page, "-sOutputFile=" + output, file,
+			}
+		},
+	}
+}
+
+// Name identifies the backend
+func (b *externalBackend) Name() string {
+	return b.name
+}
+
+// Render runs the tool once per page and decodes the PNG file it writes
+func (b *externalBackend) Render(buffer []byte, pages []int, dpi float64, page func(page int, img *image.RGBA) error) error {
+	path, err := exec.LookPath(b.binary)
+	if err != nil {
+		return fmt.Errorf("renderer %s not found: %w", b.name, err)
+	}
+
+	dir, err := os.MkdirTemp("", "pdf-extractor-render-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "document.pdf")
+	if err := os.WriteFile(file, buffer, 0o600); err != nil {
+		return fmt.Errorf("failed to write PDF for %s: %w", b.name, err)
+	}
+
+	resolution := strconv.FormatFloat(dpi, 'f', -1, 64)
+	for _, p := range pages {
+		output := filepath.Join(dir, fmt.Sprintf("page-%d.png", p))
+		img, err := b.run(path, b.args(file, output, strconv.Itoa(p), resolution), output)
+		if err != nil {
+			return &PageError{Page: p, Stage: types.PageStageRender, Err: err}
+		}
+		if err := page(p, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run runs the tool and decodes the PNG file it writes to output
+func (b *externalBackend) run(path string, args []string, output string) (*image.RGBA, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", b.name, err, strings.TrimSpace(stderr.String()))
+	}
+	defer os.Remove(output)
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		return nil, fmt.Errorf("%s produced no image: %w", b.name, err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s output: %w", b.name, err)
+	}
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba, nil
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
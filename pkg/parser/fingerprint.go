@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// FingerprintFromPath fingerprints a PDF file, see Fingerprint
+func FingerprintFromPath(pdfPath string) (*types.Fingerprint, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF from path: %w", err)
+	}
+
+	return Fingerprint(data)
+}
+
+// Fingerprint returns the hashes and structural features of a PDF buffer, to deduplicate documents
+// and key caches. Hash changes with any byte of the file, while ContentHash only covers what the
+// pages show, so that a document saved again with new metadata, IDs or compression keeps it. The
+// fingerprint of a buffer is always the same.
+func Fingerprint(buffer []byte) (*types.Fingerprint, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	ctx, err := api.ReadAndValidate(bytes.NewReader(buffer), conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, fmt.Errorf("failed to read PDF pages: %w", err)
+	}
+
+	// Hash the page contents before the fonts are read, which optimizes the PDF
+	content := sha256.New()
+	for pageNum := 1; pageNum <= ctx.PageCount; pageNum++ {
+		pageDict, _, inherited, err := ctx.PageDict(pageNum, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", pageNum, err)
+		}
+		fmt.Fprintf(content, "page %d\n", pageNum)
+		if box := inherited.MediaBox; box != nil {
+			fmt.Fprintf(content, "%g %g\n", box.Width(), box.Height())
+		}
+		// Pages without content are blank, which the hash of their number and size covers
+		if data, err := ctx.PageContent(pageDict, pageNum); err == nil {
+			content.Write(data)
+		}
+	}
+
+	if err := api.OptimizeContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read PDF fonts: %w", err)
+	}
+	info, err := pdfcpu.Info(ctx, "", nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF info: %w", err)
+	}
+	fonts := make([]string, 0, len(info.Fonts))
+	for _, font := range info.Fonts {
+		if font.Name != "" && !slices.Contains(fonts, font.Name) {
+			fonts = append(fonts, font.Name)
+		}
+	}
+	slices.Sort(fonts)
+	for _, font := range fonts {
+		fmt.Fprintf(content, "font %s\n", font)
+	}
+
+	sum := sha256.Sum256(buffer)
+	return &types.Fingerprint{
+		Hash:        hex.EncodeToString(sum[:]),
+		ContentHash: hex.EncodeToString(content.Sum(nil)),
+		NumPages:    ctx.PageCount,
+		Version:     info.Version,
+		Fonts:       fonts,
+		Producer:    info.Producer,
+		Creator:     info.Creator,
+	}, nil
+}
@@ -0,0 +1,97 @@
+//go:build !nomupdf
+
+package parser
+
+import (
+	"errors"
+	"image"
+	"strings"
+
+	"github.com/gen2brain/go-fitz"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// defaultBackend names the built-in backend, which reads and renders PDFs with MuPDF
+const defaultBackend = types.RendererMuPDF
+
+func init() {
+	RegisterBackend(documentBackend{documentTextBackend{name: defaultBackend}})
+}
+
+// fitzDocument reads a PDF document with MuPDF
+type fitzDocument struct {
+	*fitz.Document
+}
+
+// openDocument opens a PDF buffer with MuPDF
+func openDocument(buffer []byte) (document, error) {
+	doc, err := fitz.NewFromMemory(buffer)
+	if err != nil {
+		return nil, err
+	}
+	return fitzDocument{doc}, nil
+}
+
+// Lines reads the lines of a page from the HTML output, which positions every line
+func (d fitzDocument) Lines(pageNum int) ([]textLine, error) {
+	page, err := d.HTML(pageNum, false)
+	if err != nil {
+		return nil, err
+	}
+	return htmlLines(page), nil
+}
+
+// Glyphs reads the glyphs of a page from the SVG output, which draws every glyph at its exact
+// position
+func (d fitzDocument) Glyphs(pageNum int) (width, height float64, glyphs []glyph, err error) {
+	svg, err := d.SVG(pageNum)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	width, height = svgSize(svg)
+	return width, height, svgGlyphs(svg), nil
+}
+
+// Images reads the images of a page from the HTML output
+func (d fitzDocument) Images(pageNum int) ([]types.PdfEmbeddedImage, error) {
+	page, err := d.HTML(pageNum, false)
+	if err != nil {
+		return nil, err
+	}
+	return htmlImages(page)
+}
+
+// Render renders a page at the given resolution
+func (d fitzDocument) Render(pageNum int, dpi float64) (*image.RGBA, error) {
+	return d.ImageDPI(pageNum, dpi)
+}
+
+// Outline reads the table of contents, which MuPDF lists depth first with the level of each entry
+func (d fitzDocument) Outline() ([]types.OutlineItem, error) {
+	entries, err := d.ToC()
+	if errors.Is(err, fitz.ErrLoadOutline) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return outlineTree(entries), nil
+}
+
+// outlineTree nests a depth-first list of outline entries under their parents
+func outlineTree(entries []fitz.Outline) []types.OutlineItem {
+	var items []types.OutlineItem
+	for i := 0; i < len(entries); {
+		end := i + 1
+		for end < len(entries) && entries[end].Level > entries[i].Level {
+			end++
+		}
+		items = append(items, types.OutlineItem{
+			Title: strings.TrimSpace(entries[i].Title),
+			// MuPDF numbers pages from 0, and entries pointing outside the document to -1
+			Page:     max(entries[i].Page+1, 0),
+			Children: outlineTree(entries[i+1 : end]),
+		})
+		i = end
+	}
+	return items
+}
@@ -0,0 +1,227 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // register JPEG decoding for embedded images
+	"image/png"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// pixelsPerPoint is the CSS pixel density used by fitz's HTML output
+const pixelsPerPoint = 96.0 / 72.0
+
+var (
+	htmlImagePattern     = regexp.MustCompile(`<img style="([^"]*)" src="data:([^;]+);base64,([^"]*)"`)
+	cssMatrixPattern     = regexp.MustCompile(`matrix\(([^)]*)\)`)
+	base64SpacesReplacer = strings.NewReplacer("\n", "", "\r", "", " ", "")
+)
+
+// ExtractImagesFromPath extracts the images embedded in a PDF file
+func ExtractImagesFromPath(pdfPath string) ([]types.PdfEmbeddedImage, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF from path: %w", err)
+	}
+
+	return ExtractImagesFromBuffer(data)
+}
+
+// ExtractImagesFromBuffer extracts the images embedded in a PDF buffer, with their position on the page
+func ExtractImagesFromBuffer(buffer []byte) ([]types.PdfEmbeddedImage, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+
+	images := make([]types.PdfEmbeddedImage, 0)
+	for pageNum := 0; pageNum < doc.NumPage(); pageNum++ {
+		pageImages, err := doc.Images(pageNum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read images of page %d: %w", pageNum+1, err)
+		}
+		for _, img := range pageImages {
+			img.Page = pageNum + 1
+			images = append(images, img)
+		}
+	}
+
+	return images, nil
+}
+
+// htmlImages decodes the images of a page from fitz's HTML output, which inlines every image with
+// its transform
+func htmlImages(page string) ([]types.PdfEmbeddedImage, error) {
+	images := make([]types.PdfEmbeddedImage, 0)
+	for _, match := range htmlImagePattern.FindAllStringSubmatch(page, -1) {
+		img, err := parseHTMLImage(match[1], match[2], match[3])
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// parseHTMLImage decodes an image inlined by fitz's HTML output and computes its page bounds
+func parseHTMLImage(style, mimeType, data string) (types.PdfEmbeddedImage, error) {
+	data = base64SpacesReplacer.Replace(data)
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return types.PdfEmbeddedImage{}, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return types.PdfEmbeddedImage{}, err
+	}
+
+	img := types.PdfEmbeddedImage{
+		Width:    config.Width,
+		Height:   config.Height,
+		MimeType: mimeType,
+		Base64:   data,
+	}
+
+	// The CSS matrix scales the image around its center and then translates it, in CSS pixels
+	match := cssMatrixPattern.FindStringSubmatch(style)
+	if match == nil {
+		return img, nil
+	}
+	m := make([]float64, 0, 6)
+	for _, value := range strings.Split(match[1], ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return types.PdfEmbeddedImage{}, fmt.Errorf("invalid image transform %q", match[1])
+		}
+		m = append(m, f)
+	}
+	if len(m) != 6 {
+		return types.PdfEmbeddedImage{}, fmt.Errorf("invalid image transform %q", match[1])
+	}
+
+	w, h := float64(config.Width), float64(config.Height)
+	width := math.Abs(m[0])*w + math.Abs(m[2])*h
+	height := math.Abs(m[1])*w + math.Abs(m[3])*h
+	centerX := m[4] + w/2
+	centerY := m[5] + h/2
+	img.Bounds = types.Rect{
+		X:      (centerX - width/2) / pixelsPerPoint,
+		Y:      (centerY - height/2) / pixelsPerPoint,
+		Width:  width / pixelsPerPoint,
+		Height: height / pixelsPerPoint,
+	}
+
+	return img, nil
+}
+
+// RenderPdfToImages renders every page of a PDF buffer as a base64-encoded PNG image
+func RenderPdfToImages(buffer []byte) ([]types.PdfPageImage, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	return convertPdfToImages(buffer, nil, nil)
+}
+
+// RenderPdfPagesToImages renders the given pages (1-indexed) of a PDF buffer as base64-encoded PNG images
+func RenderPdfPagesToImages(buffer []byte, pages []int) ([]types.PdfPageImage, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("at least one page must be selected")
+	}
+
+	return convertPdfToImages(buffer, pages, nil)
+}
+
+// CropPageImage crops a region out of a rendered page. The region is relative to the page size,
+// with all values between 0 and 1. The result is a base64-encoded PNG image.
+func CropPageImage(page types.PdfPageImage, region types.Rect) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(page.Base64)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode page %d image: %w", page.Page, err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode page %d image: %w", page.Page, err)
+	}
+
+	bounds := img.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	rect := image.Rect(
+		bounds.Min.X+int(region.X*w),
+		bounds.Min.Y+int(region.Y*h),
+		bounds.Min.X+int(math.Ceil((region.X+region.Width)*w)),
+		bounds.Min.Y+int(math.Ceil((region.Y+region.Height)*h)),
+	).Intersect(bounds)
+	if rect.Empty() {
+		return "", fmt.Errorf("crop region %+v is outside page %d", region, page.Page)
+	}
+
+	subImager, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	})
+	if !ok {
+		return "", fmt.Errorf("page %d image cannot be cropped", page.Page)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, subImager.SubImage(rect)); err != nil {
+		return "", fmt.Errorf("failed to encode crop of page %d as PNG: %w", page.Page, err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// RedactPageImage blacks out regions of a rendered page, such as boxes of personal data, before it
+// is sent anywhere. The regions are relative to the page size, with all values between 0 and 1.
+// The result is a copy of the page with a base64-encoded PNG image.
+func RedactPageImage(page types.PdfPageImage, regions []types.Rect) (types.PdfPageImage, error) {
+	raw, err := base64.StdEncoding.DecodeString(page.Base64)
+	if err != nil {
+		return page, fmt.Errorf("failed to decode page %d image: %w", page.Page, err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return page, fmt.Errorf("failed to decode page %d image: %w", page.Page, err)
+	}
+
+	bounds := img.Bounds()
+	redacted := image.NewRGBA(bounds)
+	draw.Draw(redacted, bounds, img, bounds.Min, draw.Src)
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	black := image.NewUniform(color.Black)
+	for _, region := range regions {
+		rect := image.Rect(
+			bounds.Min.X+int(math.Floor(region.X*w)),
+			bounds.Min.Y+int(math.Floor(region.Y*h)),
+			bounds.Min.X+int(math.Ceil((region.X+region.Width)*w)),
+			bounds.Min.Y+int(math.Ceil((region.Y+region.Height)*h)),
+		).Intersect(bounds)
+		draw.Draw(redacted, rect, black, image.Point{}, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, redacted); err != nil {
+		return page, fmt.Errorf("failed to encode redacted page %d as PNG: %w", page.Page, err)
+	}
+	page.Base64 = base64.StdEncoding.EncodeToString(buf.Bytes())
+	return page, nil
+}
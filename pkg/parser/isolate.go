@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"runtime"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// ErrRenderFailed is returned, wrapped, when an isolated renderer panics on a malformed PDF, see
+// types.ParseOptions.IsolateRendering
+var ErrRenderFailed = errors.New("rendering failed")
+
+// isolatedRenderer runs a renderer in a goroutine of its own, turning its panics into errors
+type isolatedRenderer struct {
+	Renderer
+}
+
+// Render renders the pages in isolation
+func (r isolatedRenderer) Render(buffer []byte, pages []int, dpi float64, page func(page int, img *image.RGBA) error) error {
+	return isolate(func() error {
+		return r.Renderer.Render(buffer, pages, dpi, page)
+	})
+}
+
+// isolate runs fn in a goroutine locked to its OS thread and returns its error, or an
+// ErrRenderFailed error when it panics. The thread of a goroutine that panicked is never unlocked,
+// so the runtime discards it along with any state the native renderer left on it.
+func isolate(fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("%w: %v", ErrRenderFailed, r)
+				return
+			}
+			runtime.UnlockOSThread()
+		}()
+		done <- fn()
+	}()
+	return <-done
+}
+
+// pageCount returns the number of pages of a PDF buffer, in isolation when the options ask for it
+func pageCount(buffer []byte, options *types.ParseOptions) (int, error) {
+	if options == nil || !options.IsolateRendering {
+		return documentPageCount(buffer)
+	}
+	var numPages int
+	err := isolate(func() error {
+		var err error
+		numPages, err = documentPageCount(buffer)
+		return err
+	})
+	return numPages, err
+}
@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// averageCharWidth is the average width of a character relative to the font size, used to
+	// estimate the width of lines and to align text in layout mode
+	averageCharWidth = 0.5
+	// minColumnLineLength is the shortest average line, in characters, of a column of running
+	// text; narrower columns are kept together row by row as tables or forms
+	minColumnLineLength = 20
+)
+
+// layoutRow is a row of lines sharing a baseline, positioned relative to the left edge of the
+// column holding it
+type layoutRow struct {
+	lines []textLine
+	left  float64
+}
+
+// layoutPages extracts the text of each page of a PDF buffer in layout mode, see layoutText
+func layoutPages(buffer []byte) ([]string, error) {
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+
+	pages := make([]string, doc.NumPage())
+	for pageNum := range pages {
+		lines, err := doc.Lines(pageNum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", pageNum+1, err)
+		}
+		pages[pageNum] = layoutText(lines)
+	}
+	return pages, nil
+}
+
+// layoutText reconstructs the text of a page from its positioned lines: columns of running text
+// are read one after the other, and the cells of rows are spaced out to keep the alignment of
+// tables and forms
+func layoutText(lines []textLine) string {
+	charWidth := bodyFontSize(lines) * averageCharWidth
+	if charWidth <= 0 {
+		charWidth = 1
+	}
+
+	var b strings.Builder
+	var previous *textLine
+	for _, row := range layoutRows(lines) {
+		first := row.lines[0]
+		if previous != nil {
+			b.WriteString("\n")
+			if startsParagraph(previous, &first) {
+				b.WriteString("\n")
+			}
+		}
+
+		position := 0
+		for i, line := range row.lines {
+			column := int(math.Round((line.Left - row.left) / charWidth))
+			if i > 0 {
+				column = max(column, position+1)
+			}
+			column = max(column, position)
+			b.WriteString(strings.Repeat(" ", column-position))
+			text := strings.TrimSpace(line.Text)
+			b.WriteString(text)
+			position = column + utf8.RuneCountInString(text)
+		}
+		previous = &row.lines[len(row.lines)-1]
+	}
+	return b.String()
+}
+
+// readingOrder returns the lines of a page in reading order, see layoutRows
+func readingOrder(lines []textLine) []textLine {
+	ordered := make([]textLine, 0, len(lines))
+	for _, row := range layoutRows(lines) {
+		ordered = append(ordered, row.lines...)
+	}
+	return ordered
+}
+
+// layoutRows splits the lines of a page into rows in reading order with a recursive XY-cut: a
+// region is split at its widest gap, vertical gaps being only considered between columns of
+// running text, until only single rows remain
+func layoutRows(lines []textLine) []layoutRow {
+	if len(lines) == 0 {
+		return nil
+	}
+	left := lines[0].Left
+	for _, line := range lines {
+		left = min(left, line.Left)
+	}
+	return cutRegion(lines, left)
+}
+
+// cutRegion splits a region of lines into rows in reading order, positioned relative to left
+func cutRegion(lines []textLine, left float64) []layoutRow {
+	top, bottom, rowGap := cutRows(lines)
+	leftSide, rightSide, columnGap := cutColumns(lines)
+
+	switch {
+	case leftSide != nil && columnGap >= rowGap:
+		rightLeft := rightSide[0].Left
+		for _, line := range rightSide {
+			rightLeft = min(rightLeft, line.Left)
+		}
+		return append(cutRegion(leftSide, left), cutRegion(rightSide, rightLeft)...)
+	case top != nil:
+		return append(cutRegion(top, left), cutRegion(bottom, left)...)
+	default:
+		// A single row, possibly with lines of different heights
+		row := append([]textLine(nil), lines...)
+		sort.SliceStable(row, func(a, b int) bool { return row[a].Left < row[b].Left })
+		return []layoutRow{{lines: row, left: left}}
+	}
+}
+
+// cutRows splits a region at its widest horizontal gap
+func cutRows(lines []textLine) (top, bottom []textLine, gap float64) {
+	sorted := append([]textLine(nil), lines...)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Top < sorted[b].Top })
+
+	edge := sorted[0].Top + sorted[0].Height
+	cut := -1
+	for i := 1; i < len(sorted); i++ {
+		if space := sorted[i].Top - edge; space > gap {
+			cut, gap = i, space
+		}
+		edge = max(edge, sorted[i].Top+sorted[i].Height)
+	}
+	if cut < 0 {
+		return nil, nil, 0
+	}
+	return sorted[:cut], sorted[cut:], gap
+}
+
+// cutColumns splits a region at its widest vertical gap, when it is at least as wide as the font
+// and both sides read as columns of running text
+func cutColumns(lines []textLine) (left, right []textLine, gap float64) {
+	sorted := append([]textLine(nil), lines...)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Left < sorted[b].Left })
+
+	edge := lineRight(sorted[0])
+	cut, fontSize := -1, sorted[0].FontSize
+	for i := 1; i < len(sorted); i++ {
+		if space := sorted[i].Left - edge; space > gap {
+			cut, gap = i, space
+		}
+		edge = max(edge, lineRight(sorted[i]))
+		fontSize = max(fontSize, sorted[i].FontSize)
+	}
+	if cut < 0 || gap < fontSize || !isColumn(sorted[:cut]) || !isColumn(sorted[cut:]) {
+		return nil, nil, 0
+	}
+	return sorted[:cut], sorted[cut:], gap
+}
+
+// isColumn reports whether lines read as a column of running text rather than table cells
+func isColumn(lines []textLine) bool {
+	if len(lines) < 2 {
+		return false
+	}
+	characters := 0
+	for _, line := range lines {
+		characters += utf8.RuneCountInString(strings.TrimSpace(line.Text))
+	}
+	return characters >= len(lines)*minColumnLineLength
+}
+
+// lineRight estimates the right edge of a line from its length and font size, since fitz's HTML
+// output does not give the width of lines
+func lineRight(line textLine) float64 {
+	return line.Left + float64(utf8.RuneCountInString(strings.TrimRight(line.Text, " ")))*line.FontSize*averageCharWidth
+}
+
+// bodyFontSize returns the font size of most characters of lines, rounded to half points
+func bodyFontSize(lines []textLine) float64 {
+	characters := make(map[float64]int)
+	for _, line := range lines {
+		characters[roundSize(line.FontSize)] += utf8.RuneCountInString(line.Text)
+	}
+	body := 0.0
+	for size, count := range characters {
+		if count > characters[body] || (count == characters[body] && size < body) {
+			body = size
+		}
+	}
+	return body
+}
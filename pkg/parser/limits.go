@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// ErrRenderLimitExceeded is returned, wrapped in a *RenderLimitError, when rendering a PDF would
+// exceed ParseOptions.MaxRenderMemoryBytes or takes longer than ParseOptions.MaxRenderDuration
+var ErrRenderLimitExceeded = errors.New("render limit exceeded")
+
+// Limits of rendering, see RenderLimitError
+const (
+	LimitMemory   = "memory"
+	LimitDuration = "duration"
+)
+
+// RenderLimitError reports the page whose rendering was aborted and the limit it exceeded. It
+// matches ErrRenderLimitExceeded with errors.Is.
+type RenderLimitError struct {
+	// Page is the page (1-indexed) that was not rendered
+	Page int
+	// Limit is the exceeded limit: LimitMemory or LimitDuration
+	Limit string
+	// Detail describes the excess
+	Detail string
+}
+
+// Error describes the exceeded limit
+func (e *RenderLimitError) Error() string {
+	return fmt.Sprintf("%s: %s of page %d: %s", ErrRenderLimitExceeded, e.Limit, e.Page, e.Detail)
+}
+
+// Unwrap returns ErrRenderLimitExceeded
+func (e *RenderLimitError) Unwrap() error {
+	return ErrRenderLimitExceeded
+}
+
+// limitedRenderer aborts the rendering of pages whose raster would take more than maxMemory bytes,
+// or once maxDuration has passed
+type limitedRenderer struct {
+	Renderer
+	maxMemory   int64
+	maxDuration time.Duration
+}
+
+// Render checks the size of the pages before rendering them, and stops waiting for them when time
+// runs out. The page being rendered then completes in the background, but no page is rendered
+// after it.
+func (r limitedRenderer) Render(buffer []byte, pages []int, dpi float64, page func(page int, img *image.RGBA) error) error {
+	if r.maxMemory > 0 {
+		if err := checkRasterSize(buffer, pages, dpi, r.maxMemory); err != nil {
+			return err
+		}
+	}
+	if r.maxDuration <= 0 || len(pages) == 0 {
+		return r.Renderer.Render(buffer, pages, dpi, page)
+	}
+
+	// The mutex keeps the callback from running once the caller stopped waiting
+	var mu sync.Mutex
+	aborted := false
+	rendered := 0
+	errAborted := errors.New("rendering aborted")
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Renderer.Render(buffer, pages, dpi, func(p int, img *image.RGBA) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if aborted {
+				return errAborted
+			}
+			rendered++
+			return page(p, img)
+		})
+	}()
+
+	timer := time.NewTimer(r.maxDuration)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		mu.Lock()
+		defer mu.Unlock()
+		aborted = true
+		return &RenderLimitError{Page: pages[min(rendered, len(pages)-1)], Limit: LimitDuration, Detail: fmt.Sprintf("not rendered within %s", r.maxDuration)}
+	}
+}
+
+// checkRasterSize fails when the raster of one of the pages, at the given resolution, would take
+// more than maxMemory bytes
+func checkRasterSize(buffer []byte, pages []int, dpi float64, maxMemory int64) error {
+	dims, err := api.PageDims(bytes.NewReader(buffer), nil)
+	if err != nil {
+		return fmt.Errorf("failed to read page dimensions: %w", err)
+	}
+	scale := dpi / 72
+	for _, p := range pages {
+		if p < 1 || p > len(dims) {
+			continue
+		}
+		// Pages are rendered as 4 bytes per pixel
+		size := math.Ceil(dims[p-1].Width*scale) * math.Ceil(dims[p-1].Height*scale) * 4
+		if size > float64(maxMemory) {
+			return &RenderLimitError{
+				Page:   p,
+				Limit:  LimitMemory,
+				Detail: fmt.Sprintf("%.0fx%.0f pt at %.0f DPI takes %.0f bytes, more than %d", dims[p-1].Width, dims[p-1].Height, dpi, size, maxMemory),
+			}
+		}
+	}
+	return nil
+}
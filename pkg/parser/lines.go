@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	htmlLinePattern  = regexp.MustCompile(`(?s)<p style="([^"]*)">(.*?)</p>`)
+	htmlTokenPattern = regexp.MustCompile(`<[^>]*>|[^<]+`)
+	cssPointsPattern = regexp.MustCompile(`([a-z-]+):\s*(-?[0-9.]+)pt`)
+)
+
+// textLine is a line of text read from fitz's HTML output, positioned in points from the top-left
+// corner of the page
+type textLine struct {
+	Text string
+	// Top and Left are the position of the line
+	Top  float64
+	Left float64
+	// Height is the line height
+	Height float64
+	// FontSize is the largest font size used on the line
+	FontSize float64
+	// Bold is set when all the text of the line is bold
+	Bold bool
+}
+
+// htmlLines reads the lines of text of a page from fitz's HTML output, in fitz's reading order,
+// skipping blank lines
+func htmlLines(page string) []textLine {
+	lines := make([]textLine, 0)
+	for _, match := range htmlLinePattern.FindAllStringSubmatch(page, -1) {
+		line := parseHTMLLine(match[1], match[2])
+		if strings.TrimSpace(line.Text) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseHTMLLine parses a paragraph of fitz's HTML output, which holds a single line of text made
+// of styled spans
+func parseHTMLLine(style, content string) textLine {
+	line := textLine{}
+	for _, match := range cssPointsPattern.FindAllStringSubmatch(style, -1) {
+		value, _ := strconv.ParseFloat(match[2], 64)
+		switch match[1] {
+		case "top":
+			line.Top = value
+		case "left":
+			line.Left = value
+		case "line-height":
+			line.Height = value
+		}
+	}
+
+	var text strings.Builder
+	bold, boldText, plainText := 0, false, false
+	for _, token := range htmlTokenPattern.FindAllString(content, -1) {
+		switch {
+		case token == "<b>":
+			bold++
+		case token == "</b>":
+			bold--
+		case strings.HasPrefix(token, "<span"):
+			for _, match := range cssPointsPattern.FindAllStringSubmatch(token, -1) {
+				if match[1] == "font-size" {
+					size, _ := strconv.ParseFloat(match[2], 64)
+					line.FontSize = max(line.FontSize, size)
+				}
+			}
+		case strings.HasPrefix(token, "<"):
+			// Other markup (italics, sub- and superscripts) carries no layout information
+		default:
+			value := html.UnescapeString(token)
+			text.WriteString(value)
+			if strings.TrimSpace(value) != "" {
+				if bold > 0 {
+					boldText = true
+				} else {
+					plainText = true
+				}
+			}
+		}
+	}
+
+	line.Text = text.String()
+	line.Bold = boldText && !plainText
+	if line.FontSize == 0 {
+		line.FontSize = line.Height
+	}
+	return line
+}
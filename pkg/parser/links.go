@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// ExtractLinksFromPath reads the hyperlinks of a PDF file
+func ExtractLinksFromPath(pdfPath string) ([]types.Link, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF from path: %w", err)
+	}
+
+	return ExtractLinksFromBuffer(data)
+}
+
+// ExtractLinksFromBuffer reads the hyperlinks of a PDF buffer in page order: the link annotations
+// pointing to a URI, such as a web address or a mailto: email address, with the text of the page
+// they cover. Links to other pages of the document are left out.
+func ExtractLinksFromBuffer(buffer []byte) ([]types.Link, error) {
+	reader, err := newAnnotationReader(buffer)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	links := make([]types.Link, 0)
+	err = reader.each(func(pageNum int, subtype string, dict pdftypes.Dict, bounds types.Rect) {
+		if subtype != "Link" {
+			return
+		}
+		action, err := reader.ctx.DereferenceDict(dict["A"])
+		if err != nil || action == nil {
+			return
+		}
+		if kind := action.NameEntry("S"); kind == nil || *kind != "URI" {
+			return
+		}
+		uri := reader.text(action["URI"])
+		if uri == "" {
+			return
+		}
+		links = append(links, types.Link{
+			Page:   pageNum,
+			URI:    uri,
+			Text:   reader.markedText(pageNum, dict, bounds),
+			Bounds: bounds,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
@@ -0,0 +1,350 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// headingScale is the minimum ratio between the font size of a heading and the body text
+	headingScale = 1.15
+	// maxHeadingLevel is the deepest heading level derived from font sizes
+	maxHeadingLevel = 3
+	// maxHeadingLength is the longest line, in characters, considered as a heading
+	maxHeadingLength = 120
+	// maxTableCellLength is the longest average cell, in characters, of a table; longer cells
+	// are columns of running text
+	maxTableCellLength = 40
+	// paragraphGap is the vertical gap, relative to the line height, that separates paragraphs
+	paragraphGap = 0.8
+)
+
+var (
+	bulletPattern   = regexp.MustCompile(`^\s*(?:[•◦▪▫●○■□‣⁃∙·*+–-])\s+(.*)$`)
+	numberedPattern = regexp.MustCompile(`^\s*([0-9]{1,3})[.)]\s+(.*)$`)
+	markdownEscaper = strings.NewReplacer("|", `\|`)
+)
+
+// ToMarkdownFromPath converts the text of a PDF file to Markdown, see ToMarkdown
+func ToMarkdownFromPath(pdfPath string) (string, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF from path: %w", err)
+	}
+
+	return ToMarkdown(data)
+}
+
+// ToMarkdown converts the text of a PDF buffer to Markdown. Headings are recognized by their font
+// size or weight, bulleted and numbered lines become list items and aligned rows of short cells
+// become tables. Scanned PDFs without a text layer produce an empty string.
+func ToMarkdown(buffer []byte) (string, error) {
+	if !isValidPdfSignature(buffer) {
+		return "", errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	pages, err := markdownPages(buffer, false, false)
+	if err != nil {
+		return "", err
+	}
+
+	nonEmpty := make([]string, 0, len(pages))
+	for _, page := range pages {
+		if page != "" {
+			nonEmpty = append(nonEmpty, page)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n"), nil
+}
+
+// markdownPages converts the text of each page of a PDF buffer to Markdown, reading the lines in
+// the order reconstructed from their layout when layout is set, or on multi-column pages only when
+// columns is set
+func markdownPages(buffer []byte, layout, columns bool) ([]string, error) {
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+
+	lines := make([][]textLine, doc.NumPage())
+	for pageNum := range lines {
+		if lines[pageNum], err = doc.Lines(pageNum); err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", pageNum+1, err)
+		}
+		if layout || (columns && columnCount(lines[pageNum]) > 1) {
+			lines[pageNum] = readingOrder(lines[pageNum])
+		}
+	}
+
+	levels := headingLevels(lines)
+	pages := make([]string, len(lines))
+	for pageNum, pageLines := range lines {
+		pages[pageNum] = pageMarkdown(pageLines, levels)
+	}
+	return pages, nil
+}
+
+// headingLevels maps the font sizes, rounded to half points, that are larger than the body text
+// to heading levels, the largest size being level 1. The body text size is the size of most
+// characters in the document.
+func headingLevels(pages [][]textLine) map[float64]int {
+	var all []textLine
+	for _, lines := range pages {
+		all = append(all, lines...)
+	}
+	body := bodyFontSize(all)
+
+	sizes := make([]float64, 0)
+	for _, lines := range pages {
+		for _, line := range lines {
+			size := roundSize(line.FontSize)
+			if size >= body*headingScale && isHeadingText(line.Text) && !containsSize(sizes, size) {
+				sizes = append(sizes, size)
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sizes)))
+
+	levels := make(map[float64]int, len(sizes))
+	for i, size := range sizes {
+		levels[size] = min(i+1, maxHeadingLevel)
+	}
+	return levels
+}
+
+// pageMarkdown converts the lines of a page to Markdown, given the heading levels of font sizes
+func pageMarkdown(lines []textLine, levels map[float64]int) string {
+	tables := findTables(lines)
+
+	var blocks []string
+	var paragraph []string
+	var previous, heading *textLine
+	var itemLeft float64
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, strings.Join(paragraph, "\n"))
+			paragraph = nil
+		}
+	}
+
+	emitted := make(map[int]bool)
+	for i := range lines {
+		line := &lines[i]
+		if table, ok := tables[i]; ok {
+			if !emitted[table.id] {
+				emitted[table.id] = true
+				flush()
+				blocks = append(blocks, table.markdown())
+			}
+			heading, previous = nil, nil
+			continue
+		}
+
+		text := strings.TrimSpace(line.Text)
+		level, sized := levels[roundSize(line.FontSize)]
+		if !sized && line.Bold && isHeadingText(text) && !strings.HasSuffix(text, ":") {
+			// Bold lines of body size are the least significant headings
+			level, sized = maxHeadingLevel+1, true
+		}
+
+		switch {
+		case sized && isHeadingText(text):
+			marker := strings.Repeat("#", level) + " "
+			last := len(blocks) - 1
+			if heading != nil && len(paragraph) == 0 && strings.HasPrefix(blocks[last], marker) && !startsParagraph(heading, line) {
+				// Heading wrapped over several lines
+				blocks[last] += " " + text
+			} else {
+				flush()
+				blocks = append(blocks, marker+text)
+			}
+			heading, previous = line, nil
+			continue
+		case bulletPattern.MatchString(text) || numberedPattern.MatchString(text):
+			if len(paragraph) > 0 && !isListItem(paragraph[len(paragraph)-1]) {
+				flush()
+			}
+			if match := numberedPattern.FindStringSubmatch(text); match != nil {
+				paragraph = append(paragraph, match[1]+". "+match[2])
+			} else {
+				paragraph = append(paragraph, "- "+bulletPattern.FindStringSubmatch(text)[1])
+			}
+			itemLeft = line.Left
+		case previous != nil && !startsParagraph(previous, line):
+			last := len(paragraph) - 1
+			switch {
+			case last >= 0 && isListItem(paragraph[last]) && line.Left > itemLeft:
+				// Wrapped list item
+				paragraph[last] += " " + text
+			case last >= 0 && isListItem(paragraph[last]):
+				flush()
+				paragraph = append(paragraph, escapeMarkdownLine(text))
+			default:
+				paragraph = append(paragraph, escapeMarkdownLine(text))
+			}
+		default:
+			flush()
+			paragraph = append(paragraph, escapeMarkdownLine(text))
+		}
+		heading, previous = nil, line
+	}
+	flush()
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// startsParagraph reports whether a line starts a new paragraph after the previous line: when it
+// is separated by a large vertical gap or does not follow the previous line downwards
+func startsParagraph(previous, line *textLine) bool {
+	gap := line.Top - (previous.Top + previous.Height)
+	return gap < -previous.Height/2 || gap > previous.Height*paragraphGap
+}
+
+// markdownTable is a table found on a page, as rows of cells
+type markdownTable struct {
+	id   int
+	rows [][]string
+}
+
+// markdown formats the table with its first row as the header
+func (t *markdownTable) markdown() string {
+	var b strings.Builder
+	for i, row := range t.rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = markdownEscaper.Replace(strings.TrimSpace(cell))
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |")
+		if i == 0 {
+			b.WriteString("\n|" + strings.Repeat(" --- |", len(row)))
+		}
+		if i < len(t.rows)-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// findTables groups the lines of a page into rows sharing a baseline and finds runs of at least
+// two consecutive rows with the same number of cells in the same columns. It returns the table of
+// each line that belongs to one, by line index.
+func findTables(lines []textLine) map[int]*markdownTable {
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return lines[order[a]].Top < lines[order[b]].Top
+	})
+
+	// Group the lines into rows of cells ordered from left to right
+	var rows [][]int
+	for _, i := range order {
+		last := len(rows) - 1
+		if last >= 0 {
+			first := lines[rows[last][0]]
+			if math.Abs(lines[i].Top-first.Top) <= max(first.Height, lines[i].Height)/3 {
+				rows[last] = append(rows[last], i)
+				continue
+			}
+		}
+		rows = append(rows, []int{i})
+	}
+	for _, row := range rows {
+		sort.Slice(row, func(a, b int) bool { return lines[row[a]].Left < lines[row[b]].Left })
+	}
+
+	tables := make(map[int]*markdownTable)
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && len(rows[start]) > 1 && sameColumns(lines, rows[start], rows[end]) {
+			end++
+		}
+		if end-start >= 2 && isTable(lines, rows[start:end]) {
+			table := &markdownTable{id: start}
+			for _, row := range rows[start:end] {
+				cells := make([]string, len(row))
+				for j, i := range row {
+					cells[j] = lines[i].Text
+					tables[i] = table
+				}
+				table.rows = append(table.rows, cells)
+			}
+		}
+		start = end
+	}
+	return tables
+}
+
+// sameColumns reports whether a row has its cells in the columns of the header row. A column
+// extends halfway to its neighbors so that right-aligned numbers still fall in their column.
+func sameColumns(lines []textLine, header, row []int) bool {
+	if len(row) != len(header) {
+		return false
+	}
+	for j, i := range row {
+		left := lines[i].Left
+		if j > 0 && left < (lines[header[j-1]].Left+lines[header[j]].Left)/2 {
+			return false
+		}
+		if j < len(header)-1 && left >= (lines[header[j]].Left+lines[header[j+1]].Left)/2 {
+			return false
+		}
+	}
+	return true
+}
+
+// isTable reports whether aligned rows form a table rather than columns of running text
+func isTable(lines []textLine, rows [][]int) bool {
+	characters, cells := 0, 0
+	for _, row := range rows {
+		for _, i := range row {
+			characters += utf8.RuneCountInString(strings.TrimSpace(lines[i].Text))
+			cells++
+		}
+	}
+	return characters <= cells*maxTableCellLength
+}
+
+// isHeadingText reports whether a line is short enough to be a heading and does not read as a
+// sentence
+func isHeadingText(text string) bool {
+	text = strings.TrimSpace(text)
+	length := utf8.RuneCountInString(text)
+	return length > 0 && length <= maxHeadingLength && !strings.HasSuffix(text, ".")
+}
+
+// isListItem reports whether a Markdown line is a list item
+func isListItem(line string) bool {
+	return strings.HasPrefix(line, "- ") || numberedPattern.MatchString(line)
+}
+
+// escapeMarkdownLine escapes the characters that would turn a line of text into Markdown markup
+func escapeMarkdownLine(text string) string {
+	if strings.HasPrefix(text, "#") || strings.HasPrefix(text, ">") {
+		return `\` + text
+	}
+	return text
+}
+
+// roundSize rounds a font size to half points
+func roundSize(size float64) float64 {
+	return math.Round(size*2) / 2
+}
+
+// containsSize reports whether sizes contains size
+func containsSize(sizes []float64, size float64) bool {
+	for _, s := range sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// minPageText is the smallest text, in bytes, of a page read as text in mixed mode, unless the
+// text threshold is lower
+const minPageText = 20
+
+// DetectPageTypes tells, for the text of every page of a document, whether the page is read as
+// "text" or, when its text layer is missing, too short or garbage (see TextQuality), rendered as
+// "images". The thresholds come from the options.
+func DetectPageTypes(pages []string, options *types.ParseOptions) []string {
+	threshold, minQuality := minPageText, 0.0
+	if options != nil {
+		if options.TextThreshold > 0 {
+			threshold = min(threshold, options.TextThreshold)
+		}
+		minQuality = options.MinTextQuality
+	}
+
+	pageTypes := make([]string, len(pages))
+	for i, page := range pages {
+		if hasExtractableText(page, threshold) && !isGarbage(page, minQuality) {
+			pageTypes[i] = "text"
+		} else {
+			pageTypes[i] = "images"
+		}
+	}
+	return pageTypes
+}
+
+// parseMixedPdf completes parsed with the content of a document mixing text and scanned pages:
+// the text of the text pages and the images of the other pages
+func parseMixedPdf(buffer []byte, text string, pages, pageTypes []string, options *types.ParseOptions, parsed *types.ParsedPdf) (*types.ParsedPdf, error) {
+	text, pages, err := processText(buffer, text, pages, options)
+	if err != nil {
+		return nil, err
+	}
+
+	scanned := make([]int, 0, len(pageTypes))
+	for i, pageType := range pageTypes {
+		if pageType != "images" {
+			continue
+		}
+		scanned = append(scanned, i+1)
+		if i < len(pages) {
+			pages[i] = ""
+		}
+	}
+	start := time.Now()
+	images, failures, err := renderPdfPages(buffer, scanned, options, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert PDF to images: %w", err)
+	}
+	parsed.RenderDuration = time.Since(start)
+	parsed.FailedPages = failures
+	ocrText, ocrLayout, err := optionalOCRText(buffer, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.TextLayout {
+		parsed.Layout = withOCRLayout(parsed.Layout, ocrLayout)
+	}
+
+	parsed.Content = types.ParsedPdfContent{
+		Type:         "mixed",
+		TextContent:  strings.Join(pages, "\n") + "\n",
+		TextPages:    pages,
+		ImageContent: images,
+		PageTypes:    pageTypes,
+		OCRText:      ocrText,
+	}
+	return parsed, nil
+}
+
+// optionalOCRText recognizes the text of a document with the OCR backend of the options, if any,
+// and its layout when the backend reads it
+func optionalOCRText(buffer []byte, options *types.ParseOptions) (string, []types.PageLayout, error) {
+	if options == nil || options.OCRBackend == "" {
+		return "", nil, nil
+	}
+	text, layouts, err := recognizeText(buffer, options.OCRBackend)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to recognize text: %w", err)
+	}
+	return text, layouts, nil
+}
+
+// withOCRLayout fills the layout of the pages without text, such as scanned pages, with the layout
+// recognized by the OCR backend
+func withOCRLayout(layout, ocrLayout []types.PageLayout) []types.PageLayout {
+	for i, page := range ocrLayout {
+		switch {
+		case i >= len(layout):
+			layout = append(layout, page)
+		case len(layout[i].Lines) == 0:
+			layout[i] = page
+		}
+	}
+	return layout
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	inlineSpacePattern = regexp.MustCompile(`[ \t\x{00A0}\x{2000}-\x{200A}\x{202F}\x{3000}]+`)
+	blankLinesPattern  = regexp.MustCompile(`\n{3,}`)
+)
+
+// NormalizeText cleans up text read from a PDF text layer: words hyphenated at line breaks are
+// joined, sentences broken over several lines are rejoined, runs of spaces are collapsed and runs
+// of blank lines are reduced to one
+func NormalizeText(text string) string {
+	return normalizeText(text, false)
+}
+
+// normalizeText normalizes text, keeping the runs of spaces that align columns when keepAlignment
+// is set
+func normalizeText(text string, keepAlignment bool) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	// Soft hyphens only mark where words may be broken
+	text = strings.ReplaceAll(text, "\u00ad", "")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\u00a0")
+		if !keepAlignment {
+			line = inlineSpacePattern.ReplaceAllString(strings.TrimLeft(line, " \t\u00a0"), " ")
+		}
+		lines[i] = line
+	}
+
+	joined := make([]string, 0, len(lines))
+	for _, line := range lines {
+		last := len(joined) - 1
+		if last < 0 || keepAlignment {
+			joined = append(joined, line)
+			continue
+		}
+		previous := joined[last]
+		switch {
+		case endsWithHyphenatedWord(previous) && startsLowercase(line):
+			// "inter-" + "national" becomes "international"
+			joined[last] = previous[:len(previous)-1] + strings.TrimSpace(line)
+		case continuesSentence(previous) && startsLowercase(line):
+			joined[last] = previous + " " + strings.TrimSpace(line)
+		default:
+			joined = append(joined, line)
+		}
+	}
+
+	return blankLinesPattern.ReplaceAllString(strings.Join(joined, "\n"), "\n\n")
+}
+
+// endsWithHyphenatedWord reports whether a line ends with a word broken by a hyphen, such as
+// "inter-", rather than a dash or a compound word ending in a hyphen after a digit or a space
+func endsWithHyphenatedWord(line string) bool {
+	if !strings.HasSuffix(line, "-") || strings.HasSuffix(line, "--") {
+		return false
+	}
+	r, _ := utf8.DecodeLastRuneInString(line[:len(line)-1])
+	return unicode.IsLower(r)
+}
+
+// continuesSentence reports whether the sentence of a line may continue on the next line: it is
+// running text that does not end with a punctuation mark closing a sentence or a clause, nor is a
+// Markdown heading, list item or table row
+func continuesSentence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "|") || isListItem(trimmed) ||
+		bulletPattern.MatchString(trimmed) {
+		return false
+	}
+	r, _ := utf8.DecodeLastRuneInString(trimmed)
+	return !strings.ContainsRune(".!?:;。！？", r)
+}
+
+// startsLowercase reports whether a line starts with a lowercase letter
+func startsLowercase(line string) bool {
+	r, _ := utf8.DecodeRuneInString(strings.TrimSpace(line))
+	return unicode.IsLower(r)
+}
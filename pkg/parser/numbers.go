@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberPattern matches the numbers of a text with their thousands and decimal separators, spaces
+// excepted as they also separate numbers
+var numberPattern = regexp.MustCompile(`\d[\d.,'\x{00A0}\x{202F}]*`)
+
+// DetectDecimalSeparator returns the decimal separator of the numbers of a text, "," for documents
+// written as "1.234,56" and "." for documents written as "1,234.56" or without evidence of either.
+// Numbers with both separators, or with a separator repeated as thousands separators or followed by
+// one or two digits, tell the separator; numbers like "1.234" are ambiguous and left out.
+func DetectDecimalSeparator(text string) string {
+	votes := map[string]int{}
+	for _, number := range numberPattern.FindAllString(text, -1) {
+		if separator := decimalSeparatorOf(strings.TrimRight(number, ".,'\u00a0\u202f")); separator != "" {
+			votes[separator]++
+		}
+	}
+	if votes[","] > votes["."] {
+		return ","
+	}
+	return "."
+}
+
+// decimalSeparatorOf returns the decimal separator a number is written with, or "" when the number
+// does not tell
+func decimalSeparatorOf(number string) string {
+	dot, comma := strings.LastIndex(number, "."), strings.LastIndex(number, ",")
+	switch {
+	case dot >= 0 && comma >= 0 && dot > comma:
+		return "."
+	case dot >= 0 && comma >= 0:
+		return ","
+	case dot < 0 && comma < 0:
+		return ""
+	}
+	separator, other := ".", ","
+	if comma >= 0 {
+		separator, other = ",", "."
+	}
+	if strings.Count(number, separator) > 1 {
+		// Repeated separators group thousands
+		return other
+	}
+	if digits := len(number) - strings.LastIndex(number, separator) - 1; digits == 1 || digits == 2 {
+		return separator
+	}
+	return ""
+}
+
+// ParseNumber parses a number written with a decimal separator, "." or ",", and any thousands
+// separators, such as "1.234,56", "1'234.56" or "1 234,56 EUR". Currency symbols and codes are
+// ignored, and amounts in parentheses or with a leading or trailing minus are negative. A decimal
+// separator repeated in the number is read as a thousands separator.
+func ParseNumber(text, decimalSeparator string) (float64, error) {
+	if decimalSeparator != "." && decimalSeparator != "," {
+		return 0, fmt.Errorf("invalid decimal separator %q", decimalSeparator)
+	}
+	trimmed := strings.TrimSpace(text)
+	negative := strings.HasPrefix(trimmed, "(") && strings.HasSuffix(trimmed, ")")
+	var digits strings.Builder
+	for _, r := range trimmed {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case string(r) == decimalSeparator:
+			digits.WriteRune('.')
+		case r == '-' || r == '−':
+			negative = true
+		}
+	}
+	number := strings.TrimRight(digits.String(), ".")
+	if strings.Count(number, ".") > 1 {
+		number = strings.ReplaceAll(number, ".", "")
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || number == "" {
+		return 0, fmt.Errorf("invalid number %q", text)
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// ExtractNumbers returns the values of the numbers of a text written with a decimal separator, in
+// order, without their sign
+func ExtractNumbers(text, decimalSeparator string) []float64 {
+	var numbers []float64
+	for _, number := range numberPattern.FindAllString(text, -1) {
+		if value, err := ParseNumber(number, decimalSeparator); err == nil {
+			numbers = append(numbers, value)
+		}
+	}
+	return numbers
+}
@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// ExtractOutlineFromPath reads the outline (bookmarks) of a PDF file
+func ExtractOutlineFromPath(pdfPath string) ([]types.OutlineItem, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF from path: %w", err)
+	}
+
+	return ExtractOutlineFromBuffer(data)
+}
+
+// ExtractOutlineFromBuffer reads the outline (bookmarks) of a PDF buffer, the table of contents
+// shown by PDF viewers, with the page each entry points to. It returns nil when the PDF has no
+// outline.
+func ExtractOutlineFromBuffer(buffer []byte) ([]types.OutlineItem, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+
+	return doc.Outline()
+}
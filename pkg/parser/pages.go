@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// PageError reports a page a backend failed to read or render. A TextExtractor returns the pages
+// it read along with a PageErrors error for the others. A Renderer stops at the first page it
+// fails to render with a *PageError; unless the parse policy is strict, the page is then skipped
+// and the pages after it are rendered. Both are reported in ParsedPdf.FailedPages.
+type PageError struct {
+	// Page is the page number (1-indexed)
+	Page int
+	// Stage is what failed: types.PageStageText or types.PageStageRender
+	Stage string
+	// Err is the error of the backend
+	Err error
+}
+
+// Error describes the failure
+func (e *PageError) Error() string {
+	action := "read"
+	if e.Stage == types.PageStageRender {
+		action = "render"
+	}
+	return fmt.Sprintf("failed to %s page %d: %v", action, e.Page, e.Err)
+}
+
+// Unwrap returns the error of the backend
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// failure returns the page failure reported in the parsed PDF
+func (e *PageError) failure() types.PageFailure {
+	return types.PageFailure{Page: e.Page, Stage: e.Stage, Reason: e.Err.Error()}
+}
+
+// PageErrors reports the pages a TextExtractor failed to read
+type PageErrors []*PageError
+
+// Error lists the failures
+func (e PageErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, pageErr := range e {
+		messages[i] = pageErr.Error()
+	}
+	return strings.Join(messages, "; ")
+}
@@ -5,17 +5,20 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"image"
 	"image/png"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/gen2brain/go-fitz"
 	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
 	"github.com/pdfcpu/pdfcpu/pkg/api"
 )
 
 const (
 	defaultTextThreshold = 100
+	defaultDPI           = 300.0
 )
 
 // ParsePdfFromPath parses a PDF file from a file path and extracts its content
@@ -35,42 +38,142 @@ func ParsePdfFromBuffer(buffer []byte, options *types.ParseOptions) (*types.Pars
 		return nil, errors.New("invalid PDF: file does not contain PDF signature")
 	}
 
+	if options != nil {
+		switch options.ParsePolicy {
+		case "", types.ParsePolicyBestEffort, types.ParsePolicyStrict:
+		default:
+			return nil, fmt.Errorf("unknown parse policy %q", options.ParsePolicy)
+		}
+	}
+
 	threshold := defaultTextThreshold
 	if options != nil && options.TextThreshold > 0 {
 		threshold = options.TextThreshold
 	}
 
 	// Extract text and metadata
-	text, numPages, info, err := extractTextFromPdf(buffer)
+	text, pages, numPages, info, backendLayout, textFailures, err := extractTextFromPdf(buffer, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse PDF: %w", err)
 	}
 
+	var barcodes []types.Barcode
+	if options != nil && options.DecodeBarcodes {
+		barcodes, err = DecodeBarcodesFromBuffer(buffer, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode barcodes: %w", err)
+		}
+	}
+
+	var layout []types.PageLayout
+	if options != nil && options.TextLayout && backendLayout != nil {
+		layout = backendLayout
+	} else if options != nil && options.TextLayout {
+		layout, err = ExtractTextLayoutFromBuffer(buffer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read text layout: %w", err)
+		}
+	}
+
+	var outline []types.OutlineItem
+	if options != nil && options.Outline {
+		outline, err = ExtractOutlineFromBuffer(buffer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read outline: %w", err)
+		}
+	}
+	if options != nil && options.Annotations {
+		annotations, err := ExtractAnnotationsFromBuffer(buffer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read annotations: %w", err)
+		}
+		info[types.InfoAnnotations] = annotations
+	}
+	var links []types.Link
+	if options != nil && options.Links {
+		links, err = ExtractLinksFromBuffer(buffer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read links: %w", err)
+		}
+	}
+
 	// Check if PDF has extractable text
-	if hasExtractableText(text, threshold) {
+	forceImages := options != nil && options.ForceImages
+	minQuality := 0.0
+	if options != nil {
+		minQuality = options.MinTextQuality
+	}
+	forceText := options != nil && options.ForceText
+	if forceImages && forceText {
+		return nil, errors.New("ForceImages and ForceText are exclusive")
+	}
+	if forceText && strings.TrimSpace(text) == "" {
+		return nil, errors.New("PDF contains no text layer")
+	}
+	if !forceImages && !forceText && options != nil && options.MixedPages {
+		if pageTypes := DetectPageTypes(pages, options); slices.Contains(pageTypes, "text") && slices.Contains(pageTypes, "images") {
+			return parseMixedPdf(buffer, text, pages, pageTypes, options, &types.ParsedPdf{
+				NumPages: numPages,
+				Info:     info,
+				Barcodes: barcodes,
+				Layout:   layout,
+				Outline:  outline,
+				Links:    links,
+			})
+		}
+	}
+	// A corrupt or CID-mangled text layer can be long enough and still unreadable
+	if forceText || (!forceImages && hasExtractableText(text, threshold) && !isGarbage(text, minQuality)) {
+		text, pages, err = processText(buffer, text, pages, options)
+		if err != nil {
+			return nil, err
+		}
 		return &types.ParsedPdf{
 			Content: types.ParsedPdfContent{
 				Type:        "text",
 				TextContent: text,
+				TextPages:   pages,
 			},
-			NumPages: numPages,
-			Info:     info,
+			NumPages:    numPages,
+			Info:        info,
+			Barcodes:    barcodes,
+			Layout:      layout,
+			FailedPages: textFailures,
+			Outline:     outline,
+			Links:       links,
 		}, nil
 	}
 
 	// If no text, convert to images
-	images, err := convertPdfToImages(buffer)
+	start := time.Now()
+	images, renderFailures, err := renderPdfPages(buffer, nil, options, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert PDF to images: %w", err)
 	}
+	renderDuration := time.Since(start)
+
+	ocrText, ocrLayout, err := optionalOCRText(buffer, options)
+	if err != nil {
+		return nil, err
+	}
+	if options != nil && options.TextLayout {
+		layout = withOCRLayout(layout, ocrLayout)
+	}
 
 	return &types.ParsedPdf{
 		Content: types.ParsedPdfContent{
 			Type:         "images",
 			ImageContent: images,
+			OCRText:      ocrText,
 		},
-		NumPages: numPages,
-		Info:     info,
+		NumPages:       numPages,
+		Info:           info,
+		Barcodes:       barcodes,
+		Layout:         layout,
+		RenderDuration: renderDuration,
+		FailedPages:    renderFailures,
+		Outline:        outline,
+		Links:          links,
 	}, nil
 }
 
@@ -101,6 +204,46 @@ func isValidPdfSignature(buffer []byte) bool {
 	return string(buffer[0:4]) == "%PDF"
 }
 
+// processText converts, cleans and normalizes the text of a text-based PDF as set by the options
+func processText(buffer []byte, text string, pages []string, options *types.ParseOptions) (string, []string, error) {
+	var err error
+	switch {
+	case options != nil && options.Markdown:
+		pages, err = markdownPages(buffer, options.LayoutMode, options.ReadColumns)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to convert PDF to Markdown: %w", err)
+		}
+		text = strings.Join(pages, "\n") + "\n"
+	case options != nil && options.LayoutMode:
+		pages, err = layoutPages(buffer)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to extract PDF layout: %w", err)
+		}
+		text = strings.Join(pages, "\n") + "\n"
+	case options != nil && options.ReadColumns:
+		pages, err = columnPages(buffer, pages)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read PDF columns: %w", err)
+		}
+		text = strings.Join(pages, "\n") + "\n"
+	}
+	for i, page := range pages {
+		pages[i] = CleanUnicode(page)
+	}
+	text = CleanUnicode(text)
+	if options != nil && options.StripBoilerplate {
+		pages = StripBoilerplate(pages)
+		text = strings.Join(pages, "\n") + "\n"
+	}
+	if options != nil && options.NormalizeText {
+		for i, page := range pages {
+			pages[i] = normalizeText(page, options.LayoutMode && !options.Markdown)
+		}
+		text = strings.Join(pages, "\n") + "\n"
+	}
+	return text, pages, nil
+}
+
 // hasExtractableText detects if PDF has sufficient text content
 func hasExtractableText(text string, threshold int) bool {
 	if text == "" {
@@ -110,29 +253,53 @@ func hasExtractableText(text string, threshold int) bool {
 	return len(trimmedText) >= threshold
 }
 
-// extractTextFromPdf extracts text content and metadata from a PDF buffer
-func extractTextFromPdf(buffer []byte) (text string, numPages int, info map[string]interface{}, err error) {
+// extractTextFromPdf extracts text content, the text of each page and metadata from a PDF buffer
+// with the text backend selected by the options, along with the page layouts when the backend is
+// a LayoutExtractor and the pages it failed to read, unless the parse policy is strict
+func extractTextFromPdf(buffer []byte, options *types.ParseOptions) (text string, pages []string, numPages int, info map[string]interface{}, layouts []types.PageLayout, failures []types.PageFailure, err error) {
+	strict := options != nil && options.ParsePolicy == types.ParsePolicyStrict
+
 	// Get page count first using pdfcpu
 	numPages, err = getPageCount(buffer)
 	if err != nil {
+		if strict {
+			return "", nil, 0, nil, nil, nil, fmt.Errorf("failed to count pages: %w", err)
+		}
 		numPages = 1 // Default to 1 page if we can't determine
 	}
 
-	// Use go-fitz for reliable text extraction
+	// Use the text backend for reliable text extraction
 	// (pdfcpu's text extraction API requires file system operations which are more complex)
-	doc, err := fitz.NewFromMemory(buffer)
+	extractor, err := textExtractor(options)
 	if err != nil {
-		return "", numPages, make(map[string]interface{}), nil
+		return "", nil, numPages, nil, nil, nil, err
+	}
+	extracted, layouts, err := extractText(extractor, buffer)
+	var pageErrs PageErrors
+	if errors.As(err, &pageErrs) && extracted != nil && !strict {
+		// Keep the pages read, and report the others
+		for _, pageErr := range pageErrs {
+			failures = append(failures, pageErr.failure())
+		}
+		err = nil
+	}
+	if err != nil {
+		if strict || (options != nil && options.TextBackend != "") {
+			return "", nil, numPages, nil, nil, nil, fmt.Errorf("backend %s failed: %w", extractor.Name(), err)
+		}
+		// Documents the built-in backend can't open are rendered instead
+		return "", nil, numPages, make(map[string]interface{}), nil, nil, nil
+	}
+	if numPages < len(extracted) {
+		// The backend read pages pdfcpu could not count
+		numPages = len(extracted)
 	}
-	defer doc.Close()
 
 	// Extract text from all pages
 	var textBuilder strings.Builder
-	for pageNum := 0; pageNum < numPages; pageNum++ {
-		pageText, err := doc.Text(pageNum)
-		if err != nil {
-			continue
-		}
+	pages = make([]string, numPages)
+	copy(pages, extracted)
+	for _, pageText := range pages {
 		textBuilder.WriteString(pageText)
 		textBuilder.WriteString("\n")
 	}
@@ -140,7 +307,7 @@ func extractTextFromPdf(buffer []byte) (text string, numPages int, info map[stri
 	// Create empty info map
 	info = make(map[string]interface{})
 
-	return textBuilder.String(), numPages, info, nil
+	return textBuilder.String(), pages, numPages, info, layouts, failures, nil
 }
 
 // getPageCount returns the number of pages in a PDF using pdfcpu
@@ -162,48 +329,163 @@ func getPageCount(buffer []byte) (int, error) {
 	return 1, nil
 }
 
-// convertPdfToImages converts PDF pages to base64-encoded PNG images
-func convertPdfToImages(buffer []byte) ([]types.PdfPageImage, error) {
-	// Open PDF document using go-fitz
-	doc, err := fitz.NewFromMemory(buffer)
+// convertPdfToImages converts PDF pages to base64-encoded PNG images.
+// Only the given pages (1-indexed) are converted, or every page when pages is empty.
+func convertPdfToImages(buffer []byte, pages []int, options *types.ParseOptions) ([]types.PdfPageImage, error) {
+	images, _, err := renderPdfPages(buffer, pages, options, false)
+	return images, err
+}
+
+// renderPdfPages converts PDF pages to base64-encoded PNG images like convertPdfToImages. When
+// skipFailed is set and the parse policy is not strict, the pages the renderer fails to render are
+// skipped and returned as failures instead of failing the conversion.
+func renderPdfPages(buffer []byte, pages []int, options *types.ParseOptions, skipFailed bool) ([]types.PdfPageImage, []types.PageFailure, error) {
+	skipFailed = skipFailed && (options == nil || options.ParsePolicy != types.ParsePolicyStrict)
+	enhanceContrast := options != nil && options.EnhanceContrast
+
+	numPages, err := pageCount(buffer, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open PDF: %w", err)
+		return nil, nil, err
 	}
-	defer func(doc *fitz.Document) {
-		err := doc.Close()
-		if err != nil {
-			fmt.Printf("failed to close PDF document: %v\n", err)
-		}
-	}(doc)
-
-	numPages := doc.NumPage()
 	if numPages == 0 {
-		return nil, errors.New("PDF conversion produced no images")
+		return nil, nil, errors.New("PDF conversion produced no images")
 	}
 
-	images := make([]types.PdfPageImage, 0, numPages)
+	render, err := renderer(options)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Convert each page to image
-	for pageNum := 0; pageNum < numPages; pageNum++ {
-		// Render page as image at high DPI
-		img, err := doc.Image(pageNum)
-		if err != nil {
-			return nil, fmt.Errorf("failed to render page %d: %w", pageNum+1, err)
+	pageNums := make([]int, 0, numPages)
+	if len(pages) == 0 {
+		for page := 1; page <= numPages; page++ {
+			pageNums = append(pageNums, page)
+		}
+	} else {
+		for _, page := range pages {
+			if page < 1 || page > numPages {
+				return nil, nil, fmt.Errorf("page %d out of range: PDF has %d pages", page, numPages)
+			}
+			pageNums = append(pageNums, page)
+		}
+	}
+
+	cache := newPageCache(buffer, options)
+	var dpi float64
+	rendered := make(map[int]string, len(pageNums))
+	encode := func(pageNum int, img *image.RGBA) error {
+		var page image.Image = img
+		if enhanceContrast {
+			page = stretchContrast(img)
 		}
 
 		// Encode image to PNG and then to base64
 		var buf bytes.Buffer
-		if err := png.Encode(&buf, img); err != nil {
-			return nil, fmt.Errorf("failed to encode page %d as PNG: %w", pageNum+1, err)
+		if err := png.Encode(&buf, page); err != nil {
+			return fmt.Errorf("failed to encode page %d as PNG: %w", pageNum, err)
 		}
 
-		base64Str := base64.StdEncoding.EncodeToString(buf.Bytes())
+		rendered[pageNum] = base64.StdEncoding.EncodeToString(buf.Bytes())
+		cache.save(pageNum, dpi, buf.Bytes())
+		return nil
+	}
+
+	runs, err := renderRuns(buffer, pageNums, options)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		images = append(images, types.PdfPageImage{
-			Page:   pageNum + 1,
-			Base64: base64Str,
-		})
+	// Render each page as image at high DPI, rendering again the pages after a page that failed, and
+	// reusing the renders of the artifact store
+	var failures []types.PageFailure
+	for _, run := range runs {
+		cached, remaining := cache.load(run.pages, run.dpi)
+		for page, base64Str := range cached {
+			rendered[page] = base64Str
+		}
+		for dpi = run.dpi; len(remaining) > 0; {
+			err = render.Render(buffer, remaining, run.dpi, encode)
+			if err == nil {
+				break
+			}
+			var pageErr *PageError
+			if !skipFailed || !errors.As(err, &pageErr) || !slices.Contains(remaining, pageErr.Page) {
+				return nil, nil, err
+			}
+			failures = append(failures, pageErr.failure())
+			remaining = remaining[slices.Index(remaining, pageErr.Page)+1:]
+		}
+	}
+
+	images := make([]types.PdfPageImage, 0, len(rendered))
+	for _, pageNum := range pageNums {
+		if base64Str, ok := rendered[pageNum]; ok {
+			images = append(images, types.PdfPageImage{Page: pageNum, Base64: base64Str})
+		}
+	}
+	return images, failures, nil
+}
+
+// documentPageCount returns the number of pages of a PDF buffer, read by the built-in backend
+func documentPageCount(buffer []byte) (int, error) {
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+	return doc.NumPage(), nil
+}
+
+// stretchContrast converts a page render to grayscale and stretches its histogram so that the
+// darkest and lightest percentile map to black and white, which makes faint pen strokes stand out
+func stretchContrast(img *image.RGBA) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(bounds)
+
+	var histogram [256]int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			// ITU-R BT.601 luma
+			l := uint8((299*int(c.R) + 587*int(c.G) + 114*int(c.B)) / 1000)
+			gray.Pix[gray.PixOffset(x, y)] = l
+			histogram[l]++
+		}
+	}
+
+	// Find the 1st and 99th percentiles
+	total := bounds.Dx() * bounds.Dy()
+	low, high := 0, 255
+	for count := 0; low < 255; low++ {
+		count += histogram[low]
+		if count > total/100 {
+			break
+		}
+	}
+	for count := 0; high > 0; high-- {
+		count += histogram[high]
+		if count > total/100 {
+			break
+		}
+	}
+	if high <= low {
+		return gray
+	}
+
+	var lookup [256]uint8
+	for i := range lookup {
+		switch {
+		case i <= low:
+			lookup[i] = 0
+		case i >= high:
+			lookup[i] = 255
+		default:
+			lookup[i] = uint8((i - low) * 255 / (high - low))
+		}
+	}
+	for i, l := range gray.Pix {
+		gray.Pix[i] = lookup[l]
 	}
 
-	return images, nil
+	return gray
 }
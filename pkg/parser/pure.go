@@ -0,0 +1,184 @@
+//go:build nomupdf
+
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/ledongthuc/pdf"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// defaultBackend names the built-in backend, which only reads the text of PDFs
+const defaultBackend = "gopdf"
+
+func init() {
+	RegisterBackend(documentTextBackend{name: defaultBackend})
+}
+
+// pureDocument reads the text layer of a PDF document in pure Go, so that the library builds with
+// CGO_ENABLED=0. It cannot render pages or decode embedded images.
+type pureDocument struct {
+	reader *pdf.Reader
+	buffer []byte
+}
+
+// openDocument opens a PDF buffer with the pure-Go reader
+func openDocument(buffer []byte) (doc document, err error) {
+	defer recoverPdf(&err)
+	reader, err := pdf.NewReader(bytes.NewReader(buffer), int64(len(buffer)))
+	if err != nil {
+		return nil, err
+	}
+	return &pureDocument{reader: reader, buffer: buffer}, nil
+}
+
+// NumPage returns the number of pages
+func (d *pureDocument) NumPage() int {
+	return d.reader.NumPage()
+}
+
+// Text returns the lines of a page, one per line
+func (d *pureDocument) Text(pageNum int) (string, error) {
+	lines, err := d.Lines(pageNum)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(line.Text)
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// Lines groups the glyphs of a page into lines
+func (d *pureDocument) Lines(pageNum int) ([]textLine, error) {
+	width, height, glyphs, err := d.Glyphs(pageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups [][]glyph
+	for i := range glyphs {
+		if i == 0 || startsLine(&glyphs[i-1], &glyphs[i]) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], glyphs[i])
+	}
+
+	lines := make([]textLine, 0, len(groups))
+	for _, group := range groups {
+		bold, plain := false, false
+		for _, g := range group {
+			if strings.TrimSpace(g.text) != "" {
+				bold, plain = bold || g.bold, plain || !g.bold
+			}
+		}
+		// Blank lines have no layout
+		for _, line := range pageLayout(width, height, group).Lines {
+			lines = append(lines, textLine{
+				Text:     line.Text,
+				Top:      line.Bounds.Y,
+				Left:     line.Bounds.X,
+				Height:   line.Bounds.Height,
+				FontSize: line.Bounds.Height / (glyphAscent + glyphDescent),
+				Bold:     bold && !plain,
+			})
+		}
+	}
+	return lines, nil
+}
+
+// Glyphs reads the glyphs drawn by the content stream of a page
+func (d *pureDocument) Glyphs(pageNum int) (width, height float64, glyphs []glyph, err error) {
+	defer recoverPdf(&err)
+	page := d.reader.Page(pageNum + 1)
+	if page.V.IsNull() {
+		return 0, 0, nil, fmt.Errorf("page %d not found", pageNum+1)
+	}
+
+	box := mediaBox(page)
+	left, bottom := box.Index(0).Float64(), box.Index(1).Float64()
+	width, height = box.Index(2).Float64()-left, box.Index(3).Float64()-bottom
+
+	glyphs = make([]glyph, 0)
+	for _, text := range page.Content().Text {
+		if text.FontSize <= 0 {
+			continue
+		}
+		g := glyph{
+			text:  text.S,
+			x:     text.X - left,
+			y:     height - (text.Y - bottom),
+			size:  text.FontSize,
+			right: text.W,
+			bold:  strings.Contains(text.Font, "Bold"),
+		}
+		if g.right <= 0 && strings.TrimSpace(g.text) != "" {
+			// Fonts without widths, such as the standard fonts, have no advance
+			g.right = text.FontSize * averageCharWidth
+		}
+		glyphs = append(glyphs, g)
+	}
+	return width, height, glyphs, nil
+}
+
+// Images is not supported without MuPDF
+func (d *pureDocument) Images(int) ([]types.PdfEmbeddedImage, error) {
+	return nil, ErrRenderingUnavailable
+}
+
+// Render is not supported without MuPDF
+func (d *pureDocument) Render(int, float64) (*image.RGBA, error) {
+	return nil, ErrRenderingUnavailable
+}
+
+// Outline reads the bookmarks with pdfcpu, as the pure-Go reader does not resolve their pages
+func (d *pureDocument) Outline() ([]types.OutlineItem, error) {
+	bookmarks, err := api.Bookmarks(bytes.NewReader(d.buffer), nil)
+	if err != nil {
+		return nil, err
+	}
+	return bookmarkTree(bookmarks), nil
+}
+
+// bookmarkTree converts pdfcpu bookmarks to outline items
+func bookmarkTree(bookmarks []pdfcpu.Bookmark) []types.OutlineItem {
+	var items []types.OutlineItem
+	for _, bookmark := range bookmarks {
+		items = append(items, types.OutlineItem{
+			Title:    strings.TrimSpace(bookmark.Title),
+			Page:     max(bookmark.PageFrom, 0),
+			Children: bookmarkTree(bookmark.Kids),
+		})
+	}
+	return items
+}
+
+// Close releases the document
+func (d *pureDocument) Close() error {
+	return nil
+}
+
+// mediaBox returns the media box of a page, which may be inherited from its ancestors
+func mediaBox(page pdf.Page) pdf.Value {
+	for v := page.V; !v.IsNull(); v = v.Key("Parent") {
+		if box := v.Key("MediaBox"); !box.IsNull() {
+			return box
+		}
+	}
+	return pdf.Value{}
+}
+
+// recoverPdf turns the panics the pure-Go reader raises on malformed documents into an error
+func recoverPdf(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("malformed PDF: %v", r)
+	}
+}
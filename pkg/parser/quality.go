@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// defaultMinTextQuality is the TextQuality below which a text layer is considered garbage
+	defaultMinTextQuality = 0.5
+	// minQualityRunes is the smallest text, in non-space characters, whose quality is judged
+	minQualityRunes = 40
+	// minQualityWords is the smallest number of Latin words whose vowels and stopwords are counted
+	minQualityWords = 10
+	// minEntropyRunes is the smallest text, in non-space characters, whose entropy is measured
+	minEntropyRunes = 200
+)
+
+// stopwords are frequent short words of the most common languages written in the Latin script
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`
+		the of and to in is it for on that with as by at be this are from or an was not
+		de la el en los las del que por con una para es al lo se su
+		le les des du et un une est dans pour pas qui sur au
+		der die das und ist den dem von zu mit sich des auf für nicht ein eine im
+		il di che per non della sono alla gli
+		do da em os um uma não na no`) {
+		stopwords[word] = true
+	}
+}
+
+// TextQuality scores how much a text layer looks like natural language, from 0 (garbage) to 1,
+// to tell apart corrupt or CID-mangled text layers that are long enough but unreadable. The score
+// is the lowest of:
+//   - the share of characters that are not replacement, control or private use characters;
+//   - the share of letters, digits and common punctuation among the characters;
+//   - for Latin text, the share of stopwords among the words or of vowels and frequent letters
+//     among the letters;
+//   - for long Latin text, how far the character entropy is from that of random characters.
+//
+// Texts too short to judge score 1.
+func TextQuality(text string) float64 {
+	var total, invalid, symbols, letters, latin, vowels, frequent int
+	frequencies := make(map[rune]int)
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		frequencies[r]++
+		switch {
+		case r == unicode.ReplacementChar || unicode.IsControl(r) || unicode.Is(unicode.Co, r) || !unicode.IsPrint(r):
+			invalid++
+		case unicode.IsLetter(r):
+			letters++
+			if unicode.Is(unicode.Latin, r) {
+				latin++
+				switch base := unicode.ToLower(baseLetter(r)); {
+				case strings.ContainsRune("aeiou", base):
+					vowels++
+					frequent++
+				case strings.ContainsRune("nrstl", base):
+					frequent++
+				}
+			}
+		case unicode.IsDigit(r) || strings.ContainsRune(`.,;:!?'"()-–—/%&€$£@#*+=[]«»‘’“”…`, r):
+		default:
+			symbols++
+		}
+	}
+	if total < minQualityRunes {
+		return 1
+	}
+
+	quality := 1 - float64(invalid)/float64(total)
+	// Natural text is at least 90% letters, digits and punctuation
+	quality = min(quality, scale(1-float64(symbols)/float64(total), 0.6, 0.9))
+
+	// The remaining signals only hold for text mostly written in the Latin script
+	if latin*2 < letters {
+		return max(0, quality)
+	}
+
+	var words, common int
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		words++
+		if stopwords[word] {
+			common++
+		}
+	}
+	if words >= minQualityWords {
+		// Stopwords are over a fifth of the words of natural text. In other languages, vowels are
+		// over a third of the letters and the most frequent letters of Latin scripts over 60%;
+		// shifted or mangled characters keep neither.
+		wordScore := scale(float64(common)/float64(words), 0.02, 0.08)
+		vowelScore := scale(float64(vowels)/float64(latin), 0.22, 0.3)
+		letterScore := scale(float64(frequent)/float64(latin), 0.5, 0.6)
+		quality = min(quality, max(wordScore, min(vowelScore, letterScore)))
+	}
+
+	if total >= minEntropyRunes {
+		entropy := 0.0
+		for _, count := range frequencies {
+			p := float64(count) / float64(total)
+			entropy -= p * math.Log2(p)
+		}
+		// Latin text is about 4.5 bits per character, random printable ASCII about 6.5
+		quality = min(quality, scale(6.2-entropy, 0, 0.7))
+	}
+	return max(0, quality)
+}
+
+// isGarbage reports whether a text layer scores below the minimum quality of the options
+func isGarbage(text string, minQuality float64) bool {
+	if minQuality < 0 {
+		return false
+	}
+	if minQuality == 0 {
+		minQuality = defaultMinTextQuality
+	}
+	return TextQuality(text) < minQuality
+}
+
+// baseLetter returns the unaccented letter of the common accented Latin letters
+func baseLetter(r rune) rune {
+	const accented, base = "àáâãäåèéêëìíîïòóôõöùúûü", "aaaaaaeeeeiiiiooooouuuu"
+	if i := strings.IndexRune(accented, unicode.ToLower(r)); i >= 0 {
+		return []rune(base)[len([]rune(accented[:i]))]
+	}
+	return r
+}
+
+// scale maps value linearly from [low, high] to [0, 1], clamped
+func scale(value, low, high float64) float64 {
+	return max(0, min(1, (value-low)/(high-low)))
+}
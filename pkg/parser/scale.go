@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+const (
+	// minBudgetDPI is the lowest resolution pages are rendered at with a pixel budget, below which
+	// the text of large pages is no longer legible
+	minBudgetDPI = 72.0
+	// maxBudgetDPI is the highest resolution pages are rendered at with a pixel budget, above which
+	// small pages gain no detail
+	maxBudgetDPI = 600.0
+)
+
+// renderRun is a run of consecutive pages rendered at the same resolution
+type renderRun struct {
+	pages []int
+	dpi   float64
+}
+
+// renderRuns splits the pages to render into runs of the same resolution, keeping their order: the
+// DPI of the options, or the resolution of each page within the pixel budget of the options
+func renderRuns(buffer []byte, pages []int, options *types.ParseOptions) ([]renderRun, error) {
+	dpi := defaultDPI
+	if options != nil && options.DPI > 0 {
+		dpi = options.DPI
+	}
+	if options == nil || options.PixelBudget <= 0 {
+		return []renderRun{{pages: pages, dpi: dpi}}, nil
+	}
+
+	dims, err := api.PageDims(bytes.NewReader(buffer), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page dimensions: %w", err)
+	}
+	var runs []renderRun
+	for _, page := range pages {
+		pageDPI := dpi
+		if page <= len(dims) {
+			pageDPI = budgetDPI(dims[page-1].Width, dims[page-1].Height, options.PixelBudget)
+		}
+		if len(runs) > 0 && runs[len(runs)-1].dpi == pageDPI {
+			runs[len(runs)-1].pages = append(runs[len(runs)-1].pages, page)
+			continue
+		}
+		runs = append(runs, renderRun{pages: []int{page}, dpi: pageDPI})
+	}
+	return runs, nil
+}
+
+// budgetDPI returns the resolution, in whole DPI, at which a page of the given dimensions in points
+// takes about budget pixels, between minBudgetDPI and maxBudgetDPI
+func budgetDPI(width, height float64, budget int) float64 {
+	if width <= 0 || height <= 0 {
+		return defaultDPI
+	}
+	// A point is 1/72 inch
+	dpi := math.Floor(math.Sqrt(float64(budget)/(width*height)) * 72)
+	return min(max(dpi, minBudgetDPI), maxBudgetDPI)
+}
@@ -0,0 +1,373 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// textLayerFont is the resource name of the font of the invisible text layer
+const textLayerFont = "FOCR"
+
+// AddTextLayer returns a copy of a PDF buffer with an invisible text layer drawn over its pages,
+// which makes scanned documents searchable and their text selectable. The lines of each layout are
+// drawn on the layout's page at their bounds; bounds are measured from the top-left corner of the
+// page as displayed, in units of the layout's Width and Height, which are scaled to the page size
+// (e.g. a Width and Height of 1 for bounds given as fractions of the page). The page content is
+// left unchanged.
+func AddTextLayer(buffer []byte, layouts []types.PageLayout) ([]byte, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	ctx, err := api.ReadAndValidate(bytes.NewReader(buffer), model.NewDefaultConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, fmt.Errorf("failed to read PDF pages: %w", err)
+	}
+
+	var font *pdftypes.IndirectRef
+	for _, layout := range layouts {
+		if len(layout.Lines) == 0 {
+			continue
+		}
+		if layout.Page < 1 || layout.Page > ctx.PageCount {
+			return nil, fmt.Errorf("page %d out of range: PDF has %d pages", layout.Page, ctx.PageCount)
+		}
+		if layout.Width <= 0 || layout.Height <= 0 {
+			return nil, fmt.Errorf("page %d: layout has no size", layout.Page)
+		}
+		if font == nil {
+			if font, err = addTextLayerFont(ctx.XRefTable); err != nil {
+				return nil, fmt.Errorf("failed to add text layer font: %w", err)
+			}
+		}
+
+		pageDict, _, inherited, err := ctx.PageDict(layout.Page, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", layout.Page, err)
+		}
+		box := inherited.CropBox
+		if box == nil {
+			box = inherited.MediaBox
+		}
+		if box == nil {
+			return nil, fmt.Errorf("page %d has no media box", layout.Page)
+		}
+
+		if err := addPageFont(ctx.XRefTable, pageDict, inherited.Resources, *font); err != nil {
+			return nil, fmt.Errorf("failed to add text layer to page %d: %w", layout.Page, err)
+		}
+		content := textLayerContent(layout, box, inherited.Rotate)
+		if err := wrapPageContent(ctx.XRefTable, pageDict, content); err != nil {
+			return nil, fmt.Errorf("failed to add text layer to page %d: %w", layout.Page, err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := api.WriteContext(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// textLayerContent draws the lines of a layout as invisible text, each line stretched over the
+// width of its bounds
+func textLayerContent(layout types.PageLayout, box *pdftypes.Rectangle, rotate int) []byte {
+	// The origin and axes of the page as displayed, in default user space
+	width, height := box.Width(), box.Height()
+	var origin, right, down [2]float64
+	switch ((rotate % 360) + 360) % 360 {
+	case 90:
+		origin, right, down = [2]float64{box.LL.X, box.LL.Y}, [2]float64{0, 1}, [2]float64{1, 0}
+		width, height = height, width
+	case 180:
+		origin, right, down = [2]float64{box.UR.X, box.LL.Y}, [2]float64{-1, 0}, [2]float64{0, 1}
+	case 270:
+		origin, right, down = [2]float64{box.UR.X, box.UR.Y}, [2]float64{0, -1}, [2]float64{-1, 0}
+		width, height = height, width
+	default:
+		origin, right, down = [2]float64{box.LL.X, box.UR.Y}, [2]float64{1, 0}, [2]float64{0, -1}
+	}
+	scaleX, scaleY := width/layout.Width, height/layout.Height
+
+	var b strings.Builder
+	// Render mode 3 draws neither fill nor stroke
+	b.WriteString("BT 3 Tr\n")
+	for _, line := range layout.Lines {
+		text := strings.TrimSpace(line.Text)
+		characters := utf8.RuneCountInString(text)
+		if characters == 0 || line.Bounds.Width <= 0 || line.Bounds.Height <= 0 {
+			continue
+		}
+		size := line.Bounds.Height * scaleY
+		lineWidth := line.Bounds.Width * scaleX
+		// The font advances every glyph by half an em
+		stretch := lineWidth / (float64(characters) * size * averageCharWidth) * 100
+
+		u := line.Bounds.X * scaleX
+		v := (line.Bounds.Y + line.Bounds.Height*glyphAscent) * scaleY
+		x := origin[0] + u*right[0] + v*down[0]
+		y := origin[1] + u*right[1] + v*down[1]
+		fmt.Fprintf(&b, "/%s %.2f Tf %.2f Tz %.4f %.4f %.4f %.4f %.2f %.2f Tm <%s> Tj\n",
+			textLayerFont, size, stretch, right[0], right[1], -down[0], -down[1], x, y, encodeUTF16(text))
+	}
+	b.WriteString("ET")
+	return []byte(b.String())
+}
+
+// encodeUTF16 encodes text as hexadecimal two-byte codes of the text layer font, which are the
+// Unicode code points of the basic multilingual plane
+func encodeUTF16(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r > 0xFFFF || (r >= 0xD800 && r <= 0xDFFF) {
+			r = utf8.RuneError
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
+
+// addTextLayerFont adds the font of the text layer: an embedded composite font without visible
+// glyphs whose codes map to Unicode through its ToUnicode map, so that any text can be searched
+// and copied
+func addTextLayerFont(xRefTable *model.XRefTable) (*pdftypes.IndirectRef, error) {
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// Ranges may not cross a change of their first byte, and a block holds at most 100 ranges
+	for start := 0; start < 256; start += 100 {
+		end := min(start+100, 256)
+		fmt.Fprintf(&cmap, "%d beginbfrange\n", end-start)
+		for high := start; high < end; high++ {
+			fmt.Fprintf(&cmap, "<%02X00> <%02XFF> <%02X00>\n", high, high, high)
+		}
+		cmap.WriteString("endbfrange\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+
+	toUnicode, err := xRefTable.NewStreamDictForBuf([]byte(cmap.String()))
+	if err != nil {
+		return nil, err
+	}
+	if err := toUnicode.Encode(); err != nil {
+		return nil, err
+	}
+	toUnicodeRef, err := xRefTable.IndRefForNewObject(*toUnicode)
+	if err != nil {
+		return nil, err
+	}
+
+	fontFile, err := xRefTable.NewStreamDictForBuf(glyphLessFont())
+	if err != nil {
+		return nil, err
+	}
+	fontFile.InsertInt("Length1", len(fontFile.Content))
+	if err := fontFile.Encode(); err != nil {
+		return nil, err
+	}
+	fontFileRef, err := xRefTable.IndRefForNewObject(*fontFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every code maps to the only glyph of the font
+	cidToGID, err := xRefTable.NewStreamDictForBuf(make([]byte, 2*0x10000))
+	if err != nil {
+		return nil, err
+	}
+	if err := cidToGID.Encode(); err != nil {
+		return nil, err
+	}
+	cidToGIDRef, err := xRefTable.IndRefForNewObject(*cidToGID)
+	if err != nil {
+		return nil, err
+	}
+
+	descendant := pdftypes.Dict{
+		"Type":     pdftypes.Name("Font"),
+		"Subtype":  pdftypes.Name("CIDFontType2"),
+		"BaseFont": pdftypes.Name("GlyphLessFont"),
+		"CIDSystemInfo": pdftypes.Dict{
+			"Registry":   pdftypes.StringLiteral("Adobe"),
+			"Ordering":   pdftypes.StringLiteral("Identity"),
+			"Supplement": pdftypes.Integer(0),
+		},
+		"FontDescriptor": pdftypes.Dict{
+			"Type":        pdftypes.Name("FontDescriptor"),
+			"FontName":    pdftypes.Name("GlyphLessFont"),
+			"Flags":       pdftypes.Integer(5),
+			"FontBBox":    pdftypes.NewNumberArray(0, -200, 500, 800),
+			"ItalicAngle": pdftypes.Integer(0),
+			"Ascent":      pdftypes.Integer(800),
+			"Descent":     pdftypes.Integer(-200),
+			"CapHeight":   pdftypes.Integer(700),
+			"StemV":       pdftypes.Integer(80),
+			"FontFile2":   *fontFileRef,
+		},
+		"CIDToGIDMap": *cidToGIDRef,
+		"DW":          pdftypes.Integer(int(averageCharWidth * 1000)),
+	}
+	return xRefTable.IndRefForNewObject(pdftypes.Dict{
+		"Type":            pdftypes.Name("Font"),
+		"Subtype":         pdftypes.Name("Type0"),
+		"BaseFont":        pdftypes.Name("GlyphLessFont"),
+		"Encoding":        pdftypes.Name("Identity-H"),
+		"DescendantFonts": pdftypes.Array{descendant},
+		"ToUnicode":       *toUnicodeRef,
+	})
+}
+
+// addPageFont gives a page its own resources with the text layer font added to the resources in
+// effect, which may be shared with other pages or inherited from the page tree
+func addPageFont(xRefTable *model.XRefTable, pageDict, inherited pdftypes.Dict, font pdftypes.IndirectRef) error {
+	resources := pdftypes.NewDict()
+	if inherited != nil {
+		resources = inherited.Clone().(pdftypes.Dict)
+	}
+
+	fonts := pdftypes.NewDict()
+	if obj, found := resources.Find("Font"); found {
+		existing, err := xRefTable.DereferenceDict(obj)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			fonts = existing.Clone().(pdftypes.Dict)
+		}
+	}
+	fonts[textLayerFont] = font
+	resources["Font"] = fonts
+	pageDict["Resources"] = resources
+	return nil
+}
+
+// wrapPageContent draws content over a page, after its own content which is isolated in a saved
+// graphics state so that it cannot affect the position of the added content
+func wrapPageContent(xRefTable *model.XRefTable, pageDict pdftypes.Dict, content []byte) error {
+	newStream := func(data []byte) (*pdftypes.IndirectRef, error) {
+		sd, err := xRefTable.NewStreamDictForBuf(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := sd.Encode(); err != nil {
+			return nil, err
+		}
+		return xRefTable.IndRefForNewObject(*sd)
+	}
+
+	contents := pdftypes.Array{}
+	if obj, found := pageDict.Find("Contents"); found {
+		switch existing := obj.(type) {
+		case pdftypes.IndirectRef:
+			// A reference to a stream or to an array of streams
+			resolved, err := xRefTable.Dereference(existing)
+			if err != nil {
+				return err
+			}
+			if streams, ok := resolved.(pdftypes.Array); ok {
+				contents = append(contents, streams...)
+			} else {
+				contents = append(contents, existing)
+			}
+		case pdftypes.Array:
+			contents = append(contents, existing...)
+		case pdftypes.StreamDict:
+			ref, err := xRefTable.IndRefForNewObject(existing)
+			if err != nil {
+				return err
+			}
+			contents = append(contents, *ref)
+		}
+	}
+
+	save, err := newStream([]byte("q"))
+	if err != nil {
+		return err
+	}
+	restore, err := newStream(append([]byte("Q\n"), content...))
+	if err != nil {
+		return err
+	}
+	pageDict["Contents"] = append(append(pdftypes.Array{*save}, contents...), *restore)
+	return nil
+}
+
+// glyphLessFont builds a minimal TrueType font whose only glyph is empty and half an em wide, so
+// that the text layer font can be embedded without drawing anything
+func glyphLessFont() []byte {
+	u16 := func(values ...int) []byte {
+		b := make([]byte, 0, 2*len(values))
+		for _, v := range values {
+			b = append(b, byte(uint16(v)>>8), byte(uint16(v)))
+		}
+		return b
+	}
+	em, advance := 1000, int(averageCharWidth*1000)
+	ascent, descent := int(glyphAscent*1000), -int(glyphDescent*1000)
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{
+		// A single segment mapping every character to the empty glyph
+		{"cmap", u16(0, 1, 3, 1, 0, 12, 4, 24, 0, 2, 2, 0, 0, 0xFFFF, 0, 0xFFFF, 1, 0)},
+		{"glyf", nil},
+		{"head", append(u16(1, 0, 1, 0, 0, 0, 0x5F0F, 0x3CF5, 0x000B, em, 0, 0, 0, 0, 0, 0, 0, 0),
+			u16(0, descent, advance, ascent, 0, 3, 2, 0, 0)...)},
+		{"hhea", u16(1, 0, ascent, descent, 0, advance, 0, 0, advance, 1, 0, 0, 0, 0, 0, 0, 0, 1)},
+		{"hmtx", u16(advance, 0)},
+		{"loca", u16(0, 0)},
+		{"maxp", u16(1, 0, 1, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0)},
+		{"name", u16(0, 0, 6)},
+		{"post", u16(3, 0, 0, 0, -100, 50, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0)},
+	}
+
+	checksum := func(data []byte) uint32 {
+		var sum uint32
+		for i := 0; i < len(data); i += 4 {
+			var word [4]byte
+			copy(word[:], data[i:])
+			sum += uint32(word[0])<<24 | uint32(word[1])<<16 | uint32(word[2])<<8 | uint32(word[3])
+		}
+		return sum
+	}
+
+	font := u16(1, 0, len(tables), 128, 3, len(tables)*16-128)
+	offset := len(font) + 16*len(tables)
+	var data []byte
+	headOffset := 0
+	for _, table := range tables {
+		sum := checksum(table.data)
+		font = append(font, table.tag...)
+		font = append(font, byte(sum>>24), byte(sum>>16), byte(sum>>8), byte(sum))
+		font = append(font, u16(offset>>16, offset, len(table.data)>>16, len(table.data))...)
+		if table.tag == "head" {
+			headOffset = offset
+		}
+		padded := append(append([]byte(nil), table.data...), make([]byte, (4-len(table.data)%4)%4)...)
+		data = append(data, padded...)
+		offset += len(padded)
+	}
+	font = append(font, data...)
+
+	// The checksum adjustment makes the checksum of the whole font a fixed value
+	adjustment := 0xB1B0AFBA - checksum(font)
+	font[headOffset+8] = byte(adjustment >> 24)
+	font[headOffset+9] = byte(adjustment >> 16)
+	font[headOffset+10] = byte(adjustment >> 8)
+	font[headOffset+11] = byte(adjustment)
+	return font
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// tileOverlap is the share of a tile overlapping its neighbours, so that text cut by the edge of a
+// tile is whole in the next one
+const tileOverlap = 0.1
+
+// TilePageImage splits a rendered page larger than tileSize pixels on a side into overlapping tiles
+// of at most tileSize pixels, in reading order: left to right, then top to bottom. A page that fits
+// is returned as a single tile covering it.
+func TilePageImage(page types.PdfPageImage, tileSize int) ([]types.PageTile, error) {
+	if tileSize <= 0 {
+		return nil, errors.New("tile size must be positive")
+	}
+	raw, err := base64.StdEncoding.DecodeString(page.Base64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode page %d image: %w", page.Page, err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode page %d image: %w", page.Page, err)
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= tileSize && height <= tileSize {
+		return []types.PageTile{{Page: page.Page, Region: types.Rect{Width: 1, Height: 1}, Base64: page.Base64}}, nil
+	}
+	subImager, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, fmt.Errorf("page %d image cannot be cropped", page.Page)
+	}
+
+	columns, rows := tileOffsets(width, tileSize), tileOffsets(height, tileSize)
+	tiles := make([]types.PageTile, 0, len(columns)*len(rows))
+	for _, y := range rows {
+		for _, x := range columns {
+			rect := image.Rect(x, y, min(x+tileSize, width), min(y+tileSize, height)).Add(bounds.Min)
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, subImager.SubImage(rect)); err != nil {
+				return nil, fmt.Errorf("failed to encode tile of page %d as PNG: %w", page.Page, err)
+			}
+			tiles = append(tiles, types.PageTile{
+				Page: page.Page,
+				Region: types.Rect{
+					X:      float64(x) / float64(width),
+					Y:      float64(y) / float64(height),
+					Width:  float64(rect.Dx()) / float64(width),
+					Height: float64(rect.Dy()) / float64(height),
+				},
+				Base64: base64.StdEncoding.EncodeToString(buf.Bytes()),
+			})
+		}
+	}
+	return tiles, nil
+}
+
+// tileOffsets returns the offsets of the tiles covering a side of the given length, spread evenly
+// so that neighbouring tiles overlap by at least tileOverlap
+func tileOffsets(length, tileSize int) []int {
+	if length <= tileSize {
+		return []int{0}
+	}
+	step := float64(tileSize) * (1 - tileOverlap)
+	count := int(math.Ceil(float64(length-tileSize)/step)) + 1
+	offsets := make([]int, count)
+	for i := range offsets {
+		offsets[i] = int(math.Round(float64(i) * float64(length-tileSize) / float64(count-1)))
+	}
+	return offsets
+}
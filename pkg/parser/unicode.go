@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ligatureReplacer expands the Latin typographic ligatures that fonts map to single characters
+var ligatureReplacer = strings.NewReplacer(
+	"ﬀ", "ff",
+	"ﬁ", "fi",
+	"ﬂ", "fl",
+	"ﬃ", "ffi",
+	"ﬄ", "ffl",
+	"ﬅ", "st",
+	"ﬆ", "st",
+	"Ĳ", "IJ",
+	"ĳ", "ij",
+)
+
+// CleanUnicode cleans up text read from a PDF so that extracted values such as names and emails do
+// not contain invisible characters: ligatures are expanded, invisible formatting characters (soft
+// hyphens, zero-width spaces, byte order marks, directional marks) and control characters other
+// than tabs and line breaks are removed, Unicode spaces become plain spaces, and the text is
+// normalized to NFC so that accented letters have a single encoding
+func CleanUnicode(text string) string {
+	text = ligatureReplacer.Replace(text)
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == '\u200c' || r == '\u200d':
+			// Joiners are meaningful in Persian, Indic scripts and emoji sequences
+			return r
+		case unicode.Is(unicode.Zs, r):
+			return ' '
+		case unicode.Is(unicode.Cf, r) || unicode.IsControl(r):
+			return -1
+		default:
+			return r
+		}
+	}, text)
+	return norm.NFC.String(text)
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// currencySymbols are the ISO 4217 codes of currency symbols. Symbols shared by several currencies,
+// such as "kr", are left out.
+var currencySymbols = map[string]string{
+	"€": "EUR", "£": "GBP", "¥": "JPY", "₹": "INR", "₽": "RUB", "₩": "KRW", "₺": "TRY", "₪": "ILS",
+	"฿": "THB", "zł": "PLN", "Kč": "CZK", "Fr.": "CHF", "$": "USD", "US$": "USD", "R$": "BRL",
+	"A$": "AUD", "AU$": "AUD", "C$": "CAD", "CA$": "CAD", "HK$": "HKD", "NZ$": "NZD", "S$": "SGD",
+	"MX$": "MXN",
+}
+
+// currencyCodes are the ISO 4217 codes recognized in text
+var currencyCodes = []string{
+	"AED", "AUD", "BGN", "BRL", "CAD", "CHF", "CNY", "CZK", "DKK", "EUR", "GBP", "HKD", "HUF", "ILS",
+	"INR", "JPY", "KRW", "MXN", "NOK", "NZD", "PLN", "RON", "RUB", "SAR", "SEK", "SGD", "THB", "TRY",
+	"USD", "ZAR",
+}
+
+// unitAliases are the canonical symbols of units of measure, by lowercased spelling
+var unitAliases = map[string]string{
+	"mg": "mg", "g": "g", "kg": "kg", "kgs": "kg", "t": "t", "lb": "lb", "lbs": "lb", "oz": "oz",
+	"mm": "mm", "cm": "cm", "m": "m", "km": "km", "ft": "ft", "mi": "mi",
+	"m2": "m²", "m²": "m²", "sqm": "m²", "sq ft": "ft²", "ft²": "ft²", "m3": "m³", "m³": "m³",
+	"ml": "ml", "cl": "cl", "l": "l", "gal": "gal",
+	"wh": "Wh", "kwh": "kWh", "mwh": "MWh", "kw": "kW",
+	"h": "h", "hrs": "h", "hours": "h", "min": "min",
+	"pc": "pcs", "pcs": "pcs", "stk": "pcs", "stück": "pcs", "units": "pcs",
+}
+
+var (
+	currencyPattern = regexp.MustCompile(`(?:\b(?:US|AU|CA|HK|NZ|MX|A|C|R|S))?\$|[€£¥₹₽₩₺₪฿]|\bzł|\bKč\b|\bFr\.|\b(?:` + strings.Join(currencyCodes, "|") + `)\b`)
+	unitPattern     = regexp.MustCompile(`(?i)\d\s?(sq ft|kgs|kwh|mwh|stück|units|hours|lbs|sqm|gal|hrs|min|pcs|stk|m²|m³|ft²|mg|kg|lb|oz|mm|cm|km|ft|mi|m2|m3|ml|cl|wh|kw|pc|g|t|m|l|h)(?:$|[^\p{L}\d])`)
+)
+
+// DetectCurrency returns the ISO 4217 code of the currency used most in a text, from currency
+// symbols such as "€" or "US$" and ISO codes such as "EUR", or "" when the text has none. Ties go
+// to the currency found first.
+func DetectCurrency(text string) string {
+	counts := map[string]int{}
+	var order []string
+	for _, match := range currencyPattern.FindAllString(text, -1) {
+		code := CurrencyCode(match)
+		if counts[code] == 0 {
+			order = append(order, code)
+		}
+		counts[code]++
+	}
+	best := ""
+	for _, code := range order {
+		if counts[code] > counts[best] {
+			best = code
+		}
+	}
+	return best
+}
+
+// CurrencyCode returns the ISO 4217 code of a currency symbol or code, such as "EUR" for "€" or
+// "eur", or "" when it is not recognized
+func CurrencyCode(currency string) string {
+	currency = strings.TrimSpace(currency)
+	if code, ok := currencySymbols[currency]; ok {
+		return code
+	}
+	upper := strings.ToUpper(currency)
+	for _, code := range currencyCodes {
+		if upper == code {
+			return code
+		}
+	}
+	return ""
+}
+
+// DetectUnits returns the units of measure written after the numbers of a text, as canonical
+// symbols such as "kg", "m²" or "pcs", from the most to the least used
+func DetectUnits(text string) []string {
+	counts := map[string]int{}
+	for _, match := range unitPattern.FindAllStringSubmatch(text, -1) {
+		counts[unitAliases[strings.ToLower(match[1])]]++
+	}
+	units := make([]string, 0, len(counts))
+	for unit := range counts {
+		units = append(units, unit)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		if counts[units[i]] != counts[units[j]] {
+			return counts[units[i]] > counts[units[j]]
+		}
+		return units[i] < units[j]
+	})
+	return units
+}
@@ -0,0 +1,253 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	// glyphAscent and glyphDescent are the extent of a line of text above and below its baseline,
+	// relative to the font size, matching the line boxes of fitz's HTML output
+	glyphAscent  = 0.8
+	glyphDescent = 0.2
+	// wordGap is the smallest blank space between two glyphs, relative to the font size, that
+	// separates words set without a space character
+	wordGap = 0.25
+	// lineGap is the smallest blank space between two glyphs, relative to the font size, that
+	// separates lines of text sharing a baseline, such as table cells
+	lineGap = 1.5
+)
+
+var (
+	svgSizePattern  = regexp.MustCompile(`<svg[^>]*\swidth="([0-9.]+)" height="([0-9.]+)"`)
+	svgPathPattern  = regexp.MustCompile(`<path id="([^"]+)" d="([^"]*)"`)
+	svgGlyphPattern = regexp.MustCompile(`<use data-text="([^"]*)" xlink:href="#([^"]+)" transform="matrix\(([^)]*)\)"`)
+	svgTokenPattern = regexp.MustCompile(`[A-Za-z]|-?(?:[0-9]*\.[0-9]+|[0-9]+)(?:[eE][-+]?[0-9]+)?`)
+)
+
+// glyph is a character drawn on a page, at its origin on the baseline
+type glyph struct {
+	text string
+	x, y float64
+	size float64
+	// left and right are the horizontal extent of the glyph's ink
+	left, right float64
+	// bold is set when the glyph is known to be drawn with a bold font
+	bold bool
+}
+
+// ExtractTextLayoutFromPath reads the words and lines of every page of a PDF file with their
+// bounding boxes
+func ExtractTextLayoutFromPath(pdfPath string) ([]types.PageLayout, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF from path: %w", err)
+	}
+
+	return ExtractTextLayoutFromBuffer(data)
+}
+
+// ExtractTextLayoutFromBuffer reads the words and lines of every page of a PDF buffer with their
+// bounding boxes, in points from the top-left corner of the page. Invisible text, such as the
+// text layer of a searchable PDF, is not drawn and has no layout.
+func ExtractTextLayoutFromBuffer(buffer []byte) ([]types.PageLayout, error) {
+	if !isValidPdfSignature(buffer) {
+		return nil, errors.New("invalid PDF: file does not contain PDF signature")
+	}
+
+	doc, err := openDocument(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer doc.Close()
+
+	layouts := make([]types.PageLayout, doc.NumPage())
+	for pageNum := range layouts {
+		width, height, glyphs, err := doc.Glyphs(pageNum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", pageNum+1, err)
+		}
+		layouts[pageNum] = pageLayout(width, height, glyphs)
+		layouts[pageNum].Page = pageNum + 1
+	}
+	return layouts, nil
+}
+
+// pageLayout groups the glyphs of a page, in drawing order, into words and lines
+func pageLayout(width, height float64, glyphs []glyph) types.PageLayout {
+	layout := types.PageLayout{Width: width, Height: height, Lines: make([]types.TextLine, 0)}
+
+	var line *types.TextLine
+	var word []glyph
+	var previous *glyph
+	endWord := func() {
+		if len(word) > 0 {
+			line.Words = append(line.Words, glyphWord(word))
+			word = nil
+		}
+	}
+	endLine := func() {
+		if line != nil {
+			endWord()
+			if len(line.Words) > 0 {
+				layout.Lines = append(layout.Lines, textLineOf(line.Words))
+			}
+		}
+		line = &types.TextLine{}
+	}
+
+	for _, g := range glyphs {
+		if previous == nil || startsLine(previous, &g) {
+			endLine()
+		} else if g.x+g.left-(previous.x+previous.right) > g.size*wordGap {
+			endWord()
+		}
+		if strings.TrimSpace(g.text) == "" {
+			endWord()
+		} else {
+			word = append(word, g)
+		}
+		previous = &g
+	}
+	if line != nil {
+		endLine()
+	}
+	return layout
+}
+
+// startsLine reports whether a glyph starts a new line after the previous glyph: when it is not
+// on the same baseline, goes backwards, or is separated by a large gap
+func startsLine(previous, g *glyph) bool {
+	size := max(previous.size, g.size)
+	if math.Abs(g.y-previous.y) > size*0.3 {
+		return true
+	}
+	gap := g.x + g.left - (previous.x + previous.right)
+	return gap < -size || gap > size*lineGap
+}
+
+// svgSize reads the size of a page drawn by fitz's SVG output
+func svgSize(svg string) (width, height float64) {
+	if match := svgSizePattern.FindStringSubmatch(svg); match != nil {
+		width, _ = strconv.ParseFloat(match[1], 64)
+		height, _ = strconv.ParseFloat(match[2], 64)
+	}
+	return width, height
+}
+
+// svgGlyphs reads the glyphs drawn by fitz's SVG output, with the horizontal extent of their ink
+// computed from their outlines
+func svgGlyphs(svg string) []glyph {
+	extents := make(map[string][2]float64)
+	for _, match := range svgPathPattern.FindAllStringSubmatch(svg, -1) {
+		if left, right, ok := pathExtent(match[2]); ok {
+			extents[match[1]] = [2]float64{left, right}
+		}
+	}
+
+	glyphs := make([]glyph, 0)
+	for _, match := range svgGlyphPattern.FindAllStringSubmatch(svg, -1) {
+		values := strings.Split(match[3], ",")
+		if len(values) != 6 {
+			continue
+		}
+		m := make([]float64, 6)
+		for i, value := range values {
+			m[i], _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		}
+		size := math.Hypot(m[0], m[1])
+		if size == 0 {
+			continue
+		}
+
+		g := glyph{text: html.UnescapeString(match[1]), x: m[4], y: m[5], size: size}
+		if extent, ok := extents[match[2]]; ok && extent[1] > extent[0] {
+			g.left, g.right = extent[0]*size, extent[1]*size
+		} else if strings.TrimSpace(g.text) != "" {
+			// Without an outline, assume an average glyph
+			g.right = size * averageCharWidth
+		}
+		glyphs = append(glyphs, g)
+	}
+	return glyphs
+}
+
+// pathExtent returns the horizontal extent of an SVG path made of absolute commands, in the
+// units of the path
+func pathExtent(d string) (left, right float64, ok bool) {
+	left, right = math.Inf(1), math.Inf(-1)
+	// Indices of the horizontal coordinates among the parameters of each command
+	xs := map[string][]int{"M": {0}, "L": {0}, "T": {0}, "H": {0}, "V": {}, "C": {0, 2, 4}, "S": {0, 2}, "Q": {0, 2}, "A": {5}}
+	arity := map[string]int{"M": 2, "L": 2, "T": 2, "H": 1, "V": 1, "C": 6, "S": 4, "Q": 4, "A": 7}
+
+	command := ""
+	var params []float64
+	for _, token := range svgTokenPattern.FindAllString(d, -1) {
+		if unicode.IsLetter(rune(token[0])) {
+			if token == "Z" || token == "z" {
+				command = ""
+				continue
+			}
+			if _, known := arity[token]; !known {
+				// Relative commands would need the current point to be tracked
+				return 0, 0, false
+			}
+			command, params = token, nil
+			continue
+		}
+		if command == "" {
+			return 0, 0, false
+		}
+		value, _ := strconv.ParseFloat(token, 64)
+		params = append(params, value)
+		if len(params) == arity[command] {
+			for _, i := range xs[command] {
+				left, right = min(left, params[i]), max(right, params[i])
+			}
+			params = nil
+		}
+	}
+	return left, right, right >= left
+}
+
+// glyphWord builds a word from its glyphs
+func glyphWord(glyphs []glyph) types.TextWord {
+	var text strings.Builder
+	left, right := math.Inf(1), math.Inf(-1)
+	baseline, size := glyphs[0].y, 0.0
+	for _, g := range glyphs {
+		text.WriteString(g.text)
+		left = min(left, g.x+min(g.left, 0))
+		right = max(right, g.x+g.right)
+		size = max(size, g.size)
+	}
+	return types.TextWord{
+		Text: CleanUnicode(text.String()),
+		Bounds: types.Rect{
+			X:      left,
+			Y:      baseline - size*glyphAscent,
+			Width:  right - left,
+			Height: size * (glyphAscent + glyphDescent),
+		},
+	}
+}
+
+// textLineOf builds a line from its words
+func textLineOf(words []types.TextWord) types.TextLine {
+	texts := make([]string, len(words))
+	bounds := words[0].Bounds
+	for i, word := range words {
+		texts[i] = word.Text
+		bounds = bounds.Union(word.Bounds)
+	}
+	return types.TextLine{Text: strings.Join(texts, " "), Bounds: bounds, Words: words}
+}
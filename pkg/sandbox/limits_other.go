@@ -0,0 +1,14 @@
+//go:build !unix
+
+package sandbox
+
+import "runtime/debug"
+
+// limit keeps the Go heap below the memory limit. Other limits are not available on this
+// platform, where the parent still kills the helper process after its timeout.
+func limit(maxMemoryBytes int64, _ uint64) error {
+	if maxMemoryBytes > 0 {
+		debug.SetMemoryLimit(maxMemoryBytes / 10 * 9)
+	}
+	return nil
+}
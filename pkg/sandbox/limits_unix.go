@@ -0,0 +1,23 @@
+//go:build unix
+
+package sandbox
+
+import (
+	"runtime/debug"
+	"syscall"
+)
+
+// limit limits the data segment and CPU time of the process. The Go heap is also kept below the
+// memory limit, so that the garbage collector works harder before the limit is hit.
+func limit(maxMemoryBytes int64, cpuSeconds uint64) error {
+	if maxMemoryBytes > 0 {
+		debug.SetMemoryLimit(maxMemoryBytes / 10 * 9)
+		if err := syscall.Setrlimit(syscall.RLIMIT_DATA, &syscall.Rlimit{Cur: uint64(maxMemoryBytes), Max: uint64(maxMemoryBytes)}); err != nil {
+			return err
+		}
+	}
+	if cpuSeconds > 0 {
+		return syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: cpuSeconds, Max: cpuSeconds + 1})
+	}
+	return nil
+}
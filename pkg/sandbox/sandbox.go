@@ -0,0 +1,405 @@
+// Package sandbox parses untrusted PDFs in a separate helper process, so that a crash or an
+// exploit of the parser on a malicious document cannot take down or take over the calling
+// process. The helper is limited in memory and time and, on Linux, restricted by a seccomp filter
+// that forbids it to run programs, open network connections and modify files. It receives the
+// PDF on its standard input and answers with the parsed PDF on its standard output; it also
+// renders, reads the text and layout of, fingerprints and adds text layers to PDFs, for the
+// features of the extractor that do not parse them.
+//
+// The helper is the running executable by default: call Main first thing in main, so that the
+// executable serves as the helper when started as one.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const (
+	// helperEnv is the environment variable starting a program as the helper process
+	helperEnv = "GO_PDF_EXTRACTOR_SANDBOX"
+	// defaultMaxMemory is the default limit of the data segment of the helper process
+	defaultMaxMemory = 1 << 30
+	// defaultTimeout is the default time the helper process has to parse a PDF
+	defaultTimeout = 2 * time.Minute
+	// maxStderr is how much of the end of the standard error of the helper is kept for errors
+	maxStderr = 4096
+)
+
+// ErrCrashed is returned, wrapped, when the helper process crashes or is killed, e.g. for
+// exceeding its memory or time limit
+var ErrCrashed = errors.New("sandboxed parser crashed")
+
+// Operations of the helper process
+const (
+	operationParse       = "parse"
+	operationRender      = "render"
+	operationLayout      = "layout"
+	operationFingerprint = "fingerprint"
+	operationTextLayer   = "textLayer"
+	operationText        = "text"
+)
+
+// Kinds of the typed errors of the parser, rebuilt from the answer of the helper process
+const (
+	kindRenderFailed         = "renderFailed"
+	kindRenderingUnavailable = "renderingUnavailable"
+	kindRenderLimit          = "renderLimit"
+	kindPage                 = "page"
+	kindPages                = "pages"
+)
+
+// request is the operation, PDF and options sent to the helper process
+type request struct {
+	Operation      string              `json:"operation"`
+	PDF            []byte              `json:"pdf"`
+	Options        *types.ParseOptions `json:"options,omitempty"`
+	Pages          []int               `json:"pages,omitempty"`
+	Layouts        []types.PageLayout  `json:"layouts,omitempty"`
+	Backend        string              `json:"backend,omitempty"`
+	MaxMemoryBytes int64               `json:"maxMemoryBytes"`
+	CPUSeconds     uint64              `json:"cpuSeconds"`
+	Seccomp        bool                `json:"seccomp"`
+}
+
+// response is the result, or the error, of the operation of the helper process
+type response struct {
+	OK          bool                 `json:"ok,omitempty"`
+	ParsedPdf   *types.ParsedPdf     `json:"parsedPdf,omitempty"`
+	Images      []types.PdfPageImage `json:"images,omitempty"`
+	Layouts     []types.PageLayout   `json:"layouts,omitempty"`
+	Fingerprint *types.Fingerprint   `json:"fingerprint,omitempty"`
+	PDF         []byte               `json:"pdf,omitempty"`
+	Pages       []string             `json:"pages,omitempty"`
+	Error       *helperError         `json:"error,omitempty"`
+}
+
+// helperError is an error of the helper process. Kind names the typed error of the parser it
+// wraps, if any, whose fields it carries so that the caller can match it with errors.Is and
+// errors.As.
+type helperError struct {
+	Message string `json:"message"`
+	Kind    string `json:"kind,omitempty"`
+	Page    int    `json:"page,omitempty"`
+	Stage   string `json:"stage,omitempty"`
+	Limit   string `json:"limit,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+	// Cause is the error of the backend of a page error
+	Cause *helperError `json:"cause,omitempty"`
+	// Pages are the page errors of a text backend
+	Pages []*helperError `json:"pages,omitempty"`
+}
+
+// remoteError is an error of the helper process whose message differs from the typed error it wraps
+type remoteError struct {
+	message string
+	err     error
+}
+
+// Error returns the message of the helper process
+func (e *remoteError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the typed error of the parser
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+// Parse parses a PDF buffer in a helper process, like parser.ParsePdfFromBuffer. Only the
+// backends built into the helper are available to it, and with the seccomp filter the external
+// renderers, which run programs, fail. The errors of the parser keep their type:
+// parser.ErrRenderFailed, *parser.RenderLimitError, and the *parser.PageError and
+// parser.PageErrors of the strict parse policy match with errors.Is and errors.As.
+func Parse(ctx context.Context, buffer []byte, options *types.ParseOptions, config types.SandboxConfig) (*types.ParsedPdf, error) {
+	answer, err := run(ctx, request{Operation: operationParse, PDF: buffer, Options: options}, config)
+	if err != nil {
+		return nil, err
+	}
+	// Info values are decoded as generic JSON, so restore the type of annotations
+	if value, ok := answer.ParsedPdf.Info[types.InfoAnnotations]; ok {
+		data, _ := json.Marshal(value)
+		var annotations []types.Annotation
+		if json.Unmarshal(data, &annotations) == nil {
+			answer.ParsedPdf.Info[types.InfoAnnotations] = annotations
+		}
+	}
+	return answer.ParsedPdf, nil
+}
+
+// Render renders the given pages (1-indexed) of a PDF buffer, or all of them when pages is empty,
+// in a helper process, like parser.RenderPdfPagesToImages and parser.RenderPdfToImages
+func Render(ctx context.Context, buffer []byte, pages []int, config types.SandboxConfig) ([]types.PdfPageImage, error) {
+	answer, err := run(ctx, request{Operation: operationRender, PDF: buffer, Pages: pages}, config)
+	if err != nil {
+		return nil, err
+	}
+	return answer.Images, nil
+}
+
+// TextLayout reads the words and lines of the pages of a PDF buffer in a helper process, like
+// parser.ExtractTextLayoutFromBuffer
+func TextLayout(ctx context.Context, buffer []byte, config types.SandboxConfig) ([]types.PageLayout, error) {
+	answer, err := run(ctx, request{Operation: operationLayout, PDF: buffer}, config)
+	if err != nil {
+		return nil, err
+	}
+	return answer.Layouts, nil
+}
+
+// Fingerprint fingerprints a PDF buffer in a helper process, like parser.Fingerprint
+func Fingerprint(ctx context.Context, buffer []byte, config types.SandboxConfig) (*types.Fingerprint, error) {
+	answer, err := run(ctx, request{Operation: operationFingerprint, PDF: buffer}, config)
+	if err != nil {
+		return nil, err
+	}
+	return answer.Fingerprint, nil
+}
+
+// AddTextLayer adds an invisible text layer to a PDF buffer in a helper process, like
+// parser.AddTextLayer
+func AddTextLayer(ctx context.Context, buffer []byte, layouts []types.PageLayout, config types.SandboxConfig) ([]byte, error) {
+	answer, err := run(ctx, request{Operation: operationTextLayer, PDF: buffer, Layouts: layouts}, config)
+	if err != nil {
+		return nil, err
+	}
+	return answer.PDF, nil
+}
+
+// ExtractText reads the text of each page of a PDF buffer with a registered text backend in a
+// helper process, like the ExtractText method of the backend. The backend must be registered in
+// the helper too, e.g. by an import of the package registering it.
+func ExtractText(ctx context.Context, buffer []byte, backend string, config types.SandboxConfig) ([]string, error) {
+	answer, err := run(ctx, request{Operation: operationText, PDF: buffer, Backend: backend}, config)
+	if err != nil {
+		return nil, err
+	}
+	return answer.Pages, nil
+}
+
+// run sends a request to a helper process, with the limits of the configuration, and returns its
+// answer, or its error
+func run(ctx context.Context, req request, config types.SandboxConfig) (*response, error) {
+	path := config.HelperPath
+	if path == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the helper executable: %w", err)
+		}
+		path = executable
+	}
+	maxMemory := config.MaxMemoryBytes
+	if maxMemory <= 0 {
+		maxMemory = defaultMaxMemory
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	req.MaxMemoryBytes = maxMemory
+	req.CPUSeconds = uint64(math.Ceil(timeout.Seconds()))
+	req.Seccomp = !config.DisableSeccomp
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sandbox request: %w", err)
+	}
+
+	helperCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(helperCtx, path)
+	cmd.Env = append(os.Environ(), helperEnv+"=1")
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout bytes.Buffer
+	stderr := &tailBuffer{max: maxStderr}
+	cmd.Stdout, cmd.Stderr = &stdout, stderr
+
+	runErr := cmd.Run()
+	// The caller giving up is not a crash of the helper
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if errors.Is(helperCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: time limit of %s exceeded", ErrCrashed, timeout)
+	}
+	var answer response
+	if err := json.Unmarshal(stdout.Bytes(), &answer); err != nil || (!answer.OK && answer.Error == nil) {
+		if runErr == nil {
+			runErr = errors.New("no answer")
+		}
+		return nil, fmt.Errorf("%w: %v: %s", ErrCrashed, runErr, strings.TrimSpace(stderr.String()))
+	}
+	if answer.Error != nil {
+		return nil, answer.Error.err()
+	}
+	return &answer, nil
+}
+
+// Main serves as the helper process and exits when the program was started as one, and returns
+// immediately otherwise. Call it first thing in main, before the program does anything else.
+func Main() {
+	if os.Getenv(helperEnv) != "1" {
+		return
+	}
+	os.Exit(serve(os.Stdin, os.Stdout))
+}
+
+// serve reads a request, applies its limits and runs its operation, and writes the response. It
+// returns the exit code of the helper process.
+func serve(in io.Reader, out io.Writer) int {
+	answer, err := func() (response, error) {
+		var req request
+		if err := json.NewDecoder(in).Decode(&req); err != nil {
+			return response{}, fmt.Errorf("failed to read sandbox request: %w", err)
+		}
+		if err := limit(req.MaxMemoryBytes, req.CPUSeconds); err != nil {
+			return response{}, fmt.Errorf("failed to limit the sandbox: %w", err)
+		}
+		if req.Seccomp {
+			if err := restrict(); err != nil {
+				return response{}, fmt.Errorf("failed to install the seccomp filter: %w", err)
+			}
+		}
+		return operate(req)
+	}()
+
+	if err != nil {
+		answer = response{Error: encodeError(err)}
+	} else {
+		answer.OK = true
+	}
+	if err := json.NewEncoder(out).Encode(answer); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// operate runs the operation of a request
+func operate(req request) (response, error) {
+	var answer response
+	var err error
+	switch req.Operation {
+	case operationParse:
+		answer.ParsedPdf, err = parser.ParsePdfFromBuffer(req.PDF, req.Options)
+	case operationRender:
+		if len(req.Pages) == 0 {
+			answer.Images, err = parser.RenderPdfToImages(req.PDF)
+		} else {
+			answer.Images, err = parser.RenderPdfPagesToImages(req.PDF, req.Pages)
+		}
+	case operationLayout:
+		answer.Layouts, err = parser.ExtractTextLayoutFromBuffer(req.PDF)
+	case operationFingerprint:
+		answer.Fingerprint, err = parser.Fingerprint(req.PDF)
+	case operationTextLayer:
+		answer.PDF, err = parser.AddTextLayer(req.PDF, req.Layouts)
+	case operationText:
+		backend, ok := parser.LookupBackend(req.Backend)
+		if !ok {
+			return answer, fmt.Errorf("unknown text backend %q", req.Backend)
+		}
+		extractor, ok := backend.(parser.TextExtractor)
+		if !ok {
+			return answer, fmt.Errorf("backend %q cannot extract text", req.Backend)
+		}
+		answer.Pages, err = extractor.ExtractText(req.PDF)
+	default:
+		err = fmt.Errorf("unknown sandbox operation %q", req.Operation)
+	}
+	return answer, err
+}
+
+// encodeError describes an error for the caller, along with the typed error of the parser it wraps
+func encodeError(err error) *helperError {
+	encoded := &helperError{Message: err.Error()}
+	var pageErrs parser.PageErrors
+	var pageErr *parser.PageError
+	var limitErr *parser.RenderLimitError
+	switch {
+	case errors.As(err, &pageErrs):
+		encoded.Kind = kindPages
+		for _, pageErr := range pageErrs {
+			encoded.Pages = append(encoded.Pages, encodeError(pageErr))
+		}
+	case errors.As(err, &pageErr):
+		encoded.Kind, encoded.Page, encoded.Stage = kindPage, pageErr.Page, pageErr.Stage
+		if pageErr.Err != nil {
+			encoded.Cause = encodeError(pageErr.Err)
+		}
+	case errors.As(err, &limitErr):
+		encoded.Kind, encoded.Page, encoded.Limit, encoded.Detail = kindRenderLimit, limitErr.Page, limitErr.Limit, limitErr.Detail
+	case errors.Is(err, parser.ErrRenderFailed):
+		encoded.Kind = kindRenderFailed
+	case errors.Is(err, parser.ErrRenderingUnavailable):
+		encoded.Kind = kindRenderingUnavailable
+	}
+	return encoded
+}
+
+// err rebuilds the error of the helper process, wrapping the typed error of its kind
+func (e *helperError) err() error {
+	var typed error
+	switch e.Kind {
+	case kindPages:
+		pageErrs := make(parser.PageErrors, 0, len(e.Pages))
+		for _, page := range e.Pages {
+			pageErrs = append(pageErrs, page.pageError())
+		}
+		typed = pageErrs
+	case kindPage:
+		typed = e.pageError()
+	case kindRenderLimit:
+		typed = &parser.RenderLimitError{Page: e.Page, Limit: e.Limit, Detail: e.Detail}
+	case kindRenderFailed:
+		typed = parser.ErrRenderFailed
+	case kindRenderingUnavailable:
+		typed = parser.ErrRenderingUnavailable
+	default:
+		return errors.New(e.Message)
+	}
+	if typed.Error() == e.Message {
+		return typed
+	}
+	return &remoteError{message: e.Message, err: typed}
+}
+
+// pageError rebuilds a page error of the helper process
+func (e *helperError) pageError() *parser.PageError {
+	pageErr := &parser.PageError{Page: e.Page, Stage: e.Stage, Err: errors.New(e.Message)}
+	if e.Cause != nil {
+		pageErr.Err = e.Cause.err()
+	}
+	return pageErr
+}
+
+// tailBuffer keeps the last bytes written to it
+type tailBuffer struct {
+	max  int
+	data []byte
+}
+
+// Write appends p, dropping the oldest bytes beyond the maximum
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if len(b.data) > b.max {
+		b.data = b.data[len(b.data)-b.max:]
+	}
+	return len(p), nil
+}
+
+// String returns the bytes kept
+func (b *tailBuffer) String() string {
+	return string(b.data)
+}
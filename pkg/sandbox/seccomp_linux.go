@@ -0,0 +1,98 @@
+//go:build linux && (amd64 || arm64)
+
+package sandbox
+
+import (
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Offsets in struct seccomp_data of the fields the filter reads
+const (
+	offsetNr   = 0
+	offsetArch = 4
+	offsetArgs = 16
+)
+
+// writeFlags are the open flags of files opened to be modified
+const writeFlags = unix.O_WRONLY | unix.O_RDWR | unix.O_CREAT | unix.O_TRUNC
+
+// deniedSyscalls are the system calls of every architecture the helper process may not make: to
+// run programs, use the network, modify files, change its privileges or reach other processes
+// and the kernel
+var deniedSyscalls = []uint32{
+	unix.SYS_EXECVE, unix.SYS_EXECVEAT,
+	unix.SYS_SOCKET, unix.SYS_SOCKETPAIR, unix.SYS_CONNECT, unix.SYS_BIND, unix.SYS_LISTEN, unix.SYS_ACCEPT, unix.SYS_ACCEPT4,
+	unix.SYS_OPENAT2, unix.SYS_UNLINKAT, unix.SYS_RENAMEAT, unix.SYS_RENAMEAT2, unix.SYS_MKDIRAT, unix.SYS_MKNODAT,
+	unix.SYS_LINKAT, unix.SYS_SYMLINKAT, unix.SYS_FCHMODAT, unix.SYS_FCHOWNAT, unix.SYS_TRUNCATE,
+	unix.SYS_SETUID, unix.SYS_SETGID, unix.SYS_SETREUID, unix.SYS_SETREGID, unix.SYS_SETRESUID, unix.SYS_SETRESGID, unix.SYS_SETGROUPS,
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_CHROOT, unix.SYS_SETNS, unix.SYS_UNSHARE,
+	unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE, unix.SYS_KEXEC_LOAD, unix.SYS_KEXEC_FILE_LOAD, unix.SYS_REBOOT,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD, unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+}
+
+// openSyscall is a system call opening files, with the index of its flags argument
+type openSyscall struct {
+	nr        uint32
+	flagsArgs uint32
+}
+
+// restrict installs the seccomp filter on every thread of the process. Denied system calls fail
+// with EPERM, and the process is killed if it makes system calls of another architecture.
+func restrict() error {
+	filter := seccompFilter()
+	program := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return err
+	}
+	// With TSYNC, a thread that could not be synchronized is returned instead of an error
+	thread, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER, unix.SECCOMP_FILTER_FLAG_TSYNC, uintptr(unsafe.Pointer(&program)))
+	if errno != 0 {
+		return errno
+	}
+	if thread != 0 {
+		return unix.ESRCH
+	}
+	return nil
+}
+
+// seccompFilter compiles the BPF program of the filter
+func seccompFilter() []unix.SockFilter {
+	denied := append(append([]uint32{}, deniedSyscalls...), archDeniedSyscalls...)
+	opens := archOpenSyscalls
+
+	// The program ends with an allow and a deny return, which the checks jump to
+	length := 4 + len(denied) + 3*len(opens) + 2
+	allow, deny := length-2, length-1
+	filter := make([]unix.SockFilter, 0, length)
+	jump := func(code uint16, k uint32, jt, jf int) {
+		at := len(filter)
+		filter = append(filter, unix.SockFilter{Code: code, K: k, Jt: uint8(jt - at - 1), Jf: uint8(jf - at - 1)})
+	}
+	stmt := func(code uint16, k uint32) {
+		filter = append(filter, unix.SockFilter{Code: code, K: k})
+	}
+
+	stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offsetArch)
+	jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, auditArch, len(filter)+2, len(filter)+1)
+	stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_KILL_PROCESS)
+	stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offsetNr)
+	for _, nr := range denied {
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, nr, deny, len(filter)+1)
+	}
+	for _, open := range opens {
+		// Files may be opened for reading only
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, open.nr, len(filter)+1, len(filter)+3)
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offsetArgs+8*open.flagsArgs)
+		jump(unix.BPF_JMP|unix.BPF_JSET|unix.BPF_K, writeFlags, deny, allow)
+	}
+	stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ALLOW)
+	stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM))
+	return filter
+}
@@ -0,0 +1,18 @@
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+// auditArch identifies the system calls of the architecture
+const auditArch = unix.AUDIT_ARCH_X86_64
+
+// archDeniedSyscalls are the legacy system calls of the architecture that are denied
+var archDeniedSyscalls = []uint32{
+	unix.SYS_FORK, unix.SYS_VFORK, unix.SYS_CREAT, unix.SYS_UNLINK, unix.SYS_RMDIR, unix.SYS_RENAME,
+	unix.SYS_MKDIR, unix.SYS_MKNOD, unix.SYS_LINK, unix.SYS_SYMLINK, unix.SYS_CHMOD, unix.SYS_CHOWN, unix.SYS_LCHOWN,
+}
+
+// archOpenSyscalls are the system calls of the architecture opening files
+var archOpenSyscalls = []openSyscall{
+	{nr: unix.SYS_OPENAT, flagsArgs: 2},
+	{nr: unix.SYS_OPEN, flagsArgs: 1},
+}
@@ -0,0 +1,14 @@
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+// auditArch identifies the system calls of the architecture
+const auditArch = unix.AUDIT_ARCH_AARCH64
+
+// archDeniedSyscalls are the legacy system calls of the architecture that are denied; arm64 has none
+var archDeniedSyscalls []uint32
+
+// archOpenSyscalls are the system calls of the architecture opening files
+var archOpenSyscalls = []openSyscall{
+	{nr: unix.SYS_OPENAT, flagsArgs: 2},
+}
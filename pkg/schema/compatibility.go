@@ -0,0 +1,289 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Kinds of Change
+const (
+	ChangeFieldRemoved    = "field-removed"
+	ChangeFieldAdded      = "field-added"
+	ChangeRequiredRemoved = "required-removed"
+	ChangeRequiredAdded   = "required-added"
+	ChangeTypeChanged     = "type-changed"
+	ChangeEnumChanged     = "enum-changed"
+	ChangeFormatChanged   = "format-changed"
+)
+
+// Change is a difference between two versions of a schema, see CheckCompatibility
+type Change struct {
+	// Kind is the kind of change, such as ChangeFieldRemoved
+	Kind string
+	// Path is the JSON pointer of the changed schema, such as "/properties/total"
+	Path string
+	// Breaking reports whether the change breaks the consumers of the data of the old schema
+	Breaking bool
+	// Description describes the change, such as "type changed from number to string"
+	Description string
+}
+
+// String describes the change and where it is, marking breaking changes
+func (c Change) String() string {
+	text := pointerOrRoot(c.Path) + ": " + c.Description
+	if c.Breaking {
+		text += " (breaking)"
+	}
+	return text
+}
+
+// CheckCompatibility returns the changes from an old version of a schema to a new one, walking
+// their properties and array items in key order. A change is breaking when data extracted with
+// the new schema may break the consumers of the data of the old one: a required field is removed
+// or no longer required, the type of a field is changed to one the old schema did not allow, enum
+// values are added or the enum is removed, or the format of a field is changed or removed. Added
+// fields, newly required fields and narrowed types and enums are reported as compatible. The
+// local references of both schemas are inlined first; external ones are an error.
+func CheckCompatibility(oldSchema, newSchema map[string]interface{}) ([]Change, error) {
+	oldNormalized, err := normalizedSchema(oldSchema)
+	if err != nil {
+		return nil, fmt.Errorf("old schema: %w", err)
+	}
+	newNormalized, err := normalizedSchema(newSchema)
+	if err != nil {
+		return nil, fmt.Errorf("new schema: %w", err)
+	}
+	var changes []Change
+	compareNodes(oldNormalized, newNormalized, "", &changes)
+	return changes, nil
+}
+
+// normalizedSchema returns a copy of a schema with its references inlined, through JSON so that
+// schemas built in Go, with lists such as []string, hold the types of decoded JSON only
+func normalizedSchema(schema map[string]interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return InlineRefs(decoded, nil)
+}
+
+// BreakingChanges returns the breaking changes among changes
+func BreakingChanges(changes []Change) []Change {
+	var breaking []Change
+	for _, change := range changes {
+		if change.Breaking {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
+
+// compareNodes appends the changes between two nodes of a schema at a JSON pointer
+func compareNodes(oldNode, newNode map[string]interface{}, path string, changes *[]Change) {
+	compareTypes(oldNode, newNode, path, changes)
+	oldNode, newNode = valueSchema(oldNode), valueSchema(newNode)
+	compareEnums(oldNode, newNode, path, changes)
+	compareFormats(oldNode, newNode, path, changes)
+
+	oldProperties, _ := oldNode["properties"].(map[string]interface{})
+	newProperties, _ := newNode["properties"].(map[string]interface{})
+	oldRequired, _ := stringList(oldNode["required"])
+	newRequired, _ := stringList(newNode["required"])
+	names := append(sortedKeys(oldProperties), sortedKeys(newProperties)...)
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		at := path + "/properties/" + escapePointer(name)
+		oldProperty, inOld := oldProperties[name]
+		newProperty, inNew := newProperties[name]
+		wasRequired, isRequired := slices.Contains(oldRequired, name), slices.Contains(newRequired, name)
+		switch {
+		case !inNew:
+			*changes = append(*changes, Change{Kind: ChangeFieldRemoved, Path: at, Breaking: wasRequired, Description: requiredOrOptional(wasRequired) + " field removed"})
+			continue
+		case !inOld:
+			*changes = append(*changes, Change{Kind: ChangeFieldAdded, Path: at, Description: requiredOrOptional(isRequired) + " field added"})
+			continue
+		case wasRequired && !isRequired:
+			*changes = append(*changes, Change{Kind: ChangeRequiredRemoved, Path: at, Breaking: true, Description: "field no longer required"})
+		case !wasRequired && isRequired:
+			*changes = append(*changes, Change{Kind: ChangeRequiredAdded, Path: at, Description: "field now required"})
+		}
+		oldChild, _ := oldProperty.(map[string]interface{})
+		newChild, _ := newProperty.(map[string]interface{})
+		if oldChild != nil && newChild != nil {
+			compareNodes(oldChild, newChild, at, changes)
+		}
+	}
+
+	oldItems, _ := oldNode["items"].(map[string]interface{})
+	newItems, _ := newNode["items"].(map[string]interface{})
+	if oldItems != nil && newItems != nil {
+		compareNodes(oldItems, newItems, path+"/items", changes)
+	}
+}
+
+// compareTypes appends the change of the types of two nodes, breaking when the new node allows a
+// type the old one did not
+func compareTypes(oldNode, newNode map[string]interface{}, path string, changes *[]Change) {
+	oldTypes, newTypes := schemaTypes(oldNode), schemaTypes(newNode)
+	if slices.Equal(oldTypes, newTypes) {
+		return
+	}
+	breaking := len(newTypes) == 0 && len(oldTypes) > 0
+	for _, newType := range newTypes {
+		if len(oldTypes) > 0 && !slices.Contains(oldTypes, newType) && (newType != "integer" || !slices.Contains(oldTypes, "number")) {
+			breaking = true
+		}
+	}
+	*changes = append(*changes, Change{
+		Kind:        ChangeTypeChanged,
+		Path:        path,
+		Breaking:    breaking,
+		Description: fmt.Sprintf("type changed from %s to %s", typeNames(oldTypes), typeNames(newTypes)),
+	})
+}
+
+// compareEnums appends the changes of the enum values of two nodes, breaking when values are
+// added or the enum is removed
+func compareEnums(oldNode, newNode map[string]interface{}, path string, changes *[]Change) {
+	oldValues, hasOld := enumValues(oldNode)
+	newValues, hasNew := enumValues(newNode)
+	switch {
+	case !hasOld && !hasNew:
+	case !hasNew:
+		*changes = append(*changes, Change{Kind: ChangeEnumChanged, Path: path, Breaking: true, Description: "enum removed"})
+	case !hasOld:
+		*changes = append(*changes, Change{Kind: ChangeEnumChanged, Path: path, Description: "enum added"})
+	default:
+		if added := missingValues(newValues, oldValues); len(added) > 0 {
+			*changes = append(*changes, Change{Kind: ChangeEnumChanged, Path: path, Breaking: true, Description: "enum values added: " + strings.Join(added, ", ")})
+		}
+		if removed := missingValues(oldValues, newValues); len(removed) > 0 {
+			*changes = append(*changes, Change{Kind: ChangeEnumChanged, Path: path, Description: "enum values removed: " + strings.Join(removed, ", ")})
+		}
+	}
+}
+
+// compareFormats appends the change of the format of two nodes, the "format" keyword or the
+// "x-format" directive, breaking unless a format is added
+func compareFormats(oldNode, newNode map[string]interface{}, path string, changes *[]Change) {
+	oldFormat, newFormat := schemaFormat(oldNode), schemaFormat(newNode)
+	switch {
+	case oldFormat == newFormat:
+	case oldFormat == "":
+		*changes = append(*changes, Change{Kind: ChangeFormatChanged, Path: path, Description: "format " + newFormat + " added"})
+	case newFormat == "":
+		*changes = append(*changes, Change{Kind: ChangeFormatChanged, Path: path, Breaking: true, Description: "format " + oldFormat + " removed"})
+	default:
+		*changes = append(*changes, Change{Kind: ChangeFormatChanged, Path: path, Breaking: true, Description: fmt.Sprintf("format changed from %s to %s", oldFormat, newFormat)})
+	}
+}
+
+// schemaTypes returns the sorted types a node allows: those of its type keyword, or else of the
+// branches of its anyOf or oneOf, or none when any type is allowed
+func schemaTypes(node map[string]interface{}) []string {
+	var types []string
+	switch value := node["type"].(type) {
+	case string:
+		types = []string{value}
+	case []interface{}, []string:
+		types, _ = stringList(value)
+	case nil:
+		for _, keyword := range []string{"anyOf", "oneOf"} {
+			branches, _ := node[keyword].([]interface{})
+			for _, branch := range branches {
+				branchNode, _ := branch.(map[string]interface{})
+				branchTypes := schemaTypes(branchNode)
+				if len(branchTypes) == 0 {
+					return nil
+				}
+				types = append(types, branchTypes...)
+			}
+		}
+	}
+	types = slices.Clone(types)
+	slices.Sort(types)
+	return slices.Compact(types)
+}
+
+// valueSchema returns the branch of a nullable node, an anyOf or oneOf of a schema and of the
+// null type, or the node itself
+func valueSchema(node map[string]interface{}) map[string]interface{} {
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		branches, _ := node[keyword].([]interface{})
+		var value map[string]interface{}
+		count := 0
+		for _, branch := range branches {
+			branchNode, _ := branch.(map[string]interface{})
+			if types := schemaTypes(branchNode); len(types) == 1 && types[0] == "null" {
+				continue
+			}
+			value = branchNode
+			count++
+		}
+		if count == 1 && value != nil {
+			return value
+		}
+	}
+	return node
+}
+
+// typeNames names the types of a node, "any" for none
+func typeNames(types []string) string {
+	if len(types) == 0 {
+		return "any"
+	}
+	return strings.Join(types, " or ")
+}
+
+// enumValues returns the enum values of a node as JSON, and whether it has an enum
+func enumValues(node map[string]interface{}) ([]string, bool) {
+	values, ok := node["enum"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	encoded := make([]string, 0, len(values))
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		encoded = append(encoded, string(data))
+	}
+	return encoded, true
+}
+
+// missingValues returns the values missing from others, in order
+func missingValues(values, others []string) []string {
+	var missing []string
+	for _, value := range values {
+		if !slices.Contains(others, value) {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
+
+// schemaFormat returns the format of a node, its "x-format" directive taking precedence
+func schemaFormat(node map[string]interface{}) string {
+	if format, ok := node["x-format"].(string); ok {
+		return format
+	}
+	format, _ := node["format"].(string)
+	return format
+}
+
+// requiredOrOptional names whether a field is required
+func requiredOrOptional(required bool) string {
+	if required {
+		return "required"
+	}
+	return "optional"
+}
@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Violation is a value of extracted data breaking an enum or pattern constraint of its schema
+type Violation struct {
+	// Path is the dot-separated path of the value, with the indexes of array items as numbers
+	Path string
+	// Keyword is the broken constraint: "enum" or "pattern"
+	Keyword string
+	// Value is the offending value
+	Value interface{}
+	// Description describes the constraint
+	Description string
+}
+
+// CheckConstraints validates data against a schema and returns the values breaking its enum and
+// pattern constraints. Other validation errors, such as missing properties, are ignored.
+func CheckConstraints(data map[string]interface{}, schema map[string]interface{}) ([]Violation, error) {
+	converted, _, err := validatorSchema(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate data: %w", err)
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(converted), gojsonschema.NewGoLoader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate data: %w", err)
+	}
+
+	var violations []Violation
+	for _, resultError := range result.Errors() {
+		keyword := resultError.Type()
+		if keyword != "enum" && keyword != "pattern" {
+			continue
+		}
+		path := resultError.Field()
+		if path == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+			path = ""
+		}
+		violations = append(violations, Violation{
+			Path:        path,
+			Keyword:     keyword,
+			Value:       resultError.Value(),
+			Description: resultError.Description(),
+		})
+	}
+	return violations, nil
+}
+
+// ValidateData validates data against a schema and returns a description of every validation
+// error, such as a missing required property or a value of the wrong type. The
+// unevaluatedProperties, unevaluatedItems, minContains and maxContains of schemas of draft 2019-09
+// and 2020-12 are checked too.
+func ValidateData(data map[string]interface{}, schema map[string]interface{}) ([]string, error) {
+	converted, draft, err := validatorSchema(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate data: %w", err)
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(converted), gojsonschema.NewGoLoader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate data: %w", err)
+	}
+
+	errors := make([]string, 0, len(result.Errors()))
+	for _, resultError := range result.Errors() {
+		errors = append(errors, resultError.String())
+	}
+	if draft == Draft201909 || draft == Draft202012 {
+		errors = append(errors, checkUnevaluated(data, schema, draft)...)
+	}
+	return errors, nil
+}
@@ -0,0 +1,340 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// JSON Schema drafts a schema can target, see DetectDraft
+const (
+	Draft4      = "draft-04"
+	Draft6      = "draft-06"
+	Draft7      = "draft-07"
+	Draft201909 = "2019-09"
+	Draft202012 = "2020-12"
+)
+
+// draftURIs are the drafts by the URI of their meta-schema, without scheme nor trailing "#"
+var draftURIs = map[string]string{
+	"json-schema.org/draft-04/schema":      Draft4,
+	"json-schema.org/draft-06/schema":      Draft6,
+	"json-schema.org/draft-07/schema":      Draft7,
+	"json-schema.org/draft/2019-09/schema": Draft201909,
+	"json-schema.org/draft/2020-12/schema": Draft202012,
+	// The latest draft
+	"json-schema.org/schema": Draft202012,
+}
+
+// draft2019Keywords are the keywords introduced by draft 2019-09 and kept by 2020-12, and
+// draft2020Keywords those introduced by 2020-12
+var (
+	draft2019Keywords = []string{"$defs", "$anchor", "dependentRequired", "dependentSchemas", "unevaluatedProperties", "unevaluatedItems", "minContains", "maxContains"}
+	draft2020Keywords = []string{"prefixItems", "$dynamicRef", "$dynamicAnchor"}
+)
+
+// unsupportedKeywords are the keywords of draft 2019-09 and 2020-12 that cannot be validated
+var unsupportedKeywords = []string{"$dynamicRef", "$dynamicAnchor", "$recursiveRef", "$recursiveAnchor"}
+
+// DetectDraft returns the JSON Schema draft a schema targets: the draft of its $schema keyword, or
+// else 2020-12 or 2019-09 when it uses keywords they introduced, and draft-07 otherwise. A $schema
+// of an unknown draft is an error.
+func DetectDraft(schema map[string]interface{}) (string, error) {
+	if uri, ok := schema["$schema"]; ok {
+		text, _ := uri.(string)
+		trimmed := strings.TrimSuffix(text, "#")
+		trimmed = strings.TrimPrefix(strings.TrimPrefix(trimmed, "http://"), "https://")
+		if draft, ok := draftURIs[trimmed]; ok {
+			return draft, nil
+		}
+		return "", fmt.Errorf("unsupported $schema %v", uri)
+	}
+	switch {
+	case usesKeyword(schema, draft2020Keywords):
+		return Draft202012, nil
+	case usesKeyword(schema, []string{"$recursiveRef", "$recursiveAnchor"}):
+		return Draft201909, nil
+	case usesKeyword(schema, draft2019Keywords):
+		return Draft202012, nil
+	default:
+		return Draft7, nil
+	}
+}
+
+// usesKeyword reports whether a schema or one of its sub-schemas uses one of the keywords
+func usesKeyword(node interface{}, keywords []string) bool {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			if valueKeywords[key] {
+				continue
+			}
+			for _, keyword := range keywords {
+				if key == keyword {
+					return true
+				}
+			}
+			if key != "properties" && key != "patternProperties" && !isDefinitionKeyword(key) && key != "dependentSchemas" {
+				if usesKeyword(value, keywords) {
+					return true
+				}
+				continue
+			}
+			// The keys of these keywords are names, not keywords
+			children, _ := value.(map[string]interface{})
+			for _, child := range children {
+				if usesKeyword(child, keywords) {
+					return true
+				}
+			}
+		}
+	case []interface{}:
+		for _, value := range node {
+			if usesKeyword(value, keywords) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkDraftKeywords checks the keywords of draft 2019-09 and 2020-12 that the validator does not
+// know, which it would otherwise ignore
+func checkDraftKeywords(node interface{}, draft, path string) error {
+	children := func(value interface{}, path string) error {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			return checkDraftKeywords(value, draft, path)
+		case []interface{}:
+			for i, child := range value {
+				if err := checkDraftKeywords(child, draft, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	schemaNode, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for _, keyword := range unsupportedKeywords {
+		if _, ok := schemaNode[keyword]; ok {
+			return fmt.Errorf("unsupported keyword %s at %s", keyword, pointerOrRoot(path))
+		}
+	}
+	for key, value := range schemaNode {
+		at := path + "/" + escapePointer(key)
+		switch {
+		case valueKeywords[key]:
+		case key == "prefixItems":
+			if list, ok := value.([]interface{}); !ok || !allSchemas(list) {
+				return fmt.Errorf("prefixItems at %s must be an array of schemas", pointerOrRoot(path))
+			}
+			if err := children(value, at); err != nil {
+				return err
+			}
+		case key == "items" && draft == Draft202012:
+			if !isSchema(value) {
+				return fmt.Errorf("items at %s must be a schema in draft 2020-12, use prefixItems for tuples", pointerOrRoot(path))
+			}
+			if err := children(value, at); err != nil {
+				return err
+			}
+		case key == "unevaluatedProperties" || key == "unevaluatedItems":
+			if !isSchema(value) {
+				return fmt.Errorf("%s at %s must be a schema", key, pointerOrRoot(path))
+			}
+			if err := children(value, at); err != nil {
+				return err
+			}
+		case key == "dependentRequired":
+			dependencies, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("dependentRequired at %s must be an object", pointerOrRoot(path))
+			}
+			for name, required := range dependencies {
+				if _, ok := stringList(required); !ok {
+					return fmt.Errorf("dependentRequired %q at %s must be an array of strings", name, pointerOrRoot(path))
+				}
+			}
+		case key == "minContains" || key == "maxContains":
+			if !nonNegativeInteger(value) {
+				return fmt.Errorf("%s at %s must be a non-negative integer", key, pointerOrRoot(path))
+			}
+		case key == "properties" || key == "patternProperties" || key == "dependentSchemas" || isDefinitionKeyword(key):
+			named, _ := value.(map[string]interface{})
+			for name, child := range named {
+				if err := checkDraftKeywords(child, draft, at+"/"+escapePointer(name)); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := children(value, at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// toDraft7 returns a copy of a schema of draft 2019-09 or 2020-12 in the keywords of draft-07
+// where they mean the same: prefixItems and items become items and additionalItems, and
+// dependentRequired and dependentSchemas become dependencies, both of a property in an allOf. The
+// unevaluated keywords and contains with minContains or maxContains, which draft-07 does not
+// know, are left for checkUnevaluated.
+func toDraft7(node interface{}, draft string) interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			switch {
+			case key == "$schema":
+			case valueKeywords[key]:
+				converted[key] = value
+			case key == "properties" || key == "patternProperties" || key == "dependentSchemas" || isDefinitionKeyword(key):
+				named, _ := value.(map[string]interface{})
+				convertedNamed := make(map[string]interface{}, len(named))
+				for name, child := range named {
+					convertedNamed[name] = toDraft7(child, draft)
+				}
+				converted[key] = convertedNamed
+			default:
+				converted[key] = toDraft7(value, draft)
+			}
+		}
+		if draft == Draft202012 {
+			if prefixItems, ok := converted["prefixItems"]; ok {
+				delete(converted, "prefixItems")
+				if items, ok := converted["items"]; ok {
+					converted["additionalItems"] = items
+				}
+				converted["items"] = prefixItems
+			}
+		}
+		_, hasMinContains := converted["minContains"]
+		_, hasMaxContains := converted["maxContains"]
+		if hasMinContains || hasMaxContains {
+			delete(converted, "contains")
+			delete(converted, "minContains")
+			delete(converted, "maxContains")
+		}
+		dependencies := make(map[string]interface{})
+		if required, ok := converted["dependentRequired"].(map[string]interface{}); ok {
+			for name, value := range required {
+				dependencies[name] = value
+			}
+		}
+		if schemas, ok := converted["dependentSchemas"].(map[string]interface{}); ok {
+			for name, value := range schemas {
+				if required, ok := dependencies[name]; ok {
+					value = map[string]interface{}{"allOf": []interface{}{map[string]interface{}{"required": required}, value}}
+				}
+				dependencies[name] = value
+			}
+		}
+		if len(dependencies) > 0 {
+			delete(converted, "dependentRequired")
+			delete(converted, "dependentSchemas")
+			converted["dependencies"] = dependencies
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(node))
+		for i, value := range node {
+			converted[i] = toDraft7(value, draft)
+		}
+		return converted
+	default:
+		return node
+	}
+}
+
+// validatorSchema returns the schema handed to the validator: a copy in the keywords of draft-07
+// for schemas of later drafts, or the schema itself
+func validatorSchema(schema map[string]interface{}) (map[string]interface{}, string, error) {
+	draft, err := DetectDraft(schema)
+	if err != nil {
+		return nil, "", err
+	}
+	if draft != Draft201909 && draft != Draft202012 {
+		return schema, draft, nil
+	}
+	if err := checkDraftKeywords(schema, draft, ""); err != nil {
+		return nil, "", err
+	}
+	converted, _ := toDraft7(schema, draft).(map[string]interface{})
+	return converted, draft, nil
+}
+
+// isSchema reports whether a value is a schema: an object or a boolean
+func isSchema(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, bool:
+		return true
+	default:
+		return false
+	}
+}
+
+// allSchemas reports whether the values of a list are all schemas
+func allSchemas(list []interface{}) bool {
+	for _, value := range list {
+		if !isSchema(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// integer returns the value of a number keyword as an int
+func integer(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		return value, true
+	case float64:
+		return int(value), true
+	default:
+		return 0, false
+	}
+}
+
+// nonNegativeInteger reports whether a value is a non-negative integer
+func nonNegativeInteger(value interface{}) bool {
+	switch value := value.(type) {
+	case int:
+		return value >= 0
+	case float64:
+		return value >= 0 && value == math.Trunc(value)
+	default:
+		return false
+	}
+}
+
+// stringList returns the strings of a list of strings
+func stringList(value interface{}) ([]string, bool) {
+	switch value := value.(type) {
+	case []string:
+		return value, true
+	case []interface{}:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			text, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, text)
+		}
+		return list, true
+	default:
+		return nil, false
+	}
+}
+
+// pointerOrRoot names a JSON pointer of a schema, "/" for the root
+func pointerOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
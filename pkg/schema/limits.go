@@ -0,0 +1,194 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// ErrSchemaLimit is returned, wrapped in a *LimitError, when a schema exceeds a limit of
+// structured output
+var ErrSchemaLimit = errors.New("schema limit exceeded")
+
+// LimitError reports the first limit of structured output a schema exceeds. It matches
+// ErrSchemaLimit with errors.Is.
+type LimitError struct {
+	// Limit names what exceeds the limit, such as "properties" or "levels of nesting"
+	Limit string
+	// Max is the limit
+	Max int
+	// Path is the JSON pointer of the schema where the limit is exceeded, such as "/properties/items"
+	Path string
+}
+
+// Error describes the limit and where it is exceeded
+func (e *LimitError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("schema exceeds %d %s at %s", e.Max, e.Limit, path)
+}
+
+// Unwrap returns ErrSchemaLimit
+func (e *LimitError) Unwrap() error {
+	return ErrSchemaLimit
+}
+
+// Limits are the limits of structured output on the size of schemas, see CheckLimits. Zero fields
+// are not checked.
+type Limits struct {
+	// MaxProperties is the number of object properties of the whole schema
+	MaxProperties int
+	// MaxDepth is the number of levels of nested objects
+	MaxDepth int
+	// MaxEnumValues is the number of enum values of the whole schema
+	MaxEnumValues int
+	// MaxStringLength is the number of characters of the property names, definition names and
+	// string enum and const values of the whole schema
+	MaxStringLength int
+	// LargeEnumValues is the number of string values above which an enum is large
+	LargeEnumValues int
+	// MaxLargeEnumLength is the number of characters of the values of a large enum
+	MaxLargeEnumLength int
+}
+
+// OpenAILimits are the limits of the structured outputs of OpenAI
+var OpenAILimits = Limits{
+	MaxProperties:      5000,
+	MaxDepth:           10,
+	MaxEnumValues:      1000,
+	MaxStringLength:    120000,
+	LargeEnumValues:    250,
+	MaxLargeEnumLength: 15000,
+}
+
+// CheckLimits checks a schema against the limits of structured output on its size, which models
+// otherwise reject with an opaque error. The schema is walked in key order, and a *LimitError
+// reports the first limit exceeded. Zero limits are not checked.
+func CheckLimits(schema map[string]interface{}, limits Limits) error {
+	counter := &limitCounter{limits: limits}
+	return counter.walk(schema, "", 0)
+}
+
+// limitCounter counts the size of the schema walked so far
+type limitCounter struct {
+	limits                               Limits
+	properties, enumValues, stringLength int
+}
+
+// walk counts a node of a schema at a JSON pointer, nested in depth objects
+func (c *limitCounter) walk(node map[string]interface{}, path string, depth int) error {
+	if properties, ok := node["properties"].(map[string]interface{}); ok {
+		depth++
+		if exceeds(depth, c.limits.MaxDepth) {
+			return &LimitError{Limit: "levels of nesting", Max: c.limits.MaxDepth, Path: path}
+		}
+		for _, name := range sortedKeys(properties) {
+			c.properties++
+			if exceeds(c.properties, c.limits.MaxProperties) {
+				return &LimitError{Limit: "properties", Max: c.limits.MaxProperties, Path: path}
+			}
+			if err := c.addString(name, path); err != nil {
+				return err
+			}
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				if err := c.walk(property, path+"/properties/"+escapePointer(name), depth); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := c.countValues(node, path); err != nil {
+		return err
+	}
+
+	for _, keyword := range []string{"$defs", "definitions"} {
+		definitions, _ := node[keyword].(map[string]interface{})
+		for _, name := range sortedKeys(definitions) {
+			if err := c.addString(name, path); err != nil {
+				return err
+			}
+			// Definitions are nested where they are referenced, which is not followed
+			if definition, ok := definitions[name].(map[string]interface{}); ok {
+				if err := c.walk(definition, path+"/"+keyword+"/"+escapePointer(name), 0); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, keyword := range []string{"items", "additionalProperties", "not"} {
+		if child, ok := node[keyword].(map[string]interface{}); ok {
+			if err := c.walk(child, path+"/"+keyword, depth); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf", "allOf", "prefixItems"} {
+		children, _ := node[keyword].([]interface{})
+		for i, child := range children {
+			if child, ok := child.(map[string]interface{}); ok {
+				if err := c.walk(child, fmt.Sprintf("%s/%s/%d", path, keyword, i), depth); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// countValues counts the enum and const values of a node
+func (c *limitCounter) countValues(node map[string]interface{}, path string) error {
+	if value, ok := node["const"].(string); ok {
+		if err := c.addString(value, path); err != nil {
+			return err
+		}
+	}
+	values, _ := node["enum"].([]interface{})
+	if len(values) == 0 {
+		return nil
+	}
+	c.enumValues += len(values)
+	if exceeds(c.enumValues, c.limits.MaxEnumValues) {
+		return &LimitError{Limit: "enum values", Max: c.limits.MaxEnumValues, Path: path}
+	}
+	texts, length := 0, 0
+	for _, value := range values {
+		if text, ok := value.(string); ok {
+			texts++
+			length += utf8.RuneCountInString(text)
+			if err := c.addString(text, path); err != nil {
+				return err
+			}
+		}
+	}
+	if texts > c.limits.LargeEnumValues && exceeds(length, c.limits.MaxLargeEnumLength) {
+		return &LimitError{Limit: fmt.Sprintf("characters in an enum of more than %d values", c.limits.LargeEnumValues), Max: c.limits.MaxLargeEnumLength, Path: path}
+	}
+	return nil
+}
+
+// addString counts a name or value of the schema
+func (c *limitCounter) addString(text, path string) error {
+	c.stringLength += utf8.RuneCountInString(text)
+	if exceeds(c.stringLength, c.limits.MaxStringLength) {
+		return &LimitError{Limit: "characters of names and values", Max: c.limits.MaxStringLength, Path: path}
+	}
+	return nil
+}
+
+// exceeds reports whether a count exceeds a limit, zero limits being unchecked
+func exceeds(count, limit int) bool {
+	return limit > 0 && count > limit
+}
+
+// sortedKeys returns the keys of a map, sorted
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,287 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// definitionKeywords are the keywords holding the reusable sub-schemas of a schema
+var definitionKeywords = []string{"$defs", "definitions"}
+
+// valueKeywords are the keywords holding JSON values rather than schemas, whose "$ref" keys are
+// not references
+var valueKeywords = map[string]bool{"const": true, "default": true, "enum": true, "examples": true}
+
+// RefLoader returns the schema document of the URI of an external reference, such as
+// "common.json" for "common.json#/$defs/address"
+type RefLoader func(uri string) (map[string]interface{}, error)
+
+// InlineRefs returns a copy of a schema whose "$ref" references are replaced by the sub-schemas
+// they point to, for models that accept neither external references nor, often, local ones.
+// References are local JSON pointers, such as "#/$defs/address", or URIs of other documents,
+// optionally followed by a pointer, which are read with load; relative URIs are resolved against
+// the URI of the document holding them. Keywords next to a reference, such as "description",
+// override those of the sub-schema. The definitions of the schema are dropped, unless a recursive
+// reference to the schema or one of its definitions, which cannot be inlined, is kept; other
+// recursive references are an error. The schema is returned as is when it holds no reference.
+func InlineRefs(schema map[string]interface{}, load RefLoader) (map[string]interface{}, error) {
+	if !hasRefs(schema) {
+		return schema, nil
+	}
+	inliner := &refInliner{root: schema, load: load, documents: make(map[string]map[string]interface{})}
+	inlined, err := inliner.inline(schema, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	result := inlined.(map[string]interface{})
+	if !inliner.kept {
+		return result, nil
+	}
+	for _, keyword := range definitionKeywords {
+		definitions, _ := schema[keyword].(map[string]interface{})
+		if definitions == nil {
+			continue
+		}
+		inlinedDefinitions := make(map[string]interface{}, len(definitions))
+		for name, definition := range definitions {
+			pointer := "/" + keyword + "/" + escapePointer(name)
+			if inlinedDefinitions[name], err = inliner.inline(definition, "", []string{"#" + pointer}); err != nil {
+				return nil, err
+			}
+		}
+		result[keyword] = inlinedDefinitions
+	}
+	return result, nil
+}
+
+// refInliner replaces the references of a schema
+type refInliner struct {
+	root map[string]interface{}
+	load RefLoader
+	// documents are the external documents loaded, by URI
+	documents map[string]map[string]interface{}
+	// kept reports whether a recursive reference of the root was kept
+	kept bool
+}
+
+// inline returns a copy of a node of the document of a URI, "" for the root, with its references
+// replaced. The stack holds the references being inlined, to detect recursion.
+func (r *refInliner) inline(node interface{}, uri string, stack []string) (interface{}, error) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := node["$ref"].(string); ok {
+			return r.inlineRef(node, ref, uri, stack)
+		}
+		inlined := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			if isDefinitionKeyword(key) {
+				// Definitions are inlined where they are referenced
+				continue
+			}
+			if valueKeywords[key] {
+				inlined[key] = value
+				continue
+			}
+			child, err := r.inline(value, uri, stack)
+			if err != nil {
+				return nil, err
+			}
+			inlined[key] = child
+		}
+		return inlined, nil
+	case []interface{}:
+		inlined := make([]interface{}, len(node))
+		for i, value := range node {
+			child, err := r.inline(value, uri, stack)
+			if err != nil {
+				return nil, err
+			}
+			inlined[i] = child
+		}
+		return inlined, nil
+	default:
+		return node, nil
+	}
+}
+
+// inlineRef returns the sub-schema a reference of a node points to, inlined, with the other
+// keywords of the node
+func (r *refInliner) inlineRef(node map[string]interface{}, ref, uri string, stack []string) (interface{}, error) {
+	target, fragment, _ := strings.Cut(ref, "#")
+	if target != "" {
+		resolved, err := resolveURI(uri, target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+		}
+		target = resolved
+	} else {
+		target = uri
+	}
+	key := target + "#" + fragment
+	for _, inlining := range stack {
+		if inlining != key {
+			continue
+		}
+		if target != "" || (fragment != "" && !isDefinitionPointer(fragment)) {
+			return nil, fmt.Errorf("recursive $ref %q cannot be inlined", ref)
+		}
+		// The root and its definitions are sent along, so the reference can stay
+		r.kept = true
+		return node, nil
+	}
+
+	document := r.root
+	if target != "" {
+		var err error
+		if document, err = r.document(target); err != nil {
+			return nil, fmt.Errorf("failed to load $ref %q: %w", ref, err)
+		}
+	}
+	subSchema, err := resolvePointer(document, fragment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+	}
+	inlined, err := r.inline(subSchema, target, append(stack[:len(stack):len(stack)], key))
+	if err != nil {
+		return nil, err
+	}
+	result, ok := inlined.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$ref %q does not point to a schema", ref)
+	}
+	if len(node) > 1 {
+		result = copySchemaKeys(result)
+		for key, value := range node {
+			if key == "$ref" {
+				continue
+			}
+			if result[key], err = r.inline(value, uri, stack); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// document returns the external document of a URI, loading it once
+func (r *refInliner) document(uri string) (map[string]interface{}, error) {
+	if document, ok := r.documents[uri]; ok {
+		return document, nil
+	}
+	if r.load == nil {
+		return nil, errors.New("no loader of external references")
+	}
+	document, err := r.load(uri)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, fmt.Errorf("no schema %s", uri)
+	}
+	r.documents[uri] = document
+	return document, nil
+}
+
+// resolveURI resolves a URI reference against the URI of the document holding it
+func resolveURI(base, reference string) (string, error) {
+	referenceURL, err := url.Parse(reference)
+	if err != nil {
+		return "", err
+	}
+	if base == "" {
+		return referenceURL.String(), nil
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(referenceURL).String(), nil
+}
+
+// resolvePointer returns the value a JSON pointer, such as "/$defs/address", points to in a
+// document
+func resolvePointer(document map[string]interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return document, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("unsupported anchor %q, expected a JSON pointer", pointer)
+	}
+	var current interface{} = document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node := current.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("no %q in %q", token, pointer)
+			}
+			current = child
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("no item %q in %q", token, pointer)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("no %q in %q", token, pointer)
+		}
+	}
+	return current, nil
+}
+
+// isDefinitionKeyword reports whether a keyword holds the definitions of a schema
+func isDefinitionKeyword(keyword string) bool {
+	return slices.Contains(definitionKeywords, keyword)
+}
+
+// isDefinitionPointer reports whether a JSON pointer points to a definition of the root, such as
+// "/$defs/node"
+func isDefinitionPointer(pointer string) bool {
+	for _, keyword := range definitionKeywords {
+		if name, ok := strings.CutPrefix(pointer, "/"+keyword+"/"); ok && !strings.Contains(name, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// escapePointer escapes a key as a token of a JSON pointer
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// copySchemaKeys returns a shallow copy of a schema
+func copySchemaKeys(schema map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		copied[key] = value
+	}
+	return copied
+}
+
+// hasRefs reports whether a schema holds a reference
+func hasRefs(node interface{}) bool {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		if _, ok := node["$ref"].(string); ok {
+			return true
+		}
+		for key, value := range node {
+			if !valueKeywords[key] && hasRefs(value) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, value := range node {
+			if hasRefs(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
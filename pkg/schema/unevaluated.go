@@ -0,0 +1,340 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// maxUnevaluatedDepth bounds the references followed by checkUnevaluated, for recursive schemas
+const maxUnevaluatedDepth = 64
+
+// checkUnevaluated checks data against the unevaluatedProperties and unevaluatedItems keywords of
+// a schema of draft 2019-09 or 2020-12, and against contains with minContains or maxContains,
+// which the validator does not know. The properties and
+// items evaluated by the subschemas of allOf, anyOf, oneOf, if, then, else, dependentSchemas and
+// local references are counted, those of anyOf, oneOf and if only when they validate the data.
+// The errors are described like those of the validator.
+func checkUnevaluated(data interface{}, schema map[string]interface{}, draft string) []string {
+	checker := &unevaluatedChecker{root: schema, draft: draft}
+	checker.walk(schema, data, "", 0)
+	// A value is walked once for every subschema applied to it
+	slices.Sort(checker.errors)
+	return slices.Compact(checker.errors)
+}
+
+// unevaluatedChecker walks data along its schema
+type unevaluatedChecker struct {
+	root   map[string]interface{}
+	draft  string
+	errors []string
+}
+
+// walk checks a value against a node of the schema at a dot-separated path
+func (c *unevaluatedChecker) walk(node interface{}, value interface{}, path string, depth int) {
+	schemaNode, ok := node.(map[string]interface{})
+	if !ok || depth > maxUnevaluatedDepth {
+		return
+	}
+	if target := c.ref(schemaNode); target != nil {
+		c.walk(target, value, path, depth+1)
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		c.checkProperties(schemaNode, value, path, depth)
+	case []interface{}:
+		c.checkItems(schemaNode, value, path, depth)
+	}
+	for _, branch := range c.applied(schemaNode, value) {
+		c.walk(branch, value, path, depth+1)
+	}
+}
+
+// checkProperties checks the unevaluated properties of an object and walks its properties
+func (c *unevaluatedChecker) checkProperties(node map[string]interface{}, object map[string]interface{}, path string, depth int) {
+	unevaluated, hasUnevaluated := node["unevaluatedProperties"]
+	var evaluated map[string]bool
+	all := true
+	if hasUnevaluated {
+		evaluated, all = c.evaluatedProperties(node, object, true, depth)
+	}
+	properties, _ := node["properties"].(map[string]interface{})
+	patterns := compiledPatterns(node)
+	for _, key := range sortedKeys(object) {
+		child := join(path, key)
+		matched := false
+		if property, ok := properties[key]; ok {
+			c.walk(property, object[key], child, depth+1)
+			matched = true
+		}
+		for pattern, property := range patterns {
+			if pattern.MatchString(key) {
+				c.walk(property, object[key], child, depth+1)
+				matched = true
+			}
+		}
+		if additional, ok := node["additionalProperties"]; ok && !matched {
+			c.walk(additional, object[key], child, depth+1)
+		}
+		if all || evaluated[key] {
+			continue
+		}
+		if !c.valid(unevaluated, object[key]) {
+			c.errors = append(c.errors, fmt.Sprintf("%s: Property %q is not allowed by unevaluatedProperties", rootOr(path), key))
+			continue
+		}
+		c.walk(unevaluated, object[key], child, depth+1)
+	}
+}
+
+// checkItems checks the unevaluated items of an array and walks its items
+func (c *unevaluatedChecker) checkItems(node map[string]interface{}, array []interface{}, path string, depth int) {
+	unevaluated, hasUnevaluated := node["unevaluatedItems"]
+	evaluated := len(array)
+	if hasUnevaluated {
+		evaluated = c.evaluatedItems(node, array, true, depth)
+	}
+	if message := c.containsError(node, array); message != "" {
+		c.errors = append(c.errors, fmt.Sprintf("%s: %s", rootOr(path), message))
+	}
+	prefix, rest := c.itemSchemas(node)
+	for i, item := range array {
+		child := join(path, strconv.Itoa(i))
+		switch {
+		case i < len(prefix):
+			c.walk(prefix[i], item, child, depth+1)
+		case rest != nil:
+			c.walk(rest, item, child, depth+1)
+		}
+		if i < evaluated {
+			continue
+		}
+		if !c.valid(unevaluated, item) {
+			c.errors = append(c.errors, fmt.Sprintf("%s: Item %d is not allowed by unevaluatedItems", rootOr(path), i))
+			continue
+		}
+		c.walk(unevaluated, item, child, depth+1)
+	}
+}
+
+// containsError describes how an array matches the contains of a node fewer times than its
+// minContains or more than its maxContains, or returns "" when it does not
+func (c *unevaluatedChecker) containsError(node map[string]interface{}, array []interface{}) string {
+	contains, ok := node["contains"]
+	minimum, hasMinimum := integer(node["minContains"])
+	maximum, hasMaximum := integer(node["maxContains"])
+	if !ok || (!hasMinimum && !hasMaximum) {
+		return ""
+	}
+	if !hasMinimum {
+		minimum = 1
+	}
+	matches := 0
+	for _, item := range array {
+		if c.valid(contains, item) {
+			matches++
+		}
+	}
+	switch {
+	case matches < minimum:
+		return fmt.Sprintf("Array must contain at least %d items matching contains, got %d", minimum, matches)
+	case hasMaximum && matches > maximum:
+		return fmt.Sprintf("Array must contain at most %d items matching contains, got %d", maximum, matches)
+	default:
+		return ""
+	}
+}
+
+// evaluatedProperties returns the properties of an object evaluated by a node and the subschemas
+// it applies, or whether all of them are. The unevaluatedProperties of the node itself are left
+// out when self is set.
+func (c *unevaluatedChecker) evaluatedProperties(node map[string]interface{}, object map[string]interface{}, self bool, depth int) (map[string]bool, bool) {
+	evaluated := make(map[string]bool)
+	if depth > maxUnevaluatedDepth {
+		return evaluated, true
+	}
+	if _, ok := node["additionalProperties"]; ok {
+		return evaluated, true
+	}
+	if _, ok := node["unevaluatedProperties"]; ok && !self {
+		return evaluated, true
+	}
+	properties, _ := node["properties"].(map[string]interface{})
+	patterns := compiledPatterns(node)
+	for key := range object {
+		if _, ok := properties[key]; ok {
+			evaluated[key] = true
+		}
+		for pattern := range patterns {
+			if pattern.MatchString(key) {
+				evaluated[key] = true
+			}
+		}
+	}
+	branches := c.applied(node, object)
+	if target := c.ref(node); target != nil {
+		branches = append(branches, target)
+	}
+	for _, branch := range branches {
+		branchNode, ok := branch.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		keys, all := c.evaluatedProperties(branchNode, object, false, depth+1)
+		if all {
+			return evaluated, true
+		}
+		for key := range keys {
+			evaluated[key] = true
+		}
+	}
+	return evaluated, false
+}
+
+// evaluatedItems returns the number of leading items of an array evaluated by a node and the
+// subschemas it applies, or the maximum int when all of them are. The unevaluatedItems of the
+// node itself are left out when self is set.
+func (c *unevaluatedChecker) evaluatedItems(node map[string]interface{}, array []interface{}, self bool, depth int) int {
+	const all = int(^uint(0) >> 1)
+	if depth > maxUnevaluatedDepth {
+		return all
+	}
+	if _, ok := node["unevaluatedItems"]; ok && !self {
+		return all
+	}
+	prefix, rest := c.itemSchemas(node)
+	if rest != nil {
+		return all
+	}
+	evaluated := len(prefix)
+	branches := c.applied(node, array)
+	if target := c.ref(node); target != nil {
+		branches = append(branches, target)
+	}
+	for _, branch := range branches {
+		if branchNode, ok := branch.(map[string]interface{}); ok {
+			evaluated = max(evaluated, c.evaluatedItems(branchNode, array, false, depth+1))
+		}
+	}
+	return evaluated
+}
+
+// itemSchemas returns the schemas of the leading items of an array and of the other items, if any,
+// in the keywords of the draft
+func (c *unevaluatedChecker) itemSchemas(node map[string]interface{}) ([]interface{}, interface{}) {
+	if c.draft == Draft202012 {
+		prefix, _ := node["prefixItems"].([]interface{})
+		return prefix, node["items"]
+	}
+	if prefix, ok := node["items"].([]interface{}); ok {
+		return prefix, node["additionalItems"]
+	}
+	return nil, node["items"]
+}
+
+// applied returns the subschemas a node applies to a value: allOf, the branches of anyOf and oneOf
+// validating it, if with then or else depending on whether if validates it, and the
+// dependentSchemas of the properties it has
+func (c *unevaluatedChecker) applied(node map[string]interface{}, value interface{}) []interface{} {
+	var branches []interface{}
+	allOf, _ := node["allOf"].([]interface{})
+	branches = append(branches, allOf...)
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		list, _ := node[keyword].([]interface{})
+		for _, branch := range list {
+			if c.valid(branch, value) {
+				branches = append(branches, branch)
+			}
+		}
+	}
+	if condition, ok := node["if"]; ok {
+		if c.valid(condition, value) {
+			branches = append(branches, condition)
+			if then, ok := node["then"]; ok {
+				branches = append(branches, then)
+			}
+		} else if otherwise, ok := node["else"]; ok {
+			branches = append(branches, otherwise)
+		}
+	}
+	dependentSchemas, _ := node["dependentSchemas"].(map[string]interface{})
+	object, _ := value.(map[string]interface{})
+	for _, name := range sortedKeys(dependentSchemas) {
+		if _, ok := object[name]; ok {
+			branches = append(branches, dependentSchemas[name])
+		}
+	}
+	return branches
+}
+
+// ref returns the schema a local reference of a node points to, or nil
+func (c *unevaluatedChecker) ref(node map[string]interface{}) interface{} {
+	ref, ok := node["$ref"].(string)
+	if !ok || len(ref) == 0 || ref[0] != '#' {
+		return nil
+	}
+	target, err := resolvePointer(c.root, ref[1:])
+	if err != nil {
+		return nil
+	}
+	return target
+}
+
+// valid reports whether a value is valid against a subschema, minContains and maxContains of the
+// subschema itself included. The definitions of the schema are added to the subschema, so that its
+// local references to them resolve.
+func (c *unevaluatedChecker) valid(node interface{}, value interface{}) bool {
+	switch node := node.(type) {
+	case bool:
+		return node
+	case map[string]interface{}:
+		standalone := copySchemaKeys(node)
+		for _, keyword := range definitionKeywords {
+			if definitions, ok := c.root[keyword]; ok {
+				if _, ok := standalone[keyword]; !ok {
+					standalone[keyword] = definitions
+				}
+			}
+		}
+		if array, ok := value.([]interface{}); ok && c.containsError(node, array) != "" {
+			return false
+		}
+		converted := toDraft7(standalone, c.draft)
+		result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(converted), gojsonschema.NewGoLoader(value))
+		return err == nil && result.Valid()
+	default:
+		return true
+	}
+}
+
+// compiledPatterns returns the schemas of the patternProperties of a node by compiled pattern
+func compiledPatterns(node map[string]interface{}) map[*regexp.Regexp]interface{} {
+	patternProperties, _ := node["patternProperties"].(map[string]interface{})
+	patterns := make(map[*regexp.Regexp]interface{}, len(patternProperties))
+	for pattern, property := range patternProperties {
+		if compiled, err := regexp.Compile(pattern); err == nil {
+			patterns[compiled] = property
+		}
+	}
+	return patterns
+}
+
+// join appends a key to a dot-separated path
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// rootOr names a dot-separated path like the validator, "(root)" for the root
+func rootOr(path string) string {
+	if path == "" {
+		return gojsonschema.STRING_ROOT_SCHEMA_PROPERTY
+	}
+	return path
+}
@@ -7,7 +7,8 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 )
 
-// ValidateSchema validates that a JSON schema is properly formatted
+// ValidateSchema validates that a JSON schema is properly formatted. Schemas of draft 2019-09 and
+// 2020-12, see DetectDraft, have the keywords they introduced checked too.
 func ValidateSchema(schema map[string]interface{}) error {
 	// Basic type checking
 	if schema == nil {
@@ -19,12 +20,17 @@ func ValidateSchema(schema map[string]interface{}) error {
 		return errors.New("schema cannot be empty")
 	}
 
+	// gojsonschema knows the keywords up to draft-07
+	converted, _, err := validatorSchema(schema)
+	if err != nil {
+		return fmt.Errorf("schema validation failed: %w", err)
+	}
+
 	// Use gojsonschema to validate the schema
-	schemaLoader := gojsonschema.NewGoLoader(schema)
+	schemaLoader := gojsonschema.NewGoLoader(converted)
 
 	// Try to load the schema - this will validate it's a valid JSON schema
-	_, err := gojsonschema.NewSchema(schemaLoader)
-	if err != nil {
+	if _, err := gojsonschema.NewSchema(schemaLoader); err != nil {
 		return fmt.Errorf("schema validation failed: %w", err)
 	}
 
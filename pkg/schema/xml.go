@@ -0,0 +1,401 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultRootElement = "extraction"
+	defaultItemElement = "item"
+	xsdNamespace       = "http://www.w3.org/2001/XMLSchema"
+	xsiNamespace       = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// XMLOptions configures the XML serialization of extracted data and the generated XSD
+type XMLOptions struct {
+	// RootElement is the name of the document element (default: "extraction")
+	RootElement string
+	// ItemElement is the name of the elements wrapping array items (default: "item")
+	ItemElement string
+	// Namespace is the target namespace of the documents, if any
+	Namespace string
+}
+
+// MarshalXML serializes extracted data to XML. The element structure follows the JSON schema:
+// each property becomes an element, in the order of the schema's required list (then
+// alphabetically), arrays wrap each item in an ItemElement element, and null values are written
+// as nil elements. Properties missing from the schema are left out, so that the document is valid
+// against the XSD generated by GenerateXSD for the same schema and options.
+func MarshalXML(data map[string]interface{}, schema map[string]interface{}, options XMLOptions) ([]byte, error) {
+	options = xmlDefaults(options)
+
+	// Normalize to generic JSON values, whatever Go types the data was built with
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+
+	root := xml.StartElement{
+		Name: xml.Name{Local: elementName(options.RootElement)},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace}},
+	}
+	if options.Namespace != "" {
+		root.Attr = append(root.Attr, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: options.Namespace})
+	}
+	if err := encodeXMLContent(encoder, root, value, schema, options); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write XML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeXMLContent writes value as the element start, following its schema
+func encodeXMLContent(encoder *xml.Encoder, start xml.StartElement, value interface{}, schema map[string]interface{}, options XMLOptions) error {
+	if value == nil {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xsi:nil"}, Value: "true"})
+		return encodeTokens(encoder, start, start.End())
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if err := encodeTokens(encoder, start); err != nil {
+			return err
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range propertyOrder(schema) {
+			field, ok := v[name]
+			if !ok {
+				continue
+			}
+			fieldSchema, _ := properties[name].(map[string]interface{})
+			child := xml.StartElement{Name: xml.Name{Local: elementName(name)}}
+			if err := encodeXMLContent(encoder, child, field, fieldSchema, options); err != nil {
+				return err
+			}
+		}
+		return encodeTokens(encoder, start.End())
+	case []interface{}:
+		if err := encodeTokens(encoder, start); err != nil {
+			return err
+		}
+		itemSchema, _ := schema["items"].(map[string]interface{})
+		for _, item := range v {
+			child := xml.StartElement{Name: xml.Name{Local: elementName(options.ItemElement)}}
+			if err := encodeXMLContent(encoder, child, item, itemSchema, options); err != nil {
+				return err
+			}
+		}
+		return encodeTokens(encoder, start.End())
+	default:
+		return encodeTokens(encoder, start, xml.CharData(formatXMLValue(v)), start.End())
+	}
+}
+
+// encodeTokens writes XML tokens, wrapping the first error
+func encodeTokens(encoder *xml.Encoder, tokens ...xml.Token) error {
+	for _, token := range tokens {
+		if err := encoder.EncodeToken(token); err != nil {
+			return fmt.Errorf("failed to write XML: %w", err)
+		}
+	}
+	return nil
+}
+
+// formatXMLValue formats a scalar JSON value as element text
+func formatXMLValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// GenerateXSD generates an XML Schema describing the documents written by MarshalXML for the
+// given JSON schema and options. Required properties are mandatory elements, nullable types are
+// nillable elements and enums become enumeration restrictions.
+func GenerateXSD(schema map[string]interface{}, options XMLOptions) ([]byte, error) {
+	if err := ValidateSchema(schema); err != nil {
+		return nil, err
+	}
+	options = xmlDefaults(options)
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+
+	root := xml.StartElement{
+		Name: xml.Name{Local: "xs:schema"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns:xs"}, Value: xsdNamespace},
+			{Name: xml.Name{Local: "elementFormDefault"}, Value: "qualified"},
+		},
+	}
+	if options.Namespace != "" {
+		root.Attr = append(root.Attr,
+			xml.Attr{Name: xml.Name{Local: "targetNamespace"}, Value: options.Namespace},
+			xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: options.Namespace},
+		)
+	}
+	if err := encodeTokens(encoder, root); err != nil {
+		return nil, err
+	}
+	if err := encodeXSDElement(encoder, options.RootElement, schema, nil, options); err != nil {
+		return nil, err
+	}
+	if err := encodeTokens(encoder, root.End()); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write XSD: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeXSDElement writes the declaration of an element with the given occurrence constraints
+func encodeXSDElement(encoder *xml.Encoder, name string, schema map[string]interface{}, occurs []xml.Attr, options XMLOptions) error {
+	typeName, nullable := schemaType(schema)
+
+	element := xml.StartElement{
+		Name: xml.Name{Local: "xs:element"},
+		Attr: append([]xml.Attr{{Name: xml.Name{Local: "name"}, Value: elementName(name)}}, occurs...),
+	}
+	if nullable {
+		element.Attr = append(element.Attr, xml.Attr{Name: xml.Name{Local: "nillable"}, Value: "true"})
+	}
+
+	enum, _ := schema["enum"].([]interface{})
+	if enum == nil {
+		if values, ok := schema["enum"].([]string); ok {
+			for _, value := range values {
+				enum = append(enum, value)
+			}
+		}
+	}
+
+	switch {
+	case typeName == "object":
+		properties, _ := schema["properties"].(map[string]interface{})
+		requiredNames := requiredSet(schema)
+		if err := encodeTokens(encoder, element, xsStart("complexType"), xsStart("sequence")); err != nil {
+			return err
+		}
+		for _, property := range propertyOrder(schema) {
+			propertySchema, _ := properties[property].(map[string]interface{})
+			var occurs []xml.Attr
+			if !requiredNames[property] {
+				occurs = []xml.Attr{{Name: xml.Name{Local: "minOccurs"}, Value: "0"}}
+			}
+			if err := encodeXSDElement(encoder, property, propertySchema, occurs, options); err != nil {
+				return err
+			}
+		}
+		return encodeTokens(encoder, xsEnd("sequence"), xsEnd("complexType"), element.End())
+	case typeName == "array":
+		itemSchema, _ := schema["items"].(map[string]interface{})
+		if err := encodeTokens(encoder, element, xsStart("complexType"), xsStart("sequence")); err != nil {
+			return err
+		}
+		occurs := []xml.Attr{
+			{Name: xml.Name{Local: "minOccurs"}, Value: "0"},
+			{Name: xml.Name{Local: "maxOccurs"}, Value: "unbounded"},
+		}
+		if err := encodeXSDElement(encoder, options.ItemElement, itemSchema, occurs, options); err != nil {
+			return err
+		}
+		return encodeTokens(encoder, xsEnd("sequence"), xsEnd("complexType"), element.End())
+	case len(enum) > 0:
+		if err := encodeTokens(encoder, element); err != nil {
+			return err
+		}
+		if err := encodeXSDEnum(encoder, typeName, enum); err != nil {
+			return err
+		}
+		return encodeTokens(encoder, element.End())
+	default:
+		element.Attr = append(element.Attr, xml.Attr{Name: xml.Name{Local: "type"}, Value: xsdType(typeName)})
+		return encodeTokens(encoder, element, element.End())
+	}
+}
+
+// encodeXSDEnum writes an anonymous simple type restricted to the enum values
+func encodeXSDEnum(encoder *xml.Encoder, typeName string, values []interface{}) error {
+	restriction := xsStart("restriction")
+	restriction.Attr = []xml.Attr{{Name: xml.Name{Local: "base"}, Value: xsdType(typeName)}}
+	if err := encodeTokens(encoder, xsStart("simpleType"), restriction); err != nil {
+		return err
+	}
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		enumeration := xsStart("enumeration")
+		enumeration.Attr = []xml.Attr{{Name: xml.Name{Local: "value"}, Value: formatXMLValue(value)}}
+		if err := encodeTokens(encoder, enumeration, enumeration.End()); err != nil {
+			return err
+		}
+	}
+	return encodeTokens(encoder, restriction.End(), xsEnd("simpleType"))
+}
+
+func xsStart(name string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Local: "xs:" + name}}
+}
+
+func xsEnd(name string) xml.EndElement {
+	return xml.EndElement{Name: xml.Name{Local: "xs:" + name}}
+}
+
+// schemaType returns the JSON type of a schema and whether it allows null
+func schemaType(schema map[string]interface{}) (string, bool) {
+	switch t := schema["type"].(type) {
+	case string:
+		return t, false
+	case []interface{}, []string:
+		var names []string
+		if list, ok := t.([]string); ok {
+			names = list
+		} else {
+			for _, name := range t.([]interface{}) {
+				if s, ok := name.(string); ok {
+					names = append(names, s)
+				}
+			}
+		}
+		typeName, nullable := "", false
+		for _, name := range names {
+			if name == "null" {
+				nullable = true
+			} else if typeName == "" {
+				typeName = name
+			}
+		}
+		return typeName, nullable
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object", false
+	}
+	return "", false
+}
+
+// xsdType maps a JSON scalar type to a built-in XML Schema type
+func xsdType(typeName string) string {
+	switch typeName {
+	case "string":
+		return "xs:string"
+	case "number":
+		return "xs:decimal"
+	case "integer":
+		return "xs:integer"
+	case "boolean":
+		return "xs:boolean"
+	default:
+		return "xs:anyType"
+	}
+}
+
+// propertyOrder returns the property names of an object schema: required properties in their
+// listed order, then the others alphabetically
+func propertyOrder(schema map[string]interface{}) []string {
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(properties))
+	seen := make(map[string]bool, len(properties))
+	for _, name := range requiredList(schema) {
+		if _, ok := properties[name]; ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	rest := make([]string, 0)
+	for name := range properties {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// requiredList returns the required property names of an object schema
+func requiredList(schema map[string]interface{}) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []interface{}:
+		names := make([]string, 0, len(required))
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// requiredSet returns the required property names of an object schema as a set
+func requiredSet(schema map[string]interface{}) map[string]bool {
+	set := make(map[string]bool)
+	for _, name := range requiredList(schema) {
+		set[name] = true
+	}
+	return set
+}
+
+// elementName turns a property name into a valid XML element name
+func elementName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+			b.WriteRune(r)
+		case unicode.IsDigit(r) || r == '-' || r == '.':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 || strings.HasPrefix(strings.ToLower(b.String()), "xml") {
+		return "_" + b.String()
+	}
+	return b.String()
+}
+
+// xmlDefaults fills in the default XML options
+func xmlDefaults(options XMLOptions) XMLOptions {
+	if options.RootElement == "" {
+		options.RootElement = defaultRootElement
+	}
+	if options.ItemElement == "" {
+		options.ItemElement = defaultItemElement
+	}
+	return options
+}
@@ -0,0 +1,264 @@
+// Package store provides stores of the history of extractions: in memory, e.g. for tests, and in
+// SQLite and PostgreSQL databases. Set one as ExtractorConfig.Store.
+//
+// The SQL stores use database/sql, so the application chooses and registers the driver, e.g.
+// github.com/mattn/go-sqlite3 or modernc.org/sqlite for SQLite, and github.com/jackc/pgx/v5/stdlib
+// or github.com/lib/pq for PostgreSQL.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// Table is the table of the extractions in SQL databases
+const Table = "extractions"
+
+// Memory is a store keeping the extractions in memory. It is safe for concurrent use.
+type Memory struct {
+	mu          sync.Mutex
+	extractions []types.StoredExtraction
+}
+
+// NewMemory creates an empty store in memory
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// SaveExtraction adds an extraction
+func (m *Memory) SaveExtraction(_ context.Context, extraction types.StoredExtraction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.extractions = append(m.extractions, extraction)
+	return nil
+}
+
+// GetByDocumentHash returns the extractions of the PDF of a SHA-256, the latest first
+func (m *Memory) GetByDocumentHash(_ context.Context, hash string) ([]types.StoredExtraction, error) {
+	return m.latest(func(extraction types.StoredExtraction) bool { return extraction.DocumentHash == hash }, 0), nil
+}
+
+// ListByStatus returns the latest extractions of a status, at most limit of them (all of them for
+// 0), the latest first
+func (m *Memory) ListByStatus(_ context.Context, status types.BatchStatus, limit int) ([]types.StoredExtraction, error) {
+	return m.latest(func(extraction types.StoredExtraction) bool { return extraction.Status == status }, limit), nil
+}
+
+// latest returns the latest extractions matching a filter, at most limit of them unless 0
+func (m *Memory) latest(match func(types.StoredExtraction) bool, limit int) []types.StoredExtraction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	extractions := make([]types.StoredExtraction, 0)
+	for _, extraction := range slices.Backward(m.extractions) {
+		if limit > 0 && len(extractions) == limit {
+			break
+		}
+		if match(extraction) {
+			extractions = append(extractions, extraction)
+		}
+	}
+	return extractions
+}
+
+// dialect is what the SQL of a database engine differs in
+type dialect struct {
+	// createTable creates the table of the extractions and its indexes
+	createTable []string
+	// placeholder returns the placeholder of the nth parameter of a query, from 1
+	placeholder func(n int) string
+	// encodeTime returns the value of a time in the table
+	encodeTime func(t time.Time) interface{}
+}
+
+// columns are the columns of the extractions, in the order of StoredExtraction
+var columns = []string{
+	"document_id", "document_hash", "status", "error", "data", "model", "tokens_used",
+	"schema_name", "schema_hash", "schema_version", "library_version", "time", "duration_ms",
+}
+
+// sqlite is the dialect of SQLite, storing times as RFC 3339 text
+var sqlite = dialect{
+	createTable: []string{
+		`CREATE TABLE IF NOT EXISTS ` + Table + ` (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	document_id TEXT NOT NULL,
+	document_hash TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL,
+	data TEXT,
+	model TEXT NOT NULL,
+	tokens_used INTEGER NOT NULL,
+	schema_name TEXT NOT NULL,
+	schema_hash TEXT NOT NULL,
+	schema_version TEXT NOT NULL,
+	library_version TEXT NOT NULL,
+	time TEXT NOT NULL,
+	duration_ms INTEGER NOT NULL
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + Table + `_document_hash ON ` + Table + ` (document_hash)`,
+		`CREATE INDEX IF NOT EXISTS ` + Table + `_status ON ` + Table + ` (status, id)`,
+	},
+	placeholder: func(int) string { return "?" },
+	encodeTime:  func(t time.Time) interface{} { return t.UTC().Format(time.RFC3339Nano) },
+}
+
+// postgres is the dialect of PostgreSQL, storing data as JSONB
+var postgres = dialect{
+	createTable: []string{
+		`CREATE TABLE IF NOT EXISTS ` + Table + ` (
+	id BIGSERIAL PRIMARY KEY,
+	document_id TEXT NOT NULL,
+	document_hash TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL,
+	data JSONB,
+	model TEXT NOT NULL,
+	tokens_used INTEGER NOT NULL,
+	schema_name TEXT NOT NULL,
+	schema_hash TEXT NOT NULL,
+	schema_version TEXT NOT NULL,
+	library_version TEXT NOT NULL,
+	time TIMESTAMPTZ NOT NULL,
+	duration_ms BIGINT NOT NULL
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + Table + `_document_hash ON ` + Table + ` (document_hash)`,
+		`CREATE INDEX IF NOT EXISTS ` + Table + `_status ON ` + Table + ` (status, id)`,
+	},
+	placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	encodeTime:  func(t time.Time) interface{} { return t.UTC() },
+}
+
+// SQL is a store keeping the extractions in the extractions table of a SQL database. It is safe
+// for concurrent use.
+type SQL struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewSQLite creates a store in a SQLite database, creating its table when it does not exist
+func NewSQLite(ctx context.Context, db *sql.DB) (*SQL, error) {
+	return newSQL(ctx, db, sqlite)
+}
+
+// NewPostgres creates a store in a PostgreSQL database, creating its table when it does not exist
+func NewPostgres(ctx context.Context, db *sql.DB) (*SQL, error) {
+	return newSQL(ctx, db, postgres)
+}
+
+// newSQL creates a store in a database of a dialect
+func newSQL(ctx context.Context, db *sql.DB, dialect dialect) (*SQL, error) {
+	for _, statement := range dialect.createTable {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("failed to create the %s table: %w", Table, err)
+		}
+	}
+	return &SQL{db: db, dialect: dialect}, nil
+}
+
+// SaveExtraction inserts an extraction
+func (s *SQL) SaveExtraction(ctx context.Context, extraction types.StoredExtraction) error {
+	var data sql.NullString
+	if extraction.Data != nil {
+		encoded, err := json.Marshal(extraction.Data)
+		if err != nil {
+			return fmt.Errorf("failed to encode data: %w", err)
+		}
+		data = sql.NullString{String: string(encoded), Valid: true}
+	}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = s.dialect.placeholder(i + 1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", Table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	_, err := s.db.ExecContext(ctx, query,
+		extraction.DocumentID, extraction.DocumentHash, string(extraction.Status), extraction.Error, data,
+		extraction.Model, extraction.TokensUsed, extraction.SchemaName, extraction.SchemaHash,
+		extraction.SchemaVersion, extraction.LibraryVersion, s.dialect.encodeTime(extraction.Time),
+		extraction.DurationMs)
+	if err != nil {
+		return fmt.Errorf("failed to insert extraction: %w", err)
+	}
+	return nil
+}
+
+// GetByDocumentHash returns the extractions of the PDF of a SHA-256, the latest first
+func (s *SQL) GetByDocumentHash(ctx context.Context, hash string) ([]types.StoredExtraction, error) {
+	return s.query(ctx, "document_hash", hash, 0)
+}
+
+// ListByStatus returns the latest extractions of a status, at most limit of them (all of them for
+// 0), the latest first
+func (s *SQL) ListByStatus(ctx context.Context, status types.BatchStatus, limit int) ([]types.StoredExtraction, error) {
+	return s.query(ctx, "status", string(status), limit)
+}
+
+// query returns the latest extractions whose column holds a value, at most limit of them unless 0
+func (s *SQL) query(ctx context.Context, column, value string, limit int) ([]types.StoredExtraction, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s ORDER BY id DESC", strings.Join(columns, ", "), Table, column, s.dialect.placeholder(1))
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extractions: %w", err)
+	}
+	defer rows.Close()
+
+	extractions := make([]types.StoredExtraction, 0)
+	for rows.Next() {
+		var extraction types.StoredExtraction
+		var status string
+		var data sql.NullString
+		var storedTime interface{}
+		if err := rows.Scan(&extraction.DocumentID, &extraction.DocumentHash, &status, &extraction.Error, &data,
+			&extraction.Model, &extraction.TokensUsed, &extraction.SchemaName, &extraction.SchemaHash,
+			&extraction.SchemaVersion, &extraction.LibraryVersion, &storedTime, &extraction.DurationMs); err != nil {
+			return nil, fmt.Errorf("failed to read extraction: %w", err)
+		}
+		extraction.Status = types.BatchStatus(status)
+		if data.Valid {
+			if err := json.Unmarshal([]byte(data.String), &extraction.Data); err != nil {
+				return nil, fmt.Errorf("failed to decode data: %w", err)
+			}
+		}
+		if extraction.Time, err = decodeTime(storedTime); err != nil {
+			return nil, err
+		}
+		extractions = append(extractions, extraction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query extractions: %w", err)
+	}
+	return extractions, nil
+}
+
+// decodeTime returns the time of a row, read by drivers as a time or as RFC 3339 text
+func decodeTime(value interface{}) (time.Time, error) {
+	switch value := value.(type) {
+	case time.Time:
+		return value.UTC(), nil
+	case string:
+		return parseTime(value)
+	case []byte:
+		return parseTime(string(value))
+	default:
+		return time.Time{}, fmt.Errorf("unexpected time %v", value)
+	}
+}
+
+// parseTime parses an RFC 3339 time
+func parseTime(text string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", text, err)
+	}
+	return t, nil
+}
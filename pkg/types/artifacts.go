@@ -0,0 +1,14 @@
+package types
+
+import "context"
+
+// ArtifactStore stores the artifacts of parsing, such as rendered page images, under keys made of
+// the hash of the PDF and the rendering settings, so that retries, re-extractions with other schemas
+// and review UIs reuse them instead of rendering the pages again. See the pkg/artifacts package for
+// stores in a directory and in S3.
+type ArtifactStore interface {
+	// Get returns the artifact stored under a key, and false when there is none
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Put stores an artifact under a key, replacing any artifact stored under it
+	Put(ctx context.Context, key string, data []byte) error
+}
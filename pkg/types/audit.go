@@ -0,0 +1,94 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// ExtractionRecordSchemaVersion is the version of the ExtractionRecord payload. It changes only
+// when a field is removed or changes meaning; new optional fields may be added.
+const ExtractionRecordSchemaVersion = 1
+
+// ExtractionRecord is the audit record of an extraction: the exact prompts, schema, model and
+// parameters of every model call, to review and reproduce extractions in regulated workflows
+type ExtractionRecord struct {
+	// SchemaVersion is the record schema version (ExtractionRecordSchemaVersion)
+	SchemaVersion int `json:"schemaVersion"`
+	// DocumentID identifies the document (ExtractionOptions.DocumentID, the PDF path, or the
+	// SHA-256 of the PDF)
+	DocumentID string `json:"documentId"`
+	// Schema is the name of the extraction schema (ExtractionOptions.SchemaName, or the title of
+	// the schema)
+	Schema string `json:"schema,omitempty"`
+	// SchemaHash is the SHA-256, in hex, of the extraction schema serialized as JSON with sorted keys
+	SchemaHash string `json:"schemaHash"`
+	// SchemaRevision is the version of the extraction schema (ExtractionOptions.SchemaVersion),
+	// named apart from SchemaVersion, the version of the record
+	SchemaRevision string `json:"schemaRevision,omitempty"`
+	// LibraryVersion is the version of the library (extractor.LibraryVersion)
+	LibraryVersion string `json:"libraryVersion,omitempty"`
+	// PromptVersion is the version of the built-in prompts of the extractor (extractor.PromptVersion)
+	PromptVersion int `json:"promptVersion"`
+	// SystemPrompt is the system prompt of the extractor
+	SystemPrompt string `json:"systemPrompt,omitempty"`
+	// UserPrompt is the text of the first user message of the first model call, the instructions
+	// and the text of the document
+	UserPrompt string `json:"userPrompt,omitempty"`
+	// Provider is the provider preset of the extractor
+	Provider string `json:"provider"`
+	// BaseURL is the endpoint of the model
+	BaseURL string `json:"baseUrl"`
+	// Calls are the model calls of the extraction, in order, retries and form field detection
+	// included; empty when the data was extracted with a layout template
+	Calls []ModelCall `json:"calls"`
+	// Template is the name of the layout template the data was extracted with, if any
+	Template string `json:"template,omitempty"`
+	// Model is the model that answered, as reported by the provider
+	Model string `json:"model,omitempty"`
+	// Data is the extracted data (when the extraction succeeded)
+	Data map[string]interface{} `json:"data,omitempty"`
+	// TokensUsed is the number of tokens used
+	TokensUsed int `json:"tokensUsed"`
+	// Error is the error message (when the extraction failed)
+	Error string `json:"error,omitempty"`
+	// Time is when the extraction ended
+	Time time.Time `json:"time"`
+	// DurationMs is the extraction time in milliseconds
+	DurationMs int64 `json:"durationMs"`
+}
+
+// ModelCall is a chat completion request of an extraction, as sent to the provider
+type ModelCall struct {
+	// Model is the requested model
+	Model string `json:"model"`
+	// Parameters are the other parameters of the request, such as the temperature, the maximum
+	// number of tokens and the response format with its schema
+	Parameters map[string]interface{} `json:"parameters"`
+	// Messages are the messages of the request
+	Messages []ModelMessage `json:"messages"`
+}
+
+// ModelMessage is a message of a model call. Images are recorded by hash, as page images would
+// make records too large; the hash identifies the image among the artifacts of the document.
+type ModelMessage struct {
+	// Role is "system", "user" or "assistant"
+	Role string `json:"role"`
+	// Content is the text of the message, its text parts separated by newlines
+	Content string `json:"content"`
+	// Images are the SHA-256, in hex, of the URLs of the images of the message, in order: the
+	// data URL of inlined images
+	Images []string `json:"images,omitempty"`
+}
+
+// Recorder persists the audit records of extractions, e.g. to an append-only store
+type Recorder interface {
+	Record(ctx context.Context, record ExtractionRecord) error
+}
+
+// RecorderFunc adapts a function to the Recorder interface
+type RecorderFunc func(ctx context.Context, record ExtractionRecord) error
+
+// Record calls f
+func (f RecorderFunc) Record(ctx context.Context, record ExtractionRecord) error {
+	return f(ctx, record)
+}
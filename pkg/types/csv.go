@@ -0,0 +1,251 @@
+package types
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultFlattenSeparator = "."
+	defaultArrayJoin        = "; "
+)
+
+// FlattenConfig configures how nested extraction data is flattened into CSV rows
+type FlattenConfig struct {
+	// Explode is the path of an array of objects whose items become one row each, with the
+	// fields outside the array repeated on every row (e.g. "lineItems" or "invoice.lines").
+	// When empty, each result is a single row and arrays of objects get indexed columns
+	// ("lineItems.0.amount").
+	Explode string
+	// Columns selects and orders the columns. When empty, every column is written in
+	// alphabetical order.
+	Columns []string
+	// Separator joins nested field names in column names (default: ".")
+	Separator string
+	// ArrayJoin joins the values of arrays of scalars in a single cell (default: "; ")
+	ArrayJoin string
+	// Delimiter is the field delimiter (default: ','). Use '\t' for TSV.
+	Delimiter rune
+	// OmitHeader skips the header row
+	OmitHeader bool
+}
+
+// ToCSV flattens the extracted data into CSV rows, see FlattenConfig
+func (r *ExtractionResult) ToCSV(config FlattenConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []*ExtractionResult{r}, config); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteCSV flattens the extracted data of several results into a single CSV table written to w,
+// with the columns of all results combined
+func WriteCSV(w io.Writer, results []*ExtractionResult, config FlattenConfig) error {
+	rows := make([]map[string]string, 0, len(results))
+	for _, result := range results {
+		if result == nil {
+			return errors.New("result is nil")
+		}
+		flattened, err := FlattenData(result.Data, config)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, flattened...)
+	}
+
+	columns := config.Columns
+	if len(columns) == 0 {
+		seen := make(map[string]bool)
+		for _, row := range rows {
+			for column := range row {
+				if !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+			}
+		}
+		sort.Strings(columns)
+	}
+
+	writer := csv.NewWriter(w)
+	if config.Delimiter != 0 {
+		writer.Comma = config.Delimiter
+	}
+	if !config.OmitHeader {
+		if err := writer.Write(columns); err != nil {
+			return fmt.Errorf("failed to write CSV header: %w", err)
+		}
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = row[column]
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// FlattenData flattens nested extraction data into rows mapping column names to cell values, see
+// FlattenConfig
+func FlattenData(data map[string]interface{}, config FlattenConfig) ([]map[string]string, error) {
+	values, err := FlattenValues(data, config)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]string, len(values))
+	for i, value := range values {
+		rows[i] = make(map[string]string, len(value))
+		for column, cell := range value {
+			rows[i][column] = formatCell(cell)
+		}
+	}
+	return rows, nil
+}
+
+// FlattenValues flattens nested extraction data like FlattenData, but keeps the cell values typed:
+// each cell is a string, a json.Number, a bool or nil. Arrays of scalars are joined into strings.
+func FlattenValues(data map[string]interface{}, config FlattenConfig) ([]map[string]interface{}, error) {
+	if config.Separator == "" {
+		config.Separator = defaultFlattenSeparator
+	}
+	if config.ArrayJoin == "" {
+		config.ArrayJoin = defaultArrayJoin
+	}
+
+	// Normalize to generic JSON values, whatever Go types the data was built with
+	normalized, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(normalized))
+	decoder.UseNumber()
+	var generic map[string]interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+
+	explode := strings.ReplaceAll(config.Explode, ".", config.Separator)
+	row := make(map[string]interface{})
+	flattenValue(row, "", generic, explode, config)
+	if config.Explode == "" {
+		return []map[string]interface{}{row}, nil
+	}
+
+	value, ok := lookupPath(generic, strings.Split(config.Explode, "."))
+	if !ok || value == nil {
+		// Nothing to explode: keep the other fields on a single row
+		return []map[string]interface{}{row}, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot explode %q: not an array", config.Explode)
+	}
+	if len(items) == 0 {
+		return []map[string]interface{}{row}, nil
+	}
+
+	rows := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		itemRow := make(map[string]interface{}, len(row))
+		for column, cell := range row {
+			itemRow[column] = cell
+		}
+		flattenValue(itemRow, explode, item, "", config)
+		rows = append(rows, itemRow)
+	}
+	return rows, nil
+}
+
+// flattenValue writes value into row under the column name prefix, recursing into objects and
+// arrays of objects. The array at the skip column is left out; its items are flattened into rows
+// of their own by FlattenData.
+func flattenValue(row map[string]interface{}, prefix string, value interface{}, skip string, config FlattenConfig) {
+	join := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + config.Separator + name
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			flattenValue(row, join(name), field, skip, config)
+		}
+	case []interface{}:
+		if skip != "" && prefix == skip {
+			return
+		}
+		if isScalarArray(v) {
+			cells := make([]string, len(v))
+			for i, item := range v {
+				cells[i] = formatCell(item)
+			}
+			row[prefix] = strings.Join(cells, config.ArrayJoin)
+			return
+		}
+		for i, item := range v {
+			flattenValue(row, join(strconv.Itoa(i)), item, skip, config)
+		}
+	default:
+		row[prefix] = v
+	}
+}
+
+// isScalarArray reports whether an array holds no objects or arrays
+func isScalarArray(items []interface{}) bool {
+	for _, item := range items {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+// lookupPath returns the value at a path of field names in nested data
+func lookupPath(data map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = data
+	for _, name := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// formatCell formats a scalar JSON value as a CSV cell
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// EventType is the type of an extraction event
+type EventType string
+
+// Extraction event types
+const (
+	EventExtractionCompleted EventType = "extraction.completed"
+	EventExtractionFailed    EventType = "extraction.failed"
+)
+
+// EventSchemaVersion is the version of the Event payload. It changes only when a field is removed
+// or changes meaning; new optional fields may be added.
+const EventSchemaVersion = 1
+
+// Event is published when an extraction completes or fails
+type Event struct {
+	// SchemaVersion is the event schema version (EventSchemaVersion)
+	SchemaVersion int `json:"schemaVersion"`
+	// Type is extraction.completed or extraction.failed
+	Type EventType `json:"type"`
+	// DocumentID identifies the document (ExtractionOptions.DocumentID, the PDF path, or the
+	// SHA-256 of the PDF)
+	DocumentID string `json:"documentId"`
+	// Schema is the name of the extraction schema (ExtractionOptions.SchemaName, or the title of
+	// the schema)
+	Schema string `json:"schema,omitempty"`
+	// Time is when the extraction ended
+	Time time.Time `json:"time"`
+	// DurationMs is the extraction time in milliseconds
+	DurationMs int64 `json:"durationMs"`
+	// Data is the extracted data (when Type is extraction.completed)
+	Data map[string]interface{} `json:"data,omitempty"`
+	// Model is the model used for extraction
+	Model string `json:"model,omitempty"`
+	// TokensUsed is the number of tokens used
+	TokensUsed int `json:"tokensUsed"`
+	// Error is the error message (when Type is extraction.failed)
+	Error string `json:"error,omitempty"`
+}
+
+// Publisher publishes extraction events, e.g. to a message broker
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// PublisherFunc adapts a function to the Publisher interface
+type PublisherFunc func(ctx context.Context, event Event) error
+
+// Publish calls f
+func (f PublisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// MultiPublisher publishes events to several publishers in order, e.g. to a message broker and to
+// a metrics collector. Every publisher receives the event even when an earlier one fails.
+type MultiPublisher []Publisher
+
+// Publish publishes the event to every publisher and returns their errors joined
+func (m MultiPublisher) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, publisher := range m {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes and closes the publishers implementing io.Closer and returns their errors joined
+func (m MultiPublisher) Close() error {
+	var errs []error
+	for _, publisher := range m {
+		if closer, ok := publisher.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
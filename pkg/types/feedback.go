@@ -0,0 +1,51 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// FeedbackRecord is a correction of extracted data by a reviewer, see Extractor.RecordFeedback
+type FeedbackRecord struct {
+	// DocumentID identifies the document (ExtractionOptions.DocumentID, the PDF path, or the
+	// SHA-256 of the PDF)
+	DocumentID string `json:"documentId"`
+	// Schema is the name of the schema of the data (ExtractionOptions.SchemaName, or the title of
+	// the schema), when the document was extracted by the extractor recording the feedback
+	Schema string `json:"schema,omitempty"`
+	// Text is the text of the document as given to the model, empty for scanned documents or
+	// documents the extractor recording the feedback did not extract
+	Text string `json:"text,omitempty"`
+	// Extracted is the data as extracted, when the document was extracted by the extractor
+	// recording the feedback
+	Extracted map[string]interface{} `json:"extracted,omitempty"`
+	// Corrected is the data as corrected by the reviewer
+	Corrected map[string]interface{} `json:"corrected"`
+	// Corrections are the dot-separated paths of the values the reviewer changed, added or
+	// removed, sorted (empty when Extracted is)
+	Corrections []string `json:"corrections,omitempty"`
+	// Time is when the feedback was recorded
+	Time time.Time `json:"time"`
+}
+
+// FeedbackStore keeps the feedback recorded on extractions. See the pkg/feedback package for
+// stores in memory and in a JSON Lines file.
+type FeedbackStore interface {
+	// Save adds a feedback record
+	Save(ctx context.Context, record FeedbackRecord) error
+	// Records returns the feedback records, in the order they were saved
+	Records(ctx context.Context) ([]FeedbackRecord, error)
+}
+
+// FeedbackExample is a document with its data as corrected by a reviewer: a few-shot example for
+// the model, or a case of an evaluation dataset, see Extractor.FeedbackDataset
+type FeedbackExample struct {
+	// DocumentID identifies the document
+	DocumentID string `json:"documentId"`
+	// Schema is the name of the schema of the data
+	Schema string `json:"schema,omitempty"`
+	// Text is the text of the document, empty for scanned documents
+	Text string `json:"text,omitempty"`
+	// Expected is the data as corrected by the reviewer
+	Expected map[string]interface{} `json:"expected"`
+}
@@ -0,0 +1,33 @@
+package types
+
+import "context"
+
+// IndexDocument is an extracted document handed to an Indexer
+type IndexDocument struct {
+	// ID identifies the document in the index (ExtractionOptions.DocumentID, the PDF path, or the
+	// SHA-256 of the PDF)
+	ID string `json:"id"`
+	// Text is the full text of the document: the text layer of text-based PDFs, or the
+	// transcription of scanned PDFs when SearchablePDF is set (empty otherwise)
+	Text string `json:"text"`
+	// Data is the extracted data
+	Data map[string]interface{} `json:"data"`
+	// NumPages is the number of pages of the document
+	NumPages int `json:"numPages"`
+	// Model is the model used for extraction
+	Model string `json:"model"`
+}
+
+// Indexer adds extracted documents to a full-text index. Documents indexed again with the same ID
+// replace the previous version.
+type Indexer interface {
+	Index(ctx context.Context, document IndexDocument) error
+}
+
+// IndexerFunc adapts a function to the Indexer interface
+type IndexerFunc func(ctx context.Context, document IndexDocument) error
+
+// Index calls f
+func (f IndexerFunc) Index(ctx context.Context, document IndexDocument) error {
+	return f(ctx, document)
+}
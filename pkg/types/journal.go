@@ -0,0 +1,13 @@
+package types
+
+import "context"
+
+// BatchJournal keeps the progress of batch extractions, the record of every document extracted,
+// so that an interrupted batch resumes without extracting its documents again. See
+// BatchOptions.Journal and the pkg/journal package for journals in memory and in a JSON Lines file.
+type BatchJournal interface {
+	// Save adds the record of an extracted document
+	Save(ctx context.Context, record JSONLRecord) error
+	// Records returns the records, in the order they were saved
+	Records(ctx context.Context) ([]JSONLRecord, error)
+}
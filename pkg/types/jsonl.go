@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// JSONLSchemaVersion is the version of the JSON Lines record schema written by JSONLWriter. It
+// changes only when a field is removed or changes meaning; new optional fields may be added.
+const JSONLSchemaVersion = 1
+
+// JSONLRecord is a line written by JSONLWriter
+type JSONLRecord struct {
+	// SchemaVersion is the record schema version (JSONLSchemaVersion)
+	SchemaVersion int `json:"schemaVersion"`
+	// DocumentID identifies the document
+	DocumentID string `json:"documentId"`
+	// Status is "success" or "error"
+	Status BatchStatus `json:"status"`
+	// Error is the error message (when Status is error)
+	Error string `json:"error,omitempty"`
+	// Data is the extracted data (when Status is success)
+	Data map[string]interface{} `json:"data,omitempty"`
+	// Model is the model used for extraction
+	Model string `json:"model,omitempty"`
+	// Schema is the name of the extraction schema (ExtractionResult.SchemaName)
+	Schema string `json:"schema,omitempty"`
+	// SchemaHash is the hash of the extraction schema (ExtractionResult.SchemaHash)
+	SchemaHash string `json:"schemaHash,omitempty"`
+	// SchemaRevision is the version of the extraction schema (ExtractionResult.SchemaVersion),
+	// named apart from SchemaVersion, the version of the record
+	SchemaRevision string `json:"schemaRevision,omitempty"`
+	// LibraryVersion is the version of the library that extracted the data
+	LibraryVersion string `json:"libraryVersion,omitempty"`
+	// TokensUsed is the number of tokens used
+	TokensUsed int `json:"tokensUsed"`
+	// DurationMs is the extraction time in milliseconds
+	DurationMs int64 `json:"durationMs"`
+}
+
+// JSONLWriter streams batch results as JSON Lines, one JSONLRecord per line. It is safe for
+// concurrent use.
+type JSONLWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONLWriter creates a JSON Lines writer writing to w
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &JSONLWriter{encoder: encoder}
+}
+
+// Write writes the record of a batch result as a single line
+func (w *JSONLWriter) Write(result BatchResult) error {
+	record := NewJSONLRecord(result)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.encoder.Encode(record); err != nil {
+		return fmt.Errorf("failed to write JSONL record: %w", err)
+	}
+	return nil
+}
+
+// NewJSONLRecord returns the record of a batch result
+func NewJSONLRecord(result BatchResult) JSONLRecord {
+	record := JSONLRecord{
+		SchemaVersion: JSONLSchemaVersion,
+		DocumentID:    result.ID,
+		Status:        result.Status,
+		DurationMs:    result.Duration.Milliseconds(),
+	}
+	if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+	if result.Result != nil {
+		record.Data = result.Result.Data
+		record.Model = result.Result.Model
+		record.Schema = result.Result.SchemaName
+		record.SchemaHash = result.Result.SchemaHash
+		record.SchemaRevision = result.Result.SchemaVersion
+		record.LibraryVersion = result.Result.LibraryVersion
+		record.TokensUsed = result.Result.TokensUsed
+	}
+	return record
+}
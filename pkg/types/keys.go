@@ -0,0 +1,17 @@
+package types
+
+import "context"
+
+// KeyProvider supplies the API key of the model requests, e.g. from Vault or a cloud secrets
+// manager, so that rotated keys are picked up without recreating the extractor
+type KeyProvider interface {
+	APIKey(ctx context.Context) (string, error)
+}
+
+// KeyProviderFunc adapts a function to the KeyProvider interface
+type KeyProviderFunc func(ctx context.Context) (string, error)
+
+// APIKey calls f
+func (f KeyProviderFunc) APIKey(ctx context.Context) (string, error) {
+	return f(ctx)
+}
@@ -0,0 +1,83 @@
+package types
+
+import "strings"
+
+// wordPunctuation is trimmed from words when matching text against a page layout
+const wordPunctuation = ".,;:!?()[]{}\"'"
+
+// Find returns the bounds of every occurrence of text on the page, matching whole words without
+// regard to case or surrounding punctuation, with one rectangle per line an occurrence spans. It
+// is meant to highlight extracted values on the original page.
+func (l *PageLayout) Find(text string) []Rect {
+	query := strings.Fields(text)
+	if len(query) == 0 {
+		return nil
+	}
+
+	type position struct{ line, word int }
+	words := make([]position, 0)
+	for i, line := range l.Lines {
+		for j := range line.Words {
+			words = append(words, position{i, j})
+		}
+	}
+
+	matches := make([]Rect, 0)
+	for start := 0; start+len(query) <= len(words); start++ {
+		matched := true
+		for k, term := range query {
+			at := words[start+k]
+			if !strings.EqualFold(strings.Trim(l.Lines[at.line].Words[at.word].Text, wordPunctuation), strings.Trim(term, wordPunctuation)) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		// Merge the words of the occurrence line by line
+		var bounds *Rect
+		lastLine := -1
+		for _, at := range words[start : start+len(query)] {
+			word := l.Lines[at.line].Words[at.word].Bounds
+			if at.line != lastLine {
+				if bounds != nil {
+					matches = append(matches, *bounds)
+				}
+				bounds, lastLine = &word, at.line
+				continue
+			}
+			merged := bounds.Union(word)
+			bounds = &merged
+		}
+		matches = append(matches, *bounds)
+	}
+	return matches
+}
+
+// Union returns the smallest rectangle containing both rectangles
+func (r Rect) Union(other Rect) Rect {
+	left, top := min(r.X, other.X), min(r.Y, other.Y)
+	right, bottom := max(r.X+r.Width, other.X+other.Width), max(r.Y+r.Height, other.Y+other.Height)
+	return Rect{X: left, Y: top, Width: right - left, Height: bottom - top}
+}
+
+// TextIn returns the words of the page whose center lies in a rectangle, in points from the
+// top-left corner of the page, the words of a line separated by spaces and the lines by newlines
+func (l *PageLayout) TextIn(region Rect) string {
+	lines := make([]string, 0)
+	for _, line := range l.Lines {
+		words := make([]string, 0)
+		for _, word := range line.Words {
+			x, y := word.Bounds.X+word.Bounds.Width/2, word.Bounds.Y+word.Bounds.Height/2
+			if x >= region.X && x <= region.X+region.Width && y >= region.Y && y <= region.Y+region.Height {
+				words = append(words, word.Text)
+			}
+		}
+		if len(words) > 0 {
+			lines = append(lines, strings.Join(words, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,9 @@
+package types
+
+// RemoteService is implemented by the components sending data over the network, such as the remote
+// OCR providers, artifact stores, indexers and publishers of this module, so that
+// ExtractorConfig.AirGapped can check where they send it
+type RemoteService interface {
+	// Endpoints returns the URLs or host:port addresses the component connects to
+	Endpoints() []string
+}
@@ -0,0 +1,56 @@
+package types
+
+import "context"
+
+// EntityQuery is a name extracted from a document, handed to an EntityResolver
+type EntityQuery struct {
+	// Field is the dot-separated path of the name in the extracted data
+	Field string
+	// Kind is the kind of entity of the field, such as "vendor" or "customer", see
+	// ExtractionOptions.ResolveEntities
+	Kind string
+	// Name is the extracted name
+	Name string
+	// Data is the extracted data, for resolvers matching on other fields too, such as a VAT ID.
+	// It must not be modified.
+	Data map[string]interface{}
+}
+
+// EntityMatch is the entity of the master data of the caller that an extracted name refers to
+type EntityMatch struct {
+	// ID is the canonical ID of the entity
+	ID string
+	// Name is the canonical name of the entity
+	Name string
+	// Confidence is how sure the resolver is of the match, from 0 to 1
+	Confidence float64
+}
+
+// EntityResolver maps the names of customers, vendors and other parties extracted from documents
+// to the canonical IDs of the master data of the caller. See extractor.FuzzyResolver for a resolver
+// matching names against a list of entities.
+type EntityResolver interface {
+	// Resolve returns the entity a name refers to, and false when it refers to none
+	Resolve(ctx context.Context, query EntityQuery) (EntityMatch, bool, error)
+}
+
+// EntityResolverFunc adapts a function to the EntityResolver interface
+type EntityResolverFunc func(ctx context.Context, query EntityQuery) (EntityMatch, bool, error)
+
+// Resolve calls f
+func (f EntityResolverFunc) Resolve(ctx context.Context, query EntityQuery) (EntityMatch, bool, error) {
+	return f(ctx, query)
+}
+
+// MasterEntity is an entity of the master data of the caller, for extractor.FuzzyResolver
+type MasterEntity struct {
+	// ID is the canonical ID of the entity
+	ID string
+	// Kind is the kind of entity, such as "vendor", matched against EntityQuery.Kind ("" matches
+	// any kind)
+	Kind string
+	// Name is the canonical name of the entity
+	Name string
+	// Aliases are the other names the entity is known by, such as trade names and former names
+	Aliases []string
+}
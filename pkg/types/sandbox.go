@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+// SandboxConfig configures the parsing of untrusted PDFs in a separate helper process, which
+// contains crashes and exploits of the parser; see the pkg/sandbox package
+type SandboxConfig struct {
+	// HelperPath is the helper executable, a program calling sandbox.Main first thing in main
+	// (default: the running executable)
+	HelperPath string
+	// MaxMemoryBytes limits the data segment of the helper process (default: 1 GiB)
+	MaxMemoryBytes int64
+	// Timeout limits the time the helper process has to parse a PDF (default: 2 minutes)
+	Timeout time.Duration
+	// DisableSeccomp runs the helper process without its seccomp filter, which on Linux
+	// forbids it to run programs, open network connections and modify files
+	DisableSeccomp bool
+}
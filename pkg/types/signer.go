@@ -0,0 +1,20 @@
+package types
+
+import "net/http"
+
+// RequestSigner authenticates the model requests to OpenAI-compatible gateways that do not take a
+// Bearer API key, e.g. with custom headers, an HMAC signature or an OAuth2 token. The pkg/auth
+// package provides signers for the common schemes.
+type RequestSigner interface {
+	// SignRequest adds the credentials of a request with the given body to its headers. The body
+	// is nil for requests without one.
+	SignRequest(req *http.Request, body []byte) error
+}
+
+// RequestSignerFunc adapts a function to the RequestSigner interface
+type RequestSignerFunc func(req *http.Request, body []byte) error
+
+// SignRequest calls f
+func (f RequestSignerFunc) SignRequest(req *http.Request, body []byte) error {
+	return f(req, body)
+}
@@ -0,0 +1,51 @@
+package types
+
+import "encoding/json"
+
+// ModelRequest is the model request of an extraction, built from a parsed PDF by
+// Extractor.BuildRequest. Like ParsedPdf and ModelResponse, it round-trips through JSON, so that
+// each step of an extraction can run as a separate retryable activity of a workflow engine.
+type ModelRequest struct {
+	// Body is the chat completion request body
+	Body map[string]interface{} `json:"body"`
+	// Barcodes are the barcodes decoded from the pages, carried over to the result
+	Barcodes []Barcode `json:"barcodes,omitempty"`
+	// Links are the hyperlinks of the pages, carried over to the result
+	Links []Link `json:"links,omitempty"`
+	// FailedPages are the pages that could not be read or rendered, carried over to the result
+	FailedPages []PageFailure `json:"failedPages,omitempty"`
+	// Warnings are carried over to the result
+	Warnings []string `json:"warnings,omitempty"`
+	// Schemas are the names of the schemas combined in the request, to split the result by
+	Schemas []string `json:"schemas,omitempty"`
+	// NullableFields are the paths of the fields whose status the model reports
+	NullableFields []string `json:"nullableFields,omitempty"`
+	// IdempotencyKey is sent with the request, so that retrying the step is not billed twice by providers that support it
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+	// Schema is the JSON schema the model is asked for, carried over to check the keys of the response against
+	Schema map[string]interface{} `json:"schema,omitempty"`
+	// SchemaDrift is the policy of the keys of the response missing from the schema, carried over to the response
+	SchemaDrift string `json:"schemaDrift,omitempty"`
+}
+
+// ModelResponse is the raw model response of an extraction, returned by Extractor.CallModel
+type ModelResponse struct {
+	// Body is the chat completion response body
+	Body json.RawMessage `json:"body"`
+	// Barcodes are the barcodes decoded from the pages, carried over to the result
+	Barcodes []Barcode `json:"barcodes,omitempty"`
+	// Links are the hyperlinks of the pages, carried over to the result
+	Links []Link `json:"links,omitempty"`
+	// FailedPages are the pages that could not be read or rendered, carried over to the result
+	FailedPages []PageFailure `json:"failedPages,omitempty"`
+	// Warnings are carried over to the result
+	Warnings []string `json:"warnings,omitempty"`
+	// Schemas are the names of the schemas combined in the request, to split the result by
+	Schemas []string `json:"schemas,omitempty"`
+	// NullableFields are the paths of the fields whose status the model reports
+	NullableFields []string `json:"nullableFields,omitempty"`
+	// Schema is the JSON schema the model was asked for, to check the keys of the response against
+	Schema map[string]interface{} `json:"schema,omitempty"`
+	// SchemaDrift is the policy of the keys of the response missing from the schema
+	SchemaDrift string `json:"schemaDrift,omitempty"`
+}
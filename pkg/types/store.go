@@ -0,0 +1,50 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// StoredExtraction is an extraction, completed or failed, kept in the history of a Store
+type StoredExtraction struct {
+	// DocumentID identifies the document (ExtractionOptions.DocumentID, the PDF path, or the
+	// SHA-256 of the PDF)
+	DocumentID string `json:"documentId"`
+	// DocumentHash is the SHA-256, in hex, of the PDF, empty when only a parsed PDF was given
+	DocumentHash string `json:"documentHash,omitempty"`
+	// Status is "success" or "error"
+	Status BatchStatus `json:"status"`
+	// Error is the error message (when Status is error)
+	Error string `json:"error,omitempty"`
+	// Data is the extracted data (when Status is success)
+	Data map[string]interface{} `json:"data,omitempty"`
+	// Model is the model used for extraction
+	Model string `json:"model,omitempty"`
+	// TokensUsed is the number of tokens used
+	TokensUsed int `json:"tokensUsed"`
+	// SchemaName is the name of the extraction schema (ExtractionResult.SchemaName)
+	SchemaName string `json:"schemaName,omitempty"`
+	// SchemaHash is the hash of the extraction schema (ExtractionResult.SchemaHash)
+	SchemaHash string `json:"schemaHash,omitempty"`
+	// SchemaVersion is the version of the extraction schema (ExtractionResult.SchemaVersion)
+	SchemaVersion string `json:"schemaVersion,omitempty"`
+	// LibraryVersion is the version of the library (ExtractionResult.LibraryVersion)
+	LibraryVersion string `json:"libraryVersion,omitempty"`
+	// Time is when the extraction ended
+	Time time.Time `json:"time"`
+	// DurationMs is the extraction time in milliseconds
+	DurationMs int64 `json:"durationMs"`
+}
+
+// Store keeps the history of extractions, to serve them again and query them. See
+// ExtractorConfig.Store and the pkg/store package for stores in memory and in SQLite and
+// PostgreSQL databases.
+type Store interface {
+	// SaveExtraction adds an extraction to the history
+	SaveExtraction(ctx context.Context, extraction StoredExtraction) error
+	// GetByDocumentHash returns the extractions of the PDF of a SHA-256, the latest first
+	GetByDocumentHash(ctx context.Context, hash string) ([]StoredExtraction, error)
+	// ListByStatus returns the latest extractions of a status, at most limit of them (all of them
+	// for 0), the latest first
+	ListByStatus(ctx context.Context, status BatchStatus, limit int) ([]StoredExtraction, error)
+}
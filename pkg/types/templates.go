@@ -0,0 +1,40 @@
+package types
+
+// LayoutTemplate describes a known document layout, such as a tax form or the invoices of a
+// regular vendor, whose fields are always at the same place on the page. Documents matching a
+// template are extracted from the positions of their words, without calling the model.
+type LayoutTemplate struct {
+	// Name identifies the template in ExtractionResult.Template
+	Name string
+	// Anchors are the texts identifying documents of the layout, such as the title and form
+	// number of a form; a document matches the template when all of them are found
+	Anchors []TemplateAnchor
+	// Fields are the values read from regions of the pages
+	Fields []TemplateField
+}
+
+// TemplateAnchor is a text identifying the documents of a LayoutTemplate
+type TemplateAnchor struct {
+	// Text is the text to find, matched as whole words regardless of case and punctuation
+	Text string
+	// Page is the page the text must be on (1-indexed), or 0 for any page
+	Page int
+	// Region is the area of the page the text must be in, relative to the page size (all values
+	// between 0 and 1), or the whole page when empty
+	Region Rect
+}
+
+// TemplateField is a value of a LayoutTemplate read from a region of a page
+type TemplateField struct {
+	// Name is the field of the data the value is stored in, as a dot-separated path (e.g.
+	// "seller.vatId")
+	Name string
+	// Page is the page number (1-indexed)
+	Page int
+	// Region is the area of the value relative to the page size (all values between 0 and 1). The
+	// words whose center lies in it make up the value, its lines separated by newlines.
+	Region Rect
+	// Pattern is a regular expression the value is narrowed to, its first group when it has one,
+	// e.g. `Invoice no\. (\S+)` (optional)
+	Pattern string
+}
@@ -1,11 +1,32 @@
 package types
 
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+)
+
 // ExtractorConfig holds the configuration for the PDF data extractor
 type ExtractorConfig struct {
-	// OpenAIAPIKey is the API key for OpenAI (required)
+	// OpenAIAPIKey is the API key for OpenAI (required, unless KeyProvider or Signer is set or for local providers that do not check it)
 	OpenAIAPIKey string
+	// KeyProvider supplies the API key instead of OpenAIAPIKey, fetched lazily and again after KeyRefreshInterval or when the key is rejected (optional)
+	KeyProvider KeyProvider
+	// KeyRefreshInterval is how long a key of the KeyProvider is used before it is fetched again (default: 5 minutes; negative fetches it for every request)
+	KeyRefreshInterval time.Duration
+	// Signer authenticates the requests to gateways that do not take a Bearer API key, e.g. with custom headers, an HMAC signature or an OAuth2 token; see the pkg/auth package (optional)
+	Signer RequestSigner
+	// OpenAIOrganization is the OpenAI organization the requests are attributed to, sent as the OpenAI-Organization header (optional)
+	OpenAIOrganization string
+	// OpenAIProject is the OpenAI project the requests are attributed to, sent as the OpenAI-Project header (optional)
+	OpenAIProject string
 	// BaseURL is the custom base URL for OpenAI-compatible endpoints (optional)
 	BaseURL string
+	// Provider selects the preset of a known OpenAI-compatible server: ProviderOpenAI (default), ProviderDockerModelRunner, ProviderLlamaCpp, ProviderLMStudio or ProviderVLLM
+	Provider string
+	// ResponseFormat is how structured output is requested: ResponseFormatJSONSchema, ResponseFormatJSONObject or ResponseFormatPrompt (default: from the provider preset)
+	ResponseFormat string
 	// Model is the default model to use for both text and vision extraction (default: "gpt-4o-mini")
 	Model string
 	// TextModel is the model to use specifically for text-based PDF extraction (optional)
@@ -16,22 +37,189 @@ type ExtractorConfig struct {
 	VisionEnabled bool
 	// TextThreshold is the minimum text length to consider PDF as text-based (default: 100)
 	TextThreshold int
+	// MinTextQuality is the text quality, from 0 to 1, below which a text layer is considered garbage and read through vision (default: 0.5; negative disables the check)
+	MinTextQuality float64
+	// RepairJSON repairs malformed model output, such as JSON wrapped in prose or with trailing commas, comments or single quotes, instead of failing to parse it
+	RepairJSON bool
+	// ProxyURL is the proxy of the model requests, e.g. "http://proxy.corp:3128", overriding the HTTP_PROXY and HTTPS_PROXY environment variables (optional)
+	ProxyURL string
+	// CACertFile is a PEM bundle of CA certificates trusted for the model requests on top of the system roots, e.g. the CA of a TLS-intercepting proxy (optional)
+	CACertFile string
+	// ClientCertFile and ClientKeyFile are the PEM certificate and key presented to servers requiring mutual TLS (optional)
+	ClientCertFile string
+	ClientKeyFile  string
+	// AllowedHosts are the hosts the model requests may be sent to, checked for every request and redirect, e.g. "api.openai.com" or "*.openai.azure.com" for its subdomains; requests to other hosts fail with extractor.ErrHostNotAllowed (optional)
+	AllowedHosts []string
+	// AirGapped refuses any network egress but to local endpoints: New fails when BaseURL, ProxyURL or an endpoint of a component, such as a remote OCR backend, is not local, and connections to other addresses, e.g. redirects, fail with extractor.ErrEgressRefused. Always set in builds with the airgapped tag
+	AirGapped bool
+	// LocalHosts are the hostnames of on-prem servers allowed in air-gapped mode, e.g. "llm.corp.example" or "*.corp.example" for its subdomains, besides loopback and private addresses, single-label names and the .localhost, .local and .internal domains (optional)
+	LocalHosts []string
 	// SystemPrompt is the custom system prompt for the AI model (optional)
 	SystemPrompt string
+	// Indexer receives the text and extracted data of every successfully extracted document (optional)
+	Indexer Indexer
+	// Templates are the layouts of known documents, such as forms, extracted from the positions of their words without calling the model when a document matches one; see Extractor.RegisterTemplate (optional)
+	Templates []LayoutTemplate
+	// FeedbackStore keeps the corrections of extracted data recorded with Extractor.RecordFeedback; see the pkg/feedback package (optional)
+	FeedbackStore FeedbackStore
+	// FeedbackExamples is the number of documents corrected by reviewers, of the same schema, given to the model as examples in the prompt (default: 0, none)
+	FeedbackExamples int
+	// EntityResolver maps the names of ExtractionOptions.ResolveEntities to the canonical IDs of the master data of the caller, see extractor.FuzzyResolver (optional)
+	EntityResolver EntityResolver
+	// Publisher receives an event when an extraction completes or fails (optional)
+	Publisher Publisher
+	// Recorder receives the audit record of every extraction, completed or failed, with the exact prompts, schema hash, model and parameters of its model calls; a recording error fails the extraction (optional)
+	Recorder Recorder
+	// Store keeps the history of extractions, completed or failed, with the hash of their PDF and the version of their schema; a storage error fails the extraction; see the pkg/store package (optional)
+	Store Store
+	// DebugLogger logs the chat completion requests and responses at debug level, with the values of RedactFields, email addresses, IBANs, payment card numbers, social security numbers, phone numbers and page images redacted (optional)
+	DebugLogger *slog.Logger
+	// RedactFields are the names of the fields whose values are redacted from debug logs, at any depth of the requests and responses and of the answers of the model, regardless of case (optional)
+	RedactFields []string
+	// ContextWindow is the context window, in tokens, of the models (default: known for OpenAI models, unchecked for others)
+	ContextWindow int
+	// ModelPrices are the prices of models by model name prefix, for the cost estimates of Extractor.Plan (default: known for OpenAI models, in USD)
+	ModelPrices map[string]ModelPrice
+	// SchemaLimits are the limits on the size of the schemas of the provider, checked before extracting (default: from the provider preset with a json_schema response format, such as those of OpenAI; a zero SchemaLimits checks none)
+	SchemaLimits *schema.Limits
+	// TextBackend names the parser backend that reads the text of PDFs (optional)
+	TextBackend string
+	// OCRBackend names a parser backend, such as a remote OCR service, whose text is given to the vision model along with the page images of scanned PDFs (optional)
+	OCRBackend string
+	// CrossCheckBackend names the parser backend, such as a local ocr.Tesseract backend, whose text ExtractionOptions.CrossCheckOCR checks extracted values against (default: OCRBackend)
+	CrossCheckBackend string
+	// Renderer names the parser backend that renders the pages of scanned PDFs: RendererMuPDF (default), RendererPdftoppm, RendererGhostscript or a custom backend
+	Renderer string
+	// RendererPath is the path of the pdftoppm or Ghostscript binary (optional)
+	RendererPath string
+	// IsolateRendering recovers from panics of the renderer on malformed PDFs, failing the extraction with parser.ErrRenderFailed instead of crashing the process
+	IsolateRendering bool
+	// ImageFormat is the format of the page images sent to the vision model: ImageFormatPNG (default), ImageFormatWebP or ImageFormatAVIF, which make the requests of scanned pages 30-60% smaller. The provider must accept the format, and its encoder must be installed (optional)
+	ImageFormat string
+	// ImageEncoderPath is the path of the cwebp or avifenc binary (default: found in the PATH)
+	ImageEncoderPath string
+	// ImageUploader uploads the page images of vision requests and references them by URL instead of inlining them as base64, so that requests stay small and retries reuse the uploads (optional)
+	ImageUploader ImageUploader
+	// ImageDetail is the detail level of the page images sent to the vision model: ImageDetailLow, ImageDetailHigh, ImageDetailAuto or ImageDetailAdaptive, overridden by PdfPageImage.Detail (default: the default of the provider)
+	ImageDetail string
+	// TileSize sends page images larger than this many pixels on a side to the vision model as overlapping tiles of at most this size, each with its position on the page, so that large drawings and ledgers are not downscaled into illegibility (optional)
+	TileSize int
+	// PixelBudget renders the pages of scanned PDFs at the resolution that gives each about this many pixels, instead of a single DPI for pages from A6 receipts to A0 drawings (optional)
+	PixelBudget int
+	// ArtifactStore caches the rendered page images, keyed by the hash of the PDF and the resolution, so that retries, re-extractions with other schemas and review UIs do not render the pages again; see the pkg/artifacts package (optional)
+	ArtifactStore ArtifactStore
+	// MaxRenderMemoryBytes aborts the rendering of pages whose raster would take more memory, failing the extraction with a parser.RenderLimitError (optional)
+	MaxRenderMemoryBytes int64
+	// MaxRenderDuration aborts rendering when the pages of a PDF are not rendered in time, failing the extraction with a parser.RenderLimitError (optional)
+	MaxRenderDuration time.Duration
+	// ParsePolicy is how failures to read PDFs are handled: ParsePolicyBestEffort (default) or ParsePolicyStrict, which fails the extraction instead of falling through to vision
+	ParsePolicy string
+	// Sandbox parses, renders, fingerprints and reads the layout of the PDFs of extractions in a separate helper process, limited in memory and time, to contain crashes and exploits of the parser on untrusted documents (optional)
+	Sandbox *SandboxConfig
 }
 
 // ExtractionOptions holds options for extracting data from a PDF
 type ExtractionOptions struct {
-	// Schema is the JSON schema defining the structure of data to extract (required)
+	// Schema is the JSON schema defining the structure of data to extract (required, unless Schemas is set)
 	Schema map[string]interface{}
+	// Schemas are independent JSON schemas by name, extracted in a single model call with a combined schema and split into ExtractionResult.SchemaData (optional, replaces Schema)
+	Schemas map[string]map[string]interface{}
+	// SchemaRefs are the schema documents referenced by the external $ref of Schema and Schemas, by URI, e.g. {"common.json": commonSchema} for "common.json#/$defs/address"; references are inlined before the schema is sent to the model (optional)
+	SchemaRefs map[string]map[string]interface{}
 	// PDFPath is the path to the PDF file (either PDFPath or PDFBuffer must be provided)
 	PDFPath string
 	// PDFBuffer is the PDF file as bytes (either PDFPath or PDFBuffer must be provided)
 	PDFBuffer []byte
+	// ParsedPdf is the PDF already parsed by Extractor.Parse, to extract several schemas from a document without parsing and rendering it again (optional, replaces PDFPath and PDFBuffer)
+	ParsedPdf *ParsedPdf
 	// Temperature is the OpenAI temperature parameter (0-2, optional)
 	Temperature *float64
 	// MaxTokens is the maximum tokens for the response (optional)
 	MaxTokens *int
+	// Instructions are additional instructions appended to the extraction prompt (optional)
+	Instructions string
+	// DecodeBarcodes decodes barcodes and QR codes locally and gives their payloads to the model as ground truth
+	DecodeBarcodes bool
+	// HandwritingMode renders pages at a higher resolution with enhanced contrast and uses a prompt tuned for handwritten content
+	HandwritingMode bool
+	// FormFields are checkboxes and signature boxes detected on cropped page regions and stored in the result (optional)
+	FormFields []FormField
+	// Markdown sends the text of text-based PDFs to the model as Markdown, which keeps the structure of headings, lists and tables
+	Markdown bool
+	// LayoutMode rebuilds the text of text-based PDFs from the position of its lines, reading multi-column pages column by column and keeping the alignment of tables
+	LayoutMode bool
+	// ReadColumns reorders the text of multi-column pages, such as papers and newsletters, column by column instead of reading across the columns, keeping the text of other pages as extracted
+	ReadColumns bool
+	// SearchablePDF transcribes the pages of scanned PDFs read through vision and returns a copy of the PDF with an invisible text layer in the result
+	SearchablePDF bool
+	// DocumentID identifies the document for the Indexer and in events (default: the PDF path, or the SHA-256 of the PDF)
+	DocumentID string
+	// SchemaName names the schema in events and metrics (default: the "title" of the schema)
+	SchemaName string
+	// SchemaVersion versions the schema in results and records, e.g. a release of a schema registry
+	SchemaVersion string
+	// StripBoilerplate removes running headers, footers and page numbers repeated across the pages of text-based PDFs before building the prompt
+	StripBoilerplate bool
+	// NormalizeText joins words hyphenated at line breaks and sentences broken over lines, and collapses runs of whitespace in the text of text-based PDFs
+	NormalizeText bool
+	// TextThreshold overrides ExtractorConfig.TextThreshold for this call (optional)
+	TextThreshold int
+	// VisionEnabled overrides ExtractorConfig.VisionEnabled for this call (optional)
+	VisionEnabled *bool
+	// ForceMode overrides the detection of text-based PDFs: ForceModeAuto (default), ForceModeText to always read the text layer, or ForceModeVision to always send page images, e.g. for tables that read better visually
+	ForceMode string
+	// MixedPages sends the pages of documents mixing digital and scanned pages as text and the scanned pages only as images, in a single request to the vision model
+	MixedPages bool
+	// TruncateToFit truncates the middle of the text of documents that do not fit in the context window of the model, instead of failing with ErrContextLengthExceeded
+	TruncateToFit bool
+	// IdempotencyKey is sent as the Idempotency-Key header of the model request, so that providers supporting it answer a retried request without running and billing it again (optional)
+	IdempotencyKey string
+	// NullableFields are dot-separated paths of schema fields the model must return as null, with a reason in ExtractionResult.FieldStatus, instead of guessing when the document does not show them (optional)
+	NullableFields []string
+	// SchemaDrift is what happens to the keys of the data returned by the model that the schema does not have, reported in ExtractionResult.Drift: SchemaDriftPrune (default) removes them, SchemaDriftKeep keeps them and SchemaDriftFail fails the extraction with extractor.ErrSchemaDrift, also on missing required keys
+	SchemaDrift string
+	// Vocabularies are the allowed values of fields, by dot-separated path where "*" stands for any array item, such as vendor names or GL account codes: extracted values are matched to the closest allowed value, reported in ExtractionResult.VocabularyMatches (optional)
+	Vocabularies map[string][]string
+	// VocabularyThreshold is the lowest confidence, from 0 to 1, at which an extracted value is replaced by the closest value of its vocabulary (default: 0.8)
+	VocabularyThreshold float64
+	// RedactRegions are areas of the pages blacked out on the page images before they are sent to the vision model, e.g. the social security number box of a standardized form (optional)
+	RedactRegions []PageRedaction
+	// RedactPatterns are regular expressions whose matches are blacked out on the page images, found with the text layout of the pages from their text layer or ExtractorConfig.OCRBackend, and replaced in the text sent to the model (optional)
+	RedactPatterns []string
+	// Minimize sends the model only the passages of the document relevant to the schema, found by the words of its field names, titles and enum values or MinimizeAnchors, and the text recognized by ExtractorConfig.OCRBackend instead of page images; no content of the document is cached, indexed, remembered for feedback, logged or recorded in audit records, and renderers and encoders writing temporary files are refused
+	Minimize bool
+	// MinimizeAnchors are words or phrases, such as the labels of a form, marking the relevant passages of the document besides the fields of the schema (when Minimize is set)
+	MinimizeAnchors []string
+	// Audit sets ExtractionResult.Record, the audit record of the extraction, even without an ExtractorConfig.Recorder
+	Audit bool
+	// SkipTemplates extracts the document with the model even when it matches a layout template of the extractor
+	SkipTemplates bool
+	// ResolveEntities are the fields holding the names of customers, vendors and other parties, by dot-separated path where "*" stands for any array item, with the kind of entity they name, e.g. {"seller.name": "vendor"}: the names are resolved by ExtractorConfig.EntityResolver into ExtractionResult.ResolvedEntities (optional)
+	ResolveEntities map[string]string
+	// DateRules check that extracted dates fall within plausible windows, such as an invoice date within the last 5 years or a due date after the invoice date (optional)
+	DateRules []DateRule
+	// DateRulePolicy is what happens to dates breaking DateRules: DateRulePolicyWarn (default) reports them in ExtractionResult.Warnings, DateRulePolicyRetry asks the model once to read them again before reporting those left
+	DateRulePolicy string
+	// FieldDirectivePolicy is what happens to values breaking the x-format, x-language and x-unit keywords of the schema that cannot be rewritten locally: FieldDirectivePolicyWarn (default) reports them in ExtractionResult.Warnings, FieldDirectivePolicyRetry asks the model once to fix them before reporting those left
+	FieldDirectivePolicy string
+	// Outline reads the outline (bookmarks) of the PDF into ParsedPdf.Outline
+	Outline bool
+	// Fingerprint fingerprints the PDF into ExtractionResult.Fingerprint, to detect documents submitted again (see parser.Fingerprint)
+	Fingerprint bool
+	// Annotations reads the annotations of the PDF, such as highlights, sticky notes and stamps, and gives them to the model along with the document, as the notes of reviewers often hold the values to extract
+	Annotations bool
+	// Links reads the hyperlinks of the PDF into the result and gives their targets to the model, as the text of links to emails and web addresses is often shortened on the page
+	Links bool
+	// DetectUnits detects the currency and units of measure of the document from the symbols and ISO codes of its text into ExtractionResult.Currency and Units, and normalizes the currency fields of the data ("currency" or "currencyCode"): symbols become ISO 4217 codes, and fields left empty get the currency of the document
+	DetectUnits bool
+	// CheckNumbers parses the amounts extracted as strings into number fields, such as "1.234,56", with the decimal separator of the document, and corrects numbers that are not in the text of the document but whose magnitude is off by a misread decimal separator, with a warning for each value changed
+	CheckNumbers bool
+	// DecimalSeparator is the decimal separator of the numbers of the document for CheckNumbers, "." or "," (default: detected from the text of the document)
+	DecimalSeparator string
+	// CrossCheckOCR recognizes the text of scanned PDFs read through vision with the OCR backend of ExtractorConfig.CrossCheckBackend and reports the numbers and identifiers of the data that do not appear in it in ExtractionResult.Unverified, as vision models sometimes make up values such as invoice numbers
+	CrossCheckOCR bool
+	// Section limits the extraction to a section of the document, given by its heading, such as "Schedule A", or by a path of outline titles separated by ">", such as "Part II > Schedule A". The section is found in the outline of the PDF or else from its heading to the next heading of the same kind, and extraction fails with extractor.ErrSectionNotFound when it is not found (optional)
+	Section string
 }
 
 // PdfPageImage represents an image of a PDF page
@@ -40,16 +228,38 @@ type PdfPageImage struct {
 	Page int
 	// Base64 is the base64-encoded PNG image
 	Base64 string
+	// Detail is the detail level the image is sent to the vision model at, overriding
+	// ExtractorConfig.ImageDetail (optional)
+	Detail string
+}
+
+// PageTile is a crop of a rendered page, see parser.TilePageImage
+type PageTile struct {
+	// Page is the page number (1-indexed)
+	Page int
+	// Region is the area of the page covered by the tile, relative to the page size (0 to 1)
+	Region Rect
+	// Base64 is the base64-encoded PNG image
+	Base64 string
 }
 
 // ParsedPdfContent represents the content extracted from a PDF
 type ParsedPdfContent struct {
-	// Type indicates whether content is "text" or "images"
+	// Type indicates whether content is "text", "images" or, for documents mixing text and scanned
+	// pages read with ParseOptions.MixedPages, "mixed"
 	Type string
-	// TextContent holds the text content (when Type is "text")
+	// TextContent holds the text content (when Type is "text" or "mixed")
 	TextContent string
-	// ImageContent holds the image content (when Type is "images")
+	// TextPages holds the text of each page, in page order (when Type is "text", or "mixed" with
+	// the scanned pages left empty)
+	TextPages []string
+	// ImageContent holds the image content (when Type is "images", or "mixed" with the scanned pages only)
 	ImageContent []PdfPageImage
+	// PageTypes tells whether each page is read as "text" or "images", in page order (when Type is "mixed")
+	PageTypes []string
+	// OCRText holds the text recognized by the OCR backend, to read alongside the images (when Type
+	// is "images" and ParseOptions.OCRBackend is set)
+	OCRText string
 }
 
 // ParsedPdf represents the result of PDF parsing
@@ -60,20 +270,1306 @@ type ParsedPdf struct {
 	NumPages int
 	// Info holds metadata from the PDF
 	Info map[string]interface{}
+	// Barcodes are the barcodes and QR codes decoded from the pages (when DecodeBarcodes is set)
+	Barcodes []Barcode
+	// Layout holds the words and lines of each page with their bounding boxes (when TextLayout is set)
+	Layout []PageLayout
+	// RenderDuration is the time spent rendering pages as images while parsing
+	RenderDuration time.Duration
+	// FailedPages are the pages that could not be read or rendered and are missing from the content
+	FailedPages []PageFailure
+	// Outline is the outline (bookmarks) of the PDF, empty when it has none (when Outline is set)
+	Outline []OutlineItem
+	// Links are the hyperlinks of the pages (when Links is set)
+	Links []Link
+}
+
+// Fingerprint identifies a PDF and describes its structure, to deduplicate documents and key caches
+type Fingerprint struct {
+	// Hash is the SHA-256 of the PDF, in hex
+	Hash string
+	// ContentHash is the SHA-256, in hex, of the pages of the PDF: their size, their content
+	// streams and the fonts they use. Copies of a document saved again with other metadata,
+	// document IDs or compression have the same ContentHash.
+	ContentHash string
+	// NumPages is the number of pages of the PDF
+	NumPages int
+	// Version is the PDF version of the file, e.g. "1.7"
+	Version string
+	// Fonts are the names of the fonts used by the pages, sorted, without their subset prefix
+	Fonts []string
+	// Producer is the application that produced the PDF, from its metadata
+	Producer string
+	// Creator is the application that created the original document, from its metadata
+	Creator string
+}
+
+// OutlineItem is an entry of the outline of a PDF, the table of contents shown by PDF viewers
+type OutlineItem struct {
+	// Title is the title of the entry
+	Title string
+	// Page is the page the entry points to (1-indexed), or 0 when it points outside the document
+	Page int
+	// Children are the entries nested under the entry, such as the sections of a chapter
+	Children []OutlineItem
+}
+
+// InfoAnnotations is the key of ParsedPdf.Info holding the annotations of the PDF as []Annotation
+// (when Annotations is set)
+const InfoAnnotations = "annotations"
+
+// Annotation is a comment added to a page of a PDF, such as a highlight, a sticky note or a stamp
+type Annotation struct {
+	// Page is the page number (1-indexed)
+	Page int
+	// Type is the PDF subtype of the annotation, such as "Highlight", "Text" (sticky notes),
+	// "FreeText" or "Stamp"
+	Type string
+	// Author is the author of the annotation, usually the name of the reviewer
+	Author string
+	// Contents is the comment of the annotation, or the name of stamps without one, such as "Approved"
+	Contents string
+	// Text is the text of the page marked by highlight, underline, squiggly and strikeout annotations
+	Text string
+	// Modified is the date of the last change, as written in the PDF (e.g. "D:20240131120000Z")
+	Modified string
+	// Bounds is the area of the annotation, in points from the top-left corner of the page
+	Bounds Rect
+}
+
+// Link is a hyperlink of a page of a PDF
+type Link struct {
+	// Page is the page number (1-indexed)
+	Page int
+	// URI is the target of the link, such as "https://example.com" or "mailto:jane@example.com"
+	URI string
+	// Text is the text of the page covered by the link, which may differ from its target
+	Text string
+	// Bounds is the area of the link, in points from the top-left corner of the page
+	Bounds Rect
+}
+
+// PageFailure is a page of a PDF that could not be read or rendered
+type PageFailure struct {
+	// Page is the page number (1-indexed)
+	Page int
+	// Stage is what failed: PageStageText or PageStageRender
+	Stage string
+	// Reason is the error of the parser backend
+	Reason string
 }
 
+// ChunkFailure is a part of a long document, extracted in several requests, whose extraction
+// failed: its pages are missing from the partial result
+type ChunkFailure struct {
+	// FirstPage and LastPage are the pages (1-indexed) covered by the chunk
+	FirstPage, LastPage int
+	// Section is the title of the outline section of the chunk (when chunking by outline)
+	Section string
+	// Err is the extraction error of the chunk
+	Err error
+}
+
+// Stages of PageFailure
+const (
+	// PageStageText is the reading of the text of a page
+	PageStageText = "text"
+	// PageStageRender is the rendering of a page as an image
+	PageStageRender = "render"
+)
+
 // ExtractionResult represents the result of data extraction
 type ExtractionResult struct {
 	// Data is the extracted data matching the schema
 	Data map[string]interface{}
+	// SchemaData is the extracted data of each of ExtractionOptions.Schemas, by name (when Schemas is set)
+	SchemaData map[string]map[string]interface{}
 	// TokensUsed is the number of tokens used in the API call
 	TokensUsed int
+	// Usage details the tokens used in the API calls, including cached and reasoning tokens
+	Usage Usage
 	// Model is the model used for extraction
 	Model string
+	// SchemaHash is the SHA-256, in hex, of the extraction schema serialized as JSON with sorted keys, its references inlined
+	SchemaHash string
+	// SchemaName is the name of the extraction schema (ExtractionOptions.SchemaName, or the title of the schema)
+	SchemaName string
+	// SchemaVersion is the version of the extraction schema (ExtractionOptions.SchemaVersion)
+	SchemaVersion string
+	// LibraryVersion is the version of the library that extracted the data (extractor.LibraryVersion)
+	LibraryVersion string
+	// Template is the name of the layout template the data was extracted with, without calling the model, or "" when the model extracted it
+	Template string
+	// Barcodes are the barcodes and QR codes decoded from the pages (when DecodeBarcodes is set)
+	Barcodes []Barcode
+	// Links are the hyperlinks of the pages (when Links is set)
+	Links []Link
+	// FailedPages are the pages that could not be read or rendered, missing from what the model saw
+	FailedPages []PageFailure
+	// SearchablePDF is the PDF with an invisible text layer over its scanned pages (when SearchablePDF is set and the PDF was read through vision)
+	SearchablePDF []byte
+	// Warnings report degraded extractions, such as text truncated to fit the context window
+	Warnings []string
+	// VocabularyMatches are the closest allowed values of the fields with a vocabulary, by dot-separated path (when Vocabularies is set)
+	VocabularyMatches map[string]VocabularyMatch
+	// ResolvedEntities are the entities of the master data named by the fields of ResolveEntities, by dot-separated path (when ResolveEntities is set)
+	ResolvedEntities map[string]EntityMatch
+	// Currency is the ISO 4217 code of the currency used most in the document, "" when its text has none (when DetectUnits is set)
+	Currency string
+	// Units are the units of measure written after the numbers of the document, such as "kg" or "m²", from the most to the least used (when DetectUnits is set)
+	Units []string
+	// Unverified are the dot-separated paths of the numbers and identifiers of the data that do not appear in the OCR text of the pages, likely misread or made up by the vision model (when CrossCheckOCR is set)
+	Unverified []string
+	// Fingerprint holds the hashes and structural features of the PDF (when Fingerprint is set)
+	Fingerprint *Fingerprint
+	// Record is the audit record of the extraction (when Audit is set or ExtractorConfig.Recorder is configured)
+	Record *ExtractionRecord
+	// Timings is the time spent in the steps of the extraction
+	Timings Timings
+	// Drift reports the keys of the data returned by the model that the schema does not have, and the required keys it left out, as with providers ignoring strict mode (nil when the keys match the schema)
+	Drift *SchemaDrift
+	// FieldStatus tells whether each of ExtractionOptions.NullableFields was found, by path: FieldStatusFound, FieldStatusNotPresent or FieldStatusIllegible (when NullableFields is set)
+	FieldStatus map[string]string
+}
+
+// Timings is the time spent in the steps of an extraction, to monitor latency per document type
+type Timings struct {
+	// Parse is the time spent parsing the PDF, rendering included (0 for a ParsedPdf given in the options)
+	Parse time.Duration `json:"parse"`
+	// Render is the part of Parse spent rendering pages as images
+	Render time.Duration `json:"render"`
+	// Request is the time spent waiting for the model to extract the data, retries included
+	Request time.Duration `json:"request"`
+	// Total is the time spent in the whole extraction
+	Total time.Duration `json:"total"`
+	// Retries is the number of model calls repeated, e.g. to correct constraint violations
+	Retries int `json:"retries"`
 }
 
 // ParseOptions holds options for PDF parsing
 type ParseOptions struct {
 	// TextThreshold is the minimum text length to consider PDF as text-based
 	TextThreshold int
+	// MinTextQuality is the parser.TextQuality below which the text layer is considered garbage and
+	// the pages are rendered as images instead (default: 0.5; negative disables the check)
+	MinTextQuality float64
+	// ForceImages skips text detection and always renders the pages as images
+	ForceImages bool
+	// ForceText skips text detection and always reads the text layer, failing when it is empty
+	ForceText bool
+	// MixedPages detects the content of every page, and reads documents mixing text and scanned
+	// pages as "mixed" content: the text of the text pages and images of the scanned pages only
+	MixedPages bool
+	// DPI is the resolution used to render pages as images (default: 300)
+	DPI float64
+	// PixelBudget renders each page at the resolution that gives it about this many pixels, from
+	// its physical dimensions, instead of at DPI: small receipts are rendered sharper and large
+	// drawings smaller. The resolution is kept between 72 and 600 DPI (optional)
+	PixelBudget int
+	// EnhanceContrast converts page renders to grayscale and stretches their contrast
+	EnhanceContrast bool
+	// ArtifactStore caches the page renders, keyed by the hash of the PDF and the resolution, and
+	// pages found in it are not rendered again. It is not used by parsers running in a sandbox
+	// (optional)
+	ArtifactStore ArtifactStore `json:"-"`
+	// DecodeBarcodes renders every page and decodes its barcodes and QR codes
+	DecodeBarcodes bool
+	// Markdown converts the text content to Markdown, keeping headings, lists and tables
+	Markdown bool
+	// LayoutMode rebuilds the text content from the position of its lines: columns are read in
+	// order and the alignment of tables is kept
+	LayoutMode bool
+	// ReadColumns detects multi-column pages and reorders their text column by column, keeping the
+	// text of other pages as extracted (implied by LayoutMode)
+	ReadColumns bool
+	// TextLayout reads the bounding boxes of the words and lines of every page into ParsedPdf.Layout; pages without text get the layout recognized by OCRBackend, when it reads layouts
+	TextLayout bool
+	// Outline reads the outline (bookmarks) of the PDF into ParsedPdf.Outline
+	Outline bool
+	// Annotations reads the annotations of the PDF, such as highlights, sticky notes and stamps, into
+	// ParsedPdf.Info under InfoAnnotations
+	Annotations bool
+	// Links reads the hyperlinks of the PDF into ParsedPdf.Links
+	Links bool
+	// StripBoilerplate removes running headers, footers and page numbers from the text content
+	StripBoilerplate bool
+	// NormalizeText joins words hyphenated at line breaks and sentences broken over lines, and
+	// collapses runs of whitespace in the text content
+	NormalizeText bool
+	// TextBackend names the registered parser backend that reads the text of the pages (default: the built-in backend)
+	TextBackend string
+	// OCRBackend names a registered parser backend, such as a remote OCR service, whose text is kept in
+	// ParsedPdfContent.OCRText alongside the page images of PDFs without a text layer (optional)
+	OCRBackend string
+	// Renderer names the registered parser backend that renders the pages as images: RendererMuPDF (default),
+	// RendererPdftoppm, RendererGhostscript or a custom backend
+	Renderer string
+	// RendererPath is the path of the pdftoppm or Ghostscript binary (default: "pdftoppm" or "gs" found in the PATH)
+	RendererPath string
+	// IsolateRendering renders pages in a goroutine of its own that recovers from panics of the
+	// renderer on malformed PDFs, which then fail with parser.ErrRenderFailed instead of crashing
+	// the process
+	IsolateRendering bool
+	// MaxRenderMemoryBytes aborts rendering with a parser.RenderLimitError before a page whose
+	// raster would take more memory, e.g. a page of huge dimensions (optional)
+	MaxRenderMemoryBytes int64
+	// MaxRenderDuration aborts rendering with a parser.RenderLimitError when the pages are not
+	// rendered in time, e.g. because of a compression bomb (optional)
+	MaxRenderDuration time.Duration
+	// ParsePolicy is how failures to read PDFs are handled: ParsePolicyBestEffort (default) or
+	// ParsePolicyStrict, which surfaces them instead of falling through to an expensive vision call
+	ParsePolicy string
+}
+
+// Backends built into the parser, see ParseOptions
+const (
+	// RendererMuPDF renders pages with the built-in MuPDF library
+	RendererMuPDF = "mupdf"
+	// RendererPdftoppm renders pages with pdftoppm from Poppler
+	RendererPdftoppm = "pdftoppm"
+	// RendererGhostscript renders pages with Ghostscript
+	RendererGhostscript = "ghostscript"
+)
+
+// Image formats of the page images sent to vision models, see ExtractorConfig.ImageFormat
+const (
+	// ImageFormatPNG is lossless PNG, accepted by every provider
+	ImageFormatPNG = "png"
+	// ImageFormatWebP is lossy WebP, encoded with libwebp's cwebp
+	ImageFormatWebP = "webp"
+	// ImageFormatAVIF is lossy AVIF, encoded with libavif's avifenc
+	ImageFormatAVIF = "avif"
+)
+
+// Detail levels of the page images sent to vision models, see ExtractorConfig.ImageDetail
+const (
+	// ImageDetailLow sends images downscaled to 512x512 pixels, for a fixed 85 tokens
+	ImageDetailLow = "low"
+	// ImageDetailHigh sends images in 512-pixel tiles, for up to about 1,100 tokens
+	ImageDetailHigh = "high"
+	// ImageDetailAuto lets the model choose the detail level from the size of the image
+	ImageDetailAuto = "auto"
+	// ImageDetailAdaptive sends simple pages, such as blank or sparse pages and small images, at low
+	// detail and other pages at high detail
+	ImageDetailAdaptive = "adaptive"
+)
+
+// OpenAI-compatible servers with a built-in preset, see ExtractorConfig.Provider
+const (
+	// ProviderOpenAI is the OpenAI API
+	ProviderOpenAI = "openai"
+	// ProviderDockerModelRunner is Docker Model Runner
+	ProviderDockerModelRunner = "docker-model-runner"
+	// ProviderLlamaCpp is the llama.cpp server (llama-server)
+	ProviderLlamaCpp = "llama.cpp"
+	// ProviderLMStudio is the LM Studio server
+	ProviderLMStudio = "lmstudio"
+	// ProviderVLLM is the vLLM OpenAI-compatible server
+	ProviderVLLM = "vllm"
+)
+
+// Statuses of ExtractionResult.FieldStatus
+const (
+	// FieldStatusFound reports a field read from the document
+	FieldStatusFound = "found"
+	// FieldStatusNotPresent reports a field the document does not contain, extracted as null
+	FieldStatusNotPresent = "not_present"
+	// FieldStatusIllegible reports a field the document contains but that cannot be read, extracted as null
+	FieldStatusIllegible = "illegible"
+)
+
+// Policies of ExtractionOptions.SchemaDrift
+const (
+	// SchemaDriftPrune removes the keys missing from the schema from the data and reports them
+	SchemaDriftPrune = "prune"
+	// SchemaDriftKeep keeps the keys missing from the schema in the data and reports them
+	SchemaDriftKeep = "keep"
+	// SchemaDriftFail fails the extraction when the keys of the data do not match the schema
+	SchemaDriftFail = "fail"
+)
+
+// SchemaDrift is the difference between the keys of the data returned by the model and its schema,
+// see ExtractionResult.Drift
+type SchemaDrift struct {
+	// Unexpected are the dot-separated paths of the keys the schema does not have, where array
+	// items are given by index
+	Unexpected []string
+	// Missing are the dot-separated paths of the required keys left out
+	Missing []string
+}
+
+// VocabularyMatch is the closest allowed value of an extracted value, see ExtractionOptions.Vocabularies
+type VocabularyMatch struct {
+	// Extracted is the value read by the model
+	Extracted string
+	// Value is the closest allowed value, "" when no allowed value is similar at all
+	Value string
+	// Confidence is the similarity of the extracted and allowed values, from 0 to 1 for values
+	// identical regardless of case, spacing and punctuation
+	Confidence float64
+	// Matched tells whether the extracted value was replaced by the allowed value, its confidence
+	// reaching ExtractionOptions.VocabularyThreshold
+	Matched bool
+}
+
+// DateRule is a plausible window for an extracted date, see ExtractionOptions.DateRules. Dates are
+// read as ISO 8601 dates ("2024-01-31") or date-times; fields that are missing or null are not
+// checked.
+type DateRule struct {
+	// Field is the dot-separated path of the date, where "*" stands for any array item, e.g.
+	// "invoiceDate" or "payments.*.date"
+	Field string
+	// MaxAge is how far in the past the date may be, e.g. 5 years (optional)
+	MaxAge time.Duration
+	// MaxAhead is how far in the future the date may be, e.g. 24 hours for dates that cannot be in
+	// the future (optional)
+	MaxAhead time.Duration
+	// After is the dot-separated path of a date the date may not precede, e.g. "invoiceDate" for
+	// "dueDate" (optional)
+	After string
+}
+
+// Policies of ExtractionOptions.DateRulePolicy
+const (
+	// DateRulePolicyWarn reports the dates breaking the rules in ExtractionResult.Warnings
+	DateRulePolicyWarn = "warn"
+	// DateRulePolicyRetry asks the model once to read the dates breaking the rules again, and
+	// reports those left in ExtractionResult.Warnings
+	DateRulePolicyRetry = "retry"
+)
+
+// Policies of ExtractionOptions.FieldDirectivePolicy
+const (
+	// FieldDirectivePolicyWarn reports the values breaking the field directives in
+	// ExtractionResult.Warnings
+	FieldDirectivePolicyWarn = "warn"
+	// FieldDirectivePolicyRetry asks the model once to fix the values breaking the field
+	// directives, and reports those left in ExtractionResult.Warnings
+	FieldDirectivePolicyRetry = "retry"
+)
+
+// Policies of ParseOptions.ParsePolicy
+const (
+	// ParsePolicyBestEffort reads what it can of PDFs the text backend fails to read, rendering
+	// them for the vision model instead, and reports the pages that cannot be read or rendered in
+	// ParsedPdf.FailedPages
+	ParsePolicyBestEffort = "best_effort"
+	// ParsePolicyStrict fails to parse PDFs the text backend fails to read, whose pages cannot be
+	// counted, or with a page that cannot be read or rendered
+	ParsePolicyStrict = "strict"
+)
+
+// Modes of ExtractionOptions.ForceMode
+const (
+	// ForceModeAuto reads PDFs with enough readable text as text and the others through vision
+	ForceModeAuto = "auto"
+	// ForceModeText always reads the text layer
+	ForceModeText = "text"
+	// ForceModeVision always sends page images to the vision model
+	ForceModeVision = "vision"
+)
+
+// Ways of requesting structured output, see ExtractorConfig.ResponseFormat
+const (
+	// ResponseFormatJSONSchema sends the schema as a strict json_schema response format
+	ResponseFormatJSONSchema = "json_schema"
+	// ResponseFormatJSONObject sends a json_object response format constrained by the schema, for
+	// servers that predate json_schema
+	ResponseFormatJSONObject = "json_object"
+	// ResponseFormatPrompt sends no response format and asks for JSON matching the schema in the prompt
+	ResponseFormatPrompt = "prompt"
+)
+
+// Summary lengths supported by SummarizeOptions
+const (
+	SummaryLengthShort  = "short"
+	SummaryLengthMedium = "medium"
+	SummaryLengthLong   = "long"
+)
+
+// SummarizeOptions holds options for summarizing a PDF
+type SummarizeOptions struct {
+	// Length is the desired summary length: "short", "medium" or "long" (default: "medium")
+	Length string
+	// Focus is an optional topic or angle the summary should concentrate on
+	Focus string
+}
+
+// SummaryEntity represents a notable entity mentioned in a document summary
+type SummaryEntity struct {
+	// Name is the entity name as it appears in the document
+	Name string
+	// Type is the entity category (e.g. "person", "organization", "location")
+	Type string
+}
+
+// SummaryResult represents a structured summary of a PDF
+type SummaryResult struct {
+	// Title is the document title or a short descriptive title
+	Title string
+	// Abstract is the prose summary of the document
+	Abstract string
+	// KeyPoints are the main points of the document
+	KeyPoints []string
+	// Entities are the notable entities mentioned in the document
+	Entities []SummaryEntity
+	// TokensUsed is the number of tokens used in the API call
+	TokensUsed int
+	// Model is the model used for summarization
+	Model string
+}
+
+// Entity types recognized by entity extraction
+const (
+	EntityTypePerson       = "person"
+	EntityTypeOrganization = "organization"
+	EntityTypeDate         = "date"
+	EntityTypeAmount       = "amount"
+	EntityTypeLocation     = "location"
+)
+
+// TextSpan represents a range of characters in the extracted text
+type TextSpan struct {
+	// Start is the offset of the first character (0-indexed, in Unicode code points)
+	Start int
+	// End is the offset just past the last character
+	End int
+}
+
+// Entity represents a named entity found in a PDF
+type Entity struct {
+	// Type is the entity category (one of the EntityType constants)
+	Type string
+	// Text is the entity as it appears in the document
+	Text string
+	// Value is the normalized value for dates (YYYY-MM-DD) and amounts (decimal number), empty otherwise
+	Value string
+	// Spans are the locations of every mention of the entity in the extracted text (empty for scanned PDFs)
+	Spans []TextSpan
+}
+
+// EntityResult represents the result of entity extraction
+type EntityResult struct {
+	// Entities are the named entities found in the document
+	Entities []Entity
+	// Text is the extracted text the entity spans refer to (empty for scanned PDFs)
+	Text string
+	// TokensUsed is the number of tokens used in the API call
+	TokensUsed int
+	// Model is the model used for extraction
+	Model string
+}
+
+// KeyValuePair represents a label and its value found in a form-like document
+type KeyValuePair struct {
+	// Key is the label as printed in the document
+	Key string
+	// Value is the value associated with the label, as printed in the document
+	Value string
+}
+
+// KeyValueResult represents the result of key-value extraction
+type KeyValueResult struct {
+	// Pairs are the label:value pairs found in the document, in reading order
+	Pairs []KeyValuePair
+	// TokensUsed is the number of tokens used in the API call
+	TokensUsed int
+	// Model is the model used for extraction
+	Model string
+}
+
+// Rect represents a rectangle by its top-left corner and size
+type Rect struct {
+	// X is the horizontal position of the left edge
+	X float64
+	// Y is the vertical position of the top edge
+	Y float64
+	// Width is the width of the rectangle
+	Width float64
+	// Height is the height of the rectangle
+	Height float64
+}
+
+// PdfEmbeddedImage represents an image embedded in a PDF page (as opposed to a page render)
+type PdfEmbeddedImage struct {
+	// Page is the page number (1-indexed)
+	Page int
+	// Bounds is the area the image covers on the page, in points from the top-left corner
+	Bounds Rect
+	// Width is the width of the image in pixels
+	Width int
+	// Height is the height of the image in pixels
+	Height int
+	// MimeType is the MIME type of the image data (e.g. "image/png")
+	MimeType string
+	// Base64 is the base64-encoded image data
+	Base64 string
+}
+
+// TextWord is a word of a PDF page with its bounding box
+type TextWord struct {
+	// Text is the text of the word
+	Text string
+	// Bounds is the area the word covers on the page, in points from the top-left corner
+	Bounds Rect
+}
+
+// TextLine is a line of text of a PDF page with its bounding box and words
+type TextLine struct {
+	// Text is the text of the line, its words separated by single spaces
+	Text string
+	// Bounds is the area the line covers on the page, in points from the top-left corner
+	Bounds Rect
+	// Words are the words of the line, from left to right
+	Words []TextWord
+}
+
+// PageLayout holds the positioned text of a PDF page
+type PageLayout struct {
+	// Page is the page number (1-indexed)
+	Page int
+	// Width is the width of the page in points
+	Width float64
+	// Height is the height of the page in points
+	Height float64
+	// Lines are the lines of text of the page, in content order
+	Lines []TextLine
+}
+
+// SearchablePdfResult represents a PDF made searchable with an invisible text layer
+type SearchablePdfResult struct {
+	// PDF is the PDF with an invisible text layer over its pages that had no text
+	PDF []byte
+	// Pages are the transcribed lines of the pages that had no text, with bounds given as fractions of
+	// the page size (Width and Height are 1)
+	Pages []PageLayout
+	// TokensUsed is the number of tokens used in the API calls
+	TokensUsed int
+	// Usage details the tokens used in the API calls
+	Usage Usage
+	// Model is the model used for transcription
+	Model string
+}
+
+// Mark kinds reported by mark detection
+const (
+	MarkKindSignature = "signature"
+	MarkKindStamp     = "stamp"
+	MarkKindLogo      = "logo"
+)
+
+// Mark represents a signature, stamp or logo detected on a PDF page
+type Mark struct {
+	// Kind is the kind of mark (one of the MarkKind constants)
+	Kind string
+	// Page is the page number (1-indexed)
+	Page int
+	// Bounds is the area of the mark relative to the page size (all values between 0 and 1)
+	Bounds Rect
+	// Base64 is the base64-encoded PNG image of the mark, cropped from the page render
+	Base64 string
+}
+
+// MarkDetectionResult represents the result of mark detection
+type MarkDetectionResult struct {
+	// HasSignature indicates whether a handwritten signature is present
+	HasSignature bool
+	// HasStamp indicates whether a stamp or seal is present
+	HasStamp bool
+	// HasLogo indicates whether a logo is present
+	HasLogo bool
+	// Marks are the detected marks with their cropped images
+	Marks []Mark
+	// TokensUsed is the number of tokens used in the API call
+	TokensUsed int
+	// Model is the model used for detection
+	Model string
+}
+
+// Form field kinds supported by form field detection
+const (
+	FormFieldCheckbox  = "checkbox"
+	FormFieldSignature = "signature"
+)
+
+// FormField describes a checkbox or signature box at a known position on a form page
+type FormField struct {
+	// Name is the result field the detected value is stored in, as a dot-separated path (e.g. "consent.signed")
+	Name string
+	// Kind is the kind of field: "checkbox" or "signature"
+	Kind string
+	// Page is the page number (1-indexed)
+	Page int
+	// Region is the area of the field relative to the page size (all values between 0 and 1)
+	Region Rect
+}
+
+// PageRedaction is an area of the pages blacked out on the page images sent to the vision model
+type PageRedaction struct {
+	// Page is the page number (1-indexed), or 0 for every page
+	Page int
+	// Region is the area to black out relative to the page size (all values between 0 and 1)
+	Region Rect
+}
+
+// FormFieldResult represents the result of form field detection
+type FormFieldResult struct {
+	// Values maps each field name to whether the checkbox is checked or the signature box is signed
+	Values map[string]bool
+	// TokensUsed is the number of tokens used in the API call
+	TokensUsed int
+	// Usage details the tokens used in the API call
+	Usage Usage
+	// Model is the model used for detection
+	Model string
+}
+
+// Barcode represents a barcode or QR code decoded from a PDF page
+type Barcode struct {
+	// Page is the page number (1-indexed)
+	Page int
+	// Format is the symbology of the code (e.g. "QR_CODE", "EAN_13", "CODE_128")
+	Format string
+	// Text is the decoded payload
+	Text string
+}
+
+// MRZ formats defined by ICAO Doc 9303
+const (
+	MRZFormatTD1 = "TD1"
+	MRZFormatTD2 = "TD2"
+	MRZFormatTD3 = "TD3"
+)
+
+// MRZ represents the parsed machine readable zone of an identity document
+type MRZ struct {
+	// Format is the MRZ layout: "TD1" (ID cards), "TD2" or "TD3" (passports)
+	Format string
+	// DocumentCode is the document type code (e.g. "P", "ID")
+	DocumentCode string
+	// IssuingCountry is the ISO 3166-1 alpha-3 code of the issuing state
+	IssuingCountry string
+	// Surname is the primary identifier of the holder
+	Surname string
+	// GivenNames is the secondary identifier of the holder
+	GivenNames string
+	// DocumentNumber is the document number
+	DocumentNumber string
+	// Nationality is the ISO 3166-1 alpha-3 code of the holder's nationality
+	Nationality string
+	// DateOfBirth is the date of birth (YYYY-MM-DD)
+	DateOfBirth string
+	// Sex is "M", "F" or "X"
+	Sex string
+	// ExpiryDate is the date of expiry (YYYY-MM-DD)
+	ExpiryDate string
+	// OptionalData holds the optional data elements, fillers removed
+	OptionalData string
+	// Valid indicates whether every check digit matches
+	Valid bool
+	// Errors lists the check digits that do not match
+	Errors []string
+}
+
+// Identity document types recognized by identity document extraction
+const (
+	IdentityDocumentPassport        = "passport"
+	IdentityDocumentIDCard          = "id_card"
+	IdentityDocumentDriverLicense   = "driver_license"
+	IdentityDocumentResidencePermit = "residence_permit"
+	IdentityDocumentOther           = "other"
+)
+
+// IdentityDocumentResult represents the fields extracted from an identity document
+type IdentityDocumentResult struct {
+	// DocumentType is the kind of document (one of the IdentityDocument constants)
+	DocumentType string
+	// DocumentNumber is the document number
+	DocumentNumber string
+	// IssuingCountry is the ISO 3166-1 alpha-3 code of the issuing country
+	IssuingCountry string
+	// Surname is the holder's surname
+	Surname string
+	// GivenNames is the holder's given names
+	GivenNames string
+	// Nationality is the ISO 3166-1 alpha-3 code of the holder's nationality
+	Nationality string
+	// DateOfBirth is the holder's date of birth (YYYY-MM-DD)
+	DateOfBirth string
+	// Sex is "M", "F" or "X"
+	Sex string
+	// ExpiryDate is the date of expiry (YYYY-MM-DD)
+	ExpiryDate string
+	// MRZ is the parsed machine readable zone, nil if the document has none or it could not be parsed
+	MRZ *MRZ
+	// Validated indicates that the MRZ check digits match and agree with the visual fields
+	Validated bool
+	// Warnings describe failed check digits and disagreements between the MRZ and the visual fields
+	Warnings []string
+	// TokensUsed is the number of tokens used in the API call
+	TokensUsed int
+	// Model is the model used for extraction
+	Model string
+}
+
+// ReceiptItem represents a line item of a receipt
+type ReceiptItem struct {
+	// Description is the item description as printed
+	Description string
+	// Quantity is the number of units (1 when not printed)
+	Quantity float64
+	// UnitPrice is the price of one unit
+	UnitPrice float64
+	// Amount is the line total
+	Amount float64
+}
+
+// ReceiptResult represents the data extracted from a receipt
+type ReceiptResult struct {
+	// Merchant is the normalized merchant name
+	Merchant string
+	// MerchantRaw is the merchant name as printed on the receipt
+	MerchantRaw string
+	// Date is the purchase date (YYYY-MM-DD)
+	Date string
+	// Time is the purchase time (HH:MM), empty when not printed
+	Time string
+	// Currency is the ISO 4217 currency code
+	Currency string
+	// Items are the purchased line items
+	Items []ReceiptItem
+	// Subtotal is the amount before tax, tip and discounts (derived from the items when not printed)
+	Subtotal float64
+	// Tax is the total tax amount
+	Tax float64
+	// TaxIncluded indicates that the item prices already include tax (common with VAT)
+	TaxIncluded bool
+	// Tip is the tip or gratuity amount
+	Tip float64
+	// Discount is the total discount, as a positive amount
+	Discount float64
+	// Total is the amount paid
+	Total float64
+	// PaymentMethod is the payment method (e.g. "cash", "card"), empty when not printed
+	PaymentMethod string
+	// Reconciled indicates that the items, subtotal, tax, tip, discount and total add up
+	Reconciled bool
+	// Warnings describe the amounts that do not add up
+	Warnings []string
+	// TokensUsed is the number of tokens used in the API call
+	TokensUsed int
+	// Model is the model used for extraction
+	Model string
+}
+
+// InvoiceParty represents the seller (BG-4) or buyer (BG-7) of an invoice
+type InvoiceParty struct {
+	// Name is the party's legal name (BT-27 / BT-44)
+	Name string
+	// VATID is the party's VAT identifier, with country prefix (BT-31 / BT-48)
+	VATID string
+	// LegalRegistrationID is the party's legal registration identifier (BT-30 / BT-47)
+	LegalRegistrationID string
+	// Street is the main address line (BT-35 / BT-50)
+	Street string
+	// City is the city (BT-37 / BT-52)
+	City string
+	// PostalCode is the post code (BT-38 / BT-53)
+	PostalCode string
+	// CountryCode is the ISO 3166-1 alpha-2 country code (BT-40 / BT-55)
+	CountryCode string
+}
+
+// InvoiceLine represents an invoice line (BG-25)
+type InvoiceLine struct {
+	// ID is the line identifier (BT-126)
+	ID string
+	// Name is the item name (BT-153)
+	Name string
+	// Quantity is the invoiced quantity (BT-129)
+	Quantity float64
+	// UnitCode is the UN/ECE Recommendation 20 unit of measure code (BT-130, e.g. "C62", "HUR")
+	UnitCode string
+	// NetPrice is the item net price (BT-146)
+	NetPrice float64
+	// NetAmount is the line net amount (BT-131)
+	NetAmount float64
+	// VATCategory is the VAT category code of the item (BT-151, e.g. "S", "Z", "E", "AE")
+	VATCategory string
+	// VATRate is the VAT rate of the item in percent (BT-152)
+	VATRate float64
+}
+
+// InvoiceVATBreakdown represents a VAT breakdown entry (BG-23)
+type InvoiceVATBreakdown struct {
+	// Category is the VAT category code (BT-118)
+	Category string
+	// Rate is the VAT rate in percent (BT-119)
+	Rate float64
+	// TaxableAmount is the taxable amount of the category (BT-116)
+	TaxableAmount float64
+	// TaxAmount is the VAT amount of the category (BT-117)
+	TaxAmount float64
+}
+
+// Invoice represents an invoice following the European e-invoicing semantic model (EN 16931).
+// Field comments reference the corresponding business terms (BT) and groups (BG).
+type Invoice struct {
+	// Number is the invoice number (BT-1)
+	Number string
+	// IssueDate is the invoice issue date, YYYY-MM-DD (BT-2)
+	IssueDate string
+	// TypeCode is the UNTDID 1001 invoice type code (BT-3, "380" invoice, "381" credit note)
+	TypeCode string
+	// Currency is the ISO 4217 invoice currency code (BT-5)
+	Currency string
+	// DueDate is the payment due date, YYYY-MM-DD (BT-9)
+	DueDate string
+	// BuyerReference is the reference assigned by the buyer (BT-10)
+	BuyerReference string
+	// PurchaseOrderReference is the purchase order reference (BT-13)
+	PurchaseOrderReference string
+	// PaymentTerms describes the payment terms (BT-20)
+	PaymentTerms string
+	// Seller is the seller party (BG-4)
+	Seller InvoiceParty
+	// Buyer is the buyer party (BG-7)
+	Buyer InvoiceParty
+	// PaymentIBAN is the IBAN of the payment account (BT-84)
+	PaymentIBAN string
+	// PaymentReference is the remittance information (BT-83)
+	PaymentReference string
+	// Lines are the invoice lines (BG-25)
+	Lines []InvoiceLine
+	// VATBreakdown is the VAT breakdown per category and rate (BG-23)
+	VATBreakdown []InvoiceVATBreakdown
+	// LineTotal is the sum of the invoice line net amounts (BT-106)
+	LineTotal float64
+	// TaxExclusiveTotal is the invoice total amount without VAT (BT-109)
+	TaxExclusiveTotal float64
+	// TaxTotal is the invoice total VAT amount (BT-110)
+	TaxTotal float64
+	// TaxInclusiveTotal is the invoice total amount with VAT (BT-112)
+	TaxInclusiveTotal float64
+	// PaidAmount is the amount already paid (BT-113)
+	PaidAmount float64
+	// PayableAmount is the amount due for payment (BT-115)
+	PayableAmount float64
+}
+
+// InvoiceResult represents the result of invoice extraction
+type InvoiceResult struct {
+	// Invoice is the extracted invoice
+	Invoice Invoice
+	// TokensUsed is the number of tokens used in the API call
+	TokensUsed int
+	// Model is the model used for extraction
+	Model string
+}
+
+// ClauseType is the category of a contract clause
+type ClauseType string
+
+const (
+	ClauseConfidentiality      ClauseType = "confidentiality"
+	ClauseTermination          ClauseType = "termination"
+	ClauseRenewal              ClauseType = "renewal"
+	ClausePayment              ClauseType = "payment"
+	ClauseLiability            ClauseType = "liability"
+	ClauseIndemnification      ClauseType = "indemnification"
+	ClauseIntellectualProperty ClauseType = "intellectual_property"
+	ClauseWarranty             ClauseType = "warranty"
+	ClauseNonCompete           ClauseType = "non_compete"
+	ClauseDataProtection       ClauseType = "data_protection"
+	ClauseAssignment           ClauseType = "assignment"
+	ClauseForceMajeure         ClauseType = "force_majeure"
+	ClauseGoverningLaw         ClauseType = "governing_law"
+	ClauseDisputeResolution    ClauseType = "dispute_resolution"
+	ClauseOther                ClauseType = "other"
+)
+
+// ContractOptions configures contract analysis
+type ContractOptions struct {
+	// ChunkSize is the maximum number of characters of contract text sent in a single request
+	// (default: 24000). Longer contracts are split at page and paragraph boundaries.
+	ChunkSize int
+	// ImagePagesPerChunk is the maximum number of page images sent in a single request for
+	// scanned contracts (default: 8)
+	ImagePagesPerChunk int
+	// ChunkByOutline splits long contracts at the sections of their outline (bookmarks), one
+	// request per section, instead of filling chunks up to ChunkSize. Sections longer than a chunk
+	// are still split, and contracts without an outline are chunked by size.
+	ChunkByOutline bool
+}
+
+// ContractTerm is a contract term value with the pages it is stated on
+type ContractTerm struct {
+	// Value is the term as stated in the contract (dates are YYYY-MM-DD)
+	Value string
+	// Pages are the pages (1-indexed) the term is stated on
+	Pages []int
+}
+
+// ContractParty represents a party to a contract
+type ContractParty struct {
+	// Name is the party's name
+	Name string
+	// Role is the party's role in the contract (e.g. "licensor", "customer")
+	Role string
+	// Pages are the pages (1-indexed) the party is identified on
+	Pages []int
+}
+
+// ContractClause represents a classified contract clause
+type ContractClause struct {
+	// Type is the clause category
+	Type ClauseType
+	// Title is the clause heading or number as printed
+	Title string
+	// Summary is a short summary of the clause
+	Summary string
+	// Pages are the pages (1-indexed) the clause spans
+	Pages []int
+}
+
+// ContractObligation represents an obligation of a contract party
+type ContractObligation struct {
+	// Party is the name of the obligated party
+	Party string
+	// Description describes what the party must do or refrain from doing
+	Description string
+	// Deadline is the due date or period of the obligation, if any
+	Deadline string
+	// Pages are the pages (1-indexed) the obligation is stated on
+	Pages []int
+}
+
+// ContractResult represents the result of contract analysis
+type ContractResult struct {
+	// Parties are the parties to the contract
+	Parties []ContractParty
+	// EffectiveDate is the date the contract takes effect
+	EffectiveDate ContractTerm
+	// TerminationDate is the date the contract ends, if fixed
+	TerminationDate ContractTerm
+	// RenewalTerms describes how and when the contract renews
+	RenewalTerms ContractTerm
+	// Clauses are the classified clauses of the contract
+	Clauses []ContractClause
+	// Obligations are the obligations of the parties
+	Obligations []ContractObligation
+	// Chunks is the number of requests the contract was split into
+	Chunks int
+	// FailedChunks are the chunks whose extraction failed, whose pages are missing from the result
+	FailedChunks []ChunkFailure
+	// Completeness is the fraction of the chunks extracted, from 0 to 1: below 1 the result is
+	// partial, and FailedChunks tells which pages are missing
+	Completeness float64
+	// TokensUsed is the number of tokens used in the API calls
+	TokensUsed int
+	// Model is the model used for extraction
+	Model string
+}
+
+// ResumeExperience represents a position held by a candidate
+type ResumeExperience struct {
+	// Title is the job title
+	Title string
+	// Employer is the employer's name
+	Employer string
+	// Location is where the position was held
+	Location string
+	// StartDate is the start of the position, YYYY-MM (or YYYY when the month is unknown)
+	StartDate string
+	// EndDate is the end of the position, YYYY-MM or YYYY; empty for current positions
+	EndDate string
+	// Current is true when the candidate still holds the position
+	Current bool
+	// Description summarizes the responsibilities and achievements
+	Description string
+	// Skills are the skills used in the position
+	Skills []string
+}
+
+// ResumeEducation represents an education entry of a candidate
+type ResumeEducation struct {
+	// Institution is the school or university name
+	Institution string
+	// Degree is the degree or qualification obtained (e.g. "MSc")
+	Degree string
+	// Field is the field of study
+	Field string
+	// StartDate is the start of the studies, YYYY-MM or YYYY
+	StartDate string
+	// EndDate is the end of the studies, YYYY-MM or YYYY
+	EndDate string
+}
+
+// ResumeSkill represents a skill of a candidate, deduplicated across the resume
+type ResumeSkill struct {
+	// Name is the skill name, as first mentioned
+	Name string
+	// FirstUsed is the earliest start date of the positions using the skill, YYYY-MM or YYYY
+	FirstUsed string
+	// LastUsed is the latest end date of the positions using the skill; empty when still in use
+	LastUsed string
+	// Mentions is the number of times the skill is mentioned in the resume
+	Mentions int
+}
+
+// Resume represents a normalized candidate profile
+type Resume struct {
+	// GivenName is the candidate's given name
+	GivenName string
+	// FamilyName is the candidate's family name
+	FamilyName string
+	// Email is the candidate's email address
+	Email string
+	// Phone is the candidate's phone number
+	Phone string
+	// Location is where the candidate lives
+	Location string
+	// Summary is the candidate's profile summary
+	Summary string
+	// Experience lists the positions held, most recent first
+	Experience []ResumeExperience
+	// Education lists the education entries, most recent first
+	Education []ResumeEducation
+	// Skills lists the candidate's skills, with repeated mentions merged
+	Skills []ResumeSkill
+}
+
+// ResumeResult represents the result of resume extraction
+type ResumeResult struct {
+	// Resume is the extracted candidate profile
+	Resume Resume
+	// TokensUsed is the number of tokens used in the API call
+	TokensUsed int
+	// Model is the model used for extraction
+	Model string
+}
+
+// BankStatementOptions configures bank statement extraction
+type BankStatementOptions struct {
+	// ChunkSize is the maximum number of characters of statement text sent in a single request
+	// (default: 24000). Longer statements are split at page boundaries.
+	ChunkSize int
+	// ImagePagesPerChunk is the maximum number of page images sent in a single request for
+	// scanned statements (default: 8)
+	ImagePagesPerChunk int
+}
+
+// BankTransaction represents a row of a bank statement's transaction table
+type BankTransaction struct {
+	// Date is the booking date, YYYY-MM-DD
+	Date string
+	// Description is the transaction description as printed
+	Description string
+	// Amount is the signed amount: negative for debits, positive for credits
+	Amount float64
+	// Balance is the running balance printed after the transaction, or nil when the row has none
+	Balance *float64
+	// Page is the page (1-indexed) the transaction is printed on
+	Page int
+}
+
+// BankStatement represents the account metadata and transactions of a bank statement
+type BankStatement struct {
+	// BankName is the name of the bank
+	BankName string
+	// AccountHolder is the name of the account holder
+	AccountHolder string
+	// AccountNumber is the account number or IBAN
+	AccountNumber string
+	// Currency is the ISO 4217 account currency code
+	Currency string
+	// PeriodStart is the first day of the statement period, YYYY-MM-DD
+	PeriodStart string
+	// PeriodEnd is the last day of the statement period, YYYY-MM-DD
+	PeriodEnd string
+	// OpeningBalance is the balance at the start of the period, or nil when not printed
+	OpeningBalance *float64
+	// ClosingBalance is the balance at the end of the period, or nil when not printed
+	ClosingBalance *float64
+	// Transactions are the statement rows, in printed order
+	Transactions []BankTransaction
+}
+
+// BankStatementResult represents the result of bank statement extraction
+type BankStatementResult struct {
+	// Statement is the extracted statement
+	Statement BankStatement
+	// Reconciled is true when the running balances and the opening and closing balances are
+	// consistent with the transactions
+	Reconciled bool
+	// Warnings describe balance inconsistencies, which usually point at missed or misread rows
+	Warnings []string
+	// Chunks is the number of requests the statement was split into
+	Chunks int
+	// FailedChunks are the chunks whose extraction failed, whose pages are missing from the result
+	FailedChunks []ChunkFailure
+	// Completeness is the fraction of the chunks extracted, from 0 to 1: below 1 the result is
+	// partial, and FailedChunks tells which pages are missing
+	Completeness float64
+	// TokensUsed is the number of tokens used in the API calls
+	TokensUsed int
+	// Model is the model used for extraction
+	Model string
+}
+
+// PaperAuthor represents an author of a scientific paper
+type PaperAuthor struct {
+	// Name is the author's full name
+	Name string
+	// Affiliation is the author's institution
+	Affiliation string
+	// Email is the author's email address
+	Email string
+}
+
+// Citation represents a structured entry of a paper's reference list
+type Citation struct {
+	// Raw is the reference as printed
+	Raw string
+	// Authors are the cited authors' names, in order
+	Authors []string
+	// Title is the title of the cited work
+	Title string
+	// Year is the publication year, or 0 when not stated
+	Year int
+	// Venue is the journal, conference or publisher
+	Venue string
+	// DOI is the Digital Object Identifier, without resolver prefix (e.g. "10.1000/xyz123")
+	DOI string
+}
+
+// Paper represents the bibliographic metadata and references of a scientific paper
+type Paper struct {
+	// Title is the paper title
+	Title string
+	// Authors are the paper authors, in order
+	Authors []PaperAuthor
+	// Abstract is the paper abstract
+	Abstract string
+	// Keywords are the paper keywords
+	Keywords []string
+	// DOI is the paper's Digital Object Identifier, without resolver prefix
+	DOI string
+	// Venue is the journal or conference the paper is published in
+	Venue string
+	// Year is the publication year, or 0 when not stated
+	Year int
+	// References is the parsed reference list, in printed order
+	References []Citation
+}
+
+// PaperResult represents the result of scientific paper extraction
+type PaperResult struct {
+	// Paper is the extracted paper
+	Paper Paper
+	// FailedChunks are the chunks whose extraction failed, whose pages are missing from the result
+	FailedChunks []ChunkFailure
+	// Completeness is the fraction of the chunks extracted, from 0 to 1: below 1 the result is
+	// partial, and FailedChunks tells which pages are missing
+	Completeness float64
+	// TokensUsed is the number of tokens used in the API calls
+	TokensUsed int
+	// Model is the model used for extraction
+	Model string
+}
+
+// BatchStatus is the outcome of the extraction of a batch document
+type BatchStatus string
+
+const (
+	BatchStatusSuccess BatchStatus = "success"
+	BatchStatusError   BatchStatus = "error"
+)
+
+// BatchDocument is a document of a batch extraction
+type BatchDocument struct {
+	// ID identifies the document in the results (default: the PDF path, or its index in the batch)
+	ID string
+	// Options are the extraction options of the document
+	Options ExtractionOptions
+}
+
+// BatchOptions configures a batch extraction
+type BatchOptions struct {
+	// Concurrency is the number of documents extracted in parallel (default: 4)
+	Concurrency int
+	// Journal records the documents extracted, and skips the documents it holds a success record of, to resume an interrupted batch; see the pkg/journal package (optional)
+	Journal BatchJournal
+}
+
+// BatchResult is the outcome of the extraction of a batch document
+type BatchResult struct {
+	// ID identifies the document
+	ID string
+	// Status is the outcome of the extraction
+	Status BatchStatus
+	// Result is the extraction result (when Status is success)
+	Result *ExtractionResult
+	// Err is the extraction error (when Status is error)
+	Err error
+	// Duration is the time the extraction took
+	Duration time.Duration
+	// Resumed is set when the result was read from BatchOptions.Journal instead of extracted: its
+	// Result holds only the data, the model and the tokens used by the earlier run
+	Resumed bool
+}
+
+// ModelPrice is the price of a model, per million tokens
+type ModelPrice struct {
+	// Input is the price of a million prompt tokens
+	Input float64
+	// Output is the price of a million completion tokens
+	Output float64
+}
+
+// DocumentPlan is the estimated cost of the extraction of a document, see Extractor.Plan
+type DocumentPlan struct {
+	// ID identifies the document (in batch plans)
+	ID string
+	// Pages is the number of pages of the document
+	Pages int
+	// Route is how the document is sent to the model: "text", "images" or "mixed"
+	Route string
+	// TextPages and ImagePages are the numbers of pages sent as text and as images
+	TextPages, ImagePages int
+	// Model is the model the document is sent to
+	Model string
+	// PromptTokens is the estimated number of tokens of the request
+	PromptTokens int
+	// CompletionTokens is the estimated number of tokens of the answer
+	CompletionTokens int
+	// MaxCompletionTokens is the most tokens the answer may take (ExtractionOptions.MaxTokens, or the
+	// tokens reserved for the response)
+	MaxCompletionTokens int
+	// Cost is the estimated cost, 0 when the price of the model is unknown
+	Cost float64
+	// MaxCost is the cost with an answer of MaxCompletionTokens
+	MaxCost float64
+	// Priced is set when the price of the model is known
+	Priced bool
+	// Skipped is set for batch documents BatchOptions.Journal holds a success record of, which are
+	// not extracted again and left out of the totals
+	Skipped bool
+	// Err is the error that would fail the extraction, such as an unreadable PDF or a request
+	// exceeding the context window of the model
+	Err error
+}
+
+// BatchPlan is the estimated cost of a batch extraction, see Extractor.PlanBatch
+type BatchPlan struct {
+	// Documents are the plans of the documents, in batch order
+	Documents []DocumentPlan
+	// Pages is the number of pages of the documents to extract
+	Pages int
+	// PromptTokens, CompletionTokens and MaxCompletionTokens are the totals of the documents to extract
+	PromptTokens, CompletionTokens, MaxCompletionTokens int
+	// Cost and MaxCost are the totals of the documents to extract whose model has a known price
+	Cost, MaxCost float64
+	// UnpricedModels are the models without known price, whose documents are left out of the costs
+	UnpricedModels []string
 }
@@ -0,0 +1,18 @@
+package types
+
+import "context"
+
+// ImageUploader uploads the page images of vision requests, e.g. to an object store or to the file
+// storage of a provider, and returns a URL the model can fetch the image from, such as a presigned
+// URL, so that requests reference images instead of inlining them as base64
+type ImageUploader interface {
+	UploadImage(ctx context.Context, data []byte, mimeType string) (string, error)
+}
+
+// ImageUploaderFunc adapts a function to the ImageUploader interface
+type ImageUploaderFunc func(ctx context.Context, data []byte, mimeType string) (string, error)
+
+// UploadImage calls f
+func (f ImageUploaderFunc) UploadImage(ctx context.Context, data []byte, mimeType string) (string, error) {
+	return f(ctx, data, mimeType)
+}
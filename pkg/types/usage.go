@@ -0,0 +1,35 @@
+package types
+
+// Usage is the token usage of the model calls of an extraction, read from the usage of chat
+// completion responses. Servers report it in different shapes; the common fields are filled from
+// the OpenAI fields or their provider-specific equivalents.
+type Usage struct {
+	// PromptTokens is the number of tokens of the requests
+	PromptTokens int `json:"promptTokens"`
+	// CompletionTokens is the number of tokens generated, including reasoning tokens
+	CompletionTokens int `json:"completionTokens"`
+	// TotalTokens is the number of prompt and completion tokens
+	TotalTokens int `json:"totalTokens"`
+	// CachedTokens is the number of prompt tokens read from the prompt cache, usually billed at a discount
+	CachedTokens int `json:"cachedTokens,omitempty"`
+	// ReasoningTokens is the number of completion tokens spent reasoning, billed but not part of the answer
+	ReasoningTokens int `json:"reasoningTokens,omitempty"`
+	// Details holds every numeric usage field reported by the server, by dot-separated path, e.g.
+	// "prompt_tokens_details.audio_tokens" or "cache_creation_input_tokens"
+	Details map[string]int `json:"details,omitempty"`
+}
+
+// Add adds the usage of another model call
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.CachedTokens += other.CachedTokens
+	u.ReasoningTokens += other.ReasoningTokens
+	if len(other.Details) > 0 && u.Details == nil {
+		u.Details = make(map[string]int, len(other.Details))
+	}
+	for key, value := range other.Details {
+		u.Details[key] += value
+	}
+}
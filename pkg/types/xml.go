@@ -0,0 +1,10 @@
+package types
+
+import "github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+
+// ToXML serializes the extracted data to XML with an element structure derived from the JSON
+// schema used for extraction, see schema.MarshalXML. schema.GenerateXSD produces the matching
+// XML Schema.
+func (r *ExtractionResult) ToXML(jsonSchema map[string]interface{}, options schema.XMLOptions) ([]byte, error) {
+	return schema.MarshalXML(r.Data, jsonSchema, options)
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/sandbox"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// withAnnotations adds annotation dictionaries to the first page of a PDF built by newTestPdf
+func withAnnotations(pdf []byte, annotations ...string) []byte {
+	page := testPageObject(1)
+	objects := map[int]string{}
+	refs := make([]string, 0, len(annotations))
+	for i, annotation := range annotations {
+		id := testPdfSize(pdf) + i
+		objects[id] = fmt.Sprintf("<< /Type /Annot /P %d 0 R %s >>", page, annotation)
+		refs = append(refs, fmt.Sprintf("%d 0 R", id))
+	}
+	objects[page] = fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Annots [%s] >>",
+		page-1, strings.Join(refs, " "))
+	return withObjects(pdf, objects)
+}
+
+func TestAnnotations(t *testing.T) {
+	pdf := withAnnotations(newTestPdf([]string{"Invoice INV-001", "Total: 1,250.00 EUR"}),
+		"/Subtype /Highlight /T (Reviewer) /Contents (Wrong number, see PO) /M (D:20240131120000Z) /Rect [60 716 400 732] /QuadPoints [60 732 400 732 60 716 400 716]",
+		"/Subtype /Text /T (Alice) /Contents (PO number is PO-7781) /Rect [500 700 520 720]",
+		"/Subtype /Stamp /Name /Approved /Rect [400 600 500 650]",
+		"/Subtype /Link /Rect [72 716 200 732] /A << /S /URI /URI (https://example.com) >>",
+	)
+
+	annotations, err := parser.ExtractAnnotationsFromBuffer(pdf)
+	if err != nil {
+		t.Fatalf("Expected annotations, got error: %v", err)
+	}
+	expected := []types.Annotation{
+		{Page: 1, Type: "Highlight", Author: "Reviewer", Contents: "Wrong number, see PO", Text: "Invoice INV-001", Modified: "D:20240131120000Z", Bounds: types.Rect{X: 60, Y: 60, Width: 340, Height: 16}},
+		{Page: 1, Type: "Text", Author: "Alice", Contents: "PO number is PO-7781", Bounds: types.Rect{X: 500, Y: 72, Width: 20, Height: 20}},
+		{Page: 1, Type: "Stamp", Contents: "Approved", Bounds: types.Rect{X: 400, Y: 142, Width: 100, Height: 50}},
+	}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Errorf("Expected annotations %+v, got %+v", expected, annotations)
+	}
+
+	t.Run("Extraction", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"poNumber": "PO-7781"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+		parsed, err := ext.Parse(types.ExtractionOptions{PDFBuffer: pdf, Annotations: true})
+		if err != nil {
+			t.Fatalf("Expected parsed PDF, got error: %v", err)
+		}
+		if found, _ := parsed.Info[types.InfoAnnotations].([]types.Annotation); !reflect.DeepEqual(found, expected) {
+			t.Errorf("Expected annotations in the PDF info, got %+v", parsed.Info[types.InfoAnnotations])
+		}
+
+		_, err = ext.Extract(types.ExtractionOptions{
+			ParsedPdf: parsed,
+			Schema:    map[string]interface{}{"type": "object", "properties": map[string]interface{}{"poNumber": map[string]interface{}{"type": "string"}}},
+		})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		prompt := mock.userPrompt(0)
+		for _, note := range []string{`Page 1, Text by Alice: comment "PO number is PO-7781"`, `Highlight by Reviewer: marks "Invoice INV-001", comment "Wrong number, see PO"`, "Stamp: comment \"Approved\""} {
+			if !strings.Contains(prompt, note) {
+				t.Errorf("Expected %q in the prompt, got %q", note, prompt)
+			}
+		}
+	})
+
+	t.Run("Sandbox", func(t *testing.T) {
+		parsed, err := sandbox.Parse(context.Background(), pdf, &types.ParseOptions{TextThreshold: 10, Annotations: true}, types.SandboxConfig{})
+		if err != nil {
+			t.Fatalf("Expected the helper to parse the PDF, got %v", err)
+		}
+		if found, _ := parsed.Info[types.InfoAnnotations].([]types.Annotation); !reflect.DeepEqual(found, expected) {
+			t.Errorf("Expected annotations from the helper, got %#v", parsed.Info[types.InfoAnnotations])
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"image"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/artifacts"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// recordingBackend records the pages it renders
+type recordingBackend struct{ rendered *[]int }
+
+func (recordingBackend) Name() string { return "recording" }
+
+func (b recordingBackend) Render(_ []byte, pages []int, _ float64, page func(int, *image.RGBA) error) error {
+	for _, p := range pages {
+		*b.rendered = append(*b.rendered, p)
+		if err := page(p, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestArtifactStore(t *testing.T) {
+	var rendered []int
+	parser.RegisterBackend(recordingBackend{rendered: &rendered})
+	pdf := newTestPdf([]string{"Page one"}, []string{"Page two"}, []string{"Page three"})
+
+	store, err := artifacts.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected artifact store, got error: %v", err)
+	}
+	options := &types.ParseOptions{ForceImages: true, Renderer: "recording", DPI: 150, ArtifactStore: store}
+
+	first, err := parser.ParsePdfFromBuffer(pdf, options)
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	if !reflect.DeepEqual(rendered, []int{1, 2, 3}) {
+		t.Fatalf("Expected the 3 pages rendered, got %v", rendered)
+	}
+	if _, ok, _ := store.Get(context.Background(), parser.PageImageKey(pdf, 2, 150, false)); !ok {
+		t.Errorf("Expected page 2 in the store under %s", parser.PageImageKey(pdf, 2, 150, false))
+	}
+
+	// Parsing again reuses the renders, and only the pages missing from the store are rendered
+	rendered = nil
+	second, err := parser.ParsePdfFromBuffer(pdf, options)
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	if len(rendered) != 0 || !reflect.DeepEqual(second.Content.ImageContent, first.Content.ImageContent) {
+		t.Errorf("Expected the stored renders, got %d pages rendered again", len(rendered))
+	}
+
+	options.DPI = 200
+	if _, err := parser.ParsePdfFromBuffer(pdf, options); err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	if !reflect.DeepEqual(rendered, []int{1, 2, 3}) {
+		t.Errorf("Expected the pages rendered again at another resolution, got %v", rendered)
+	}
+
+	t.Run("S3", func(t *testing.T) {
+		var mu sync.Mutex
+		objects := map[string][]byte{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth := r.Header.Get("Authorization"); !strings.Contains(auth, "/eu-west-1/s3/aws4_request") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+				t.Errorf("Expected a SigV4 signature for S3, got %q", auth)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			switch r.Method {
+			case http.MethodPut:
+				objects[r.URL.Path], _ = io.ReadAll(r.Body)
+			case http.MethodGet:
+				data, ok := objects[r.URL.Path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write(data)
+			}
+		}))
+		defer server.Close()
+
+		store, err := artifacts.NewS3(artifacts.S3Config{Bucket: "renders", Region: "eu-west-1", Endpoint: server.URL, Prefix: "extractor/", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+		if err != nil {
+			t.Fatalf("Expected S3 store, got error: %v", err)
+		}
+		if _, ok, err := store.Get(context.Background(), "pages/missing.png"); ok || err != nil {
+			t.Errorf("Expected a missing object to be a miss, got %v, %v", ok, err)
+		}
+
+		rendered = nil
+		options := &types.ParseOptions{ForceImages: true, Renderer: "recording", ArtifactStore: store}
+		for range 2 {
+			if _, err := parser.ParsePdfFromBuffer(pdf, options); err != nil {
+				t.Fatalf("Expected parsed PDF, got error: %v", err)
+			}
+		}
+		if len(rendered) != 3 {
+			t.Errorf("Expected the pages rendered once, got %v", rendered)
+		}
+		if _, ok := objects["/renders/extractor/"+parser.PageImageKey(pdf, 1, 300, false)]; !ok {
+			t.Errorf("Expected page 1 under the prefix in the bucket, got %d objects", len(objects))
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestAudit(t *testing.T) {
+	schema := map[string]interface{}{
+		"title":      "invoice",
+		"type":       "object",
+		"properties": map[string]interface{}{"invoiceNumber": map[string]interface{}{"type": "string"}},
+	}
+	encoded, _ := json.Marshal(schema)
+	sum := sha256.Sum256(encoded)
+	schemaHash := hex.EncodeToString(sum[:])
+
+	t.Run("Record", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"invoiceNumber": "INV-001"})
+		var records []types.ExtractionRecord
+		recorder := types.RecorderFunc(func(ctx context.Context, record types.ExtractionRecord) error {
+			records = append(records, record)
+			return nil
+		})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, SystemPrompt: "You extract invoices.", Recorder: recorder})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Invoice INV-001"}), Schema: schema, DocumentID: "inv-1", Instructions: "Read the invoice number."})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if len(records) != 1 || result.Record == nil {
+			t.Fatalf("Expected a record, got %d records and %+v", len(records), result.Record)
+		}
+		record := records[0]
+		if record.SchemaVersion != types.ExtractionRecordSchemaVersion || record.DocumentID != "inv-1" || record.Schema != "invoice" || record.SchemaHash != schemaHash {
+			t.Errorf("Expected the document and schema in the record, got %+v", record)
+		}
+		if record.PromptVersion != extractor.PromptVersion || record.SystemPrompt != "You extract invoices." || record.Provider != types.ProviderOpenAI || record.BaseURL != mock.URL {
+			t.Errorf("Expected the prompt and provider in the record, got %+v", record)
+		}
+		if !strings.Contains(record.UserPrompt, "Read the invoice number.") || !strings.Contains(record.UserPrompt, "Invoice INV-001") {
+			t.Errorf("Expected the user prompt in the record, got %q", record.UserPrompt)
+		}
+		if len(record.Calls) != 1 || record.Calls[0].Model != "gpt-4o-mini" || record.Calls[0].Parameters["temperature"] != 0.0 || record.Calls[0].Parameters["response_format"] == nil {
+			t.Fatalf("Expected the model call in the record, got %+v", record.Calls)
+		}
+		if messages := record.Calls[0].Messages; len(messages) != 2 || messages[0].Role != "system" || messages[1].Content != record.UserPrompt {
+			t.Errorf("Expected the messages of the call, got %+v", messages)
+		}
+		if record.Data["invoiceNumber"] != "INV-001" || record.Error != "" || record.Time.IsZero() {
+			t.Errorf("Expected the data in the record, got %+v", record)
+		}
+	})
+
+	t.Run("Images", func(t *testing.T) {
+		parser.RegisterBackend(blankBackend{})
+		mock := newMockServer(t, map[string]interface{}{"invoiceNumber": "INV-001"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, Renderer: "blank"})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Scan"}, []string{"Scan"}), Schema: schema, ForceMode: types.ForceModeVision, Audit: true})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if result.Record == nil || len(result.Record.Calls) != 1 {
+			t.Fatalf("Expected a record with a call, got %+v", result.Record)
+		}
+		user := result.Record.Calls[0].Messages[len(result.Record.Calls[0].Messages)-1]
+		if len(user.Images) != 2 || len(user.Images[0]) != 64 || strings.Contains(user.Content, "base64") {
+			t.Errorf("Expected the page images by hash, got %+v", user)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		mock := newMockServer(t, rawContent("not JSON"))
+		var records []types.ExtractionRecord
+		failing := errors.New("audit store unavailable")
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Recorder: types.RecorderFunc(func(ctx context.Context, record types.ExtractionRecord) error {
+			records = append(records, record)
+			return failing
+		})})
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Invoice INV-001"}), Schema: schema})
+		if err == nil || !errors.Is(err, failing) {
+			t.Errorf("Expected the extraction and recording errors, got %v", err)
+		}
+		if len(records) != 1 || records[0].Error == "" || len(records[0].Calls) != 1 {
+			t.Errorf("Expected the failure recorded with its call, got %+v", records)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"invoiceNumber": "INV-001"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		if result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Invoice INV-001"}), Schema: schema}); err != nil || result.Record != nil {
+			t.Errorf("Expected no record unless asked, got %+v, %v", result, err)
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// transcriptBackend reads the text of every page from a fixed transcript
+type transcriptBackend struct {
+	pages []string
+}
+
+func (b transcriptBackend) Name() string { return "transcript" }
+
+func (b transcriptBackend) ExtractText([]byte) ([]string, error) { return b.pages, nil }
+
+// blankBackend renders every page as a small white image
+type blankBackend struct{}
+
+func (blankBackend) Name() string { return "blank" }
+
+func (blankBackend) Render(_ []byte, pages []int, _ float64, page func(int, *image.RGBA) error) error {
+	for _, p := range pages {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+		img.Set(0, 0, color.White)
+		if err := page(p, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// crashingBackend panics when rendering, as native renderers do on some malformed PDFs
+type crashingBackend struct{}
+
+func (crashingBackend) Name() string { return "crashing" }
+
+func (crashingBackend) Render([]byte, []int, float64, func(int, *image.RGBA) error) error {
+	var page *image.RGBA
+	_ = page.Pix[0]
+	return nil
+}
+
+// slowBackend takes its time to render every page
+type slowBackend struct{ blankBackend }
+
+func (slowBackend) Name() string { return "slow" }
+
+func (b slowBackend) Render(buffer []byte, pages []int, dpi float64, page func(int, *image.RGBA) error) error {
+	for _, p := range pages {
+		time.Sleep(200 * time.Millisecond)
+		if err := b.blankBackend.Render(buffer, []int{p}, dpi, page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchyBackend fails to read and render the first page
+type patchyBackend struct{ blankBackend }
+
+func (patchyBackend) Name() string { return "patchy" }
+
+func (patchyBackend) ExtractText([]byte) ([]string, error) {
+	return []string{"", "Total 10.00 due on receipt of this invoice"}, parser.PageErrors{{Page: 1, Stage: types.PageStageText, Err: errors.New("broken content stream")}}
+}
+
+func (b patchyBackend) Render(buffer []byte, pages []int, dpi float64, page func(int, *image.RGBA) error) error {
+	for _, p := range pages {
+		if p == 1 {
+			return &parser.PageError{Page: p, Stage: types.PageStageRender, Err: errors.New("broken image")}
+		}
+		if err := b.blankBackend.Render(buffer, []int{p}, dpi, page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestBackends(t *testing.T) {
+	parser.RegisterBackend(transcriptBackend{pages: []string{"Invoice INV-7 from the transcript", "Total 10.00"}})
+	parser.RegisterBackend(blankBackend{})
+	parser.RegisterBackend(crashingBackend{})
+	parser.RegisterBackend(slowBackend{})
+	parser.RegisterBackend(patchyBackend{})
+
+	names := parser.Backends()
+	for _, name := range []string{types.RendererPdftoppm, types.RendererGhostscript, "transcript", "blank"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Expected backend %q to be registered, got %v", name, names)
+		}
+	}
+
+	backend, ok := parser.LookupBackend("transcript")
+	if !ok {
+		t.Fatal("Expected the transcript backend to be found")
+	}
+	if capabilities := parser.Capabilities(backend); !slices.Equal(capabilities, []parser.Capability{parser.CapabilityText}) {
+		t.Errorf("Expected the text capability, got %v", capabilities)
+	}
+	pdftoppm, _ := parser.LookupBackend(types.RendererPdftoppm)
+	if capabilities := parser.Capabilities(pdftoppm); !slices.Equal(capabilities, []parser.Capability{parser.CapabilityRender}) {
+		t.Errorf("Expected the render capability, got %v", capabilities)
+	}
+
+	pdf := newTestPdf([]string{"Printed text"}, []string{"Second page"})
+
+	t.Run("Text backend", func(t *testing.T) {
+		parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextBackend: "transcript", TextThreshold: 10})
+		if err != nil {
+			t.Fatalf("Expected parsed PDF, got error: %v", err)
+		}
+		if parsedPdf.Content.Type != "text" || !strings.Contains(parsedPdf.Content.TextContent, "from the transcript") {
+			t.Errorf("Expected the transcript text, got %q", parsedPdf.Content.TextContent)
+		}
+		if len(parsedPdf.Content.TextPages) != 2 || parsedPdf.Content.TextPages[1] != "Total 10.00" {
+			t.Errorf("Unexpected pages: %q", parsedPdf.Content.TextPages)
+		}
+	})
+
+	t.Run("Renderer", func(t *testing.T) {
+		parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "blank"})
+		if err != nil {
+			t.Fatalf("Expected parsed PDF, got error: %v", err)
+		}
+		if len(parsedPdf.Content.ImageContent) != 2 || parsedPdf.Content.ImageContent[1].Page != 2 {
+			t.Errorf("Expected 2 page images, got %+v", parsedPdf.Content.ImageContent)
+		}
+	})
+
+	t.Run("Missing capability", func(t *testing.T) {
+		_, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextBackend: "blank"})
+		if err == nil || !strings.Contains(err.Error(), `backend "blank" cannot extract text`) {
+			t.Errorf("Expected a capability error, got %v", err)
+		}
+		_, err = parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "transcript"})
+		if err == nil || !strings.Contains(err.Error(), `backend "transcript" cannot render pages`) {
+			t.Errorf("Expected a capability error, got %v", err)
+		}
+	})
+
+	t.Run("Isolated rendering", func(t *testing.T) {
+		_, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "crashing", IsolateRendering: true})
+		if !errors.Is(err, parser.ErrRenderFailed) {
+			t.Errorf("Expected the panic to fail the render, got %v", err)
+		}
+		parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "blank", IsolateRendering: true})
+		if err != nil || len(parsedPdf.Content.ImageContent) != 2 {
+			t.Errorf("Expected isolated renders to succeed, got %v", err)
+		}
+	})
+
+	t.Run("Render limits", func(t *testing.T) {
+		var limitErr *parser.RenderLimitError
+		_, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "blank", MaxRenderMemoryBytes: 1 << 20})
+		if !errors.Is(err, parser.ErrRenderLimitExceeded) || !errors.As(err, &limitErr) || limitErr.Limit != parser.LimitMemory || limitErr.Page != 1 {
+			t.Errorf("Expected the raster of the first page to exceed the memory limit, got %v", err)
+		}
+		_, err = parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "slow", MaxRenderDuration: 300 * time.Millisecond})
+		if !errors.As(err, &limitErr) || limitErr.Limit != parser.LimitDuration || limitErr.Page != 2 {
+			t.Errorf("Expected the second page to exceed the time limit, got %v", err)
+		}
+		parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "slow", MaxRenderMemoryBytes: 64 << 20, MaxRenderDuration: time.Second})
+		if err != nil || len(parsedPdf.Content.ImageContent) != 2 {
+			t.Errorf("Expected renders within the limits to succeed, got %v", err)
+		}
+	})
+
+	t.Run("Failed pages", func(t *testing.T) {
+		parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextBackend: "patchy", TextThreshold: 10})
+		if err != nil {
+			t.Fatalf("Expected the pages read, got error: %v", err)
+		}
+		expected := []types.PageFailure{{Page: 1, Stage: types.PageStageText, Reason: "broken content stream"}}
+		if parsedPdf.Content.Type != "text" || !slices.Equal(parsedPdf.FailedPages, expected) {
+			t.Errorf("Expected the first page reported as failed, got %+v", parsedPdf.FailedPages)
+		}
+
+		parsedPdf, err = parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "patchy"})
+		if err != nil {
+			t.Fatalf("Expected the pages rendered, got error: %v", err)
+		}
+		expected = []types.PageFailure{{Page: 1, Stage: types.PageStageRender, Reason: "broken image"}}
+		if len(parsedPdf.Content.ImageContent) != 1 || parsedPdf.Content.ImageContent[0].Page != 2 || !slices.Equal(parsedPdf.FailedPages, expected) {
+			t.Errorf("Expected the second page rendered and the first reported, got %+v", parsedPdf.FailedPages)
+		}
+
+		_, err = parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextBackend: "patchy", TextThreshold: 10, ParsePolicy: types.ParsePolicyStrict})
+		if err == nil || !strings.Contains(err.Error(), "failed to read page 1: broken content stream") {
+			t.Errorf("Expected strict parsing to fail on the page, got %v", err)
+		}
+		_, err = parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "patchy", ParsePolicy: types.ParsePolicyStrict})
+		if err == nil || !strings.Contains(err.Error(), "failed to render page 1: broken image") {
+			t.Errorf("Expected strict parsing to fail on the page, got %v", err)
+		}
+
+		mock := newMockServer(t, map[string]interface{}{"total": "10.00"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test-key", BaseURL: mock.URL, TextThreshold: 10, TextBackend: "patchy"})
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if len(result.FailedPages) != 1 || result.FailedPages[0].Page != 1 {
+			t.Errorf("Expected the failed page on the result, got %+v", result.FailedPages)
+		}
+	})
+
+	t.Run("Unknown backend", func(t *testing.T) {
+		_, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextBackend: "pdfium"})
+		if err == nil || !strings.Contains(err.Error(), `unknown text backend "pdfium"`) {
+			t.Errorf("Expected an unknown backend error, got %v", err)
+		}
+	})
+}
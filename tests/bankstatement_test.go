@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestExtractBankStatement(t *testing.T) {
+	pdf := newTestPdf([]string{
+		"ACME BANK - Statement for Jane Doe - DE89370400440532013000 - March 2024",
+		"Opening balance 100.00 | 03-01 Groceries -20.00 80.00 | 03-02 Coffee -10.00 70.00",
+		"03-03 Rent -30.00 40.00 | Closing balance 40.00",
+	})
+
+	statement := func(transactions ...map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"bankName": "Acme Bank", "accountHolder": "Jane Doe", "accountNumber": "DE89370400440532013000", "currency": "EUR",
+			"periodStart": "2024-03-01", "periodEnd": "2024-03-31", "openingBalance": 100.0, "closingBalance": 40.0,
+			"transactions": transactions,
+		}
+	}
+	row := func(date, description string, amount, balance float64) map[string]interface{} {
+		return map[string]interface{}{"date": date, "description": description, "amount": amount, "balance": balance, "page": 1}
+	}
+
+	t.Run("Reconciles running balances", func(t *testing.T) {
+		mock := newMockServer(t, statement(
+			row("2024-03-01", "Groceries", -20, 80),
+			row("2024-03-02", "Coffee", -10, 70),
+			row("2024-03-03", "Rent", -30, 40),
+		))
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+
+		result, err := ext.ExtractBankStatement(context.Background(), pdf, types.BankStatementOptions{})
+		if err != nil {
+			t.Fatalf("Expected statement, got error: %v", err)
+		}
+		if !result.Reconciled || len(result.Statement.Transactions) != 3 {
+			t.Errorf("Expected 3 reconciled transactions, got %d with warnings %v", len(result.Statement.Transactions), result.Warnings)
+		}
+		if result.Statement.OpeningBalance == nil || *result.Statement.OpeningBalance != 100 {
+			t.Errorf("Expected opening balance 100, got %v", result.Statement.OpeningBalance)
+		}
+	})
+
+	t.Run("Reports missed rows", func(t *testing.T) {
+		mock := newMockServer(t, statement(
+			row("2024-03-01", "Groceries", -20, 80),
+			row("2024-03-03", "Rent", -30, 40),
+		))
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+
+		result, err := ext.ExtractBankStatement(context.Background(), pdf, types.BankStatementOptions{})
+		if err != nil {
+			t.Fatalf("Expected statement, got error: %v", err)
+		}
+		if result.Reconciled || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "row 2") {
+			t.Errorf("Expected a single warning about row 2, got %v", result.Warnings)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// newQRCodePdf builds a two-page PDF with a QR code of the payload on its second page
+func newQRCodePdf(t *testing.T, payload string) []byte {
+	t.Helper()
+	code, err := qrcode.NewQRCodeWriter().Encode(payload, gozxing.BarcodeFormat_QR_CODE, 200, 200, nil)
+	if err != nil {
+		t.Fatalf("Failed to encode QR code: %v", err)
+	}
+	pdf := newTestPdf(
+		[]string{"Payment slip - please pay the amount below using the QR code on the next page"},
+		[]string{"Scan to pay with your banking app - reference and IBAN are in the code"},
+	)
+	return withImage(pdf, 2, code, 200, 300, 200, 200)
+}
+
+func TestDecodeBarcodes(t *testing.T) {
+	payload := "BCD\n002\n1\nSCT\nCOBADEFFXXX\nACME GmbH\nDE89370400440532013000\nEUR24.20\n\n\nINV-2024-001"
+	pdf := newQRCodePdf(t, payload)
+
+	t.Run("Parser", func(t *testing.T) {
+		barcodes, err := parser.DecodeBarcodesFromBuffer(pdf, nil)
+		if err != nil {
+			t.Fatalf("Expected barcodes, got error: %v", err)
+		}
+		if len(barcodes) != 1 {
+			t.Fatalf("Expected one barcode, got %+v", barcodes)
+		}
+		if barcodes[0].Page != 2 || barcodes[0].Format != "QR_CODE" || barcodes[0].Text != payload {
+			t.Errorf("Expected the QR code of page 2 with the payload, got %+v", barcodes[0])
+		}
+
+		parsed, err := parser.ParsePdfFromBuffer(newTestPdf([]string{"No code on this page"}), &types.ParseOptions{DecodeBarcodes: true})
+		if err != nil || len(parsed.Barcodes) != 0 {
+			t.Errorf("Expected no barcode on a text page, got %+v (%v)", parsed.Barcodes, err)
+		}
+	})
+
+	t.Run("Ground truth in the prompt", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"iban": "DE89370400440532013000"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"iban": map[string]interface{}{"type": "string"}}}
+
+		result, err := ext.ExtractWithContext(context.Background(), types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, DecodeBarcodes: true})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if len(result.Barcodes) != 1 || result.Barcodes[0].Text != payload {
+			t.Errorf("Expected the decoded QR code in the result, got %+v", result.Barcodes)
+		}
+		prompt := mock.userPrompt(0)
+		if !strings.Contains(prompt, "Their contents are exact") || !strings.Contains(prompt, "- Page 2, QR_CODE: "+payload) {
+			t.Errorf("Expected the QR code payload as ground truth in the prompt, got %q", prompt)
+		}
+
+		// Without the option the pages are not decoded
+		if _, err := ext.ExtractWithContext(context.Background(), types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if strings.Contains(mock.userPrompt(1), "QR_CODE") {
+			t.Errorf("Expected no barcode in the prompt without DecodeBarcodes, got %q", mock.userPrompt(1))
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/journal"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestExtractBatchJSONL(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"title": "Quarterly report"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{"title": map[string]interface{}{"type": "string"}},
+		"required":             []string{"title"},
+		"additionalProperties": false,
+	}
+	documents := []types.BatchDocument{
+		{ID: "report", Options: types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Quarterly report for the first quarter"}), Schema: schema}},
+		{ID: "broken", Options: types.ExtractionOptions{PDFBuffer: []byte("not a pdf"), Schema: schema}},
+	}
+
+	var buf bytes.Buffer
+	writer := types.NewJSONLWriter(&buf)
+	for result := range ext.ExtractBatch(context.Background(), documents, types.BatchOptions{Concurrency: 2}) {
+		if err := writer.Write(result); err != nil {
+			t.Fatalf("Expected record to be written, got error: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per document, got %d", len(lines))
+	}
+	records := make(map[string]types.JSONLRecord)
+	for _, line := range lines {
+		var record types.JSONLRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected valid JSON line, got error: %v", err)
+		}
+		records[record.DocumentID] = record
+	}
+
+	report := records["report"]
+	if report.Status != types.BatchStatusSuccess || report.Data["title"] != "Quarterly report" || report.TokensUsed != 42 || report.SchemaVersion != types.JSONLSchemaVersion {
+		t.Errorf("Unexpected success record: %+v", report)
+	}
+	broken := records["broken"]
+	if broken.Status != types.BatchStatusError || broken.Error == "" || broken.Data != nil {
+		t.Errorf("Unexpected error record: %+v", broken)
+	}
+}
+
+func TestExtractBatchJournal(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"title": "Annual report"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}}}
+	documents := []types.BatchDocument{
+		{ID: "quarterly", Options: types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Quarterly report for the first quarter"}), Schema: schema}},
+		{ID: "annual", Options: types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Annual report of the year"}), Schema: schema}},
+		{ID: "failed", Options: types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Monthly report of March"}), Schema: schema}},
+	}
+
+	// A run that crashed after extracting the first document, while writing the record of the second
+	path := filepath.Join(t.TempDir(), "batch.jsonl")
+	previous := `{"schemaVersion":1,"documentId":"quarterly","status":"success","data":{"title":"Quarterly report"},"model":"gpt-4o-mini","tokensUsed":42,"durationMs":1200}` + "\n" +
+		`{"schemaVersion":1,"documentId":"failed","status":"error","error":"timeout","tokensUsed":0,"durationMs":0}` + "\n" +
+		`{"schemaVersion":1,"documentId":"annual","sta`
+	if err := os.WriteFile(path, []byte(previous), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := journal.NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected the journal to open, got error: %v", err)
+	}
+
+	results := make(map[string]types.BatchResult)
+	for result := range ext.ExtractBatch(context.Background(), documents, types.BatchOptions{Journal: file}) {
+		results[result.ID] = result
+	}
+	file.Close()
+
+	if len(mock.Requests) != 2 {
+		t.Errorf("Expected only the unfinished documents to be extracted, got %d requests", len(mock.Requests))
+	}
+	quarterly := results["quarterly"]
+	if !quarterly.Resumed || quarterly.Status != types.BatchStatusSuccess || quarterly.Result.Data["title"] != "Quarterly report" || quarterly.Result.TokensUsed != 42 {
+		t.Errorf("Expected the result of the journal, got %+v", quarterly)
+	}
+	for _, id := range []string{"annual", "failed"} {
+		if result := results[id]; result.Resumed || result.Status != types.BatchStatusSuccess || result.Result.Data["title"] != "Annual report" {
+			t.Errorf("Expected %s to be extracted, got %+v", id, result)
+		}
+	}
+
+	// The incomplete line is dropped, and the extracted documents are appended
+	file, err = journal.NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected the journal to open, got error: %v", err)
+	}
+	defer file.Close()
+	records, _ := file.Records(context.Background())
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 records, got %+v", records)
+	}
+	for _, record := range records[2:] {
+		if record.Status != types.BatchStatusSuccess || record.Data["title"] != "Annual report" {
+			t.Errorf("Expected the record of an extracted document, got %+v", record)
+		}
+	}
+
+	t.Run("Resume", func(t *testing.T) {
+		for result := range ext.ExtractBatch(context.Background(), documents, types.BatchOptions{Journal: file}) {
+			if !result.Resumed {
+				t.Errorf("Expected every document to be resumed, got %+v", result)
+			}
+		}
+		if len(mock.Requests) != 2 {
+			t.Errorf("Expected no new request, got %d requests", len(mock.Requests))
+		}
+	})
+}
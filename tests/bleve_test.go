@@ -0,0 +1,42 @@
+//go:build bleve
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/bleveindex"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestBleveIndexer(t *testing.T) {
+	index, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	indexer := bleveindex.New(index)
+	defer indexer.Close()
+
+	ctx := context.Background()
+	documents := []types.IndexDocument{
+		{ID: "a.pdf", Text: "Invoice for consulting services", Data: map[string]interface{}{"vendor": map[string]interface{}{"name": "Acme"}}},
+		{ID: "b.pdf", Text: "Delivery note", Data: map[string]interface{}{"vendor": map[string]interface{}{"name": "Globex"}}},
+	}
+	for _, document := range documents {
+		if err := indexer.Index(ctx, document); err != nil {
+			t.Fatalf("Failed to index %s: %v", document.ID, err)
+		}
+	}
+
+	for query, want := range map[string]string{"consulting": "a.pdf", "data.vendor.name:globex": "b.pdf"} {
+		hits, err := indexer.Search(ctx, query, 10)
+		if err != nil {
+			t.Fatalf("Search %q failed: %v", query, err)
+		}
+		if len(hits) != 1 || hits[0].ID != want {
+			t.Errorf("Search %q: expected %s, got %+v", query, want, hits)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestStripBoilerplate(t *testing.T) {
+	sections := []string{"Introduction", "Market overview", "Financial results", "Outlook"}
+	bodies := []string{"We grew in every region.", "Demand stayed strong.", "Revenue rose by 12%.", "Margins should improve.", "We expect further growth."}
+	pages := make([][]string, 4)
+	for i := range pages {
+		pages[i] = append([]string{"ACME Corp - Annual Report 2024", sections[i]}, bodies[i:i+2]...)
+		pages[i] = append(pages[i], "Confidential", fmt.Sprintf("Page %d of 4", i+1))
+	}
+
+	parsed, err := parser.ParsePdfFromBuffer(newTestPdf(pages...), &types.ParseOptions{StripBoilerplate: true})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	text := parsed.Content.TextContent
+	for _, boilerplate := range []string{"Annual Report", "Confidential", "of 4"} {
+		if strings.Contains(text, boilerplate) {
+			t.Errorf("Expected %q to be stripped, got:\n%s", boilerplate, text)
+		}
+	}
+	for _, content := range []string{"Introduction", "We grew in every region.", "Revenue rose by 12%.", "We expect further growth."} {
+		if !strings.Contains(text, content) {
+			t.Errorf("Expected %q to be kept, got:\n%s", content, text)
+		}
+	}
+	if len(parsed.Content.TextPages) != 4 || strings.Contains(parsed.Content.TextPages[0], "Confidential") {
+		t.Errorf("Expected the pages to be stripped too, got %q", parsed.Content.TextPages)
+	}
+
+	t.Run("lines repeated on too few pages are kept", func(t *testing.T) {
+		stripped := parser.StripBoilerplate([]string{"Invoice\nTotal 10", "Invoice\nTotal 20", "Delivery note\n3", "Terms\nPage iv"})
+		if stripped[0] != "Invoice\nTotal 10" || stripped[2] != "Delivery note" || stripped[3] != "Terms" {
+			t.Errorf("Unexpected stripped pages: %q", stripped)
+		}
+	})
+}
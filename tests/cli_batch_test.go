@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/internal/cli"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// cliTestDir writes a schema and PDF files to a temporary directory, which it returns. The files
+// named *.pdf hold a text document and the others are not PDF files.
+func cliTestDir(t *testing.T, files ...string) string {
+	t.Helper()
+	t.Setenv("OPENAI_API_KEY", "test")
+
+	dir := t.TempDir()
+	schema := `{"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}`
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pdf := newTestPdf([]string{
+		"Certificate of completion issued to Jane Doe for the advanced course",
+		"on distributed systems, held in Berlin from March to June of this year",
+	})
+	for _, file := range files {
+		data := pdf
+		if strings.HasSuffix(file, ".broken.pdf") {
+			data = []byte("not a PDF file")
+		}
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// readRecords reads the JSON Lines records of a batch
+func readRecords(t *testing.T, data []byte) []types.JSONLRecord {
+	t.Helper()
+	records := make([]types.JSONLRecord, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var record types.JSONLRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Expected a JSON record, got %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestCLIBatchExitCodes(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"})
+
+	tests := []struct {
+		name     string
+		files    []string
+		patterns []string
+		want     int
+		records  int
+	}{
+		{"all succeeded", []string{"a.pdf", "b.pdf"}, []string{"*.pdf"}, 0, 2},
+		{"some failed", []string{"a.pdf", "c.broken.pdf"}, []string{"*.pdf"}, 3, 2},
+		{"all failed", []string{"c.broken.pdf", "d.broken.pdf"}, []string{"*.pdf"}, 1, 2},
+		{"no match", []string{"a.pdf"}, []string{"*.txt"}, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := cliTestDir(t, tt.files...)
+			args := []string{"batch", "-base-url", mock.URL, "-schema", filepath.Join(dir, "schema.json"), "-concurrency", "1", "-quiet"}
+			for _, pattern := range tt.patterns {
+				args = append(args, filepath.Join(dir, pattern))
+			}
+
+			var stdout, stderr bytes.Buffer
+			if code := cli.Run(args, nil, &stdout, &stderr); code != tt.want {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.want, code, stderr.String())
+			}
+			if records := readRecords(t, stdout.Bytes()); len(records) != tt.records {
+				t.Errorf("Expected %d records, got %d", tt.records, len(records))
+			}
+		})
+	}
+}
+
+func TestCLIBatchUsage(t *testing.T) {
+	dir := cliTestDir(t, "a.pdf")
+	schema := filepath.Join(dir, "schema.json")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no pattern", []string{"batch", "-schema", schema}},
+		{"no schema", []string{"batch", filepath.Join(dir, "*.pdf")}},
+		{"resume without output file", []string{"batch", "-schema", schema, "-resume", filepath.Join(dir, "*.pdf")}},
+		{"invalid concurrency", []string{"batch", "-schema", schema, "-concurrency", "0", filepath.Join(dir, "*.pdf")}},
+		{"unknown flag", []string{"batch", "-unknown", filepath.Join(dir, "*.pdf")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := cli.Run(tt.args, nil, &stdout, &stderr); code != 2 {
+				t.Errorf("Expected exit code 2, got %d: %s", code, stderr.String())
+			}
+		})
+	}
+}
+
+func TestCLIBatchMatching(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"})
+	dir := cliTestDir(t, "a.pdf", "b.PDF", "notes.txt", "reports/c.pdf", "reports/d.pdf")
+
+	// A directory stands for its PDF files, and a file matched twice is extracted once
+	var stdout, stderr bytes.Buffer
+	args := []string{"batch", "-base-url", mock.URL, "-schema", filepath.Join(dir, "schema.json"), "-concurrency", "1", "-quiet",
+		filepath.Join(dir, "reports"), filepath.Join(dir, "reports", "c.pdf"), filepath.Join(dir, "*.[pP][dD][fF]")}
+	if code := cli.Run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	ids := make([]string, 0)
+	for _, record := range readRecords(t, stdout.Bytes()) {
+		rel, _ := filepath.Rel(dir, record.DocumentID)
+		ids = append(ids, filepath.ToSlash(rel))
+	}
+	sort.Strings(ids)
+	if got := strings.Join(ids, ","); got != "a.pdf,b.PDF,reports/c.pdf,reports/d.pdf" {
+		t.Errorf("Expected the PDF files of the patterns once each, got %s", got)
+	}
+}
+
+func TestCLIBatchResume(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"})
+	dir := cliTestDir(t, "a.pdf", "b.pdf")
+	out := filepath.Join(dir, "out.jsonl")
+	args := []string{"batch", "-base-url", mock.URL, "-schema", filepath.Join(dir, "schema.json"), "-out", out, "-resume", "-concurrency", "1", "-quiet", filepath.Join(dir, "*.pdf")}
+
+	var stdout, stderr bytes.Buffer
+	if code := cli.Run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if len(mock.Requests) != 2 {
+		t.Fatalf("Expected 2 extractions, got %d", len(mock.Requests))
+	}
+
+	// A new document is extracted, and the journaled ones are not extracted again
+	if err := os.WriteFile(filepath.Join(dir, "c.pdf"), newTestPdf([]string{
+		"Certificate of completion issued to John Roe for the advanced course",
+		"on relational databases, held in Madrid from April to July of this year",
+	}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if code := cli.Run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if len(mock.Requests) != 3 {
+		t.Errorf("Expected only the new document extracted, got %d extractions", len(mock.Requests))
+	}
+	if !strings.Contains(stderr.String(), "(2 resumed)") {
+		t.Errorf("Expected 2 resumed documents, got %q", stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no output with -resume, got %q", stdout.String())
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records := readRecords(t, data); len(records) != 3 {
+		t.Errorf("Expected 3 records in the output file, got %d", len(records))
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestCLIBatchWriteError(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"})
+	dir := cliTestDir(t, "a.pdf", "b.pdf", "c.pdf", "d.pdf", "e.pdf", "f.pdf")
+
+	// The batch stops at the first failed write, and returns without leaving workers blocked
+	var stderr bytes.Buffer
+	args := []string{"batch", "-base-url", mock.URL, "-schema", filepath.Join(dir, "schema.json"), "-concurrency", "1", "-quiet", filepath.Join(dir, "*.pdf")}
+	if code := cli.Run(args, nil, failingWriter{}, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "disk full") {
+		t.Errorf("Expected the write error reported, got %q", stderr.String())
+	}
+	if len(mock.Requests) >= 6 {
+		t.Errorf("Expected the remaining documents not extracted, got %d extractions", len(mock.Requests))
+	}
+}
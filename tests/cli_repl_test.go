@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/internal/cli"
+)
+
+func TestCLIRepl(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// want holds the texts expected in the output, in order
+		want []string
+		// requests is the number of extractions sent to the model
+		requests int
+		// model is the model of the last extraction, when set
+		model string
+	}{
+		{"help", "help\n", []string{"> Commands:", "schema <file>", "quit"}, 0, ""},
+		{"show", "show\n", []string{"a.pdf: 1 pages, read as text", "schema      (none)", "> {dir}/a.pdf: 1 pages"}, 0, ""},
+		{"empty line", "\n   \nshow\n", []string{"> > > {dir}/a.pdf"}, 0, ""},
+		{"unknown command", "frobnicate now\n", []string{`error: unknown command "frobnicate"`}, 0, ""},
+		{"run without schema", "run\n", []string{`error: no schema, set one with "schema <file>"`}, 0, ""},
+		{"schema without file", "schema\n", []string{"error: schema takes a file"}, 0, ""},
+		{"missing schema file", "schema missing.json\nshow\n", []string{"error: failed to read schema", "schema      (none)"}, 0, ""},
+		{"run", "schema {dir}/schema.json\nrun\n", []string{`"name": "Jane Doe"`, "Attempt 1: ", "42 tokens (42 in the session)"}, 1, ""},
+		{"arguments trimmed", "  schema   {dir}/schema.json  \nprompt   Use the full name  \nshow\n", []string{"schema      {dir}/schema.json\n", "prompt      Use the full name\n"}, 0, ""},
+		{"model", "schema {dir}/schema.json\nmodel gpt-4o\nrun\nmodel\nshow\n", []string{"Attempt 1: gpt-4o", "model       (default)"}, 1, "gpt-4o"},
+		{"temperature", "temperature 0.5\nshow\ntemperature\nshow\n", []string{"temperature 0.5", "model       (default)"}, 0, ""},
+		{"invalid temperature", "temperature 3\ntemperature hot\n", []string{`error: invalid temperature "3"`, `error: invalid temperature "hot"`}, 0, ""},
+		{"history", "history\nschema {dir}/schema.json\nprompt Use the full name\nrun\nrun\nhistory\n", []string{"No attempt yet", "1. ", `42 tokens, prompt "Use the full name"`, "2. ", "84 tokens in the session"}, 2, ""},
+		{"quit", "quit\nhelp\n", []string{"> "}, 0, ""},
+		{"exit", "exit\nhelp\n", []string{"> "}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"})
+			dir := cliTestDir(t, "a.pdf")
+
+			var stdout, stderr bytes.Buffer
+			stdin := strings.NewReader(strings.ReplaceAll(tt.input, "{dir}", dir))
+			args := []string{"repl", "-base-url", mock.URL, filepath.Join(dir, "a.pdf")}
+			if code := cli.Run(args, stdin, &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+			}
+
+			output := stdout.String()
+			rest := output
+			for _, want := range tt.want {
+				want = strings.ReplaceAll(want, "{dir}", dir)
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("Expected %q in the output, got:\n%s", want, output)
+				}
+				rest = rest[i+len(want):]
+			}
+			if strings.HasPrefix(tt.input, "quit") || strings.HasPrefix(tt.input, "exit") {
+				if strings.Contains(output, "Commands:") {
+					t.Errorf("Expected no command run after %s, got:\n%s", tt.input[:4], output)
+				}
+			}
+			if len(mock.Requests) != tt.requests {
+				t.Errorf("Expected %d extractions, got %d", tt.requests, len(mock.Requests))
+			}
+			if tt.model != "" && mock.Requests[len(mock.Requests)-1]["model"] != tt.model {
+				t.Errorf("Expected the extraction sent to %s, got %v", tt.model, mock.Requests[len(mock.Requests)-1]["model"])
+			}
+		})
+	}
+}
+
+func TestCLIReplSchemaReread(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"}, map[string]interface{}{"name": "Jane Doe", "city": "Berlin"})
+	dir := cliTestDir(t, "a.pdf")
+	schemaPath := filepath.Join(dir, "schema.json")
+
+	// The schema file is read again on every run, so that it can be edited between runs. A write to
+	// the pipe returns once the session reads it, after the previous command is done.
+	stdin, input := io.Pipe()
+	var stdout, stderr bytes.Buffer
+	done := make(chan int)
+	go func() {
+		done <- cli.Run([]string{"repl", "-base-url", mock.URL, "-schema", schemaPath, filepath.Join(dir, "a.pdf")}, stdin, &stdout, &stderr)
+	}()
+	io.WriteString(input, "run\n")
+	io.WriteString(input, "history\n")
+	schema := `{"type": "object", "properties": {"name": {"type": "string"}, "city": {"type": "string"}}, "required": ["name", "city"]}`
+	if err := os.WriteFile(schemaPath, []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(input, "run\n")
+	input.Close()
+	if code := <-done; code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	if len(mock.Requests) != 2 {
+		t.Fatalf("Expected 2 extractions, got %d", len(mock.Requests))
+	}
+	first, _ := json.Marshal(mock.Requests[0])
+	second, _ := json.Marshal(mock.Requests[1])
+	if strings.Contains(string(first), "city") || !strings.Contains(string(second), "city") {
+		t.Errorf("Expected the second run with the edited schema, got %s", second)
+	}
+}
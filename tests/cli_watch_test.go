@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/internal/cli"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// folderFiles returns the names of the files of a folder, sorted
+func folderFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCLIWatchOnce(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"})
+
+	tests := []struct {
+		name   string
+		files  []string
+		want   int
+		done   []string
+		failed []string
+	}{
+		{"all succeeded", []string{"inbox/a.pdf", "inbox/b.pdf"}, 0, []string{"a.json", "a.pdf", "b.json", "b.pdf"}, []string{}},
+		{"some failed", []string{"inbox/a.pdf", "inbox/c.broken.pdf"}, 3, []string{"a.json", "a.pdf"}, []string{"c.broken.json", "c.broken.pdf"}},
+		{"all failed", []string{"inbox/c.broken.pdf", "inbox/d.broken.pdf"}, 1, []string{}, []string{"c.broken.json", "c.broken.pdf", "d.broken.json", "d.broken.pdf"}},
+		{"no file", []string{"inbox/notes.txt"}, 0, []string{}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := cliTestDir(t, tt.files...)
+			out := filepath.Join(dir, "out")
+			args := []string{"watch", "-base-url", mock.URL, "-schema", filepath.Join(dir, "schema.json"), "-out", out, "-concurrency", "1", "-once", filepath.Join(dir, "inbox")}
+
+			var stdout, stderr bytes.Buffer
+			if code := cli.Run(args, nil, &stdout, &stderr); code != tt.want {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.want, code, stderr.String())
+			}
+			if got := folderFiles(t, filepath.Join(out, "done")); strings.Join(got, ",") != strings.Join(tt.done, ",") {
+				t.Errorf("Expected %v in the done folder, got %v", tt.done, got)
+			}
+			if got := folderFiles(t, filepath.Join(out, "failed")); strings.Join(got, ",") != strings.Join(tt.failed, ",") {
+				t.Errorf("Expected %v in the failed folder, got %v", tt.failed, got)
+			}
+			for _, name := range tt.failed {
+				if !strings.HasSuffix(name, ".json") {
+					continue
+				}
+				data, err := os.ReadFile(filepath.Join(out, "failed", name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var record types.JSONLRecord
+				if err := json.Unmarshal(data, &record); err != nil || record.Status != types.BatchStatusError || record.Error == "" {
+					t.Errorf("Expected an error record in %s, got %s", name, data)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIWatchUsage(t *testing.T) {
+	dir := cliTestDir(t, "inbox/a.pdf")
+	schema := filepath.Join(dir, "schema.json")
+	inbox := filepath.Join(dir, "inbox")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no directory", []string{"watch", "-schema", schema, "-out", dir}},
+		{"no schema", []string{"watch", "-out", dir, inbox}},
+		{"no output directory", []string{"watch", "-schema", schema, inbox}},
+		{"missing directory", []string{"watch", "-schema", schema, "-out", dir, filepath.Join(dir, "missing")}},
+		{"invalid interval", []string{"watch", "-schema", schema, "-out", dir, "-interval", "0s", inbox}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := cli.Run(tt.args, nil, &stdout, &stderr); code != 2 {
+				t.Errorf("Expected exit code 2, got %d: %s", code, stderr.String())
+			}
+		})
+	}
+}
+
+// syncBuffer is a buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor polls a condition until it holds, failing the test after a few seconds
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCLIWatch(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"})
+	dir := cliTestDir(t, "inbox/a.pdf", "b.pdf")
+	inbox := filepath.Join(dir, "inbox")
+	out := filepath.Join(dir, "out")
+
+	var stderr syncBuffer
+	done := make(chan int)
+	go func() {
+		args := []string{"watch", "-base-url", mock.URL, "-schema", filepath.Join(dir, "schema.json"), "-out", out, "-interval", "200ms", "-concurrency", "1", inbox}
+		done <- cli.Run(args, nil, &bytes.Buffer{}, &stderr)
+	}()
+
+	// The files already in the directory are extracted, then the files arriving in it
+	waitFor(t, "a.pdf in the done folder", func() bool { return fileExistsAt(filepath.Join(out, "done", "a.pdf")) })
+	if err := os.Rename(filepath.Join(dir, "b.pdf"), filepath.Join(inbox, "b.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "b.pdf in the done folder", func() bool { return fileExistsAt(filepath.Join(out, "done", "b.pdf")) })
+
+	// A file being written is extracted once it stops changing
+	partial, err := os.Create(filepath.Join(inbox, "c.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf, _ := os.ReadFile(filepath.Join(out, "done", "a.pdf"))
+	partial.Write(pdf[:len(pdf)/2])
+	time.Sleep(20 * time.Millisecond)
+	partial.Write(pdf[len(pdf)/2:])
+	partial.Close()
+	waitFor(t, "c.pdf in the done folder", func() bool { return fileExistsAt(filepath.Join(out, "done", "c.pdf")) })
+	if failed := folderFiles(t, filepath.Join(out, "failed")); len(failed) != 0 {
+		t.Errorf("Expected no failed file, got %v: %s", failed, stderr.String())
+	}
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Errorf("Expected exit code 0, got %d: %s", code, stderr.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected watch to stop on interrupt")
+	}
+	if !strings.Contains(stderr.String(), "Watching ") || strings.Contains(stderr.String(), "warning:") {
+		t.Errorf("Expected the directory watched with notifications, got %s", stderr.String())
+	}
+}
+
+// fileExistsAt reports whether a file exists
+func fileExistsAt(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	oldSchema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"number":   map[string]interface{}{"type": "string"},
+			"total":    map[string]interface{}{"type": "number"},
+			"currency": map[string]interface{}{"type": "string", "enum": []interface{}{"EUR", "USD"}},
+			"date":     map[string]interface{}{"type": "string", "format": "date"},
+			"notes":    map[string]interface{}{"type": "string"},
+			"lines": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"$ref": "#/$defs/line"},
+			},
+		},
+		"required": []interface{}{"number", "total", "date"},
+		"$defs": map[string]interface{}{
+			"line": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"amount": map[string]interface{}{"type": "number"}},
+				"required":   []interface{}{"amount"},
+			},
+		},
+	}
+
+	t.Run("Breaking", func(t *testing.T) {
+		newSchema := map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"total":    map[string]interface{}{"type": "string"},
+				"currency": map[string]interface{}{"type": "string", "enum": []interface{}{"EUR", "GBP"}},
+				"date":     map[string]interface{}{"type": "string"},
+				"notes":    map[string]interface{}{"type": "string"},
+				"lines": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{"amount": map[string]interface{}{"type": "number"}},
+					},
+				},
+			},
+			"required": []interface{}{"total", "date"},
+		}
+		changes, err := schema.CheckCompatibility(oldSchema, newSchema)
+		if err != nil {
+			t.Fatalf("Expected changes, got %v", err)
+		}
+		expected := []string{
+			`/properties/currency: enum values added: "GBP" (breaking)`,
+			`/properties/currency: enum values removed: "USD"`,
+			"/properties/date: format date removed (breaking)",
+			"/properties/lines/items/properties/amount: field no longer required (breaking)",
+			"/properties/number: required field removed (breaking)",
+			"/properties/total: type changed from number to string (breaking)",
+		}
+		if len(changes) != len(expected) {
+			t.Fatalf("Expected %d changes, got %v", len(expected), changes)
+		}
+		for i, change := range changes {
+			if change.String() != expected[i] {
+				t.Errorf("Expected %q, got %q", expected[i], change.String())
+			}
+		}
+		if breaking := schema.BreakingChanges(changes); len(breaking) != 5 {
+			t.Errorf("Expected 5 breaking changes, got %v", breaking)
+		}
+	})
+
+	t.Run("Compatible", func(t *testing.T) {
+		newSchema := map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"number":   map[string]interface{}{"type": "string"},
+				"total":    map[string]interface{}{"type": "integer"},
+				"currency": map[string]interface{}{"type": "string", "enum": []interface{}{"EUR"}},
+				"date":     map[string]interface{}{"type": "string", "format": "date"},
+				"notes":    map[string]interface{}{"type": "string"},
+				"vendor":   map[string]interface{}{"type": "string"},
+				"lines": map[string]interface{}{
+					"type":  "array",
+					"items": oldSchema["$defs"].(map[string]interface{})["line"],
+				},
+			},
+			"required": []interface{}{"number", "total", "date", "notes"},
+		}
+		changes, err := schema.CheckCompatibility(oldSchema, newSchema)
+		if err != nil {
+			t.Fatalf("Expected changes, got %v", err)
+		}
+		if len(changes) != 4 {
+			t.Errorf("Expected 4 changes, got %v", changes)
+		}
+		if breaking := schema.BreakingChanges(changes); len(breaking) != 0 {
+			t.Errorf("Expected no breaking change, got %v", breaking)
+		}
+	})
+
+	t.Run("Nullable", func(t *testing.T) {
+		nullable := map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"total": map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "number"}, map[string]interface{}{"type": "null"}}},
+			},
+		}
+		plain := map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"total": map[string]interface{}{"type": "number"}},
+		}
+		changes, _ := schema.CheckCompatibility(plain, nullable)
+		if len(changes) != 1 || !changes[0].Breaking || changes[0].Description != "type changed from number to null or number" {
+			t.Errorf("Expected a breaking type change, got %v", changes)
+		}
+		if changes, _ := schema.CheckCompatibility(nullable, plain); len(schema.BreakingChanges(changes)) != 0 {
+			t.Errorf("Expected no breaking change, got %v", changes)
+		}
+	})
+
+	if _, err := schema.CheckCompatibility(map[string]interface{}{"$ref": "common.json"}, oldSchema); err == nil {
+		t.Error("Expected an error for an external reference")
+	}
+}
+
+func TestCheckCompatibilityGoSchemas(t *testing.T) {
+	oldSchema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"kind":    map[string]interface{}{"type": "string", "enum": []string{"invoice", "receipt"}},
+			"balance": map[string]interface{}{"type": []string{"number", "null"}},
+		},
+	}
+	newSchema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"kind":    map[string]interface{}{"type": "string", "enum": []string{"invoice", "receipt", "quote"}},
+			"balance": map[string]interface{}{"type": []string{"null", "number"}},
+		},
+	}
+	changes, err := schema.CheckCompatibility(oldSchema, newSchema)
+	if err != nil {
+		t.Fatalf("Expected changes, got %v", err)
+	}
+	if len(changes) != 1 || changes[0].String() != `/properties/kind: enum values added: "quote" (breaking)` {
+		t.Errorf("Expected the enum value added, got %v", changes)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestConstraintCorrection(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+		"currency": map[string]interface{}{"type": "string", "enum": []string{"EUR", "USD"}},
+		"status":   map[string]interface{}{"type": "string", "enum": []string{"paid", "pending", "overdue"}},
+		"vat_id":   map[string]interface{}{"type": "string", "pattern": "^[A-Z]{2}[0-9]{8}$"},
+	}}
+	pdf := newTestPdf([]string{"Invoice paid in euros", "VAT ES12345678"})
+
+	t.Run("Local correction", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"currency": "Euro", "status": "Paid", "vat_id": "es 1234 5678"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if result.Data["currency"] != "EUR" || result.Data["status"] != "paid" || result.Data["vat_id"] != "ES12345678" {
+			t.Errorf("Expected the values corrected locally, got %v", result.Data)
+		}
+		if len(mock.Requests) != 1 {
+			t.Errorf("Expected no re-prompt, got %d requests", len(mock.Requests))
+		}
+	})
+
+	t.Run("Re-prompt", func(t *testing.T) {
+		mock := newMockServer(t,
+			map[string]interface{}{"currency": "EUR", "status": "settled", "vat_id": "ES12345678"},
+			map[string]interface{}{"currency": "EUR", "status": "paid", "vat_id": "ES12345678"},
+		)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if result.Data["status"] != "paid" || result.TokensUsed != 84 {
+			t.Errorf("Expected the re-prompted answer with the tokens of both calls, got %v and %d tokens", result.Data, result.TokensUsed)
+		}
+		if len(mock.Requests) != 2 {
+			t.Fatalf("Expected a re-prompt, got %d requests", len(mock.Requests))
+		}
+		messages := mock.Requests[1]["messages"].([]interface{})
+		last := messages[len(messages)-1].(map[string]interface{})["content"].(string)
+		if !strings.Contains(last, `status = "settled"`) || messages[len(messages)-2].(map[string]interface{})["role"] != "assistant" {
+			t.Errorf("Expected the violation in the re-prompt, got %v", messages)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"currency": "EUR", "status": "settled", "vat_id": "unknown"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		var constraintErr *extractor.ConstraintError
+		if !errors.Is(err, extractor.ErrConstraintViolation) || !errors.As(err, &constraintErr) || len(constraintErr.Violations) != 2 {
+			t.Fatalf("Expected a constraint error with 2 violations, got %v", err)
+		}
+	})
+}
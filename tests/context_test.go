@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestContextLength(t *testing.T) {
+	pages := make([][]string, 30)
+	for i := range pages {
+		for j := 0; j < 20; j++ {
+			pages[i] = append(pages[i], fmt.Sprintf("Page %d line %d of the general terms and conditions", i+1, j+1))
+		}
+	}
+	pdf := newTestPdf(pages...)
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}}}
+	maxTokens := 500
+
+	mock := newMockServer(t, map[string]interface{}{"title": "Terms"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, ContextWindow: 3000})
+
+	_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, MaxTokens: &maxTokens})
+	var contextErr *extractor.ContextLengthError
+	if !errors.Is(err, extractor.ErrContextLengthExceeded) || !errors.As(err, &contextErr) {
+		t.Fatalf("Expected ErrContextLengthExceeded, got %v", err)
+	}
+	if contextErr.Limit != 3000 || contextErr.Tokens <= 3000 {
+		t.Errorf("Unexpected measured size: %+v", contextErr)
+	}
+	if len(mock.Requests) != 0 {
+		t.Errorf("Expected no API call, got %d", len(mock.Requests))
+	}
+
+	result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, MaxTokens: &maxTokens, TruncateToFit: true})
+	if err != nil {
+		t.Fatalf("Expected truncated extraction to succeed, got %v", err)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "truncated") {
+		t.Errorf("Expected a truncation warning, got %q", result.Warnings)
+	}
+	prompt := mock.userPrompt(0)
+	if !strings.Contains(prompt, "Page 1 line 1 ") || !strings.Contains(prompt, "Page 30 line 20 ") || strings.Contains(prompt, "Page 15 line 10 ") {
+		t.Errorf("Expected the beginning and end of the document to be kept")
+	}
+	if !strings.Contains(prompt, "characters omitted") || len(prompt)/4 > 3000-maxTokens {
+		t.Errorf("Expected the prompt to fit, got %d characters", len(prompt))
+	}
+
+	t.Run("unknown models are not checked", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, Model: "local-model"})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+			t.Errorf("Expected extraction to succeed, got %v", err)
+		}
+	})
+}
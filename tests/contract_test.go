@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestAnalyzeContract(t *testing.T) {
+	pdf := newTestPdf(
+		[]string{"SERVICES AGREEMENT between Acme Corp (Provider) and Globex Inc (Customer)", "Effective as of January 1, 2024."},
+		[]string{"5. Confidentiality. Each party shall keep the other party's information confidential."},
+		[]string{"9. Term. This agreement renews automatically for one-year terms unless terminated."},
+	)
+
+	term := func(value string, pages ...int) map[string]interface{} {
+		return map[string]interface{}{"value": value, "pages": pages}
+	}
+	answer := map[string]interface{}{
+		"parties": []map[string]interface{}{
+			{"name": "Acme Corp", "role": "provider", "pages": []int{1}},
+			{"name": "Globex Inc", "role": "customer", "pages": []int{1}},
+		},
+		"effectiveDate":   term("2024-01-01", 1),
+		"terminationDate": term(""),
+		"renewalTerms":    term("Renews automatically for one-year terms", 3),
+		"clauses": []map[string]interface{}{
+			{"type": "confidentiality", "title": "5. Confidentiality", "summary": "Mutual confidentiality", "pages": []int{2}},
+		},
+		"obligations": []map[string]interface{}{
+			{"party": "Acme Corp", "description": "Keep customer information confidential", "deadline": "", "pages": []int{2}},
+		},
+	}
+	mock := newMockServer(t, answer)
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+	result, err := ext.AnalyzeContract(context.Background(), pdf, types.ContractOptions{ChunkSize: 120})
+	if err != nil {
+		t.Fatalf("Expected contract analysis, got error: %v", err)
+	}
+
+	if result.Chunks < 2 || len(mock.Requests) != result.Chunks {
+		t.Fatalf("Expected the contract to be split into several requests, got %d chunks and %d requests", result.Chunks, len(mock.Requests))
+	}
+	if !strings.Contains(mock.userPrompt(0), "[Page 1]") || !strings.Contains(mock.userPrompt(0), "part 1 of") {
+		t.Errorf("Expected page markers and part instructions in the prompt, got %q", mock.userPrompt(0))
+	}
+	if len(result.Parties) != 2 || len(result.Clauses) != 1 || len(result.Obligations) != 1 {
+		t.Errorf("Expected items repeated across chunks to be merged, got %+v", result)
+	}
+	if result.EffectiveDate.Value != "2024-01-01" || !reflect.DeepEqual(result.EffectiveDate.Pages, []int{1}) {
+		t.Errorf("Expected cited effective date, got %+v", result.EffectiveDate)
+	}
+	if result.Clauses[0].Type != types.ClauseConfidentiality {
+		t.Errorf("Expected confidentiality clause, got %q", result.Clauses[0].Type)
+	}
+	if result.TokensUsed != 42*result.Chunks {
+		t.Errorf("Expected tokens of every chunk to be summed, got %d", result.TokensUsed)
+	}
+	if result.Completeness != 1 || len(result.FailedChunks) > 0 {
+		t.Errorf("Expected a complete result, got completeness %v and failed chunks %+v", result.Completeness, result.FailedChunks)
+	}
+
+	t.Run("Partial results", func(t *testing.T) {
+		mock := newMockServer(t, answer, rawContent("The model ran out of tokens"), answer)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		partial, err := ext.AnalyzeContract(context.Background(), pdf, types.ContractOptions{ChunkSize: 120})
+		if err != nil {
+			t.Fatalf("Expected a partial contract analysis, got error: %v", err)
+		}
+		if len(partial.FailedChunks) != 1 || partial.FailedChunks[0].Err == nil || partial.FailedChunks[0].FirstPage != 2 {
+			t.Fatalf("Expected the second chunk to fail, got %+v", partial.FailedChunks)
+		}
+		if expected := float64(partial.Chunks-1) / float64(partial.Chunks); partial.Completeness != expected {
+			t.Errorf("Expected completeness %v, got %v", expected, partial.Completeness)
+		}
+		if len(partial.Parties) != 2 || partial.TokensUsed != 42*(partial.Chunks-1) {
+			t.Errorf("Expected the data of the other chunks, got %+v", partial)
+		}
+
+		failing := newMockServer(t, rawContent("The model ran out of tokens"))
+		ext, _ = extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: failing.URL, TextThreshold: 10})
+		if _, err := ext.AnalyzeContract(context.Background(), pdf, types.ContractOptions{ChunkSize: 120}); err == nil || !strings.Contains(err.Error(), "failed to extract pages 1-") {
+			t.Errorf("Expected an error when every chunk fails, got %v", err)
+		}
+	})
+}
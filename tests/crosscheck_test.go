@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/ocr"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// fakeTesseract stands in for tesseract, printing the TSV of a scanned invoice for any page piped
+// to its standard input
+const fakeTesseract = `#!/bin/sh
+[ "$1" = stdin ] || exit 1
+head -c 8 | grep -q PNG || exit 2
+printf 'level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n'
+printf '4\t1\t1\t1\t1\t0\t100\t100\t600\t50\t-1\t\n'
+printf '5\t1\t1\t1\t1\t1\t100\t100\t250\t50\t96\tInvoice\n'
+printf '5\t1\t1\t1\t1\t2\t400\t100\t300\t50\t91\tINV-9931\n'
+printf '4\t1\t1\t1\t2\t0\t100\t200\t800\t50\t-1\t\n'
+printf '5\t1\t1\t1\t2\t1\t100\t200\t200\t50\t95\tTotal\n'
+printf '5\t1\t1\t1\t2\t2\t400\t200\t300\t50\t93\t1.250,50\n'
+printf '5\t1\t1\t1\t2\t3\t750\t200\t150\t50\t90\tEUR\n'
+`
+
+func TestCrossCheckOCR(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake OCR engine is a shell script")
+	}
+	binary := filepath.Join(t.TempDir(), "tesseract")
+	if err := os.WriteFile(binary, []byte(fakeTesseract), 0o700); err != nil {
+		t.Fatalf("Failed to write OCR engine: %v", err)
+	}
+	parser.RegisterBackend(blankBackend{})
+	tesseract := ocr.NewTesseract(ocr.TesseractConfig{Path: binary, Renderer: "blank", DPI: 72})
+	parser.RegisterBackend(ocr.NewBackend("tesseract", tesseract, 0))
+	pdf := newTestPdf([]string{"Scan"})
+
+	t.Run("Tesseract", func(t *testing.T) {
+		layouts, err := tesseract.Recognize(context.Background(), pdf)
+		if err != nil {
+			t.Fatalf("Expected recognized text, got error: %v", err)
+		}
+		if len(layouts) != 1 || len(layouts[0].Lines) != 2 || layouts[0].Lines[1].Text != "Total 1.250,50 EUR" {
+			t.Fatalf("Expected the lines of the TSV, got %+v", layouts)
+		}
+		// The blank renders are 8 pixels wide, so pixels are scaled to 612/8 points
+		if word := layouts[0].Lines[0].Words[1]; word.Text != "INV-9931" || word.Bounds.X != 400*612.0/8 {
+			t.Errorf("Expected the word bounds in points, got %+v", word)
+		}
+	})
+
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"invoiceNumber": map[string]interface{}{"type": "string"},
+			"total":         map[string]interface{}{"type": "number"},
+			"issueDate":     map[string]interface{}{"type": "string"},
+			"lines":         map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+		},
+	}
+	extract := func(t *testing.T, data map[string]interface{}) *types.ExtractionResult {
+		mock := newMockServer(t, data)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, Renderer: "blank", CrossCheckBackend: "tesseract"})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, ForceMode: types.ForceModeVision, CrossCheckOCR: true})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		return result
+	}
+
+	t.Run("Verified", func(t *testing.T) {
+		result := extract(t, map[string]interface{}{"invoiceNumber": "INV 9931", "total": 1250.5, "issueDate": "2024-01-31", "lines": []interface{}{map[string]interface{}{"amount": 1250.5}}})
+		if len(result.Unverified) != 0 || len(result.Warnings) != 0 {
+			t.Errorf("Expected every value found in the OCR text, got %v %v", result.Unverified, result.Warnings)
+		}
+	})
+
+	t.Run("Made up", func(t *testing.T) {
+		result := extract(t, map[string]interface{}{"invoiceNumber": "INV-9981", "total": 1250.5, "lines": []interface{}{map[string]interface{}{"amount": 125}}})
+		if expected := []string{"invoiceNumber", "lines.0.amount"}; !reflect.DeepEqual(result.Unverified, expected) {
+			t.Errorf("Expected unverified values %v, got %v", expected, result.Unverified)
+		}
+		if len(result.Warnings) != 2 || !strings.Contains(result.Warnings[0], `invoiceNumber = "INV-9981" not found in the OCR text`) {
+			t.Errorf("Expected a warning per unverified value, got %v", result.Warnings)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestToCSV(t *testing.T) {
+	result := &types.ExtractionResult{Data: map[string]interface{}{
+		"invoiceNumber": "INV-1",
+		"vendor":        map[string]interface{}{"name": "Acme, Inc.", "country": "US"},
+		"tags":          []interface{}{"urgent", "q1"},
+		"lineItems": []interface{}{
+			map[string]interface{}{"description": "Widget", "amount": 10.5},
+			map[string]interface{}{"description": "Gadget", "amount": 2.0},
+		},
+	}}
+
+	t.Run("Explodes line items into rows", func(t *testing.T) {
+		out, err := result.ToCSV(types.FlattenConfig{Explode: "lineItems"})
+		if err != nil {
+			t.Fatalf("Expected CSV, got error: %v", err)
+		}
+		expected := "invoiceNumber,lineItems.amount,lineItems.description,tags,vendor.country,vendor.name\n" +
+			"INV-1,10.5,Widget,urgent; q1,US,\"Acme, Inc.\"\n" +
+			"INV-1,2,Gadget,urgent; q1,US,\"Acme, Inc.\"\n"
+		if string(out) != expected {
+			t.Errorf("Unexpected CSV:\n%s\nexpected:\n%s", out, expected)
+		}
+	})
+
+	t.Run("Indexes arrays without explode", func(t *testing.T) {
+		out, err := result.ToCSV(types.FlattenConfig{Columns: []string{"invoiceNumber", "lineItems_1_description"}, Separator: "_", Delimiter: '\t'})
+		if err != nil {
+			t.Fatalf("Expected TSV, got error: %v", err)
+		}
+		if string(out) != "invoiceNumber\tlineItems_1_description\nINV-1\tGadget\n" {
+			t.Errorf("Unexpected TSV: %q", out)
+		}
+	})
+
+	t.Run("Combines results of a batch", func(t *testing.T) {
+		other := &types.ExtractionResult{Data: map[string]interface{}{"invoiceNumber": "INV-2", "lineItems": []interface{}{}}}
+		var buf bytes.Buffer
+		if err := types.WriteCSV(&buf, []*types.ExtractionResult{result, other}, types.FlattenConfig{Explode: "lineItems"}); err != nil {
+			t.Fatalf("Expected CSV, got error: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 4 || !strings.HasPrefix(lines[3], "INV-2,,,") {
+			t.Errorf("Expected header, two item rows and one row for the result without items, got %q", lines)
+		}
+	})
+
+	t.Run("Rejects exploding a non-array", func(t *testing.T) {
+		if _, err := result.ToCSV(types.FlattenConfig{Explode: "vendor"}); err == nil {
+			t.Error("Expected error when exploding an object")
+		}
+	})
+}
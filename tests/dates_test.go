@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestDateRules(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"invoiceDate": map[string]interface{}{"type": "string"},
+			"dueDate":     map[string]interface{}{"type": "string"},
+			"payments":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+		},
+	}
+	rules := []types.DateRule{
+		{Field: "invoiceDate", MaxAge: 5 * 365 * 24 * time.Hour, MaxAhead: 24 * time.Hour},
+		{Field: "dueDate", After: "invoiceDate"},
+		{Field: "payments.*.date", After: "invoiceDate"},
+	}
+	recent := time.Now().AddDate(0, -1, 0).Format(time.DateOnly)
+	later := time.Now().AddDate(0, 0, 1).Format(time.DateOnly)
+	implausible := map[string]interface{}{
+		"invoiceDate": "2004-03-01",
+		"dueDate":     "2004-02-01",
+		"payments":    []interface{}{map[string]interface{}{"date": "01/04/2004"}},
+	}
+	plausible := map[string]interface{}{
+		"invoiceDate": recent,
+		"dueDate":     later,
+		"payments":    []interface{}{map[string]interface{}{"date": later}},
+	}
+	pdf := newTestPdf([]string{"Invoice INV-11", "Date: 2024-03-01"})
+
+	t.Run("Warn", func(t *testing.T) {
+		mock := newMockServer(t, implausible)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, DateRules: rules})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		expected := []string{
+			`implausible date invoiceDate = "2004-03-01": more than 5 years in the past`,
+			`implausible date dueDate = "2004-02-01": before invoiceDate (2004-03-01)`,
+			`implausible date payments.0.date = "01/04/2004": not an ISO 8601 date`,
+		}
+		if strings.Join(result.Warnings, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Expected warnings %q, got %q", expected, result.Warnings)
+		}
+		if len(mock.Requests) != 1 {
+			t.Errorf("Expected no retry, got %d requests", len(mock.Requests))
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		mock := newMockServer(t, implausible, plausible)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, DateRules: rules, DateRulePolicy: types.DateRulePolicyRetry})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if len(mock.Requests) != 2 || len(result.Warnings) != 0 || result.Data["invoiceDate"] != recent || result.Timings.Retries != 1 {
+			t.Errorf("Expected the corrected dates after one retry, got %d requests, %v, %q", len(mock.Requests), result.Data, result.Warnings)
+		}
+		messages, _ := mock.Requests[1]["messages"].([]interface{})
+		last, _ := messages[len(messages)-1].(map[string]interface{})
+		if content, _ := last["content"].(string); !strings.Contains(content, `dueDate = "2004-02-01": before invoiceDate`) {
+			t.Errorf("Expected the implausible dates in the retry, got %q", content)
+		}
+	})
+}
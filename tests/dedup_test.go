@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestRequestDeduplication(t *testing.T) {
+	var calls atomic.Int32
+	var mu sync.Mutex
+	keys := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		mu.Lock()
+		keys[r.Header.Get("Idempotency-Key")] = true
+		mu.Unlock()
+		// Stay in flight long enough for the other extractions to catch up
+		time.Sleep(300 * time.Millisecond)
+		fmt.Fprint(w, `{"model":"gpt-4o-mini","choices":[{"message":{"content":"{\"total\":\"24.20\"}"}}],"usage":{"total_tokens":42}}`)
+	}))
+	defer server.Close()
+
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: server.URL, TextThreshold: 10})
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	pdf := newTestPdf([]string{"Receipt", "Total 24.20"})
+
+	extractAll := func(keys ...string) {
+		var wg sync.WaitGroup
+		for _, key := range keys {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, IdempotencyKey: key})
+				if err != nil || result.Data["total"] != "24.20" {
+					t.Errorf("Expected extraction to succeed, got %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	extractAll("doc-1", "doc-1", "doc-1", "doc-1", "doc-1")
+	if calls.Load() != 1 {
+		t.Errorf("Expected concurrent identical requests to make a single call, got %d", calls.Load())
+	}
+	if !keys["doc-1"] {
+		t.Errorf("Expected the idempotency key to be sent, got %v", keys)
+	}
+
+	calls.Store(0)
+	extractAll("doc-1", "doc-2")
+	if calls.Load() != 2 {
+		t.Errorf("Expected requests with different keys to make separate calls, got %d", calls.Load())
+	}
+}
+
+func TestRequestDeduplicationCancel(t *testing.T) {
+	var calls, canceled atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// The server notices a canceled request once it has read its body
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-r.Context().Done():
+			canceled.Add(1)
+			return
+		}
+		fmt.Fprint(w, `{"model":"gpt-4o-mini","choices":[{"message":{"content":"{\"total\":\"24.20\"}"}}],"usage":{"total_tokens":42}}`)
+	}))
+	defer server.Close()
+
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: server.URL, TextThreshold: 10})
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	options := types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Receipt", "Total 24.20"}), Schema: schema, IdempotencyKey: "doc-1"}
+
+	// The first caller giving up does not fail the call shared with the others
+	first, cancelFirst := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := ext.ExtractWithContext(first, options); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the first caller canceled, got %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		time.Sleep(50 * time.Millisecond)
+		result, err := ext.ExtractWithContext(context.Background(), options)
+		if err != nil || result.Data["total"] != "24.20" {
+			t.Errorf("Expected the second caller to get the shared result, got %v", err)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancelFirst()
+	wg.Wait()
+	if calls.Load() != 1 || canceled.Load() != 0 {
+		t.Errorf("Expected a single call, not canceled, got %d calls, %d canceled", calls.Load(), canceled.Load())
+	}
+
+	// The call is canceled once every caller has given up, and a later request makes a new call
+	calls.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := ext.ExtractWithContext(ctx, options); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected the callers to time out, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	deadline := time.Now().Add(time.Second)
+	for canceled.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if calls.Load() != 1 || canceled.Load() != 1 {
+		t.Errorf("Expected the shared call canceled, got %d calls, %d canceled", calls.Load(), canceled.Load())
+	}
+	if result, err := ext.ExtractWithContext(context.Background(), options); err != nil || result.Data["total"] != "24.20" {
+		t.Errorf("Expected a new call to succeed, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected a new call, got %d calls", calls.Load())
+	}
+}
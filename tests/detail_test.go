@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// testPageImage encodes a 1000x1200 white page image with every other run of 10 rows dark when inked
+func testPageImage(t *testing.T, page int, inked bool) types.PdfPageImage {
+	img := image.NewGray(image.Rect(0, 0, 1000, 1200))
+	for y := 0; y < 1200; y++ {
+		for x := 0; x < 1000; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+			if inked && (y/10)%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 20})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return types.PdfPageImage{Page: page, Base64: base64.StdEncoding.EncodeToString(buf.Bytes())}
+}
+
+func TestImageDetail(t *testing.T) {
+	blank := testPageImage(t, 2, false)
+	override := testPageImage(t, 3, false)
+	override.Detail = types.ImageDetailHigh
+	parsedPdf := &types.ParsedPdf{
+		Content:  types.ParsedPdfContent{Type: "images", ImageContent: []types.PdfPageImage{testPageImage(t, 1, true), blank, override}},
+		NumPages: 3,
+	}
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+
+	details := func(mock *mockServer) []interface{} {
+		messages := mock.Requests[0]["messages"].([]interface{})
+		content := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+		var found []interface{}
+		for _, part := range content[1:] {
+			found = append(found, part.(map[string]interface{})["image_url"].(map[string]interface{})["detail"])
+		}
+		return found
+	}
+
+	for _, test := range []struct {
+		detail   string
+		expected []interface{}
+	}{
+		{"", []interface{}{nil, nil, "high"}},
+		{types.ImageDetailLow, []interface{}{"low", "low", "high"}},
+		{types.ImageDetailAdaptive, []interface{}{"high", "low", "high"}},
+	} {
+		mock := newMockServer(t, map[string]interface{}{"total": "10.00"})
+		ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, ImageDetail: test.detail})
+		if err != nil {
+			t.Fatalf("Expected extractor, got error: %v", err)
+		}
+		if _, err := ext.Extract(types.ExtractionOptions{ParsedPdf: parsedPdf, Schema: schema}); err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if found := details(mock); len(found) != 3 || found[0] != test.expected[0] || found[1] != test.expected[1] || found[2] != test.expected[2] {
+			t.Errorf("Expected details %v with %q, got %v", test.expected, test.detail, found)
+		}
+	}
+
+	if _, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", ImageDetail: "medium"}); err == nil {
+		t.Error("Expected an unknown detail level to be rejected")
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestFieldDirectives(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"invoiceDate": map[string]interface{}{"type": "string", "x-format": "date"},
+			"currency":    map[string]interface{}{"type": "string", "x-format": "currency"},
+			"total":       map[string]interface{}{"type": "number", "x-unit": "cents"},
+			"customer":    map[string]interface{}{"type": "string", "x-language": "sr-Latn"},
+			"items": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"weight": map[string]interface{}{"type": "number", "x-unit": "kg"}}},
+			},
+		},
+	}
+	misformatted := map[string]interface{}{
+		"invoiceDate": "2024/03/01",
+		"currency":    "€",
+		"total":       1250.5,
+		"customer":    "Никола Тесла",
+		"items":       []interface{}{map[string]interface{}{"weight": 2.5}},
+	}
+	fixed := map[string]interface{}{
+		"invoiceDate": "2024-03-01",
+		"currency":    "EUR",
+		"total":       125050,
+		"customer":    "Nikola Tesla",
+		"items":       []interface{}{map[string]interface{}{"weight": 2.5}},
+	}
+	pdf := newTestPdf([]string{"Invoice INV-12", "Date: 2024/03/01", "Customer: Никола Тесла", "Total: 1.250,50 €"})
+
+	t.Run("Warn", func(t *testing.T) {
+		mock := newMockServer(t, misformatted)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		expected := []string{
+			`currency = "€" rewritten as "EUR"`,
+			`invoiceDate = "2024/03/01" rewritten as "2024-03-01"`,
+			`misformatted value customer = "Никола Тесла": not in Latin script`,
+			`misformatted value total = 1250.5: not an integer amount in cents`,
+		}
+		if strings.Join(result.Warnings, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Expected warnings %q, got %q", expected, result.Warnings)
+		}
+		if result.Data["invoiceDate"] != "2024-03-01" || result.Data["currency"] != "EUR" {
+			t.Errorf("Expected the values rewritten locally, got %v", result.Data)
+		}
+		if len(mock.Requests) != 1 {
+			t.Errorf("Expected no retry, got %d requests", len(mock.Requests))
+		}
+
+		prompt := mock.userPrompt(0)
+		for _, line := range []string{
+			"- invoiceDate: a date as YYYY-MM-DD",
+			"- items.*.weight: in kg, converted if the document uses another unit",
+			`- customer: written in the language "sr", translated if the document uses another language, in Latin script`,
+		} {
+			if !strings.Contains(prompt, line) {
+				t.Errorf("Expected %q in the prompt, got %q", line, prompt)
+			}
+		}
+		body, _ := json.Marshal(mock.Requests[0]["response_format"])
+		if strings.Contains(string(body), "x-") {
+			t.Errorf("Expected the directives left out of the request schema, got %s", body)
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		mock := newMockServer(t, misformatted, fixed)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, FieldDirectivePolicy: types.FieldDirectivePolicyRetry})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if len(mock.Requests) != 2 || result.Data["customer"] != "Nikola Tesla" || len(result.Warnings) != 0 {
+			t.Errorf("Expected the corrected values after one retry, got %d requests, %v, %q", len(mock.Requests), result.Data, result.Warnings)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		mock := newMockServer(t, fixed)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		invalid := map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"invoiceDate": map[string]interface{}{"type": "string", "x-format": "julian"}},
+		}
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: invalid}); err == nil || !strings.Contains(err.Error(), "unknown x-format julian of invoiceDate") {
+			t.Errorf("Expected an unknown format error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+)
+
+func TestDetectDraft(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   map[string]interface{}
+		expected string
+	}{
+		{"Draft7URI", map[string]interface{}{"$schema": "http://json-schema.org/draft-07/schema#", "type": "object"}, schema.Draft7},
+		{"Draft2020URI", map[string]interface{}{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "object"}, schema.Draft202012},
+		{"Draft2019URI", map[string]interface{}{"$schema": "https://json-schema.org/draft/2019-09/schema", "type": "object"}, schema.Draft201909},
+		{"PrefixItems", map[string]interface{}{"type": "object", "properties": map[string]interface{}{"pair": map[string]interface{}{"prefixItems": []interface{}{true, true}}}}, schema.Draft202012},
+		{"Unevaluated", map[string]interface{}{"type": "object", "unevaluatedProperties": false}, schema.Draft202012},
+		{"PropertyNamedLikeKeyword", map[string]interface{}{"type": "object", "properties": map[string]interface{}{"prefixItems": map[string]interface{}{"type": "string"}}}, schema.Draft7},
+		{"Plain", map[string]interface{}{"type": "object"}, schema.Draft7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			draft, err := schema.DetectDraft(tt.schema)
+			if err != nil || draft != tt.expected {
+				t.Errorf("Expected %s, got %q, %v", tt.expected, draft, err)
+			}
+		})
+	}
+	if _, err := schema.DetectDraft(map[string]interface{}{"$schema": "https://example.com/meta"}); err == nil {
+		t.Error("Expected an error for an unknown $schema")
+	}
+}
+
+func TestDraft2020Validation(t *testing.T) {
+	invoiceSchema := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type":    "object",
+		"allOf": []interface{}{
+			map[string]interface{}{"$ref": "#/$defs/document"},
+		},
+		"properties": map[string]interface{}{
+			"total": map[string]interface{}{"type": "number"},
+			"range": map[string]interface{}{
+				"type":        "array",
+				"prefixItems": []interface{}{map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "string"}},
+				"items":       false,
+			},
+		},
+		"dependentRequired":     map[string]interface{}{"total": []interface{}{"currency"}},
+		"unevaluatedProperties": false,
+		"$defs": map[string]interface{}{
+			"document": map[string]interface{}{
+				"properties": map[string]interface{}{"number": map[string]interface{}{"type": "string"}, "currency": map[string]interface{}{"type": "string"}},
+			},
+		},
+	}
+	if err := schema.ValidateSchema(invoiceSchema); err != nil {
+		t.Fatalf("Expected a valid schema, got %v", err)
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		data := map[string]interface{}{"number": "INV-15", "total": 10.0, "currency": "EUR", "range": []interface{}{"2024-01-01", "2024-01-31"}}
+		errs, err := schema.ValidateData(data, invoiceSchema)
+		if err != nil || len(errs) != 0 {
+			t.Errorf("Expected valid data, got %q, %v", errs, err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		data := map[string]interface{}{"number": "INV-15", "total": 10.0, "note": "late", "range": []interface{}{"2024-01-01", 31.0, "extra"}}
+		errs, err := schema.ValidateData(data, invoiceSchema)
+		if err != nil {
+			t.Fatalf("Expected validation errors, got %v", err)
+		}
+		joined := strings.Join(errs, "\n")
+		for _, expected := range []string{
+			"range.1: Invalid type",
+			"range: No additional items allowed on array",
+			"(root): Has a dependency on currency",
+			`(root): Property "note" is not allowed by unevaluatedProperties`,
+		} {
+			if !strings.Contains(joined, expected) {
+				t.Errorf("Expected %q among the errors, got %q", expected, errs)
+			}
+		}
+		if strings.Contains(joined, `"number"`) {
+			t.Errorf("Expected the properties of allOf evaluated, got %q", errs)
+		}
+	})
+
+	t.Run("AnyOf", func(t *testing.T) {
+		partySchema := map[string]interface{}{
+			"type": "object",
+			"anyOf": []interface{}{
+				map[string]interface{}{"properties": map[string]interface{}{"vat": map[string]interface{}{"type": "string"}}, "required": []interface{}{"vat"}},
+				map[string]interface{}{"properties": map[string]interface{}{"iban": map[string]interface{}{"type": "string"}}, "required": []interface{}{"iban"}},
+			},
+			"unevaluatedProperties": false,
+		}
+		errs, _ := schema.ValidateData(map[string]interface{}{"vat": "DE123", "iban": 7.0}, partySchema)
+		if len(errs) != 1 || errs[0] != `(root): Property "iban" is not allowed by unevaluatedProperties` {
+			t.Errorf("Expected only the properties of the valid branch evaluated, got %q", errs)
+		}
+	})
+
+	t.Run("Contains", func(t *testing.T) {
+		contains := func(keywords map[string]interface{}) map[string]interface{} {
+			list := map[string]interface{}{"type": "array", "contains": map[string]interface{}{"type": "integer"}}
+			for key, value := range keywords {
+				list[key] = value
+			}
+			return map[string]interface{}{
+				"$schema":    "https://json-schema.org/draft/2020-12/schema",
+				"type":       "object",
+				"properties": map[string]interface{}{"list": list},
+			}
+		}
+		for _, tt := range []struct {
+			name     string
+			keywords map[string]interface{}
+			list     []interface{}
+			expected string
+		}{
+			{"MinContains", map[string]interface{}{"minContains": 2.0}, []interface{}{1.0, "a"}, "list: Array must contain at least 2 items matching contains, got 1"},
+			{"MinContainsMet", map[string]interface{}{"minContains": 2.0}, []interface{}{1.0, "a", 2.0}, ""},
+			{"MinContainsZero", map[string]interface{}{"minContains": 0.0}, []interface{}{"a"}, ""},
+			{"MaxContains", map[string]interface{}{"maxContains": 1.0}, []interface{}{1.0, 2.0}, "list: Array must contain at most 1 items matching contains, got 2"},
+			{"MaxContainsNoMatch", map[string]interface{}{"maxContains": 1.0}, []interface{}{"a"}, "list: Array must contain at least 1 items matching contains, got 0"},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				errs, err := schema.ValidateData(map[string]interface{}{"list": tt.list}, contains(tt.keywords))
+				if err != nil {
+					t.Fatalf("Expected validation, got %v", err)
+				}
+				if tt.expected == "" && len(errs) != 0 {
+					t.Errorf("Expected valid data, got %q", errs)
+				}
+				if tt.expected != "" && (len(errs) != 1 || errs[0] != tt.expected) {
+					t.Errorf("Expected %q, got %q", tt.expected, errs)
+				}
+			})
+		}
+	})
+
+	t.Run("DependentRequiredAndSchemas", func(t *testing.T) {
+		// Both dependencies of a property apply
+		paymentSchema := map[string]interface{}{
+			"$schema":           "https://json-schema.org/draft/2020-12/schema",
+			"type":              "object",
+			"properties":        map[string]interface{}{"iban": map[string]interface{}{"type": "string"}, "bic": map[string]interface{}{"type": "string"}, "bank": map[string]interface{}{"type": "string"}},
+			"dependentRequired": map[string]interface{}{"iban": []interface{}{"bic"}},
+			"dependentSchemas":  map[string]interface{}{"iban": map[string]interface{}{"required": []interface{}{"bank"}}},
+		}
+		for _, tt := range []struct {
+			data     map[string]interface{}
+			expected string
+		}{
+			{map[string]interface{}{"iban": "DE89", "bank": "Bank"}, "bic"},
+			{map[string]interface{}{"iban": "DE89", "bic": "COBADEFF"}, "bank"},
+			{map[string]interface{}{"iban": "DE89", "bic": "COBADEFF", "bank": "Bank"}, ""},
+		} {
+			errs, err := schema.ValidateData(tt.data, paymentSchema)
+			if err != nil {
+				t.Fatalf("Expected validation, got %v", err)
+			}
+			joined := strings.Join(errs, "\n")
+			if tt.expected == "" && len(errs) != 0 {
+				t.Errorf("Expected valid data, got %q", errs)
+			}
+			if tt.expected != "" && !strings.Contains(joined, tt.expected) {
+				t.Errorf("Expected an error on %s for %v, got %q", tt.expected, tt.data, errs)
+			}
+		}
+	})
+
+	t.Run("InvalidKeywords", func(t *testing.T) {
+		for _, tt := range []struct {
+			schema   map[string]interface{}
+			expected string
+		}{
+			{map[string]interface{}{"type": "array", "prefixItems": map[string]interface{}{"type": "string"}}, "prefixItems at / must be an array of schemas"},
+			{map[string]interface{}{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "array", "items": []interface{}{true}}, "items at / must be a schema in draft 2020-12"},
+			{map[string]interface{}{"type": "object", "properties": map[string]interface{}{"a": map[string]interface{}{"$dynamicRef": "#node"}}}, "unsupported keyword $dynamicRef at /properties/a"},
+			{map[string]interface{}{"$schema": "https://example.com/meta", "type": "object"}, "unsupported $schema"},
+		} {
+			if err := schema.ValidateSchema(tt.schema); err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected an error with %q, got %v", tt.expected, err)
+			}
+		}
+	})
+}
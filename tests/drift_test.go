@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestSchemaDrift(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"invoice_number": map[string]interface{}{"type": "string"},
+			"seller":         map[string]interface{}{"$ref": "#/$defs/party"},
+			"lines": map[string]interface{}{"type": "array", "items": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"amount": map[string]interface{}{"type": "number"}},
+				"required":   []string{"amount"},
+			}},
+			"metadata": map[string]interface{}{"type": "object", "additionalProperties": true},
+		},
+		"required": []string{"invoice_number", "seller", "total"},
+		"$defs": map[string]interface{}{"party": map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "object", "properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}}},
+				map[string]interface{}{"type": "null"},
+			},
+		}},
+	}
+	schema["properties"].(map[string]interface{})["total"] = map[string]interface{}{"type": "number"}
+	answer := map[string]interface{}{
+		"invoice_number": "INV-42",
+		"seller":         map[string]interface{}{"name": "ACME", "vat_id": "ES12345678"},
+		"lines":          []interface{}{map[string]interface{}{"amount": 10, "note": "first"}, map[string]interface{}{}},
+		"metadata":       map[string]interface{}{"source": "scan"},
+		"confidence":     0.9,
+	}
+	pdf := newTestPdf([]string{"Invoice INV-42", "ACME"})
+	expected := &types.SchemaDrift{Unexpected: []string{"confidence", "lines.0.note", "seller.vat_id"}, Missing: []string{"lines.1.amount", "total"}}
+
+	t.Run("Prune", func(t *testing.T) {
+		mock := newMockServer(t, answer)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if !reflect.DeepEqual(result.Drift, expected) {
+			t.Errorf("Expected drift %+v, got %+v", expected, result.Drift)
+		}
+		data, _ := json.Marshal(result.Data)
+		if string(data) != `{"invoice_number":"INV-42","lines":[{"amount":10},{}],"metadata":{"source":"scan"},"seller":{"name":"ACME"}}` {
+			t.Errorf("Expected the unexpected keys to be removed, got %s", data)
+		}
+		warnings := strings.Join(result.Warnings, "\n")
+		if !strings.Contains(warnings, "confidence, lines.0.note, seller.vat_id (removed)") || !strings.Contains(warnings, "left out required keys: lines.1.amount, total") {
+			t.Errorf("Expected the drift in the warnings, got %q", warnings)
+		}
+	})
+
+	t.Run("Keep", func(t *testing.T) {
+		mock := newMockServer(t, answer)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, SchemaDrift: types.SchemaDriftKeep})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if !reflect.DeepEqual(result.Drift, expected) || result.Data["confidence"] != 0.9 {
+			t.Errorf("Expected the unexpected keys to be reported and kept, got %+v and %v", result.Drift, result.Data)
+		}
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		mock := newMockServer(t, answer)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, SchemaDrift: types.SchemaDriftFail})
+		if !errors.Is(err, extractor.ErrSchemaDrift) || !strings.Contains(err.Error(), "seller.vat_id") {
+			t.Errorf("Expected a schema drift error, got %v", err)
+		}
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, SchemaDrift: "ignore"}); err == nil {
+			t.Error("Expected an error for an unknown schema drift policy")
+		}
+	})
+
+	t.Run("No drift", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"invoice_number": "INV-42", "seller": nil, "total": 10, "extra": map[string]interface{}{"any": "key"}})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		loose := map[string]interface{}{"type": "object", "properties": schema["properties"], "required": schema["required"], "$defs": schema["$defs"], "additionalProperties": true}
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: loose, NullableFields: []string{"invoice_number"}})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if result.Drift != nil || len(result.Warnings) > 0 {
+			t.Errorf("Expected no drift, got %+v and %q", result.Drift, result.Warnings)
+		}
+	})
+
+	t.Run("Steps", func(t *testing.T) {
+		mock := newMockServer(t, answer)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		parsed, _ := ext.Parse(types.ExtractionOptions{PDFBuffer: pdf})
+		request, err := ext.BuildRequest(parsed, types.ExtractionOptions{Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected request, got error: %v", err)
+		}
+		response, err := ext.CallModel(t.Context(), request)
+		if err != nil {
+			t.Fatalf("Expected response, got error: %v", err)
+		}
+		// The response of the step may be stored and parsed by another worker
+		encoded, _ := json.Marshal(response)
+		var decoded types.ModelResponse
+		json.Unmarshal(encoded, &decoded)
+		result, err := ext.ParseResult(&decoded)
+		if err != nil {
+			t.Fatalf("Expected result, got error: %v", err)
+		}
+		if !reflect.DeepEqual(result.Drift, expected) {
+			t.Errorf("Expected drift %+v, got %+v", expected, result.Drift)
+		}
+	})
+}
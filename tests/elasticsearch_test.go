@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/elasticsearch"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestElasticsearchWriter(t *testing.T) {
+	type request struct {
+		method, path, auth, body string
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{r.Method, r.URL.EscapedPath(), r.Header.Get("Authorization"), string(body)})
+		switch {
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/_bulk":
+			_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"_id":"a.pdf","status":201}},{"index":{"_id":"b.pdf","status":400,"error":{"type":"mapper_parsing_exception"}}}]}`))
+		default:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	writer, err := elasticsearch.New(elasticsearch.Config{URL: server.URL + "/", Index: "invoices", APIKey: "secret", IncludeText: true})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	ctx := context.Background()
+
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"number":   map[string]interface{}{"type": "string"},
+			"currency": map[string]interface{}{"type": "string", "enum": []interface{}{"EUR", "USD"}},
+			"date":     map[string]interface{}{"type": []interface{}{"string", "null"}, "format": "date"},
+			"lines": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"quantity": map[string]interface{}{"type": "integer"}}},
+			},
+		},
+	}
+	if err := writer.CreateIndex(ctx, schema); err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	if len(requests) != 2 || requests[1].method != http.MethodPut || requests[1].path != "/invoices" || requests[1].auth != "ApiKey secret" {
+		t.Fatalf("Expected the index to be created after checking it exists, got %+v", requests)
+	}
+	var created struct {
+		Mappings struct {
+			Properties struct {
+				Data struct {
+					Properties map[string]map[string]interface{} `json:"properties"`
+				} `json:"data"`
+				Text map[string]interface{} `json:"text"`
+			} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(requests[1].body), &created); err != nil {
+		t.Fatalf("Expected JSON mapping, got error: %v", err)
+	}
+	data := created.Mappings.Properties.Data.Properties
+	if data["number"]["type"] != "text" || data["currency"]["type"] != "keyword" || data["date"]["type"] != "date" || created.Mappings.Properties.Text["type"] != "text" {
+		t.Errorf("Unexpected mapping: %s", requests[1].body)
+	}
+	if quantity, _ := data["lines"]["properties"].(map[string]interface{})["quantity"].(map[string]interface{}); quantity["type"] != "long" {
+		t.Errorf("Expected array items to be mapped, got %v", data["lines"])
+	}
+
+	requests = nil
+	document := types.IndexDocument{ID: "scans/a.pdf", Text: "Invoice 42", Data: map[string]interface{}{"number": "42"}, NumPages: 1}
+	if err := writer.Index(ctx, document); err != nil {
+		t.Fatalf("Failed to index document: %v", err)
+	}
+	if requests[0].method != http.MethodPut || requests[0].path != "/invoices/_doc/scans%2Fa.pdf" || !strings.Contains(requests[0].body, `"text":"Invoice 42"`) {
+		t.Errorf("Unexpected index request: %+v", requests[0])
+	}
+
+	t.Run("bulk", func(t *testing.T) {
+		requests = nil
+		results := []types.BatchResult{
+			{ID: "a.pdf", Status: types.BatchStatusSuccess, Result: &types.ExtractionResult{Data: map[string]interface{}{"number": "1"}}},
+			{ID: "b.pdf", Status: types.BatchStatusSuccess, Result: &types.ExtractionResult{Data: map[string]interface{}{"number": "2"}}},
+			{ID: "c.pdf", Status: types.BatchStatusError},
+		}
+		err := writer.WriteBatch(ctx, results)
+		if err == nil || !strings.Contains(err.Error(), "b.pdf") {
+			t.Errorf("Expected the failed bulk item to be reported, got %v", err)
+		}
+		if len(requests) != 1 || strings.Count(requests[0].body, "\n") != 4 {
+			t.Errorf("Expected one bulk request with two documents, got %+v", requests)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// fakeCwebp stands in for cwebp, writing the content of $WEBP_OUTPUT to the output file
+const fakeCwebp = `#!/bin/sh
+for last; do :; done
+printf "$WEBP_OUTPUT" > "$last"
+`
+
+func TestImageFormat(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake encoder is a shell script")
+	}
+	binary := filepath.Join(t.TempDir(), "cwebp")
+	if err := os.WriteFile(binary, []byte(fakeCwebp), 0o700); err != nil {
+		t.Fatalf("Failed to write encoder: %v", err)
+	}
+
+	parser.RegisterBackend(drawingBackend{})
+	parsedPdf, err := parser.ParsePdfFromBuffer(newTestPdf([]string{"Scan"}), &types.ParseOptions{ForceImages: true, Renderer: "drawing"})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	page := parsedPdf.Content.ImageContent[0].Base64
+
+	t.Run("Encode", func(t *testing.T) {
+		t.Setenv("WEBP_OUTPUT", "RIFF0000WEBP")
+		encoded, mimeType, err := parser.EncodePageImage(page, types.ImageFormatWebP, binary)
+		if err != nil || mimeType != "image/webp" || encoded != base64.StdEncoding.EncodeToString([]byte("RIFF0000WEBP")) {
+			t.Errorf("Expected the WebP image, got %q %q (%v)", mimeType, encoded, err)
+		}
+
+		// Images that do not get smaller are kept as PNG
+		t.Setenv("WEBP_OUTPUT", strings.Repeat("x", 100000))
+		if encoded, mimeType, err := parser.EncodePageImage(page, types.ImageFormatWebP, binary); err != nil || mimeType != "image/png" || encoded != page {
+			t.Errorf("Expected the PNG image, got %q (%v)", mimeType, err)
+		}
+	})
+
+	t.Run("Extraction", func(t *testing.T) {
+		t.Setenv("WEBP_OUTPUT", "RIFF0000WEBP")
+		mock := newMockServer(t, map[string]interface{}{"total": "10.00"})
+		ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, ImageFormat: types.ImageFormatWebP, ImageEncoderPath: binary})
+		if err != nil {
+			t.Fatalf("Expected extractor, got error: %v", err)
+		}
+		if _, err := ext.Extract(types.ExtractionOptions{ParsedPdf: parsedPdf, Schema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}}); err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		messages := mock.Requests[0]["messages"].([]interface{})
+		content := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+		url := content[1].(map[string]interface{})["image_url"].(map[string]interface{})["url"].(string)
+		if !strings.HasPrefix(url, "data:image/webp;base64,") {
+			t.Errorf("Expected a WebP page image, got %.40s", url)
+		}
+	})
+
+	t.Run("Unsupported by provider", func(t *testing.T) {
+		if _, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", ImageFormat: types.ImageFormatAVIF}); err == nil || !strings.Contains(err.Error(), "does not accept avif") {
+			t.Errorf("Expected AVIF to be rejected for OpenAI, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/store"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestExtractEntities(t *testing.T) {
+	pdf := newTestPdf([]string{
+		"This agreement is made between ACME Corp and Jane Doe of Berlin.",
+		"Payment of 1,200.00 EUR is due on 15 March 2024 to ACME",
+		"Corp at its registered office. Both parties accept the terms above.",
+	})
+
+	mock := newMockServer(t, map[string]interface{}{
+		"entities": []map[string]interface{}{
+			{"type": "organization", "text": "ACME Corp", "value": ""},
+			{"type": "person", "text": "Jane Doe", "value": ""},
+			{"type": "amount", "text": "1,200.00 EUR", "value": "1200.00"},
+			{"type": "location", "text": "Atlantis", "value": ""},
+		},
+	})
+
+	ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+	if err != nil {
+		t.Fatalf("Failed to create extractor: %v", err)
+	}
+
+	result, err := ext.ExtractEntities(context.Background(), pdf)
+	if err != nil {
+		t.Fatalf("Expected entities, got error: %v", err)
+	}
+
+	if len(result.Entities) != 4 {
+		t.Fatalf("Expected 4 entities, got %d", len(result.Entities))
+	}
+
+	runes := []rune(result.Text)
+	for _, entity := range result.Entities[:3] {
+		if len(entity.Spans) == 0 {
+			t.Errorf("Expected spans for %q", entity.Text)
+		}
+		for _, span := range entity.Spans {
+			if span.Start < 0 || span.End > len(runes) || span.Start >= span.End {
+				t.Errorf("Invalid span %+v for %q", span, entity.Text)
+			}
+		}
+	}
+
+	// The second mention of ACME Corp is split across a line break
+	if spans := result.Entities[0].Spans; len(spans) != 2 {
+		t.Errorf("Expected 2 mentions of ACME Corp, got %+v", spans)
+	}
+	if span := result.Entities[1].Spans[0]; string(runes[span.Start:span.End]) != "Jane Doe" {
+		t.Errorf("Span does not point at entity text: %q", string(runes[span.Start:span.End]))
+	}
+	if result.Entities[2].Value != "1200.00" {
+		t.Errorf("Expected normalized amount, got %q", result.Entities[2].Value)
+	}
+	if len(result.Entities[3].Spans) != 0 {
+		t.Errorf("Expected no spans for entity missing from text, got %+v", result.Entities[3].Spans)
+	}
+}
+
+func TestExtractEntitiesHistory(t *testing.T) {
+	pdf := newTestPdf([]string{"This agreement is made between ACME Corp and Jane Doe of Berlin."})
+	sum := sha256.Sum256(pdf)
+	mock := newMockServer(t, map[string]interface{}{
+		"entities": []map[string]interface{}{{"type": "organization", "text": "ACME Corp", "value": ""}},
+	})
+
+	// Entity extractions are recorded and saved like any other extraction
+	var records []types.ExtractionRecord
+	history := store.NewMemory()
+	ext, _ := extractor.New(types.ExtractorConfig{
+		OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Store: history,
+		Recorder: types.RecorderFunc(func(ctx context.Context, record types.ExtractionRecord) error {
+			records = append(records, record)
+			return nil
+		}),
+	})
+	result, err := ext.ExtractEntities(context.Background(), pdf)
+	if err != nil {
+		t.Fatalf("Expected entities, got error: %v", err)
+	}
+	if len(result.Entities) != 1 || len(result.Entities[0].Spans) != 1 {
+		t.Errorf("Expected the entity with its span, got %+v", result.Entities)
+	}
+
+	if len(records) != 1 || records[0].Data["entities"] == nil || !strings.Contains(records[0].UserPrompt, "ACME Corp") {
+		t.Errorf("Expected the extraction recorded, got %+v", records)
+	}
+	extractions, _ := history.GetByDocumentHash(context.Background(), hex.EncodeToString(sum[:]))
+	if len(extractions) != 1 || extractions[0].Status != types.BatchStatusSuccess || extractions[0].TokensUsed != result.TokensUsed {
+		t.Errorf("Expected the extraction saved, got %+v", extractions)
+	}
+}
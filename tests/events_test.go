@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestPublisher(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"title": "Quarterly report"})
+	var events []types.Event
+	publisher := types.PublisherFunc(func(ctx context.Context, event types.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Publisher: publisher})
+
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}}}
+	pdf := newTestPdf([]string{"Quarterly report for the first quarter"})
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, DocumentID: "report"}); err != nil {
+		t.Fatalf("Expected extraction to succeed, got error: %v", err)
+	}
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: []byte("not a pdf"), Schema: schema, DocumentID: "broken"}); err == nil {
+		t.Fatal("Expected extraction of an invalid PDF to fail")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected two events, got %d", len(events))
+	}
+	completed, failed := events[0], events[1]
+	if completed.Type != types.EventExtractionCompleted || completed.DocumentID != "report" || completed.Data["title"] != "Quarterly report" ||
+		completed.TokensUsed != 42 || completed.SchemaVersion != types.EventSchemaVersion || completed.Time.IsZero() {
+		t.Errorf("Unexpected completed event: %+v", completed)
+	}
+	if failed.Type != types.EventExtractionFailed || failed.DocumentID != "broken" || failed.Error == "" || failed.Data != nil {
+		t.Errorf("Unexpected failed event: %+v", failed)
+	}
+
+	t.Run("publish error", func(t *testing.T) {
+		failing := types.PublisherFunc(func(ctx context.Context, event types.Event) error {
+			return errors.New("broker unavailable")
+		})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Publisher: failing})
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if err == nil || !strings.Contains(err.Error(), "broker unavailable") {
+			t.Errorf("Expected the publish error, got %v", err)
+		}
+	})
+}
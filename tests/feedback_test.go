@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/feedback"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestFeedback(t *testing.T) {
+	ctx := context.Background()
+	schema := map[string]interface{}{
+		"title": "invoice",
+		"type":  "object",
+		"properties": map[string]interface{}{
+			"vendor": map[string]interface{}{"type": "string"},
+			"total":  map[string]interface{}{"type": "number"},
+			"lines":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+	first := newTestPdf([]string{"Invoice from ACME Corp.", "Total: 120.00 EUR"})
+	second := newTestPdf([]string{"Invoice from Globex", "Total: 80.00 EUR"})
+
+	t.Run("Record", func(t *testing.T) {
+		store := feedback.NewMemory()
+		mock := newMockServer(t,
+			map[string]interface{}{"vendor": "ACME", "total": 12, "lines": []interface{}{"Hosting"}},
+			map[string]interface{}{"vendor": "Globex", "total": 80, "lines": []interface{}{}},
+		)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, FeedbackStore: store, FeedbackExamples: 2})
+
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: first, Schema: schema, DocumentID: "inv-1"}); err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		corrected := map[string]interface{}{"vendor": "ACME Corp.", "total": 120, "lines": []string{"Hosting"}}
+		if err := ext.RecordFeedback(ctx, "inv-1", corrected); err != nil {
+			t.Fatalf("Expected feedback recorded, got error: %v", err)
+		}
+		records, _ := store.Records(ctx)
+		if len(records) != 1 {
+			t.Fatalf("Expected a record, got %+v", records)
+		}
+		record := records[0]
+		if record.DocumentID != "inv-1" || record.Schema != "invoice" || !strings.Contains(record.Text, "ACME Corp.") || record.Extracted["vendor"] != "ACME" {
+			t.Errorf("Expected the extraction in the record, got %+v", record)
+		}
+		if !reflect.DeepEqual(record.Corrections, []string{"total", "vendor"}) {
+			t.Errorf("Expected the corrected paths, got %v", record.Corrections)
+		}
+
+		// The correction is given to the model as an example for the next document of the schema
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: second, Schema: schema, DocumentID: "inv-2"}); err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if prompt := mock.userPrompt(0); strings.Contains(prompt, "Example 1") {
+			t.Errorf("Expected no example before any feedback, got %q", prompt)
+		}
+		prompt := mock.userPrompt(1)
+		for _, part := range []string{"corrected by reviewers", "Example 1 document:\nInvoice from ACME Corp.", `Example 1 data:` + "\n" + `{"lines":["Hosting"],"total":120,"vendor":"ACME Corp."}`} {
+			if !strings.Contains(prompt, part) {
+				t.Errorf("Expected %q in the prompt, got %q", part, prompt)
+			}
+		}
+
+		dataset, err := ext.FeedbackDataset(ctx, "invoice")
+		if err != nil {
+			t.Fatalf("Expected dataset, got error: %v", err)
+		}
+		if len(dataset) != 1 || dataset[0].DocumentID != "inv-1" || dataset[0].Expected["vendor"] != "ACME Corp." || dataset[0].Text == "" {
+			t.Errorf("Expected the corrected document in the dataset, got %+v", dataset)
+		}
+		if other, _ := ext.FeedbackDataset(ctx, "receipt"); len(other) != 0 {
+			t.Errorf("Expected no document of another schema, got %+v", other)
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "feedback.jsonl")
+		store, err := feedback.NewFile(path)
+		if err != nil {
+			t.Fatalf("Expected feedback file, got error: %v", err)
+		}
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", FeedbackStore: store})
+		// Feedback on documents the extractor did not extract holds the corrected data only
+		if err := ext.RecordFeedback(ctx, "inv-9", map[string]interface{}{"vendor": "Initech"}); err != nil {
+			t.Fatalf("Expected feedback recorded, got error: %v", err)
+		}
+		if err := ext.RecordFeedback(ctx, "inv-9", map[string]interface{}{"vendor": "Initech Ltd"}); err != nil {
+			t.Fatalf("Expected feedback recorded, got error: %v", err)
+		}
+		if err := ext.Shutdown(ctx); err != nil {
+			t.Fatalf("Expected shutdown, got error: %v", err)
+		}
+
+		reopened, err := feedback.NewFile(path)
+		if err != nil {
+			t.Fatalf("Expected feedback file reopened, got error: %v", err)
+		}
+		defer reopened.Close()
+		records, _ := reopened.Records(ctx)
+		if len(records) != 2 || records[1].Corrected["vendor"] != "Initech Ltd" || records[0].Extracted != nil || records[0].Time.IsZero() {
+			t.Errorf("Expected the records of the file, got %+v", records)
+		}
+		ext, _ = extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", FeedbackStore: reopened})
+		if dataset, _ := ext.FeedbackDataset(ctx, ""); len(dataset) != 1 || dataset[0].Expected["vendor"] != "Initech Ltd" {
+			t.Errorf("Expected the last correction of the document, got %+v", dataset)
+		}
+	})
+
+	t.Run("No store", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test"})
+		if err := ext.RecordFeedback(ctx, "inv-1", map[string]interface{}{}); err == nil {
+			t.Error("Expected an error without a feedback store")
+		}
+	})
+}
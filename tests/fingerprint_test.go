@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestFingerprint(t *testing.T) {
+	pages := [][]string{{"Invoice INV-001", "Total: 1,250.00 EUR"}, {"Page two"}}
+	pdf := newTestPdf(pages...)
+
+	fingerprint, err := parser.Fingerprint(pdf)
+	if err != nil {
+		t.Fatalf("Expected fingerprint, got error: %v", err)
+	}
+	if len(fingerprint.Hash) != 64 || len(fingerprint.ContentHash) != 64 || fingerprint.NumPages != 2 || fingerprint.Version != "1.4" {
+		t.Errorf("Expected the hashes, page count and version of the PDF, got %+v", fingerprint)
+	}
+	if !reflect.DeepEqual(fingerprint.Fonts, []string{"Helvetica"}) {
+		t.Errorf("Expected the fonts used by the pages, got %v", fingerprint.Fonts)
+	}
+	if again, _ := parser.Fingerprint(pdf); !reflect.DeepEqual(again, fingerprint) {
+		t.Errorf("Expected the same fingerprint for the same PDF, got %+v and %+v", fingerprint, again)
+	}
+
+	// Saved again with metadata: other bytes, same content
+	size := testPdfSize(pdf)
+	saved := withObjects(pdf, map[int]string{size: "<< /Producer (Scanner Suite 2.1) /Creator (Writer) >>"})
+	saved = []byte(strings.Replace(string(saved), "/Root 1 0 R /Prev", fmt.Sprintf("/Root 1 0 R /Info %d 0 R /Prev", size), 1))
+	resaved, err := parser.Fingerprint(saved)
+	if err != nil {
+		t.Fatalf("Expected fingerprint of the saved PDF, got error: %v", err)
+	}
+	if resaved.Hash == fingerprint.Hash || resaved.ContentHash != fingerprint.ContentHash {
+		t.Errorf("Expected another hash and the same content hash, got %+v and %+v", fingerprint, resaved)
+	}
+	if resaved.Producer != "Scanner Suite 2.1" || resaved.Creator != "Writer" {
+		t.Errorf("Expected the producer and creator of the metadata, got %+v", resaved)
+	}
+
+	if other, _ := parser.Fingerprint(newTestPdf([]string{"Invoice INV-002", "Total: 1,250.00 EUR"}, []string{"Page two"})); other.ContentHash == fingerprint.ContentHash {
+		t.Error("Expected another content hash for another document")
+	}
+	if _, err := parser.Fingerprint([]byte("not a PDF")); err == nil {
+		t.Error("Expected an error for an invalid PDF")
+	}
+
+	t.Run("Extraction", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"total": 1250})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "number"}}}
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, Fingerprint: true})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if !reflect.DeepEqual(result.Fingerprint, fingerprint) {
+			t.Errorf("Expected the fingerprint of the PDF, got %+v", result.Fingerprint)
+		}
+		if result, _ := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); result.Fingerprint != nil {
+			t.Errorf("Expected no fingerprint unless asked, got %+v", result.Fingerprint)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestForceMode(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	mock := newMockServer(t, map[string]interface{}{"total": "24.20"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true})
+
+	// Below the default threshold of 100 characters, so read through vision in auto mode
+	pdf := newTestPdf([]string{"Item  Qty  Price", "Widget  2  10.00", "Total  24.20"})
+	sent := func(i int) string {
+		data, _ := json.Marshal(mock.Requests[i])
+		return string(data)
+	}
+
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+		t.Fatalf("Expected extraction to succeed, got %v", err)
+	}
+	if !strings.Contains(sent(0), "image_url") {
+		t.Error("Expected the short text to be read through vision in auto mode")
+	}
+
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, ForceMode: types.ForceModeText}); err != nil {
+		t.Fatalf("Expected extraction to succeed, got %v", err)
+	}
+	if strings.Contains(sent(1), "image_url") || !strings.Contains(mock.userPrompt(1), "Total  24.20") {
+		t.Errorf("Expected the text layer to be sent, got %s", sent(1))
+	}
+
+	textExt, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, TextThreshold: 10})
+	if _, err := textExt.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, ForceMode: types.ForceModeVision}); err != nil {
+		t.Fatalf("Expected extraction to succeed, got %v", err)
+	}
+	if !strings.Contains(sent(2), "image_url") {
+		t.Error("Expected page images in vision mode despite the text layer")
+	}
+
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{}), Schema: schema, ForceMode: types.ForceModeText}); err == nil || !strings.Contains(err.Error(), "no text layer") {
+		t.Errorf("Expected a missing text layer error, got %v", err)
+	}
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, ForceMode: "ocr"}); err == nil || !strings.Contains(err.Error(), `unknown force mode "ocr"`) {
+		t.Errorf("Expected an unknown mode error, got %v", err)
+	}
+	if len(mock.Requests) != 3 {
+		t.Errorf("Expected 3 requests, got %d", len(mock.Requests))
+	}
+}
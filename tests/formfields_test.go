@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestFormFields(t *testing.T) {
+	pdf := newTestPdf([]string{
+		"Insurance claim form. Policy holder: John Smith. Policy number: 99-1234.",
+		"[ ] I confirm the information above is correct.",
+		"Signature: ____________________ Date: 2024-05-01",
+	})
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"policyNumber": map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"policyNumber"},
+		"additionalProperties": false,
+	}
+	fields := []types.FormField{
+		{Name: "consent.confirmed", Kind: types.FormFieldCheckbox, Page: 1, Region: types.Rect{X: 0.1, Y: 0.1, Width: 0.05, Height: 0.05}},
+		{Name: "consent.signed", Kind: types.FormFieldSignature, Page: 1, Region: types.Rect{X: 0.1, Y: 0.15, Width: 0.4, Height: 0.05}},
+	}
+
+	t.Run("Merges detected values into data", func(t *testing.T) {
+		// The mock answers both the extraction and the detection request with the same payload
+		mock := newMockServer(t, map[string]interface{}{
+			"policyNumber": "99-1234",
+			"field1":       true,
+			"field2":       false,
+		})
+		ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true})
+		if err != nil {
+			t.Fatalf("Failed to create extractor: %v", err)
+		}
+
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, FormFields: fields})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got error: %v", err)
+		}
+
+		consent, ok := result.Data["consent"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected consent object in data, got %+v", result.Data)
+		}
+		if consent["confirmed"] != true || consent["signed"] != false {
+			t.Errorf("Unexpected form field values: %+v", consent)
+		}
+		if len(mock.Requests) != 2 {
+			t.Errorf("Expected 2 API calls, got %d", len(mock.Requests))
+		}
+		if result.TokensUsed != 84 {
+			t.Errorf("Expected tokens of both calls to be added, got %d", result.TokensUsed)
+		}
+	})
+
+	t.Run("Rejects regions outside the page", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"policyNumber": "99-1234"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true})
+		_, err := ext.Extract(types.ExtractionOptions{
+			PDFBuffer: pdf,
+			Schema:    schema,
+			FormFields: []types.FormField{
+				{Name: "signed", Kind: types.FormFieldSignature, Page: 1, Region: types.Rect{X: 0.9, Y: 0.9, Width: 0.5, Height: 0.5}},
+			},
+		})
+		if err == nil {
+			t.Error("Expected error for region outside the page")
+		}
+		if len(mock.Requests) != 0 {
+			t.Errorf("Expected validation before any API call, got %d calls", len(mock.Requests))
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// decodePageImage decodes a base64 PNG page image
+func decodePageImage(t *testing.T, encoded string) image.Image {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("Expected a base64 image, got error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected a PNG image, got error: %v", err)
+	}
+	return img
+}
+
+// grayRange returns the darkest and lightest gray levels of an image
+func grayRange(img image.Image) (uint8, uint8) {
+	low, high := uint8(255), uint8(0)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			l := uint8((299*(r>>8) + 587*(g>>8) + 114*(b>>8)) / 1000)
+			low, high = min(low, l), max(high, l)
+		}
+	}
+	return low, high
+}
+
+func TestEnhanceContrast(t *testing.T) {
+	// Faint gray strokes on a light gray page, like pencil on a yellowed form
+	pdf := newTestPdfFromContent("0.85 g 0 0 612 792 re f 0.65 g 72 600 200 40 re f BT 0.65 g /F1 48 Tf 72 700 Td (Faint) Tj ET")
+
+	render := func(enhance bool) image.Image {
+		parsed, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, DPI: 72, EnhanceContrast: enhance})
+		if err != nil {
+			t.Fatalf("Expected the page rendered, got error: %v", err)
+		}
+		if len(parsed.Content.ImageContent) != 1 {
+			t.Fatalf("Expected one page image, got %d", len(parsed.Content.ImageContent))
+		}
+		return decodePageImage(t, parsed.Content.ImageContent[0].Base64)
+	}
+
+	if low, high := grayRange(render(false)); low < 150 || high > 230 {
+		t.Fatalf("Expected a low-contrast render, got gray levels from %d to %d", low, high)
+	}
+	enhanced := render(true)
+	if _, ok := enhanced.(*image.Gray); !ok {
+		t.Errorf("Expected a grayscale render, got %T", enhanced)
+	}
+	if low, high := grayRange(enhanced); low != 0 || high != 255 {
+		t.Errorf("Expected the contrast stretched from black to white, got gray levels from %d to %d", low, high)
+	}
+}
+
+func TestHandwritingMode(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}}}
+	// Long enough to be read as text, if not for handwriting mode
+	pdf := newTestPdf([]string{
+		"Registration form - Name: (handwritten) - Date: (handwritten)",
+		"Please write in capital letters and sign at the bottom of the page",
+	})
+
+	t.Run("Renders the pages for vision", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, VisionEnabled: true})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, HandwritingMode: true}); err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+
+		if prompt := mock.userPrompt(0); !strings.Contains(prompt, "handwritten content") {
+			t.Errorf("Expected the handwriting prompt, got %q", prompt)
+		}
+		messages := mock.Requests[0]["messages"].([]interface{})
+		content, ok := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+		if !ok || len(content) != 2 {
+			t.Fatalf("Expected the prompt and one page image, got %v", messages[len(messages)-1])
+		}
+		url := content[1].(map[string]interface{})["image_url"].(map[string]interface{})["url"].(string)
+		img := decodePageImage(t, strings.TrimPrefix(url, "data:image/png;base64,"))
+		// A US Letter page of 8.5 x 11 inches at 400 DPI
+		if bounds := img.Bounds(); bounds.Dx() != 3400 || bounds.Dy() != 4400 {
+			t.Errorf("Expected the page rendered at 400 DPI, got %dx%d", bounds.Dx(), bounds.Dy())
+		}
+		if _, ok := img.(*image.Gray); !ok {
+			t.Errorf("Expected a contrast-enhanced grayscale render, got %T", img)
+		}
+	})
+
+	t.Run("Rejects the text force mode", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"name": "Jane Doe"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		_, err := ext.ExtractWithContext(context.Background(), types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, HandwritingMode: true, ForceMode: types.ForceModeText})
+		if err == nil || !strings.Contains(err.Error(), "handwriting mode requires vision") {
+			t.Errorf("Expected the text force mode to be rejected, got %v", err)
+		}
+		if len(mock.Requests) != 0 {
+			t.Errorf("Expected no request to the model, got %d", len(mock.Requests))
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"Incorrect API key provided"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":"gpt-4o-mini"},{"id":"gpt-4o"}]}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "good", BaseURL: server.URL, VisionModel: "gpt-4o"})
+	if err := ext.HealthCheck(ctx); err != nil {
+		t.Errorf("Expected health check to pass, got %v", err)
+	}
+
+	ext, _ = extractor.New(types.ExtractorConfig{OpenAIAPIKey: "bad", BaseURL: server.URL})
+	if err := ext.HealthCheck(ctx); err == nil || !strings.Contains(err.Error(), "credentials") {
+		t.Errorf("Expected a credentials error, got %v", err)
+	}
+
+	ext, _ = extractor.New(types.ExtractorConfig{OpenAIAPIKey: "good", BaseURL: server.URL, Model: "gpt-5-typo"})
+	if err := ext.HealthCheck(ctx); err == nil || !strings.Contains(err.Error(), "gpt-5-typo") {
+		t.Errorf("Expected an unavailable model error, got %v", err)
+	}
+
+	t.Run("readiness handler", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ext.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503, got %d", recorder.Code)
+		}
+
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "good", BaseURL: server.URL})
+		recorder = httptest.NewRecorder()
+		ext.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if recorder.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+	})
+}
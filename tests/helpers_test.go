@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestPdf builds a minimal PDF with one page per entry, each page showing the given lines of text
+func newTestPdf(pages ...[]string) []byte {
+	contents := make([]string, 0, len(pages))
+	for _, lines := range pages {
+		var content strings.Builder
+		content.WriteString("BT /F1 12 Tf 72 720 Td")
+		for _, line := range lines {
+			fmt.Fprintf(&content, " (%s) Tj 0 -16 Td", pdfString(line))
+		}
+		content.WriteString(" ET")
+		contents = append(contents, content.String())
+	}
+	return newTestPdfFromContent(contents...)
+}
+
+// pdfString escapes text for use in a PDF string literal
+func pdfString(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(text)
+}
+
+// newTestPdfFromContent builds a minimal PDF with one page per content stream. The streams can use
+// the fonts /F1 (Helvetica) and /F2 (Helvetica-Bold).
+func newTestPdfFromContent(contents ...string) []byte {
+	objects := []string{"", "", "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>", "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold >>"}
+	kids := make([]string, 0, len(contents))
+
+	for _, content := range contents {
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+		contentID := len(objects)
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> >>", contentID))
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objects)))
+	}
+
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+// testPdfSize returns the number of objects of a PDF built by newTestPdf, plus one for the free
+// object 0: the number of the next object to add
+func testPdfSize(pdf []byte) int {
+	matches := regexp.MustCompile(`/Size (\d+)`).FindAllStringSubmatch(string(pdf), -1)
+	size, _ := strconv.Atoi(matches[len(matches)-1][1])
+	return size
+}
+
+// testPageObject returns the number of the object of a page (1-indexed) of a PDF built by
+// newTestPdf, whose page objects follow the catalog, the page tree, two fonts and their content
+func testPageObject(page int) int {
+	return 4 + 2*page
+}
+
+// withObjects adds or replaces objects of a PDF built by newTestPdf, by number, in an incremental
+// update. New objects are numbered from testPdfSize on.
+func withObjects(pdf []byte, objects map[int]string) []byte {
+	matches := regexp.MustCompile(`startxref\n(\d+)`).FindAllStringSubmatch(string(pdf), -1)
+	previous := matches[len(matches)-1][1]
+	size := testPdfSize(pdf)
+
+	ids := make([]int, 0, len(objects))
+	for id := range objects {
+		ids = append(ids, id)
+		size = max(size, id+1)
+	}
+	sort.Ints(ids)
+
+	buf := bytes.NewBuffer(append([]byte(nil), pdf...))
+	offsets := make(map[int]int, len(ids))
+	for _, id := range ids {
+		offsets[id] = buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", id, objects[id])
+	}
+	xref := buf.Len()
+	buf.WriteString("xref\n0 1\n0000000000 65535 f \n")
+	for _, id := range ids {
+		fmt.Fprintf(buf, "%d 1\n%010d 00000 n \n", id, offsets[id])
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R /Prev %s >>\nstartxref\n%d\n%%%%EOF\n", size, previous, xref)
+	return buf.Bytes()
+}
+
+// withImage draws a grayscale image on a page of a PDF built by newTestPdf, in an incremental
+// update, with its bottom left corner at x, y and a width and height, all in points
+func withImage(pdf []byte, page int, img image.Image, x, y, width, height float64) []byte {
+	bounds := img.Bounds()
+	pixels := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			pixels = append(pixels, color.GrayModel.Convert(img.At(px, py)).(color.Gray).Y)
+		}
+	}
+	draw := fmt.Sprintf("q %g 0 0 %g %g %g cm /Im1 Do Q", width, height, x, y)
+	next := testPdfSize(pdf)
+	pageObject := testPageObject(page)
+	return withObjects(pdf, map[int]string{
+		next: fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Length %d >>\nstream\n%s\nendstream",
+			bounds.Dx(), bounds.Dy(), len(pixels), pixels),
+		next + 1: fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(draw), draw),
+		pageObject: fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents [%d 0 R %d 0 R] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> /XObject << /Im1 %d 0 R >> >> >>",
+			pageObject-1, next+1, next),
+	})
+}
+
+// mockServer is a fake OpenAI-compatible endpoint that answers every chat completion with a fixed payload
+type mockServer struct {
+	*httptest.Server
+	// Requests holds the decoded request bodies received by the server
+	Requests []map[string]interface{}
+	// Headers holds the headers of the requests received by the server
+	Headers []http.Header
+}
+
+// rawContent is a mock payload sent as the message content as is, instead of serialized as JSON
+type rawContent string
+
+// newMockServer starts a mock server replying with the given data serialized as the message content.
+// When several payloads are given, the i-th request is answered with the i-th payload and the
+// last payload is repeated for any further request.
+func newMockServer(t *testing.T, data interface{}, more ...interface{}) *mockServer {
+	t.Helper()
+
+	contents := make([]string, 0, 1+len(more))
+	for _, payload := range append([]interface{}{data}, more...) {
+		if raw, ok := payload.(rawContent); ok {
+			contents = append(contents, string(raw))
+			continue
+		}
+		content, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("failed to marshal mock response: %v", err)
+		}
+		contents = append(contents, string(content))
+	}
+
+	mock := &mockServer{}
+	mock.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request map[string]interface{}
+		_ = json.Unmarshal(body, &request)
+		mock.Requests = append(mock.Requests, request)
+		mock.Headers = append(mock.Headers, r.Header.Clone())
+		content := contents[min(len(mock.Requests), len(contents))-1]
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"model": request["model"],
+			"choices": []interface{}{
+				map[string]interface{}{
+					"message": map[string]interface{}{"content": content},
+				},
+			},
+			"usage": map[string]interface{}{"total_tokens": 42},
+		})
+	}))
+	t.Cleanup(mock.Close)
+
+	return mock
+}
+
+// userPrompt returns the text of the user message of the i-th recorded request
+func (m *mockServer) userPrompt(i int) string {
+	messages, _ := m.Requests[i]["messages"].([]interface{})
+	for _, message := range messages {
+		msg, _ := message.(map[string]interface{})
+		if msg["role"] != "user" {
+			continue
+		}
+		switch content := msg["content"].(type) {
+		case string:
+			return content
+		case []interface{}:
+			first, _ := content[0].(map[string]interface{})
+			text, _ := first["text"].(string)
+			return text
+		}
+	}
+	return ""
+}
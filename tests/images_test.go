@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// newStampImage returns an image black on its left half and white on its right half
+func newStampImage(width, height int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x >= width/2 {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img
+}
+
+// stampRegion is where newStampPdf draws the stamp, relative to the page size from the top-left
+// corner: 200 x 100 points at 100 points from the left and 392 points from the top of the page
+var stampRegion = types.Rect{X: 100.0 / 612, Y: 392.0 / 792, Width: 200.0 / 612, Height: 100.0 / 792}
+
+// newStampPdf builds a one-page PDF with a stamp image of 80 x 40 pixels drawn over 200 x 100
+// points, its bottom left corner at 100, 300 points
+func newStampPdf() []byte {
+	pdf := newTestPdf([]string{"Delivery note 4711 - received in good condition, see the stamp below"})
+	return withImage(pdf, 1, newStampImage(80, 40), 100, 300, 200, 100)
+}
+
+// meanGray returns the mean gray level of the left and right halves of an image
+func meanGray(img image.Image) (float64, float64) {
+	bounds := img.Bounds()
+	var left, right float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray := float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			if x-bounds.Min.X < bounds.Dx()/2 {
+				left += gray
+			} else {
+				right += gray
+			}
+		}
+	}
+	half := float64(bounds.Dx() * bounds.Dy() / 2)
+	return left / half, right / half
+}
+
+func TestExtractImages(t *testing.T) {
+	images, err := parser.ExtractImagesFromBuffer(newStampPdf())
+	if err != nil {
+		t.Fatalf("Expected images, got error: %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("Expected one image, got %d", len(images))
+	}
+	img := images[0]
+	if img.Page != 1 || img.Width != 80 || img.Height != 40 || !strings.HasPrefix(img.MimeType, "image/") {
+		t.Errorf("Expected an image of 80x40 pixels on page 1, got page %d, %dx%d, %s", img.Page, img.Width, img.Height, img.MimeType)
+	}
+
+	// The bounds are in points from the top-left corner of the page
+	want := types.Rect{X: 100, Y: 392, Width: 200, Height: 100}
+	for _, value := range [][2]float64{
+		{img.Bounds.X, want.X}, {img.Bounds.Y, want.Y}, {img.Bounds.Width, want.Width}, {img.Bounds.Height, want.Height},
+	} {
+		if math.Abs(value[0]-value[1]) > 1 {
+			t.Errorf("Expected bounds %+v, got %+v", want, img.Bounds)
+			break
+		}
+	}
+
+	decoded := decodeEmbeddedImage(t, img)
+	if bounds := decoded.Bounds(); bounds.Dx() != 80 || bounds.Dy() != 40 {
+		t.Errorf("Expected the decoded image to be 80x40 pixels, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if left, right := meanGray(decoded); left > 10 || right < 245 {
+		t.Errorf("Expected the image black on the left and white on the right, got mean gray levels %.0f and %.0f", left, right)
+	}
+
+	if _, err := parser.ExtractImagesFromBuffer([]byte("not a pdf")); err == nil {
+		t.Error("Expected error for an invalid PDF")
+	}
+}
+
+// decodeEmbeddedImage decodes the data of an embedded image
+func decodeEmbeddedImage(t *testing.T, img types.PdfEmbeddedImage) image.Image {
+	t.Helper()
+	if img.MimeType != "image/png" {
+		t.Fatalf("Expected a PNG image, got %s", img.MimeType)
+	}
+	return decodePageImage(t, img.Base64)
+}
+
+func TestCropPageImage(t *testing.T) {
+	pages, err := parser.RenderPdfToImages(newStampPdf())
+	if err != nil {
+		t.Fatalf("Expected page images, got error: %v", err)
+	}
+
+	crop, err := parser.CropPageImage(pages[0], stampRegion)
+	if err != nil {
+		t.Fatalf("Expected a crop, got error: %v", err)
+	}
+	img := decodePageImage(t, crop)
+	// 200 x 100 points at 300 DPI
+	if bounds := img.Bounds(); math.Abs(float64(bounds.Dx())-833) > 2 || math.Abs(float64(bounds.Dy())-417) > 2 {
+		t.Errorf("Expected a crop of about 833x417 pixels, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if left, right := meanGray(img); left > 30 || right < 225 {
+		t.Errorf("Expected the crop to hold the stamp, got mean gray levels %.0f and %.0f", left, right)
+	}
+
+	if _, err := parser.CropPageImage(pages[0], types.Rect{X: 1.5, Y: 1.5, Width: 0.1, Height: 0.1}); err == nil {
+		t.Error("Expected error for a region outside the page")
+	}
+}
+
+func TestDetectMarks(t *testing.T) {
+	pdf := newStampPdf()
+	mock := newMockServer(t, map[string]interface{}{
+		"hasSignature": false,
+		"hasStamp":     true,
+		"hasLogo":      false,
+		"marks": []map[string]interface{}{
+			{"kind": "stamp", "page": 1, "bounds": map[string]interface{}{"x": stampRegion.X, "y": stampRegion.Y, "width": stampRegion.Width, "height": stampRegion.Height}},
+			// A page the document does not have
+			{"kind": "logo", "page": 3, "bounds": map[string]interface{}{"x": 0.1, "y": 0.1, "width": 0.2, "height": 0.1}},
+		},
+	})
+
+	t.Run("Returns the marks with their crops", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, TextThreshold: 10})
+		result, err := ext.DetectMarks(context.Background(), pdf)
+		if err != nil {
+			t.Fatalf("Expected marks, got error: %v", err)
+		}
+		if result.HasSignature || !result.HasStamp || result.HasLogo {
+			t.Errorf("Expected a stamp only, got %+v", result)
+		}
+		if result.TokensUsed != 42 || result.Model != "gpt-4o-mini" {
+			t.Errorf("Expected the usage of the call, got %d tokens and model %q", result.TokensUsed, result.Model)
+		}
+		if len(result.Marks) != 1 {
+			t.Fatalf("Expected the mark on a missing page dropped, got %+v", result.Marks)
+		}
+		mark := result.Marks[0]
+		if mark.Kind != types.MarkKindStamp || mark.Page != 1 {
+			t.Errorf("Expected the stamp of page 1, got %+v", mark)
+		}
+		if left, right := meanGray(decodePageImage(t, mark.Base64)); left > 30 || right < 225 {
+			t.Errorf("Expected the crop of the stamp, got mean gray levels %.0f and %.0f", left, right)
+		}
+
+		// The page is sent as an image despite its text layer, with the built-in schema
+		request, _ := json.Marshal(mock.Requests[0])
+		if !strings.Contains(string(request), "image_url") || !strings.Contains(string(request), `"hasStamp"`) {
+			t.Errorf("Expected the page image and the mark schema in the request, got %.200s", request)
+		}
+		if prompt := mock.userPrompt(0); !strings.Contains(prompt, "signature, a stamp or seal, and a logo") {
+			t.Errorf("Expected the mark instructions in the prompt, got %q", prompt)
+		}
+	})
+
+	t.Run("Requires vision", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+		if _, err := ext.DetectMarks(context.Background(), pdf); err == nil || !strings.Contains(err.Error(), "requires vision") {
+			t.Errorf("Expected an error without vision, got %v", err)
+		}
+	})
+
+	t.Run("Invalid input type", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", VisionEnabled: true})
+		if _, err := ext.DetectMarks(context.Background(), 42); err == nil {
+			t.Error("Expected error for invalid input type")
+		}
+	})
+}
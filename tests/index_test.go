@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestIndexer(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"title": "Quarterly report"})
+	var documents []types.IndexDocument
+	indexer := types.IndexerFunc(func(ctx context.Context, document types.IndexDocument) error {
+		documents = append(documents, document)
+		return nil
+	})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Indexer: indexer})
+
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}}}
+	pdf := newTestPdf([]string{"Quarterly report for the first quarter"})
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+		t.Fatalf("Expected extraction to succeed, got error: %v", err)
+	}
+
+	if len(documents) != 1 {
+		t.Fatalf("Expected one indexed document, got %d", len(documents))
+	}
+	sum := sha256.Sum256(pdf)
+	document := documents[0]
+	if document.ID != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected the SHA-256 of the PDF as ID, got %q", document.ID)
+	}
+	if !strings.Contains(document.Text, "Quarterly report for the first quarter") || document.Data["title"] != "Quarterly report" || document.NumPages != 1 {
+		t.Errorf("Unexpected indexed document: %+v", document)
+	}
+
+	t.Run("batch document ID", func(t *testing.T) {
+		documents = nil
+		batch := []types.BatchDocument{{ID: "report", Options: types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}}}
+		for range ext.ExtractBatch(context.Background(), batch, types.BatchOptions{}) {
+		}
+		if len(documents) != 1 || documents[0].ID != "report" {
+			t.Errorf("Expected the batch ID as document ID, got %+v", documents)
+		}
+	})
+
+	t.Run("indexer error", func(t *testing.T) {
+		failing := types.IndexerFunc(func(ctx context.Context, document types.IndexDocument) error {
+			return errors.New("index unavailable")
+		})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Indexer: failing})
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, DocumentID: "report"})
+		if err == nil || !strings.Contains(err.Error(), "report") {
+			t.Errorf("Expected indexing error for the document, got %v", err)
+		}
+	})
+}
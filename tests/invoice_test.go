@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/einvoice"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestExtractInvoice(t *testing.T) {
+	pdf := newTestPdf([]string{
+		"INVOICE INV-2024-001 | 2024-03-15 | ACME GmbH DE123456789",
+		"Consulting 10 h x 100.00 = 1000.00 | VAT 19% 190.00 | Total EUR 1190.00",
+	})
+
+	party := func(name, vatID, country string) map[string]interface{} {
+		return map[string]interface{}{
+			"name": name, "vatId": vatID, "legalRegistrationId": "", "street": "Hauptstrasse 1",
+			"city": "Berlin", "postalCode": "10115", "countryCode": country,
+		}
+	}
+	mock := newMockServer(t, map[string]interface{}{
+		"number":                 "INV-2024-001",
+		"issueDate":              "2024-03-15",
+		"typeCode":               "",
+		"currency":               "EUR",
+		"dueDate":                "2024-04-14",
+		"buyerReference":         "",
+		"purchaseOrderReference": "PO-77",
+		"paymentTerms":           "30 days net",
+		"seller":                 party("ACME GmbH", "DE123456789", "DE"),
+		"buyer":                  party("Buyer AG", "", "DE"),
+		"paymentIban":            "DE89370400440532013000",
+		"paymentReference":       "INV-2024-001",
+		"lines": []map[string]interface{}{
+			{"id": "1", "name": "Consulting", "quantity": 10, "unitCode": "HUR", "netPrice": 100, "netAmount": 1000, "vatCategory": "S", "vatRate": 19},
+		},
+		"vatBreakdown": []map[string]interface{}{
+			{"category": "S", "rate": 19, "taxableAmount": 1000, "taxAmount": 190},
+		},
+		"lineTotal":         1000,
+		"taxExclusiveTotal": 1000,
+		"taxTotal":          190,
+		"taxInclusiveTotal": 1190,
+		"paidAmount":        0,
+		"payableAmount":     1190,
+	})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+
+	result, err := ext.ExtractInvoice(context.Background(), pdf)
+	if err != nil {
+		t.Fatalf("Expected invoice, got error: %v", err)
+	}
+	invoice := result.Invoice
+	if invoice.TypeCode != "380" {
+		t.Errorf("Expected default type code 380, got %q", invoice.TypeCode)
+	}
+	if invoice.Seller.VATID != "DE123456789" || invoice.PaymentIBAN == "" {
+		t.Errorf("Expected seller VAT ID and IBAN to be decoded, got %+v", invoice)
+	}
+	if len(invoice.Lines) != 1 || invoice.Lines[0].UnitCode != "HUR" {
+		t.Errorf("Expected one line in hours, got %+v", invoice.Lines)
+	}
+
+	t.Run("UBL", func(t *testing.T) {
+		out, err := einvoice.ToUBL(&invoice)
+		if err != nil {
+			t.Fatalf("Expected UBL, got error: %v", err)
+		}
+		assertWellFormed(t, out)
+		for _, want := range []string{
+			`<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"`,
+			`<cbc:CustomizationID>urn:cen.eu:en16931:2017</cbc:CustomizationID>`,
+			`<cbc:InvoiceTypeCode>380</cbc:InvoiceTypeCode>`,
+			`<cbc:DueDate>2024-04-14</cbc:DueDate>`,
+			`<cbc:InvoicedQuantity unitCode="HUR">10</cbc:InvoicedQuantity>`,
+			`<cbc:PayableAmount currencyID="EUR">1190.00</cbc:PayableAmount>`,
+			`<cbc:ID>DE89370400440532013000</cbc:ID>`,
+		} {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("Expected UBL to contain %s", want)
+			}
+		}
+	})
+
+	t.Run("CII", func(t *testing.T) {
+		out, err := einvoice.ToCII(&invoice)
+		if err != nil {
+			t.Fatalf("Expected CII, got error: %v", err)
+		}
+		assertWellFormed(t, out)
+		for _, want := range []string{
+			`<rsm:CrossIndustryInvoice`,
+			`<udt:DateTimeString format="102">20240315</udt:DateTimeString>`,
+			`<ram:ID schemeID="VA">DE123456789</ram:ID>`,
+			`<ram:DuePayableAmount>1190.00</ram:DuePayableAmount>`,
+		} {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("Expected CII to contain %s", want)
+			}
+		}
+	})
+
+	t.Run("Credit note", func(t *testing.T) {
+		creditNote := invoice
+		creditNote.TypeCode = "381"
+		out, err := einvoice.ToUBL(&creditNote)
+		if err != nil {
+			t.Fatalf("Expected UBL, got error: %v", err)
+		}
+		assertWellFormed(t, out)
+		for _, want := range []string{
+			`<CreditNote xmlns="urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"`,
+			`<cbc:CreditNoteTypeCode>381</cbc:CreditNoteTypeCode>`,
+			`<cac:CreditNoteLine>`,
+			`<cbc:CreditedQuantity unitCode="HUR">10</cbc:CreditedQuantity>`,
+			`<cbc:PaymentDueDate>2024-04-14</cbc:PaymentDueDate>`,
+		} {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("Expected the credit note to contain %s, got %s", want, out)
+			}
+		}
+		// The quantity of an invoice line and the due date of an invoice are not valid in a credit note
+		for _, unwanted := range []string{"InvoicedQuantity", "<cbc:DueDate>"} {
+			if strings.Contains(string(out), unwanted) {
+				t.Errorf("Expected no %s in the credit note, got %s", unwanted, out)
+			}
+		}
+	})
+
+	t.Run("Rejects missing business terms", func(t *testing.T) {
+		if _, err := einvoice.ToUBL(&types.Invoice{Currency: "EUR"}); err == nil {
+			t.Error("Expected error for invoice without number and parties")
+		}
+	})
+}
+
+// assertWellFormed fails the test when the document is not well-formed XML
+func assertWellFormed(t *testing.T, document []byte) {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(string(document)))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Expected well-formed XML, got error: %v", err)
+		}
+	}
+}
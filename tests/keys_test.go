@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestKeyProvider(t *testing.T) {
+	validKey := "key-1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+validKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"message":"invalid key"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"model":"gpt-4o-mini","choices":[{"message":{"content":"{\"total\":\"24.20\"}"}}],"usage":{"total_tokens":42}}`)
+	}))
+	defer server.Close()
+
+	fetches := 0
+	secret := "key-1"
+	provider := types.KeyProviderFunc(func(ctx context.Context) (string, error) {
+		fetches++
+		return secret, nil
+	})
+	ext, err := extractor.New(types.ExtractorConfig{BaseURL: server.URL, TextThreshold: 10, KeyProvider: provider})
+	if err != nil {
+		t.Fatalf("Expected extractor without static key, got error: %v", err)
+	}
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	options := types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Receipt", "Total 24.20"}), Schema: schema}
+
+	for i := 0; i < 2; i++ {
+		if _, err := ext.Extract(options); err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected the key to be fetched once and cached, got %d fetches", fetches)
+	}
+
+	// Rotate the key in the secrets manager and on the server
+	secret, validKey = "key-2", "key-2"
+	if _, err := ext.Extract(options); err != nil {
+		t.Fatalf("Expected the rotated key to be picked up, got %v", err)
+	}
+	if fetches != 2 {
+		t.Errorf("Expected the rejected key to be fetched again, got %d fetches", fetches)
+	}
+
+	failing, _ := extractor.New(types.ExtractorConfig{BaseURL: server.URL, TextThreshold: 10, KeyProvider: types.KeyProviderFunc(func(ctx context.Context) (string, error) {
+		return "", errors.New("vault sealed")
+	})})
+	if _, err := failing.Extract(options); err == nil || !strings.Contains(err.Error(), "vault sealed") {
+		t.Errorf("Expected the provider error, got %v", err)
+	}
+}
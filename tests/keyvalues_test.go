@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestExtractKeyValues(t *testing.T) {
+	pdf := newTestPdf([]string{
+		"Application form - Name: Jane Doe - Date of birth: 1990-04-12",
+		"Policy number: PX-0042 - Phone: - Signature: on file",
+	})
+
+	t.Run("Returns the pairs in order", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{
+			"pairs": []map[string]interface{}{
+				{"key": "Name", "value": "Jane Doe"},
+				{"key": "Date of birth", "value": "1990-04-12"},
+				{"key": "Policy number", "value": "PX-0042"},
+				{"key": "Phone", "value": ""},
+			},
+		})
+
+		ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		if err != nil {
+			t.Fatalf("Failed to create extractor: %v", err)
+		}
+
+		result, err := ext.ExtractKeyValues(context.Background(), pdf)
+		if err != nil {
+			t.Fatalf("Expected key-value pairs, got error: %v", err)
+		}
+
+		want := []types.KeyValuePair{
+			{Key: "Name", Value: "Jane Doe"},
+			{Key: "Date of birth", Value: "1990-04-12"},
+			{Key: "Policy number", Value: "PX-0042"},
+			{Key: "Phone", Value: ""},
+		}
+		if len(result.Pairs) != len(want) {
+			t.Fatalf("Expected %d pairs, got %+v", len(want), result.Pairs)
+		}
+		for i, pair := range want {
+			if result.Pairs[i] != pair {
+				t.Errorf("Expected pair %d to be %+v, got %+v", i, pair, result.Pairs[i])
+			}
+		}
+		if result.TokensUsed != 42 || result.Model != "gpt-4o-mini" {
+			t.Errorf("Expected the usage of the call, got %d tokens and model %q", result.TokensUsed, result.Model)
+		}
+
+		// The built-in schema is sent in place of one of the caller
+		request, _ := json.Marshal(mock.Requests[0]["response_format"])
+		for _, part := range []string{`"pairs"`, `"required":["key","value"]`, `"additionalProperties":false`} {
+			if !strings.Contains(string(request), part) {
+				t.Errorf("Expected %s in the request schema, got %s", part, request)
+			}
+		}
+		if prompt := mock.userPrompt(0); !strings.Contains(prompt, "label:value pair") || !strings.Contains(prompt, "Policy number: PX-0042") {
+			t.Errorf("Expected the instructions and the text in the prompt, got %q", prompt)
+		}
+	})
+
+	t.Run("Invalid data", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"pairs": "Name: Jane Doe"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		if _, err := ext.ExtractKeyValues(context.Background(), pdf); err == nil {
+			t.Error("Expected pairs of the wrong type to fail")
+		}
+	})
+
+	t.Run("Invalid input type", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test"})
+		if _, err := ext.ExtractKeyValues(context.Background(), 42); err == nil {
+			t.Error("Expected error for invalid input type")
+		}
+	})
+}
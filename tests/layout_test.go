@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestLayoutMode(t *testing.T) {
+	left := []string{"The left column starts the article", "and continues with its second line", "before it ends on the third line."}
+	right := []string{"The right column is read only after", "the left column, even though both", "columns share the same baselines."}
+
+	// Draw the columns row by row, interleaved, below a full-width title
+	var content strings.Builder
+	content.WriteString("BT /F1 16 Tf 72 740 Td (Two Column Article) Tj ET")
+	for i := range left {
+		y := 700 - 14*i
+		fmt.Fprintf(&content, " BT /F1 10 Tf 72 %d Td (%s) Tj ET", y, left[i])
+		fmt.Fprintf(&content, " BT /F1 10 Tf 320 %d Td (%s) Tj ET", y, right[i])
+	}
+	content.WriteString(" BT /F1 10 Tf 72 600 Td (Item) Tj 200 0 Td (Amount) Tj ET")
+	content.WriteString(" BT /F1 10 Tf 72 586 Td (Consulting) Tj 200 0 Td (1,500.00) Tj ET")
+
+	parsedPdf, err := parser.ParsePdfFromBuffer(newTestPdfFromContent(content.String()), &types.ParseOptions{LayoutMode: true})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	text := parsedPdf.Content.TextPages[0]
+
+	expected := "Two Column Article\n\n" + strings.Join(left, "\n") + "\n\n" + strings.Join(right, "\n")
+	if !strings.HasPrefix(text, expected) {
+		t.Errorf("Expected the columns in reading order, got:\n%s", text)
+	}
+	// 200pt at 5pt per character puts the second column 40 characters in
+	if !strings.HasSuffix(text, "Item"+strings.Repeat(" ", 36)+"Amount\nConsulting"+strings.Repeat(" ", 30)+"1,500.00") {
+		t.Errorf("Expected the table rows to keep their alignment, got:\n%s", text)
+	}
+}
+
+func TestReadColumns(t *testing.T) {
+	left := []string{"The left column starts the article", "and continues with its second line", "before it ends on the third line."}
+	right := []string{"The right column is read only after", "the left column, even though both", "columns share the same baselines."}
+
+	var content strings.Builder
+	content.WriteString("BT /F1 16 Tf 72 740 Td (Two Column Article) Tj ET")
+	for i := range left {
+		y := 700 - 14*i
+		fmt.Fprintf(&content, " BT /F1 10 Tf 72 %d Td (%s) Tj ET", y, left[i])
+		fmt.Fprintf(&content, " BT /F1 10 Tf 320 %d Td (%s) Tj ET", y, right[i])
+	}
+	pdf := newTestPdfFromContent(content.String(), "BT /F1 10 Tf 72 700 Td (A single column page with one line of text.) Tj ET")
+
+	columns, err := parser.DetectColumnsFromBuffer(pdf)
+	if err != nil {
+		t.Fatalf("Expected columns, got error: %v", err)
+	}
+	if len(columns) != 2 || columns[0] != 2 || columns[1] != 1 {
+		t.Errorf("Expected 2 columns on the first page and 1 on the second, got %v", columns)
+	}
+
+	plain, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ReadColumns: true})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	expected := "Two Column Article\n\n" + strings.Join(left, "\n") + "\n\n" + strings.Join(right, "\n")
+	if text := parsedPdf.Content.TextPages[0]; text != expected {
+		t.Errorf("Expected the columns in reading order, got:\n%s", text)
+	}
+	if parsedPdf.Content.TextPages[1] != plain.Content.TextPages[1] {
+		t.Errorf("Expected single-column pages to be kept as extracted, got %q instead of %q", parsedPdf.Content.TextPages[1], plain.Content.TextPages[1])
+	}
+}
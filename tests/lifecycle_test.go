@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// closingPublisher counts the events it receives and whether it was closed
+type closingPublisher struct {
+	events atomic.Int32
+	closed atomic.Bool
+}
+
+func (p *closingPublisher) Publish(ctx context.Context, event types.Event) error {
+	p.events.Add(1)
+	return nil
+}
+
+func (p *closingPublisher) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+		fmt.Fprint(w, `{"model":"gpt-4o-mini","choices":[{"message":{"content":"{\"total\":\"24.20\"}"}}],"usage":{"total_tokens":42}}`)
+	}))
+	defer server.Close()
+
+	publisher := &closingPublisher{}
+	ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test-key", BaseURL: server.URL, TextThreshold: 10, Publisher: types.MultiPublisher{publisher}})
+	if err != nil {
+		t.Fatalf("Failed to create extractor: %v", err)
+	}
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	options := types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Receipt", "Total 24.20"}), Schema: schema}
+
+	extracted := make(chan error, 1)
+	go func() {
+		_, err := ext.Extract(options)
+		extracted <- err
+	}()
+	<-received
+
+	// The extraction in flight outlives a short deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := ext.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to expire while the extraction is in flight, got %v", err)
+	}
+	if publisher.closed.Load() {
+		t.Error("Expected the publisher to stay open while the extraction is in flight")
+	}
+	if _, err := ext.Extract(options); !errors.Is(err, extractor.ErrClosed) {
+		t.Errorf("Expected new extractions to be rejected, got %v", err)
+	}
+
+	close(release)
+	if err := ext.Close(); err != nil {
+		t.Fatalf("Expected a clean shutdown, got %v", err)
+	}
+	if err := <-extracted; err != nil {
+		t.Errorf("Expected the extraction in flight to complete, got %v", err)
+	}
+	if publisher.events.Load() != 1 || !publisher.closed.Load() {
+		t.Errorf("Expected the completion event to be published before the publisher was closed, got %d events, closed %v", publisher.events.Load(), publisher.closed.Load())
+	}
+	if err := ext.Close(); err != nil {
+		t.Errorf("Expected closing again to succeed, got %v", err)
+	}
+}
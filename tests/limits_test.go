@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// objectSchema returns an object schema with the given number of string properties
+func objectSchema(count int) map[string]interface{} {
+	properties := make(map[string]interface{}, count)
+	for i := 0; i < count; i++ {
+		properties[fmt.Sprintf("field%04d", i)] = map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func TestCheckLimits(t *testing.T) {
+	nested := objectSchema(1)
+	for i := 0; i < 3; i++ {
+		nested = map[string]interface{}{"type": "object", "properties": map[string]interface{}{"child": nested}}
+	}
+	values := make([]interface{}, 300)
+	for i := range values {
+		values[i] = fmt.Sprintf("category-%03d", i)
+	}
+
+	tests := []struct {
+		name     string
+		schema   map[string]interface{}
+		limits   schema.Limits
+		expected string
+	}{
+		{
+			name: "Properties",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"items": map[string]interface{}{"type": "array", "items": objectSchema(120)}},
+			},
+			limits:   schema.Limits{MaxProperties: 100},
+			expected: "schema exceeds 100 properties at /properties/items/items",
+		},
+		{
+			name:     "Depth",
+			schema:   nested,
+			limits:   schema.Limits{MaxDepth: 3},
+			expected: "schema exceeds 3 levels of nesting at /properties/child/properties/child/properties/child",
+		},
+		{
+			name: "EnumValues",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"category": map[string]interface{}{"type": "string", "enum": values}},
+			},
+			limits:   schema.Limits{MaxEnumValues: 200},
+			expected: "schema exceeds 200 enum values at /properties/category",
+		},
+		{
+			name: "LargeEnum",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"category": map[string]interface{}{"type": "string", "enum": values}},
+			},
+			limits:   schema.Limits{LargeEnumValues: 250, MaxLargeEnumLength: 3000},
+			expected: "schema exceeds 3000 characters in an enum of more than 250 values at /properties/category",
+		},
+		{
+			name:     "StringLength",
+			schema:   objectSchema(50),
+			limits:   schema.Limits{MaxStringLength: 400},
+			expected: "schema exceeds 400 characters of names and values at /",
+		},
+		{
+			name:   "Within",
+			schema: objectSchema(50),
+			limits: schema.OpenAILimits,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.CheckLimits(tt.schema, tt.limits)
+			switch {
+			case tt.expected == "" && err != nil:
+				t.Errorf("Expected no error, got %v", err)
+			case tt.expected != "" && (err == nil || err.Error() != tt.expected):
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			case tt.expected != "" && !errors.Is(err, schema.ErrSchemaLimit):
+				t.Errorf("Expected an error matching ErrSchemaLimit, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSchemaLimitsPreflight(t *testing.T) {
+	pdf := newTestPdf([]string{"Invoice INV-14", "Total: 100.00"})
+	tooLarge := objectSchema(5001)
+
+	t.Run("OpenAI", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: tooLarge})
+		var limitErr *schema.LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != "properties" || limitErr.Max != 5000 {
+			t.Errorf("Expected a properties limit error, got %v", err)
+		}
+		if len(mock.Requests) != 0 {
+			t.Errorf("Expected no request, got %d", len(mock.Requests))
+		}
+	})
+
+	t.Run("Unchecked", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"field0000": "INV-14"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, SchemaLimits: &schema.Limits{}})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: tooLarge}); err != nil && strings.Contains(err.Error(), "schema exceeds") {
+			t.Errorf("Expected the limits unchecked, got %v", err)
+		}
+		if len(mock.Requests) != 1 {
+			t.Errorf("Expected the request sent, got %d requests", len(mock.Requests))
+		}
+	})
+}
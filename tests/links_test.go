@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestLinks(t *testing.T) {
+	pdf := withAnnotations(newTestPdf([]string{"Contact: jane.doe@exa...", "Portal: example.com/billing"}),
+		"/Subtype /Link /Rect [60 716 400 732] /A << /S /URI /URI (mailto:jane.doe@example.com) >>",
+		"/Subtype /Link /Rect [60 700 400 716] /A << /S /URI /URI (https://example.com/billing/invoices) >>",
+		"/Subtype /Link /Rect [60 600 400 616] /Dest [5 0 R /Fit]",
+		"/Subtype /Text /Contents (Not a link) /Rect [500 700 520 720]",
+	)
+
+	links, err := parser.ExtractLinksFromBuffer(pdf)
+	if err != nil {
+		t.Fatalf("Expected links, got error: %v", err)
+	}
+	expected := []types.Link{
+		{Page: 1, URI: "mailto:jane.doe@example.com", Text: "Contact: jane.doe@exa...", Bounds: types.Rect{X: 60, Y: 60, Width: 340, Height: 16}},
+		{Page: 1, URI: "https://example.com/billing/invoices", Text: "Portal: example.com/billing", Bounds: types.Rect{X: 60, Y: 76, Width: 340, Height: 16}},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected links %+v, got %+v", expected, links)
+	}
+
+	t.Run("Extraction", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"email": "jane.doe@example.com"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+		result, err := ext.Extract(types.ExtractionOptions{
+			PDFBuffer: pdf,
+			Schema:    map[string]interface{}{"type": "object", "properties": map[string]interface{}{"email": map[string]interface{}{"type": "string"}}},
+			Links:     true,
+		})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if !reflect.DeepEqual(result.Links, expected) {
+			t.Errorf("Expected links in the result, got %+v", result.Links)
+		}
+		if prompt := mock.userPrompt(0); !strings.Contains(prompt, `Page 1, "Contact: jane.doe@exa...": mailto:jane.doe@example.com`) {
+			t.Errorf("Expected the link targets in the prompt, got %q", prompt)
+		}
+	})
+}
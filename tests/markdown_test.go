@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// markdownTestContent is a page with a title, a section heading, a bold heading, a paragraph, a
+// list and a table
+const markdownTestContent = "BT /F1 20 Tf 72 740 Td (Quarterly Report) Tj ET " +
+	"BT /F1 15 Tf 72 700 Td (Summary) Tj ET " +
+	"BT /F1 10 Tf 72 680 Td (Revenue grew in every region this quarter) Tj 0 -12 Td (thanks to the new product line.) Tj ET " +
+	"BT /F1 10 Tf 72 640 Td (- Europe up 12%) Tj 0 -12 Td (- Asia up 8%) Tj ET " +
+	"BT /F2 10 Tf 72 600 Td (Figures) Tj ET " +
+	"BT /F1 10 Tf 72 580 Td (Region) Tj 150 0 Td (Revenue) Tj ET " +
+	"BT /F1 10 Tf 72 566 Td (Europe) Tj 150 0 Td (1,200) Tj ET " +
+	"BT /F1 10 Tf 72 552 Td (Asia | Pacific) Tj 150 0 Td (800) Tj ET"
+
+func TestToMarkdown(t *testing.T) {
+	markdown, err := parser.ToMarkdown(newTestPdfFromContent(markdownTestContent))
+	if err != nil {
+		t.Fatalf("Expected Markdown, got error: %v", err)
+	}
+
+	expected := "# Quarterly Report\n\n" +
+		"## Summary\n\n" +
+		"Revenue grew in every region this quarter\nthanks to the new product line.\n\n" +
+		"- Europe up 12%\n- Asia up 8%\n\n" +
+		"#### Figures\n\n" +
+		"| Region | Revenue |\n| --- | --- |\n| Europe | 1,200 |\n| Asia \\| Pacific | 800 |"
+	if markdown != expected {
+		t.Errorf("Unexpected Markdown:\n%s\n\nExpected:\n%s", markdown, expected)
+	}
+
+	t.Run("Markdown parse option", func(t *testing.T) {
+		parsedPdf, err := parser.ParsePdfFromBuffer(newTestPdfFromContent(markdownTestContent), &types.ParseOptions{Markdown: true})
+		if err != nil {
+			t.Fatalf("Expected parsed PDF, got error: %v", err)
+		}
+		if len(parsedPdf.Content.TextPages) != 1 || !strings.HasPrefix(parsedPdf.Content.TextPages[0], "# Quarterly Report") {
+			t.Errorf("Expected Markdown text pages, got %q", parsedPdf.Content.TextPages)
+		}
+		if !strings.Contains(parsedPdf.Content.TextContent, "| Europe | 1,200 |") {
+			t.Errorf("Expected Markdown text content, got %q", parsedPdf.Content.TextContent)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/metrics"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"title": "Quarterly report"})
+	collector := metrics.New()
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Publisher: types.MultiPublisher{collector}})
+
+	schema := map[string]interface{}{"title": "report", "type": "object", "properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}}}
+	pdf := newTestPdf([]string{"Quarterly report for the first quarter"})
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+		t.Fatalf("Expected extraction to succeed, got error: %v", err)
+	}
+	_, _ = ext.Extract(types.ExtractionOptions{PDFBuffer: []byte("not a pdf"), Schema: schema, SchemaName: `say "hi"`})
+
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK || !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("Unexpected response: %d %s", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+
+	body := recorder.Body.String()
+	for _, line := range []string{
+		`pdf_extractor_extractions_total{schema="report",status="completed"} 1`,
+		`pdf_extractor_extractions_total{schema="report",status="failed"} 0`,
+		`pdf_extractor_extractions_total{schema="say \"hi\"",status="failed"} 1`,
+		`pdf_extractor_extraction_duration_seconds_bucket{schema="report",le="+Inf"} 1`,
+		`pdf_extractor_extraction_duration_seconds_count{schema="report"} 1`,
+		`pdf_extractor_tokens_total{schema="report"} 42`,
+		"# TYPE pdf_extractor_extraction_duration_seconds histogram",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected %q in metrics:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsInFlight(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+		fmt.Fprint(w, `{"model":"gpt-4o-mini","choices":[{"message":{"content":"{\"title\":\"Quarterly report\"}"}}],"usage":{"total_tokens":42}}`)
+	}))
+	defer server.Close()
+
+	collector := metrics.New()
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: server.URL, TextThreshold: 10, Publisher: types.MultiPublisher{collector}})
+	scrape := func() string {
+		var body strings.Builder
+		if err := collector.Write(&body); err != nil {
+			t.Fatal(err)
+		}
+		return body.String()
+	}
+	if strings.Contains(scrape(), "pdf_extractor_in_flight") {
+		t.Error("Expected no in-flight gauge before TrackInFlight")
+	}
+	collector.TrackInFlight(ext.InFlight)
+	if body := scrape(); !strings.Contains(body, "# TYPE pdf_extractor_in_flight gauge\npdf_extractor_in_flight 0\n") {
+		t.Errorf("Expected no extraction in flight, got:\n%s", body)
+	}
+
+	schema := map[string]interface{}{"title": "report", "type": "object", "properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}}}
+	extracted := make(chan error, 1)
+	go func() {
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Quarterly report for the first quarter"}), Schema: schema})
+		extracted <- err
+	}()
+	<-received
+	if body := scrape(); !strings.Contains(body, "pdf_extractor_in_flight 1\n") {
+		t.Errorf("Expected one extraction in flight, got:\n%s", body)
+	}
+
+	close(release)
+	if err := <-extracted; err != nil {
+		t.Fatalf("Expected extraction to succeed, got error: %v", err)
+	}
+	if body := scrape(); !strings.Contains(body, "pdf_extractor_in_flight 0\n") {
+		t.Errorf("Expected no extraction in flight once it returned, got:\n%s", body)
+	}
+}
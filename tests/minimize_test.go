@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/feedback"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestMinimize(t *testing.T) {
+	pdf := newTestPdf(
+		[]string{"ACME GmbH", "Invoice number: INV-7781", "Issued in Berlin", "Reference code 4455", "Patient: Jane Roe", "Diagnosis: chronic condition", "Treatment plan attached", "Total amount: 950.00 EUR"},
+		[]string{"Terms and conditions apply", "Confidential appendix"},
+	)
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"invoiceNumber": map[string]interface{}{"type": "string"},
+			"totalAmount":   map[string]interface{}{"type": "number"},
+		},
+	}
+	data := map[string]interface{}{"invoiceNumber": "INV-7781", "totalAmount": 950}
+
+	t.Run("Relevant passages", func(t *testing.T) {
+		mock := newMockServer(t, data)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, Minimize: true}); err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		prompt := mock.userPrompt(0)
+		for _, text := range []string{"INV-7781", "Reference code 4455", "Treatment plan attached", "950.00 EUR", "[...]", "passages of the document relevant to the requested data"} {
+			if !strings.Contains(prompt, text) {
+				t.Errorf("Expected %q in the prompt, got %q", text, prompt)
+			}
+		}
+		for _, text := range []string{"Jane Roe", "Diagnosis", "Confidential appendix"} {
+			if strings.Contains(prompt, text) {
+				t.Errorf("Expected %q left out of the prompt, got %q", text, prompt)
+			}
+		}
+
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, Minimize: true, MinimizeAnchors: []string{"Diagnosis"}}); err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if prompt := mock.userPrompt(1); !strings.Contains(prompt, "Diagnosis: chronic condition") {
+			t.Errorf("Expected the passage of the anchor in the prompt, got %q", prompt)
+		}
+	})
+
+	t.Run("No relevant passage", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"vatId": ""})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{"vatId": map[string]interface{}{"type": "string"}}}, Minimize: true})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "no passage of the document mentions the fields of the schema") {
+			t.Errorf("Expected a warning, got %q", result.Warnings)
+		}
+		if strings.Contains(mock.userPrompt(0), "ACME") {
+			t.Errorf("Expected no text of the document sent, got %q", mock.userPrompt(0))
+		}
+	})
+
+	t.Run("No persistence", func(t *testing.T) {
+		mock := newMockServer(t, data)
+		indexed := 0
+		var logs bytes.Buffer
+		store := feedback.NewMemory()
+		ext, _ := extractor.New(types.ExtractorConfig{
+			OpenAIAPIKey:  "test",
+			BaseURL:       mock.URL,
+			TextThreshold: 10,
+			FeedbackStore: store,
+			DebugLogger:   slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+			Indexer: types.IndexerFunc(func(ctx context.Context, document types.IndexDocument) error {
+				indexed++
+				return nil
+			}),
+		})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, DocumentID: "inv-7781", Minimize: true, Audit: true})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if indexed != 0 {
+			t.Errorf("Expected the document not indexed, got %d calls", indexed)
+		}
+		if strings.Contains(logs.String(), "INV-7781") || !strings.Contains(logs.String(), "chat completion request") {
+			t.Errorf("Expected the requests logged without their body, got %s", logs.String())
+		}
+		record := result.Record
+		if record == nil || !strings.HasPrefix(record.UserPrompt, "sha256:") || !strings.HasPrefix(record.Calls[0].Messages[len(record.Calls[0].Messages)-1].Content, "sha256:") {
+			t.Errorf("Expected the prompts recorded by hash, got %+v", record)
+		}
+
+		if err := ext.RecordFeedback(context.Background(), "inv-7781", data); err != nil {
+			t.Fatalf("Expected feedback recorded, got error: %v", err)
+		}
+		records, _ := store.Records(context.Background())
+		if len(records) != 1 || records[0].Text != "" {
+			t.Errorf("Expected no text of the document kept for feedback, got %+v", records)
+		}
+	})
+
+	t.Run("Temporary files", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: "http://127.0.0.1:1", Renderer: types.RendererPdftoppm})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, Minimize: true}); err == nil || !strings.Contains(err.Error(), "temporary file") {
+			t.Errorf("Expected the renderer refused, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestMixedPages(t *testing.T) {
+	pdf := newTestPdf(
+		[]string{"Purchase order PO-981 issued by ACME Corporation", "Delivery to Main Street 1, Springfield"},
+		[]string{},
+		[]string{"Terms: payment within thirty days of the invoice date"},
+	)
+
+	parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextThreshold: 10})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	if parsedPdf.Content.Type != "text" {
+		t.Errorf("Expected the document to be read as text without MixedPages, got %q", parsedPdf.Content.Type)
+	}
+
+	parsedPdf, err = parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextThreshold: 10, MixedPages: true})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	content := parsedPdf.Content
+	if content.Type != "mixed" || !slices.Equal(content.PageTypes, []string{"text", "images", "text"}) {
+		t.Fatalf("Expected mixed content, got %q with pages %v", content.Type, content.PageTypes)
+	}
+	if len(content.ImageContent) != 1 || content.ImageContent[0].Page != 2 {
+		t.Errorf("Expected an image of the scanned page only, got %d images", len(content.ImageContent))
+	}
+	if len(content.TextPages) != 3 || content.TextPages[1] != "" || !strings.Contains(content.TextPages[2], "thirty days") {
+		t.Errorf("Unexpected text pages: %q", content.TextPages)
+	}
+
+	if pageTypes := parser.DetectPageTypes([]string{"Invoice INV-42 for consulting", " ", "p. 3"}, nil); !slices.Equal(pageTypes, []string{"text", "images", "images"}) {
+		t.Errorf("Unexpected page types: %v", pageTypes)
+	}
+
+	t.Run("Extraction", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"order_number": "PO-981", "signed": true})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, TextThreshold: 10})
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+			"order_number": map[string]interface{}{"type": "string"},
+			"signed":       map[string]interface{}{"type": "boolean"},
+		}}
+
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, MixedPages: true})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if result.Data["order_number"] != "PO-981" || len(mock.Requests) != 1 {
+			t.Errorf("Expected a single merged result, got %v from %d requests", result.Data, len(mock.Requests))
+		}
+
+		var request struct {
+			Messages []struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		data, _ := json.Marshal(mock.Requests[0])
+		json.Unmarshal(data, &request)
+		var parts []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		json.Unmarshal(request.Messages[len(request.Messages)-1].Content, &parts)
+		kinds := make([]string, 0, len(parts))
+		for _, part := range parts[1:] {
+			kinds = append(kinds, part.Type)
+		}
+		if !slices.Equal(kinds, []string{"text", "image_url", "text"}) || !strings.HasPrefix(parts[3].Text, "[Page 3]") {
+			t.Errorf("Expected the pages in order as text and images, got %v", kinds)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/mrz"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestMRZParse(t *testing.T) {
+	t.Run("TD3 passport specimen", func(t *testing.T) {
+		parsed, err := mrz.Parse([]string{
+			"P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<",
+			"L898902C36UTO7408122F1204159ZE184226B<<<<<10",
+		})
+		if err != nil {
+			t.Fatalf("Expected MRZ to parse, got error: %v", err)
+		}
+		if !parsed.Valid {
+			t.Errorf("Expected valid check digits, got errors: %v", parsed.Errors)
+		}
+		if parsed.Format != types.MRZFormatTD3 || parsed.DocumentNumber != "L898902C3" {
+			t.Errorf("Unexpected format or document number: %+v", parsed)
+		}
+		if parsed.Surname != "ERIKSSON" || parsed.GivenNames != "ANNA MARIA" {
+			t.Errorf("Unexpected names: %q %q", parsed.Surname, parsed.GivenNames)
+		}
+		if parsed.DateOfBirth != "1974-08-12" || parsed.ExpiryDate != "2012-04-15" || parsed.Sex != "F" {
+			t.Errorf("Unexpected dates or sex: %+v", parsed)
+		}
+	})
+
+	t.Run("TD1 identity card specimen", func(t *testing.T) {
+		parsed, err := mrz.Parse([]string{
+			"I<UTOD231458907<<<<<<<<<<<<<<<",
+			"7408122F1204159UTO<<<<<<<<<<<6",
+			"ERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+		})
+		if err != nil {
+			t.Fatalf("Expected MRZ to parse, got error: %v", err)
+		}
+		if !parsed.Valid {
+			t.Errorf("Expected valid check digits, got errors: %v", parsed.Errors)
+		}
+		if parsed.Format != types.MRZFormatTD1 || parsed.DocumentNumber != "D23145890" || parsed.Nationality != "UTO" {
+			t.Errorf("Unexpected fields: %+v", parsed)
+		}
+	})
+
+	t.Run("Detects OCR errors through check digits", func(t *testing.T) {
+		parsed, err := mrz.Parse([]string{
+			"P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<",
+			"L898962C36UTO7408122F1204159ZE184226B<<<<<10",
+		})
+		if err != nil {
+			t.Fatalf("Expected MRZ to parse, got error: %v", err)
+		}
+		if parsed.Valid || len(parsed.Errors) == 0 {
+			t.Error("Expected check digit mismatch for altered document number")
+		}
+	})
+
+	t.Run("Rejects unknown layouts", func(t *testing.T) {
+		if _, err := mrz.Parse([]string{"P<UTOERIKSSON"}); err == nil {
+			t.Error("Expected error for unknown layout")
+		}
+	})
+}
@@ -0,0 +1,33 @@
+//go:build nomupdf
+
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestPureGoBackend(t *testing.T) {
+	pdf := newTestPdf([]string{"Invoice INV-2024", "Total due 120.00"}, []string{"Second page"})
+
+	parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextThreshold: 10})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	if parsedPdf.Content.Type != "text" || parsedPdf.NumPages != 2 {
+		t.Fatalf("Expected a text PDF of 2 pages, got %s with %d pages", parsedPdf.Content.Type, parsedPdf.NumPages)
+	}
+	for _, expected := range []string{"Invoice INV-2024", "Total due 120.00", "Second page"} {
+		if !strings.Contains(parsedPdf.Content.TextContent, expected) {
+			t.Errorf("Expected text to contain %q, got %q", expected, parsedPdf.Content.TextContent)
+		}
+	}
+
+	if _, err := parser.RenderPdfToImages(pdf); !errors.Is(err, parser.ErrRenderingUnavailable) {
+		t.Errorf("Expected ErrRenderingUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestNormalizeText(t *testing.T) {
+	text := "The  contract between the\nparties is governed by inter-\nnational law.\n\n\n\nTotal:\n  1 200,00   EUR  \nSee section 4 -\nPayment terms\nWell-\nKnown"
+	want := "The contract between the parties is governed by international law.\n\nTotal:\n1 200,00 EUR\nSee section 4 -\nPayment terms\nWell-\nKnown"
+	if got := parser.NormalizeText(text); got != want {
+		t.Errorf("Unexpected normalized text:\n%q\nwant:\n%q", got, want)
+	}
+
+	t.Run("parse option", func(t *testing.T) {
+		pdf := newTestPdf([]string{"Payment is due within thirty days of the", "invoice date, without any deduc-", "tion whatsoever."})
+		parsed, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{NormalizeText: true, TextThreshold: 10})
+		if err != nil {
+			t.Fatalf("Expected parsed PDF, got error: %v", err)
+		}
+		if !strings.Contains(parsed.Content.TextContent, "Payment is due within thirty days of the invoice date, without any deduction whatsoever.") {
+			t.Errorf("Expected the sentence to be repaired, got %q", parsed.Content.TextContent)
+		}
+	})
+}
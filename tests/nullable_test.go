@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestNullableFields(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"invoice_number": map[string]interface{}{"type": "string"},
+			"seller": map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+				"vat_id": map[string]interface{}{"type": "string"},
+			}},
+			"currency": map[string]interface{}{"type": "string", "enum": []string{"EUR", "USD"}},
+		},
+		"required": []string{"invoice_number", "seller", "currency"},
+	}
+	mock := newMockServer(t, map[string]interface{}{
+		"invoice_number": "INV-42",
+		"seller":         map[string]interface{}{"vat_id": "ES12345678"},
+		"currency":       nil,
+		"_field_status":  map[string]interface{}{"seller.vat_id": "illegible", "currency": "not_present"},
+	})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+	pdf := newTestPdf([]string{"Invoice INV-42", "VAT ID smudged"})
+
+	result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, NullableFields: []string{"seller.vat_id", "currency", "invoice_number"}})
+	if err != nil {
+		t.Fatalf("Expected extraction to succeed, got %v", err)
+	}
+	expected := map[string]string{"seller.vat_id": types.FieldStatusIllegible, "currency": types.FieldStatusNotPresent, "invoice_number": types.FieldStatusFound}
+	for field, status := range expected {
+		if result.FieldStatus[field] != status {
+			t.Errorf("Expected %s to be %s, got %q", field, status, result.FieldStatus[field])
+		}
+	}
+	if result.Data["seller"].(map[string]interface{})["vat_id"] != nil || result.Data["invoice_number"] != "INV-42" {
+		t.Errorf("Expected the illegible field to be null, got %v", result.Data)
+	}
+	if _, ok := result.Data["_field_status"]; ok {
+		t.Error("Expected the statuses to be removed from the data")
+	}
+
+	request, _ := json.Marshal(mock.Requests[0]["response_format"])
+	for _, part := range []string{`"type":["string","null"]`, `"enum":["EUR","USD",null]`, `"_field_status"`} {
+		if !strings.Contains(string(request), part) {
+			t.Errorf("Expected %s in the request schema, got %s", part, request)
+		}
+	}
+	if schema["properties"].(map[string]interface{})["currency"].(map[string]interface{})["type"] != "string" {
+		t.Error("Expected the schema of the options to be left untouched")
+	}
+
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, NullableFields: []string{"total"}}); err == nil {
+		t.Error("Expected an error for a nullable field not in the schema")
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestNumbers(t *testing.T) {
+	t.Run("Decimal separator", func(t *testing.T) {
+		for text, expected := range map[string]string{
+			"Gesamtbetrag 1.234,56 EUR\nVersand 4,90 EUR": ",",
+			"Total $1,234.56\nShipping 4.90":              ".",
+			"Population 1.234.567":                        ",",
+			"Quantity 1.500\nPage 1 of 2":                 ".",
+		} {
+			if separator := parser.DetectDecimalSeparator(text); separator != expected {
+				t.Errorf("Expected %q for %q, got %q", expected, text, separator)
+			}
+		}
+	})
+
+	t.Run("Parse", func(t *testing.T) {
+		for _, test := range []struct {
+			text, separator string
+			expected        float64
+		}{
+			{"1.234,56", ",", 1234.56},
+			{"1'234.56 CHF", ".", 1234.56},
+			{"(1,250.00)", ".", -1250},
+			{"1 234,5 €", ",", 1234.5},
+			{"1.234.567", ".", 1234567},
+			{"150,00-", ",", -150},
+		} {
+			if value, err := parser.ParseNumber(test.text, test.separator); err != nil || value != test.expected {
+				t.Errorf("Expected %q to be %g, got %g (%v)", test.text, test.expected, value, err)
+			}
+		}
+		if _, err := parser.ParseNumber("n/a", "."); err == nil {
+			t.Error("Expected an error for a value without digits")
+		}
+	})
+
+	t.Run("Extraction", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"total": "1.234,56", "quantity": 1.5, "shipping": 4.9})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{
+			PDFBuffer: newTestPdf([]string{"Rechnung RE-17", "Gesamtbetrag 1.234,56 EUR", "Menge 1.500 Stück", "Versand 4,90 EUR"}),
+			Schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"total":    map[string]interface{}{"type": "number"},
+					"quantity": map[string]interface{}{"type": "integer"},
+					"shipping": map[string]interface{}{"type": "number"},
+				},
+			},
+			CheckNumbers: true,
+		})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if expected := map[string]interface{}{"total": 1234.56, "quantity": 1500.0, "shipping": 4.9}; !reflect.DeepEqual(result.Data, expected) {
+			t.Errorf("Expected data %v, got %v", expected, result.Data)
+		}
+		if len(result.Warnings) != 2 || !strings.HasPrefix(result.Warnings[0], "quantity = 1.5 corrected to 1500") || !strings.HasPrefix(result.Warnings[1], `total = "1.234,56" parsed as 1234.56`) {
+			t.Errorf("Expected a warning per corrected value, got %q", result.Warnings)
+		}
+	})
+}
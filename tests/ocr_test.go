@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/ocr"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestAzureOCR(t *testing.T) {
+	polls := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Ocp-Apim-Subscription-Key") != "azure-key" {
+			t.Errorf("Expected the API key header, got %q", r.Header.Get("Ocp-Apim-Subscription-Key"))
+		}
+		if r.Method == http.MethodPost {
+			if r.URL.Path != "/documentintelligence/documentModels/prebuilt-read:analyze" {
+				t.Errorf("Unexpected analyze path %q", r.URL.Path)
+			}
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["base64Source"] == "" {
+				t.Error("Expected the document in base64Source")
+			}
+			w.Header().Set("Operation-Location", server.URL+"/operations/1")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		polls++
+		if polls == 1 {
+			fmt.Fprint(w, `{"status":"running"}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":"succeeded","analyzeResult":{"pages":[{"pageNumber":1,"width":8.5,"height":11,"unit":"inch",
+			"lines":[{"content":"Invoice INV-42","polygon":[1,1,3,1,3,1.5,1,1.5],"spans":[{"offset":0,"length":14}]}],
+			"words":[{"content":"Invoice","polygon":[1,1,2,1,2,1.5,1,1.5],"span":{"offset":0,"length":7}},
+				{"content":"INV-42","polygon":[2.2,1,3,1,3,1.5,2.2,1.5],"span":{"offset":8,"length":6}}]}]}}`)
+	}))
+	defer server.Close()
+
+	provider, err := ocr.NewAzure(ocr.AzureConfig{Endpoint: server.URL, APIKey: "azure-key", PollInterval: 1})
+	if err != nil {
+		t.Fatalf("Expected provider, got error: %v", err)
+	}
+	parser.RegisterBackend(ocr.NewBackend("azure", provider, 0))
+
+	parsedPdf, err := parser.ParsePdfFromBuffer(newTestPdf([]string{}), &types.ParseOptions{TextBackend: "azure", TextLayout: true, TextThreshold: 1})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	if polls != 2 {
+		t.Errorf("Expected the analysis to be polled twice, got %d", polls)
+	}
+	if parsedPdf.Content.Type != "text" || strings.TrimSpace(parsedPdf.Content.TextContent) != "Invoice INV-42" {
+		t.Errorf("Expected the recognized text, got %q", parsedPdf.Content.TextContent)
+	}
+	if len(parsedPdf.Layout) != 1 || len(parsedPdf.Layout[0].Lines) != 1 {
+		t.Fatalf("Expected the recognized layout, got %+v", parsedPdf.Layout)
+	}
+	line := parsedPdf.Layout[0].Lines[0]
+	if parsedPdf.Layout[0].Width != 612 || line.Bounds.X != 72 || line.Bounds.Width != 144 || len(line.Words) != 2 || line.Words[1].Text != "INV-42" {
+		t.Errorf("Expected the layout in points, got %+v", parsedPdf.Layout[0])
+	}
+
+	t.Run("Scanned layout", func(t *testing.T) {
+		parser.RegisterBackend(paperBackend{})
+		parsed, err := parser.ParsePdfFromBuffer(newTestPdf([]string{}), &types.ParseOptions{OCRBackend: "azure", Renderer: "paper", TextLayout: true})
+		if err != nil {
+			t.Fatalf("Expected parsed PDF, got error: %v", err)
+		}
+		if parsed.Content.Type != "images" || len(parsed.Layout) != 1 || len(parsed.Layout[0].Lines) != 1 || parsed.Layout[0].Lines[0].Text != "Invoice INV-42" {
+			t.Errorf("Expected the layout recognized by the OCR backend for the scanned page, got %+v", parsed.Layout)
+		}
+	})
+
+	t.Run("Augment vision", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"invoice_number": "INV-42"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, OCRBackend: "azure"})
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"invoice_number": map[string]interface{}{"type": "string"}}}
+
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{}), Schema: schema}); err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		request, _ := json.Marshal(mock.Requests[0])
+		if !strings.Contains(string(request), "image_url") || !strings.Contains(string(request), "--- Page 1 ---\\nInvoice INV-42") {
+			t.Errorf("Expected the page images along with the recognized text, got %s", request)
+		}
+
+		// Minimized extractions send the recognized text only
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{}), Schema: schema, Minimize: true}); err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		request, _ = json.Marshal(mock.Requests[1])
+		if strings.Contains(string(request), "image_url") || !strings.Contains(string(request), "Invoice INV-42") {
+			t.Errorf("Expected the recognized text without the page images, got %s", request)
+		}
+	})
+}
+
+func TestDocumentAIOCR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/acme/locations/eu/processors/ocr-1:process" {
+			t.Errorf("Unexpected process path %q", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer google-token" {
+			t.Errorf("Expected the access token, got %q", r.Header.Get("Authorization"))
+		}
+		fmt.Fprint(w, `{"document":{"text":"Total 10.00\n","pages":[{"pageNumber":1,
+			"lines":[{"layout":{"textAnchor":{"textSegments":[{"endIndex":"12"}]},"boundingPoly":{"normalizedVertices":[{"x":0.1,"y":0.5},{"x":0.5,"y":0.5},{"x":0.5,"y":0.55},{"x":0.1,"y":0.55}]}}}],
+			"tokens":[{"layout":{"textAnchor":{"textSegments":[{"endIndex":"6"}]},"boundingPoly":{"normalizedVertices":[{"x":0.1,"y":0.5},{"x":0.3,"y":0.55}]}}},
+				{"layout":{"textAnchor":{"textSegments":[{"startIndex":"6","endIndex":"12"}]},"boundingPoly":{"normalizedVertices":[{"x":0.35,"y":0.5},{"x":0.5,"y":0.55}]}}}]}]}}`)
+	}))
+	defer server.Close()
+
+	provider, err := ocr.NewDocumentAI(ocr.DocumentAIConfig{
+		ProjectID: "acme", Location: "eu", ProcessorID: "ocr-1", Endpoint: server.URL,
+		Token: func(context.Context) (string, error) { return "google-token", nil },
+	})
+	if err != nil {
+		t.Fatalf("Expected provider, got error: %v", err)
+	}
+
+	layouts, err := ocr.NewBackend("documentai", provider, 0).ExtractLayout(newTestPdf([]string{}))
+	if err != nil {
+		t.Fatalf("Expected layout, got error: %v", err)
+	}
+	if len(layouts) != 1 || len(layouts[0].Lines) != 1 {
+		t.Fatalf("Expected one line, got %+v", layouts)
+	}
+	line := layouts[0].Lines[0]
+	if line.Text != "Total 10.00" || len(line.Words) != 2 || line.Words[1].Text != "10.00" {
+		t.Errorf("Unexpected line: %+v", line)
+	}
+	if line.Bounds.X != 0.1*layouts[0].Width || line.Bounds.Y != 0.5*layouts[0].Height {
+		t.Errorf("Expected the bounds scaled to the page, got %+v", line.Bounds)
+	}
+}
+
+func TestTextractOCR(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Amz-Target") != "Textract.DetectDocumentText" {
+			t.Errorf("Unexpected target %q", r.Header.Get("X-Amz-Target"))
+		}
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/textract/aws4_request") {
+			t.Errorf("Expected a signed request, got %q", auth)
+		}
+		fmt.Fprintf(w, `{"Blocks":[{"BlockType":"PAGE","Id":"p"},
+			{"BlockType":"LINE","Id":"l","Text":"Page %d","Geometry":{"BoundingBox":{"Left":0.1,"Top":0.1,"Width":0.2,"Height":0.05}},"Relationships":[{"Type":"CHILD","Ids":["w1","w2"]}]},
+			{"BlockType":"WORD","Id":"w1","Text":"Page","Geometry":{"BoundingBox":{"Left":0.1,"Top":0.1,"Width":0.1,"Height":0.05}}},
+			{"BlockType":"WORD","Id":"w2","Text":"%d","Geometry":{"BoundingBox":{"Left":0.25,"Top":0.1,"Width":0.05,"Height":0.05}}}]}`, requests, requests)
+	}))
+	defer server.Close()
+
+	provider, err := ocr.NewTextract(ocr.TextractConfig{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("Expected provider, got error: %v", err)
+	}
+
+	pages, err := ocr.NewBackend("textract", provider, 0).ExtractText(newTestPdf([]string{}, []string{}))
+	if err != nil {
+		t.Fatalf("Expected text, got error: %v", err)
+	}
+	if requests != 2 || len(pages) != 2 || pages[0] != "Page 1" || pages[1] != "Page 2" {
+		t.Errorf("Expected one request per page, got %d requests and %q", requests, pages)
+	}
+
+	if _, err := ocr.NewTextract(ocr.TextractConfig{Region: "eu-west-1"}); err == nil {
+		t.Error("Expected an error without credentials")
+	}
+}
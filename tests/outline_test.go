@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// withOutline adds an outline to a PDF built by newTestPdf, replacing its catalog
+func withOutline(pdf []byte, outline []types.OutlineItem) []byte {
+	objects := map[int]string{}
+	next := testPdfSize(pdf)
+	var add func(items []types.OutlineItem, parent int) (first, last int)
+	add = func(items []types.OutlineItem, parent int) (first, last int) {
+		ids := make([]int, len(items))
+		for i := range items {
+			ids[i] = next
+			next++
+		}
+		for i, item := range items {
+			object := fmt.Sprintf("<< /Title (%s) /Parent %d 0 R /Dest [%d 0 R /Fit]", pdfString(item.Title), parent, testPageObject(item.Page))
+			if i > 0 {
+				object += fmt.Sprintf(" /Prev %d 0 R", ids[i-1])
+			}
+			if i < len(items)-1 {
+				object += fmt.Sprintf(" /Next %d 0 R", ids[i+1])
+			}
+			if len(item.Children) > 0 {
+				childFirst, childLast := add(item.Children, ids[i])
+				object += fmt.Sprintf(" /First %d 0 R /Last %d 0 R /Count %d", childFirst, childLast, len(item.Children))
+			}
+			objects[ids[i]] = object + " >>"
+		}
+		return ids[0], ids[len(ids)-1]
+	}
+	root := next
+	next++
+	first, last := add(outline, root)
+	objects[root] = fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>", first, last, len(outline))
+	objects[1] = fmt.Sprintf("<< /Type /Catalog /Pages 2 0 R /Outlines %d 0 R >>", root)
+	return withObjects(pdf, objects)
+}
+
+func TestOutline(t *testing.T) {
+	outline := []types.OutlineItem{
+		{Title: "1. Definitions", Page: 1},
+		{Title: "2. Services", Page: 2, Children: []types.OutlineItem{
+			{Title: "2.1 Scope", Page: 2},
+			{Title: "2.2 Support", Page: 3},
+		}},
+		{Title: "3. Fees", Page: 4},
+	}
+	pdf := withOutline(newTestPdf(
+		[]string{"1. Definitions. Terms used in this agreement."},
+		[]string{"2. Services. The provider delivers hosting."},
+		[]string{"2.2 Support. Support is available on business days."},
+		[]string{"3. Fees. The customer pays 100 EUR per month."},
+	), outline)
+
+	t.Run("Read", func(t *testing.T) {
+		items, err := parser.ExtractOutlineFromBuffer(pdf)
+		if err != nil {
+			t.Fatalf("Expected outline, got error: %v", err)
+		}
+		if !reflect.DeepEqual(items, outline) {
+			t.Errorf("Expected outline %+v, got %+v", outline, items)
+		}
+
+		parsed, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextThreshold: 10, Outline: true})
+		if err != nil {
+			t.Fatalf("Expected parsed PDF, got error: %v", err)
+		}
+		if !reflect.DeepEqual(parsed.Outline, outline) {
+			t.Errorf("Expected outline in the parsed PDF, got %+v", parsed.Outline)
+		}
+	})
+
+	t.Run("No outline", func(t *testing.T) {
+		items, err := parser.ExtractOutlineFromBuffer(newTestPdf([]string{"No bookmarks here."}))
+		if err != nil || items != nil {
+			t.Errorf("Expected no outline, got %+v, %v", items, err)
+		}
+	})
+
+	t.Run("Chunk by outline", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{
+			"parties": []map[string]interface{}{}, "clauses": []map[string]interface{}{}, "obligations": []map[string]interface{}{},
+			"effectiveDate":   map[string]interface{}{"value": "", "pages": []int{}},
+			"terminationDate": map[string]interface{}{"value": "", "pages": []int{}},
+			"renewalTerms":    map[string]interface{}{"value": "", "pages": []int{}},
+		})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+		result, err := ext.AnalyzeContract(context.Background(), pdf, types.ContractOptions{ChunkByOutline: true})
+		if err != nil {
+			t.Fatalf("Expected contract analysis, got error: %v", err)
+		}
+		if result.Chunks != 3 || len(mock.Requests) != 3 {
+			t.Fatalf("Expected one request per top-level section, got %d chunks and %d requests", result.Chunks, len(mock.Requests))
+		}
+		for i, want := range []struct {
+			title string
+			pages []string
+		}{
+			{"1. Definitions", []string{"[Page 1]"}},
+			{"2. Services", []string{"[Page 2]", "[Page 3]"}},
+			{"3. Fees", []string{"[Page 4]"}},
+		} {
+			if !strings.Contains(mock.userPrompt(i), want.title) {
+				t.Errorf("Expected request %d to name the section %q", i, want.title)
+			}
+			for _, marker := range want.pages {
+				if !strings.Contains(mock.userPrompt(i), marker) {
+					t.Errorf("Expected request %d to hold %s, got %q", i, marker, mock.userPrompt(i))
+				}
+			}
+			if markers := regexp.MustCompile(`\[Page \d+\]`).FindAllString(mock.userPrompt(i), -1); len(markers) != len(want.pages) {
+				t.Errorf("Expected request %d to hold the pages of its section only, got %q", i, mock.userPrompt(i))
+			}
+		}
+
+		// Without an outline, contracts are chunked by size
+		result, err = ext.AnalyzeContract(context.Background(), newTestPdf([]string{"A short contract without bookmarks."}), types.ContractOptions{ChunkByOutline: true})
+		if err != nil || result.Chunks != 1 {
+			t.Errorf("Expected a single chunk without an outline, got %+v, %v", result, err)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestPerCallOverrides(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	mock := newMockServer(t, map[string]interface{}{"total": "24.20"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true})
+	pdf := newTestPdf([]string{"Receipt", "Total 24.20"})
+
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, TextThreshold: 10}); err != nil {
+		t.Fatalf("Expected extraction to succeed, got %v", err)
+	}
+	if !strings.Contains(mock.userPrompt(0), "Total 24.20") {
+		t.Errorf("Expected the lower threshold to read the short text as text, got %q", mock.userPrompt(0))
+	}
+
+	disabled := false
+	_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, VisionEnabled: &disabled})
+	if err == nil || !strings.Contains(err.Error(), "vision mode is disabled") {
+		t.Errorf("Expected vision to be disabled for the call, got %v", err)
+	}
+
+	noVision, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+	enabled := true
+	if _, err := noVision.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, VisionEnabled: &enabled}); err != nil {
+		t.Errorf("Expected vision to be enabled for the call, got %v", err)
+	}
+	if len(mock.Requests) != 2 {
+		t.Errorf("Expected 2 requests, got %d", len(mock.Requests))
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestExtractPaper(t *testing.T) {
+	pdf := newTestPdf(
+		[]string{"Attention Is All You Need", "A. Vaswani, N. Shazeer - Google Brain", "Abstract: The dominant sequence transduction models are based on recurrent networks."},
+		[]string{"1. Introduction", "Recurrent neural networks have been firmly established [1].", "References", "[1] S. Hochreiter and J. Schmidhuber. Long short-term memory. Neural Computation, 1997. https://doi.org/10.1162/neco.1997.9.8.1735."},
+	)
+
+	mock := newMockServer(t,
+		map[string]interface{}{
+			"title":    "Attention Is All You Need",
+			"authors":  []map[string]interface{}{{"name": "A. Vaswani", "affiliation": "Google Brain", "email": ""}},
+			"abstract": "The dominant sequence transduction models are based on recurrent networks.",
+			"keywords": []string{}, "doi": "", "venue": "", "year": 2017,
+		},
+		map[string]interface{}{
+			"references": []map[string]interface{}{{
+				"raw":     "[1] S. Hochreiter and J. Schmidhuber. Long short-term memory. Neural Computation, 1997. https://doi.org/10.1162/neco.1997.9.8.1735.",
+				"authors": []string{"S. Hochreiter", "J. Schmidhuber"},
+				"title":   "Long short-term memory", "year": 1997, "venue": "Neural Computation", "doi": "",
+			}},
+		},
+	)
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+	result, err := ext.ExtractPaper(context.Background(), pdf)
+	if err != nil {
+		t.Fatalf("Expected paper, got error: %v", err)
+	}
+
+	if len(mock.Requests) != 2 {
+		t.Fatalf("Expected a metadata and a references request, got %d requests", len(mock.Requests))
+	}
+	references := mock.userPrompt(1)
+	if strings.Contains(references, "Introduction") || !strings.Contains(references, "Hochreiter") {
+		t.Errorf("Expected only the reference section to be sent for citation parsing, got %q", references)
+	}
+
+	paper := result.Paper
+	if paper.Title != "Attention Is All You Need" || paper.Year != 2017 {
+		t.Errorf("Unexpected metadata: %+v", paper)
+	}
+	if len(paper.References) != 1 {
+		t.Fatalf("Expected one reference, got %+v", paper.References)
+	}
+	if doi := paper.References[0].DOI; doi != "10.1162/neco.1997.9.8.1735" {
+		t.Errorf("Expected DOI recovered from the printed reference, got %q", doi)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build parquet
+
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parquet"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestWriteParquet(t *testing.T) {
+	results := []types.BatchResult{
+		{ID: "invoice.pdf", Status: types.BatchStatusSuccess, Result: &types.ExtractionResult{TokensUsed: 42, Data: map[string]interface{}{
+			"number":    "INV-1",
+			"lineItems": []interface{}{map[string]interface{}{"amount": 10.5}, map[string]interface{}{"amount": 3}},
+		}}},
+		{ID: "broken.pdf", Status: types.BatchStatusError, Err: errors.New("invalid PDF")},
+	}
+
+	var buf bytes.Buffer
+	if err := parquet.WriteResults(&buf, results, types.FlattenConfig{Explode: "lineItems"}); err != nil {
+		t.Fatalf("Expected parquet file, got error: %v", err)
+	}
+
+	file := buf.Bytes()
+	if !bytes.HasPrefix(file, []byte("PAR1")) || !bytes.HasSuffix(file, []byte("PAR1")) {
+		t.Fatal("Expected parquet magic bytes at both ends of the file")
+	}
+	footerLength := int(binary.LittleEndian.Uint32(file[len(file)-8 : len(file)-4]))
+	if footerLength <= 0 || footerLength > len(file)-12 {
+		t.Fatalf("Unexpected footer length %d for a %d byte file", footerLength, len(file))
+	}
+	footer := file[len(file)-8-footerLength : len(file)-8]
+	for _, column := range []string{"documentId", "status", "error", "tokensUsed", "data.number", "data.lineItems.amount"} {
+		if !bytes.Contains(footer, []byte(column)) {
+			t.Errorf("Expected column %q in the file schema", column)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// countingBackend reads a fixed text and counts how many times documents are read
+type countingBackend struct {
+	reads *int
+}
+
+func (b countingBackend) Name() string { return "counting" }
+
+func (b countingBackend) ExtractText([]byte) ([]string, error) {
+	*b.reads++
+	return []string{"Invoice INV-42 issued by ACME Corporation, total 24.20 EUR"}, nil
+}
+
+func TestPreParsedPdf(t *testing.T) {
+	reads := 0
+	parser.RegisterBackend(countingBackend{reads: &reads})
+	mock := newMockServer(t, map[string]interface{}{"number": "INV-42"}, map[string]interface{}{"total": "24.20"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, TextBackend: "counting"})
+	pdf := newTestPdf([]string{"Invoice"})
+
+	parsedPdf, err := ext.Parse(types.ExtractionOptions{PDFBuffer: pdf})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+
+	schemas := []map[string]interface{}{
+		{"type": "object", "properties": map[string]interface{}{"number": map[string]interface{}{"type": "string"}}},
+		{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}},
+	}
+	for i, schema := range schemas {
+		result, err := ext.Extract(types.ExtractionOptions{ParsedPdf: parsedPdf, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction %d to succeed, got %v", i, err)
+		}
+		if len(result.Data) != 1 || !strings.Contains(mock.userPrompt(i), "INV-42") {
+			t.Errorf("Unexpected result %d: %v", i, result.Data)
+		}
+	}
+	if reads != 1 {
+		t.Errorf("Expected the PDF to be read once, got %d", reads)
+	}
+
+	if _, err := ext.ExtractInvoice(context.Background(), parsedPdf); err != nil {
+		t.Errorf("Expected an invoice from the parsed PDF, got %v", err)
+	}
+	if _, err := ext.DetectFormFields(context.Background(), parsedPdf, []types.FormField{{Name: "signed", Kind: "checkbox", Page: 1, Region: types.Rect{Width: 0.1, Height: 0.1}}}); err == nil || !strings.Contains(err.Error(), "the PDF itself is needed") {
+		t.Errorf("Expected the PDF to be required, got %v", err)
+	}
+	if reads != 1 {
+		t.Errorf("Expected the PDF to be read once, got %d", reads)
+	}
+}
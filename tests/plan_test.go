@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/journal"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestPlanBatch(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"title": "Quarterly report"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}}}
+	maxTokens := 500
+	documents := []types.BatchDocument{
+		{ID: "quarterly", Options: types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Quarterly report for the first quarter"}, []string{"Revenue grew by ten percent"}), Schema: schema, MaxTokens: &maxTokens}},
+		{ID: "broken", Options: types.ExtractionOptions{PDFBuffer: []byte("not a pdf"), Schema: schema}},
+		{ID: "annual", Options: types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Annual report of the year"}), Schema: schema}},
+	}
+	done := journal.NewMemory()
+	done.Save(context.Background(), types.JSONLRecord{DocumentID: "annual", Status: types.BatchStatusSuccess})
+
+	plan, err := ext.PlanBatch(context.Background(), documents, types.BatchOptions{Journal: done})
+	if err != nil {
+		t.Fatalf("Expected a plan, got error: %v", err)
+	}
+	if len(mock.Requests) != 0 {
+		t.Errorf("Expected no model call, got %d", len(mock.Requests))
+	}
+
+	quarterly := plan.Documents[0]
+	if quarterly.ID != "quarterly" || quarterly.Pages != 2 || quarterly.Route != "text" || quarterly.TextPages != 2 || quarterly.ImagePages != 0 || quarterly.Model != "gpt-4o-mini" {
+		t.Errorf("Unexpected plan of a text document: %+v", quarterly)
+	}
+	if quarterly.PromptTokens <= 0 || quarterly.CompletionTokens <= 0 || quarterly.CompletionTokens > 500 || quarterly.MaxCompletionTokens != 500 {
+		t.Errorf("Expected estimated tokens, got %+v", quarterly)
+	}
+	cost := (float64(quarterly.PromptTokens)*0.15 + float64(quarterly.CompletionTokens)*0.60) / 1e6
+	if !quarterly.Priced || math.Abs(quarterly.Cost-cost) > 1e-12 || quarterly.MaxCost <= quarterly.Cost {
+		t.Errorf("Expected the cost at the gpt-4o-mini price, got %+v", quarterly)
+	}
+	if plan.Documents[1].Err == nil {
+		t.Errorf("Expected the error of the broken document, got %+v", plan.Documents[1])
+	}
+	if !plan.Documents[2].Skipped {
+		t.Errorf("Expected the extracted document to be skipped, got %+v", plan.Documents[2])
+	}
+	if plan.Pages != 2 || plan.PromptTokens != quarterly.PromptTokens || plan.Cost != quarterly.Cost || len(plan.UnpricedModels) != 0 {
+		t.Errorf("Expected the totals of the documents to extract, got %+v", plan)
+	}
+
+	t.Run("Prices", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Model: "local-llm"})
+		plan, _ := ext.PlanBatch(context.Background(), documents[:1], types.BatchOptions{})
+		if plan.Documents[0].Priced || plan.Cost != 0 || !reflect.DeepEqual(plan.UnpricedModels, []string{"local-llm"}) {
+			t.Errorf("Expected an unpriced model, got %+v", plan)
+		}
+
+		ext, _ = extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Model: "local-llm",
+			ModelPrices: map[string]types.ModelPrice{"local": {Input: 1, Output: 2}}})
+		document := ext.Plan(documents[0].Options)
+		if want := (float64(document.PromptTokens) + 2*float64(document.CompletionTokens)) / 1e6; !document.Priced || math.Abs(document.Cost-want) > 1e-12 {
+			t.Errorf("Expected the configured price, got %+v", document)
+		}
+	})
+}
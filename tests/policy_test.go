@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestParsePolicy(t *testing.T) {
+	// pdfcpu rejects the misnamed catalog that MuPDF reads anyway
+	pdf := newTestPdf([]string{"Invoice INV-42 issued to ACME Corporation"}, []string{"Total 24.20"})
+	broken := bytes.Replace(pdf, []byte("/Type /Catalog"), []byte("/Type /Catalogue"), 1)
+
+	parsedPdf, err := parser.ParsePdfFromBuffer(broken, &types.ParseOptions{TextThreshold: 10})
+	if err != nil {
+		t.Fatalf("Expected best effort parsing to read what it can, got %v", err)
+	}
+	if !strings.Contains(parsedPdf.Content.TextContent, "INV-42") {
+		t.Errorf("Expected the text of the first page, got %q", parsedPdf.Content.TextContent)
+	}
+
+	_, err = parser.ParsePdfFromBuffer(broken, &types.ParseOptions{TextThreshold: 10, ParsePolicy: types.ParsePolicyStrict})
+	if err == nil || !strings.Contains(err.Error(), "failed to count pages") {
+		t.Errorf("Expected strict parsing to surface the error, got %v", err)
+	}
+	if _, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextThreshold: 10, ParsePolicy: types.ParsePolicyStrict}); err != nil {
+		t.Errorf("Expected strict parsing of a sound PDF to succeed, got %v", err)
+	}
+
+	_, err = parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ParsePolicy: "lenient"})
+	if err == nil || !strings.Contains(err.Error(), `unknown parse policy "lenient"`) {
+		t.Errorf("Expected an unknown policy error, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/store"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestPresetsHistory(t *testing.T) {
+	contractPdf := newTestPdf(
+		[]string{"SERVICES AGREEMENT between Acme Corp (Provider) and Globex Inc (Customer)", "Effective as of January 1, 2024."},
+		[]string{"5. Confidentiality. Each party shall keep the other party's information confidential."},
+	)
+	statementPdf := newTestPdf(
+		[]string{"Example Bank - Statement of account DE89370400440532013000 - Opening balance 100.00"},
+		[]string{"2024-03-02 Grocery store -20.00 80.00 - Closing balance 80.00"},
+	)
+	paperPdf := newTestPdf(
+		[]string{"Attention Is All You Need", "A. Vaswani - Google Brain - Abstract: sequence transduction models."},
+		[]string{"References", "[1] S. Hochreiter and J. Schmidhuber. Long short-term memory. Neural Computation, 1997."},
+	)
+	identityPdf := newTestPdf([]string{"PASSPORT - Surname DOE - Given names JANE - Nationality DEU - Date of birth 1990-04-12"})
+	term := map[string]interface{}{"value": "", "pages": []int{}}
+
+	tests := []struct {
+		name     string
+		pdf      []byte
+		payloads []interface{}
+		// requests is the number of model calls of the extraction, and key a key of its data
+		requests int
+		key      string
+		// vision is set for the extractions always sent as page images
+		vision  bool
+		extract func(ext *extractor.Extractor, pdf []byte) (int, error)
+	}{
+		{
+			"Contract", contractPdf,
+			[]interface{}{map[string]interface{}{
+				"parties":       []map[string]interface{}{{"name": "Acme Corp", "role": "provider", "pages": []int{1}}},
+				"effectiveDate": term, "terminationDate": term, "renewalTerms": term,
+				"clauses": []interface{}{}, "obligations": []interface{}{},
+			}},
+			2, "Parties", false,
+			func(ext *extractor.Extractor, pdf []byte) (int, error) {
+				result, err := ext.AnalyzeContract(context.Background(), pdf, types.ContractOptions{ChunkSize: 120})
+				if err != nil {
+					return 0, err
+				}
+				return result.TokensUsed, nil
+			},
+		},
+		{
+			"Bank statement", statementPdf,
+			[]interface{}{map[string]interface{}{
+				"bankName": "Example Bank", "accountHolder": "", "accountNumber": "DE89370400440532013000", "currency": "EUR",
+				"periodStart": "", "periodEnd": "", "openingBalance": 100, "closingBalance": 80,
+				"transactions": []map[string]interface{}{{"date": "2024-03-02", "description": "Grocery store", "amount": -20, "balance": 80, "page": 2}},
+			}},
+			2, "Statement", false,
+			func(ext *extractor.Extractor, pdf []byte) (int, error) {
+				result, err := ext.ExtractBankStatement(context.Background(), pdf, types.BankStatementOptions{ChunkSize: 120})
+				if err != nil {
+					return 0, err
+				}
+				return result.TokensUsed, nil
+			},
+		},
+		{
+			"Paper", paperPdf,
+			[]interface{}{
+				map[string]interface{}{"title": "Attention Is All You Need", "authors": []interface{}{}, "abstract": "", "keywords": []string{}, "doi": "", "venue": "", "year": 2017},
+				map[string]interface{}{"references": []map[string]interface{}{{"raw": "[1] S. Hochreiter", "authors": []string{}, "title": "Long short-term memory", "year": 1997, "venue": "", "doi": ""}}},
+			},
+			2, "Paper", false,
+			func(ext *extractor.Extractor, pdf []byte) (int, error) {
+				result, err := ext.ExtractPaper(context.Background(), pdf)
+				if err != nil {
+					return 0, err
+				}
+				return result.TokensUsed, nil
+			},
+		},
+		{
+			"Identity document", identityPdf,
+			[]interface{}{map[string]interface{}{
+				"documentType": types.IdentityDocumentPassport, "documentNumber": "C01X00T47", "issuingCountry": "DEU", "surname": "DOE",
+				"givenNames": "JANE", "nationality": "DEU", "dateOfBirth": "1990-04-12", "sex": "F", "expiryDate": "2030-01-01", "mrzLines": []string{},
+			}},
+			1, "surname", true,
+			func(ext *extractor.Extractor, pdf []byte) (int, error) {
+				result, err := ext.ExtractIdentityDocument(context.Background(), pdf)
+				if err != nil {
+					return 0, err
+				}
+				return result.TokensUsed, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockServer(t, tt.payloads[0], tt.payloads[1:]...)
+			var records []types.ExtractionRecord
+			var events []types.Event
+			history := store.NewMemory()
+			ext, _ := extractor.New(types.ExtractorConfig{
+				OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, VisionEnabled: true, Store: history,
+				Recorder: types.RecorderFunc(func(ctx context.Context, record types.ExtractionRecord) error {
+					records = append(records, record)
+					return nil
+				}),
+				Publisher: types.PublisherFunc(func(ctx context.Context, event types.Event) error {
+					events = append(events, event)
+					return nil
+				}),
+			})
+
+			tokens, err := tt.extract(ext, tt.pdf)
+			if err != nil {
+				t.Fatalf("Expected extraction to succeed, got error: %v", err)
+			}
+			if len(mock.Requests) != tt.requests || tokens != 42*tt.requests {
+				t.Fatalf("Expected %d model calls, got %d requests and %d tokens", tt.requests, len(mock.Requests), tokens)
+			}
+
+			sent, _ := json.Marshal(mock.Requests[0])
+			if strings.Contains(string(sent), "image_url") != tt.vision {
+				t.Errorf("Expected page images sent: %v, got %.200s", tt.vision, sent)
+			}
+
+			// The model calls of the document make a single extraction, with the merged data
+			if len(records) != 1 || len(records[0].Calls) != tt.requests || records[0].Data[tt.key] == nil {
+				t.Fatalf("Expected one record with every model call, got %+v", records)
+			}
+			if len(events) != 1 || events[0].Type != types.EventExtractionCompleted || events[0].TokensUsed != tokens || events[0].Data[tt.key] == nil {
+				t.Errorf("Expected one completion event with the merged data, got %+v", events)
+			}
+			sum := sha256.Sum256(tt.pdf)
+			extractions, _ := history.GetByDocumentHash(context.Background(), hex.EncodeToString(sum[:]))
+			if len(extractions) != 1 || extractions[0].Status != types.BatchStatusSuccess || extractions[0].TokensUsed != tokens || extractions[0].SchemaHash == "" {
+				t.Errorf("Expected the extraction saved, got %+v", extractions)
+			}
+		})
+	}
+
+	t.Run("Failure", func(t *testing.T) {
+		mock := newMockServer(t, rawContent("The model ran out of tokens"))
+		var events []types.Event
+		ext, _ := extractor.New(types.ExtractorConfig{
+			OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10,
+			Publisher: types.PublisherFunc(func(ctx context.Context, event types.Event) error {
+				events = append(events, event)
+				return nil
+			}),
+		})
+		if _, err := ext.AnalyzeContract(context.Background(), contractPdf, types.ContractOptions{ChunkSize: 120}); err == nil {
+			t.Fatal("Expected the analysis to fail when every chunk fails")
+		}
+		if len(events) != 1 || events[0].Type != types.EventExtractionFailed {
+			t.Errorf("Expected one failure event, got %+v", events)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestProviderPresets(t *testing.T) {
+	pdf := newTestPdf([]string{"Invoice INV-42 issued to ACME Corporation"})
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"invoice_number": map[string]interface{}{"type": "string"}}}
+	data := map[string]interface{}{"invoice_number": "INV-42"}
+
+	for _, preset := range extractor.Presets() {
+		if preset.BaseURL == "" || preset.ResponseFormat == "" {
+			t.Errorf("Incomplete preset: %+v", preset)
+		}
+	}
+
+	t.Run("Default base URL", func(t *testing.T) {
+		ext, err := extractor.New(types.ExtractorConfig{Provider: types.ProviderDockerModelRunner, Model: "ai/gemma3"})
+		if err != nil {
+			t.Fatalf("Expected extractor without an API key, got error: %v", err)
+		}
+		if ext.GetModel() != "ai/gemma3" {
+			t.Errorf("Unexpected model %s", ext.GetModel())
+		}
+		if _, err := extractor.New(types.ExtractorConfig{Provider: types.ProviderVLLM}); err == nil || !strings.Contains(err.Error(), "model is required") {
+			t.Errorf("Expected a missing model error, got %v", err)
+		}
+		if _, err := extractor.New(types.ExtractorConfig{Provider: "ollama", Model: "llama3"}); err == nil || !strings.Contains(err.Error(), `unknown provider "ollama"`) {
+			t.Errorf("Expected an unknown provider error, got %v", err)
+		}
+		if _, err := extractor.New(types.ExtractorConfig{}); err == nil || !strings.Contains(err.Error(), "API key is required") {
+			t.Errorf("Expected OpenAI to require an API key, got %v", err)
+		}
+	})
+
+	t.Run("llama.cpp", func(t *testing.T) {
+		mock := newMockServer(t, data)
+		ext, _ := extractor.New(types.ExtractorConfig{Provider: types.ProviderLlamaCpp, BaseURL: mock.URL, Model: "qwen2.5", TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if result.Data["invoice_number"] != "INV-42" {
+			t.Errorf("Unexpected data: %v", result.Data)
+		}
+		format, _ := mock.Requests[0]["response_format"].(map[string]interface{})
+		if format["type"] != "json_object" || format["schema"] == nil {
+			t.Errorf("Expected a json_object response format with the schema, got %v", format)
+		}
+		if auth := mock.Headers[0].Get("Authorization"); auth != "" {
+			t.Errorf("Expected no Authorization header without an API key, got %q", auth)
+		}
+	})
+
+	t.Run("Prompt response format", func(t *testing.T) {
+		mock := newMockServer(t, data)
+		ext, _ := extractor.New(types.ExtractorConfig{
+			Provider: types.ProviderVLLM, BaseURL: mock.URL, Model: "mistral", OpenAIAPIKey: "vllm-key",
+			ResponseFormat: types.ResponseFormatPrompt, TextThreshold: 10,
+		})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if _, ok := mock.Requests[0]["response_format"]; ok {
+			t.Error("Expected no response format")
+		}
+		if prompt := mock.userPrompt(0); !strings.Contains(prompt, "Invoice INV-42") || !strings.Contains(prompt, `"invoice_number"`) {
+			t.Errorf("Expected the schema in the prompt, got %q", prompt)
+		}
+		if auth := mock.Headers[0].Get("Authorization"); auth != "Bearer vllm-key" {
+			t.Errorf("Expected the configured API key, got %q", auth)
+		}
+	})
+
+	t.Run("OpenAI organization and project", func(t *testing.T) {
+		mock := newMockServer(t, data)
+		ext, _ := extractor.New(types.ExtractorConfig{
+			BaseURL: mock.URL, OpenAIAPIKey: "test-key", OpenAIOrganization: "org-acme", OpenAIProject: "proj_invoices", TextThreshold: 10,
+		})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if org, project := mock.Headers[0].Get("OpenAI-Organization"), mock.Headers[0].Get("OpenAI-Project"); org != "org-acme" || project != "proj_invoices" {
+			t.Errorf("Expected the organization and project headers, got %q and %q", org, project)
+		}
+	})
+}
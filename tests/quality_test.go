@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const qualityText = "This agreement is made between the supplier and the customer for the delivery of goods. The customer shall pay the invoice within thirty days of receipt. Late payments are subject to interest at the rate stated in the order."
+
+// shiftText shifts the code of every non-space character, like a text layer mapped to the wrong
+// glyph identifiers
+func shiftText(text string, offset rune) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' {
+			return r
+		}
+		return r + offset
+	}, text)
+}
+
+func TestTextQuality(t *testing.T) {
+	readable := map[string]string{
+		"English": qualityText,
+		"Polish":  "Niniejsza umowa zostaje zawarta pomiędzy dostawcą a klientem w sprawie dostawy towarów. Klient zapłaci fakturę w terminie trzydziestu dni od jej otrzymania.",
+		"Chinese": "本协议由供应商与客户就货物交付事宜签订。客户应在收到发票后三十天内付款。逾期付款将按订单中规定的利率计息。",
+		"Invoice": "INVOICE No. INV-2024-0042 Date: 2024-03-15 Qty 2 x Widget 10.00 EUR 20.00 VAT 21% 4.20 Total 24.20 EUR",
+		"Short":   "§§§ ¤¤¤",
+	}
+	for name, text := range readable {
+		if quality := parser.TextQuality(text); quality < 0.9 {
+			t.Errorf("Expected %s text to be readable, got %.2f", name, quality)
+		}
+	}
+
+	garbage := map[string]string{
+		"Shifted letters": strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' {
+				return 'a' + (r-'a'+3)%26
+			}
+			return r
+		}, qualityText),
+		"Shifted codes":      shiftText(qualityText, -29),
+		"Private use glyphs": shiftText(qualityText, 0xE000),
+		"Replacement":        strings.Repeat("\uFFFD\uFFFD\uFFFD ", 20),
+	}
+	for name, text := range garbage {
+		if quality := parser.TextQuality(text); quality >= 0.5 {
+			t.Errorf("Expected %s text to be garbage, got %.2f", name, quality)
+		}
+	}
+
+	t.Run("Vision fallback", func(t *testing.T) {
+		pdf := newTestPdf([]string{shiftText(qualityText, -29)})
+		parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextThreshold: 10})
+		if err != nil {
+			t.Fatalf("Expected parsed PDF, got error: %v", err)
+		}
+		if parsedPdf.Content.Type != "images" {
+			t.Errorf("Expected garbage text to be read through vision, got %q", parsedPdf.Content.Type)
+		}
+
+		parsedPdf, err = parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{TextThreshold: 10, MinTextQuality: -1})
+		if err != nil {
+			t.Fatalf("Expected parsed PDF, got error: %v", err)
+		}
+		if parsedPdf.Content.Type != "text" {
+			t.Errorf("Expected the check to be disabled, got %q", parsedPdf.Content.Type)
+		}
+
+		parsedPdf, err = parser.ParsePdfFromBuffer(newTestPdf([]string{qualityText}), &types.ParseOptions{TextThreshold: 10})
+		if err != nil || parsedPdf.Content.Type != "text" {
+			t.Errorf("Expected readable text to be kept, got %v", err)
+		}
+	})
+}
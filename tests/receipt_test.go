@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestExtractReceipt(t *testing.T) {
+	pdf := newTestPdf([]string{
+		"STARBUCKS STORE #12345 - 1 Main Street, Springfield",
+		"2x Latte 4.50 9.00 | 1x Muffin 3.25 | Subtotal 12.25",
+		"Tax 1.01 | Tip 2.00 | Discount -1.00 | Total 14.26 | VISA",
+	})
+
+	receipt := func(total float64) map[string]interface{} {
+		return map[string]interface{}{
+			"merchantRaw": "STARBUCKS   STORE #12345",
+			"date":        "2024-05-01",
+			"time":        "08:15",
+			"currency":    "USD",
+			"items": []map[string]interface{}{
+				{"description": "Latte", "quantity": 2, "unitPrice": 4.5, "amount": 9.0},
+				{"description": "Muffin", "quantity": 1, "unitPrice": 3.25, "amount": 3.25},
+			},
+			"subtotal":      12.25,
+			"tax":           1.01,
+			"taxIncluded":   false,
+			"tip":           2.0,
+			"discount":      -1.0,
+			"total":         total,
+			"paymentMethod": "card",
+		}
+	}
+
+	t.Run("Reconciles amounts and normalizes merchant", func(t *testing.T) {
+		mock := newMockServer(t, receipt(14.26))
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+
+		result, err := ext.ExtractReceipt(context.Background(), pdf)
+		if err != nil {
+			t.Fatalf("Expected receipt, got error: %v", err)
+		}
+		if result.Merchant != "Starbucks" {
+			t.Errorf("Expected normalized merchant, got %q", result.Merchant)
+		}
+		if !result.Reconciled {
+			t.Errorf("Expected receipt to reconcile, got warnings: %v", result.Warnings)
+		}
+		if result.Discount != 1.0 {
+			t.Errorf("Expected positive discount, got %v", result.Discount)
+		}
+	})
+
+	t.Run("Reports totals that do not add up", func(t *testing.T) {
+		mock := newMockServer(t, receipt(41.26))
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+
+		result, err := ext.ExtractReceipt(context.Background(), pdf)
+		if err != nil {
+			t.Fatalf("Expected receipt, got error: %v", err)
+		}
+		if result.Reconciled || len(result.Warnings) == 0 {
+			t.Error("Expected reconciliation warning for wrong total")
+		}
+	})
+}
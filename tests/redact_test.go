@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestRedactPII(t *testing.T) {
+	for text, expected := range map[string]string{
+		"Contact jane.doe@example.com today":        "Contact [EMAIL] today",
+		"IBAN DE89 3704 0044 0532 0130 00 (EUR)":    "IBAN [IBAN] (EUR)",
+		"Paid with 4242 4242 4242 4242":             "Paid with [CARD]",
+		"SSN 123-45-6789":                           "SSN [SSN]",
+		"Call +44 20 7946 0958 or (555) 123-4567":   "Call [PHONE] or [PHONE]",
+		"Invoice 2024-01-31, total 1,250.00 EUR":    "Invoice 2024-01-31, total 1,250.00 EUR",
+		"Order 4242 4242 4242 4243 is no card":      "Order 4242 4242 4242 4243 is no card",
+		"Reference INV-2024-0001, PO number 778120": "Reference INV-2024-0001, PO number 778120",
+	} {
+		if redacted := extractor.RedactPII(text); redacted != expected {
+			t.Errorf("Expected %q redacted to %q, got %q", text, expected, redacted)
+		}
+	}
+}
+
+func TestDebugLogger(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"patientName": "Jane Doe", "ssn": "123-45-6789", "total": 42.5})
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, DebugLogger: logger, RedactFields: []string{"PatientName"}})
+
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"patientName": map[string]interface{}{"type": "string"},
+			"ssn":         map[string]interface{}{"type": "string"},
+			"total":       map[string]interface{}{"type": "number"},
+		},
+	}
+	pdf := newTestPdf([]string{"Contact: jane.doe@example.com", "Card 4242 4242 4242 4242, total 42.50"})
+	result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+	if err != nil {
+		t.Fatalf("Expected extraction, got error: %v", err)
+	}
+	if result.Data["patientName"] != "Jane Doe" || result.Data["ssn"] != "123-45-6789" {
+		t.Errorf("Expected the data itself left as is, got %v", result.Data)
+	}
+
+	bodies := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry struct {
+			Msg  string `json:"msg"`
+			Body string `json:"body"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected JSON logs, got %q", line)
+		}
+		bodies[entry.Msg] = entry.Body
+	}
+	request, response := bodies["chat completion request"], bodies["chat completion response"]
+	for _, text := range []string{"[EMAIL]", "[CARD]", `"patientName":{"type":"string"}`} {
+		if !strings.Contains(request, text) {
+			t.Errorf("Expected %s in the logged request, got %s", text, request)
+		}
+	}
+	for _, text := range []string{`\"patientName\":\"[REDACTED]\"`, `\"ssn\":\"[SSN]\"`, `\"total\":42.5`} {
+		if !strings.Contains(response, text) {
+			t.Errorf("Expected %s in the logged response, got %s", text, response)
+		}
+	}
+	for _, text := range []string{"Jane Doe", "jane.doe@example.com", "4242 4242", "123-45-6789"} {
+		if strings.Contains(logs.String(), text) {
+			t.Errorf("Expected %q redacted from the logs, got %s", text, logs.String())
+		}
+	}
+
+	// Nothing is logged above the debug level
+	logs.Reset()
+	quiet, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, DebugLogger: slog.New(slog.NewJSONHandler(&logs, nil))})
+	if _, err := quiet.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil || logs.Len() != 0 {
+		t.Errorf("Expected nothing logged, got %s, %v", logs.String(), err)
+	}
+}
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// paperBackend renders every page as a white 612x792 image, a pixel per point
+type paperBackend struct{}
+
+func (paperBackend) Name() string { return "paper" }
+
+func (paperBackend) Render(_ []byte, pages []int, _ float64, page func(int, *image.RGBA) error) error {
+	for _, p := range pages {
+		img := image.NewRGBA(image.Rect(0, 0, 612, 792))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		if err := page(p, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sentPage decodes the i-th page image of the first request of a mock server
+func sentPage(t *testing.T, mock *mockServer, i int) image.Image {
+	t.Helper()
+	messages, _ := mock.Requests[0]["messages"].([]interface{})
+	user, _ := messages[len(messages)-1].(map[string]interface{})
+	images := make([]string, 0)
+	for _, part := range user["content"].([]interface{}) {
+		if imageURL, ok := part.(map[string]interface{})["image_url"].(map[string]interface{}); ok {
+			images = append(images, imageURL["url"].(string))
+		}
+	}
+	if i >= len(images) {
+		t.Fatalf("Expected page image %d in the request, got %d images", i, len(images))
+	}
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(images[i], "data:image/png;base64,"))
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Expected a PNG page image, got error: %v", err)
+	}
+	return img
+}
+
+// isBlack reports whether a pixel of an image is opaque black
+func isBlack(img image.Image, x, y int) bool {
+	r, g, b, a := img.At(x, y).RGBA()
+	return r == 0 && g == 0 && b == 0 && a == 0xffff
+}
+
+func TestRedactPages(t *testing.T) {
+	parser.RegisterBackend(paperBackend{})
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}}}
+
+	t.Run("Image", func(t *testing.T) {
+		page := types.PdfPageImage{Page: 1}
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		var buf bytes.Buffer
+		png.Encode(&buf, img)
+		page.Base64 = base64.StdEncoding.EncodeToString(buf.Bytes())
+
+		redacted, err := parser.RedactPageImage(page, []types.Rect{{X: 0, Y: 0, Width: 0.5, Height: 0.5}})
+		if err != nil {
+			t.Fatalf("Expected redacted page, got error: %v", err)
+		}
+		raw, _ := base64.StdEncoding.DecodeString(redacted.Base64)
+		decoded, _ := png.Decode(bytes.NewReader(raw))
+		if !isBlack(decoded, 2, 2) || isBlack(decoded, 7, 7) || decoded.Bounds().Dx() != 10 {
+			t.Errorf("Expected the top-left quarter blacked out")
+		}
+		if redacted.Page != 1 || page.Base64 == redacted.Base64 {
+			t.Errorf("Expected a redacted copy of the page")
+		}
+	})
+
+	t.Run("Regions", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"name": "Jane Roe"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, Renderer: "paper"})
+		_, err := ext.Extract(types.ExtractionOptions{
+			PDFBuffer:     newTestPdf([]string{"Form"}, []string{"Form"}),
+			Schema:        schema,
+			ForceMode:     types.ForceModeVision,
+			RedactRegions: []types.PageRedaction{{Page: 2, Region: types.Rect{X: 0.5, Y: 0.5, Width: 0.5, Height: 0.5}}, {Region: types.Rect{X: 0, Y: 0, Width: 0.1, Height: 0.1}}},
+		})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		first, second := sentPage(t, mock, 0), sentPage(t, mock, 1)
+		if !isBlack(first, 10, 10) || isBlack(first, 500, 700) {
+			t.Errorf("Expected only the region of every page blacked out on page 1")
+		}
+		if !isBlack(second, 10, 10) || !isBlack(second, 500, 700) || isBlack(second, 100, 700) {
+			t.Errorf("Expected both regions blacked out on page 2")
+		}
+	})
+
+	t.Run("Patterns", func(t *testing.T) {
+		pdf := newTestPdf([]string{"Name: Jane Roe", "SSN: 555-12-3456", "Signed in Berlin"})
+		layouts, err := parser.ExtractTextLayoutFromBuffer(pdf)
+		if err != nil || len(layouts) != 1 || len(layouts[0].Lines) != 3 {
+			t.Fatalf("Expected the layout of the page, got %+v, %v", layouts, err)
+		}
+		ssn := layouts[0].Lines[1].Words[len(layouts[0].Lines[1].Words)-1].Bounds
+		label := layouts[0].Lines[1].Words[0].Bounds
+
+		mock := newMockServer(t, map[string]interface{}{"name": "Jane Roe"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, Renderer: "paper"})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, ForceMode: types.ForceModeVision, RedactPatterns: []string{`\d{3}-\d{2}-\d{4}`}}); err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		page := sentPage(t, mock, 0)
+		if !isBlack(page, int(ssn.X+ssn.Width/2), int(ssn.Y+ssn.Height/2)) {
+			t.Errorf("Expected the number blacked out at %+v", ssn)
+		}
+		// The pure-Go backend does not measure the words of the line apart
+		if label.X+label.Width < ssn.X && isBlack(page, int(label.X+label.Width/2), int(label.Y+label.Height/2)) {
+			t.Errorf("Expected the label left as is at %+v", label)
+		}
+
+		// The matches are also replaced in text sent to the model
+		parsed, err := ext.Parse(types.ExtractionOptions{PDFBuffer: pdf, TextThreshold: 10, RedactPatterns: []string{`\d{3}-\d{2}-\d{4}`}})
+		if err != nil || strings.Contains(parsed.Content.TextContent, "3456") || !strings.Contains(parsed.Content.TextContent, "SSN: [REDACTED]") {
+			t.Errorf("Expected the number replaced in the text, got %q, %v", parsed.Content.TextContent, err)
+		}
+	})
+
+	t.Run("No layout", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"name": "Jane Roe"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, Renderer: "paper"})
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{}), Schema: schema, RedactPatterns: []string{`\d{3}-\d{2}-\d{4}`}})
+		if err == nil || !strings.Contains(err.Error(), "no text layout") {
+			t.Errorf("Expected the scanned page without layout refused, got %v", err)
+		}
+		if len(mock.Requests) != 0 {
+			t.Errorf("Expected no page sent, got %d requests", len(mock.Requests))
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestReExtractFields(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+		"seller": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}}},
+		"total":  map[string]interface{}{"type": "string"},
+	}}
+	mock := newMockServer(t, map[string]interface{}{"seller.name": "ACME Corporation"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+	pdf := newTestPdf(
+		[]string{"Cover letter for the annual report"},
+		[]string{"Terms and conditions of sale"},
+		[]string{"Sold by ACME Corp International"},
+		[]string{"Payment due within thirty days"},
+		[]string{"Total 24.20"},
+	)
+	previous := &types.ExtractionResult{Data: map[string]interface{}{
+		"seller": map[string]interface{}{"name": "ACME Corp"},
+		"total":  "24.20",
+	}}
+
+	result, err := ext.ReExtractFields(context.Background(), previous, []string{"seller.name"}, types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+	if err != nil {
+		t.Fatalf("Expected re-extraction to succeed, got %v", err)
+	}
+	if result.Data["seller"].(map[string]interface{})["name"] != "ACME Corporation" || result.Data["total"] != "24.20" {
+		t.Errorf("Expected only the field to be replaced, got %v", result.Data)
+	}
+	if previous.Data["seller"].(map[string]interface{})["name"] != "ACME Corp" {
+		t.Error("Expected the previous result to be left untouched")
+	}
+
+	prompt := mock.userPrompt(0)
+	if !strings.Contains(prompt, `seller.name (previously extracted: "ACME Corp")`) {
+		t.Errorf("Expected the previous value in the prompt, got %q", prompt)
+	}
+	if strings.Contains(prompt, "Cover letter") || strings.Contains(prompt, "Total 24.20") || !strings.Contains(prompt, "Terms and conditions") || !strings.Contains(prompt, "Payment due") {
+		t.Errorf("Expected the prompt focused on the pages around the previous value, got %q", prompt)
+	}
+	format := mock.Requests[0]["response_format"].(map[string]interface{})
+	properties := format["json_schema"].(map[string]interface{})["schema"].(map[string]interface{})["properties"].(map[string]interface{})
+	if len(properties) != 1 || properties["seller.name"] == nil {
+		t.Errorf("Expected a schema with the field only, got %v", properties)
+	}
+
+	if _, err := ext.ReExtractFields(context.Background(), previous, []string{"buyer"}, types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err == nil || !strings.Contains(err.Error(), `field "buyer" is not in the schema`) {
+		t.Errorf("Expected an unknown field to be rejected, got %v", err)
+	}
+}
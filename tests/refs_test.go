@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestInlineRefs(t *testing.T) {
+	common := map[string]interface{}{
+		"$defs": map[string]interface{}{
+			"address": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"street": map[string]interface{}{"type": "string"}, "country": map[string]interface{}{"$ref": "#/$defs/country"}},
+			},
+			"country": map[string]interface{}{"type": "string", "enum": []interface{}{"DE", "FR"}},
+		},
+	}
+	load := func(uri string) (map[string]interface{}, error) {
+		if uri != "https://example.com/common.json" {
+			t.Errorf("Expected the absolute URI of the document, got %q", uri)
+		}
+		return common, nil
+	}
+	addressSchema := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"street": map[string]interface{}{"type": "string"}, "country": map[string]interface{}{"type": "string", "enum": []interface{}{"DE", "FR"}}},
+	}
+
+	t.Run("Inline", func(t *testing.T) {
+		schemaData := map[string]interface{}{
+			"$id":  "https://example.com/invoice.json",
+			"type": "object",
+			"properties": map[string]interface{}{
+				"seller": map[string]interface{}{"$ref": "#/$defs/party"},
+				"buyer":  map[string]interface{}{"$ref": "#/$defs/party", "description": "The customer"},
+			},
+			"$defs": map[string]interface{}{
+				"party": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"address": map[string]interface{}{"$ref": "https://example.com/common.json#/$defs/address"}},
+				},
+			},
+		}
+		inlined, err := schema.InlineRefs(schemaData, load)
+		if err != nil {
+			t.Fatalf("Expected inlined schema, got error: %v", err)
+		}
+		party := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"address": addressSchema}}
+		buyer := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"address": addressSchema}, "description": "The customer"}
+		expected := map[string]interface{}{
+			"$id":        "https://example.com/invoice.json",
+			"type":       "object",
+			"properties": map[string]interface{}{"seller": party, "buyer": buyer},
+		}
+		if !reflect.DeepEqual(inlined, expected) {
+			got, _ := json.Marshal(inlined)
+			t.Errorf("Unexpected inlined schema %s", got)
+		}
+		if _, ok := schemaData["$defs"]; !ok {
+			t.Error("Expected the schema left unchanged")
+		}
+	})
+
+	t.Run("Recursive", func(t *testing.T) {
+		schemaData := map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"root": map[string]interface{}{"$ref": "#/$defs/node"}},
+			"$defs": map[string]interface{}{
+				"node": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"children": map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/$defs/node"}}},
+				},
+			},
+		}
+		inlined, err := schema.InlineRefs(schemaData, nil)
+		if err != nil {
+			t.Fatalf("Expected inlined schema, got error: %v", err)
+		}
+		encoded, _ := json.Marshal(inlined)
+		if _, ok := inlined["$defs"]; !ok || !strings.Contains(string(encoded), `"items":{"$ref":"#/$defs/node"}`) {
+			t.Errorf("Expected the recursive reference kept with the definitions, got %s", encoded)
+		}
+		if err := schema.ValidateSchema(inlined); err != nil {
+			t.Errorf("Expected a valid schema, got %v", err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for ref, expected := range map[string]string{
+			"common.json":        "no loader of external references",
+			"#/$defs/missing":    `no "$defs" in "/$defs/missing"`,
+			"#address":           "unsupported anchor",
+			"#/properties/total": `does not point to a schema`,
+		} {
+			schemaData := map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"total": true, "address": map[string]interface{}{"$ref": ref}},
+			}
+			if _, err := schema.InlineRefs(schemaData, nil); err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected an error with %q for %s, got %v", expected, ref, err)
+			}
+		}
+	})
+
+	t.Run("Extract", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"address": map[string]interface{}{"street": "Hauptstraße 1", "country": "DE"}})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		schemaData := map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"address": map[string]interface{}{"$ref": "common.json#/$defs/address"}},
+		}
+		_, err := ext.Extract(types.ExtractionOptions{
+			PDFBuffer:  newTestPdf([]string{"Invoice INV-13", "Hauptstraße 1, Germany"}),
+			Schema:     schemaData,
+			SchemaRefs: map[string]map[string]interface{}{"common.json": common},
+		})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		format, _ := mock.Requests[0]["response_format"].(map[string]interface{})
+		jsonSchema, _ := format["json_schema"].(map[string]interface{})
+		sent, _ := json.Marshal(jsonSchema["schema"])
+		if strings.Contains(string(sent), "$ref") || !strings.Contains(string(sent), `"enum":["DE","FR"]`) {
+			t.Errorf("Expected the inlined schema in the request, got %s", sent)
+		}
+
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Invoice"}), Schema: schemaData}); err == nil || !strings.Contains(err.Error(), "no schema common.json in SchemaRefs") {
+			t.Errorf("Expected an error for the missing document, got %v", err)
+		}
+	})
+}
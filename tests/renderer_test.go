@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// fakeRenderers stands in for pdftoppm and Ghostscript: each records its arguments and copies a
+// fixed PNG to the requested output file
+var fakeRenderers = map[string]string{
+	types.RendererPdftoppm: `#!/bin/sh
+echo "$@" >> "$RENDERER_ARGS"
+for last; do :; done
+cp "$RENDERER_PNG" "$last.png"
+`,
+	types.RendererGhostscript: `#!/bin/sh
+echo "$@" >> "$RENDERER_ARGS"
+for arg; do
+	case "$arg" in -sOutputFile=*) cp "$RENDERER_PNG" "${arg#-sOutputFile=}";; esac
+done
+`,
+}
+
+func TestExternalRenderer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake renderers are shell scripts")
+	}
+
+	dir := t.TempDir()
+	var page bytes.Buffer
+	if err := png.Encode(&page, image.NewGray(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	pngPath := filepath.Join(dir, "page.png")
+	if err := os.WriteFile(pngPath, page.Bytes(), 0o600); err != nil {
+		t.Fatalf("Failed to write PNG: %v", err)
+	}
+	t.Setenv("RENDERER_PNG", pngPath)
+
+	pdf := newTestPdfFromContent("", "")
+	expectedArgs := map[string][]string{
+		types.RendererPdftoppm:    {"-png -r 150 -f 1 -l 1", "-png -r 150 -f 2 -l 2"},
+		types.RendererGhostscript: {"-r150 -dFirstPage=1 -dLastPage=1", "-r150 -dFirstPage=2 -dLastPage=2"},
+	}
+	for tool, script := range fakeRenderers {
+		t.Run(tool, func(t *testing.T) {
+			binary := filepath.Join(dir, tool)
+			if err := os.WriteFile(binary, []byte(script), 0o700); err != nil {
+				t.Fatalf("Failed to write fake renderer: %v", err)
+			}
+			argsPath := filepath.Join(dir, tool+".args")
+			t.Setenv("RENDERER_ARGS", argsPath)
+
+			parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{
+				ForceImages:  true,
+				DPI:          150,
+				Renderer:     tool,
+				RendererPath: binary,
+			})
+			if err != nil {
+				t.Fatalf("Expected parsed PDF, got error: %v", err)
+			}
+			if len(parsedPdf.Content.ImageContent) != 2 {
+				t.Fatalf("Expected 2 page images, got %d", len(parsedPdf.Content.ImageContent))
+			}
+			raw, err := base64.StdEncoding.DecodeString(parsedPdf.Content.ImageContent[1].Base64)
+			if err != nil {
+				t.Fatalf("Failed to decode page image: %v", err)
+			}
+			config, err := png.DecodeConfig(bytes.NewReader(raw))
+			if err != nil || config.Width != 40 || config.Height != 30 {
+				t.Errorf("Expected the 40x30 render, got %+v (%v)", config, err)
+			}
+
+			args, err := os.ReadFile(argsPath)
+			if err != nil {
+				t.Fatalf("Failed to read renderer arguments: %v", err)
+			}
+			calls := strings.Split(strings.TrimSpace(string(args)), "\n")
+			if len(calls) != 2 {
+				t.Fatalf("Expected 2 renderer calls, got %q", calls)
+			}
+			for i, call := range calls {
+				if !strings.Contains(call, expectedArgs[tool][i]) {
+					t.Errorf("Expected call %d to contain %q, got %q", i+1, expectedArgs[tool][i], call)
+				}
+			}
+		})
+	}
+
+	t.Run("Missing binary", func(t *testing.T) {
+		_, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{
+			ForceImages:  true,
+			Renderer:     types.RendererPdftoppm,
+			RendererPath: filepath.Join(dir, "missing"),
+		})
+		if err == nil || !strings.Contains(err.Error(), "renderer pdftoppm not found") {
+			t.Errorf("Expected a missing renderer error, got %v", err)
+		}
+	})
+
+	t.Run("Unknown renderer", func(t *testing.T) {
+		_, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "pdfium"})
+		if err == nil || !strings.Contains(err.Error(), `unknown renderer "pdfium"`) {
+			t.Errorf("Expected an unknown renderer error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestRepairJSON(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+		"number": map[string]interface{}{"type": "string"},
+		"total":  map[string]interface{}{"type": "number"},
+		"paid":   map[string]interface{}{"type": "boolean"},
+		"lines":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	}}
+	pdf := newTestPdf([]string{"Invoice INV-42", "Total 1.5e3"})
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Prose", "Sure! Here is the data you asked for:\n```json\n{\"number\": \"INV-42\", \"total\": 1.5e3, \"paid\": true, \"lines\": [\"a\"]}\n```\nLet me know if you need anything else."},
+		{"Trailing commas and comments", "{\"number\": \"INV-42\", // the invoice number\n \"total\": 1500, \"paid\": true, \"lines\": [\"a\",],}"},
+		{"Single quotes and bare keys", "{number: 'INV-42', total: 1500, paid: True, lines: ['a']}"},
+		{"Truncated", "{\"number\": \"INV-42\", \"total\": 1500, \"paid\": true, \"lines\": [\"a"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := newMockServer(t, rawContent(test.content))
+
+			strict, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+			if _, err := strict.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err == nil {
+				t.Error("Expected malformed output to fail without RepairJSON")
+			}
+
+			lenient, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, RepairJSON: true})
+			result, err := lenient.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+			if err != nil {
+				t.Fatalf("Expected the output to be repaired, got %v", err)
+			}
+			lines, _ := result.Data["lines"].([]interface{})
+			if result.Data["number"] != "INV-42" || result.Data["total"] != 1500.0 || result.Data["paid"] != true || len(lines) != 1 || lines[0] != "a" {
+				t.Errorf("Unexpected repaired data: %v", result.Data)
+			}
+		})
+	}
+}
+
+func TestCodeFence(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"number": map[string]interface{}{"type": "string"}}}
+	pdf := newTestPdf([]string{"Invoice INV-42"})
+	mock := newMockServer(t, rawContent("```json\n{\"number\": \"INV-42\"}\n```"))
+
+	tests := []struct {
+		name    string
+		config  types.ExtractorConfig
+		wantErr bool
+	}{
+		// Structured output is parsed strictly, fences included
+		{"Strict", types.ExtractorConfig{}, true},
+		{"JSON object", types.ExtractorConfig{ResponseFormat: types.ResponseFormatJSONObject}, true},
+		// Servers asked for JSON in the prompt tend to fence it
+		{"Prompt", types.ExtractorConfig{ResponseFormat: types.ResponseFormatPrompt}, false},
+		{"Repair", types.ExtractorConfig{RepairJSON: true}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			config.OpenAIAPIKey, config.BaseURL, config.TextThreshold = "test", mock.URL, 10
+			ext, err := extractor.New(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("Expected fenced output to fail, got %v", result.Data)
+				}
+				return
+			}
+			if err != nil || result.Data["number"] != "INV-42" {
+				t.Errorf("Expected the fenced output parsed, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestEntityResolver(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"seller":   map[string]interface{}{"type": "object"},
+			"buyer":    map[string]interface{}{"type": "object"},
+			"carriers": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+	data := map[string]interface{}{
+		"seller":   map[string]interface{}{"name": "ACME Inc.", "vatId": "ES-B12345678"},
+		"buyer":    map[string]interface{}{"name": "Initech S.L."},
+		"carriers": []interface{}{"Globex Logistics", "Unknown Freight"},
+	}
+	resolver := extractor.NewFuzzyResolver([]types.MasterEntity{
+		{ID: "V-001", Kind: "vendor", Name: "Acme Corporation"},
+		{ID: "C-042", Kind: "customer", Name: "Initech"},
+		{ID: "V-002", Kind: "vendor", Name: "Initech Supplies"},
+		{ID: "V-003", Kind: "vendor", Name: "Globex Corporation", Aliases: []string{"Globex Logistics"}},
+	}, 0)
+	pdf := newTestPdf([]string{"Invoice from ACME Inc. to Initech S.L.", "Shipped by Globex Logistics"})
+
+	t.Run("Fuzzy", func(t *testing.T) {
+		mock := newMockServer(t, data)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, EntityResolver: resolver})
+		result, err := ext.Extract(types.ExtractionOptions{
+			PDFBuffer:       pdf,
+			Schema:          schema,
+			ResolveEntities: map[string]string{"seller.name": "vendor", "buyer.name": "customer", "carriers.*": "vendor"},
+		})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		expected := map[string]types.EntityMatch{
+			"seller.name": {ID: "V-001", Name: "Acme Corporation", Confidence: 1},
+			"buyer.name":  {ID: "C-042", Name: "Initech", Confidence: 1},
+			"carriers.0":  {ID: "V-003", Name: "Globex Corporation", Confidence: 1},
+		}
+		if !reflect.DeepEqual(result.ResolvedEntities, expected) {
+			t.Errorf("Expected entities %+v, got %+v", expected, result.ResolvedEntities)
+		}
+		if warning := `carriers.1 = "Unknown Freight" resolved to no vendor`; !reflect.DeepEqual(result.Warnings, []string{warning}) {
+			t.Errorf("Expected warning %q, got %q", warning, result.Warnings)
+		}
+		if result.Data["seller"].(map[string]interface{})["name"] != "ACME Inc." {
+			t.Errorf("Expected the extracted names to be kept, got %+v", result.Data)
+		}
+	})
+
+	t.Run("Custom resolver", func(t *testing.T) {
+		mock := newMockServer(t, data, data)
+		var queries []types.EntityQuery
+		custom := types.EntityResolverFunc(func(ctx context.Context, query types.EntityQuery) (types.EntityMatch, bool, error) {
+			queries = append(queries, query)
+			// Match on the VAT ID of the seller rather than its name
+			if vatID := query.Data["seller"].(map[string]interface{})["vatId"]; vatID == "ES-B12345678" {
+				return types.EntityMatch{ID: "V-001", Name: "Acme Corporation", Confidence: 1}, true, nil
+			}
+			return types.EntityMatch{}, false, nil
+		})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, EntityResolver: custom})
+		options := types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, ResolveEntities: map[string]string{"seller.name": "vendor"}}
+		result, err := ext.Extract(options)
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if len(queries) != 1 || queries[0].Field != "seller.name" || queries[0].Kind != "vendor" || queries[0].Name != "ACME Inc." {
+			t.Errorf("Expected a query for the seller name, got %+v", queries)
+		}
+		if result.ResolvedEntities["seller.name"].ID != "V-001" {
+			t.Errorf("Expected the seller resolved, got %+v", result.ResolvedEntities)
+		}
+
+		failing := errors.New("master data unavailable")
+		ext, _ = extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, EntityResolver: types.EntityResolverFunc(
+			func(context.Context, types.EntityQuery) (types.EntityMatch, bool, error) {
+				return types.EntityMatch{}, false, failing
+			})})
+		if _, err := ext.Extract(options); !errors.Is(err, failing) {
+			t.Errorf("Expected the error of the resolver, got %v", err)
+		}
+	})
+
+	t.Run("No resolver", func(t *testing.T) {
+		mock := newMockServer(t, data)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, ResolveEntities: map[string]string{"seller.name": "vendor"}})
+		if err == nil || !strings.Contains(err.Error(), "EntityResolver") || len(mock.Requests) != 0 {
+			t.Errorf("Expected an error before calling the model, got %v", err)
+		}
+	})
+
+	t.Run("Similarity", func(t *testing.T) {
+		for _, names := range [][2]string{{"ACME Inc.", "Acme"}, {"Initech S.L.", "INITECH"}, {"Globex GmbH", "globex"}} {
+			if score := extractor.EntitySimilarity(names[0], names[1]); score != 1 {
+				t.Errorf("Expected %q and %q to be the same company, got %g", names[0], names[1], score)
+			}
+		}
+		if score := extractor.EntitySimilarity("Acme", "Globex"); score > 0.5 {
+			t.Errorf("Expected different companies to differ, got %g", score)
+		}
+	})
+}
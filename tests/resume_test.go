@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/hropen"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestExtractResume(t *testing.T) {
+	pdf := newTestPdf([]string{
+		"JANE DOE - jane@example.com - Berlin",
+		"Senior Engineer, Globex (2021-03 - present): Golang, Kubernetes",
+		"Engineer, Initech (2017-09 - 2021-02): Go, Node.js | Skills: GO, NodeJS, SQL",
+	})
+
+	mock := newMockServer(t, map[string]interface{}{
+		"givenName": "Jane", "familyName": "Doe", "email": "jane@example.com", "phone": "", "location": "Berlin", "summary": "",
+		"experience": []map[string]interface{}{
+			{"title": "Engineer", "employer": "Initech", "location": "", "startDate": "2017-09", "endDate": "2021-02", "current": false, "description": "", "skills": []string{"Go", "Node.js"}},
+			{"title": "Senior Engineer", "employer": "Globex", "location": "", "startDate": "2021-03", "endDate": "", "current": true, "description": "", "skills": []string{"Golang", "Kubernetes"}},
+		},
+		"education": []map[string]interface{}{},
+		"skills":    []string{"GO", "NodeJS", "SQL"},
+	})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+
+	result, err := ext.ExtractResume(context.Background(), pdf)
+	if err != nil {
+		t.Fatalf("Expected resume, got error: %v", err)
+	}
+	resume := result.Resume
+
+	if resume.Experience[0].Employer != "Globex" {
+		t.Errorf("Expected most recent position first, got %q", resume.Experience[0].Employer)
+	}
+
+	skills := make(map[string]types.ResumeSkill)
+	for _, skill := range resume.Skills {
+		skills[skill.Name] = skill
+	}
+	if len(resume.Skills) != 4 {
+		t.Fatalf("Expected Go, Node.js, Kubernetes and SQL once each, got %+v", resume.Skills)
+	}
+	if goSkill := skills["Go"]; goSkill.Mentions != 3 || goSkill.FirstUsed != "2017-09" || goSkill.LastUsed != "" {
+		t.Errorf("Expected Go mentioned 3 times since 2017-09 and still in use, got %+v", goSkill)
+	}
+	if node := skills["Node.js"]; node.Mentions != 2 || node.LastUsed != "2021-02" {
+		t.Errorf("Expected Node.js last used 2021-02, got %+v", node)
+	}
+
+	out, err := hropen.ToJSON(&resume)
+	if err != nil {
+		t.Fatalf("Expected HR Open JSON, got error: %v", err)
+	}
+	var candidate struct {
+		Person struct {
+			Name struct {
+				FormattedName string `json:"formattedName"`
+			} `json:"name"`
+		} `json:"person"`
+		Profiles []struct {
+			Employment     []json.RawMessage `json:"employment"`
+			Qualifications []json.RawMessage `json:"qualifications"`
+		} `json:"profiles"`
+	}
+	if err := json.Unmarshal(out, &candidate); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if candidate.Person.Name.FormattedName != "Jane Doe" || len(candidate.Profiles[0].Employment) != 2 || len(candidate.Profiles[0].Qualifications) != 4 {
+		t.Errorf("Unexpected HR Open candidate: %s", out)
+	}
+}
@@ -0,0 +1,235 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/sandbox"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// TestMain lets the test binary serve as the sandbox helper process, with the backends of the
+// tests registered
+func TestMain(m *testing.M) {
+	parser.RegisterBackend(transcriptBackend{pages: []string{"Invoice INV-7 from the transcript", "Total 10.00"}})
+	parser.RegisterBackend(blankBackend{})
+	parser.RegisterBackend(crashingBackend{})
+	parser.RegisterBackend(patchyBackend{})
+	sandbox.Main()
+	os.Exit(m.Run())
+}
+
+func TestSandbox(t *testing.T) {
+	pdf := newTestPdf([]string{"Invoice INV-42 issued to ACME Corporation"}, []string{"Total 24.20"})
+
+	t.Run("Parse", func(t *testing.T) {
+		parsedPdf, err := sandbox.Parse(context.Background(), pdf, &types.ParseOptions{TextThreshold: 10}, types.SandboxConfig{})
+		if err != nil {
+			t.Fatalf("Expected the helper to parse the PDF, got %v", err)
+		}
+		if parsedPdf.Content.Type != "text" || parsedPdf.NumPages != 2 || !strings.Contains(parsedPdf.Content.TextContent, "INV-42") {
+			t.Errorf("Unexpected parsed PDF: %+v", parsedPdf.Content)
+		}
+	})
+
+	t.Run("Malformed PDF", func(t *testing.T) {
+		_, err := sandbox.Parse(context.Background(), []byte("%PDF-1.7 garbage"), nil, types.SandboxConfig{})
+		if err == nil || errors.Is(err, sandbox.ErrCrashed) {
+			t.Errorf("Expected the parse error of the helper, got %v", err)
+		}
+	})
+
+	t.Run("Time limit", func(t *testing.T) {
+		_, err := sandbox.Parse(context.Background(), pdf, nil, types.SandboxConfig{Timeout: time.Millisecond})
+		if !errors.Is(err, sandbox.ErrCrashed) || !strings.Contains(err.Error(), "time limit") {
+			t.Errorf("Expected the time limit to kill the helper, got %v", err)
+		}
+	})
+
+	t.Run("Missing helper", func(t *testing.T) {
+		_, err := sandbox.Parse(context.Background(), pdf, nil, types.SandboxConfig{HelperPath: "/nonexistent/pdf-helper"})
+		if !errors.Is(err, sandbox.ErrCrashed) {
+			t.Errorf("Expected a helper failure, got %v", err)
+		}
+	})
+
+	t.Run("Canceled extraction", func(t *testing.T) {
+		helper := filepath.Join(t.TempDir(), "pdf-helper")
+		if err := os.WriteFile(helper, []byte("#!/bin/sh\nexec sleep 30\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		mock := newMockServer(t, map[string]interface{}{"invoice_number": "INV-42"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test-key", BaseURL: mock.URL, TextThreshold: 10, Sandbox: &types.SandboxConfig{HelperPath: helper, Timeout: time.Minute}})
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"invoice_number": map[string]interface{}{"type": "string"}}}
+
+		// The helper is killed when the context of the extraction is done, not at its time limit
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := ext.ExtractWithContext(ctx, types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, sandbox.ErrCrashed) {
+			t.Errorf("Expected the deadline of the extraction, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected the helper killed at the deadline, took %s", elapsed)
+		}
+		if len(mock.Requests) != 0 {
+			t.Errorf("Expected no model call, got %d", len(mock.Requests))
+		}
+	})
+
+	t.Run("Extractor", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"invoice_number": "INV-42"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test-key", BaseURL: mock.URL, TextThreshold: 10, Sandbox: &types.SandboxConfig{}})
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"invoice_number": map[string]interface{}{"type": "string"}}}
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+		if result.Data["invoice_number"] != "INV-42" || !strings.Contains(mock.userPrompt(0), "Invoice INV-42") {
+			t.Errorf("Expected the text parsed by the helper in the prompt, got %q", mock.userPrompt(0))
+		}
+	})
+}
+
+func TestSandboxErrors(t *testing.T) {
+	pdf := newTestPdf([]string{"Printed text"}, []string{"Second page"})
+
+	// The typed errors of the parser are rebuilt from the answer of the helper
+	t.Run("Render failed", func(t *testing.T) {
+		_, err := sandbox.Parse(context.Background(), pdf, &types.ParseOptions{ForceImages: true, Renderer: "crashing", IsolateRendering: true}, types.SandboxConfig{})
+		if !errors.Is(err, parser.ErrRenderFailed) || errors.Is(err, sandbox.ErrCrashed) {
+			t.Errorf("Expected the panic to fail the render, got %v", err)
+		}
+	})
+
+	t.Run("Render limit", func(t *testing.T) {
+		var limitErr *parser.RenderLimitError
+		_, err := sandbox.Parse(context.Background(), pdf, &types.ParseOptions{ForceImages: true, Renderer: "blank", MaxRenderMemoryBytes: 1 << 20}, types.SandboxConfig{})
+		if !errors.Is(err, parser.ErrRenderLimitExceeded) || !errors.As(err, &limitErr) || limitErr.Limit != parser.LimitMemory || limitErr.Page != 1 {
+			t.Errorf("Expected the raster of the first page to exceed the memory limit, got %v", err)
+		}
+	})
+
+	t.Run("Strict policy", func(t *testing.T) {
+		var pageErrs parser.PageErrors
+		_, err := sandbox.Parse(context.Background(), pdf, &types.ParseOptions{TextBackend: "patchy", TextThreshold: 10, ParsePolicy: types.ParsePolicyStrict}, types.SandboxConfig{})
+		if !errors.As(err, &pageErrs) || len(pageErrs) != 1 || pageErrs[0].Page != 1 || pageErrs[0].Stage != types.PageStageText {
+			t.Errorf("Expected the page errors of the text backend, got %v", err)
+		}
+		if err == nil || !strings.Contains(err.Error(), "failed to read page 1: broken content stream") {
+			t.Errorf("Expected the message of the helper, got %v", err)
+		}
+
+		var pageErr *parser.PageError
+		_, err = sandbox.Parse(context.Background(), pdf, &types.ParseOptions{ForceImages: true, Renderer: "patchy", ParsePolicy: types.ParsePolicyStrict}, types.SandboxConfig{})
+		if !errors.As(err, &pageErr) || pageErr.Page != 1 || pageErr.Stage != types.PageStageRender || pageErr.Err.Error() != "broken image" {
+			t.Errorf("Expected the page error of the renderer, got %v", err)
+		}
+	})
+}
+
+// countingHelper returns a sandbox helper running the test binary, and the number of times it ran
+func countingHelper(t *testing.T) (string, func() int) {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	helper, calls := filepath.Join(dir, "pdf-helper"), filepath.Join(dir, "calls")
+	script := fmt.Sprintf("#!/bin/sh\necho >> '%s'\nexec '%s'\n", calls, executable)
+	if err := os.WriteFile(helper, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return helper, func() int {
+		data, _ := os.ReadFile(calls)
+		return len(data)
+	}
+}
+
+func TestSandboxRouting(t *testing.T) {
+	pdf := newTestPdf([]string{"Invoice INV-42 issued to ACME Corporation"}, []string{""})
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"invoice_number": map[string]interface{}{"type": "string"}}}
+	template := types.LayoutTemplate{
+		Name:    "invoice",
+		Anchors: []types.TemplateAnchor{{Text: "Invoice", Page: 1, Region: types.Rect{Width: 1, Height: 1}}},
+		Fields:  []types.TemplateField{{Name: "invoice_number", Page: 1, Region: types.Rect{Width: 1, Height: 1}, Pattern: `INV-\d+`}},
+	}
+	fields := []types.FormField{{Name: "paid", Kind: types.FormFieldCheckbox, Page: 1, Region: types.Rect{X: 0.1, Y: 0.1, Width: 0.05, Height: 0.05}}}
+
+	// Every feature opening the PDF does it in the helper, and only there
+	tests := []struct {
+		name string
+		// calls is the number of times the helper runs
+		calls   int
+		payload map[string]interface{}
+		config  types.ExtractorConfig
+		run     func(ext *extractor.Extractor) error
+	}{
+		{"marks", 1, map[string]interface{}{"marks": []interface{}{}}, types.ExtractorConfig{VisionEnabled: true}, func(ext *extractor.Extractor) error {
+			_, err := ext.DetectMarks(context.Background(), pdf)
+			return err
+		}},
+		{"form fields", 1, map[string]interface{}{"field1": true}, types.ExtractorConfig{VisionEnabled: true}, func(ext *extractor.Extractor) error {
+			_, err := ext.DetectFormFields(context.Background(), pdf, fields)
+			return err
+		}},
+		{"searchable", 3, map[string]interface{}{"lines": []interface{}{}}, types.ExtractorConfig{VisionEnabled: true}, func(ext *extractor.Extractor) error {
+			_, err := ext.MakeSearchable(context.Background(), pdf)
+			return err
+		}},
+		{"template", 2, map[string]interface{}{}, types.ExtractorConfig{Templates: []types.LayoutTemplate{template}}, func(ext *extractor.Extractor) error {
+			result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+			if err == nil && (result.Template != "invoice" || result.Data["invoice_number"] != "INV-42") {
+				err = fmt.Errorf("expected the data of the template, got %v", result.Data)
+			}
+			return err
+		}},
+		{"fingerprint", 2, map[string]interface{}{"invoice_number": "INV-42"}, types.ExtractorConfig{}, func(ext *extractor.Extractor) error {
+			result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, Fingerprint: true})
+			if err != nil {
+				return err
+			}
+			if expected, _ := parser.Fingerprint(pdf); !reflect.DeepEqual(result.Fingerprint, expected) {
+				return fmt.Errorf("expected fingerprint %+v, got %+v", expected, result.Fingerprint)
+			}
+			return nil
+		}},
+		{"cross-check", 2, map[string]interface{}{"invoice_number": "INV-7"}, types.ExtractorConfig{VisionEnabled: true, CrossCheckBackend: "transcript"}, func(ext *extractor.Extractor) error {
+			_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, ForceMode: types.ForceModeVision, CrossCheckOCR: true})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockServer(t, tt.payload)
+			helper, calls := countingHelper(t)
+			config := tt.config
+			config.OpenAIAPIKey, config.BaseURL, config.TextThreshold = "test", mock.URL, 10
+			config.Sandbox = &types.SandboxConfig{HelperPath: helper}
+			ext, _ := extractor.New(config)
+			if err := tt.run(ext); err != nil {
+				t.Fatalf("Expected the helper to open the PDF, got %v", err)
+			}
+			if calls() != tt.calls {
+				t.Errorf("Expected %d runs of the helper, got %d", tt.calls, calls())
+			}
+
+			config.Sandbox = &types.SandboxConfig{HelperPath: "/nonexistent/pdf-helper"}
+			ext, _ = extractor.New(config)
+			if err := tt.run(ext); !errors.Is(err, sandbox.ErrCrashed) {
+				t.Errorf("Expected a helper failure, got %v", err)
+			}
+		})
+	}
+}
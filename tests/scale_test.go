@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"fmt"
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// resolutionBackend records the resolution each page is rendered at
+type resolutionBackend struct{ resolutions map[int]float64 }
+
+func (resolutionBackend) Name() string { return "resolution" }
+
+func (b resolutionBackend) Render(_ []byte, pages []int, dpi float64, page func(int, *image.RGBA) error) error {
+	for _, p := range pages {
+		b.resolutions[p] = dpi
+		if err := page(p, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestPixelBudget(t *testing.T) {
+	resolutions := map[int]float64{}
+	parser.RegisterBackend(resolutionBackend{resolutions: resolutions})
+
+	// A Letter page, an A6 receipt and an A0 drawing
+	pdf := newTestPdf([]string{"Letter"}, []string{"Receipt"}, []string{"Drawing"})
+	objects := map[int]string{}
+	for page, box := range map[int]string{2: "0 0 298 420", 3: "0 0 2384 3370"} {
+		objects[testPageObject(page)] = fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [%s] /Contents %d 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> >>",
+			box, testPageObject(page)-1)
+	}
+	pdf = withObjects(pdf, objects)
+
+	parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "resolution", PixelBudget: 1000000})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	if len(parsedPdf.Content.ImageContent) != 3 || parsedPdf.Content.ImageContent[2].Page != 3 {
+		t.Errorf("Expected the 3 pages in order, got %+v", parsedPdf.Content.ImageContent)
+	}
+	// sqrt(1,000,000 px / (612 x 792 pt)) x 72 is 103 DPI; the A0 page is kept at the 72 DPI floor
+	expected := map[int]float64{1: 103, 2: 203, 3: 72}
+	if !reflect.DeepEqual(resolutions, expected) {
+		t.Errorf("Expected resolutions %v, got %v", expected, resolutions)
+	}
+
+	clear(resolutions)
+	if _, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "resolution", DPI: 150}); err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	if !reflect.DeepEqual(resolutions, map[int]float64{1: 150, 2: 150, 3: 150}) {
+		t.Errorf("Expected every page at 150 DPI without a pixel budget, got %v", resolutions)
+	}
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestMultipleSchemas(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{
+		"invoice": map[string]interface{}{"number": "INV-42", "total": 24.2},
+		"parties": map[string]interface{}{"seller": "ACME Corporation"},
+	})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+	pdf := newTestPdf([]string{"Invoice INV-42 from ACME Corporation", "Total 24.20"})
+	schemas := map[string]map[string]interface{}{
+		"invoice": {"type": "object", "properties": map[string]interface{}{
+			"number": map[string]interface{}{"type": "string"},
+			"total":  map[string]interface{}{"type": "number"},
+		}},
+		"parties": {"type": "object", "properties": map[string]interface{}{"seller": map[string]interface{}{"type": "string"}}},
+	}
+
+	result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schemas: schemas})
+	if err != nil {
+		t.Fatalf("Expected extraction to succeed, got %v", err)
+	}
+	if len(mock.Requests) != 1 {
+		t.Fatalf("Expected a single model call, got %d", len(mock.Requests))
+	}
+	if result.SchemaData["invoice"]["number"] != "INV-42" || result.SchemaData["parties"]["seller"] != "ACME Corporation" {
+		t.Errorf("Expected the data split by schema, got %v", result.SchemaData)
+	}
+
+	format := mock.Requests[0]["response_format"].(map[string]interface{})
+	combined := format["json_schema"].(map[string]interface{})["schema"].(map[string]interface{})
+	required, _ := combined["required"].([]interface{})
+	if !slices.Equal(required, []interface{}{"invoice", "parties"}) || combined["additionalProperties"] != false {
+		t.Errorf("Unexpected combined schema: %v", combined)
+	}
+
+	_, err = ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schemas: schemas, Schema: schemas["invoice"]})
+	if err == nil || !strings.Contains(err.Error(), "cannot both be set") {
+		t.Errorf("Expected Schema and Schemas to be exclusive, got %v", err)
+	}
+	_, err = ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schemas: map[string]map[string]interface{}{"empty": {}}})
+	if err == nil || !strings.Contains(err.Error(), `invalid JSON schema "empty"`) {
+		t.Errorf("Expected the invalid schema to be named, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestMakeSearchable(t *testing.T) {
+	// A page without text, as a scanned page would be
+	pdf := newTestPdfFromContent("0 0 1 rg 100 600 200 50 re f")
+	transcription := map[string]interface{}{
+		"lines": []interface{}{
+			map[string]interface{}{
+				"text":   "Invoice 42",
+				"bounds": map[string]interface{}{"x": 0.1, "y": 0.2, "width": 0.3, "height": 0.03},
+			},
+		},
+	}
+	mock := newMockServer(t, transcription)
+	ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true})
+	if err != nil {
+		t.Fatalf("Failed to create extractor: %v", err)
+	}
+
+	result, err := ext.MakeSearchable(context.Background(), pdf)
+	if err != nil {
+		t.Fatalf("Expected searchable PDF, got error: %v", err)
+	}
+	if len(mock.Requests) != 1 || result.TokensUsed != 42 {
+		t.Errorf("Expected one transcription call, got %d calls and %d tokens", len(mock.Requests), result.TokensUsed)
+	}
+
+	parsed, err := parser.ParsePdfFromBuffer(result.PDF, &types.ParseOptions{TextThreshold: 1})
+	if err != nil {
+		t.Fatalf("Expected the searchable PDF to parse, got error: %v", err)
+	}
+	if parsed.Content.Type != "text" || strings.TrimSpace(parsed.Content.TextContent) != "Invoice 42" {
+		t.Fatalf("Expected the transcribed line in the text layer, got %q", parsed.Content.TextContent)
+	}
+	if len(result.Pages) != 1 || result.Pages[0].Page != 1 || result.Pages[0].Lines[0].Bounds.Width != 0.3 {
+		t.Errorf("Unexpected transcribed pages: %+v", result.Pages)
+	}
+
+	t.Run("pages with text are left as they are", func(t *testing.T) {
+		pdf := newTestPdf([]string{"Invoice 42"})
+		mock := newMockServer(t, transcription)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true})
+		result, err := ext.MakeSearchable(context.Background(), pdf)
+		if err != nil {
+			t.Fatalf("Expected searchable PDF, got error: %v", err)
+		}
+		if len(mock.Requests) != 0 || string(result.PDF) != string(pdf) {
+			t.Errorf("Expected the PDF to be returned unchanged without API calls, got %d calls", len(mock.Requests))
+		}
+	})
+
+	t.Run("requires vision", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+		if _, err := ext.MakeSearchable(context.Background(), pdf); err == nil {
+			t.Error("Expected error without vision")
+		}
+	})
+
+	t.Run("extraction option", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"total": 42}, transcription)
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true})
+		result, err := ext.Extract(types.ExtractionOptions{
+			PDFBuffer:     pdf,
+			Schema:        map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "number"}}},
+			SearchablePDF: true,
+		})
+		if err != nil {
+			t.Fatalf("Expected extraction to succeed, got error: %v", err)
+		}
+		parsed, err := parser.ParsePdfFromBuffer(result.SearchablePDF, &types.ParseOptions{TextThreshold: 1})
+		if err != nil {
+			t.Fatalf("Expected the searchable PDF to parse, got error: %v", err)
+		}
+		if !strings.Contains(parsed.Content.TextContent, "Invoice 42") {
+			t.Errorf("Expected the transcription in the text of the searchable PDF, got %q", parsed.Content.TextContent)
+		}
+	})
+}
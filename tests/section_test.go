@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestSection(t *testing.T) {
+	pages := [][]string{
+		{"MASTER SERVICES AGREEMENT", "1. Services. The provider hosts the platform."},
+		{"2. Fees. The customer pays the fees of Schedule A.", "3. Term. Three years."},
+		{"Schedule A", "Hosting: 100 EUR per month", "Support: 20 EUR per month"},
+		{"Schedule B", "Service levels: 99.9% uptime"},
+	}
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"monthlyFees": map[string]interface{}{"type": "number"}},
+	}
+	newExtractor := func(t *testing.T) (*extractor.Extractor, *mockServer) {
+		mock := newMockServer(t, map[string]interface{}{"monthlyFees": 120})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		return ext, mock
+	}
+
+	t.Run("Heading", func(t *testing.T) {
+		ext, mock := newExtractor(t)
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf(pages...), Schema: schema, Section: "schedule a"}); err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		prompt := mock.userPrompt(0)
+		if !strings.Contains(prompt, "Hosting: 100 EUR") || strings.Contains(prompt, "1. Services") || strings.Contains(prompt, "Service levels") {
+			t.Errorf("Expected the text of Schedule A only, got %q", prompt)
+		}
+		if !strings.Contains(prompt, `section "schedule a"`) {
+			t.Errorf("Expected the model to be told about the section, got %q", prompt)
+		}
+	})
+
+	t.Run("Numbered heading", func(t *testing.T) {
+		ext, mock := newExtractor(t)
+		for i, section := range []string{"2. Fees", "Fees"} {
+			if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf(pages...), Schema: schema, Section: section}); err != nil {
+				t.Fatalf("Expected extraction of %q, got error: %v", section, err)
+			}
+			if prompt := mock.userPrompt(i); !strings.Contains(prompt, "fees of Schedule A.") || strings.Contains(prompt, "Three years") || strings.Contains(prompt, "Hosting") {
+				t.Errorf("Expected the text of %q up to the next numbered heading, got %q", section, prompt)
+			}
+		}
+	})
+
+	t.Run("Outline", func(t *testing.T) {
+		pdf := withOutline(newTestPdf(pages...), []types.OutlineItem{
+			{Title: "Agreement", Page: 1},
+			{Title: "Schedules", Page: 3, Children: []types.OutlineItem{
+				{Title: "Schedule A - Pricing", Page: 3},
+				{Title: "Schedule B - Service levels", Page: 4},
+			}},
+		})
+		ext, mock := newExtractor(t)
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, Section: "Schedules > Schedule A"}); err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if prompt := mock.userPrompt(0); !strings.Contains(prompt, "Support: 20 EUR") || strings.Contains(prompt, "Service levels") || strings.Contains(prompt, "2. Fees") {
+			t.Errorf("Expected the pages of Schedule A only, got %q", prompt)
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		ext, mock := newExtractor(t)
+		_, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf(pages...), Schema: schema, Section: "Schedule C"})
+		if !errors.Is(err, extractor.ErrSectionNotFound) {
+			t.Errorf("Expected ErrSectionNotFound, got %v", err)
+		}
+		if len(mock.Requests) != 0 {
+			t.Errorf("Expected no model call, got %d", len(mock.Requests))
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/auth"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const signerResponse = `{"model":"gpt-4o-mini","choices":[{"message":{"content":"{\"total\":\"24.20\"}"}}],"usage":{"total_tokens":42}}`
+
+func signerOptions() types.ExtractionOptions {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	return types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Receipt", "Total 24.20"}), Schema: schema}
+}
+
+func TestHMACSigner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		expected := auth.Signature("s3cret", r.Method, r.URL.RequestURI(), r.Header.Get("X-Timestamp"), body)
+		if r.Header.Get("X-Key-Id") != "extractor" || r.Header.Get("X-Signature") != expected || r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Expected no Bearer key, got %q", r.Header.Get("Authorization"))
+		}
+		fmt.Fprint(w, signerResponse)
+	}))
+	defer server.Close()
+
+	signer, err := auth.NewHMAC(auth.HMACConfig{KeyID: "extractor", Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	ext, err := extractor.New(types.ExtractorConfig{BaseURL: server.URL, TextThreshold: 10, Signer: auth.Chain{auth.Headers{"X-Tenant": "acme"}, signer}})
+	if err != nil {
+		t.Fatalf("Expected extractor without API key, got error: %v", err)
+	}
+	if _, err := ext.Extract(signerOptions()); err != nil {
+		t.Fatalf("Expected signed request to be accepted, got %v", err)
+	}
+
+	if _, err := auth.NewHMAC(auth.HMACConfig{KeyID: "extractor"}); err == nil {
+		t.Error("Expected error without secret")
+	}
+}
+
+func TestOAuth2Signer(t *testing.T) {
+	var issued, current atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "models.read models.invoke" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+		n := issued.Add(1)
+		current.Store(n)
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", current.Load()) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, signerResponse)
+	}))
+	defer server.Close()
+
+	signer, err := auth.NewOAuth2(auth.OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"models.read", "models.invoke"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	ext, err := extractor.New(types.ExtractorConfig{BaseURL: server.URL, TextThreshold: 10, Signer: signer})
+	if err != nil {
+		t.Fatalf("Failed to create extractor: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := ext.Extract(signerOptions()); err != nil {
+			t.Fatalf("Expected extraction to succeed, got %v", err)
+		}
+	}
+	if issued.Load() != 1 {
+		t.Errorf("Expected the token to be requested once and cached, got %d requests", issued.Load())
+	}
+
+	// Revoke the token on the gateway
+	current.Store(0)
+	issued.Store(0)
+	if _, err := ext.Extract(signerOptions()); err != nil {
+		t.Fatalf("Expected the rejected token to be refreshed, got %v", err)
+	}
+	if issued.Load() != 1 {
+		t.Errorf("Expected a new token after the rejection, got %d requests", issued.Load())
+	}
+
+	wrong, _ := auth.NewOAuth2(auth.OAuth2Config{TokenURL: tokenServer.URL, ClientID: "client", ClientSecret: "wrong"})
+	failing, _ := extractor.New(types.ExtractorConfig{BaseURL: server.URL, TextThreshold: 10, Signer: wrong})
+	if _, err := failing.Extract(signerOptions()); err == nil {
+		t.Error("Expected the token error to fail the extraction")
+	}
+}
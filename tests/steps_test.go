@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// roundTrip serializes a step's output and decodes it into out, as a workflow engine would
+// between activities
+func roundTrip(t *testing.T, in, out interface{}) {
+	t.Helper()
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Failed to serialize state: %v", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatalf("Failed to deserialize state: %v", err)
+	}
+}
+
+func TestExtractionSteps(t *testing.T) {
+	mock := newMockServer(t, map[string]interface{}{"title": "Quarterly report"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+	maxTokens := 100
+	options := types.ExtractionOptions{
+		PDFBuffer: newTestPdf([]string{"Quarterly report for the first quarter"}),
+		Schema:    map[string]interface{}{"type": "object", "properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}}},
+		MaxTokens: &maxTokens,
+	}
+
+	parsed, err := ext.Parse(options)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	var parsedState types.ParsedPdf
+	roundTrip(t, parsed, &parsedState)
+
+	request, err := ext.BuildRequest(&parsedState, options)
+	if err != nil {
+		t.Fatalf("BuildRequest failed: %v", err)
+	}
+	if len(mock.Requests) != 0 {
+		t.Fatalf("Expected no API call before CallModel, got %d", len(mock.Requests))
+	}
+	var requestState types.ModelRequest
+	roundTrip(t, request, &requestState)
+
+	response, err := ext.CallModel(context.Background(), &requestState)
+	if err != nil {
+		t.Fatalf("CallModel failed: %v", err)
+	}
+	if len(mock.Requests) != 1 || mock.Requests[0]["max_tokens"] != float64(100) {
+		t.Fatalf("Expected one API call with the request options, got %+v", mock.Requests)
+	}
+	var responseState types.ModelResponse
+	roundTrip(t, response, &responseState)
+
+	result, err := ext.ParseResult(&responseState)
+	if err != nil {
+		t.Fatalf("ParseResult failed: %v", err)
+	}
+	if result.Data["title"] != "Quarterly report" || result.TokensUsed != 42 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if prompt := mock.userPrompt(0); !strings.Contains(prompt, "Quarterly report for the first quarter") {
+		t.Errorf("Expected the text of the PDF in the prompt, got %q", prompt)
+	}
+}
@@ -0,0 +1,266 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/store"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestStore(t *testing.T) {
+	schema := map[string]interface{}{
+		"title":      "invoice",
+		"type":       "object",
+		"properties": map[string]interface{}{"invoiceNumber": map[string]interface{}{"type": "string"}},
+	}
+	pdf := newTestPdf([]string{"Invoice INV-001"})
+	sum := sha256.Sum256(pdf)
+	documentHash := hex.EncodeToString(sum[:])
+	ctx := context.Background()
+
+	history := store.NewMemory()
+	mock := newMockServer(t, map[string]interface{}{"invoiceNumber": "INV-001"}, rawContent("not JSON"))
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Store: history})
+	result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, DocumentID: "inv-1", SchemaVersion: "2"})
+	if err != nil {
+		t.Fatalf("Expected extraction, got error: %v", err)
+	}
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, DocumentID: "inv-1", SchemaVersion: "2"}); err == nil {
+		t.Fatal("Expected the second extraction to fail")
+	}
+
+	extractions, err := history.GetByDocumentHash(ctx, documentHash)
+	if err != nil || len(extractions) != 2 {
+		t.Fatalf("Expected both extractions of the document, got %+v, %v", extractions, err)
+	}
+	failed, succeeded := extractions[0], extractions[1]
+	if succeeded.Status != types.BatchStatusSuccess || succeeded.DocumentID != "inv-1" || succeeded.Data["invoiceNumber"] != "INV-001" || succeeded.TokensUsed != result.TokensUsed {
+		t.Errorf("Expected the completed extraction, got %+v", succeeded)
+	}
+	if succeeded.SchemaName != "invoice" || succeeded.SchemaVersion != "2" || succeeded.SchemaHash != result.SchemaHash {
+		t.Errorf("Expected the schema of the completed extraction, got %+v", succeeded)
+	}
+	if failed.Status != types.BatchStatusError || failed.Error == "" || failed.Data != nil || failed.SchemaHash != result.SchemaHash {
+		t.Errorf("Expected the failed extraction with its schema, got %+v", failed)
+	}
+
+	if listed, err := history.ListByStatus(ctx, types.BatchStatusError, 0); err != nil || len(listed) != 1 || listed[0].Error != failed.Error {
+		t.Errorf("Expected the failed extraction listed, got %+v, %v", listed, err)
+	}
+	history.SaveExtraction(ctx, types.StoredExtraction{DocumentID: "inv-2", Status: types.BatchStatusSuccess})
+	if listed, _ := history.ListByStatus(ctx, types.BatchStatusSuccess, 1); len(listed) != 1 || listed[0].DocumentID != "inv-2" {
+		t.Errorf("Expected the latest completed extraction, got %+v", listed)
+	}
+	if extractions, _ := history.GetByDocumentHash(ctx, "unknown"); len(extractions) != 0 {
+		t.Errorf("Expected no extraction of an unknown document, got %+v", extractions)
+	}
+}
+
+// storedExtractions returns a completed and a failed extraction of a document, in a time zone other
+// than UTC, with nanoseconds
+func storedExtractions() (types.StoredExtraction, types.StoredExtraction) {
+	end := time.Date(2026, 3, 14, 9, 26, 53, 589793238, time.FixedZone("CET", 3600))
+	succeeded := types.StoredExtraction{
+		DocumentID: "inv-1", DocumentHash: "abc", Status: types.BatchStatusSuccess,
+		Data:  map[string]interface{}{"invoiceNumber": "INV-001", "lines": []interface{}{map[string]interface{}{"total": 12.5}}},
+		Model: "gpt-4o-mini", TokensUsed: 42, SchemaName: "invoice", SchemaHash: "def", SchemaVersion: "2",
+		LibraryVersion: "1.0.0", Time: end, DurationMs: 1200,
+	}
+	failed := types.StoredExtraction{
+		DocumentID: "inv-1", DocumentHash: "abc", Status: types.BatchStatusError, Error: "invalid JSON",
+		Model: "gpt-4o-mini", SchemaName: "invoice", SchemaHash: "def", Time: end.Add(time.Minute), DurationMs: 800,
+	}
+	return succeeded, failed
+}
+
+func TestSQLiteStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// Each connection opens its own in-memory database
+	db.SetMaxOpenConns(1)
+
+	history, err := store.NewSQLite(ctx, db)
+	if err != nil {
+		t.Fatalf("Expected a store, got error: %v", err)
+	}
+	// The table is created once
+	if history, err = store.NewSQLite(ctx, db); err != nil {
+		t.Fatalf("Expected a store on the existing table, got error: %v", err)
+	}
+
+	succeeded, failed := storedExtractions()
+	for _, extraction := range []types.StoredExtraction{succeeded, failed} {
+		if err := history.SaveExtraction(ctx, extraction); err != nil {
+			t.Fatalf("Expected the extraction saved, got error: %v", err)
+		}
+	}
+	// Times are read back in UTC
+	succeeded.Time, failed.Time = succeeded.Time.UTC(), failed.Time.UTC()
+
+	extractions, err := history.GetByDocumentHash(ctx, "abc")
+	if err != nil {
+		t.Fatalf("Expected the extractions, got error: %v", err)
+	}
+	if !reflect.DeepEqual(extractions, []types.StoredExtraction{failed, succeeded}) {
+		t.Errorf("Expected the extractions, the latest first, got %+v", extractions)
+	}
+	if listed, err := history.ListByStatus(ctx, types.BatchStatusSuccess, 0); err != nil || !reflect.DeepEqual(listed, []types.StoredExtraction{succeeded}) {
+		t.Errorf("Expected the completed extraction, got %+v, %v", listed, err)
+	}
+	history.SaveExtraction(ctx, types.StoredExtraction{DocumentID: "inv-2", Status: types.BatchStatusError, Time: time.Now()})
+	if listed, _ := history.ListByStatus(ctx, types.BatchStatusError, 1); len(listed) != 1 || listed[0].DocumentID != "inv-2" {
+		t.Errorf("Expected the latest failed extraction, got %+v", listed)
+	}
+	if extractions, err := history.GetByDocumentHash(ctx, "unknown"); err != nil || len(extractions) != 0 {
+		t.Errorf("Expected no extraction of an unknown document, got %+v, %v", extractions, err)
+	}
+
+	// The extractor saves its extractions to the store
+	pdf := newTestPdf([]string{"Invoice INV-001"})
+	mock := newMockServer(t, map[string]interface{}{"invoiceNumber": "INV-001"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Store: history})
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"invoiceNumber": map[string]interface{}{"type": "string"}}}
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, DocumentID: "inv-3"}); err != nil {
+		t.Fatalf("Expected extraction, got error: %v", err)
+	}
+	if listed, _ := history.ListByStatus(ctx, types.BatchStatusSuccess, 1); len(listed) != 1 || listed[0].DocumentID != "inv-3" || listed[0].Data["invoiceNumber"] != "INV-001" {
+		t.Errorf("Expected the extraction saved, got %+v", listed)
+	}
+}
+
+// storeColumns are the columns of the extractions table, in the order of StoredExtraction
+var storeColumns = []string{
+	"document_id", "document_hash", "status", "error", "data", "model", "tokens_used",
+	"schema_name", "schema_hash", "schema_version", "library_version", "time", "duration_ms",
+}
+
+// storeRow returns the values of the row of an extraction, with data as JSON text and a time
+// value as a driver reads it
+func storeRow(extraction types.StoredExtraction, data interface{}, storedTime interface{}) []driver.Value {
+	return []driver.Value{
+		extraction.DocumentID, extraction.DocumentHash, string(extraction.Status), extraction.Error, data,
+		extraction.Model, int64(extraction.TokensUsed), extraction.SchemaName, extraction.SchemaHash,
+		extraction.SchemaVersion, extraction.LibraryVersion, storedTime, extraction.DurationMs,
+	}
+}
+
+func TestPostgresStore(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`(?s)CREATE TABLE IF NOT EXISTS extractions \(\s+id BIGSERIAL PRIMARY KEY,.*data JSONB,.*time TIMESTAMPTZ NOT NULL,\s+duration_ms BIGINT NOT NULL\s+\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE INDEX IF NOT EXISTS extractions_document_hash ON extractions (document_hash)`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE INDEX IF NOT EXISTS extractions_status ON extractions (status, id)`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	history, err := store.NewPostgres(ctx, db)
+	if err != nil {
+		t.Fatalf("Expected a store, got error: %v", err)
+	}
+
+	// Parameters are numbered, and times are stored as timestamps in UTC
+	succeeded, failed := storedExtractions()
+	insert := regexp.QuoteMeta(`INSERT INTO extractions (` + strings.Join(storeColumns, ", ") + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`)
+	mock.ExpectExec(insert).
+		WithArgs(storeRow(succeeded, `{"invoiceNumber":"INV-001","lines":[{"total":12.5}]}`, succeeded.Time.UTC())...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(insert).
+		WithArgs(storeRow(failed, nil, failed.Time.UTC())...).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	for _, extraction := range []types.StoredExtraction{succeeded, failed} {
+		if err := history.SaveExtraction(ctx, extraction); err != nil {
+			t.Fatalf("Expected the extraction saved, got error: %v", err)
+		}
+	}
+
+	// Timestamps are read as times, in the time zone of the session
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT ` + strings.Join(storeColumns, ", ") + ` FROM extractions WHERE status = $1 ORDER BY id DESC LIMIT 5`)).
+		WithArgs("error").
+		WillReturnRows(sqlmock.NewRows(storeColumns).AddRow(storeRow(failed, nil, failed.Time)...))
+	listed, err := history.ListByStatus(ctx, types.BatchStatusError, 5)
+	failed.Time = failed.Time.UTC()
+	if err != nil || !reflect.DeepEqual(listed, []types.StoredExtraction{failed}) {
+		t.Errorf("Expected the failed extraction, got %+v, %v", listed, err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT `+strings.Join(storeColumns, ", ")+` FROM extractions WHERE document_hash = $1 ORDER BY id DESC`) + `$`).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows(storeColumns).AddRow(storeRow(succeeded, []byte(`{"invoiceNumber":"INV-001","lines":[{"total":12.5}]}`), succeeded.Time)...))
+	extractions, err := history.GetByDocumentHash(ctx, "abc")
+	succeeded.Time = succeeded.Time.UTC()
+	if err != nil || !reflect.DeepEqual(extractions, []types.StoredExtraction{succeeded}) {
+		t.Errorf("Expected the completed extraction, got %+v, %v", extractions, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSQLStoreTimes(t *testing.T) {
+	end := time.Date(2026, 3, 14, 8, 26, 53, 589793238, time.UTC)
+
+	// Drivers read the RFC 3339 text of SQLite as a string or as bytes, and PostgreSQL timestamps as
+	// times
+	tests := []struct {
+		name       string
+		storedTime interface{}
+		wantErr    bool
+	}{
+		{"text", "2026-03-14T08:26:53.589793238Z", false},
+		{"bytes", []byte("2026-03-14T08:26:53.589793238Z"), false},
+		{"text with offset", "2026-03-14T09:26:53.589793238+01:00", false},
+		{"timestamp", end.In(time.FixedZone("CET", 3600)), false},
+		{"invalid text", "14/03/2026", true},
+		{"unexpected type", int64(1773476813), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			for i := 0; i < 3; i++ {
+				mock.ExpectExec("CREATE").WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+			history, err := store.NewSQLite(context.Background(), db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery(regexp.QuoteMeta(`FROM extractions WHERE document_hash = ? ORDER BY id DESC`)).
+				WithArgs("abc").
+				WillReturnRows(sqlmock.NewRows(storeColumns).AddRow(storeRow(types.StoredExtraction{DocumentID: "inv-1", DocumentHash: "abc", Status: types.BatchStatusSuccess}, nil, tt.storedTime)...))
+			extractions, err := history.GetByDocumentHash(context.Background(), "abc")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", extractions)
+				}
+				return
+			}
+			if err != nil || len(extractions) != 1 || !extractions[0].Time.Equal(end) {
+				t.Errorf("Expected the time %s, got %+v, %v", end, extractions, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestSummarize(t *testing.T) {
+	pdf := newTestPdf([]string{
+		"Quarterly report of ACME Corporation for the third quarter of 2024.",
+		"Revenue grew by twelve percent compared to the previous quarter.",
+		"The board appointed Jane Doe as the new chief financial officer.",
+	})
+
+	t.Run("Returns typed summary", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{
+			"title":     "ACME Q3 2024 report",
+			"abstract":  "ACME grew revenue and appointed a new CFO.",
+			"keyPoints": []string{"Revenue grew 12%", "New CFO"},
+			"entities": []map[string]interface{}{
+				{"name": "ACME Corporation", "type": "organization"},
+				{"name": "Jane Doe", "type": "person"},
+			},
+		})
+
+		ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL})
+		if err != nil {
+			t.Fatalf("Failed to create extractor: %v", err)
+		}
+
+		summary, err := ext.Summarize(context.Background(), pdf, types.SummarizeOptions{
+			Length: types.SummaryLengthShort,
+			Focus:  "financial results",
+		})
+		if err != nil {
+			t.Fatalf("Expected summary, got error: %v", err)
+		}
+
+		if summary.Title != "ACME Q3 2024 report" {
+			t.Errorf("Unexpected title: %q", summary.Title)
+		}
+		if len(summary.KeyPoints) != 2 || len(summary.Entities) != 2 {
+			t.Errorf("Unexpected key points or entities: %+v", summary)
+		}
+		if summary.Entities[1].Type != "person" {
+			t.Errorf("Expected second entity to be a person, got %q", summary.Entities[1].Type)
+		}
+		if summary.TokensUsed != 42 {
+			t.Errorf("Expected 42 tokens used, got %d", summary.TokensUsed)
+		}
+		if prompt := mock.userPrompt(0); !strings.Contains(prompt, "financial results") {
+			t.Errorf("Expected focus in prompt, got %q", prompt)
+		}
+	})
+
+	t.Run("Invalid length", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test"})
+		_, err := ext.Summarize(context.Background(), pdf, types.SummarizeOptions{Length: "huge"})
+		if err == nil {
+			t.Error("Expected error for invalid length")
+		}
+	})
+
+	t.Run("Invalid input type", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test"})
+		_, err := ext.Summarize(context.Background(), 42, types.SummarizeOptions{})
+		if err == nil {
+			t.Error("Expected error for unsupported input type")
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// lineRegion returns the region of a line of a page built by newTestPdf, relative to the page size
+func lineRegion(line int) types.Rect {
+	return types.Rect{X: 0, Y: (64 + 16*float64(line)) / 792, Width: 1, Height: 16.0 / 792}
+}
+
+func TestTemplates(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"taxpayer":  map[string]interface{}{"type": "string"},
+			"amount":    map[string]interface{}{"type": "number"},
+			"year":      map[string]interface{}{"type": "integer"},
+			"reference": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"id": map[string]interface{}{"type": "string"}}},
+		},
+		"required": []interface{}{"taxpayer", "amount"},
+	}
+	template := types.LayoutTemplate{
+		Name:    "form-100",
+		Anchors: []types.TemplateAnchor{{Text: "Annual return", Page: 1, Region: types.Rect{X: 0, Y: 0, Width: 1, Height: 0.2}}},
+		Fields: []types.TemplateField{
+			{Name: "taxpayer", Page: 1, Region: lineRegion(1), Pattern: `Taxpayer: (.+)`},
+			{Name: "amount", Page: 1, Region: lineRegion(2), Pattern: `[\d.,]+$`},
+			{Name: "year", Page: 1, Region: lineRegion(3), Pattern: `\d{4}`},
+			{Name: "reference.id", Page: 1, Region: lineRegion(4), Pattern: `Reference (\S+)`},
+		},
+	}
+	form := newTestPdf([]string{"FORM 100 - Annual return", "Taxpayer: Jane Doe", "Amount due: 9,876.54", "Tax year 2024", "Reference REF-77"})
+
+	t.Run("Match", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"taxpayer": "from the model", "amount": 1})
+		ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, Templates: []types.LayoutTemplate{template}})
+		if err != nil {
+			t.Fatalf("Expected extractor, got error: %v", err)
+		}
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: form, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		expected := map[string]interface{}{"taxpayer": "Jane Doe", "amount": 9876.54, "year": int64(2024), "reference": map[string]interface{}{"id": "REF-77"}}
+		if !reflect.DeepEqual(result.Data, expected) {
+			t.Errorf("Expected data %v, got %v", expected, result.Data)
+		}
+		if result.Template != "form-100" || len(mock.Requests) != 0 {
+			t.Errorf("Expected the template without calling the model, got template %q and %d requests", result.Template, len(mock.Requests))
+		}
+
+		// Other documents, and documents skipping templates, are extracted by the model
+		other := newTestPdf([]string{"FORM 200 - Quarterly report", "Taxpayer: Jane Doe"})
+		for _, options := range []types.ExtractionOptions{{PDFBuffer: other, Schema: schema}, {PDFBuffer: form, Schema: schema, SkipTemplates: true}} {
+			result, err := ext.Extract(options)
+			if err != nil || result.Template != "" || result.Data["taxpayer"] != "from the model" {
+				t.Errorf("Expected extraction by the model, got %+v, %v", result, err)
+			}
+		}
+		if len(mock.Requests) != 2 {
+			t.Errorf("Expected 2 model calls, got %d", len(mock.Requests))
+		}
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"taxpayer": "Jane Doe", "amount": 1234.56})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		// The amount is missing from this template, while the schema requires it
+		partial := template
+		partial.Name = "form-100-partial"
+		partial.Fields = template.Fields[:1]
+		if err := ext.RegisterTemplate(partial); err != nil {
+			t.Fatalf("Expected template registered, got error: %v", err)
+		}
+		result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: form, Schema: schema})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if result.Template != "" || len(mock.Requests) != 1 || result.Data["amount"] != 1234.56 {
+			t.Errorf("Expected extraction by the model, got %+v", result)
+		}
+		if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], `template "form-100-partial" matched but its data does not fit the schema`) {
+			t.Errorf("Expected a warning about the template, got %q", result.Warnings)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test"})
+		for _, invalid := range []types.LayoutTemplate{
+			{Fields: template.Fields, Anchors: template.Anchors},
+			{Name: "no anchors", Fields: template.Fields},
+			{Name: "bad region", Anchors: template.Anchors, Fields: []types.TemplateField{{Name: "a", Page: 1, Region: types.Rect{X: 0.5, Width: 0.6, Height: 0.1}}}},
+			{Name: "bad pattern", Anchors: template.Anchors, Fields: []types.TemplateField{{Name: "a", Page: 1, Region: lineRegion(0), Pattern: "("}}},
+		} {
+			if err := ext.RegisterTemplate(invalid); err == nil {
+				t.Errorf("Expected an error for template %q", invalid.Name)
+			}
+		}
+		if _, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", Templates: []types.LayoutTemplate{{Name: "empty"}}}); err == nil {
+			t.Error("Expected an error for an invalid template of the config")
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestTextLayout(t *testing.T) {
+	// Two cells on one baseline, the second set with a negative kerning adjustment instead of a space
+	content := "BT /F1 12 Tf 72 720 Td (Invoice number) Tj 200 0 Td [(INV-)-300(2024)] TJ ET " +
+		"BT /F1 12 Tf 72 700 Td (Total due) Tj ET"
+	parsedPdf, err := parser.ParsePdfFromBuffer(newTestPdfFromContent(content), &types.ParseOptions{TextLayout: true, TextThreshold: 1})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	if len(parsedPdf.Layout) != 1 {
+		t.Fatalf("Expected the layout of 1 page, got %d", len(parsedPdf.Layout))
+	}
+	layout := parsedPdf.Layout[0]
+	if layout.Page != 1 || layout.Width != 612 || layout.Height != 792 {
+		t.Errorf("Unexpected page geometry: page %d, %vx%v", layout.Page, layout.Width, layout.Height)
+	}
+
+	var texts []string
+	for _, line := range layout.Lines {
+		texts = append(texts, line.Text)
+	}
+	if len(texts) != 3 || texts[0] != "Invoice number" || texts[1] != "INV- 2024" || texts[2] != "Total due" {
+		t.Fatalf("Unexpected lines: %q", texts)
+	}
+
+	invoice := layout.Lines[0].Words[0].Bounds
+	// The text is set 720pt from the bottom of a 792pt page, 12pt high
+	if math.Abs(invoice.X-72) > 1 || math.Abs(invoice.Y-(792-720-0.8*12)) > 0.5 || math.Abs(invoice.Height-12) > 0.5 {
+		t.Errorf("Unexpected word bounds: %+v", invoice)
+	}
+	// Helvetica's "Invoice" is about 3.2 ems wide
+	if invoice.Width < 30 || invoice.Width > 45 {
+		t.Errorf("Unexpected word width: %v", invoice.Width)
+	}
+	if layout.Lines[1].Bounds.X < 270 {
+		t.Errorf("Expected the second cell at 272pt, got %+v", layout.Lines[1].Bounds)
+	}
+
+	t.Run("Find", func(t *testing.T) {
+		matches := layout.Find("invoice NUMBER:")
+		if len(matches) != 1 {
+			t.Fatalf("Expected 1 match, got %d", len(matches))
+		}
+		line := layout.Lines[0].Bounds
+		if matches[0] != line {
+			t.Errorf("Expected the match to cover the line %+v, got %+v", line, matches[0])
+		}
+		if matches := layout.Find("number Total"); len(matches) != 0 {
+			t.Errorf("Expected no match across cells, got %+v", matches)
+		}
+		if matches := layout.Find("2024 Total"); len(matches) != 2 {
+			t.Errorf("Expected a match spanning 2 lines, got %+v", matches)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+// drawingBackend renders every page as a 1000x600 drawing
+type drawingBackend struct{}
+
+func (drawingBackend) Name() string { return "drawing" }
+
+func (drawingBackend) Render(_ []byte, pages []int, _ float64, page func(int, *image.RGBA) error) error {
+	for _, p := range pages {
+		img := image.NewRGBA(image.Rect(0, 0, 1000, 600))
+		img.Set(999, 599, color.Black)
+		if err := page(p, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestTiles(t *testing.T) {
+	parser.RegisterBackend(drawingBackend{})
+	pdf := newTestPdf([]string{"Drawing"})
+
+	parsedPdf, err := parser.ParsePdfFromBuffer(pdf, &types.ParseOptions{ForceImages: true, Renderer: "drawing"})
+	if err != nil {
+		t.Fatalf("Expected parsed PDF, got error: %v", err)
+	}
+	tiles, err := parser.TilePageImage(parsedPdf.Content.ImageContent[0], 400)
+	if err != nil {
+		t.Fatalf("Expected tiles, got error: %v", err)
+	}
+	// 3 columns at x 0, 300 and 600, and 2 rows at y 0 and 200
+	if len(tiles) != 6 {
+		t.Fatalf("Expected 6 tiles, got %d", len(tiles))
+	}
+	last := tiles[5]
+	if last.Region != (types.Rect{X: 0.6, Y: 200.0 / 600, Width: 0.4, Height: 400.0 / 600}) {
+		t.Errorf("Expected the last tile at the bottom-right corner, got %+v", last.Region)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(last.Base64)
+	if img, err := png.Decode(bytes.NewReader(raw)); err != nil || img.Bounds().Dx() != 400 || img.Bounds().Dy() != 400 {
+		t.Errorf("Expected a 400x400 tile, got %v (%v)", img, err)
+	}
+
+	if small, _ := parser.TilePageImage(parsedPdf.Content.ImageContent[0], 1000); len(small) != 1 || small[0].Base64 != parsedPdf.Content.ImageContent[0].Base64 {
+		t.Errorf("Expected a page that fits to be a single tile, got %d tiles", len(small))
+	}
+
+	t.Run("Extraction", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"partNumber": "A-100"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, TileSize: 400})
+
+		_, err := ext.Extract(types.ExtractionOptions{
+			ParsedPdf: parsedPdf,
+			Schema:    map[string]interface{}{"type": "object", "properties": map[string]interface{}{"partNumber": map[string]interface{}{"type": "string"}}},
+		})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if !strings.Contains(mock.userPrompt(0), "overlapping tiles") {
+			t.Errorf("Expected the model to be told about tiles, got %q", mock.userPrompt(0))
+		}
+		messages := mock.Requests[0]["messages"].([]interface{})
+		content := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+		var labels []string
+		images := 0
+		for _, part := range content[1:] {
+			part := part.(map[string]interface{})
+			if part["type"] == "image_url" {
+				images++
+			} else {
+				labels = append(labels, part["text"].(string))
+			}
+		}
+		if images != 6 || len(labels) != 6 || labels[5] != "[Page 1, tile 6 of 6: x 60-100%, y 33-100%]" {
+			t.Errorf("Expected 6 labeled tiles, got %d images and labels %q", images, labels)
+		}
+	})
+}
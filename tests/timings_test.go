@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestTimings(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+		"currency": map[string]interface{}{"type": "string", "enum": []string{"EUR", "USD"}},
+	}}
+	mock := newMockServer(t, map[string]interface{}{"currency": "pesetas"}, map[string]interface{}{"currency": "EUR"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true})
+
+	result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{}), Schema: schema})
+	if err != nil {
+		t.Fatalf("Expected extraction to succeed, got %v", err)
+	}
+	timings := result.Timings
+	if timings.Render <= 0 || timings.Parse < timings.Render || timings.Request <= 0 || timings.Total < timings.Parse+timings.Request {
+		t.Errorf("Unexpected timings: %+v", timings)
+	}
+	if timings.Retries != 1 {
+		t.Errorf("Expected the constraint correction to count as a retry, got %d", timings.Retries)
+	}
+
+	parsedPdf, _ := ext.Parse(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{})})
+	result, err = ext.Extract(types.ExtractionOptions{ParsedPdf: parsedPdf, Schema: schema})
+	if err != nil {
+		t.Fatalf("Expected extraction to succeed, got %v", err)
+	}
+	if result.Timings.Render != 0 || result.Timings.Retries != 0 {
+		t.Errorf("Expected no render time for a parsed PDF, got %+v", result.Timings)
+	}
+}
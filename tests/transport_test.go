@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/ocr"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+const transportResponse = `{"model":"gpt-4o-mini","choices":[{"message":{"content":"{\"total\":\"24.20\"}"}}],"usage":{"total_tokens":42}}`
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+
+	// Issue a client certificate from a CA of our own
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "Test CA"}, IsCA: true, BasicConstraintsValid: true,
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), KeyUsage: x509.KeyUsageCertSign,
+	}
+	caDER, _ := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	caCert, _ := x509.ParseCertificate(caDER)
+	clientKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	clientDER, _ := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "extractor"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, &clientKey.PublicKey, caKey)
+	keyDER, _ := x509.MarshalECPrivateKey(clientKey)
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientDER}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCert)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, transportResponse)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)
+
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	pdf := newTestPdf([]string{"Receipt", "Total 24.20"})
+
+	ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: server.URL, TextThreshold: 10, CACertFile: caFile, ClientCertFile: certFile, ClientKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("Expected extractor, got error: %v", err)
+	}
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+		t.Errorf("Expected the extraction to pass mutual TLS, got %v", err)
+	}
+
+	withoutCert, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: server.URL, TextThreshold: 10, CACertFile: caFile})
+	if _, err := withoutCert.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err == nil {
+		t.Error("Expected the server to reject a client without certificate")
+	}
+
+	if _, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", ClientCertFile: certFile}); err == nil {
+		t.Error("Expected an error for a client certificate without key")
+	}
+}
+
+func TestProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the target
+		proxied = r.URL.String()
+		fmt.Fprint(w, transportResponse)
+	}))
+	defer proxy.Close()
+
+	ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: "http://llm-gateway.internal/v1", TextThreshold: 10, ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatalf("Expected extractor, got error: %v", err)
+	}
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: newTestPdf([]string{"Receipt", "Total 24.20"}), Schema: schema}); err != nil {
+		t.Fatalf("Expected extraction through the proxy, got %v", err)
+	}
+	if proxied != "http://llm-gateway.internal/v1/chat/completions" {
+		t.Errorf("Expected the request to go through the proxy, got %q", proxied)
+	}
+
+	if _, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", ProxyURL: "proxy:3128"}); err == nil {
+		t.Error("Expected an error for a proxy URL without scheme")
+	}
+}
+
+func TestAirGapped(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	pdf := newTestPdf([]string{"Receipt", "Total 24.20"})
+
+	t.Run("Local model", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"total": "24.20"})
+		ext, err := extractor.New(types.ExtractorConfig{BaseURL: mock.URL + "/v1", Provider: types.ProviderLlamaCpp, Model: "local", TextThreshold: 10, AirGapped: true})
+		if err != nil {
+			t.Fatalf("Expected extractor, got error: %v", err)
+		}
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+			t.Errorf("Expected extraction from the local model, got %v", err)
+		}
+	})
+
+	t.Run("Cloud URL", func(t *testing.T) {
+		_, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", AirGapped: true})
+		if !errors.Is(err, extractor.ErrEgressRefused) || !strings.Contains(err.Error(), "api.openai.com") {
+			t.Errorf("Expected the OpenAI API to be refused, got %v", err)
+		}
+		for _, config := range []types.ExtractorConfig{
+			{OpenAIAPIKey: "test", BaseURL: "https://llm.corp.example.com/v1", AirGapped: true},
+			{OpenAIAPIKey: "test", BaseURL: "http://8.8.8.8/v1", AirGapped: true},
+			{OpenAIAPIKey: "test", BaseURL: "http://localhost:8000/v1", ProxyURL: "http://proxy.example.com:3128", AirGapped: true},
+		} {
+			if _, err := extractor.New(config); !errors.Is(err, extractor.ErrEgressRefused) {
+				t.Errorf("Expected %s through %q to be refused, got %v", config.BaseURL, config.ProxyURL, err)
+			}
+		}
+		for _, config := range []types.ExtractorConfig{
+			{OpenAIAPIKey: "test", BaseURL: "https://llm.corp.example.com/v1", LocalHosts: []string{"LLM.corp.example.com"}, AirGapped: true},
+			{OpenAIAPIKey: "test", BaseURL: "http://model-runner.docker.internal/engines/v1", AirGapped: true},
+			{OpenAIAPIKey: "test", BaseURL: "http://vllm:8000/v1", AirGapped: true},
+			{OpenAIAPIKey: "test", BaseURL: "http://10.0.3.7:8000/v1", AirGapped: true},
+		} {
+			if _, err := extractor.New(config); err != nil {
+				t.Errorf("Expected %s to be local, got %v", config.BaseURL, err)
+			}
+		}
+	})
+
+	t.Run("OCR backend", func(t *testing.T) {
+		azure, err := ocr.NewAzure(ocr.AzureConfig{Endpoint: "https://contoso.cognitiveservices.azure.com", APIKey: "test"})
+		if err != nil {
+			t.Fatalf("Expected Azure provider, got error: %v", err)
+		}
+		parser.RegisterBackend(ocr.NewBackend("airgap-azure", azure, 0))
+		_, err = extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: "http://localhost:8000/v1", OCRBackend: "airgap-azure", AirGapped: true})
+		if !errors.Is(err, extractor.ErrEgressRefused) || !strings.Contains(err.Error(), "contoso.cognitiveservices.azure.com") {
+			t.Errorf("Expected the Azure OCR backend to be refused, got %v", err)
+		}
+
+		tesseract := ocr.NewBackend("airgap-tesseract", ocr.NewTesseract(ocr.TesseractConfig{}), 0)
+		parser.RegisterBackend(tesseract)
+		if _, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: "http://localhost:8000/v1", OCRBackend: "airgap-tesseract", AirGapped: true}); err != nil {
+			t.Errorf("Expected the local Tesseract backend to be allowed, got %v", err)
+		}
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://8.8.8.8:9/v1/chat/completions", http.StatusTemporaryRedirect)
+		}))
+		defer server.Close()
+
+		ext, err := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: server.URL + "/v1", TextThreshold: 10, AirGapped: true})
+		if err != nil {
+			t.Fatalf("Expected extractor, got error: %v", err)
+		}
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); !errors.Is(err, extractor.ErrEgressRefused) {
+			t.Errorf("Expected the redirect to a public address to be refused, got %v", err)
+		}
+	})
+}
+
+func TestAllowedHosts(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	pdf := newTestPdf([]string{"Receipt", "Total 24.20"})
+	mock := newMockServer(t, map[string]interface{}{"total": "24.20"})
+
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, AllowedHosts: []string{"127.0.0.1"}})
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+		t.Errorf("Expected extraction from an allowed host, got %v", err)
+	}
+
+	ext, _ = extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10, AllowedHosts: []string{"api.openai.com"}})
+	if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); !errors.Is(err, extractor.ErrHostNotAllowed) {
+		t.Errorf("Expected a host that is not allowed to be refused, got %v", err)
+	}
+	if len(mock.Requests) != 1 {
+		t.Errorf("Expected no request to the host that is not allowed, got %d requests", len(mock.Requests))
+	}
+
+	t.Run("Redirect", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, strings.Replace(mock.URL, "127.0.0.1", "localhost", 1)+"/chat/completions", http.StatusTemporaryRedirect)
+		}))
+		defer server.Close()
+
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: server.URL, TextThreshold: 10, AllowedHosts: []string{"127.0.0.1"}})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); !errors.Is(err, extractor.ErrHostNotAllowed) || !strings.Contains(err.Error(), "localhost") {
+			t.Errorf("Expected the redirect to another host to be refused, got %v", err)
+		}
+	})
+
+	t.Run("Subdomains", func(t *testing.T) {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, transportResponse)
+		}))
+		defer proxy.Close()
+
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: "http://eastus.LLM.example.com/v1", TextThreshold: 10, ProxyURL: proxy.URL, AllowedHosts: []string{"*.llm.example.com"}})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); err != nil {
+			t.Errorf("Expected extraction from a subdomain of an allowed domain, got %v", err)
+		}
+		ext, _ = extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: "http://llm.example.com.evil.test/v1", TextThreshold: 10, ProxyURL: proxy.URL, AllowedHosts: []string{"*.llm.example.com", "llm.example.com"}})
+		if _, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema}); !errors.Is(err, extractor.ErrHostNotAllowed) {
+			t.Errorf("Expected a lookalike host to be refused, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+)
+
+func TestCleanUnicode(t *testing.T) {
+	tests := map[string]string{
+		"O\uFB03ce manager":             "Office manager",
+		"\uFB01nance@exam\u200Bple.com": "finance@example.com",
+		"Ren\u00ADe\u00A0Dupont\uFEFF":  "Rene Dupont",
+		"Jose\u0301 Mu\u0303noz":        "Jos\u00E9 M\u0169noz",
+		"Total:\u2009\t42 EUR\n\x07ok":  "Total: \t42 EUR\nok",
+		"\u200F\u05DE\u05E1\u200E 12":   "\u05DE\u05E1 12",
+		// Zero-width non-joiners are part of Persian words
+		"\u0645\u06CC\u200C\u062E\u0648": "\u0645\u06CC\u200C\u062E\u0648",
+	}
+	for input, want := range tests {
+		if got := parser.CleanUnicode(input); got != want {
+			t.Errorf("CleanUnicode(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/parser"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestUnits(t *testing.T) {
+	t.Run("Detect", func(t *testing.T) {
+		for text, expected := range map[string]string{
+			"Total 1.234,56 € incl. VAT\nPaid in EUR\nShipping US$ 10": "EUR",
+			"Price A$ 20, A$ 30, $10":                                  "AUD",
+			"CHF 1'200.00":                                             "CHF",
+			"Invoice 42, 3 items":                                      "",
+		} {
+			if currency := parser.DetectCurrency(text); currency != expected {
+				t.Errorf("Expected %q for %q, got %q", expected, text, currency)
+			}
+		}
+		if units := parser.DetectUnits("Weight 12 kg, gross 13kg, 25 m², 10 pcs, 5 Stück, page 1 of 2"); !reflect.DeepEqual(units, []string{"kg", "pcs", "m²"}) {
+			t.Errorf("Expected units by use, got %v", units)
+		}
+		if code := parser.CurrencyCode("£"); code != "GBP" {
+			t.Errorf("Expected GBP, got %q", code)
+		}
+	})
+
+	t.Run("Extraction", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{
+			"currency": nil,
+			"lines":    []interface{}{map[string]interface{}{"amount": 10, "currency": "€"}, map[string]interface{}{"amount": 5, "currency": "EUR"}},
+		})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, err := ext.Extract(types.ExtractionOptions{
+			PDFBuffer:   newTestPdf([]string{"Delivery note DN-7", "Pallet 1: 120 kg, 10,00 EUR", "Pallet 2: 80 kg, 5,00 EUR"}),
+			Schema:      map[string]interface{}{"type": "object", "properties": map[string]interface{}{"currency": map[string]interface{}{"type": []interface{}{"string", "null"}}, "lines": map[string]interface{}{"type": "array"}}},
+			DetectUnits: true,
+		})
+		if err != nil {
+			t.Fatalf("Expected extraction, got error: %v", err)
+		}
+		if result.Currency != "EUR" || !reflect.DeepEqual(result.Units, []string{"kg"}) {
+			t.Errorf("Expected EUR and kg, got %q %v", result.Currency, result.Units)
+		}
+		lines, _ := result.Data["lines"].([]interface{})
+		if result.Data["currency"] != "EUR" || len(lines) != 2 || lines[0].(map[string]interface{})["currency"] != "EUR" {
+			t.Errorf("Expected normalized currency fields, got %v", result.Data)
+		}
+		expected := []string{"currency set to EUR, the currency of the document", `lines.0.currency = "€" normalized to EUR`}
+		if !reflect.DeepEqual(result.Warnings, expected) {
+			t.Errorf("Expected warnings %q, got %q", expected, result.Warnings)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestImageUploader(t *testing.T) {
+	var uploads []string
+	uploader := types.ImageUploaderFunc(func(_ context.Context, data []byte, mimeType string) (string, error) {
+		if mimeType != "image/png" || !strings.HasPrefix(string(data), "\x89PNG") {
+			return "", errors.New("unexpected image")
+		}
+		uploads = append(uploads, mimeType)
+		return fmt.Sprintf("https://images.example.com/%d.png", len(uploads)), nil
+	})
+	parsedPdf := &types.ParsedPdf{
+		Content:  types.ParsedPdfContent{Type: "images", ImageContent: []types.PdfPageImage{testPageImage(t, 1, true), testPageImage(t, 2, false)}},
+		NumPages: 2,
+	}
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+
+	mock := newMockServer(t, map[string]interface{}{"total": "10.00"})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, VisionEnabled: true, ImageUploader: uploader})
+	request, err := ext.BuildRequest(parsedPdf, types.ExtractionOptions{Schema: schema})
+	if err != nil {
+		t.Fatalf("Expected request, got error: %v", err)
+	}
+	// A retried call reuses the uploads
+	for range 2 {
+		if _, err := ext.CallModel(context.Background(), request); err != nil {
+			t.Fatalf("Expected model response, got error: %v", err)
+		}
+	}
+	if len(uploads) != 2 {
+		t.Errorf("Expected each page image to be uploaded once, got %d uploads", len(uploads))
+	}
+
+	for i := range mock.Requests {
+		messages := mock.Requests[i]["messages"].([]interface{})
+		content := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+		for j, part := range content[1:] {
+			url := part.(map[string]interface{})["image_url"].(map[string]interface{})["url"]
+			if expected := fmt.Sprintf("https://images.example.com/%d.png", j+1); url != expected {
+				t.Errorf("Expected request %d to reference %s, got %.40v", i, expected, url)
+			}
+		}
+	}
+	// The request built for the workflow keeps its images
+	if !strings.Contains(fmt.Sprint(request.Body["messages"]), "data:image/png;base64,") {
+		t.Error("Expected the model request to be left as is")
+	}
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestUsage(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"total": map[string]interface{}{"type": "string"}}}
+	pdf := newTestPdf([]string{"Receipt", "Total 24.20"})
+
+	tests := []struct {
+		name     string
+		usage    string
+		expected types.Usage
+	}{
+		{
+			"OpenAI",
+			`{"prompt_tokens":1200,"completion_tokens":300,"total_tokens":1500,
+				"prompt_tokens_details":{"cached_tokens":1024,"audio_tokens":0},
+				"completion_tokens_details":{"reasoning_tokens":256}}`,
+			types.Usage{PromptTokens: 1200, CompletionTokens: 300, TotalTokens: 1500, CachedTokens: 1024, ReasoningTokens: 256},
+		},
+		{
+			"DeepSeek",
+			`{"prompt_tokens":1200,"completion_tokens":300,"total_tokens":1500,"prompt_cache_hit_tokens":640,"prompt_cache_miss_tokens":560}`,
+			types.Usage{PromptTokens: 1200, CompletionTokens: 300, TotalTokens: 1500, CachedTokens: 640},
+		},
+		{
+			"Without total",
+			`{"input_tokens":100,"output_tokens":20,"cache_read_input_tokens":80}`,
+			types.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120, CachedTokens: 80},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"model":"o4-mini","choices":[{"message":{"content":"{\"total\":\"24.20\"}"}}],"usage":%s}`, test.usage)
+			}))
+			defer server.Close()
+			ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: server.URL, TextThreshold: 10})
+
+			result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+			if err != nil {
+				t.Fatalf("Expected extraction to succeed, got %v", err)
+			}
+			usage := result.Usage
+			usage.Details = nil
+			if !reflect.DeepEqual(usage, test.expected) {
+				t.Errorf("Expected usage %+v, got %+v", test.expected, usage)
+			}
+			if result.TokensUsed != test.expected.TotalTokens {
+				t.Errorf("Expected %d tokens used, got %d", test.expected.TotalTokens, result.TokensUsed)
+			}
+		})
+	}
+
+	t.Run("Details", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"total": "24.20"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		result, _ := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: schema})
+		if result.Usage.Details["total_tokens"] != 42 {
+			t.Errorf("Expected the raw usage fields in the details, got %v", result.Usage.Details)
+		}
+	})
+}
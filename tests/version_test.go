@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestResultVersioning(t *testing.T) {
+	schema := map[string]interface{}{
+		"title": "invoice",
+		"type":  "object",
+		"properties": map[string]interface{}{
+			"invoiceNumber": map[string]interface{}{"type": "string"},
+			"total":         map[string]interface{}{"type": "number"},
+		},
+	}
+	encoded, _ := json.Marshal(schema)
+	sum := sha256.Sum256(encoded)
+	schemaHash := hex.EncodeToString(sum[:])
+	pdf := newTestPdf([]string{"Invoice INV-001", "Total: 100.00"})
+
+	mock := newMockServer(t, map[string]interface{}{"invoiceNumber": "INV-001", "total": 100.0})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+	options := types.ExtractionOptions{PDFBuffer: pdf, Schema: schema, SchemaVersion: "3"}
+	result, err := ext.Extract(options)
+	if err != nil {
+		t.Fatalf("Expected extraction, got error: %v", err)
+	}
+	if result.SchemaHash != schemaHash || result.SchemaName != "invoice" || result.SchemaVersion != "3" {
+		t.Errorf("Expected the schema stamped on the result, got %q, %q, %q", result.SchemaHash, result.SchemaName, result.SchemaVersion)
+	}
+	if result.LibraryVersion != extractor.LibraryVersion() {
+		t.Errorf("Expected library version %q, got %q", extractor.LibraryVersion(), result.LibraryVersion)
+	}
+
+	record := types.NewJSONLRecord(types.BatchResult{ID: "inv-1", Status: types.BatchStatusSuccess, Result: result})
+	if record.Schema != "invoice" || record.SchemaHash != schemaHash || record.SchemaRevision != "3" || record.SchemaVersion != types.JSONLSchemaVersion {
+		t.Errorf("Expected the schema in the JSONL record, got %+v", record)
+	}
+
+	reExtracted, err := ext.ReExtractFields(context.Background(), result, []string{"total"}, options)
+	if err != nil {
+		t.Fatalf("Expected re-extraction, got error: %v", err)
+	}
+	if reExtracted.SchemaHash != schemaHash || reExtracted.SchemaName != "invoice" || reExtracted.SchemaVersion != "3" {
+		t.Errorf("Expected the full schema stamped on the re-extraction, got %q, %q, %q", reExtracted.SchemaHash, reExtracted.SchemaName, reExtracted.SchemaVersion)
+	}
+
+	changed := map[string]interface{}{"title": "invoice", "type": "object", "properties": map[string]interface{}{"invoiceNumber": map[string]interface{}{"type": "string"}}}
+	if result, err := ext.Extract(types.ExtractionOptions{PDFBuffer: pdf, Schema: changed}); err != nil || result.SchemaHash == schemaHash {
+		t.Errorf("Expected another hash for another schema, got %v", err)
+	}
+}
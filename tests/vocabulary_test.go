@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/extractor"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestVocabularies(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"vendor": map[string]interface{}{"type": "string"},
+			"lines":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+		},
+	}
+	mock := newMockServer(t, map[string]interface{}{
+		"vendor": "ACME Corp.",
+		"lines": []interface{}{
+			map[string]interface{}{"account": "6100 Office supplies"},
+			map[string]interface{}{"account": "6250"},
+			map[string]interface{}{"account": "Catering"},
+		},
+	})
+	ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+
+	result, err := ext.Extract(types.ExtractionOptions{
+		PDFBuffer: newTestPdf([]string{"ACME Corp.", "Office supplies 120.00"}),
+		Schema:    schema,
+		Vocabularies: map[string][]string{
+			"vendor":          {"Acme Corporation", "Globex Corporation", "Initech"},
+			"lines.*.account": {"6100 - Office supplies", "6200 - Travel", "6250 - Software"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected extraction, got error: %v", err)
+	}
+
+	lines := result.Data["lines"].([]interface{})
+	if result.Data["vendor"] != "Acme Corporation" || lines[0].(map[string]interface{})["account"] != "6100 - Office supplies" {
+		t.Errorf("Expected values matched to their vocabulary, got %+v", result.Data)
+	}
+	if account := lines[2].(map[string]interface{})["account"]; account != "Catering" {
+		t.Errorf("Expected a value without a close match to be kept, got %v", account)
+	}
+
+	vendor := result.VocabularyMatches["vendor"]
+	if !vendor.Matched || vendor.Extracted != "ACME Corp." || vendor.Confidence != 0.95 {
+		t.Errorf("Expected the vendor matched with a confidence of 0.95, got %+v", vendor)
+	}
+	if match := result.VocabularyMatches["lines.1.account"]; match.Matched || match.Value != "6250 - Software" || match.Confidence >= 0.8 {
+		t.Errorf("Expected a closest value below the threshold, got %+v", match)
+	}
+	if len(result.VocabularyMatches) != 4 {
+		t.Errorf("Expected a match for every value with a vocabulary, got %+v", result.VocabularyMatches)
+	}
+	warnings := strings.Join(result.Warnings, "\n")
+	for _, warning := range []string{
+		`vendor = "ACME Corp." matched to "Acme Corporation" with a confidence of 0.95`,
+		`lines.1.account = "6250" not in the vocabulary, the closest value "6250 - Software"`,
+	} {
+		if !strings.Contains(warnings, warning) {
+			t.Errorf("Expected warning %q, got %q", warning, result.Warnings)
+		}
+	}
+
+	t.Run("Threshold", func(t *testing.T) {
+		mock := newMockServer(t, map[string]interface{}{"vendor": "Acme Corp"})
+		ext, _ := extractor.New(types.ExtractorConfig{OpenAIAPIKey: "test", BaseURL: mock.URL, TextThreshold: 10})
+		options := types.ExtractionOptions{
+			PDFBuffer:           newTestPdf([]string{"Invoice from Acme Corp"}),
+			Schema:              schema,
+			Vocabularies:        map[string][]string{"vendor": {"Acme Corporation"}},
+			VocabularyThreshold: 0.99,
+		}
+		result, err := ext.Extract(options)
+		if err != nil || result.Data["vendor"] != "Acme Corp" || result.VocabularyMatches["vendor"].Matched {
+			t.Errorf("Expected the value kept below the threshold, got %+v, %v", result, err)
+		}
+
+		options.VocabularyThreshold = 2
+		if _, err := ext.Extract(options); err == nil {
+			t.Error("Expected an error for a threshold above 1")
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/ilopezluna/go-pdf-extractor/pkg/schema"
+	"github.com/ilopezluna/go-pdf-extractor/pkg/types"
+)
+
+func TestToXML(t *testing.T) {
+	invoiceSchema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"invoiceNumber": map[string]interface{}{"type": "string"},
+			"status":        map[string]interface{}{"type": "string", "enum": []interface{}{"paid", "open"}},
+			"dueDate":       map[string]interface{}{"type": []interface{}{"string", "null"}},
+			"lineItems": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"description": map[string]interface{}{"type": "string"},
+						"amount":      map[string]interface{}{"type": "number"},
+					},
+					"required":             []interface{}{"description", "amount"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []interface{}{"invoiceNumber", "status", "dueDate", "lineItems"},
+		"additionalProperties": false,
+	}
+	result := &types.ExtractionResult{Data: map[string]interface{}{
+		"invoiceNumber": "INV <1>",
+		"status":        "open",
+		"dueDate":       nil,
+		"lineItems": []interface{}{
+			map[string]interface{}{"amount": 10.5, "description": "Widget"},
+		},
+	}}
+
+	t.Run("Follows the schema structure", func(t *testing.T) {
+		out, err := result.ToXML(invoiceSchema, schema.XMLOptions{RootElement: "invoice"})
+		if err != nil {
+			t.Fatalf("Expected XML, got error: %v", err)
+		}
+		document := string(out)
+		for _, want := range []string{
+			"<invoiceNumber>INV &lt;1&gt;</invoiceNumber>",
+			`<dueDate xsi:nil="true"></dueDate>`,
+			"<lineItems>\n    <item>\n      <description>Widget</description>\n      <amount>10.5</amount>",
+		} {
+			if !strings.Contains(document, want) {
+				t.Errorf("Expected XML to contain %q, got:\n%s", want, document)
+			}
+		}
+		if strings.Index(document, "<invoiceNumber>") > strings.Index(document, "<status>") {
+			t.Error("Expected elements in the order of the schema's required list")
+		}
+		if err := xml.Unmarshal(out, new(interface{})); err != nil {
+			t.Errorf("Expected well-formed XML, got error: %v", err)
+		}
+	})
+
+	t.Run("Generates a matching XSD", func(t *testing.T) {
+		out, err := schema.GenerateXSD(invoiceSchema, schema.XMLOptions{RootElement: "invoice"})
+		if err != nil {
+			t.Fatalf("Expected XSD, got error: %v", err)
+		}
+		document := string(out)
+		for _, want := range []string{
+			`<xs:element name="invoice">`,
+			`<xs:element name="dueDate" nillable="true" type="xs:string"></xs:element>`,
+			`<xs:enumeration value="paid"></xs:enumeration>`,
+			`<xs:element name="item" minOccurs="0" maxOccurs="unbounded">`,
+			`<xs:element name="amount" type="xs:decimal"></xs:element>`,
+		} {
+			if !strings.Contains(document, want) {
+				t.Errorf("Expected XSD to contain %q, got:\n%s", want, document)
+			}
+		}
+	})
+}